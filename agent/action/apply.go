@@ -9,6 +9,8 @@ import (
 
 	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/linkverifier"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -27,6 +29,31 @@ type ApplyAction struct {
 	settingsService boshsettings.Service
 	instanceDir     string
 	fs              boshsys.FileSystem
+	linkVerifier    linkverifier.Verifier
+	hookRunner      boshappl.HookRunner
+
+	cancelSignals boshtask.CancelSignals
+}
+
+// ApplyValue is returned instead of "applied" when the spec asked for link
+// addresses to be verified, so that unreachable links surface in the apply
+// result without failing the apply itself, or when hook scripts ran.
+type ApplyValue struct {
+	Result       string                `json:"result"`
+	LinkFailures []linkverifier.Result `json:"link_failures"`
+	Hooks        []boshappl.HookResult `json:"hooks,omitempty"`
+}
+
+// ApplyOptions are passed after the desired spec.
+type ApplyOptions struct {
+	// DryRun only computes what applying the desired spec would change
+	DryRun bool `json:"dry_run"`
+}
+
+// ApplyDryRunValue is returned for a dry run instead of applying the spec.
+type ApplyDryRunValue struct {
+	Result  string               `json:"result"`
+	Changes boshappl.SpecChanges `json:"changes"`
 }
 
 func NewApply(
@@ -35,12 +62,18 @@ func NewApply(
 	settingsService boshsettings.Service,
 	dirProvider directories.Provider,
 	fs boshsys.FileSystem,
+	linkVerifier linkverifier.Verifier,
+	hookRunner boshappl.HookRunner,
+	cancelSignals boshtask.CancelSignals,
 ) (action ApplyAction) {
 	action.applier = applier
 	action.specService = specService
 	action.settingsService = settingsService
 	action.instanceDir = dirProvider.InstanceDir()
 	action.fs = fs
+	action.linkVerifier = linkVerifier
+	action.hookRunner = hookRunner
+	action.cancelSignals = cancelSignals
 	return
 }
 
@@ -56,22 +89,63 @@ func (a ApplyAction) IsLoggable() bool {
 	return true
 }
 
-func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
-	settings := a.settingsService.GetSettings()
+func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec, options ...ApplyOptions) (interface{}, error) {
+	err := desiredSpec.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	resolvedDesiredSpec, err := a.resolve(desiredSpec)
+	if err != nil {
+		return "", err
+	}
+
+	if len(options) > 0 && options[0].DryRun {
+		return a.dryRun(resolvedDesiredSpec)
+	}
+
+	return a.apply(resolvedDesiredSpec, a.specService.Set, a.cancelSignals.RunningCancelSignal())
+}
 
-	resolvedDesiredSpec, err := a.specService.PopulateDHCPNetworks(desiredSpec, settings)
+func (a ApplyAction) resolve(desiredSpec boshas.V1ApplySpec) (boshas.V1ApplySpec, error) {
+	resolvedDesiredSpec, err := a.specService.PopulateDHCPNetworks(desiredSpec, a.settingsService.GetSettings())
 	if err != nil {
-		return "", bosherr.WrapError(err, "Resolving dynamic networks")
+		return boshas.V1ApplySpec{}, bosherr.WrapError(err, "Resolving dynamic networks")
 	}
 
-	if desiredSpec.ConfigurationHash != "" {
-		err = a.applier.Apply(resolvedDesiredSpec)
+	return resolvedDesiredSpec, nil
+}
+
+// apply persists the resolved desired spec with persist once its jobs and
+// packages are applied. Cancelling rolls back like a failed apply.
+func (a ApplyAction) apply(resolvedDesiredSpec boshas.V1ApplySpec, persist func(boshas.V1ApplySpec) error, cancel *boshtask.CancelSignal) (interface{}, error) {
+	var hookResults []boshappl.HookResult
+
+	if resolvedDesiredSpec.ConfigurationHash != "" {
+		currentSpec, err := a.specService.Get()
+		if err != nil {
+			return "", bosherr.WrapError(err, "Getting current spec")
+		}
+
+		results, err := a.hookRunner.RunHooks(boshappl.PreApplyHook, currentSpec.Jobs())
 		if err != nil {
-			return "", bosherr.WrapError(err, "Applying")
+			return "", bosherr.WrapError(err, "Running pre-apply hooks")
 		}
+		hookResults = append(hookResults, results...)
+
+		err = a.applier.Apply(resolvedDesiredSpec, cancel)
+		if err != nil {
+			return "", a.rollBack(err)
+		}
+
+		results, err = a.hookRunner.RunHooks(boshappl.PostApplyHook, resolvedDesiredSpec.Jobs())
+		if err != nil {
+			return "", a.rollBack(bosherr.WrapError(err, "Running post-apply hooks"))
+		}
+		hookResults = append(hookResults, results...)
 	}
 
-	err = a.specService.Set(resolvedDesiredSpec)
+	err := persist(resolvedDesiredSpec)
 	if err != nil {
 		return "", bosherr.WrapError(err, "Persisting apply spec")
 	}
@@ -81,9 +155,52 @@ func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
 		return "", err
 	}
 
+	if len(resolvedDesiredSpec.LinkAddressSpecs) > 0 || len(hookResults) > 0 {
+		value := ApplyValue{Result: "applied", Hooks: hookResults}
+		if len(resolvedDesiredSpec.LinkAddressSpecs) > 0 {
+			value.LinkFailures = a.linkVerifier.Verify(resolvedDesiredSpec.LinkAddressSpecs)
+		}
+		return value, nil
+	}
+
 	return "applied", nil
 }
 
+// dryRun neither touches the file system nor the job supervisor
+func (a ApplyAction) dryRun(desiredSpec boshas.V1ApplySpec) (interface{}, error) {
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting current spec")
+	}
+
+	var appliedSpec boshas.ApplySpec
+	if currentSpec.ConfigurationHash != "" {
+		appliedSpec = currentSpec
+	}
+
+	return ApplyDryRunValue{
+		Result:  "dry_run",
+		Changes: boshappl.DiffSpecs(appliedSpec, desiredSpec),
+	}, nil
+}
+
+// rollBack applies the previously applied spec again so that a failed apply
+// does not leave the VM half applied. The previously applied spec stays the
+// current spec since the desired spec is only persisted after applying it.
+func (a ApplyAction) rollBack(applyErr error) error {
+	currentSpec, err := a.specService.Get()
+	if err != nil || currentSpec.ConfigurationHash == "" {
+		return bosherr.WrapError(applyErr, "Applying")
+	}
+
+	err = a.applier.Apply(currentSpec, nil)
+	if err != nil {
+		return bosherr.WrapErrorf(applyErr, "Applying (rolling back to the previously applied spec also failed: %s)", err.Error())
+	}
+
+	return bosherr.WrapError(applyErr, "Applying (rolled back to the previously applied spec)")
+}
+
 func (a ApplyAction) writeInstanceData(spec boshas.V1ApplySpec) error {
 	err := a.writeInstanceField("id", spec.NodeID)
 	if err != nil {
@@ -129,6 +246,8 @@ func (a ApplyAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
 
+// Cancel stops applying between jobs and packages and rolls back to the
+// previously applied spec
 func (a ApplyAction) Cancel() error {
-	return errors.New("not supported")
+	return nil
 }
@@ -7,13 +7,20 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
 	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/linkverifier"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/linkverifier/linkverifierfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
@@ -27,6 +34,9 @@ var _ = Describe("ApplyAction", func() {
 		dirProvider     boshdir.Provider
 		applyAction     action.ApplyAction
 		fs              boshsys.FileSystem
+		linkVerifier    *linkverifierfakes.FakeVerifier
+		hookRunner      *fakeappl.FakeHookRunner
+		taskService     *faketask.FakeService
 	)
 
 	BeforeEach(func() {
@@ -35,13 +45,16 @@ var _ = Describe("ApplyAction", func() {
 		settingsService = &fakesettings.FakeSettingsService{}
 		dirProvider = boshdir.NewProvider("/var/vcap")
 		fs = fakesys.NewFakeFileSystem()
-		applyAction = action.NewApply(applier, specService, settingsService, dirProvider, fs)
+		linkVerifier = &linkverifierfakes.FakeVerifier{}
+		hookRunner = &fakeappl.FakeHookRunner{}
+		taskService = faketask.NewFakeService()
+		applyAction = action.NewApply(applier, specService, settingsService, dirProvider, fs, linkVerifier, hookRunner, taskService)
 	})
 
 	AssertActionIsAsynchronous(applyAction)
 	AssertActionIsNotPersistent(applyAction)
 	AssertActionIsLoggable(applyAction)
-	AssertActionIsNotCancelable(applyAction)
+	AssertActionIsCancelable(applyAction)
 	AssertActionIsNotResumable(applyAction)
 
 	Describe("Run", func() {
@@ -51,6 +64,19 @@ var _ = Describe("ApplyAction", func() {
 			settingsService.Settings = settings
 		})
 
+		It("rejects malformed specs without applying or persisting them", func() {
+			desiredApplySpec := boshas.V1ApplySpec{
+				ConfigurationHash: "fake-desired-config-hash",
+				PackageSpecs:      map[string]boshas.PackageSpec{"fake-pkg": {Name: "fake-pkg"}},
+			}
+
+			_, err := applyAction.Run(desiredApplySpec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("packages.fake-pkg.version: missing"))
+			Expect(applier.Applied).To(BeFalse())
+			Expect(specService.ActionsCalled).ToNot(ContainElement("Set"))
+		})
+
 		Context("when desired spec has configuration hash", func() {
 			currentApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
 			desiredApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"}
@@ -152,6 +178,113 @@ var _ = Describe("ApplyAction", func() {
 							Expect(err).To(HaveOccurred())
 							Expect(specService.Spec).To(Equal(currentApplySpec))
 						})
+
+						It("rolls back to the current spec and reports it", func() {
+							applier.ApplyStub = func(boshas.ApplySpec) error {
+								if len(applier.ApplyDesiredApplySpecs) == 1 {
+									return errors.New("fake-apply-error")
+								}
+								return nil
+							}
+
+							_, err := applyAction.Run(desiredApplySpec)
+							Expect(err).To(MatchError("Applying (rolled back to the previously applied spec): fake-apply-error"))
+							Expect(applier.ApplyDesiredApplySpecs).To(Equal([]boshas.ApplySpec{populatedDesiredApplySpec, currentApplySpec}))
+						})
+
+						It("rolls back to the current spec when cancelled while applying", func() {
+							cancelCh := make(chan struct{})
+							taskService.RunningCancelSignalResult = boshtask.NewCancelSignal(cancelCh)
+
+							applier.ApplyStub = func(boshas.ApplySpec) error {
+								if len(applier.ApplyDesiredApplySpecs) == 1 {
+									close(cancelCh)
+								}
+								return applier.ApplyCancelSignal.Err()
+							}
+
+							_, err := applyAction.Run(desiredApplySpec)
+							Expect(err).To(MatchError("Applying (rolled back to the previously applied spec): task was cancelled"))
+							Expect(applier.ApplyDesiredApplySpecs).To(Equal([]boshas.ApplySpec{populatedDesiredApplySpec, currentApplySpec}))
+							Expect(specService.Spec).To(Equal(currentApplySpec))
+						})
+
+						It("reports when rolling back fails too", func() {
+							_, err := applyAction.Run(desiredApplySpec)
+							Expect(err).To(MatchError("Applying (rolling back to the previously applied spec also failed: fake-apply-error): fake-apply-error"))
+						})
+
+						It("does not roll back when nothing was applied before", func() {
+							specService.Spec = boshas.V1ApplySpec{}
+
+							_, err := applyAction.Run(desiredApplySpec)
+							Expect(err).To(MatchError("Applying: fake-apply-error"))
+							Expect(applier.ApplyDesiredApplySpecs).To(HaveLen(1))
+						})
+					})
+				})
+
+				Context("when hook scripts are provided", func() {
+					currentApplySpec := boshas.V1ApplySpec{
+						ConfigurationHash: "fake-current-config-hash",
+						JobSpec: boshas.JobSpec{
+							JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-current-job", Version: "v1"}},
+						},
+						RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{BlobstoreID: "fake-current-blob-id"},
+					}
+					desiredApplySpec := boshas.V1ApplySpec{
+						ConfigurationHash: "fake-desired-config-hash",
+						JobSpec: boshas.JobSpec{
+							JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-desired-job", Version: "v2"}},
+						},
+						RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{BlobstoreID: "fake-desired-blob-id"},
+					}
+
+					BeforeEach(func() {
+						specService.Spec = currentApplySpec
+						specService.PopulateDHCPNetworksResultSpec = desiredApplySpec
+					})
+
+					It("runs pre-apply hooks of the current jobs before and post-apply hooks of the desired jobs after applying", func() {
+						hookRunner.RunHooksResults = map[string][]boshappl.HookResult{
+							boshappl.PreApplyHook:  {{Hook: boshappl.PreApplyHook, Script: "fake-pre-apply-script", Stdout: "fake-stdout"}},
+							boshappl.PostApplyHook: {{Hook: boshappl.PostApplyHook, Script: "fake-post-apply-script"}},
+						}
+
+						value, err := applyAction.Run(desiredApplySpec)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(hookRunner.RunHooksHooks).To(Equal([]string{boshappl.PreApplyHook, boshappl.PostApplyHook}))
+						Expect(hookRunner.RunHooksJobs).To(Equal([][]models.Job{currentApplySpec.Jobs(), desiredApplySpec.Jobs()}))
+						Expect(applier.ApplyDesiredApplySpecs).To(Equal([]boshas.ApplySpec{desiredApplySpec}))
+
+						Expect(value).To(Equal(action.ApplyValue{
+							Result: "applied",
+							Hooks: []boshappl.HookResult{
+								{Hook: boshappl.PreApplyHook, Script: "fake-pre-apply-script", Stdout: "fake-stdout"},
+								{Hook: boshappl.PostApplyHook, Script: "fake-post-apply-script"},
+							},
+						}))
+					})
+
+					It("does not apply the desired spec when a pre-apply hook fails", func() {
+						hookRunner.RunHooksErrors = map[string]error{boshappl.PreApplyHook: errors.New("fake-hook-error")}
+
+						_, err := applyAction.Run(desiredApplySpec)
+						Expect(err).To(MatchError("Running pre-apply hooks: fake-hook-error"))
+
+						Expect(applier.ApplyDesiredApplySpecs).To(BeEmpty())
+						Expect(specService.Spec).To(Equal(currentApplySpec))
+					})
+
+					It("rolls back to the current spec when a post-apply hook fails", func() {
+						hookRunner.RunHooksErrors = map[string]error{boshappl.PostApplyHook: errors.New("fake-hook-error")}
+
+						_, err := applyAction.Run(desiredApplySpec)
+						Expect(err).To(MatchError("Applying (rolled back to the previously applied spec): Running post-apply hooks: fake-hook-error"))
+
+						Expect(applier.ApplyDesiredApplySpecs).To(Equal([]boshas.ApplySpec{desiredApplySpec, currentApplySpec}))
+						Expect(specService.Spec).To(Equal(currentApplySpec))
 					})
 				})
 
@@ -265,5 +398,98 @@ var _ = Describe("ApplyAction", func() {
 				})
 			})
 		})
+
+		Context("when desired spec has link addresses to verify", func() {
+			links := []boshas.LinkAddressSpec{
+				{Name: "db", Address: "db.example.internal", Port: 5432},
+				{Name: "cache", Address: "cache.example.internal"},
+			}
+			desiredApplySpec := boshas.V1ApplySpec{LinkAddressSpecs: links}
+
+			BeforeEach(func() {
+				specService.PopulateDHCPNetworksResultSpec = desiredApplySpec
+			})
+
+			It("verifies the link addresses after applying", func() {
+				_, err := applyAction.Run(desiredApplySpec)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(linkVerifier.VerifyCallCount()).To(Equal(1))
+				Expect(linkVerifier.VerifyArgsForCall(0)).To(Equal(links))
+			})
+
+			It("includes unreachable links in the apply result without failing", func() {
+				failures := []linkverifier.Result{{Name: "db", Address: "db.example.internal", Port: 5432, Error: "connection refused"}}
+				linkVerifier.VerifyReturns(failures)
+
+				value, err := applyAction.Run(desiredApplySpec)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal(action.ApplyValue{Result: "applied", LinkFailures: failures}))
+			})
+		})
+
+		Context("when running dry", func() {
+			sha1 := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"))
+
+			desiredApplySpec := boshas.V1ApplySpec{
+				ConfigurationHash: "fake-desired-config-hash",
+				PackageSpecs: map[string]boshas.PackageSpec{
+					"added-pkg":    {Name: "added-pkg", Version: "1", Sha1: sha1, BlobstoreID: "fake-blob-id"},
+					"upgraded-pkg": {Name: "upgraded-pkg", Version: "2", Sha1: sha1, BlobstoreID: "fake-blob-id"},
+				},
+			}
+
+			BeforeEach(func() {
+				specService.PopulateDHCPNetworksResultSpec = desiredApplySpec
+				specService.Spec = boshas.V1ApplySpec{
+					ConfigurationHash: "fake-current-config-hash",
+					PackageSpecs: map[string]boshas.PackageSpec{
+						"upgraded-pkg": {Name: "upgraded-pkg", Version: "1", Sha1: sha1, BlobstoreID: "fake-blob-id"},
+						"removed-pkg":  {Name: "removed-pkg", Version: "1", Sha1: sha1, BlobstoreID: "fake-blob-id"},
+					},
+				}
+			})
+
+			It("returns the changes without applying or persisting the desired spec", func() {
+				value, err := applyAction.Run(desiredApplySpec, action.ApplyOptions{DryRun: true})
+				Expect(err).NotTo(HaveOccurred())
+
+				dryRunValue, ok := value.(action.ApplyDryRunValue)
+				Expect(ok).To(BeTrue())
+				Expect(dryRunValue.Result).To(Equal("dry_run"))
+				Expect(dryRunValue.Changes.Packages).To(Equal(boshappl.BundleChanges{
+					Added:     []string{"added-pkg"},
+					Upgraded:  []string{"upgraded-pkg"},
+					Removed:   []string{"removed-pkg"},
+					Unchanged: []string{},
+				}))
+
+				Expect(applier.Applied).To(BeFalse())
+				Expect(specService.ActionsCalled).NotTo(ContainElement("Set"))
+				Expect(fs.FileExists(path.Join(dirProvider.InstanceDir(), "id"))).To(BeFalse())
+			})
+
+			It("adds everything when no spec was applied yet", func() {
+				specService.Spec = boshas.V1ApplySpec{}
+
+				value, err := applyAction.Run(desiredApplySpec, action.ApplyOptions{DryRun: true})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value.(action.ApplyDryRunValue).Changes.Packages.Added).To(Equal([]string{"added-pkg", "upgraded-pkg"}))
+			})
+
+			It("returns error when getting the current spec fails", func() {
+				specService.GetErr = errors.New("fake-get-error")
+
+				_, err := applyAction.Run(desiredApplySpec, action.ApplyOptions{DryRun: true})
+				Expect(err).To(MatchError("Getting current spec: fake-get-error"))
+			})
+		})
+
+		It("does not verify links when the spec has none", func() {
+			value, err := applyAction.Run(boshas.V1ApplySpec{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("applied"))
+			Expect(linkVerifier.VerifyCallCount()).To(Equal(0))
+		})
 	})
 })
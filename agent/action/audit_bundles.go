@@ -0,0 +1,73 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+)
+
+// AuditBundlesResponse is OK when no bundle failed, unverified bundles do
+// not count as failures.
+type AuditBundlesResponse struct {
+	OK      bool                   `json:"ok"`
+	Bundles []boshappl.BundleAudit `json:"bundles"`
+}
+
+// AuditBundlesAction reports for every enabled job and package bundle
+// whether its files still match the digests recorded at install, so fleets
+// can be scanned for tampering or bit-rot.
+type AuditBundlesAction struct {
+	bundleVerifier boshappl.BundleVerifier
+	specService    boshas.V1Service
+}
+
+func NewAuditBundles(bundleVerifier boshappl.BundleVerifier, specService boshas.V1Service) AuditBundlesAction {
+	return AuditBundlesAction{
+		bundleVerifier: bundleVerifier,
+		specService:    specService,
+	}
+}
+
+func (a AuditBundlesAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a AuditBundlesAction) IsPersistent() bool {
+	return false
+}
+
+func (a AuditBundlesAction) IsLoggable() bool {
+	return true
+}
+
+func (a AuditBundlesAction) Run() (AuditBundlesResponse, error) {
+	appliedSpec, err := a.specService.Get()
+	if err != nil {
+		return AuditBundlesResponse{}, bosherr.WrapError(err, "Getting applied spec")
+	}
+
+	audits := a.bundleVerifier.Audit(appliedSpec)
+	if audits == nil {
+		audits = []boshappl.BundleAudit{}
+	}
+
+	ok := true
+	for _, audit := range audits {
+		if audit.Status == boshappl.BundleAuditFailed {
+			ok = false
+		}
+	}
+
+	return AuditBundlesResponse{OK: ok, Bundles: audits}, nil
+}
+
+func (a AuditBundlesAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a AuditBundlesAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,80 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+)
+
+var _ = Describe("AuditBundles", func() {
+	var (
+		bundleVerifier *fakeappl.FakeBundleVerifier
+		specService    *fakeas.FakeV1Service
+		auditAction    action.AuditBundlesAction
+	)
+
+	BeforeEach(func() {
+		bundleVerifier = &fakeappl.FakeBundleVerifier{}
+		specService = fakeas.NewFakeV1Service()
+		specService.Spec = boshas.V1ApplySpec{ConfigurationHash: "fake-configuration-hash"}
+		auditAction = action.NewAuditBundles(bundleVerifier, specService)
+	})
+
+	AssertActionIsAsynchronous(auditAction)
+	AssertActionIsNotPersistent(auditAction)
+	AssertActionIsLoggable(auditAction)
+
+	AssertActionIsNotResumable(auditAction)
+	AssertActionIsNotCancelable(auditAction)
+
+	It("audits the bundles of the applied spec", func() {
+		response, err := auditAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response).To(Equal(action.AuditBundlesResponse{
+			OK:      true,
+			Bundles: []boshappl.BundleAudit{},
+		}))
+
+		Expect(bundleVerifier.AuditAppliedSpec).To(Equal(specService.Spec))
+	})
+
+	It("is ok when bundles passed or are unverified", func() {
+		bundleVerifier.AuditResult = []boshappl.BundleAudit{
+			{Bundle: "job fake-job", Status: boshappl.BundleAuditPassed, Problems: []string{}},
+			{Bundle: "package fake-package", Status: boshappl.BundleAuditUnverified, Problems: []string{}},
+		}
+
+		response, err := auditAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response).To(Equal(action.AuditBundlesResponse{
+			OK:      true,
+			Bundles: bundleVerifier.AuditResult,
+		}))
+	})
+
+	It("is not ok when a bundle failed", func() {
+		bundleVerifier.AuditResult = []boshappl.BundleAudit{
+			{Bundle: "job fake-job", Status: boshappl.BundleAuditPassed, Problems: []string{}},
+			{Bundle: "package fake-package", Status: boshappl.BundleAuditFailed, Problems: []string{"modified: lib/lib.so"}},
+		}
+
+		response, err := auditAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.OK).To(BeFalse())
+		Expect(response.Bundles).To(Equal(bundleVerifier.AuditResult))
+	})
+
+	It("returns error when getting the applied spec fails", func() {
+		specService.GetErr = errors.New("fake-get-error")
+
+		_, err := auditAction.Run()
+		Expect(err).To(MatchError("Getting applied spec: fake-get-error"))
+	})
+})
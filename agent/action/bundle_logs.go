@@ -48,7 +48,7 @@ func (a BundleLogsAction) IsLoggable() bool {
 }
 
 func (a BundleLogsAction) Run(request BundleLogsRequest) (BundleLogsResponse, error) {
-	tarball, err := a.logsTarProvider.Get(request.LogType, request.Filters)
+	tarball, err := a.logsTarProvider.Get(request.LogType, logstarprovider.Selection{Include: request.Filters})
 	if err != nil {
 		return BundleLogsResponse{}, err
 	}
@@ -50,9 +50,9 @@ var _ = Describe("FetchLogsAction", func() {
 			_, err := action.Run(request)
 			Expect(err).ToNot(HaveOccurred())
 
-			logType, filters := logsTarProvider.GetArgsForCall(0)
+			logType, selection := logsTarProvider.GetArgsForCall(0)
 			Expect(logType).To(Equal("job"))
-			Expect(filters).To(Equal([]string{"foo", "bar"}))
+			Expect(selection.Include).To(Equal([]string{"foo", "bar"}))
 
 			Expect(logsTarProvider.CleanUpCallCount()).To(BeZero())
 		})
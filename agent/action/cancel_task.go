@@ -3,8 +3,6 @@ package action
 import (
 	"errors"
 
-	bosherr "github.com/cloudfoundry/bosh-utils/errors"
-
 	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
@@ -29,13 +27,15 @@ func (a CancelTaskAction) IsLoggable() bool {
 	return true
 }
 
+// Run only replies canceled when a queued task was dropped or the running
+// task was asked to stop
 func (a CancelTaskAction) Run(taskID string) (string, error) {
-	task, found := a.taskService.FindTaskWithID(taskID)
-	if !found {
-		return "", bosherr.Errorf("Task with id %s could not be found", taskID)
+	err := a.taskService.CancelTask(taskID)
+	if err != nil {
+		return "", err
 	}
 
-	return "canceled", task.Cancel()
+	return "canceled", nil
 }
 
 func (a CancelTaskAction) Resume() (interface{}, error) {
@@ -44,6 +44,15 @@ var _ = Describe("CancelTaskAction", func() {
 		Expect(value).To(Equal("canceled")) // 1 l
 
 		Expect(cancelCalled).To(BeTrue())
+		Expect(taskService.CanceledTaskIDs).To(Equal([]string{"fake-task-id"}))
+	})
+
+	It("returns error when task could not be cancelled", func() {
+		taskService.CancelTaskErr = errors.New("Task fake-task-id is not running")
+
+		value, err := action.Run("fake-task-id")
+		Expect(err).To(MatchError("Task fake-task-id is not running"))
+		Expect(value).To(BeEmpty())
 	})
 
 	It("returns error when canceling task fails", func() {
@@ -0,0 +1,161 @@
+package action
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"code.cloudfoundry.org/clock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	blobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+)
+
+// CheckBlobstorePayloadSize is the size of the blob written and read back
+const CheckBlobstorePayloadSize = 1024 * 1024
+
+// CheckBlobstoreRequest passes signed URLs to check them instead of the
+// blobstore client. The delete URL is optional.
+type CheckBlobstoreRequest struct {
+	UploadSignedURL   string            `json:"upload_signed_url"`
+	DownloadSignedURL string            `json:"download_signed_url"`
+	DeleteSignedURL   string            `json:"delete_signed_url"`
+	BlobstoreHeaders  map[string]string `json:"blobstore_headers"`
+}
+
+type CheckBlobstoreResponse struct {
+	OK    bool                 `json:"ok"`
+	Steps []CheckBlobstoreStep `json:"steps"`
+}
+
+type CheckBlobstoreStep struct {
+	Name           string  `json:"name"`
+	Seconds        float64 `json:"seconds"`
+	BytesPerSecond float64 `json:"bytes_per_second,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// CheckBlobstoreAction writes a small blob, reads it back and deletes it
+// again to validate that the blobstore is reachable from this VM. Failures
+// are reported per step instead of failing the action.
+type CheckBlobstoreAction struct {
+	blobDelegator blobdelegator.BlobstoreDelegator
+	fs            boshsys.FileSystem
+	clock         clock.Clock
+}
+
+func NewCheckBlobstore(blobDelegator blobdelegator.BlobstoreDelegator, fs boshsys.FileSystem, clock clock.Clock) CheckBlobstoreAction {
+	return CheckBlobstoreAction{blobDelegator: blobDelegator, fs: fs, clock: clock}
+}
+
+func (a CheckBlobstoreAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a CheckBlobstoreAction) IsPersistent() bool {
+	return false
+}
+
+func (a CheckBlobstoreAction) IsLoggable() bool {
+	return true
+}
+
+func (a CheckBlobstoreAction) Run(requests ...CheckBlobstoreRequest) (CheckBlobstoreResponse, error) {
+	var request CheckBlobstoreRequest
+	if len(requests) > 0 {
+		request = requests[0]
+	}
+
+	if (request.UploadSignedURL == "") != (request.DownloadSignedURL == "") {
+		return CheckBlobstoreResponse{}, errors.New("Both upload_signed_url and download_signed_url are required to check signed URLs")
+	}
+
+	response := CheckBlobstoreResponse{OK: true}
+
+	record := func(name string, transferred int64, step func() error) bool {
+		started := a.clock.Now()
+		err := step()
+		result := CheckBlobstoreStep{Name: name, Seconds: a.clock.Since(started).Seconds()}
+
+		if err != nil {
+			result.Error = err.Error()
+			response.OK = false
+		} else if transferred > 0 && result.Seconds > 0 {
+			result.BytesPerSecond = float64(transferred) / result.Seconds
+		}
+
+		response.Steps = append(response.Steps, result)
+		return err == nil
+	}
+
+	var (
+		payloadPath string
+		blobID      string
+		digest      boshcrypto.MultipleDigest
+	)
+
+	defer func() {
+		if payloadPath != "" {
+			_ = a.fs.RemoveAll(payloadPath)
+		}
+	}()
+
+	if !record("prepare", 0, func() (err error) {
+		payloadPath, err = a.writePayload()
+		return err
+	}) {
+		return response, nil
+	}
+
+	if !record("write", CheckBlobstorePayloadSize, func() (err error) {
+		blobID, digest, err = a.blobDelegator.Write(request.UploadSignedURL, payloadPath, request.BlobstoreHeaders)
+		return err
+	}) {
+		return response, nil
+	}
+
+	record("read", CheckBlobstorePayloadSize, func() error {
+		fileName, err := a.blobDelegator.Get(digest, request.DownloadSignedURL, blobID, request.BlobstoreHeaders)
+		if err != nil {
+			return err
+		}
+		return a.fs.RemoveAll(fileName)
+	})
+
+	if request.UploadSignedURL == "" || request.DeleteSignedURL != "" {
+		record("delete", 0, func() error {
+			return a.blobDelegator.Delete(request.DeleteSignedURL, blobID, request.BlobstoreHeaders)
+		})
+	}
+
+	return response, nil
+}
+
+func (a CheckBlobstoreAction) writePayload() (string, error) {
+	file, err := a.fs.TempFile("bosh-agent-check-blobstore")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close() //nolint:errcheck
+
+	payload := make([]byte, CheckBlobstorePayloadSize)
+	if _, err = rand.Read(payload); err != nil {
+		_ = a.fs.RemoveAll(file.Name())
+		return "", err
+	}
+
+	if _, err = file.Write(payload); err != nil {
+		_ = a.fs.RemoveAll(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+func (a CheckBlobstoreAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a CheckBlobstoreAction) Cancel() error {
+	return errors.New("not supported")
+}
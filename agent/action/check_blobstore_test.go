@@ -0,0 +1,160 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	boshaction "github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+)
+
+var _ = Describe("CheckBlobstoreAction", func() {
+	var (
+		blobstore *fakeblobdelegator.FakeBlobstoreDelegator
+		fs        *fakesys.FakeFileSystem
+		clock     *fakeclock.FakeClock
+		digest    boshcrypto.MultipleDigest
+
+		action boshaction.CheckBlobstoreAction
+	)
+
+	BeforeEach(func() {
+		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
+		fs = fakesys.NewFakeFileSystem()
+		clock = fakeclock.NewFakeClock(time.Now())
+		digest = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"))
+
+		tempFile, err := fs.OpenFile("/tmp/check-blobstore", 0, 0600)
+		Expect(err).ToNot(HaveOccurred())
+		fs.ReturnTempFile = tempFile
+
+		blobstore.WriteStub = func(string, string, map[string]string) (string, boshcrypto.MultipleDigest, error) {
+			clock.Increment(2 * time.Second)
+			return "blob-id", digest, nil
+		}
+		blobstore.GetStub = func(boshcrypto.Digest, string, string, map[string]string) (string, error) {
+			clock.Increment(time.Second)
+			return "/tmp/downloaded", nil
+		}
+
+		action = boshaction.NewCheckBlobstore(blobstore, fs, clock)
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotPersistent(action)
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	Describe("Run", func() {
+		It("writes, reads and deletes a blob with the blobstore client", func() {
+			response, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response).To(Equal(boshaction.CheckBlobstoreResponse{
+				OK: true,
+				Steps: []boshaction.CheckBlobstoreStep{
+					{Name: "prepare"},
+					{Name: "write", Seconds: 2, BytesPerSecond: boshaction.CheckBlobstorePayloadSize / 2},
+					{Name: "read", Seconds: 1, BytesPerSecond: boshaction.CheckBlobstorePayloadSize},
+					{Name: "delete"},
+				},
+			}))
+
+			signedURL, path, _ := blobstore.WriteArgsForCall(0)
+			Expect(signedURL).To(BeEmpty())
+			Expect(path).To(Equal("/tmp/check-blobstore"))
+
+			digestArg, signedURL, blobID, _ := blobstore.GetArgsForCall(0)
+			Expect(digestArg).To(Equal(digest))
+			Expect(signedURL).To(BeEmpty())
+			Expect(blobID).To(Equal("blob-id"))
+
+			signedURL, blobID, _ = blobstore.DeleteArgsForCall(0)
+			Expect(signedURL).To(BeEmpty())
+			Expect(blobID).To(Equal("blob-id"))
+
+			Expect(fs.FileExists("/tmp/check-blobstore")).To(BeFalse())
+			Expect(fs.FileExists("/tmp/downloaded")).To(BeFalse())
+		})
+
+		It("uses signed URLs and only deletes with a delete URL", func() {
+			headers := map[string]string{"key": "value"}
+
+			response, err := action.Run(boshaction.CheckBlobstoreRequest{
+				UploadSignedURL:   "upload-url",
+				DownloadSignedURL: "download-url",
+				BlobstoreHeaders:  headers,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.OK).To(BeTrue())
+			Expect(response.Steps).To(HaveLen(3))
+
+			signedURL, _, headersArg := blobstore.WriteArgsForCall(0)
+			Expect(signedURL).To(Equal("upload-url"))
+			Expect(headersArg).To(Equal(headers))
+
+			_, signedURL, _, headersArg = blobstore.GetArgsForCall(0)
+			Expect(signedURL).To(Equal("download-url"))
+			Expect(headersArg).To(Equal(headers))
+
+			Expect(blobstore.DeleteCallCount()).To(BeZero())
+		})
+
+		It("deletes the blob with the delete URL", func() {
+			headers := map[string]string{"key": "value"}
+
+			response, err := action.Run(boshaction.CheckBlobstoreRequest{
+				UploadSignedURL:   "upload-url",
+				DownloadSignedURL: "download-url",
+				DeleteSignedURL:   "delete-url",
+				BlobstoreHeaders:  headers,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.OK).To(BeTrue())
+			Expect(response.Steps).To(HaveLen(4))
+			Expect(response.Steps[3].Name).To(Equal("delete"))
+
+			Expect(blobstore.DeleteCallCount()).To(Equal(1))
+			signedURL, _, headersArg := blobstore.DeleteArgsForCall(0)
+			Expect(signedURL).To(Equal("delete-url"))
+			Expect(headersArg).To(Equal(headers))
+		})
+
+		It("reports failed steps without failing", func() {
+			blobstore.GetStub = nil
+			blobstore.GetReturns("", errors.New("fake-get-err"))
+			blobstore.DeleteReturns(errors.New("fake-delete-err"))
+
+			response, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.OK).To(BeFalse())
+			Expect(response.Steps[2]).To(Equal(boshaction.CheckBlobstoreStep{Name: "read", Error: "fake-get-err"}))
+			Expect(response.Steps[3]).To(Equal(boshaction.CheckBlobstoreStep{Name: "delete", Error: "fake-delete-err"}))
+		})
+
+		It("skips reading when writing fails", func() {
+			blobstore.WriteStub = nil
+			blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-write-err"))
+
+			response, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.OK).To(BeFalse())
+			Expect(response.Steps).To(HaveLen(2))
+			Expect(response.Steps[1].Error).To(Equal("fake-write-err"))
+			Expect(blobstore.GetCallCount()).To(BeZero())
+		})
+
+		It("requires both upload and download signed URLs", func() {
+			_, err := action.Run(boshaction.CheckBlobstoreRequest{UploadSignedURL: "upload-url"})
+			Expect(err).To(MatchError("Both upload_signed_url and download_signed_url are required to check signed URLs"))
+		})
+	})
+})
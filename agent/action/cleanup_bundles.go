@@ -0,0 +1,75 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+)
+
+// CleanupBundlesRequest removes all previous versions instead of only those
+// the retention policy no longer retains.
+type CleanupBundlesRequest struct {
+	All bool `json:"all"`
+}
+
+// CleanupBundlesAction uninstalls the job and package bundles that are not
+// used by the applied spec, e.g. once previous versions kept for a rollback
+// or an inspection are no longer needed.
+type CleanupBundlesAction struct {
+	applier     boshappl.Applier
+	specService boshas.V1Service
+}
+
+func NewCleanupBundles(applier boshappl.Applier, specService boshas.V1Service) CleanupBundlesAction {
+	return CleanupBundlesAction{
+		applier:     applier,
+		specService: specService,
+	}
+}
+
+func (a CleanupBundlesAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a CleanupBundlesAction) IsPersistent() bool {
+	return false
+}
+
+func (a CleanupBundlesAction) IsLoggable() bool {
+	return true
+}
+
+func (a CleanupBundlesAction) Run(requests ...CleanupBundlesRequest) (string, error) {
+	var request CleanupBundlesRequest
+	if len(requests) > 0 {
+		request = requests[0]
+	}
+
+	appliedSpec, err := a.specService.Get()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting applied spec")
+	}
+
+	// Without an applied spec every bundle would be considered unused
+	if appliedSpec.ConfigurationHash == "" {
+		return "", errors.New("Cleaning up bundles requires an applied spec")
+	}
+
+	err = a.applier.CleanUp(appliedSpec, request.All)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Cleaning up bundles")
+	}
+
+	return "cleaned_up", nil
+}
+
+func (a CleanupBundlesAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a CleanupBundlesAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,73 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+)
+
+var _ = Describe("CleanupBundles", func() {
+	var (
+		applier       *fakeappl.FakeApplier
+		specService   *fakeas.FakeV1Service
+		cleanupAction action.CleanupBundlesAction
+	)
+
+	BeforeEach(func() {
+		applier = fakeappl.NewFakeApplier()
+		specService = fakeas.NewFakeV1Service()
+		specService.Spec = boshas.V1ApplySpec{ConfigurationHash: "fake-configuration-hash"}
+		cleanupAction = action.NewCleanupBundles(applier, specService)
+	})
+
+	AssertActionIsAsynchronous(cleanupAction)
+	AssertActionIsNotPersistent(cleanupAction)
+	AssertActionIsLoggable(cleanupAction)
+
+	AssertActionIsNotResumable(cleanupAction)
+	AssertActionIsNotCancelable(cleanupAction)
+
+	It("cleans up the bundles the applied spec does not use", func() {
+		result, err := cleanupAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("cleaned_up"))
+
+		Expect(applier.CleanedUp).To(BeTrue())
+		Expect(applier.CleanUpAppliedSpec).To(Equal(specService.Spec))
+		Expect(applier.CleanUpAll).To(BeFalse())
+	})
+
+	It("can clean up all unused bundles regardless of the retention policy", func() {
+		_, err := cleanupAction.Run(action.CleanupBundlesRequest{All: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(applier.CleanUpAll).To(BeTrue())
+	})
+
+	It("does not clean up without an applied spec", func() {
+		specService.Spec = boshas.V1ApplySpec{}
+
+		_, err := cleanupAction.Run()
+		Expect(err).To(MatchError("Cleaning up bundles requires an applied spec"))
+		Expect(applier.CleanedUp).To(BeFalse())
+	})
+
+	It("returns error when getting the applied spec fails", func() {
+		specService.GetErr = errors.New("fake-get-error")
+
+		_, err := cleanupAction.Run()
+		Expect(err).To(MatchError("Getting applied spec: fake-get-error"))
+	})
+
+	It("returns error when cleaning up fails", func() {
+		applier.CleanUpError = errors.New("fake-cleanup-error")
+
+		_, err := cleanupAction.Run()
+		Expect(err).To(MatchError("Cleaning up bundles: fake-cleanup-error"))
+	})
+})
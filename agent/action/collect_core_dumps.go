@@ -0,0 +1,142 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/coredump"
+	blobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+// CollectCoreDumpsRequest narrows down the core dumps to collect to the ones
+// of processes of Jobs, all of them are collected without jobs. Collected
+// core dumps are removed from the VM unless Keep is set.
+type CollectCoreDumpsRequest struct {
+	Jobs []string `json:"jobs"`
+	Keep bool     `json:"keep"`
+}
+
+type CollectedCoreDump struct {
+	coredump.CoreDump
+	BlobstoreID string `json:"blobstore_id"`
+	SHA1Digest  string `json:"sha1"`
+}
+
+// CollectCoreDumpsAction uploads core dumps of crashed job processes to the
+// blobstore, each in a tarball with the executable that dumped core, so they
+// can be analysed offline.
+type CollectCoreDumpsAction struct {
+	collector coredump.Collector
+	blobstore blobdelegator.BlobstoreDelegator
+
+	cancelSignals boshtask.CancelSignals
+}
+
+func NewCollectCoreDumps(
+	collector coredump.Collector,
+	blobstore blobdelegator.BlobstoreDelegator,
+	cancelSignals boshtask.CancelSignals,
+) (action CollectCoreDumpsAction) {
+	action.collector = collector
+	action.blobstore = blobstore
+	action.cancelSignals = cancelSignals
+	return
+}
+
+func (a CollectCoreDumpsAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a CollectCoreDumpsAction) IsPersistent() bool {
+	return false
+}
+
+func (a CollectCoreDumpsAction) IsLoggable() bool {
+	return true
+}
+
+func (a CollectCoreDumpsAction) Run(request CollectCoreDumpsRequest) ([]CollectedCoreDump, error) {
+	cancel := a.cancelSignals.RunningCancelSignal()
+
+	dumps, err := a.collector.List()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing core dumps")
+	}
+
+	collected := []CollectedCoreDump{}
+
+	for _, dump := range dumps {
+		if !dumpedByJobs(dump, request.Jobs) {
+			continue
+		}
+
+		err = cancel.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		collectedDump, err := a.collect(dump)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Collecting core dump %s", dump.Name)
+		}
+
+		collected = append(collected, collectedDump)
+	}
+
+	if request.Keep {
+		return collected, nil
+	}
+
+	// Only removed once all of them are collected, so that the core dumps of
+	// failed or cancelled tasks can be collected again
+	for _, collectedDump := range collected {
+		err = a.collector.Remove(collectedDump.CoreDump)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return collected, nil
+}
+
+func (a CollectCoreDumpsAction) collect(dump coredump.CoreDump) (CollectedCoreDump, error) {
+	tarball, err := a.collector.Package(dump)
+	if err != nil {
+		return CollectedCoreDump{}, err
+	}
+
+	defer func() {
+		_ = a.collector.CleanUp(tarball) //nolint:errcheck
+	}()
+
+	blobID, digest, err := a.blobstore.Write("", tarball, nil)
+	if err != nil {
+		return CollectedCoreDump{}, bosherr.WrapError(err, "Create file on blobstore")
+	}
+
+	return CollectedCoreDump{CoreDump: dump, BlobstoreID: blobID, SHA1Digest: digest.String()}, nil
+}
+
+func dumpedByJobs(dump coredump.CoreDump, jobs []string) bool {
+	if len(jobs) == 0 {
+		return true
+	}
+
+	for _, job := range jobs {
+		if dump.Job == job {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a CollectCoreDumpsAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a CollectCoreDumpsAction) Cancel() error {
+	return nil
+}
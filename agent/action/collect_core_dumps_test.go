@@ -0,0 +1,127 @@
+package action_test
+
+import (
+	"errors"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/coredump"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/coredump/coredumpfakes"
+	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+)
+
+var _ = Describe("CollectCoreDumpsAction", func() {
+	var (
+		collector *coredumpfakes.FakeCollector
+		blobstore *fakeblobdelegator.FakeBlobstoreDelegator
+
+		taskService *faketask.FakeService
+
+		webDump coredump.CoreDump
+		dbDump  coredump.CoreDump
+
+		action CollectCoreDumpsAction
+	)
+
+	BeforeEach(func() {
+		collector = &coredumpfakes.FakeCollector{}
+		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
+
+		webDump = coredump.CoreDump{Name: "core.web", Job: "web"}
+		dbDump = coredump.CoreDump{Name: "core.db", Job: "db"}
+		collector.ListReturns([]coredump.CoreDump{webDump, dbDump}, nil)
+
+		collector.PackageStub = func(dump coredump.CoreDump) (string, error) {
+			return "/tarballs/" + dump.Name + ".tgz", nil
+		}
+
+		blobstore.WriteStub = func(_, path string, _ map[string]string) (string, boshcrypto.MultipleDigest, error) {
+			digest := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "sha1-of-"+path))
+			return "blob-of-" + path, digest, nil
+		}
+
+		taskService = faketask.NewFakeService()
+
+		action = NewCollectCoreDumps(collector, blobstore, taskService)
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotPersistent(action)
+	AssertActionIsNotResumable(action)
+	AssertActionIsCancelable(action)
+
+	Describe("Run", func() {
+		It("uploads the packaged core dumps and removes them", func() {
+			collected, err := action.Run(CollectCoreDumpsRequest{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(collected).To(Equal([]CollectedCoreDump{
+				{CoreDump: webDump, BlobstoreID: "blob-of-/tarballs/core.web.tgz", SHA1Digest: "sha1-of-/tarballs/core.web.tgz"},
+				{CoreDump: dbDump, BlobstoreID: "blob-of-/tarballs/core.db.tgz", SHA1Digest: "sha1-of-/tarballs/core.db.tgz"},
+			}))
+
+			Expect(collector.CleanUpCallCount()).To(Equal(2))
+			Expect(collector.CleanUpArgsForCall(0)).To(Equal("/tarballs/core.web.tgz"))
+
+			Expect(collector.RemoveCallCount()).To(Equal(2))
+			Expect(collector.RemoveArgsForCall(0)).To(Equal(webDump))
+			Expect(collector.RemoveArgsForCall(1)).To(Equal(dbDump))
+		})
+
+		It("only collects the core dumps of the given jobs", func() {
+			collected, err := action.Run(CollectCoreDumpsRequest{Jobs: []string{"db"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(collected).To(HaveLen(1))
+			Expect(collected[0].CoreDump).To(Equal(dbDump))
+
+			Expect(collector.PackageCallCount()).To(Equal(1))
+		})
+
+		It("keeps the core dumps when asked to", func() {
+			_, err := action.Run(CollectCoreDumpsRequest{Keep: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(collector.RemoveCallCount()).To(BeZero())
+		})
+
+		It("returns the error listing core dumps", func() {
+			collector.ListReturns(nil, errors.New("fake-list-err"))
+
+			_, err := action.Run(CollectCoreDumpsRequest{})
+			Expect(err).To(MatchError("Listing core dumps: fake-list-err"))
+		})
+
+		It("removes no core dumps when one of them cannot be uploaded", func() {
+			blobstore.WriteStub = nil
+			blobstore.WriteReturnsOnCall(0, "blob-id", boshcrypto.MultipleDigest{}, nil)
+			blobstore.WriteReturnsOnCall(1, "", boshcrypto.MultipleDigest{}, errors.New("fake-write-err"))
+
+			_, err := action.Run(CollectCoreDumpsRequest{})
+			Expect(err).To(MatchError("Collecting core dump core.db: Create file on blobstore: fake-write-err"))
+
+			Expect(collector.CleanUpCallCount()).To(Equal(2))
+			Expect(collector.RemoveCallCount()).To(BeZero())
+		})
+
+		It("stops collecting core dumps when cancelled", func() {
+			cancelCh := make(chan struct{})
+			taskService.RunningCancelSignalResult = boshtask.NewCancelSignal(cancelCh)
+
+			blobstore.WriteStub = func(string, string, map[string]string) (string, boshcrypto.MultipleDigest, error) {
+				close(cancelCh)
+				return "blob-id", boshcrypto.MultipleDigest{}, nil
+			}
+
+			_, err := action.Run(CollectCoreDumpsRequest{})
+			Expect(err).To(MatchError(boshtask.ErrCancelled))
+
+			Expect(collector.PackageCallCount()).To(Equal(1))
+			Expect(collector.RemoveCallCount()).To(BeZero())
+		})
+	})
+})
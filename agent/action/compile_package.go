@@ -8,14 +8,18 @@ import (
 
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 type CompilePackageAction struct {
 	compiler boshcomp.Compiler
+
+	cancelSignals boshtask.CancelSignals
 }
 
-func NewCompilePackage(compiler boshcomp.Compiler) (compilePackage CompilePackageAction) {
+func NewCompilePackage(compiler boshcomp.Compiler, cancelSignals boshtask.CancelSignals) (compilePackage CompilePackageAction) {
 	compilePackage.compiler = compiler
+	compilePackage.cancelSignals = cancelSignals
 	return
 }
 
@@ -54,7 +58,7 @@ func (a CompilePackageAction) Run(blobID string, multiDigest boshcrypto.Multiple
 		})
 	}
 
-	uploadedBlobID, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps)
+	uploadedBlobID, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps, a.cancelSignals.RunningCancelSignal())
 	if err != nil {
 		return val, bosherr.WrapErrorf(err, "Compiling package %s", pkg.Name)
 	}
@@ -74,6 +78,8 @@ func (a CompilePackageAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
 
+// Cancel stops compiling before the next step and removes what was installed
+// for compiling
 func (a CompilePackageAction) Cancel() error {
-	return errors.New("not supported")
+	return nil
 }
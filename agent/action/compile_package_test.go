@@ -13,6 +13,7 @@ import (
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
 	fakecomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler/fakes"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 )
 
 func getCompileActionArguments() (blobID string, multiDigest boshcrypto.MultipleDigest, name, version string, deps boshcomp.Dependencies) {
@@ -45,14 +46,14 @@ var _ = Describe("CompilePackageAction", func() {
 
 	BeforeEach(func() {
 		compiler = fakecomp.NewFakeCompiler()
-		action = boshaction.NewCompilePackage(compiler)
+		action = boshaction.NewCompilePackage(compiler, faketask.NewFakeService())
 	})
 
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
 
-	AssertActionIsNotCancelable(action)
+	AssertActionIsCancelable(action)
 	AssertActionIsNotResumable(action)
 
 	Describe("Run", func() {
@@ -8,12 +8,15 @@ import (
 
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 type CompilePackageWithSignedURLRequest struct {
-	PackageGetSignedURL string            `json:"package_get_signed_url"`
-	UploadSignedURL     string            `json:"upload_signed_url"`
-	BlobstoreHeaders    map[string]string `json:"blobstore_headers"`
+	PackageGetSignedURL string                            `json:"package_get_signed_url"`
+	UploadSignedURL     string                            `json:"upload_signed_url"`
+	UploadMultipart     *httpblobprovider.MultipartUpload `json:"upload_multipart,omitempty"`
+	BlobstoreHeaders    map[string]string                 `json:"blobstore_headers"`
 
 	Digest  boshcrypto.MultipleDigest `json:"digest"`
 	Name    string                    `json:"name"`
@@ -23,11 +26,14 @@ type CompilePackageWithSignedURLRequest struct {
 
 type CompilePackageWithSignedURL struct {
 	compiler boshcomp.Compiler
+
+	cancelSignals boshtask.CancelSignals
 }
 
-func NewCompilePackageWithSignedURL(compiler boshcomp.Compiler) (compilePackage CompilePackageWithSignedURL) {
+func NewCompilePackageWithSignedURL(compiler boshcomp.Compiler, cancelSignals boshtask.CancelSignals) (compilePackage CompilePackageWithSignedURL) {
 	return CompilePackageWithSignedURL{
-		compiler: compiler,
+		compiler:      compiler,
+		cancelSignals: cancelSignals,
 	}
 }
 
@@ -38,6 +44,7 @@ func (a CompilePackageWithSignedURL) Run(request CompilePackageWithSignedURLRequ
 		Version:             request.Version,
 		PackageGetSignedURL: request.PackageGetSignedURL,
 		UploadSignedURL:     request.UploadSignedURL,
+		UploadMultipart:     request.UploadMultipart,
 		BlobstoreHeaders:    request.BlobstoreHeaders,
 	}
 
@@ -56,7 +63,7 @@ func (a CompilePackageWithSignedURL) Run(request CompilePackageWithSignedURLRequ
 		})
 	}
 
-	_, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps)
+	_, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps, a.cancelSignals.RunningCancelSignal())
 	if err != nil {
 		return map[string]interface{}{}, bosherr.WrapErrorf(err, "Compiling package %s", pkg.Name)
 	}
@@ -75,7 +82,7 @@ func (a CompilePackageWithSignedURL) Resume() (interface{}, error) {
 }
 
 func (a CompilePackageWithSignedURL) Cancel() error {
-	return errors.New("not supported")
+	return nil
 }
 
 func (a CompilePackageWithSignedURL) IsAsynchronous(_ ProtocolVersion) bool {
@@ -13,6 +13,8 @@ import (
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
 	fakecomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 )
 
 func getCompileWithSignedURLActionArguments() boshaction.CompilePackageWithSignedURLRequest {
@@ -52,14 +54,14 @@ var _ = Describe("CompilePackageWithSignedURL", func() {
 
 	BeforeEach(func() {
 		compiler = fakecomp.NewFakeCompiler()
-		action = boshaction.NewCompilePackageWithSignedURL(compiler)
+		action = boshaction.NewCompilePackageWithSignedURL(compiler, faketask.NewFakeService())
 	})
 
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
 
-	AssertActionIsNotCancelable(action)
+	AssertActionIsCancelable(action)
 	AssertActionIsNotResumable(action)
 
 	Describe("Run", func() {
@@ -138,6 +140,21 @@ var _ = Describe("CompilePackageWithSignedURL", func() {
 			Expect(compiler.CompileDeps).To(ConsistOf(expectedDeps))
 		})
 
+		It("passes the multipart upload to the compiler", func() {
+			compiler.CompileDigest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "some checksum")
+
+			args := getCompileWithSignedURLActionArguments()
+			args.UploadMultipart = &httpblobprovider.MultipartUpload{
+				PartSignedURLs:    []string{"fake/part/url"},
+				PartSize:          5 * 1024 * 1024,
+				CompleteSignedURL: "fake/complete/url",
+			}
+
+			_, err := action.Run(args)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(compiler.CompilePkg.UploadMultipart).To(Equal(args.UploadMultipart))
+		})
+
 		It("returns error when compile fails", func() {
 			compiler.CompileErr = errors.New("fake-compile-error")
 
@@ -1,20 +1,38 @@
 package action
 
 import (
+	"net"
+
+	"code.cloudfoundry.org/clock"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/artifact"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
 	boshagentblob "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/coredump"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/crashreport"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/diskusage"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/healthcheck"
 	blobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/linkverifier"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/loglevel"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logtail"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/netdiag"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/profiler"
 	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/snapshot"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/sshusers"
 	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/templaterenderer"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/utils"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
 	boshnotif "github.com/cloudfoundry/bosh-agent/v2/notification"
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
+	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 )
 
@@ -31,17 +49,42 @@ func NewFactory(
 	taskService boshtask.Service,
 	notifier boshnotif.Notifier,
 	applier boshappl.Applier,
+	bundleVerifier boshappl.BundleVerifier,
 	compiler boshcomp.Compiler,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	specService boshas.V1Service,
+	auditLog audit.Log,
 	jobScriptProvider boshscript.JobScriptProvider,
 	logger boshlog.Logger,
-	blobstoreDelegator blobdelegator.BlobstoreDelegator) (factory Factory) {
+	blobstoreDelegator blobdelegator.BlobstoreDelegator,
+	signedURLRefresher blobdelegator.SignedURLRefresher,
+	outputReporter boshtask.OutputReporter,
+	stageReporter boshtask.StageReporter,
+	sshUsers sshusers.Tracker,
+	logLevel *loglevel.Logger,
+	snapshotFreezer snapshot.Freezer) (factory Factory) {
 	dirProvider := platform.GetDirProvider()
 	vitalsService := platform.GetVitalsService()
 	certManager := platform.GetCertManager()
 	logsTarProvider := platform.GetLogsTarProvider()
 
+	// apply_async applies the same way, the dispatcher notifies the director
+	// once it completes instead of the director polling get_task
+	applyAction := NewApply(
+		applier,
+		specService,
+		settingsService,
+		dirProvider,
+		platform.GetFs(),
+		linkverifier.NewVerifier(linkverifier.DefaultTimeout, logger),
+		boshappl.NewHookRunner(platform.GetFs(), platform.GetRunner(), dirProvider, logger),
+		taskService,
+	)
+
+	runScriptAction := NewRunScript(jobScriptProvider, specService, applier, outputReporter, logger)
+
+	logFollower := logtail.NewFollower(platform.GetFs(), dirProvider.LogsDir(), clock.NewClock(), logtail.DefaultPollInterval)
+
 	return concreteFactory{
 		availableActions: map[string]Action{
 			// API
@@ -49,40 +92,73 @@ func NewFactory(
 			"info": NewInfo(),
 
 			// Task management
-			"get_task":    NewGetTask(taskService),
-			"cancel_task": NewCancelTask(taskService),
+			"get_task":       NewGetTask(taskService),
+			"cancel_task":    NewCancelTask(taskService),
+			"get_task_queue": NewGetTaskQueue(taskService),
 
 			// VM admin
-			"ssh":                        NewSSH(settingsService, platform, dirProvider, logger),
+			"ssh":                        NewSSH(settingsService, platform, dirProvider, clock.NewClock(), sshUsers, logger),
 			"bundle_logs":                NewBundleLogs(logsTarProvider, platform.GetFs()),
-			"fetch_logs":                 NewFetchLogs(logsTarProvider, blobstoreDelegator),
-			"fetch_logs_with_signed_url": NewFetchLogsWithSignedURLAction(logsTarProvider, blobstoreDelegator),
+			"fetch_logs":                 NewFetchLogs(logsTarProvider, blobstoreDelegator, platform.GetFs(), taskService),
+			"fetch_logs_with_signed_url": NewFetchLogsWithSignedURLAction(logsTarProvider, blobstoreDelegator, platform.GetFs(), logger),
+			"tail_logs":                  NewTailLogs(logFollower, outputReporter, blobstoreDelegator, taskService),
 			"update_settings":            NewUpdateSettings(settingsService, platform, certManager, logger, utils.NewAgentKiller()),
+			"refresh_settings":           NewRefreshSettings(settingsService, platform, certManager, utils.NewAgentKiller(), logger),
+			"manage_certificates":        NewManageCertificates(settingsService, certManager, platform.GetFs(), dirProvider),
 			"shutdown":                   NewShutdown(platform),
 			"remove_file":                NewRemoveFile(platform.GetFs()),
+			"get_crash_reports":          NewGetCrashReports(crashreport.NewStore(platform.GetFs(), dirProvider.CrashReportsDir(), crashreport.DefaultMaxReports)),
+			"collect_core_dumps":         NewCollectCoreDumps(coredump.NewCollector(platform.GetFs(), dirProvider), blobstoreDelegator, taskService),
+			"upload_artifact":            NewUploadArtifact(artifact.NewUploader(blobstoreDelegator, specService, platform.GetFs(), dirProvider, logger)),
+			"profile":                    NewProfile(profiler.NewProfiler(platform.GetFs(), platform.GetRunner(), dirProvider, clock.NewClock(), logger), blobstoreDelegator, taskService),
+			"disk_usage":                 NewDiskUsage(diskusage.NewAnalyzer(platform.GetFs()), vitalsService, platform.GetFs(), dirProvider, taskService),
+			"diagnose_network":           NewDiagnoseNetwork(netdiag.NewDiagnoser(platform.GetRunner(), net.DefaultResolver, clock.NewClock()), settingsService, taskService),
+			"check_blobstore":            NewCheckBlobstore(blobstoreDelegator, platform.GetFs(), clock.NewClock()),
+			"exec_command":               NewExecCommand(settingsService, platform.GetRunner(), auditLog, clock.NewClock(), logger, taskService),
+			"rotate_logs":                NewRotateLogs(platform),
+			"grow_ephemeral_disk":        NewGrowEphemeralDisk(settingsService, platform),
+			"set_log_level":              NewSetLogLevel(logLevel),
 
 			// Job management
-			"prepare":    NewPrepare(applier),
-			"apply":      NewApply(applier, specService, settingsService, dirProvider, platform.GetFs()),
-			"start":      NewStart(jobSupervisor, applier, specService),
-			"stop":       NewStop(jobSupervisor),
-			"drain":      NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger),
-			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService),
-			"run_errand": NewRunErrand(specService, dirProvider.JobsDir(), platform.GetRunner(), logger),
-			"run_script": NewRunScript(jobScriptProvider, specService, logger),
+			"prepare":      NewPrepare(applier),
+			"apply":        applyAction,
+			"apply_async":  applyAction,
+			"revert_apply": NewRevertApply(applyAction, specService),
+			"start":        NewStart(jobSupervisor, applier, specService),
+			"stop":         NewStop(jobSupervisor),
+			"restart_job":  NewRestartJob(jobSupervisor),
+			"drain":        NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger, taskService),
+			"get_state":    NewGetState(settingsService, specService, jobSupervisor, vitalsService, bundleVerifier, boshstats.NewProcProcessCollector(platform.GetFs(), "/proc")),
+			"run_errand":   NewRunErrand(specService, applier, dirProvider.JobsDir(), platform.GetRunner(), logger, taskService),
+			"run_script":   runScriptAction,
+
+			"list_processes":   NewListProcesses(jobSupervisor),
+			"rerun_job_script": NewRerunJobScript(jobScriptProvider, specService, applier, platform.GetFs(), dirProvider, logger),
+
+			"run_health_checks": NewRunHealthChecks(specService, healthcheck.NewChecker(jobScriptProvider, clock.NewClock()), taskService),
+
+			"cleanup_bundles": NewCleanupBundles(applier, specService),
+			"verify_bundles":  NewVerifyBundles(bundleVerifier, specService),
+			"audit_bundles":   NewAuditBundles(bundleVerifier, specService),
+			"get_audit_log":   NewGetAuditLog(auditLog),
+			"get_apply_spec":  NewGetApplySpec(specService, bundleVerifier),
 
 			// Compilation
-			"compile_package":                 NewCompilePackage(compiler),
-			"compile_package_with_signed_url": NewCompilePackageWithSignedURL(compiler),
+			"compile_package":                 NewCompilePackage(compiler, taskService),
+			"compile_package_with_signed_url": NewCompilePackageWithSignedURL(compiler, taskService),
+			"refresh_signed_url":              NewRefreshSignedURL(signedURLRefresher),
 
 			// Rendered Templates
-			"upload_blob": NewUploadBlobAction(sensitiveBlobManager),
+			"upload_blob":      NewUploadBlobAction(sensitiveBlobManager),
+			"render_templates": NewRenderTemplates(templaterenderer.NewRenderer(), settingsService, platform.GetCompressor(), sensitiveBlobManager, platform.GetFs()),
 
 			// Disk management
 			"list_disk":              NewListDisk(settingsService, platform, logger),
-			"migrate_disk":           NewMigrateDisk(platform, dirProvider),
+			"migrate_disk":           NewMigrateDisk(platform, dirProvider, stageReporter, taskService),
 			"mount_disk":             NewMountDisk(settingsService, platform, dirProvider, logger),
 			"unmount_disk":           NewUnmountDisk(settingsService, platform),
+			"prepare_snapshot":       NewPrepareSnapshot(runScriptAction, snapshotFreezer),
+			"finish_snapshot":        NewFinishSnapshot(runScriptAction, snapshotFreezer),
 			"add_persistent_disk":    NewAddPersistentDiskAction(settingsService),
 			"remove_persistent_disk": NewRemovePersistentDiskAction(settingsService),
 
@@ -7,19 +7,29 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent/script/scriptfakes"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
 
+	"code.cloudfoundry.org/clock"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	boshaction "github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/artifact"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/crashreport"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/loglevel"
 	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/templaterenderer"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/utils"
+	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
 	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
 	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit/auditfakes"
 	fakeagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore/blobstorefakes"
 	fakecomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler/fakes"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/snapshot/snapshotfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/sshusers/sshusersfakes"
 	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
 	fakenotif "github.com/cloudfoundry/bosh-agent/v2/notification/fakes"
@@ -36,14 +46,22 @@ var _ = Describe("concreteFactory", func() {
 		taskService       *faketask.FakeService
 		notifier          *fakenotif.FakeNotifier
 		applier           *fakeappl.FakeApplier
+		bundleVerifier    *fakeappl.FakeBundleVerifier
 		compiler          *fakecomp.FakeCompiler
 		jobSupervisor     *fakejobsuper.FakeJobSupervisor
 		specService       *fakeas.FakeV1Service
+		auditLog          *auditfakes.FakeLog
 		jobScriptProvider boshscript.JobScriptProvider
 		factory           boshaction.Factory
 		logger            boshlog.Logger
 		fileSystem        *fakesys.FakeFileSystem
 		blobDelegator     *fakeblobdelegator.FakeBlobstoreDelegator
+		urlRefresher      *fakeblobdelegator.FakeSignedURLRefresher
+		outputReporter    *faketask.FakeOutputReporter
+		stageReporter     *faketask.FakeStageReporter
+		sshUsers          *sshusersfakes.FakeTracker
+		logLevel          *loglevel.Logger
+		snapshotFreezer   *snapshotfakes.FakeFreezer
 	)
 
 	BeforeEach(func() {
@@ -58,12 +76,20 @@ var _ = Describe("concreteFactory", func() {
 		taskService = &faketask.FakeService{}
 		notifier = fakenotif.NewFakeNotifier()
 		applier = fakeappl.NewFakeApplier()
+		bundleVerifier = &fakeappl.FakeBundleVerifier{}
 		compiler = fakecomp.NewFakeCompiler()
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		specService = fakeas.NewFakeV1Service()
+		auditLog = &auditfakes.FakeLog{}
 		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 		blobDelegator = &fakeblobdelegator.FakeBlobstoreDelegator{}
+		urlRefresher = &fakeblobdelegator.FakeSignedURLRefresher{}
+		outputReporter = &faketask.FakeOutputReporter{}
+		stageReporter = &faketask.FakeStageReporter{}
+		sshUsers = &sshusersfakes.FakeTracker{}
+		logLevel = loglevel.NewLogger(logger, boshlog.LevelDebug, clock.NewClock())
+		snapshotFreezer = &snapshotfakes.FakeFreezer{}
 
 		factory = boshaction.NewFactory(
 			settingsService,
@@ -72,12 +98,20 @@ var _ = Describe("concreteFactory", func() {
 			taskService,
 			notifier,
 			applier,
+			bundleVerifier,
 			compiler,
 			jobSupervisor,
 			specService,
+			auditLog,
 			jobScriptProvider,
 			logger,
 			blobDelegator,
+			urlRefresher,
+			outputReporter,
+			stageReporter,
+			sshUsers,
+			logLevel,
+			snapshotFreezer,
 		)
 	})
 
@@ -90,13 +124,22 @@ var _ = Describe("concreteFactory", func() {
 	It("apply", func() {
 		action, err := factory.Create("apply")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(BeEquivalentTo(boshaction.NewApply(
-			applier,
-			specService,
-			settingsService,
-			boshdir.NewProvider("/var/vcap"),
-			fileSystem,
-		)))
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.ApplyAction{}))
+	})
+
+	It("apply_async", func() {
+		action, err := factory.Create("apply_async")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.ApplyAction{}))
+	})
+
+	It("revert_apply", func() {
+		action, err := factory.Create("revert_apply")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.RevertApplyAction{}))
 	})
 
 	It("drain", func() {
@@ -109,13 +152,34 @@ var _ = Describe("concreteFactory", func() {
 	It("fetch_logs", func() {
 		action, err := factory.Create("fetch_logs")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewFetchLogs(platform.GetLogsTarProvider(), blobDelegator)))
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.FetchLogsAction{}))
 	})
 
 	It("fetch_logs_with_signed_url", func() {
 		action, err := factory.Create("fetch_logs_with_signed_url")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewFetchLogsWithSignedURLAction(platform.GetLogsTarProvider(), blobDelegator)))
+		Expect(action).To(Equal(boshaction.NewFetchLogsWithSignedURLAction(platform.GetLogsTarProvider(), blobDelegator, platform.GetFs(), logger)))
+	})
+
+	It("tail_logs", func() {
+		action, err := factory.Create("tail_logs")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.TailLogsAction{}))
+	})
+
+	It("check_blobstore", func() {
+		action, err := factory.Create("check_blobstore")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewCheckBlobstore(blobDelegator, platform.GetFs(), clock.NewClock())))
+	})
+
+	It("exec_command", func() {
+		action, err := factory.Create("exec_command")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.ExecCommandAction{}))
 	})
 
 	It("bundle_logs", func() {
@@ -130,6 +194,55 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(boshaction.NewRemoveFile(platform.GetFs())))
 	})
 
+	It("get_crash_reports", func() {
+		action, err := factory.Create("get_crash_reports")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewGetCrashReports(
+			crashreport.NewStore(fileSystem, "/var/vcap/bosh/crash_reports", crashreport.DefaultMaxReports),
+		)))
+	})
+
+	It("collect_core_dumps", func() {
+		action, err := factory.Create("collect_core_dumps")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.CollectCoreDumpsAction{}))
+	})
+
+	It("upload_artifact", func() {
+		action, err := factory.Create("upload_artifact")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewUploadArtifact(artifact.NewUploader(blobDelegator, specService, platform.GetFs(), platform.GetDirProvider(), logger))))
+	})
+
+	It("profile", func() {
+		action, err := factory.Create("profile")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.ProfileAction{}))
+	})
+
+	It("disk_usage", func() {
+		action, err := factory.Create("disk_usage")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.DiskUsageAction{}))
+	})
+
+	It("run_health_checks", func() {
+		action, err := factory.Create("run_health_checks")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.RunHealthChecksAction{}))
+	})
+
+	It("diagnose_network", func() {
+		action, err := factory.Create("diagnose_network")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.DiagnoseNetworkAction{}))
+	})
+
 	It("get_task", func() {
 		action, err := factory.Create("get_task")
 		Expect(err).ToNot(HaveOccurred())
@@ -142,10 +255,16 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(boshaction.NewCancelTask(taskService)))
 	})
 
+	It("get_task_queue", func() {
+		action, err := factory.Create("get_task_queue")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewGetTaskQueue(taskService)))
+	})
+
 	It("get_state", func() {
 		action, err := factory.Create("get_state")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService())))
+		Expect(action).To(Equal(boshaction.NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService(), bundleVerifier, boshstats.NewProcProcessCollector(platform.GetFs(), "/proc"))))
 	})
 
 	It("list_disk", func() {
@@ -157,7 +276,7 @@ var _ = Describe("concreteFactory", func() {
 	It("migrate_disk", func() {
 		action, err := factory.Create("migrate_disk")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewMigrateDisk(platform, platform.GetDirProvider())))
+		Expect(action).To(Equal(boshaction.NewMigrateDisk(platform, platform.GetDirProvider(), stageReporter, taskService)))
 	})
 
 	It("mount_disk", func() {
@@ -181,7 +300,37 @@ var _ = Describe("concreteFactory", func() {
 	It("ssh", func() {
 		action, err := factory.Create("ssh")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewSSH(settingsService, platform, platform.GetDirProvider(), logger)))
+		Expect(action).To(Equal(boshaction.NewSSH(settingsService, platform, platform.GetDirProvider(), clock.NewClock(), sshUsers, logger)))
+	})
+
+	It("cleanup_bundles", func() {
+		action, err := factory.Create("cleanup_bundles")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewCleanupBundles(applier, specService)))
+	})
+
+	It("verify_bundles", func() {
+		action, err := factory.Create("verify_bundles")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewVerifyBundles(bundleVerifier, specService)))
+	})
+
+	It("audit_bundles", func() {
+		action, err := factory.Create("audit_bundles")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewAuditBundles(bundleVerifier, specService)))
+	})
+
+	It("get_apply_spec", func() {
+		action, err := factory.Create("get_apply_spec")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewGetApplySpec(specService, bundleVerifier)))
+	})
+
+	It("get_audit_log", func() {
+		action, err := factory.Create("get_audit_log")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewGetAuditLog(auditLog)))
 	})
 
 	It("start", func() {
@@ -196,6 +345,18 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(boshaction.NewStop(jobSupervisor)))
 	})
 
+	It("restart_job", func() {
+		action, err := factory.Create("restart_job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewRestartJob(jobSupervisor)))
+	})
+
+	It("list_processes", func() {
+		action, err := factory.Create("list_processes")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewListProcesses(jobSupervisor)))
+	})
+
 	It("remove_persistent_disk", func() {
 		action, err := factory.Create("remove_persistent_disk")
 		Expect(err).ToNot(HaveOccurred())
@@ -208,16 +369,42 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(boshaction.NewUnmountDisk(settingsService, platform)))
 	})
 
+	It("prepare_snapshot", func() {
+		action, err := factory.Create("prepare_snapshot")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewPrepareSnapshot(
+			boshaction.NewRunScript(jobScriptProvider, specService, applier, outputReporter, logger),
+			snapshotFreezer,
+		)))
+	})
+
+	It("finish_snapshot", func() {
+		action, err := factory.Create("finish_snapshot")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewFinishSnapshot(
+			boshaction.NewRunScript(jobScriptProvider, specService, applier, outputReporter, logger),
+			snapshotFreezer,
+		)))
+	})
+
 	It("compile_package", func() {
 		action, err := factory.Create("compile_package")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewCompilePackage(compiler)))
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.CompilePackageAction{}))
+	})
+
+	It("refresh_signed_url", func() {
+		action, err := factory.Create("refresh_signed_url")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewRefreshSignedURL(urlRefresher)))
 	})
 
 	It("compile_package_with_signed_url", func() {
 		action, err := factory.Create("compile_package_with_signed_url")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewCompilePackageWithSignedURL(compiler)))
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.CompilePackageWithSignedURL{}))
 	})
 
 	It("run_errand", func() {
@@ -231,7 +418,43 @@ var _ = Describe("concreteFactory", func() {
 	It("run_script", func() {
 		action, err := factory.Create("run_script")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewRunScript(jobScriptProvider, specService, logger)))
+		Expect(action).To(Equal(boshaction.NewRunScript(jobScriptProvider, specService, applier, outputReporter, logger)))
+	})
+
+	It("rerun_job_script", func() {
+		action, err := factory.Create("rerun_job_script")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewRerunJobScript(jobScriptProvider, specService, applier, fileSystem, platform.GetDirProvider(), logger)))
+	})
+
+	It("refresh_settings", func() {
+		action, err := factory.Create("refresh_settings")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewRefreshSettings(settingsService, platform, platform.GetCertManager(), utils.NewAgentKiller(), logger)))
+	})
+
+	It("manage_certificates", func() {
+		action, err := factory.Create("manage_certificates")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewManageCertificates(settingsService, platform.GetCertManager(), platform.GetFs(), platform.GetDirProvider())))
+	})
+
+	It("rotate_logs", func() {
+		action, err := factory.Create("rotate_logs")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewRotateLogs(platform)))
+	})
+
+	It("grow_ephemeral_disk", func() {
+		action, err := factory.Create("grow_ephemeral_disk")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewGrowEphemeralDisk(settingsService, platform)))
+	})
+
+	It("set_log_level", func() {
+		action, err := factory.Create("set_log_level")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewSetLogLevel(logLevel)))
 	})
 
 	It("prepare", func() {
@@ -264,4 +487,11 @@ var _ = Describe("concreteFactory", func() {
 
 		Expect(action).To(Equal(boshaction.NewUploadBlobAction(blobManager)))
 	})
+
+	It("render_templates", func() {
+		action, err := factory.Create("render_templates")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(action).To(Equal(boshaction.NewRenderTemplates(templaterenderer.NewRenderer(), settingsService, platform.GetCompressor(), blobManager, fileSystem)))
+	})
 })
@@ -0,0 +1,156 @@
+package action
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/netdiag"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+const (
+	defaultNetworkCheckTimeout = 5 * time.Second
+	maxNetworkCheckTimeout     = time.Minute
+
+	defaultNetworkCheckMTU = 1500
+)
+
+var defaultNetworkChecks = []string{netdiag.CheckGateway, netdiag.CheckMTU, netdiag.CheckDNS, netdiag.CheckTCP}
+
+// DiagnoseNetworkRequest selects the network checks to run, all of them by
+// default. The gateway of the default network is pinged and probed with
+// packets of MTU bytes. The hosts of NATS and the blobstore, usually the
+// director, are resolved and connected to along with Hosts and Addresses.
+type DiagnoseNetworkRequest struct {
+	Checks         []string `json:"checks"`
+	Hosts          []string `json:"hosts"`
+	Addresses      []string `json:"addresses"`
+	MTU            int      `json:"mtu"`
+	TimeoutSeconds int      `json:"timeout_seconds"`
+}
+
+// DiagnoseNetworkAction checks the connectivity of the VM, so incidents of
+// unreachable agents can be looked into once they are reachable again
+type DiagnoseNetworkAction struct {
+	diagnoser       netdiag.Diagnoser
+	settingsService boshsettings.Service
+
+	cancelSignals boshtask.CancelSignals
+}
+
+func NewDiagnoseNetwork(
+	diagnoser netdiag.Diagnoser,
+	settingsService boshsettings.Service,
+	cancelSignals boshtask.CancelSignals,
+) (action DiagnoseNetworkAction) {
+	action.diagnoser = diagnoser
+	action.settingsService = settingsService
+	action.cancelSignals = cancelSignals
+	return
+}
+
+func (a DiagnoseNetworkAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a DiagnoseNetworkAction) IsPersistent() bool {
+	return false
+}
+
+func (a DiagnoseNetworkAction) IsLoggable() bool {
+	return true
+}
+
+func (a DiagnoseNetworkAction) Run(request DiagnoseNetworkRequest) ([]netdiag.Result, error) {
+	cancel := a.cancelSignals.RunningCancelSignal()
+
+	checks := request.Checks
+	if len(checks) == 0 {
+		checks = defaultNetworkChecks
+	}
+
+	options := netdiag.Options{Timeout: defaultNetworkCheckTimeout}
+
+	if request.TimeoutSeconds != 0 {
+		options.Timeout = time.Duration(request.TimeoutSeconds) * time.Second
+		if request.TimeoutSeconds < 0 || options.Timeout > maxNetworkCheckTimeout {
+			return nil, bosherr.Errorf("Network check timeout %ds is not between 1s and %s", request.TimeoutSeconds, maxNetworkCheckTimeout)
+		}
+	}
+
+	settings := a.settingsService.GetSettings()
+
+	var directorHosts, directorAddresses []string
+	for _, rawURL := range []string{settings.GetMbusURL(), blobstoreEndpoint(settings.GetBlobstore())} {
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil || parsedURL.Hostname() == "" {
+			continue
+		}
+
+		directorHosts = appendMissing(directorHosts, parsedURL.Hostname())
+		if parsedURL.Port() != "" {
+			directorAddresses = appendMissing(directorAddresses, parsedURL.Host)
+		}
+	}
+
+	// Checks of the gateway are left out without a default gateway
+	network, found := settings.Networks.DefaultNetworkFor("gateway")
+	if found {
+		options.Gateway = network.Gateway
+	}
+
+	for _, check := range checks {
+		switch check {
+		case netdiag.CheckGateway:
+			options.PingGateway = true
+
+		case netdiag.CheckMTU:
+			options.MTU = request.MTU
+			if options.MTU == 0 {
+				options.MTU = defaultNetworkCheckMTU
+			}
+
+		case netdiag.CheckDNS:
+			options.Hosts = append(directorHosts, request.Hosts...)
+
+		case netdiag.CheckTCP:
+			options.Addresses = append(directorAddresses, request.Addresses...)
+
+		default:
+			return nil, bosherr.Errorf("Unknown network check '%s'", check)
+		}
+	}
+
+	results, err := a.diagnoser.Diagnose(options, cancel)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Diagnosing network")
+	}
+
+	return results, nil
+}
+
+func (a DiagnoseNetworkAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a DiagnoseNetworkAction) Cancel() error {
+	return nil
+}
+
+func blobstoreEndpoint(blobstore boshsettings.Blobstore) string {
+	endpoint, _ := blobstore.Options["endpoint"].(string) //nolint:errcheck
+	return endpoint
+}
+
+func appendMissing(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
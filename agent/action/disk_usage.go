@@ -0,0 +1,117 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/diskusage"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+const (
+	defaultDiskUsageTop = 10
+	maxDiskUsageTop     = 100
+)
+
+// DiskUsageRequest sets how many of the largest subtrees of each directory
+// are reported
+type DiskUsageRequest struct {
+	Top int `json:"top"`
+}
+
+// DiskUsageResponse has the space and inodes used on the disks of the VM
+// and what uses it in the data and store directories and the job logs.
+// Directories that do not exist, like the store without a persistent disk,
+// are left out.
+type DiskUsageResponse struct {
+	Disks       boshvitals.DiskVitals      `json:"disks"`
+	Directories []diskusage.DirectoryUsage `json:"directories"`
+}
+
+// DiskUsageAction reports what fills up the disks of the VM, so full disks
+// can be looked into without ssh
+type DiskUsageAction struct {
+	analyzer      diskusage.Analyzer
+	vitalsService boshvitals.Service
+	fs            boshsys.FileSystem
+	dirProvider   boshdir.Provider
+
+	cancelSignals boshtask.CancelSignals
+}
+
+func NewDiskUsage(
+	analyzer diskusage.Analyzer,
+	vitalsService boshvitals.Service,
+	fs boshsys.FileSystem,
+	dirProvider boshdir.Provider,
+	cancelSignals boshtask.CancelSignals,
+) (action DiskUsageAction) {
+	action.analyzer = analyzer
+	action.vitalsService = vitalsService
+	action.fs = fs
+	action.dirProvider = dirProvider
+	action.cancelSignals = cancelSignals
+	return
+}
+
+func (a DiskUsageAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a DiskUsageAction) IsPersistent() bool {
+	return false
+}
+
+func (a DiskUsageAction) IsLoggable() bool {
+	return true
+}
+
+func (a DiskUsageAction) Run(request DiskUsageRequest) (DiskUsageResponse, error) {
+	cancel := a.cancelSignals.RunningCancelSignal()
+
+	top := request.Top
+	if top == 0 {
+		top = defaultDiskUsageTop
+	}
+
+	if top < 0 || top > maxDiskUsageTop {
+		return DiskUsageResponse{}, bosherr.Errorf("Reporting the %d largest subtrees, which is not between 1 and %d", request.Top, maxDiskUsageTop)
+	}
+
+	vitals, err := a.vitalsService.Get()
+	if err != nil {
+		return DiskUsageResponse{}, bosherr.WrapError(err, "Getting disk vitals")
+	}
+
+	response := DiskUsageResponse{
+		Disks:       vitals.Disk,
+		Directories: []diskusage.DirectoryUsage{},
+	}
+
+	for _, dir := range []string{a.dirProvider.DataDir(), a.dirProvider.StoreDir(), a.dirProvider.LogsDir()} {
+		if !a.fs.FileExists(dir) {
+			continue
+		}
+
+		usage, err := a.analyzer.Analyze(dir, top, cancel)
+		if err != nil {
+			return DiskUsageResponse{}, bosherr.WrapErrorf(err, "Analyzing disk usage of %s", dir)
+		}
+
+		response.Directories = append(response.Directories, usage)
+	}
+
+	return response, nil
+}
+
+func (a DiskUsageAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a DiskUsageAction) Cancel() error {
+	return nil
+}
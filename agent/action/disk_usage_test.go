@@ -0,0 +1,100 @@
+package action_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/diskusage"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/diskusage/diskusagefakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/vitals/vitalsfakes"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+var _ = Describe("DiskUsageAction", func() {
+	var (
+		analyzer      *diskusagefakes.FakeAnalyzer
+		vitalsService *vitalsfakes.FakeService
+		fs            *fakesys.FakeFileSystem
+		action        DiskUsageAction
+	)
+
+	BeforeEach(func() {
+		analyzer = &diskusagefakes.FakeAnalyzer{}
+		analyzer.AnalyzeStub = func(root string, top int, _ *boshtask.CancelSignal) (diskusage.DirectoryUsage, error) {
+			return diskusage.DirectoryUsage{Path: root, Bytes: 100}, nil
+		}
+
+		vitalsService = &vitalsfakes.FakeService{}
+		vitalsService.GetReturns(boshvitals.Vitals{
+			Disk: boshvitals.DiskVitals{"ephemeral": {Percent: "40", InodePercent: "10"}},
+		}, nil)
+
+		fs = fakesys.NewFakeFileSystem()
+		Expect(fs.MkdirAll("/var/vcap/data", 0755)).To(Succeed())
+		Expect(fs.MkdirAll("/var/vcap/sys/log", 0755)).To(Succeed())
+
+		action = NewDiskUsage(analyzer, vitalsService, fs, boshdir.NewProvider("/var/vcap"), faketask.NewFakeService())
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotPersistent(action)
+	AssertActionIsNotResumable(action)
+	AssertActionIsCancelable(action)
+
+	Describe("Run", func() {
+		It("reports the usage of the disks and of the directories that exist", func() {
+			response, err := action.Run(DiskUsageRequest{Top: 5})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(response).To(Equal(DiskUsageResponse{
+				Disks: boshvitals.DiskVitals{"ephemeral": {Percent: "40", InodePercent: "10"}},
+				Directories: []diskusage.DirectoryUsage{
+					{Path: "/var/vcap/data", Bytes: 100},
+					{Path: "/var/vcap/sys/log", Bytes: 100},
+				},
+			}))
+
+			Expect(analyzer.AnalyzeCallCount()).To(Equal(2))
+			_, top, _ := analyzer.AnalyzeArgsForCall(0)
+			Expect(top).To(Equal(5))
+		})
+
+		It("reports the 10 largest subtrees by default", func() {
+			_, err := action.Run(DiskUsageRequest{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, top, _ := analyzer.AnalyzeArgsForCall(0)
+			Expect(top).To(Equal(10))
+		})
+
+		It("does not report more than 100 subtrees", func() {
+			_, err := action.Run(DiskUsageRequest{Top: 101})
+			Expect(err).To(MatchError("Reporting the 101 largest subtrees, which is not between 1 and 100"))
+			Expect(analyzer.AnalyzeCallCount()).To(BeZero())
+		})
+
+		It("returns the error getting disk vitals", func() {
+			vitalsService.GetReturns(boshvitals.Vitals{}, errors.New("fake-vitals-err"))
+
+			_, err := action.Run(DiskUsageRequest{})
+			Expect(err).To(MatchError("Getting disk vitals: fake-vitals-err"))
+		})
+
+		It("returns the error analyzing a directory", func() {
+			analyzer.AnalyzeStub = nil
+			analyzer.AnalyzeReturns(diskusage.DirectoryUsage{}, errors.New("fake-analyze-err"))
+
+			_, err := action.Run(DiskUsageRequest{})
+			Expect(err).To(MatchError("Analyzing disk usage of /var/vcap/data: fake-analyze-err"))
+		})
+	})
+})
@@ -6,9 +6,11 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
 	boshdrain "github.com/cloudfoundry/bosh-agent/v2/agent/script/drain"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
 	boshnotif "github.com/cloudfoundry/bosh-agent/v2/notification"
 )
@@ -19,9 +21,9 @@ type DrainAction struct {
 	specService       boshas.V1Service
 	jobSupervisor     boshjobsuper.JobSupervisor
 
-	logTag   string
-	logger   boshlog.Logger
-	cancelCh chan struct{}
+	logTag        string
+	logger        boshlog.Logger
+	cancelSignals boshtask.CancelSignals
 }
 
 type DrainType string
@@ -38,6 +40,7 @@ func NewDrain(
 	jobScriptProvider boshscript.JobScriptProvider,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	logger boshlog.Logger,
+	cancelSignals boshtask.CancelSignals,
 ) DrainAction {
 	return DrainAction{
 		notifier:          notifier,
@@ -45,9 +48,9 @@ func NewDrain(
 		jobScriptProvider: jobScriptProvider,
 		jobSupervisor:     jobSupervisor,
 
-		logTag:   "Drain Action",
-		logger:   logger,
-		cancelCh: make(chan struct{}, 1),
+		logTag:        "Drain Action",
+		logger:        logger,
+		cancelSignals: cancelSignals,
 	}
 }
 
@@ -64,6 +67,8 @@ func (a DrainAction) IsLoggable() bool {
 }
 
 func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V1ApplySpec) (int, error) {
+	cancel := a.cancelSignals.RunningCancelSignal()
+
 	currentSpec, err := a.specService.Get()
 	if err != nil {
 		return 0, bosherr.WrapError(err, "Getting current spec")
@@ -82,24 +87,37 @@ func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V1ApplySpec) (i
 	}
 	// TODO write health.json
 
-	scripts := make([]boshscript.Script, 0, len(currentSpec.Jobs()))
-	for _, job := range currentSpec.Jobs() {
-		script := a.jobScriptProvider.NewDrainScript(job.BundleName(), params)
-		scripts = append(scripts, script)
+	levels, err := boshappl.DrainLevels(currentSpec.Jobs())
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Ordering jobs to drain")
 	}
 
-	script := a.jobScriptProvider.NewParallelScript("drain", scripts)
-
-	resultsCh := make(chan error, 1)
-	go func() { resultsCh <- script.Run() }()
-	select {
-	case result := <-resultsCh:
-		a.logger.Debug(a.logTag, "Got a result")
-		return 0, result
-	case <-a.cancelCh:
-		a.logger.Debug(a.logTag, "Got a cancel request")
-		return 0, script.Cancel()
+	// Jobs are drained in parallel unless they depend on each other, then
+	// they are drained before the jobs they depend on
+	for _, level := range levels {
+		scripts := make([]boshscript.Script, 0, len(level))
+		for _, job := range level {
+			script := a.jobScriptProvider.NewDrainScript(job.BundleName(), params, job.Drain)
+			scripts = append(scripts, script)
+		}
+
+		script := a.jobScriptProvider.NewParallelScript("drain", scripts)
+
+		resultsCh := make(chan error, 1)
+		go func() { resultsCh <- script.Run() }()
+		select {
+		case result := <-resultsCh:
+			a.logger.Debug(a.logTag, "Got a result")
+			if result != nil {
+				return 0, result
+			}
+		case <-cancel.Done():
+			a.logger.Debug(a.logTag, "Got a cancel request")
+			return 0, script.Cancel()
+		}
 	}
+
+	return 0, nil
 }
 
 func (a DrainAction) determineParams(drainType DrainType, currentSpec boshas.V1ApplySpec, newSpecs []boshas.V1ApplySpec) (boshdrain.ScriptParams, error) {
@@ -141,9 +159,5 @@ func (a DrainAction) Resume() (interface{}, error) {
 
 func (a DrainAction) Cancel() error {
 	a.logger.Debug(a.logTag, "Cancelling drain action")
-	select {
-	case a.cancelCh <- struct{}{}:
-	default:
-	}
 	return nil
 }
@@ -2,6 +2,7 @@ package action_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -12,9 +13,12 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
 	boshdrain "github.com/cloudfoundry/bosh-agent/v2/agent/script/drain"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/script/scriptfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
 	fakenotif "github.com/cloudfoundry/bosh-agent/v2/notification/fakes"
 )
@@ -28,6 +32,7 @@ var _ = Describe("DrainAction", func() {
 		jobSupervisor     *fakejobsuper.FakeJobSupervisor
 		drainAction       action.DrainAction
 		logger            boshlog.Logger
+		taskService       *faketask.FakeService
 	)
 
 	BeforeEach(func() {
@@ -37,11 +42,12 @@ var _ = Describe("DrainAction", func() {
 		specService = fakeas.NewFakeV1Service()
 		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
-		drainAction = action.NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger)
+		taskService = faketask.NewFakeService()
+		drainAction = action.NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger, taskService)
 	})
 
 	BeforeEach(func() {
-		jobScriptProvider.NewDrainScriptStub = func(jobName string, params boshdrain.ScriptParams) boshscript.CancellableScript {
+		jobScriptProvider.NewDrainScriptStub = func(jobName string, params boshdrain.ScriptParams, _ models.JobDrain) boshscript.CancellableScript {
 			_, exists := fakeScripts[jobName]
 			if !exists {
 				fakeScripts[jobName] = &scriptfakes.FakeCancellableScript{}
@@ -129,7 +135,7 @@ var _ = Describe("DrainAction", func() {
 							barScript := &scriptfakes.FakeCancellableScript{}
 							barScript.TagReturns("bar")
 
-							jobScriptProvider.NewDrainScriptStub = func(jobName string, params boshdrain.ScriptParams) boshscript.CancellableScript {
+							jobScriptProvider.NewDrainScriptStub = func(jobName string, params boshdrain.ScriptParams, _ models.JobDrain) boshscript.CancellableScript {
 								Expect(params).To(Equal(boshdrain.NewUpdateParams(currentSpec, newSpec)))
 
 								if jobName == "foo" { //nolint:staticcheck
@@ -163,6 +169,74 @@ var _ = Describe("DrainAction", func() {
 							Expect(err.Error()).To(ContainSubstring("fake-error"))
 							Expect(value).To(Equal(0))
 						})
+
+						Context("when jobs depend on each other", func() {
+							var fooScript, barScript *scriptfakes.FakeCancellableScript
+
+							BeforeEach(func() {
+								currentSpec.JobSpec.JobTemplateSpecs[0].DependsOn = []string{"bar"}
+								currentSpec.JobSpec.JobTemplateSpecs[0].Drain = &boshas.JobDrainSpec{MaxSeconds: 60, OnTimeout: "continue"}
+								specService.Spec = currentSpec
+
+								fooScript = &scriptfakes.FakeCancellableScript{}
+								barScript = &scriptfakes.FakeCancellableScript{}
+
+								jobScriptProvider.NewDrainScriptStub = func(jobName string, _ boshdrain.ScriptParams, _ models.JobDrain) boshscript.CancellableScript {
+									if jobName == "foo" {
+										return fooScript
+									}
+									return barScript
+								}
+							})
+
+							It("drains dependents before the jobs they depend on", func() {
+								value, err := act()
+								Expect(err).ToNot(HaveOccurred())
+								Expect(value).To(Equal(0))
+
+								Expect(jobScriptProvider.NewParallelScriptCallCount()).To(Equal(2))
+
+								_, scripts := jobScriptProvider.NewParallelScriptArgsForCall(0)
+								Expect(scripts).To(Equal([]boshscript.Script{fooScript}))
+
+								_, scripts = jobScriptProvider.NewParallelScriptArgsForCall(1)
+								Expect(scripts).To(Equal([]boshscript.Script{barScript}))
+
+								Expect(parallelScript.RunCallCount()).To(Equal(2))
+							})
+
+							It("creates drain scripts with the drain limits of their jobs", func() {
+								_, err := act()
+								Expect(err).ToNot(HaveOccurred())
+
+								jobName, _, limits := jobScriptProvider.NewDrainScriptArgsForCall(0)
+								Expect(jobName).To(Equal("foo"))
+								Expect(limits).To(Equal(models.JobDrain{MaxDuration: time.Minute, ContinueOnTimeout: true}))
+
+								jobName, _, limits = jobScriptProvider.NewDrainScriptArgsForCall(1)
+								Expect(jobName).To(Equal("bar"))
+								Expect(limits).To(Equal(models.JobDrain{}))
+							})
+
+							It("does not drain the jobs depended on when draining their dependents fails", func() {
+								parallelScript.RunReturns(errors.New("fake-error"))
+
+								_, err := act()
+								Expect(err).To(MatchError("fake-error"))
+
+								Expect(jobScriptProvider.NewParallelScriptCallCount()).To(Equal(1))
+							})
+
+							It("returns an error when jobs depend on each other in a cycle", func() {
+								currentSpec.JobSpec.JobTemplateSpecs[1].DependsOn = []string{"foo"}
+								specService.Spec = currentSpec
+
+								_, err := act()
+								Expect(err).To(MatchError(ContainSubstring("Ordering jobs to drain")))
+
+								Expect(jobScriptProvider.NewParallelScriptCallCount()).To(Equal(0))
+							})
+						})
 					})
 
 					Context("when apply spec is not provided", func() {
@@ -242,7 +316,7 @@ var _ = Describe("DrainAction", func() {
 							barScript := &scriptfakes.FakeCancellableScript{}
 							barScript.TagReturns("bar")
 
-							jobScriptProvider.NewDrainScriptStub = func(jobName string, params boshdrain.ScriptParams) boshscript.CancellableScript {
+							jobScriptProvider.NewDrainScriptStub = func(jobName string, params boshdrain.ScriptParams, _ models.JobDrain) boshscript.CancellableScript {
 								Expect(params).To(Equal(boshdrain.NewShutdownParams(currentSpec, nil)))
 
 								if jobName == "foo" { //nolint:staticcheck
@@ -352,7 +426,7 @@ var _ = Describe("DrainAction", func() {
 
 		BeforeEach(func() {
 			parallelScript = &scriptfakes.FakeCancellableScript{}
-			jobScriptProvider.NewDrainScriptStub = func(jobName string, params boshdrain.ScriptParams) boshscript.CancellableScript {
+			jobScriptProvider.NewDrainScriptStub = func(jobName string, params boshdrain.ScriptParams, _ models.JobDrain) boshscript.CancellableScript {
 				return &scriptfakes.FakeCancellableScript{}
 			}
 			jobScriptProvider.NewParallelScriptReturns(parallelScript)
@@ -369,5 +443,32 @@ var _ = Describe("DrainAction", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 		})
+
+		Context("when the task is cancelled while draining", func() {
+			It("cancels the running drain scripts", func() {
+				specService.Spec = boshas.V1ApplySpec{
+					JobSpec: boshas.JobSpec{
+						Template:         "foo",
+						JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "foo"}},
+					},
+					RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{},
+				}
+
+				drained := make(chan struct{})
+				defer close(drained)
+				parallelScript.RunStub = func() error {
+					<-drained
+					return nil
+				}
+
+				cancelCh := make(chan struct{})
+				close(cancelCh)
+				taskService.RunningCancelSignalResult = boshtask.NewCancelSignal(cancelCh)
+
+				_, err := drainAction.Run(action.DrainTypeShutdown, newSpec)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parallelScript.CancelCallCount()).To(Equal(1))
+			})
+		})
 	})
 })
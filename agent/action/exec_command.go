@@ -0,0 +1,269 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+const (
+	execCommandActionLogTag = "execCommandAction"
+
+	defaultExecCommandTimeout = time.Minute
+	maxExecCommandTimeout     = 10 * time.Minute
+
+	// Keeps the result of the task well below the default NATS payload limit
+	maxExecCommandOutputBytes = 64 * 1024
+)
+
+// ExecCommandRequest names an allowed command and its arguments. Commands
+// still running after TimeoutSeconds are terminated.
+type ExecCommandRequest struct {
+	Command        string   `json:"command"`
+	Args           []string `json:"args"`
+	TimeoutSeconds int      `json:"timeout_seconds"`
+}
+
+// ExecCommandResult holds at most the first 64KiB of each output
+type ExecCommandResult struct {
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	StdoutTruncated bool   `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool   `json:"stderr_truncated,omitempty"`
+	ExitStatus      int    `json:"exit_code"`
+}
+
+// ExecCommandAction runs commands the operator allowed in the agent settings,
+// as a narrower alternative to ssh for routine diagnostics. Every attempt is
+// recorded in the audit log, including rejected ones.
+type ExecCommandAction struct {
+	settingsService boshsettings.Service
+	cmdRunner       boshsys.CmdRunner
+	auditLog        audit.Log
+	timeService     clock.Clock
+	logger          boshlog.Logger
+
+	cancelSignals boshtask.CancelSignals
+}
+
+func NewExecCommand(
+	settingsService boshsettings.Service,
+	cmdRunner boshsys.CmdRunner,
+	auditLog audit.Log,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+	cancelSignals boshtask.CancelSignals,
+) (action ExecCommandAction) {
+	action.settingsService = settingsService
+	action.cmdRunner = cmdRunner
+	action.auditLog = auditLog
+	action.timeService = timeService
+	action.logger = logger
+	action.cancelSignals = cancelSignals
+	return
+}
+
+func (a ExecCommandAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a ExecCommandAction) IsPersistent() bool {
+	return false
+}
+
+func (a ExecCommandAction) IsLoggable() bool {
+	return true
+}
+
+func (a ExecCommandAction) Run(request ExecCommandRequest) (ExecCommandResult, error) {
+	event := audit.Event{
+		Operation: audit.OperationExecCommand,
+		Name:      request.Command,
+		Args:      request.Args,
+	}
+
+	result, err := a.run(request, &event)
+	if err != nil {
+		event.Error = err.Error()
+	} else if result.ExitStatus != 0 {
+		event.Error = fmt.Sprintf("Command exited with %d", result.ExitStatus)
+	}
+
+	a.auditLog.Record(event)
+
+	return result, err
+}
+
+func (a ExecCommandAction) run(request ExecCommandRequest, event *audit.Event) (ExecCommandResult, error) {
+	timeout := defaultExecCommandTimeout
+	if request.TimeoutSeconds != 0 {
+		timeout = time.Duration(request.TimeoutSeconds) * time.Second
+		if request.TimeoutSeconds < 0 || timeout > maxExecCommandTimeout {
+			return ExecCommandResult{}, bosherr.Errorf("Command timeout %ds is not between 1s and %s", request.TimeoutSeconds, maxExecCommandTimeout)
+		}
+	}
+
+	path, err := a.allowedPath(request)
+	if err != nil {
+		return ExecCommandResult{}, err
+	}
+
+	event.Path = path
+
+	stdout := &cappedBuffer{limit: maxExecCommandOutputBytes}
+	stderr := &cappedBuffer{limit: maxExecCommandOutputBytes}
+
+	process, err := a.cmdRunner.RunComplexCommandAsync(boshsys.Command{
+		Name:   path,
+		Args:   request.Args,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		return ExecCommandResult{}, bosherr.WrapErrorf(err, "Running command '%s'", request.Command)
+	}
+
+	timer := a.timeService.NewTimer(timeout)
+	defer timer.Stop()
+
+	var (
+		processResult boshsys.Result
+		stopErr       error
+	)
+
+	cancelCh := a.cancelSignals.RunningCancelSignal().Done()
+
+	// Can only wait once on a process but cancelling can happen multiple times
+	for processExitedCh := process.Wait(); processExitedCh != nil; {
+		select {
+		case processResult = <-processExitedCh:
+			processExitedCh = nil
+			continue
+		case <-cancelCh:
+			// A closed channel stays ready, stop listening
+			cancelCh = nil
+			if stopErr != nil {
+				continue
+			}
+			stopErr = boshtask.ErrCancelled
+		case <-timer.C():
+			if stopErr != nil {
+				continue
+			}
+			stopErr = bosherr.Errorf("Command '%s' timed out after %s", request.Command, timeout)
+		}
+
+		err = process.TerminateNicely(10 * time.Second)
+		if err != nil {
+			a.logger.Error(execCommandActionLogTag, "Failed to terminate command '%s': %s", request.Command, err.Error())
+		}
+	}
+
+	if stopErr != nil {
+		return ExecCommandResult{}, stopErr
+	}
+
+	if processResult.Error != nil && processResult.ExitStatus == -1 {
+		return ExecCommandResult{}, bosherr.WrapErrorf(processResult.Error, "Running command '%s'", request.Command)
+	}
+
+	return ExecCommandResult{
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		StdoutTruncated: stdout.truncated,
+		StderrTruncated: stderr.truncated,
+		ExitStatus:      processResult.ExitStatus,
+	}, nil
+}
+
+// allowedPath returns the executable of the first allowed command with the
+// requested name whose argument patterns the arguments match
+func (a ExecCommandAction) allowedPath(request ExecCommandRequest) (string, error) {
+	allowed := a.settingsService.GetSettings().Env.Bosh.Agent.Settings.ExecCommands
+
+	found := false
+
+	for _, command := range allowed {
+		if command.Name != request.Command {
+			continue
+		}
+
+		found = true
+
+		matches, err := argsMatch(command.Args, request.Args)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Matching arguments of command '%s'", request.Command)
+		}
+
+		if matches {
+			return command.Path, nil
+		}
+	}
+
+	if !found {
+		return "", bosherr.Errorf("Command '%s' is not allowed", request.Command)
+	}
+
+	return "", bosherr.Errorf("Arguments of command '%s' are not allowed", request.Command)
+}
+
+func argsMatch(patterns []string, args []string) (bool, error) {
+	if len(args) > len(patterns) {
+		return false, nil
+	}
+
+	for i, arg := range args {
+		pattern, err := regexp.Compile("^(?:" + patterns[i] + ")$")
+		if err != nil {
+			return false, err
+		}
+
+		if !pattern.MatchString(arg) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (a ExecCommandAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ExecCommandAction) Cancel() error {
+	return nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and drops the rest
+// without failing the writer, so the command is not stopped by a full pipe
+type cappedBuffer struct {
+	limit     int
+	buf       []byte
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	room := b.limit - len(b.buf)
+	if len(p) > room {
+		b.buf = append(b.buf, p[:room]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	return string(b.buf)
+}
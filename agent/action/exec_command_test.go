@@ -0,0 +1,209 @@
+package action_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit/auditfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+// outputCmdRunner writes output to the commands it starts, which the fake
+// runner only does for synchronous commands
+type outputCmdRunner struct {
+	*fakesys.FakeCmdRunner
+
+	stdout string
+	stderr string
+}
+
+func (r *outputCmdRunner) RunComplexCommandAsync(cmd boshsys.Command) (boshsys.Process, error) {
+	cmd.Stdout.Write([]byte(r.stdout)) //nolint:errcheck
+	cmd.Stderr.Write([]byte(r.stderr)) //nolint:errcheck
+	return r.FakeCmdRunner.RunComplexCommandAsync(cmd)
+}
+
+var _ = Describe("ExecCommand", func() {
+	var (
+		settingsService   *fakesettings.FakeSettingsService
+		cmdRunner         *fakesys.FakeCmdRunner
+		runner            *outputCmdRunner
+		auditLog          *auditfakes.FakeLog
+		timeService       *fakeclock.FakeClock
+		taskService       *faketask.FakeService
+		execCommandAction action.ExecCommandAction
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		settingsService.Settings.Env.Bosh.Agent.Settings.ExecCommands = []boshsettings.ExecCommand{
+			{Name: "df", Path: "/bin/df", Args: []string{"-h|-i", "/var/vcap/.*"}},
+			{Name: "ss", Path: "/usr/bin/ss", Args: []string{"-tlnp"}},
+		}
+
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		runner = &outputCmdRunner{FakeCmdRunner: cmdRunner, stdout: "fake-stdout", stderr: "fake-stderr"}
+		auditLog = &auditfakes.FakeLog{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		taskService = faketask.NewFakeService()
+	})
+
+	JustBeforeEach(func() {
+		execCommandAction = action.NewExecCommand(settingsService, runner, auditLog, timeService, boshlog.NewLogger(boshlog.LevelNone), taskService)
+	})
+
+	AssertActionIsAsynchronous(action.ExecCommandAction{})
+	AssertActionIsNotPersistent(action.ExecCommandAction{})
+	AssertActionIsLoggable(action.ExecCommandAction{})
+
+	AssertActionIsNotResumable(action.ExecCommandAction{})
+	AssertActionIsCancelable(action.NewExecCommand(nil, nil, nil, nil, nil, nil))
+
+	It("runs an allowed command with its output and exit code", func() {
+		cmdRunner.AddProcess("/bin/df -h /var/vcap/data", &fakesys.FakeProcess{
+			WaitResult: boshsys.Result{ExitStatus: 3},
+		})
+
+		result, err := execCommandAction.Run(action.ExecCommandRequest{Command: "df", Args: []string{"-h", "/var/vcap/data"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(action.ExecCommandResult{
+			Stdout:     "fake-stdout",
+			Stderr:     "fake-stderr",
+			ExitStatus: 3,
+		}))
+
+		Expect(auditLog.RecordCallCount()).To(Equal(1))
+		Expect(auditLog.RecordArgsForCall(0)).To(Equal(audit.Event{
+			Operation: audit.OperationExecCommand,
+			Name:      "df",
+			Path:      "/bin/df",
+			Args:      []string{"-h", "/var/vcap/data"},
+			Error:     "Command exited with 3",
+		}))
+	})
+
+	It("allows fewer arguments than patterns", func() {
+		cmdRunner.AddProcess("/bin/df", &fakesys.FakeProcess{})
+
+		_, err := execCommandAction.Run(action.ExecCommandRequest{Command: "df"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(auditLog.RecordArgsForCall(0).Error).To(BeEmpty())
+	})
+
+	It("truncates long output", func() {
+		runner.stdout = strings.Repeat("o", 64*1024+1)
+		cmdRunner.AddProcess("/usr/bin/ss -tlnp", &fakesys.FakeProcess{})
+
+		result, err := execCommandAction.Run(action.ExecCommandRequest{Command: "ss", Args: []string{"-tlnp"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Stdout).To(HaveLen(64 * 1024))
+		Expect(result.StdoutTruncated).To(BeTrue())
+		Expect(result.StderrTruncated).To(BeFalse())
+	})
+
+	It("rejects commands that are not allowed", func() {
+		_, err := execCommandAction.Run(action.ExecCommandRequest{Command: "rm", Args: []string{"-rf", "/"}})
+		Expect(err).To(MatchError("Command 'rm' is not allowed"))
+		Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+
+		Expect(auditLog.RecordArgsForCall(0)).To(Equal(audit.Event{
+			Operation: audit.OperationExecCommand,
+			Name:      "rm",
+			Args:      []string{"-rf", "/"},
+			Error:     "Command 'rm' is not allowed",
+		}))
+	})
+
+	It("rejects arguments that do not fully match their patterns", func() {
+		_, err := execCommandAction.Run(action.ExecCommandRequest{Command: "df", Args: []string{"-hT", "/var/vcap/data"}})
+		Expect(err).To(MatchError("Arguments of command 'df' are not allowed"))
+
+		_, err = execCommandAction.Run(action.ExecCommandRequest{Command: "df", Args: []string{"-h", "/etc"}})
+		Expect(err).To(MatchError("Arguments of command 'df' are not allowed"))
+
+		_, err = execCommandAction.Run(action.ExecCommandRequest{Command: "ss", Args: []string{"-tlnp", "extra"}})
+		Expect(err).To(MatchError("Arguments of command 'ss' are not allowed"))
+
+		Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+	})
+
+	It("returns an error for invalid argument patterns", func() {
+		settingsService.Settings.Env.Bosh.Agent.Settings.ExecCommands = []boshsettings.ExecCommand{
+			{Name: "df", Path: "/bin/df", Args: []string{"("}},
+		}
+
+		_, err := execCommandAction.Run(action.ExecCommandRequest{Command: "df", Args: []string{"-h"}})
+		Expect(err).To(MatchError(ContainSubstring("Matching arguments of command 'df'")))
+	})
+
+	It("rejects timeouts longer than 10 minutes", func() {
+		_, err := execCommandAction.Run(action.ExecCommandRequest{Command: "df", TimeoutSeconds: 601})
+		Expect(err).To(MatchError("Command timeout 601s is not between 1s and 10m0s"))
+	})
+
+	It("returns an error when the command cannot be run", func() {
+		cmdRunner.AddProcess("/bin/df", &fakesys.FakeProcess{
+			WaitResult: boshsys.Result{ExitStatus: -1, Error: errors.New("fake-run-err")},
+		})
+
+		_, err := execCommandAction.Run(action.ExecCommandRequest{Command: "df"})
+		Expect(err).To(MatchError("Running command 'df': fake-run-err"))
+		Expect(auditLog.RecordArgsForCall(0).Error).To(Equal("Running command 'df': fake-run-err"))
+	})
+
+	Context("when the command keeps running", func() {
+		var process *fakesys.FakeProcess
+
+		BeforeEach(func() {
+			process = &fakesys.FakeProcess{
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{ExitStatus: 143}
+				},
+			}
+			cmdRunner.AddProcess("/bin/df", process)
+		})
+
+		It("terminates the command after the timeout", func() {
+			errCh := make(chan error, 1)
+			go func() {
+				_, err := execCommandAction.Run(action.ExecCommandRequest{Command: "df", TimeoutSeconds: 5})
+				errCh <- err
+			}()
+
+			timeService.WaitForWatcherAndIncrement(5 * time.Second)
+
+			Eventually(errCh).Should(Receive(MatchError("Command 'df' timed out after 5s")))
+			Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+		})
+
+		It("terminates the command when the task is cancelled", func() {
+			cancelCh := make(chan struct{})
+			taskService.RunningCancelSignalResult = boshtask.NewCancelSignal(cancelCh)
+
+			errCh := make(chan error, 1)
+			go func() {
+				_, err := execCommandAction.Run(action.ExecCommandRequest{Command: "df"})
+				errCh <- err
+			}()
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			close(cancelCh)
+
+			Eventually(errCh).Should(Receive(Equal(boshtask.ErrCancelled)))
+			Expect(process.TerminatedNicely).To(BeTrue())
+		})
+	})
+})
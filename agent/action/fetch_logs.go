@@ -2,25 +2,48 @@ package action
 
 import (
 	"errors"
+	"time"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	blobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
+// FetchLogsOptions are the optional settings of a fetch_logs request.
+// ContentEncoding compresses the logs with the given encoding on upload
+// instead of uploading a gzipped tarball. Exclude globs and the Since and
+// Until time window narrow down the logs the filters include.
+type FetchLogsOptions struct {
+	ContentEncoding string    `json:"content_encoding"`
+	Exclude         []string  `json:"exclude"`
+	Since           time.Time `json:"since"`
+	Until           time.Time `json:"until"`
+}
+
+// FetchLogsAction always stages the logs tarball on disk since the agent's
+// blobstore clients upload files. Only fetch_logs_with_signed_url streams.
 type FetchLogsAction struct {
 	logsTarProvider logstarprovider.LogsTarProvider
 	blobstore       blobdelegator.BlobstoreDelegator
+	fs              boshsys.FileSystem
+
+	cancelSignals boshtask.CancelSignals
 }
 
 func NewFetchLogs(
 	logsTarProvider logstarprovider.LogsTarProvider,
 	blobstore blobdelegator.BlobstoreDelegator,
+	fs boshsys.FileSystem,
+	cancelSignals boshtask.CancelSignals,
 ) (action FetchLogsAction) {
 	action.logsTarProvider = logsTarProvider
 	action.blobstore = blobstore
+	action.fs = fs
+	action.cancelSignals = cancelSignals
 	return
 }
 
@@ -36,8 +59,20 @@ func (a FetchLogsAction) IsLoggable() bool {
 	return true
 }
 
-func (a FetchLogsAction) Run(logTypes string, filters []string) (value map[string]string, err error) {
-	tarball, err := a.logsTarProvider.Get(logTypes, filters)
+func (a FetchLogsAction) Run(logTypes string, filters []string, options ...FetchLogsOptions) (value map[string]string, err error) {
+	cancel := a.cancelSignals.RunningCancelSignal()
+
+	var encoding string
+	selection := logstarprovider.Selection{Include: filters}
+
+	if len(options) > 0 {
+		encoding = options[0].ContentEncoding
+		selection.Exclude = options[0].Exclude
+		selection.Since = options[0].Since
+		selection.Until = options[0].Until
+	}
+
+	tarball, err := getLogsTarball(a.logsTarProvider, logTypes, selection, encoding)
 	if err != nil {
 		return
 	}
@@ -46,19 +81,40 @@ func (a FetchLogsAction) Run(logTypes string, filters []string) (value map[strin
 		_ = a.logsTarProvider.CleanUp(tarball) //nolint:errcheck
 	}()
 
-	blobID, multidigestSha, err := a.blobstore.Write("", tarball, nil)
+	// Collecting the logs cannot be interrupted, but uploading them can be
+	// skipped
+	err = cancel.Err()
+	if err != nil {
+		return
+	}
+
+	blobID, multidigestSha, err := blobdelegator.WriteEncoded(a.blobstore, a.fs, "", tarball, nil, encoding)
 	if err != nil {
 		return value, bosherr.WrapError(err, "Create file on blobstore")
 	}
 
 	value = map[string]string{"blobstore_id": blobID, "sha1": multidigestSha.String()}
+	if encoding != "" {
+		value["content_encoding"] = encoding
+	}
+
 	return value, nil
 }
 
+// getLogsTarball skips compressing the tarball when it is going to be
+// compressed with a content encoding on upload.
+func getLogsTarball(provider logstarprovider.LogsTarProvider, logTypes string, selection logstarprovider.Selection, encoding string) (string, error) {
+	if encoding != "" {
+		return provider.GetUncompressed(logTypes, selection)
+	}
+
+	return provider.Get(logTypes, selection)
+}
+
 func (a FetchLogsAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
 
 func (a FetchLogsAction) Cancel() error {
-	return errors.New("not supported")
+	return nil
 }
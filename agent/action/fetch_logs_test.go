@@ -2,12 +2,18 @@ package action_test
 
 import (
 	"errors"
+	"os"
+	"time"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
 	fakelogstarprovider "github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider/logstarproviderfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -19,6 +25,8 @@ var _ = Describe("FetchLogsAction", func() {
 	var (
 		blobstore       *fakeblobdelegator.FakeBlobstoreDelegator
 		logsTarProvider *fakelogstarprovider.FakeLogsTarProvider
+		fs              *fakesys.FakeFileSystem
+		taskService     *faketask.FakeService
 
 		action FetchLogsAction
 	)
@@ -26,8 +34,10 @@ var _ = Describe("FetchLogsAction", func() {
 	BeforeEach(func() {
 		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
 		logsTarProvider = &fakelogstarprovider.FakeLogsTarProvider{}
+		fs = fakesys.NewFakeFileSystem()
+		taskService = faketask.NewFakeService()
 
-		action = NewFetchLogs(logsTarProvider, blobstore)
+		action = NewFetchLogs(logsTarProvider, blobstore, fs, taskService)
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -35,7 +45,7 @@ var _ = Describe("FetchLogsAction", func() {
 
 	AssertActionIsNotPersistent(action)
 	AssertActionIsNotResumable(action)
-	AssertActionIsNotCancelable(action)
+	AssertActionIsCancelable(action)
 
 	Describe("Run", func() {
 		It("logs error if logstarprovider returns one", func() {
@@ -48,9 +58,25 @@ var _ = Describe("FetchLogsAction", func() {
 			_, err := action.Run("job", []string{"foo", "bar"})
 			Expect(err).ToNot(HaveOccurred())
 
-			logType, filters := logsTarProvider.GetArgsForCall(0)
+			logType, selection := logsTarProvider.GetArgsForCall(0)
 			Expect(logType).To(Equal("job"))
-			Expect(filters).To(Equal([]string{"foo", "bar"}))
+			Expect(selection.Include).To(Equal([]string{"foo", "bar"}))
+		})
+
+		It("narrows down the logs with the exclude globs and the time window", func() {
+			since := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+			until := since.Add(time.Hour)
+
+			_, err := action.Run("job", []string{"foo/**"}, FetchLogsOptions{Exclude: []string{"foo/*.gz"}, Since: since, Until: until})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, selection := logsTarProvider.GetArgsForCall(0)
+			Expect(selection).To(Equal(logstarprovider.Selection{
+				Include: []string{"foo/**"},
+				Exclude: []string{"foo/*.gz"},
+				Since:   since,
+				Until:   until,
+			}))
 		})
 
 		It("returns the expected log blob", func() {
@@ -87,5 +113,66 @@ var _ = Describe("FetchLogsAction", func() {
 			Expect(logsTarProvider.CleanUpCallCount()).To(Equal(1))
 			Expect(logsTarProvider.CleanUpArgsForCall(0)).To(Equal("/tmp/logs.tar"))
 		})
+
+		It("cleans up the logs without uploading them when cancelled while collecting them", func() {
+			cancelCh := make(chan struct{})
+			taskService.RunningCancelSignalResult = boshtask.NewCancelSignal(cancelCh)
+
+			logsTarProvider.GetStub = func(string, logstarprovider.Selection) (string, error) {
+				close(cancelCh)
+				return "/tmp/logs.tar", nil
+			}
+
+			_, err := action.Run("job", []string{})
+			Expect(err).To(MatchError("task was cancelled"))
+
+			Expect(blobstore.WriteCallCount()).To(BeZero())
+			Expect(logsTarProvider.CleanUpCallCount()).To(Equal(1))
+			Expect(logsTarProvider.CleanUpArgsForCall(0)).To(Equal("/tmp/logs.tar"))
+		})
+
+		Context("when a content encoding is requested", func() {
+			BeforeEach(func() {
+				logsTarProvider.GetUncompressedReturns("/tmp/logs.tar", nil)
+				Expect(fs.WriteFileString("/tmp/logs.tar", "fake-logs")).To(Succeed())
+
+				encodedFile, err := fs.OpenFile("/tmp/encoded-logs", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+				Expect(err).ToNot(HaveOccurred())
+				fs.ReturnTempFile = encodedFile
+			})
+
+			It("uploads the gzipped uncompressed tarball and records the encoding", func() {
+				var uploadedPath string
+				blobstore.WriteStub = func(_, path string, _ map[string]string) (string, boshcrypto.MultipleDigest, error) {
+					uploadedPath = path
+					return "my-blob-id", boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil
+				}
+
+				logsBlob, err := action.Run("job", []string{}, FetchLogsOptions{ContentEncoding: "gzip"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(logsBlob).To(Equal(map[string]string{"blobstore_id": "my-blob-id", "sha1": "fake-sha1", "content_encoding": "gzip"}))
+
+				Expect(logsTarProvider.GetCallCount()).To(BeZero())
+				Expect(uploadedPath).ToNot(Equal("/tmp/logs.tar"))
+				Expect(fs.FileExists(uploadedPath)).To(BeFalse())
+			})
+
+			It("uploads the uncompressed tarball encoded with zstd", func() {
+				blobstore.WriteReturns("my-blob-id", boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil)
+
+				logsBlob, err := action.Run("job", []string{}, FetchLogsOptions{ContentEncoding: "zstd"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(logsBlob).To(Equal(map[string]string{"blobstore_id": "my-blob-id", "sha1": "fake-sha1", "content_encoding": "zstd"}))
+
+				Expect(logsTarProvider.GetCallCount()).To(BeZero())
+				Expect(logsTarProvider.GetUncompressedCallCount()).To(Equal(1))
+			})
+
+			It("returns an error for an unsupported encoding", func() {
+				_, err := action.Run("job", []string{}, FetchLogsOptions{ContentEncoding: "brotli"})
+				Expect(err).To(MatchError(ContainSubstring("Unsupported content encoding 'brotli'")))
+				Expect(blobstore.WriteCallCount()).To(BeZero())
+			})
+		})
 	})
 })
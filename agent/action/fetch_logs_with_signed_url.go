@@ -2,35 +2,57 @@ package action
 
 import (
 	"errors"
+	"io"
+	"sync"
+	"time"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
 
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
 	blobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
 )
 
+const fetchLogsWithSignedURLActionLogTag = "fetchLogsWithSignedURLAction"
+
 type FetchLogsWithSignedURLRequest struct {
 	SignedURL        string            `json:"signed_url"`
 	LogType          string            `json:"log_type"`
 	Filters          []string          `json:"filters"`
 	BlobstoreHeaders map[string]string `json:"blobstore_headers"`
+	ContentEncoding  string            `json:"content_encoding"`
+
+	// Exclude and the time window narrow down the logs Filters include
+	Exclude []string  `json:"exclude"`
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
 }
 
 type FetchLogsWithSignedURLResponse struct {
-	SHA1Digest string `json:"sha1"`
+	SHA1Digest      string `json:"sha1"`
+	ContentEncoding string `json:"content_encoding,omitempty"`
 }
 
 type FetchLogsWithSignedURLAction struct {
 	logsTarProvider logstarprovider.LogsTarProvider
 	blobDelegator   blobdelegator.BlobstoreDelegator
+	fs              boshsys.FileSystem
+	logger          boshlog.Logger
 }
 
 func NewFetchLogsWithSignedURLAction(
 	logsTarProvider logstarprovider.LogsTarProvider,
-	blobDelegator blobdelegator.BlobstoreDelegator) (action FetchLogsWithSignedURLAction) {
+	blobDelegator blobdelegator.BlobstoreDelegator,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger) (action FetchLogsWithSignedURLAction) {
 	action.logsTarProvider = logsTarProvider
 	action.blobDelegator = blobDelegator
+	action.fs = fs
+	action.logger = logger
 	return
 }
 
@@ -47,7 +69,34 @@ func (a FetchLogsWithSignedURLAction) IsLoggable() bool {
 }
 
 func (a FetchLogsWithSignedURLAction) Run(request FetchLogsWithSignedURLRequest) (FetchLogsWithSignedURLResponse, error) {
-	tarball, err := a.logsTarProvider.Get(request.LogType, request.Filters)
+	selection := logstarprovider.Selection{
+		Include: request.Filters,
+		Exclude: request.Exclude,
+		Since:   request.Since,
+		Until:   request.Until,
+	}
+
+	// A gzipped tarball is the same whether the encoding is requested or
+	// not. Other encodings compress the staged uncompressed tarball.
+	if request.ContentEncoding == "" || request.ContentEncoding == blobdelegator.ContentEncodingGzip {
+		digest, err := a.streamLogs(request, selection)
+		if err == nil {
+			return FetchLogsWithSignedURLResponse{
+				SHA1Digest:      digest.String(),
+				ContentEncoding: request.ContentEncoding,
+			}, nil
+		}
+
+		if !httpblobprovider.IsStreamingRejected(err) {
+			return FetchLogsWithSignedURLResponse{}, bosherr.WrapError(err, "Streaming logs to blobstore")
+		}
+
+		a.logger.Warn(fetchLogsWithSignedURLActionLogTag, "Blobstore rejected streaming the logs, staging them on disk instead: %s", err.Error())
+	}
+
+	// Blobstores that need to know the size up front, e.g. S3 and Azure,
+	// get a staged tarball
+	tarball, err := getLogsTarball(a.logsTarProvider, request.LogType, selection, request.ContentEncoding)
 	if err != nil {
 		return FetchLogsWithSignedURLResponse{}, err
 	}
@@ -56,16 +105,72 @@ func (a FetchLogsWithSignedURLAction) Run(request FetchLogsWithSignedURLRequest)
 		_ = a.logsTarProvider.CleanUp(tarball) //nolint:errcheck
 	}()
 
-	_, digest, err := a.blobDelegator.Write(request.SignedURL, tarball, request.BlobstoreHeaders)
+	_, digest, err := blobdelegator.WriteEncoded(a.blobDelegator, a.fs, request.SignedURL, tarball, request.BlobstoreHeaders, request.ContentEncoding)
 	if err != nil {
 		return FetchLogsWithSignedURLResponse{}, bosherr.WrapError(err, "Create file on blobstore")
 	}
 
 	return FetchLogsWithSignedURLResponse{
-		SHA1Digest: digest.String(),
+		SHA1Digest:      digest.String(),
+		ContentEncoding: request.ContentEncoding,
 	}, nil
 }
 
+// streamLogs uploads the logs while they are compressed so nothing is
+// staged on disks that may be nearly full. Failing to read the logs is
+// reported instead of the failed upload it causes.
+func (a FetchLogsWithSignedURLAction) streamLogs(request FetchLogsWithSignedURLRequest, selection logstarprovider.Selection) (boshcrypto.MultipleDigest, error) {
+	var (
+		lock      sync.Mutex
+		streamErr error
+	)
+
+	open := func() (io.ReadCloser, error) {
+		reader, writer := io.Pipe()
+
+		go func() {
+			uploadWriter := &uploadPipeWriter{writer: writer}
+
+			err := a.logsTarProvider.Stream(request.LogType, selection, uploadWriter)
+			if err != nil && !uploadWriter.abandoned {
+				lock.Lock()
+				streamErr = err
+				lock.Unlock()
+			}
+			writer.CloseWithError(err) //nolint:errcheck
+		}()
+
+		return reader, nil
+	}
+
+	digest, err := a.blobDelegator.WriteStream(request.SignedURL, open, request.BlobstoreHeaders)
+	if err != nil {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if streamErr != nil {
+			return boshcrypto.MultipleDigest{}, streamErr
+		}
+	}
+
+	return digest, err
+}
+
+// uploadPipeWriter tells failures to read the logs apart from the upload
+// abandoning the stream
+type uploadPipeWriter struct {
+	writer    *io.PipeWriter
+	abandoned bool
+}
+
+func (w *uploadPipeWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	if err != nil {
+		w.abandoned = true
+	}
+	return n, err
+}
+
 func (a FetchLogsWithSignedURLAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
@@ -2,23 +2,32 @@ package action_test
 
 import (
 	"errors"
+	"io"
+	"net/http"
+	"os"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
 	fakelogstarprovider "github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider/logstarproviderfakes"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
 	boshaction "github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
 )
 
 var _ = Describe("FetchLogsWithSignedURLAction", func() {
 	var (
 		blobstore       *fakeblobdelegator.FakeBlobstoreDelegator
 		logsTarProvider *fakelogstarprovider.FakeLogsTarProvider
+		fs              *fakesys.FakeFileSystem
+		logger          *fakelogger.FakeLogger
 
 		action boshaction.FetchLogsWithSignedURLAction
 	)
@@ -26,8 +35,10 @@ var _ = Describe("FetchLogsWithSignedURLAction", func() {
 	BeforeEach(func() {
 		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
 		logsTarProvider = &fakelogstarprovider.FakeLogsTarProvider{}
+		fs = fakesys.NewFakeFileSystem()
+		logger = &fakelogger.FakeLogger{}
 
-		action = boshaction.NewFetchLogsWithSignedURLAction(logsTarProvider, blobstore)
+		action = boshaction.NewFetchLogsWithSignedURLAction(logsTarProvider, blobstore, fs, logger)
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -38,79 +49,221 @@ var _ = Describe("FetchLogsWithSignedURLAction", func() {
 	AssertActionIsNotCancelable(action)
 
 	Describe("Run", func() {
-		It("logs error if logstarprovider returns one", func() {
-			logsTarProvider.GetReturns("", errors.New("uh-oh"))
-			_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
-				SignedURL:        "foobar",
-				LogType:          "other-logs",
-				Filters:          []string{},
-				BlobstoreHeaders: map[string]string{},
-			})
-			Expect(err).To(MatchError("uh-oh"))
+		var streamed []byte
+
+		BeforeEach(func() {
+			streamed = nil
+			logsTarProvider.StreamStub = func(_ string, _ logstarprovider.Selection, w io.Writer) error {
+				_, err := w.Write([]byte("fake-logs-tgz"))
+				return err
+			}
+			blobstore.WriteStreamStub = func(_ string, open httpblobprovider.OpenStream, _ map[string]string) (boshcrypto.MultipleDigest, error) {
+				stream, err := open()
+				Expect(err).ToNot(HaveOccurred())
+				defer stream.Close() //nolint:errcheck
+
+				streamed, err = io.ReadAll(stream)
+				if err != nil {
+					return boshcrypto.MultipleDigest{}, err
+				}
+				return boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil
+			}
 		})
 
-		It("invokes logstarprovider properly", func() {
-			_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
+		It("streams the selected logs to the signed URL without staging a tarball", func() {
+			logsBlob, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
 				SignedURL:        "foobar",
 				LogType:          "job",
-				Filters:          []string{"foo", "bar"},
-				BlobstoreHeaders: map[string]string{},
+				Filters:          []string{"foo"},
+				Exclude:          []string{"foo/*.gz"},
+				BlobstoreHeaders: map[string]string{"key": "value"},
 			})
 			Expect(err).ToNot(HaveOccurred())
+			boshassert.MatchesJSONString(GinkgoT(), logsBlob, `{"sha1":"fake-sha1"}`)
 
-			logType, filters := logsTarProvider.GetArgsForCall(0)
+			Expect(streamed).To(Equal([]byte("fake-logs-tgz")))
+
+			logType, selection, _ := logsTarProvider.StreamArgsForCall(0)
 			Expect(logType).To(Equal("job"))
-			Expect(filters).To(Equal([]string{"foo", "bar"}))
-		})
+			Expect(selection).To(Equal(logstarprovider.Selection{Include: []string{"foo"}, Exclude: []string{"foo/*.gz"}}))
 
-		It("returns the expected log blob", func() {
-			multidigestSha := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "sec_dep_sha1"))
-			sha1 := multidigestSha.String()
-			blobstore.WriteReturnsOnCall(0, "my-blob-id", multidigestSha, nil)
+			signedURL, _, headers := blobstore.WriteStreamArgsForCall(0)
+			Expect(signedURL).To(Equal("foobar"))
+			Expect(headers).To(Equal(map[string]string{"key": "value"}))
+
+			Expect(logsTarProvider.GetCallCount()).To(BeZero())
+			Expect(blobstore.WriteCallCount()).To(BeZero())
+		})
 
+		It("streams the same gzipped tarball when gzip encoding is requested", func() {
 			logsBlob, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
-				SignedURL:        "foobar",
-				LogType:          "job",
-				Filters:          []string{"foo", "bar"},
-				BlobstoreHeaders: map[string]string{},
+				SignedURL:       "foobar",
+				LogType:         "job",
+				ContentEncoding: "gzip",
 			})
 			Expect(err).ToNot(HaveOccurred())
+			boshassert.MatchesJSONString(GinkgoT(), logsBlob, `{"sha1":"fake-sha1","content_encoding":"gzip"}`)
 
-			boshassert.MatchesJSONString(GinkgoT(), logsBlob, `{"sha1":"`+sha1+`"}`)
+			Expect(streamed).To(Equal([]byte("fake-logs-tgz")))
+			Expect(logsTarProvider.GetUncompressedCallCount()).To(BeZero())
 		})
 
-		It("logs error if blobstore returns one", func() {
-			blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("cloudy"))
-			_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
-				SignedURL:        "foobar",
-				LogType:          "agent",
-				Filters:          []string{"foo", "bar"},
-				BlobstoreHeaders: map[string]string{},
+		It("uploads the uncompressed tarball encoded with zstd when zstd encoding is requested", func() {
+			logsTarProvider.GetUncompressedReturns("/tmp/logs.tar", nil)
+			Expect(fs.WriteFileString("/tmp/logs.tar", "fake-logs")).To(Succeed())
+
+			encodedFile, err := fs.OpenFile("/tmp/encoded-logs", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+			Expect(err).ToNot(HaveOccurred())
+			fs.ReturnTempFile = encodedFile
+			blobstore.WriteReturns("", boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil)
+
+			logsBlob, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
+				SignedURL:       "foobar",
+				LogType:         "job",
+				ContentEncoding: "zstd",
 			})
-			Expect(err).To(MatchError(ContainSubstring("Create file on blobstore")))
-			Expect(err).To(MatchError(ContainSubstring("cloudy")))
+			Expect(err).ToNot(HaveOccurred())
+			boshassert.MatchesJSONString(GinkgoT(), logsBlob, `{"sha1":"fake-sha1","content_encoding":"zstd"}`)
+
+			Expect(blobstore.WriteStreamCallCount()).To(BeZero())
+			Expect(logsTarProvider.GetCallCount()).To(BeZero())
+
+			_, path, _ := blobstore.WriteArgsForCall(0)
+			Expect(path).ToNot(Equal("/tmp/logs.tar"))
+			Expect(logsTarProvider.CleanUpArgsForCall(0)).To(Equal("/tmp/logs.tar"))
 		})
 
-		It("cleans up compressed package only after uploading it to blobstore", func() {
-			var beforeCallCount int
-			blobstore.WriteStub = func(string, string, map[string]string) (string, boshcrypto.MultipleDigest, error) {
-				beforeCallCount = logsTarProvider.CleanUpCallCount()
+		It("returns the error reading the logs instead of the failed upload", func() {
+			logsTarProvider.StreamReturns(errors.New("fake-stream-err"))
+			logsTarProvider.StreamStub = nil
 
-				return "", boshcrypto.MultipleDigest{}, nil
-			}
-			logsTarProvider.GetReturns("/tmp/logs.tar", nil)
+			_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{SignedURL: "foobar", LogType: "job"})
+			Expect(err).To(MatchError("Streaming logs to blobstore: fake-stream-err"))
+		})
 
-			_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
-				SignedURL:        "foobar",
-				LogType:          "job",
-				Filters:          []string{"foo", "bar"},
-				BlobstoreHeaders: map[string]string{},
+		It("returns an error if the streaming upload fails", func() {
+			blobstore.WriteStreamStub = nil
+			blobstore.WriteStreamReturns(boshcrypto.MultipleDigest{}, errors.New("cloudy"))
+
+			_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{SignedURL: "foobar", LogType: "job"})
+			Expect(err).To(MatchError("Streaming logs to blobstore: cloudy"))
+			Expect(logsTarProvider.GetCallCount()).To(BeZero())
+		})
+
+		Context("when the blobstore rejects uploads without a content length", func() {
+			BeforeEach(func() {
+				blobstore.WriteStreamStub = nil
+				blobstore.WriteStreamReturns(boshcrypto.MultipleDigest{}, httpblobprovider.StatusError{StatusCode: http.StatusLengthRequired})
 			})
 
-			Expect(err).ToNot(HaveOccurred())
-			Expect(beforeCallCount).To(BeZero())
-			Expect(logsTarProvider.CleanUpCallCount()).To(Equal(1))
-			Expect(logsTarProvider.CleanUpArgsForCall(0)).To(Equal("/tmp/logs.tar"))
+			It("logs error if logstarprovider returns one", func() {
+				logsTarProvider.GetReturns("", errors.New("uh-oh"))
+				_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
+					SignedURL:        "foobar",
+					LogType:          "other-logs",
+					Filters:          []string{},
+					BlobstoreHeaders: map[string]string{},
+				})
+				Expect(err).To(MatchError("uh-oh"))
+			})
+
+			It("invokes logstarprovider properly", func() {
+				_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
+					SignedURL:        "foobar",
+					LogType:          "job",
+					Filters:          []string{"foo", "bar"},
+					BlobstoreHeaders: map[string]string{},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				logType, selection := logsTarProvider.GetArgsForCall(0)
+				Expect(logType).To(Equal("job"))
+				Expect(selection.Include).To(Equal([]string{"foo", "bar"}))
+			})
+
+			It("warns that the logs are staged on disk instead", func() {
+				_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{SignedURL: "foobar", LogType: "job"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(logger.WarnCallCount()).To(Equal(1))
+				_, msg, _ := logger.WarnArgsForCall(0)
+				Expect(msg).To(ContainSubstring("staging them on disk instead"))
+			})
+
+			It("returns the expected log blob", func() {
+				multidigestSha := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "sec_dep_sha1"))
+				sha1 := multidigestSha.String()
+				blobstore.WriteReturnsOnCall(0, "my-blob-id", multidigestSha, nil)
+
+				logsBlob, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
+					SignedURL:        "foobar",
+					LogType:          "job",
+					Filters:          []string{"foo", "bar"},
+					BlobstoreHeaders: map[string]string{},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				boshassert.MatchesJSONString(GinkgoT(), logsBlob, `{"sha1":"`+sha1+`"}`)
+			})
+
+			It("logs error if blobstore returns one", func() {
+				blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("cloudy"))
+				_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
+					SignedURL:        "foobar",
+					LogType:          "agent",
+					Filters:          []string{"foo", "bar"},
+					BlobstoreHeaders: map[string]string{},
+				})
+				Expect(err).To(MatchError(ContainSubstring("Create file on blobstore")))
+				Expect(err).To(MatchError(ContainSubstring("cloudy")))
+			})
+
+			It("cleans up compressed package only after uploading it to blobstore", func() {
+				var beforeCallCount int
+				blobstore.WriteStub = func(string, string, map[string]string) (string, boshcrypto.MultipleDigest, error) {
+					beforeCallCount = logsTarProvider.CleanUpCallCount()
+
+					return "", boshcrypto.MultipleDigest{}, nil
+				}
+				logsTarProvider.GetReturns("/tmp/logs.tar", nil)
+
+				_, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
+					SignedURL:        "foobar",
+					LogType:          "job",
+					Filters:          []string{"foo", "bar"},
+					BlobstoreHeaders: map[string]string{},
+				})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(beforeCallCount).To(BeZero())
+				Expect(logsTarProvider.CleanUpCallCount()).To(Equal(1))
+				Expect(logsTarProvider.CleanUpArgsForCall(0)).To(Equal("/tmp/logs.tar"))
+			})
+
+			It("uploads the logs gzipped when a content encoding is requested", func() {
+				logsTarProvider.GetUncompressedReturns("/tmp/logs.tar", nil)
+				Expect(fs.WriteFileString("/tmp/logs.tar", "fake-logs")).To(Succeed())
+
+				encodedFile, err := fs.OpenFile("/tmp/encoded-logs", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+				Expect(err).ToNot(HaveOccurred())
+				fs.ReturnTempFile = encodedFile
+				blobstore.WriteReturns("", boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil)
+
+				logsBlob, err := action.Run(boshaction.FetchLogsWithSignedURLRequest{
+					SignedURL:        "foobar",
+					LogType:          "job",
+					BlobstoreHeaders: map[string]string{"key": "value"},
+					ContentEncoding:  "gzip",
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				boshassert.MatchesJSONString(GinkgoT(), logsBlob, `{"sha1":"fake-sha1","content_encoding":"gzip"}`)
+
+				signedURL, path, headers := blobstore.WriteArgsForCall(0)
+				Expect(signedURL).To(Equal("foobar"))
+				Expect(path).ToNot(Equal("/tmp/logs.tar"))
+				Expect(headers).To(Equal(map[string]string{"key": "value"}))
+			})
 		})
 	})
 })
@@ -0,0 +1,69 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/snapshot"
+)
+
+type FinishSnapshotOptions struct {
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+type FinishSnapshotResponse struct {
+	Thawed bool `json:"thawed"`
+}
+
+// FinishSnapshotAction thaws the persistent disk frozen by prepare_snapshot
+// and resumes the jobs with their post-snapshot scripts. Thawed is false when
+// the disk was not frozen or was already thawed after being frozen too long.
+type FinishSnapshotAction struct {
+	runScriptAction RunScriptAction
+	freezer         snapshot.Freezer
+}
+
+func NewFinishSnapshot(runScriptAction RunScriptAction, freezer snapshot.Freezer) FinishSnapshotAction {
+	return FinishSnapshotAction{
+		runScriptAction: runScriptAction,
+		freezer:         freezer,
+	}
+}
+
+func (a FinishSnapshotAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a FinishSnapshotAction) IsPersistent() bool {
+	return false
+}
+
+func (a FinishSnapshotAction) IsLoggable() bool {
+	return true
+}
+
+func (a FinishSnapshotAction) Run(options FinishSnapshotOptions) (FinishSnapshotResponse, error) {
+	thawed, thawErr := a.freezer.Thaw()
+
+	// Jobs are resumed even if thawing failed, they may not need the disk
+	_, err := a.runScriptAction.Run(postSnapshotScriptName, RunScriptOptions{TimeoutSeconds: options.TimeoutSeconds})
+
+	if thawErr != nil {
+		return FinishSnapshotResponse{}, bosherr.WrapError(thawErr, "Thawing persistent disk")
+	}
+
+	if err != nil {
+		return FinishSnapshotResponse{Thawed: thawed}, bosherr.WrapError(err, "Running post-snapshot scripts")
+	}
+
+	return FinishSnapshotResponse{Thawed: thawed}, nil
+}
+
+func (a FinishSnapshotAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a FinishSnapshotAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,86 @@
+package action_test
+
+import (
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	fakeapplyspec "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/script/scriptfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/snapshot/snapshotfakes"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+)
+
+var _ = Describe("FinishSnapshot", func() {
+	var (
+		jobScriptProvider *scriptfakes.FakeJobScriptProvider
+		parallelScript    *scriptfakes.FakeCancellableScript
+		freezer           *snapshotfakes.FakeFreezer
+		finishSnapshot    action.FinishSnapshotAction
+	)
+
+	BeforeEach(func() {
+		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
+		parallelScript = &scriptfakes.FakeCancellableScript{}
+		jobScriptProvider.NewParallelScriptReturns(parallelScript)
+		freezer = &snapshotfakes.FakeFreezer{}
+		freezer.ThawReturns(true, nil)
+
+		runScript := action.NewRunScript(jobScriptProvider, fakeapplyspec.NewFakeV1Service(), fakeappl.NewFakeApplier(), &faketask.FakeOutputReporter{}, boshlog.NewLogger(boshlog.LevelNone))
+		finishSnapshot = action.NewFinishSnapshot(runScript, freezer)
+	})
+
+	AssertActionIsAsynchronous(finishSnapshot)
+	AssertActionIsNotPersistent(finishSnapshot)
+	AssertActionIsLoggable(finishSnapshot)
+
+	AssertActionIsNotResumable(finishSnapshot)
+	AssertActionIsNotCancelable(finishSnapshot)
+
+	Describe("Run", func() {
+		It("thaws the persistent disk and then runs the post-snapshot scripts", func() {
+			parallelScript.RunStub = func() error {
+				Expect(freezer.ThawCallCount()).To(Equal(1))
+				return nil
+			}
+
+			response, err := finishSnapshot.Run(action.FinishSnapshotOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response).To(Equal(action.FinishSnapshotResponse{Thawed: true}))
+
+			name, _ := jobScriptProvider.NewParallelScriptArgsForCall(0)
+			Expect(name).To(Equal("post-snapshot"))
+			Expect(parallelScript.RunCallCount()).To(Equal(1))
+		})
+
+		It("reports when the persistent disk was not frozen", func() {
+			freezer.ThawReturns(false, nil)
+
+			response, err := finishSnapshot.Run(action.FinishSnapshotOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Thawed).To(BeFalse())
+			Expect(parallelScript.RunCallCount()).To(Equal(1))
+		})
+
+		It("still resumes the jobs when thawing fails", func() {
+			freezer.ThawReturns(false, errors.New("fake-thaw-err"))
+
+			_, err := finishSnapshot.Run(action.FinishSnapshotOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Thawing persistent disk: fake-thaw-err"))
+			Expect(parallelScript.RunCallCount()).To(Equal(1))
+		})
+
+		It("returns an error when the post-snapshot scripts fail", func() {
+			parallelScript.RunReturns(errors.New("fake-script-err"))
+
+			_, err := finishSnapshot.Run(action.FinishSnapshotOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Running post-snapshot scripts: fake-script-err"))
+		})
+	})
+})
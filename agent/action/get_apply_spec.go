@@ -0,0 +1,99 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+)
+
+type GetApplySpecResult struct {
+	boshas.V1ApplySpec
+
+	// AppliedSpecDigest is the digest get_state reports for the spec. It is
+	// computed before the spec is sanitized and unset until a spec with a
+	// configuration hash was applied.
+	AppliedSpecDigest string `json:"applied_spec_digest,omitempty"`
+
+	// RenderedTemplateFingerprints are the content digests of the installed
+	// jobs by job name
+	RenderedTemplateFingerprints map[string]string `json:"rendered_template_fingerprints"`
+}
+
+// GetApplySpecAction returns the applied spec so that tooling can check that
+// the instance matches what the director expects. Signed URLs and blobstore
+// headers of packages are removed since they grant access to the blobstore.
+type GetApplySpecAction struct {
+	specService    boshas.V1Service
+	bundleVerifier boshappl.BundleVerifier
+}
+
+func NewGetApplySpec(specService boshas.V1Service, bundleVerifier boshappl.BundleVerifier) GetApplySpecAction {
+	return GetApplySpecAction{
+		specService:    specService,
+		bundleVerifier: bundleVerifier,
+	}
+}
+
+func (a GetApplySpecAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a GetApplySpecAction) IsPersistent() bool {
+	return false
+}
+
+func (a GetApplySpecAction) IsLoggable() bool {
+	return true
+}
+
+func (a GetApplySpecAction) Run() (GetApplySpecResult, error) {
+	spec, err := a.specService.Get()
+	if err != nil {
+		return GetApplySpecResult{}, bosherr.WrapError(err, "Getting applied spec")
+	}
+
+	result := GetApplySpecResult{}
+
+	if spec.ConfigurationHash != "" {
+		result.AppliedSpecDigest, err = spec.Digest()
+		if err != nil {
+			return GetApplySpecResult{}, bosherr.WrapError(err, "Computing digest of applied spec")
+		}
+	}
+
+	result.RenderedTemplateFingerprints, err = a.bundleVerifier.JobFingerprints(spec)
+	if err != nil {
+		return GetApplySpecResult{}, bosherr.WrapError(err, "Getting rendered template fingerprints")
+	}
+
+	packageSpecs := map[string]boshas.PackageSpec{}
+	for name, pkg := range spec.PackageSpecs {
+		pkg.SignedURL = ""
+		pkg.BlobstoreHeaders = nil
+		packageSpecs[name] = pkg
+	}
+
+	spec.PackageSpecs = packageSpecs
+
+	if spec.NetworkSpecs == nil {
+		spec.NetworkSpecs = map[string]boshas.NetworkSpec{}
+	}
+	if spec.ResourcePoolSpecs == nil {
+		spec.ResourcePoolSpecs = map[string]interface{}{}
+	}
+
+	result.V1ApplySpec = spec
+
+	return result, nil
+}
+
+func (a GetApplySpecAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a GetApplySpecAction) Cancel() error {
+	return errors.New("not supported")
+}
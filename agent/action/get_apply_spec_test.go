@@ -0,0 +1,127 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+)
+
+var _ = Describe("GetApplySpec", func() {
+	var (
+		specService        *fakeas.FakeV1Service
+		bundleVerifier     *fakeappl.FakeBundleVerifier
+		getApplySpecAction action.GetApplySpecAction
+		packageSha1        boshcrypto.MultipleDigest
+	)
+
+	BeforeEach(func() {
+		specService = fakeas.NewFakeV1Service()
+		bundleVerifier = &fakeappl.FakeBundleVerifier{}
+		getApplySpecAction = action.NewGetApplySpec(specService, bundleVerifier)
+
+		packageSha1 = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-package-sha1"))
+		templatesSha1 := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-templates-sha1"))
+		jobName := "fake-job"
+
+		specService.Spec = boshas.V1ApplySpec{
+			Deployment: "fake-deployment",
+			JobSpec: boshas.JobSpec{
+				Name:             &jobName,
+				JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-job", Version: "fake-job-version"}},
+			},
+			PackageSpecs: map[string]boshas.PackageSpec{
+				"fake-package": {
+					Name:             "fake-package",
+					Version:          "fake-package-version",
+					Sha1:             packageSha1,
+					BlobstoreID:      "fake-package-blob",
+					SignedURL:        "https://fake-signed-url",
+					BlobstoreHeaders: map[string]string{"encryption-key": "fake-key"},
+				},
+			},
+			RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{
+				Sha1:        &templatesSha1,
+				BlobstoreID: "fake-templates-blob",
+			},
+			ConfigurationHash: "fake-configuration-hash",
+		}
+
+		bundleVerifier.JobFingerprintsResult = map[string]string{"fake-job": "fake-content-digest"}
+	})
+
+	AssertActionIsNotAsynchronous(getApplySpecAction)
+	AssertActionIsNotPersistent(getApplySpecAction)
+	AssertActionIsLoggable(getApplySpecAction)
+
+	AssertActionIsNotResumable(getApplySpecAction)
+	AssertActionIsNotCancelable(getApplySpecAction)
+
+	It("returns the applied spec with job versions and package digests", func() {
+		result, err := getApplySpecAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result.Deployment).To(Equal("fake-deployment"))
+		Expect(result.JobSpec.JobTemplateSpecs).To(Equal([]boshas.JobTemplateSpec{{Name: "fake-job", Version: "fake-job-version"}}))
+		Expect(result.PackageSpecs["fake-package"].Version).To(Equal("fake-package-version"))
+		Expect(result.PackageSpecs["fake-package"].Sha1).To(Equal(packageSha1))
+		Expect(result.PackageSpecs["fake-package"].BlobstoreID).To(Equal("fake-package-blob"))
+		Expect(result.RenderedTemplatesArchiveSpec.BlobstoreID).To(Equal("fake-templates-blob"))
+	})
+
+	It("removes signed urls and blobstore headers of packages", func() {
+		result, err := getApplySpecAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result.PackageSpecs["fake-package"].SignedURL).To(BeEmpty())
+		Expect(result.PackageSpecs["fake-package"].BlobstoreHeaders).To(BeNil())
+
+		Expect(specService.Spec.PackageSpecs["fake-package"].SignedURL).To(Equal("https://fake-signed-url"))
+	})
+
+	It("returns the fingerprints of the rendered templates of the applied jobs", func() {
+		result, err := getApplySpecAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result.RenderedTemplateFingerprints).To(Equal(map[string]string{"fake-job": "fake-content-digest"}))
+		Expect(bundleVerifier.JobFingerprintsAppliedSpec).To(Equal(specService.Spec))
+	})
+
+	It("returns the digest of the spec before it was sanitized", func() {
+		digest, err := specService.Spec.Digest()
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := getApplySpecAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.AppliedSpecDigest).To(Equal(digest))
+	})
+
+	It("leaves out the digest when no spec with a configuration hash was applied", func() {
+		specService.Spec.ConfigurationHash = ""
+
+		result, err := getApplySpecAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.AppliedSpecDigest).To(BeEmpty())
+	})
+
+	It("returns an error when getting the applied spec fails", func() {
+		specService.GetErr = errors.New("fake-get-err")
+
+		_, err := getApplySpecAction.Run()
+		Expect(err).To(MatchError("Getting applied spec: fake-get-err"))
+	})
+
+	It("returns an error when getting the fingerprints fails", func() {
+		bundleVerifier.JobFingerprintsErr = errors.New("fake-fingerprints-err")
+
+		_, err := getApplySpecAction.Run()
+		Expect(err).To(MatchError("Getting rendered template fingerprints: fake-fingerprints-err"))
+	})
+})
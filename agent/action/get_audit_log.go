@@ -0,0 +1,52 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
+)
+
+type GetAuditLogAction struct {
+	auditLog audit.Log
+}
+
+func NewGetAuditLog(auditLog audit.Log) GetAuditLogAction {
+	return GetAuditLogAction{auditLog: auditLog}
+}
+
+func (a GetAuditLogAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a GetAuditLogAction) IsPersistent() bool {
+	return false
+}
+
+func (a GetAuditLogAction) IsLoggable() bool {
+	return true
+}
+
+// Run returns the recorded events from oldest to newest, only the most
+// recent ones when given a limit
+func (a GetAuditLogAction) Run(limit ...int) ([]audit.Event, error) {
+	events, err := a.auditLog.Events()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting audit events")
+	}
+
+	if len(limit) > 0 && limit[0] > 0 && len(events) > limit[0] {
+		events = events[len(events)-limit[0]:]
+	}
+
+	return events, nil
+}
+
+func (a GetAuditLogAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a GetAuditLogAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,55 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit/auditfakes"
+)
+
+var _ = Describe("GetAuditLog", func() {
+	var (
+		auditLog          *auditfakes.FakeLog
+		getAuditLogAction action.GetAuditLogAction
+		events            []audit.Event
+	)
+
+	BeforeEach(func() {
+		auditLog = &auditfakes.FakeLog{}
+		getAuditLogAction = action.NewGetAuditLog(auditLog)
+
+		events = []audit.Event{
+			{Operation: audit.OperationInstall, TaskID: "fake-task-1"},
+			{Operation: audit.OperationEnable, TaskID: "fake-task-1"},
+			{Operation: audit.OperationSetSpec, TaskID: "fake-task-2"},
+		}
+		auditLog.EventsReturns(events, nil)
+	})
+
+	AssertActionIsNotAsynchronous(getAuditLogAction)
+	AssertActionIsNotPersistent(getAuditLogAction)
+	AssertActionIsLoggable(getAuditLogAction)
+
+	AssertActionIsNotResumable(getAuditLogAction)
+	AssertActionIsNotCancelable(getAuditLogAction)
+
+	It("returns all recorded events", func() {
+		Expect(getAuditLogAction.Run()).To(Equal(events))
+	})
+
+	It("returns only the most recent events when given a limit", func() {
+		Expect(getAuditLogAction.Run(2)).To(Equal(events[1:]))
+	})
+
+	It("returns an error when the events cannot be read", func() {
+		auditLog.EventsReturns(nil, errors.New("fake-events-error"))
+
+		_, err := getAuditLogAction.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-events-error"))
+	})
+})
@@ -0,0 +1,46 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/crashreport"
+)
+
+type GetCrashReportsAction struct {
+	store crashreport.Store
+}
+
+func NewGetCrashReports(store crashreport.Store) GetCrashReportsAction {
+	return GetCrashReportsAction{store: store}
+}
+
+func (a GetCrashReportsAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a GetCrashReportsAction) IsPersistent() bool {
+	return false
+}
+
+func (a GetCrashReportsAction) IsLoggable() bool {
+	return true
+}
+
+func (a GetCrashReportsAction) Run() ([]crashreport.Report, error) {
+	reports, err := a.store.List()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting crash reports")
+	}
+
+	return reports, nil
+}
+
+func (a GetCrashReportsAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a GetCrashReportsAction) Cancel() error {
+	return errors.New("not supported")
+}
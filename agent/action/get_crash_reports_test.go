@@ -0,0 +1,55 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/crashreport"
+)
+
+var _ = Describe("GetCrashReports", func() {
+	var (
+		fs              *fakesys.FakeFileSystem
+		store           crashreport.Store
+		getCrashReports action.GetCrashReportsAction
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		store = crashreport.NewStore(fs, "/var/vcap/bosh/crash_reports", 3)
+		getCrashReports = action.NewGetCrashReports(store)
+	})
+
+	AssertActionIsNotAsynchronous(getCrashReports)
+	AssertActionIsNotPersistent(getCrashReports)
+	AssertActionIsLoggable(getCrashReports)
+
+	AssertActionIsNotResumable(getCrashReports)
+	AssertActionIsNotCancelable(getCrashReports)
+
+	It("returns saved crash reports", func() {
+		err := fs.WriteFileString("/var/vcap/bosh/crash_reports/crash-1.json", `{"tag":"Main","panic":"boom","timestamp":"2020-01-01T00:00:00Z"}`)
+		Expect(err).ToNot(HaveOccurred())
+		fs.SetGlob("/var/vcap/bosh/crash_reports/crash-*.json", []string{"/var/vcap/bosh/crash_reports/crash-1.json"})
+
+		reports, err := getCrashReports.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(Equal([]crashreport.Report{
+			{Tag: "Main", Panic: "boom", Timestamp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}))
+	})
+
+	It("returns an error when listing crash reports fails", func() {
+		fs.GlobErr = errors.New("fake-glob-err")
+
+		_, err := getCrashReports.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-glob-err"))
+	})
+})
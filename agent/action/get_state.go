@@ -2,11 +2,14 @@ package action
 
 import (
 	"errors"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 )
@@ -16,6 +19,8 @@ type GetStateAction struct {
 	specService     boshas.V1Service
 	jobSupervisor   boshjobsuper.JobSupervisor
 	vitalsService   boshvitals.Service
+	bundleVerifier  boshappl.BundleVerifier
+	processStats    boshstats.ProcessCollector
 }
 
 func NewGetState(
@@ -23,11 +28,15 @@ func NewGetState(
 	specService boshas.V1Service,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	vitalsService boshvitals.Service,
+	bundleVerifier boshappl.BundleVerifier,
+	processStats boshstats.ProcessCollector,
 ) (action GetStateAction) {
 	action.settingsService = settingsService
 	action.specService = specService
 	action.jobSupervisor = jobSupervisor
 	action.vitalsService = vitalsService
+	action.bundleVerifier = bundleVerifier
+	action.processStats = processStats
 	return
 }
 
@@ -51,6 +60,16 @@ type GetStateV1ApplySpec struct {
 	Vitals    *boshvitals.Vitals     `json:"vitals,omitempty"`
 	Processes []boshjobsuper.Process `json:"processes,omitempty"`
 	VM        boshsettings.VM        `json:"vm"`
+
+	// AppliedSpecDigest and AppliedAt let tooling detect that instances run
+	// different specs without comparing them. They are unset until a spec
+	// with a configuration hash was applied.
+	AppliedSpecDigest string     `json:"applied_spec_digest,omitempty"`
+	AppliedAt         *time.Time `json:"applied_at,omitempty"`
+
+	// BundleVerification is the outcome of the most recent bundle
+	// verification, which is not run for get_state since it reads all files
+	BundleVerification *boshappl.BundleVerification `json:"bundle_verification,omitempty"`
 }
 
 func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
@@ -62,7 +81,9 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 	var vitals boshvitals.Vitals
 	var vitalsReference *boshvitals.Vitals
 
-	if len(filters) > 0 && filters[0] == "full" {
+	full := len(filters) > 0 && filters[0] == "full"
+
+	if full {
 		vitals, err = a.vitalsService.Get()
 		if err != nil {
 			return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Building full vitals")
@@ -75,15 +96,28 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 		return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Getting processes status")
 	}
 
+	if full {
+		a.addProcessResources(processes)
+	}
+
 	settings := a.settingsService.GetSettings()
 
 	value := GetStateV1ApplySpec{
-		spec,
-		settings.AgentID,
-		a.jobSupervisor.Status(),
-		vitalsReference,
-		processes,
-		settings.VM,
+		V1ApplySpec: spec,
+		AgentID:     settings.AgentID,
+		JobState:    a.jobSupervisor.Status(),
+		Vitals:      vitalsReference,
+		Processes:   processes,
+		VM:          settings.VM,
+
+		BundleVerification: a.bundleVerifier.LastVerification(),
+	}
+
+	if spec.ConfigurationHash != "" {
+		value.AppliedSpecDigest, value.AppliedAt, err = a.appliedSpecState(spec)
+		if err != nil {
+			return GetStateV1ApplySpec{}, err
+		}
 	}
 
 	if value.NetworkSpecs == nil {
@@ -99,6 +133,43 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 	return value, nil
 }
 
+func (a GetStateAction) appliedSpecState(spec boshas.V1ApplySpec) (string, *time.Time, error) {
+	digest, err := spec.Digest()
+	if err != nil {
+		return "", nil, bosherr.WrapError(err, "Computing digest of current spec")
+	}
+
+	appliedAt, err := a.specService.AppliedAt()
+	if err != nil {
+		return "", nil, bosherr.WrapError(err, "Getting time current spec was applied")
+	}
+
+	return digest, &appliedAt, nil
+}
+
+// addProcessResources adds the resources used by processes to them. Processes
+// may exit while their stats are read, which leaves their resources unset.
+func (a GetStateAction) addProcessResources(processes []boshjobsuper.Process) {
+	for i, process := range processes {
+		if process.PID == 0 {
+			continue
+		}
+
+		stats, err := a.processStats.GetProcessStats(process.PID)
+		if err != nil {
+			continue
+		}
+
+		processes[i].Resources = &boshjobsuper.ResourceVitals{
+			CPUSecs:    stats.CPUSecs,
+			RSSKb:      stats.RSSKb,
+			OpenFDs:    stats.OpenFDs,
+			Threads:    stats.Threads,
+			UptimeSecs: stats.UptimeSecs,
+		}
+	}
+}
+
 func (a GetStateAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
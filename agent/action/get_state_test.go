@@ -2,6 +2,7 @@ package action_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -9,10 +10,14 @@ import (
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
+	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
+	fakestats "github.com/cloudfoundry/bosh-agent/v2/platform/stats/fakes"
 	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/vitals/vitalsfakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
@@ -25,6 +30,8 @@ var _ = Describe("GetState", func() {
 		specService     *fakeas.FakeV1Service
 		jobSupervisor   *fakejobsuper.FakeJobSupervisor
 		vitalsService   *vitalsfakes.FakeService
+		bundleVerifier  *fakeappl.FakeBundleVerifier
+		processStats    *fakestats.FakeProcessCollector
 		getStateAction  action.GetStateAction
 	)
 
@@ -33,7 +40,9 @@ var _ = Describe("GetState", func() {
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		specService = fakeas.NewFakeV1Service()
 		vitalsService = &vitalsfakes.FakeService{}
-		getStateAction = action.NewGetState(settingsService, specService, jobSupervisor, vitalsService)
+		bundleVerifier = &fakeappl.FakeBundleVerifier{}
+		processStats = &fakestats.FakeProcessCollector{}
+		getStateAction = action.NewGetState(settingsService, specService, jobSupervisor, vitalsService, bundleVerifier, processStats)
 	})
 
 	AssertActionIsNotAsynchronous(getStateAction)
@@ -88,6 +97,7 @@ var _ = Describe("GetState", func() {
 						boshjobsuper.Process{
 							Name:  "fake-process-name-1",
 							State: "running",
+							PID:   1234,
 						},
 						boshjobsuper.Process{
 							Name:  "fake-process-name-2",
@@ -95,6 +105,10 @@ var _ = Describe("GetState", func() {
 						},
 					}
 
+					processStats.ProcessStats = map[int]boshstats.ProcessStats{
+						1234: {CPUSecs: 1.5, RSSKb: 2048, OpenFDs: 12, Threads: 4, UptimeSecs: 60},
+					}
+
 					specService.Spec = boshas.V1ApplySpec{
 						Deployment: "fake-deployment",
 					}
@@ -110,6 +124,14 @@ var _ = Describe("GetState", func() {
 						boshjobsuper.Process{
 							Name:  "fake-process-name-1",
 							State: "running",
+							PID:   1234,
+							Resources: &boshjobsuper.ResourceVitals{
+								CPUSecs:    1.5,
+								RSSKb:      2048,
+								OpenFDs:    12,
+								Threads:    4,
+								UptimeSecs: 60,
+							},
 						},
 						boshjobsuper.Process{
 							Name:  "fake-process-name-2",
@@ -128,6 +150,27 @@ var _ = Describe("GetState", func() {
 					boshassert.MatchesJSONMap(GinkgoT(), state.VM, expectedVM)
 				})
 
+				It("leaves out the resources of processes that exited", func() {
+					jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+						boshjobsuper.Process{Name: "fake-process-name", State: "failing", PID: 1234},
+					}
+
+					state, err := getStateAction.Run("full")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(state.Processes[0].Resources).To(BeNil())
+				})
+
+				It("only gathers the resources of processes in full format", func() {
+					jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+						boshjobsuper.Process{Name: "fake-process-name", State: "running", PID: 1234},
+					}
+					processStats.ProcessStats = map[int]boshstats.ProcessStats{1234: {Threads: 4}}
+
+					state, err := getStateAction.Run()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(state.Processes[0].Resources).To(BeNil())
+				})
+
 				Describe("non-populated field formatting", func() {
 					It("returns network as empty hash if not set", func() {
 						specService.Spec = boshas.V1ApplySpec{NetworkSpecs: nil}
@@ -189,6 +232,62 @@ var _ = Describe("GetState", func() {
 			})
 		})
 
+		Context("when a spec was applied", func() {
+			appliedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+			BeforeEach(func() {
+				specService.Spec = boshas.V1ApplySpec{ConfigurationHash: "fake-config-hash"}
+				specService.AppliedAtTime = appliedAt
+			})
+
+			It("returns the digest of the applied spec and when it was applied", func() {
+				state, err := getStateAction.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(state.AppliedSpecDigest).To(HavePrefix("sha256:"))
+				Expect(*state.AppliedAt).To(Equal(appliedAt))
+			})
+
+			It("returns a different digest once another spec was applied", func() {
+				state, err := getStateAction.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				specService.Spec = boshas.V1ApplySpec{ConfigurationHash: "fake-other-config-hash"}
+
+				otherState, err := getStateAction.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(otherState.AppliedSpecDigest).ToNot(Equal(state.AppliedSpecDigest))
+			})
+
+			It("returns error when it cannot tell when the spec was applied", func() {
+				specService.AppliedAtErr = errors.New("fake-applied-at-error")
+
+				_, err := getStateAction.Run()
+				Expect(err).To(MatchError(ContainSubstring("fake-applied-at-error")))
+			})
+		})
+
+		It("leaves out the applied spec digest when no spec was applied", func() {
+			state, err := getStateAction.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			boshassert.LacksJSONKey(GinkgoT(), state, "applied_spec_digest")
+			boshassert.LacksJSONKey(GinkgoT(), state, "applied_at")
+		})
+
+		It("returns the outcome of the last bundle verification", func() {
+			verification := &boshappl.BundleVerification{
+				OK:            false,
+				Discrepancies: []boshappl.BundleDiscrepancy{{Bundle: "job fake-job", Problems: []string{"modified: bin/run"}}},
+			}
+			bundleVerifier.LastVerificationResult = verification
+
+			state, err := getStateAction.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.BundleVerification).To(Equal(verification))
+		})
+
 		Context("when current spec cannot be retrieved", func() {
 			It("without current spec", func() {
 				specService.GetErr = errors.New("fake-spec-get-error")
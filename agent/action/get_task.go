@@ -39,6 +39,8 @@ func (a GetTaskAction) Run(taskID string) (interface{}, error) {
 		return boshtask.StateValue{
 			AgentTaskID: task.ID,
 			State:       task.State,
+			Progress:    task.Progress,
+			WaitingFor:  a.waitingFor(task),
 		}, nil
 	}
 
@@ -49,6 +51,20 @@ func (a GetTaskAction) Run(taskID string) (interface{}, error) {
 	return task.Value, nil
 }
 
+// waitingFor returns the task that is running while the given one is still
+// queued, e.g. a previous apply
+func (a GetTaskAction) waitingFor(task boshtask.Task) string {
+	queued := a.taskService.QueuedTasks()
+
+	for i, queuedTask := range queued {
+		if queuedTask.ID == task.ID && i > 0 {
+			return queued[0].ID
+		}
+	}
+
+	return ""
+}
+
 func (a GetTaskAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
@@ -0,0 +1,58 @@
+package action
+
+import (
+	"errors"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+type GetTaskQueueAction struct {
+	taskService boshtask.Service
+}
+
+type QueuedTaskValue struct {
+	AgentTaskID string         `json:"agent_task_id"`
+	Method      string         `json:"method"`
+	State       boshtask.State `json:"state"`
+	Position    int            `json:"position"`
+}
+
+func NewGetTaskQueue(taskService boshtask.Service) GetTaskQueueAction {
+	return GetTaskQueueAction{taskService: taskService}
+}
+
+func (a GetTaskQueueAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a GetTaskQueueAction) IsPersistent() bool {
+	return false
+}
+
+func (a GetTaskQueueAction) IsLoggable() bool {
+	return true
+}
+
+// Run lists tasks waiting to be processed; position 1 is the running task.
+func (a GetTaskQueueAction) Run() ([]QueuedTaskValue, error) {
+	queue := []QueuedTaskValue{}
+
+	for i, task := range a.taskService.QueuedTasks() {
+		queue = append(queue, QueuedTaskValue{
+			AgentTaskID: task.ID,
+			Method:      task.Method,
+			State:       task.State,
+			Position:    i + 1,
+		})
+	}
+
+	return queue, nil
+}
+
+func (a GetTaskQueueAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a GetTaskQueueAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,49 @@
+package action_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+)
+
+var _ = Describe("GetTaskQueue", func() {
+	var (
+		taskService  *faketask.FakeService
+		getTaskQueue action.GetTaskQueueAction
+	)
+
+	BeforeEach(func() {
+		taskService = faketask.NewFakeService()
+		getTaskQueue = action.NewGetTaskQueue(taskService)
+	})
+
+	AssertActionIsNotAsynchronous(getTaskQueue)
+	AssertActionIsNotPersistent(getTaskQueue)
+	AssertActionIsLoggable(getTaskQueue)
+
+	AssertActionIsNotResumable(getTaskQueue)
+	AssertActionIsNotCancelable(getTaskQueue)
+
+	It("returns queued tasks with their positions", func() {
+		taskService.QueuedTasksResult = []boshtask.Task{
+			{ID: "fake-task-1", Method: "apply", State: boshtask.StateRunning},
+			{ID: "fake-task-2", Method: "compile_package", State: boshtask.StateRunning},
+		}
+
+		queue, err := getTaskQueue.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(queue).To(Equal([]action.QueuedTaskValue{
+			{AgentTaskID: "fake-task-1", Method: "apply", State: boshtask.StateRunning, Position: 1},
+			{AgentTaskID: "fake-task-2", Method: "compile_package", State: boshtask.StateRunning, Position: 2},
+		}))
+	})
+
+	It("returns an empty queue when nothing is running", func() {
+		queue, err := getTaskQueue.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(queue).To(BeEmpty())
+	})
+})
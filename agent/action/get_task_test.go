@@ -45,6 +45,35 @@ var _ = Describe("GetTask", func() {
 			`{"agent_task_id":"fake-task-id","state":"running"}`)
 	})
 
+	It("returns the progress of a running task", func() {
+		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+			ID:       "fake-task-id",
+			State:    boshtask.StateRunning,
+			Progress: map[string]interface{}{"step": "applying_job", "percent": 50},
+		}
+
+		taskValue, err := getTaskAction.Run("fake-task-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		boshassert.MatchesJSONString(GinkgoT(), taskValue,
+			`{"agent_task_id":"fake-task-id","state":"running","progress":{"percent":50,"step":"applying_job"}}`)
+	})
+
+	It("returns the task a queued task is waiting for", func() {
+		task := boshtask.Task{ID: "fake-task-id", Method: "apply", State: boshtask.StateRunning}
+		taskService.StartedTasks["fake-task-id"] = task
+		taskService.QueuedTasksResult = []boshtask.Task{
+			{ID: "fake-previous-apply-id", Method: "apply", State: boshtask.StateRunning},
+			task,
+		}
+
+		taskValue, err := getTaskAction.Run("fake-task-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		boshassert.MatchesJSONString(GinkgoT(), taskValue,
+			`{"agent_task_id":"fake-task-id","state":"running","waiting_for":"fake-previous-apply-id"}`)
+	})
+
 	It("returns a failed task", func() {
 		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
 			ID:    "fake-task-id",
@@ -0,0 +1,65 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+// GrowEphemeralDiskAction grows the ephemeral data partition and filesystem
+// online after the ephemeral disk was resized by the IaaS, so the VM does not
+// have to be recreated to use the added space
+type GrowEphemeralDiskAction struct {
+	settingsService boshsettings.Service
+	platform        platform.Platform
+}
+
+func NewGrowEphemeralDisk(settingsService boshsettings.Service, platform platform.Platform) GrowEphemeralDiskAction {
+	return GrowEphemeralDiskAction{
+		settingsService: settingsService,
+		platform:        platform,
+	}
+}
+
+func (a GrowEphemeralDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a GrowEphemeralDiskAction) IsPersistent() bool {
+	return false
+}
+
+func (a GrowEphemeralDiskAction) IsLoggable() bool {
+	return true
+}
+
+func (a GrowEphemeralDiskAction) Run() (string, error) {
+	diskSettings := a.settingsService.GetSettings().EphemeralDiskSettings()
+
+	devicePath, err := a.platform.GetEphemeralDiskPath(diskSettings)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting ephemeral disk path")
+	}
+
+	grown, err := a.platform.GrowEphemeralDisk(devicePath)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Growing ephemeral disk")
+	}
+
+	if !grown {
+		return "unchanged", nil
+	}
+
+	return "grown", nil
+}
+
+func (a GrowEphemeralDiskAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a GrowEphemeralDiskAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,59 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+var _ = Describe("GrowEphemeralDisk", func() {
+	var (
+		settingsService         *fakesettings.FakeSettingsService
+		platform                *platformfakes.FakePlatform
+		growEphemeralDiskAction action.GrowEphemeralDiskAction
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		settingsService.Settings.Disks.Ephemeral = "/dev/sdb"
+		platform = &platformfakes.FakePlatform{}
+		platform.GetEphemeralDiskPathReturns("/dev/xvdb", nil)
+		growEphemeralDiskAction = action.NewGrowEphemeralDisk(settingsService, platform)
+	})
+
+	AssertActionIsAsynchronous(growEphemeralDiskAction)
+	AssertActionIsNotPersistent(growEphemeralDiskAction)
+	AssertActionIsLoggable(growEphemeralDiskAction)
+
+	AssertActionIsNotCancelable(growEphemeralDiskAction)
+	AssertActionIsNotResumable(growEphemeralDiskAction)
+
+	It("grows the ephemeral disk", func() {
+		platform.GrowEphemeralDiskReturns(true, nil)
+
+		result, err := growEphemeralDiskAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("grown"))
+
+		Expect(platform.GetEphemeralDiskPathArgsForCall(0)).To(Equal(settingsService.Settings.EphemeralDiskSettings()))
+		Expect(platform.GrowEphemeralDiskArgsForCall(0)).To(Equal("/dev/xvdb"))
+	})
+
+	It("tells when the ephemeral disk has no space to grow into", func() {
+		result, err := growEphemeralDiskAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("unchanged"))
+	})
+
+	It("returns an error when growing the ephemeral disk fails", func() {
+		platform.GrowEphemeralDiskReturns(false, errors.New("fake-grow-error"))
+
+		_, err := growEphemeralDiskAction.Run()
+		Expect(err).To(MatchError("Growing ephemeral disk: fake-grow-error"))
+	})
+})
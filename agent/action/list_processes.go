@@ -0,0 +1,50 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+)
+
+// ListProcessesAction returns the complete status of all job processes as
+// known to the job supervisor, get_state only includes what the director
+// needs to tell whether the instance is healthy
+type ListProcessesAction struct {
+	jobSupervisor boshjobsuper.JobSupervisor
+}
+
+func NewListProcesses(jobSupervisor boshjobsuper.JobSupervisor) (action ListProcessesAction) {
+	action.jobSupervisor = jobSupervisor
+	return
+}
+
+func (a ListProcessesAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a ListProcessesAction) IsPersistent() bool {
+	return false
+}
+
+func (a ListProcessesAction) IsLoggable() bool {
+	return true
+}
+
+func (a ListProcessesAction) Run() ([]boshjobsuper.ProcessStatus, error) {
+	statuses, err := a.jobSupervisor.ProcessStatuses()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting process statuses")
+	}
+
+	return statuses, nil
+}
+
+func (a ListProcessesAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ListProcessesAction) Cancel() error {
+	return errors.New("not supported")
+}
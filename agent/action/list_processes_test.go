@@ -0,0 +1,79 @@
+package action_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
+)
+
+var _ = Describe("ListProcesses", func() {
+	var (
+		jobSupervisor *fakejobsuper.FakeJobSupervisor
+		listProcesses action.ListProcessesAction
+	)
+
+	BeforeEach(func() {
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		listProcesses = action.NewListProcesses(jobSupervisor)
+	})
+
+	AssertActionIsNotAsynchronous(listProcesses)
+	AssertActionIsNotPersistent(listProcesses)
+	AssertActionIsLoggable(listProcesses)
+
+	AssertActionIsNotResumable(listProcesses)
+	AssertActionIsNotCancelable(listProcesses)
+
+	Describe("Run", func() {
+		It("returns the complete status of all processes", func() {
+			exitStatus := 137
+			jobSupervisor.ProcessStatusesStatuses = []boshjobsuper.ProcessStatus{
+				{
+					Process: boshjobsuper.Process{
+						Name:   "fake-process-1",
+						State:  "running",
+						PID:    42,
+						Uptime: boshjobsuper.UptimeVitals{Secs: 10},
+						Memory: boshjobsuper.MemoryVitals{Kb: 100, Percent: 0.5},
+						CPU:    boshjobsuper.CPUVitals{Total: 1.5},
+					},
+					Monitored:      true,
+					Children:       2,
+					Restarts:       3,
+					LastExitStatus: &exitStatus,
+				},
+				{
+					Process: boshjobsuper.Process{
+						Name:  "fake-process-2",
+						State: "unknown",
+					},
+					PendingAction: "start",
+				},
+			}
+
+			statuses, err := listProcesses.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			statusesJSON, err := json.Marshal(statuses)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statusesJSON).To(MatchJSON(`[
+				{"name":"fake-process-1","state":"running","pid":42,"uptime":{"secs":10},"mem":{"kb":100,"percent":0.5},"cpu":{"total":1.5},"monitored":true,"children":2,"restarts":3,"last_exit_status":137},
+				{"name":"fake-process-2","state":"unknown","uptime":{},"mem":{"percent":0},"cpu":{"total":0},"monitored":false,"pending_action":"start","restarts":0}
+			]`))
+		})
+
+		It("returns error when getting the statuses fails", func() {
+			jobSupervisor.ProcessStatusesError = errors.New("fake-status-err")
+
+			_, err := listProcesses.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Getting process statuses: fake-status-err"))
+		})
+	})
+})
@@ -0,0 +1,144 @@
+package action
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/platform/cert"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+const jobTrustedCertsBundle = "bosh-trusted-certs.pem"
+
+// ManageCertificatesOptions add the PEM certificates in Add and remove the
+// certificates with the SHA-256 fingerprints in Remove.
+type ManageCertificatesOptions struct {
+	Add    string   `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// ManageCertificatesResult lists the changed certificates, the ones trusted
+// afterwards and the stores that were updated: the system trust store and
+// the trusted certificate directories of jobs.
+type ManageCertificatesResult struct {
+	Added         []cert.TrustedCertificate `json:"added"`
+	Removed       []cert.TrustedCertificate `json:"removed"`
+	Trusted       []cert.TrustedCertificate `json:"trusted"`
+	UpdatedStores []string                  `json:"updated_stores"`
+}
+
+// ManageCertificatesAction adds and removes individual trusted CA
+// certificates, unlike update_settings which replaces all of them
+type ManageCertificatesAction struct {
+	settingsService    boshsettings.Service
+	trustedCertManager cert.Manager
+	fs                 boshsys.FileSystem
+	dirProvider        boshdir.Provider
+}
+
+func NewManageCertificates(
+	settingsService boshsettings.Service,
+	trustedCertManager cert.Manager,
+	fs boshsys.FileSystem,
+	dirProvider boshdir.Provider,
+) ManageCertificatesAction {
+	return ManageCertificatesAction{
+		settingsService:    settingsService,
+		trustedCertManager: trustedCertManager,
+		fs:                 fs,
+		dirProvider:        dirProvider,
+	}
+}
+
+func (a ManageCertificatesAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a ManageCertificatesAction) IsPersistent() bool {
+	return false
+}
+
+func (a ManageCertificatesAction) IsLoggable() bool {
+	return true
+}
+
+func (a ManageCertificatesAction) Run(options ManageCertificatesOptions) (ManageCertificatesResult, error) {
+	report, err := a.trustedCertManager.ManageCertificates(cert.CertificateChanges{
+		Add:    options.Add,
+		Remove: options.Remove,
+	})
+	if err != nil {
+		return ManageCertificatesResult{}, bosherr.WrapError(err, "Managing trusted certificates")
+	}
+
+	result := ManageCertificatesResult{
+		Added:         report.Added,
+		Removed:       report.Removed,
+		Trusted:       report.Trusted,
+		UpdatedStores: report.UpdatedStores,
+	}
+
+	if !report.Changed() {
+		return result, nil
+	}
+
+	jobDirs, err := a.updateJobTrustedCerts(report.Bundle())
+	result.UpdatedStores = append(result.UpdatedStores, jobDirs...)
+	if err != nil {
+		return result, err
+	}
+
+	// Keep the settings in line with the trust store so that a later
+	// update_settings or get_state sees the certificates trusted now
+	updateSettings := a.settingsService.GetSettings().UpdateSettings
+	updateSettings.TrustedCerts = report.Bundle()
+
+	err = a.settingsService.SaveUpdateSettings(updateSettings)
+	if err != nil {
+		return result, bosherr.WrapError(err, "Saving trusted certificates")
+	}
+
+	return result, nil
+}
+
+// updateJobTrustedCerts writes the bundle into the trusted certificate
+// directory of each installed job and returns the directories it updated.
+// The job bundles themselves are left alone.
+func (a ManageCertificatesAction) updateJobTrustedCerts(bundle string) ([]string, error) {
+	jobDirs, err := a.fs.Glob(filepath.Join(a.dirProvider.JobsDir(), "*"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding installed jobs")
+	}
+
+	updated := []string{}
+	for _, jobDir := range jobDirs {
+		dir := a.dirProvider.JobTrustedCertsDir(filepath.Base(jobDir))
+
+		err = a.fs.MkdirAll(dir, os.FileMode(0755))
+		if err != nil {
+			return updated, bosherr.WrapErrorf(err, "Creating trusted certificates directory %s", dir)
+		}
+
+		err = a.fs.WriteFileString(filepath.Join(dir, jobTrustedCertsBundle), bundle)
+		if err != nil {
+			return updated, bosherr.WrapErrorf(err, "Writing trusted certificates to %s", dir)
+		}
+
+		updated = append(updated, dir)
+	}
+
+	return updated, nil
+}
+
+func (a ManageCertificatesAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ManageCertificatesAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,152 @@
+package action_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/cert"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/cert/certfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+var _ = Describe("ManageCertificates", func() {
+	var (
+		settingsService          *fakesettings.FakeSettingsService
+		certManager              *certfakes.FakeManager
+		fs                       *fakesys.FakeFileSystem
+		dirProvider              boshdirs.Provider
+		manageCertificatesAction action.ManageCertificatesAction
+		certA, certB             cert.TrustedCertificate
+		jobTrustedCertsDir       string
+		otherJobTrustedCertsDir  string
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		settingsService.Settings.UpdateSettings = boshsettings.UpdateSettings{
+			Mbus:         boshsettings.MBus{URLs: []string{"fake-mbus"}},
+			TrustedCerts: "fake-cert-a",
+		}
+		certManager = &certfakes.FakeManager{}
+		fs = fakesys.NewFakeFileSystem()
+		dirProvider = boshdirs.NewProvider("/var/vcap")
+		manageCertificatesAction = action.NewManageCertificates(settingsService, certManager, fs, dirProvider)
+
+		certA = cert.TrustedCertificate{Fingerprint: "fake-fingerprint-a", Subject: "CN=a", PEM: "fake-cert-a"}
+		certB = cert.TrustedCertificate{Fingerprint: "fake-fingerprint-b", Subject: "CN=b", PEM: "fake-cert-b"}
+
+		jobTrustedCertsDir = filepath.Join(dirProvider.DataDir(), "sys", "certs", "fake-job")
+		otherJobTrustedCertsDir = filepath.Join(dirProvider.DataDir(), "sys", "certs", "other-job")
+		fs.SetGlob(filepath.Join(dirProvider.JobsDir(), "*"), []string{
+			filepath.Join(dirProvider.JobsDir(), "fake-job"),
+			filepath.Join(dirProvider.JobsDir(), "other-job"),
+		})
+	})
+
+	AssertActionIsAsynchronous(manageCertificatesAction)
+	AssertActionIsNotPersistent(manageCertificatesAction)
+	AssertActionIsLoggable(manageCertificatesAction)
+
+	AssertActionIsNotCancelable(manageCertificatesAction)
+	AssertActionIsNotResumable(manageCertificatesAction)
+
+	Context("when the trusted certificates change", func() {
+		BeforeEach(func() {
+			certManager.ManageCertificatesReturns(cert.CertificateReport{
+				Added:         []cert.TrustedCertificate{certB},
+				Removed:       []cert.TrustedCertificate{},
+				Trusted:       []cert.TrustedCertificate{certA, certB},
+				UpdatedStores: []string{"ca-certificates"},
+			}, nil)
+		})
+
+		It("passes the changes to the cert manager", func() {
+			_, err := manageCertificatesAction.Run(action.ManageCertificatesOptions{Add: "fake-cert-b", Remove: []string{"fake-fingerprint-c"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(certManager.ManageCertificatesArgsForCall(0)).To(Equal(cert.CertificateChanges{
+				Add:    "fake-cert-b",
+				Remove: []string{"fake-fingerprint-c"},
+			}))
+		})
+
+		It("reports the certificates and the stores that were updated", func() {
+			result, err := manageCertificatesAction.Run(action.ManageCertificatesOptions{Add: "fake-cert-b"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result).To(Equal(action.ManageCertificatesResult{
+				Added:         []cert.TrustedCertificate{certB},
+				Removed:       []cert.TrustedCertificate{},
+				Trusted:       []cert.TrustedCertificate{certA, certB},
+				UpdatedStores: []string{"ca-certificates", jobTrustedCertsDir, otherJobTrustedCertsDir},
+			}))
+		})
+
+		It("writes the trusted certificates outside of the job bundles", func() {
+			_, err := manageCertificatesAction.Run(action.ManageCertificatesOptions{Add: "fake-cert-b"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(filepath.Join(dirProvider.JobsDir(), "fake-job", "config", "trusted_certs", "bosh-trusted-certs.pem"))).To(BeFalse())
+
+			Expect(fs.ReadFileString(filepath.Join(jobTrustedCertsDir, "bosh-trusted-certs.pem"))).To(Equal("fake-cert-a\nfake-cert-b"))
+			Expect(fs.ReadFileString(filepath.Join(otherJobTrustedCertsDir, "bosh-trusted-certs.pem"))).To(Equal("fake-cert-a\nfake-cert-b"))
+		})
+
+		It("saves the trusted certificates in the update settings", func() {
+			_, err := manageCertificatesAction.Run(action.ManageCertificatesOptions{Add: "fake-cert-b"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(settingsService.SaveUpdateSettingsCallCount).To(Equal(1))
+			Expect(settingsService.SaveUpdateSettingsLastArg.TrustedCerts).To(Equal("fake-cert-a\nfake-cert-b"))
+			Expect(settingsService.SaveUpdateSettingsLastArg.Mbus.URLs).To(Equal([]string{"fake-mbus"}))
+		})
+
+		It("returns an error when writing into a job directory fails", func() {
+			fs.WriteFileError = errors.New("fake-write-error")
+
+			result, err := manageCertificatesAction.Run(action.ManageCertificatesOptions{Add: "fake-cert-b"})
+			Expect(err).To(MatchError(ContainSubstring("Writing trusted certificates to " + jobTrustedCertsDir)))
+			Expect(result.UpdatedStores).To(Equal([]string{"ca-certificates"}))
+			Expect(settingsService.SaveUpdateSettingsCallCount).To(Equal(0))
+		})
+
+		It("returns an error when saving the update settings fails", func() {
+			settingsService.SaveUpdateSettingsErr = errors.New("fake-save-error")
+
+			_, err := manageCertificatesAction.Run(action.ManageCertificatesOptions{Add: "fake-cert-b"})
+			Expect(err).To(MatchError("Saving trusted certificates: fake-save-error"))
+		})
+	})
+
+	It("leaves jobs and settings alone when the trusted certificates do not change", func() {
+		certManager.ManageCertificatesReturns(cert.CertificateReport{
+			Added:         []cert.TrustedCertificate{},
+			Removed:       []cert.TrustedCertificate{},
+			Trusted:       []cert.TrustedCertificate{certA},
+			UpdatedStores: []string{},
+		}, nil)
+
+		result, err := manageCertificatesAction.Run(action.ManageCertificatesOptions{Add: "fake-cert-a"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.UpdatedStores).To(BeEmpty())
+
+		Expect(fs.FileExists(filepath.Join(jobTrustedCertsDir, "bosh-trusted-certs.pem"))).To(BeFalse())
+		Expect(settingsService.SaveUpdateSettingsCallCount).To(Equal(0))
+	})
+
+	It("returns an error when managing the certificates fails", func() {
+		certManager.ManageCertificatesReturns(cert.CertificateReport{}, errors.New("fake-manage-error"))
+
+		_, err := manageCertificatesAction.Run(action.ManageCertificatesOptions{Add: "fake-cert-b"})
+		Expect(err).To(MatchError("Managing trusted certificates: fake-manage-error"))
+		Expect(settingsService.SaveUpdateSettingsCallCount).To(Equal(0))
+	})
+})
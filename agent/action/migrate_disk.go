@@ -1,25 +1,36 @@
 package action
 
 import (
-	"errors"
-
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
 
+const migrateDiskStageCopying = "copying"
+
+// MigrateDiskAction copies the persistent disk to the new disk mounted at the
+// migration target and reports how much of it was copied. The action is
+// persistent, when the agent restarts during a migration it continues the
+// copy instead of starting over.
 type MigrateDiskAction struct {
-	platform    boshplatform.Platform
-	dirProvider boshdirs.Provider
+	platform      boshplatform.Platform
+	dirProvider   boshdirs.Provider
+	stageReporter boshtask.StageReporter
+	cancelSignals boshtask.CancelSignals
 }
 
 func NewMigrateDisk(
 	platform boshplatform.Platform,
 	dirProvider boshdirs.Provider,
+	stageReporter boshtask.StageReporter,
+	cancelSignals boshtask.CancelSignals,
 ) (action MigrateDiskAction) {
 	action.platform = platform
 	action.dirProvider = dirProvider
+	action.stageReporter = stageReporter
+	action.cancelSignals = cancelSignals
 	return
 }
 
@@ -28,7 +39,7 @@ func (a MigrateDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
 }
 
 func (a MigrateDiskAction) IsPersistent() bool {
-	return false
+	return true
 }
 
 func (a MigrateDiskAction) IsLoggable() bool {
@@ -36,7 +47,7 @@ func (a MigrateDiskAction) IsLoggable() bool {
 }
 
 func (a MigrateDiskAction) Run() (value interface{}, err error) {
-	err = a.platform.MigratePersistentDisk(a.dirProvider.StoreDir(), a.dirProvider.StoreMigrationDir())
+	err = a.platform.MigratePersistentDisk(a.dirProvider.StoreDir(), a.dirProvider.StoreMigrationDir(), a.reportProgress, a.cancelSignals.RunningCancelSignal())
 	if err != nil {
 		err = bosherr.WrapError(err, "Migrating persistent disk")
 		return
@@ -46,10 +57,34 @@ func (a MigrateDiskAction) Run() (value interface{}, err error) {
 	return
 }
 
+func (a MigrateDiskAction) reportProgress(percent int) {
+	if a.stageReporter == nil {
+		return
+	}
+
+	a.stageReporter.ReportStage(boshtask.StageProgress{
+		Stage:   migrateDiskStageCopying,
+		Percent: percent,
+	})
+}
+
 func (a MigrateDiskAction) Resume() (interface{}, error) {
-	return nil, errors.New("not supported")
+	// The new disk is not mounted again after a reboot, copying without it
+	// would fill up the disk below the migration target
+	_, mounted, err := a.platform.IsMountPoint(a.dirProvider.StoreMigrationDir())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Checking migration target mount point")
+	}
+
+	if !mounted {
+		return nil, bosherr.Errorf("Resuming persistent disk migration: `%s' is not mounted", a.dirProvider.StoreMigrationDir())
+	}
+
+	return a.Run()
 }
 
+// Cancel stops copying and mounts the old disk writable again. What was
+// copied already is kept, running the migration again continues from there.
 func (a MigrateDiskAction) Cancel() error {
-	return errors.New("not supported")
+	return nil
 }
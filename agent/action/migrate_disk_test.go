@@ -1,12 +1,17 @@
 package action_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
@@ -15,20 +20,23 @@ var _ = Describe("Testing with Ginkgo", func() {
 	var (
 		migrateDiskAction action.MigrateDiskAction
 		platform          *platformfakes.FakePlatform
+		stageReporter     *faketask.FakeStageReporter
+		taskService       *faketask.FakeService
 	)
 
 	BeforeEach(func() {
 		platform = &platformfakes.FakePlatform{}
+		stageReporter = &faketask.FakeStageReporter{}
+		taskService = &faketask.FakeService{}
 		dirProvider := boshdirs.NewProvider("/foo")
-		migrateDiskAction = action.NewMigrateDisk(platform, dirProvider)
+		migrateDiskAction = action.NewMigrateDisk(platform, dirProvider, stageReporter, taskService)
 	})
 
 	AssertActionIsAsynchronous(migrateDiskAction)
-	AssertActionIsNotPersistent(migrateDiskAction)
+	AssertActionIsPersistent(migrateDiskAction)
 	AssertActionIsLoggable(migrateDiskAction)
 
-	AssertActionIsNotResumable(migrateDiskAction)
-	AssertActionIsNotCancelable(migrateDiskAction)
+	AssertActionIsCancelable(migrateDiskAction)
 
 	It("migrate disk migrateDiskAction run", func() {
 		value, err := migrateDiskAction.Run()
@@ -36,8 +44,68 @@ var _ = Describe("Testing with Ginkgo", func() {
 		boshassert.MatchesJSONString(GinkgoT(), value, "{}")
 
 		Expect(platform.MigratePersistentDiskCallCount()).To(Equal(1))
-		fromPath, toPath := platform.MigratePersistentDiskArgsForCall(0)
+		fromPath, toPath, _, _ := platform.MigratePersistentDiskArgsForCall(0)
 		Expect(fromPath).To(boshassert.MatchPath("/foo/store"))
 		Expect(toPath).To(boshassert.MatchPath("/foo/store_migration_target"))
 	})
+
+	It("passes the cancel signal of the running task to the migration", func() {
+		cancel := boshtask.NewCancelSignal(make(chan struct{}))
+		taskService.RunningCancelSignalResult = cancel
+
+		_, err := migrateDiskAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		_, _, _, cancelArg := platform.MigratePersistentDiskArgsForCall(0)
+		Expect(cancelArg).To(BeIdenticalTo(cancel))
+	})
+
+	It("reports the progress of the copy", func() {
+		platform.MigratePersistentDiskStub = func(_, _ string, progress boshplatform.MigrationProgressFunc, _ *boshtask.CancelSignal) error {
+			progress(10)
+			progress(55)
+			return nil
+		}
+
+		_, err := migrateDiskAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(stageReporter.Reported).To(Equal([]boshtask.StageProgress{
+			{Stage: "copying", Percent: 10},
+			{Stage: "copying", Percent: 55},
+		}))
+	})
+
+	It("returns an error when migrating fails", func() {
+		platform.MigratePersistentDiskReturns(errors.New("fake-migrate-error"))
+
+		_, err := migrateDiskAction.Run()
+		Expect(err).To(MatchError("Migrating persistent disk: fake-migrate-error"))
+	})
+
+	Describe("Resume", func() {
+		It("continues the migration when the migration target is still mounted", func() {
+			platform.IsMountPointReturns("/dev/sdc1", true, nil)
+
+			value, err := migrateDiskAction.Resume()
+			Expect(err).ToNot(HaveOccurred())
+			boshassert.MatchesJSONString(GinkgoT(), value, "{}")
+
+			Expect(platform.IsMountPointArgsForCall(0)).To(boshassert.MatchPath("/foo/store_migration_target"))
+			Expect(platform.MigratePersistentDiskCallCount()).To(Equal(1))
+		})
+
+		It("returns an error without migrating when the migration target is not mounted", func() {
+			_, err := migrateDiskAction.Resume()
+			Expect(err).To(MatchError(ContainSubstring("is not mounted")))
+			Expect(platform.MigratePersistentDiskCallCount()).To(Equal(0))
+		})
+
+		It("returns an error when checking the migration target fails", func() {
+			platform.IsMountPointReturns("", false, errors.New("fake-mount-point-error"))
+
+			_, err := migrateDiskAction.Resume()
+			Expect(err).To(MatchError("Checking migration target mount point: fake-mount-point-error"))
+		})
+	})
 })
@@ -31,7 +31,12 @@ func (a PrepareAction) IsLoggable() bool {
 }
 
 func (a PrepareAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
-	err := a.applier.Prepare(desiredSpec)
+	err := desiredSpec.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	err = a.applier.Prepare(desiredSpec)
 	if err != nil {
 		return "", bosherr.WrapError(err, "Preparing apply spec")
 	}
@@ -0,0 +1,97 @@
+package action
+
+import (
+	"errors"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/snapshot"
+)
+
+const (
+	preSnapshotScriptName  = "pre-snapshot"
+	postSnapshotScriptName = "post-snapshot"
+
+	defaultMaxFreezeSeconds = 60
+	maxMaxFreezeSeconds     = 600
+)
+
+// PrepareSnapshotOptions limit how long the pre-snapshot scripts may run and
+// how long the persistent disk stays frozen if finish_snapshot never comes.
+type PrepareSnapshotOptions struct {
+	TimeoutSeconds   int `json:"timeout_seconds"`
+	MaxFreezeSeconds int `json:"max_freeze_seconds"`
+}
+
+type PrepareSnapshotResponse struct {
+	Frozen bool `json:"frozen"`
+}
+
+// PrepareSnapshotAction quiesces the jobs with their pre-snapshot scripts and
+// freezes the persistent disk filesystem so that the snapshot taken by the CPI
+// is consistent for the jobs. finish_snapshot undoes both.
+type PrepareSnapshotAction struct {
+	runScriptAction RunScriptAction
+	freezer         snapshot.Freezer
+}
+
+func NewPrepareSnapshot(runScriptAction RunScriptAction, freezer snapshot.Freezer) PrepareSnapshotAction {
+	return PrepareSnapshotAction{
+		runScriptAction: runScriptAction,
+		freezer:         freezer,
+	}
+}
+
+func (a PrepareSnapshotAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a PrepareSnapshotAction) IsPersistent() bool {
+	return false
+}
+
+func (a PrepareSnapshotAction) IsLoggable() bool {
+	return true
+}
+
+func (a PrepareSnapshotAction) Run(options PrepareSnapshotOptions) (PrepareSnapshotResponse, error) {
+	maxFreezeSeconds := options.MaxFreezeSeconds
+	if maxFreezeSeconds == 0 {
+		maxFreezeSeconds = defaultMaxFreezeSeconds
+	}
+
+	if maxFreezeSeconds < 0 || maxFreezeSeconds > maxMaxFreezeSeconds {
+		return PrepareSnapshotResponse{}, bosherr.Errorf("Invalid max freeze duration %ds, must be at most %ds", maxFreezeSeconds, maxMaxFreezeSeconds)
+	}
+
+	scriptOptions := RunScriptOptions{TimeoutSeconds: options.TimeoutSeconds}
+
+	_, err := a.runScriptAction.Run(preSnapshotScriptName, scriptOptions)
+	if err != nil {
+		a.resumeJobs(scriptOptions)
+		return PrepareSnapshotResponse{}, bosherr.WrapError(err, "Running pre-snapshot scripts")
+	}
+
+	frozen, err := a.freezer.Freeze(time.Duration(maxFreezeSeconds) * time.Second)
+	if err != nil {
+		a.resumeJobs(scriptOptions)
+		return PrepareSnapshotResponse{}, bosherr.WrapError(err, "Freezing persistent disk")
+	}
+
+	return PrepareSnapshotResponse{Frozen: frozen}, nil
+}
+
+// resumeJobs lets jobs that already quiesced continue when the snapshot
+// cannot be prepared. Failures are ignored since the original error matters.
+func (a PrepareSnapshotAction) resumeJobs(scriptOptions RunScriptOptions) {
+	_, _ = a.runScriptAction.Run(postSnapshotScriptName, scriptOptions)
+}
+
+func (a PrepareSnapshotAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a PrepareSnapshotAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,128 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeapplyspec "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/script/scriptfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/snapshot/snapshotfakes"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+)
+
+var _ = Describe("PrepareSnapshot", func() {
+	var (
+		jobScriptProvider *scriptfakes.FakeJobScriptProvider
+		parallelScript    *scriptfakes.FakeCancellableScript
+		specService       *fakeapplyspec.FakeV1Service
+		freezer           *snapshotfakes.FakeFreezer
+		prepareSnapshot   action.PrepareSnapshotAction
+	)
+
+	BeforeEach(func() {
+		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
+		parallelScript = &scriptfakes.FakeCancellableScript{}
+		jobScriptProvider.NewParallelScriptReturns(parallelScript)
+		specService = fakeapplyspec.NewFakeV1Service()
+		specService.Spec.RenderedTemplatesArchiveSpec = &applyspec.RenderedTemplatesArchiveSpec{}
+		freezer = &snapshotfakes.FakeFreezer{}
+		freezer.FreezeReturns(true, nil)
+
+		runScript := action.NewRunScript(jobScriptProvider, specService, fakeappl.NewFakeApplier(), &faketask.FakeOutputReporter{}, boshlog.NewLogger(boshlog.LevelNone))
+		prepareSnapshot = action.NewPrepareSnapshot(runScript, freezer)
+	})
+
+	AssertActionIsAsynchronous(prepareSnapshot)
+	AssertActionIsNotPersistent(prepareSnapshot)
+	AssertActionIsLoggable(prepareSnapshot)
+
+	AssertActionIsNotResumable(prepareSnapshot)
+	AssertActionIsNotCancelable(prepareSnapshot)
+
+	Describe("Run", func() {
+		scriptNames := func() []string {
+			var names []string
+			for i := 0; i < jobScriptProvider.NewParallelScriptCallCount(); i++ {
+				name, _ := jobScriptProvider.NewParallelScriptArgsForCall(i)
+				names = append(names, name)
+			}
+			return names
+		}
+
+		It("runs the pre-snapshot scripts and then freezes the persistent disk", func() {
+			parallelScript.RunStub = func() error {
+				Expect(freezer.FreezeCallCount()).To(Equal(0))
+				return nil
+			}
+
+			response, err := prepareSnapshot.Run(action.PrepareSnapshotOptions{MaxFreezeSeconds: 30})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response).To(Equal(action.PrepareSnapshotResponse{Frozen: true}))
+
+			Expect(scriptNames()).To(Equal([]string{"pre-snapshot"}))
+			Expect(freezer.FreezeArgsForCall(0)).To(Equal(30 * time.Second))
+		})
+
+		It("passes the timeout to the scripts", func() {
+			specService.Spec.JobSpec.JobTemplateSpecs = []applyspec.JobTemplateSpec{{Name: "fake-job"}}
+			jobScriptProvider.NewScriptReturns(&scriptfakes.FakeScript{})
+
+			_, err := prepareSnapshot.Run(action.PrepareSnapshotOptions{TimeoutSeconds: 45})
+			Expect(err).ToNot(HaveOccurred())
+
+			jobName, scriptName, _, _, timeout := jobScriptProvider.NewScriptArgsForCall(0)
+			Expect(jobName).To(Equal("fake-job"))
+			Expect(scriptName).To(Equal("pre-snapshot"))
+			Expect(timeout).To(Equal(45 * time.Second))
+		})
+
+		It("freezes for a minute by default", func() {
+			_, err := prepareSnapshot.Run(action.PrepareSnapshotOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(freezer.FreezeArgsForCall(0)).To(Equal(time.Minute))
+		})
+
+		It("reports that nothing was frozen without a persistent disk", func() {
+			freezer.FreezeReturns(false, nil)
+
+			response, err := prepareSnapshot.Run(action.PrepareSnapshotOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Frozen).To(BeFalse())
+		})
+
+		It("rejects freezing for longer than ten minutes", func() {
+			_, err := prepareSnapshot.Run(action.PrepareSnapshotOptions{MaxFreezeSeconds: 601})
+			Expect(err).To(MatchError("Invalid max freeze duration 601s, must be at most 600s"))
+			Expect(parallelScript.RunCallCount()).To(Equal(0))
+			Expect(freezer.FreezeCallCount()).To(Equal(0))
+		})
+
+		It("resumes the jobs when the pre-snapshot scripts fail", func() {
+			parallelScript.RunReturnsOnCall(0, errors.New("fake-script-err"))
+
+			_, err := prepareSnapshot.Run(action.PrepareSnapshotOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Running pre-snapshot scripts: fake-script-err"))
+
+			Expect(scriptNames()).To(Equal([]string{"pre-snapshot", "post-snapshot"}))
+			Expect(freezer.FreezeCallCount()).To(Equal(0))
+		})
+
+		It("resumes the jobs when freezing fails", func() {
+			freezer.FreezeReturns(false, errors.New("fake-freeze-err"))
+
+			_, err := prepareSnapshot.Run(action.PrepareSnapshotOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Freezing persistent disk: fake-freeze-err"))
+
+			Expect(scriptNames()).To(Equal([]string{"pre-snapshot", "post-snapshot"}))
+		})
+	})
+})
@@ -47,6 +47,15 @@ var _ = Describe("PrepareAction", func() {
 			})
 		})
 
+		It("rejects malformed specs without preparing them", func() {
+			index := -1
+
+			_, err := prepareAction.Run(boshas.V1ApplySpec{Index: &index})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("index: must not be negative"))
+			Expect(applier.Prepared).To(BeFalse())
+		})
+
 		Context("when applier fails preparing vm", func() {
 			It("returns error", func() {
 				applier.PrepareError = errors.New("fake-prepare-error")
@@ -0,0 +1,116 @@
+package action
+
+import (
+	"errors"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	blobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/profiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+const (
+	defaultProfileCPUDuration = 30 * time.Second
+
+	// Tasks run one at a time, so other tasks wait for profile
+	maxProfileCPUDuration = 5 * time.Minute
+)
+
+var defaultProfiles = []string{profiler.CPUProfile, "heap", "goroutine"}
+
+// ProfileRequest selects the profiles of the agent to capture, by default a
+// CPU profile taken for CPUSeconds, a heap profile and goroutine stacks.
+// StackDumpJobs are jobs whose processes are sent SIGQUIT to dump their
+// stacks to their logs. The captured tarball is uploaded to SignedURL when
+// given and to the blobstore otherwise.
+type ProfileRequest struct {
+	Profiles         []string          `json:"profiles"`
+	CPUSeconds       int               `json:"cpu_seconds"`
+	StackDumpJobs    []string          `json:"stack_dump_jobs"`
+	SignedURL        string            `json:"signed_url"`
+	BlobstoreHeaders map[string]string `json:"blobstore_headers"`
+}
+
+type ProfileResponse struct {
+	BlobstoreID string `json:"blobstore_id,omitempty"`
+	SHA1Digest  string `json:"sha1"`
+}
+
+// ProfileAction captures diagnostic profiles of the agent, so hangs and leaks
+// can be debugged without attaching debuggers over ssh.
+type ProfileAction struct {
+	profiler  profiler.Profiler
+	blobstore blobdelegator.BlobstoreDelegator
+
+	cancelSignals boshtask.CancelSignals
+}
+
+func NewProfile(
+	agentProfiler profiler.Profiler,
+	blobstore blobdelegator.BlobstoreDelegator,
+	cancelSignals boshtask.CancelSignals,
+) (action ProfileAction) {
+	action.profiler = agentProfiler
+	action.blobstore = blobstore
+	action.cancelSignals = cancelSignals
+	return
+}
+
+func (a ProfileAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a ProfileAction) IsPersistent() bool {
+	return false
+}
+
+func (a ProfileAction) IsLoggable() bool {
+	return true
+}
+
+func (a ProfileAction) Run(request ProfileRequest) (ProfileResponse, error) {
+	cancel := a.cancelSignals.RunningCancelSignal()
+
+	options := profiler.Options{
+		Profiles:      request.Profiles,
+		CPUDuration:   defaultProfileCPUDuration,
+		StackDumpJobs: request.StackDumpJobs,
+	}
+
+	if len(options.Profiles) == 0 && len(options.StackDumpJobs) == 0 {
+		options.Profiles = defaultProfiles
+	}
+
+	if request.CPUSeconds != 0 {
+		options.CPUDuration = time.Duration(request.CPUSeconds) * time.Second
+		if request.CPUSeconds < 0 || options.CPUDuration > maxProfileCPUDuration {
+			return ProfileResponse{}, bosherr.Errorf("Profiling the CPU for %ds, which is not between 1s and %s", request.CPUSeconds, maxProfileCPUDuration)
+		}
+	}
+
+	tarball, err := a.profiler.Capture(options, cancel)
+	if err != nil {
+		return ProfileResponse{}, bosherr.WrapError(err, "Capturing profiles")
+	}
+
+	defer func() {
+		_ = a.profiler.CleanUp(tarball) //nolint:errcheck
+	}()
+
+	blobID, digest, err := a.blobstore.Write(request.SignedURL, tarball, request.BlobstoreHeaders)
+	if err != nil {
+		return ProfileResponse{}, bosherr.WrapError(err, "Create file on blobstore")
+	}
+
+	return ProfileResponse{BlobstoreID: blobID, SHA1Digest: digest.String()}, nil
+}
+
+func (a ProfileAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ProfileAction) Cancel() error {
+	return nil
+}
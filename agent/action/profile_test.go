@@ -0,0 +1,109 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/profiler"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/profiler/profilerfakes"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+)
+
+var _ = Describe("ProfileAction", func() {
+	var (
+		agentProfiler *profilerfakes.FakeProfiler
+		blobstore     *fakeblobdelegator.FakeBlobstoreDelegator
+		action        ProfileAction
+	)
+
+	BeforeEach(func() {
+		agentProfiler = &profilerfakes.FakeProfiler{}
+		agentProfiler.CaptureReturns("/fake-profile.tgz", nil)
+
+		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
+		blobstore.WriteReturns("fake-blob-id", boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil)
+
+		action = NewProfile(agentProfiler, blobstore, faketask.NewFakeService())
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotPersistent(action)
+	AssertActionIsNotResumable(action)
+	AssertActionIsCancelable(action)
+
+	Describe("Run", func() {
+		It("uploads the captured profiles", func() {
+			response, err := action.Run(ProfileRequest{
+				Profiles:      []string{"heap"},
+				CPUSeconds:    10,
+				StackDumpJobs: []string{"web"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response).To(Equal(ProfileResponse{BlobstoreID: "fake-blob-id", SHA1Digest: "fake-sha1"}))
+
+			options, _ := agentProfiler.CaptureArgsForCall(0)
+			Expect(options).To(Equal(profiler.Options{
+				Profiles:      []string{"heap"},
+				CPUDuration:   10 * time.Second,
+				StackDumpJobs: []string{"web"},
+			}))
+
+			signedURL, path, _ := blobstore.WriteArgsForCall(0)
+			Expect(signedURL).To(BeEmpty())
+			Expect(path).To(Equal("/fake-profile.tgz"))
+
+			Expect(agentProfiler.CleanUpCallCount()).To(Equal(1))
+			Expect(agentProfiler.CleanUpArgsForCall(0)).To(Equal("/fake-profile.tgz"))
+		})
+
+		It("captures a 30s CPU profile, the heap and goroutines by default", func() {
+			_, err := action.Run(ProfileRequest{})
+			Expect(err).ToNot(HaveOccurred())
+
+			options, _ := agentProfiler.CaptureArgsForCall(0)
+			Expect(options).To(Equal(profiler.Options{
+				Profiles:    []string{"cpu", "heap", "goroutine"},
+				CPUDuration: 30 * time.Second,
+			}))
+		})
+
+		It("uploads the profiles to the signed URL when given", func() {
+			_, err := action.Run(ProfileRequest{SignedURL: "fake-signed-url", BlobstoreHeaders: map[string]string{"key": "value"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			signedURL, _, headers := blobstore.WriteArgsForCall(0)
+			Expect(signedURL).To(Equal("fake-signed-url"))
+			Expect(headers).To(Equal(map[string]string{"key": "value"}))
+		})
+
+		It("does not profile the CPU for longer than five minutes", func() {
+			_, err := action.Run(ProfileRequest{CPUSeconds: 301})
+			Expect(err).To(MatchError("Profiling the CPU for 301s, which is not between 1s and 5m0s"))
+			Expect(agentProfiler.CaptureCallCount()).To(BeZero())
+		})
+
+		It("returns the error capturing profiles", func() {
+			agentProfiler.CaptureReturns("", errors.New("fake-capture-err"))
+
+			_, err := action.Run(ProfileRequest{})
+			Expect(err).To(MatchError("Capturing profiles: fake-capture-err"))
+			Expect(blobstore.WriteCallCount()).To(BeZero())
+		})
+
+		It("returns the error uploading profiles", func() {
+			blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-write-err"))
+
+			_, err := action.Run(ProfileRequest{})
+			Expect(err).To(MatchError("Create file on blobstore: fake-write-err"))
+			Expect(agentProfiler.CleanUpCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,170 @@
+package action
+
+import (
+	"errors"
+	"reflect"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/utils"
+	"github.com/cloudfoundry/bosh-agent/v2/platform"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/cert"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+const refreshSettingsActionLogTag = "refreshSettingsAction"
+
+// RefreshSettingsResult lists the settings that changed. Applied ones are in
+// effect, Ignored ones only take effect once the VM is recreated. Changes to
+// the mbus URL or blobstore restart the agent to connect again, the result
+// then only tells that the agent restarted.
+type RefreshSettingsResult struct {
+	Applied        []string `json:"applied"`
+	Ignored        []string `json:"ignored"`
+	AgentRestarted bool     `json:"agent_restarted,omitempty"`
+}
+
+// RefreshSettingsAction fetches the settings from the infrastructure again
+// and applies the changes that are safe to apply to a running VM
+type RefreshSettingsAction struct {
+	settingsService    boshsettings.Service
+	platform           platform.Platform
+	trustedCertManager cert.Manager
+	agentKiller        utils.Killer
+	logger             boshlog.Logger
+}
+
+func NewRefreshSettings(
+	settingsService boshsettings.Service,
+	platform platform.Platform,
+	trustedCertManager cert.Manager,
+	agentKiller utils.Killer,
+	logger boshlog.Logger,
+) RefreshSettingsAction {
+	return RefreshSettingsAction{
+		settingsService:    settingsService,
+		platform:           platform,
+		trustedCertManager: trustedCertManager,
+		agentKiller:        agentKiller,
+		logger:             logger,
+	}
+}
+
+func (a RefreshSettingsAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RefreshSettingsAction) IsPersistent() bool {
+	return true
+}
+
+func (a RefreshSettingsAction) IsLoggable() bool {
+	return true
+}
+
+func (a RefreshSettingsAction) Run() (RefreshSettingsResult, error) {
+	result := RefreshSettingsResult{Applied: []string{}, Ignored: []string{}}
+
+	oldSettings := a.settingsService.GetSettings()
+
+	err := a.settingsService.LoadSettings()
+	if err != nil {
+		return result, bosherr.WrapError(err, "Loading settings")
+	}
+
+	newSettings := a.settingsService.GetSettings()
+
+	if !reflect.DeepEqual(oldSettings.UpdateSettings.TrustedCerts, newSettings.UpdateSettings.TrustedCerts) {
+		err = a.trustedCertManager.UpdateCertificates(newSettings.UpdateSettings.TrustedCerts)
+		if err != nil {
+			return result, bosherr.WrapError(err, "Updating trusted certificates")
+		}
+
+		result.Applied = append(result.Applied, "trusted_certs")
+	}
+
+	if !reflect.DeepEqual(oldSettings.GetNtpServers(), newSettings.GetNtpServers()) {
+		err = a.platform.SetTimeWithNtpServers(newSettings.GetNtpServers())
+		if err != nil {
+			return result, bosherr.WrapError(err, "Setting up NTP servers")
+		}
+
+		result.Applied = append(result.Applied, "ntp")
+	}
+
+	restartNeeded := false
+
+	if oldSettings.GetMbusURL() != newSettings.GetMbusURL() {
+		result.Applied = append(result.Applied, "mbus")
+		restartNeeded = true
+	}
+
+	// The mbus handler reloads its certificates once it notices the change
+	if oldSettings.GetMbusCerts() != newSettings.GetMbusCerts() {
+		result.Applied = append(result.Applied, "mbus_certs")
+	}
+
+	// Encryption keys are read for every message
+	if !reflect.DeepEqual(oldSettings.GetMbusEncryption(), newSettings.GetMbusEncryption()) {
+		result.Applied = append(result.Applied, "mbus_encryption")
+	}
+
+	if !reflect.DeepEqual(oldSettings.GetBlobstore(), newSettings.GetBlobstore()) {
+		result.Applied = append(result.Applied, "blobstore")
+		restartNeeded = true
+	}
+
+	result.Ignored = ignoredSettingsChanges(oldSettings, newSettings)
+
+	a.logger.Info(refreshSettingsActionLogTag, "Applied settings changes %v, ignored settings changes %v", result.Applied, result.Ignored)
+
+	if restartNeeded {
+		a.agentKiller.KillAgent()
+		panic("This line of code should be unreachable due to killing of agent")
+	}
+
+	return result, nil
+}
+
+// ignoredSettingsChanges returns the changed settings that are only set up
+// when the VM boots
+func ignoredSettingsChanges(oldSettings, newSettings boshsettings.Settings) []string {
+	ignored := []string{}
+
+	if oldSettings.AgentID != newSettings.AgentID {
+		ignored = append(ignored, "agent_id")
+	}
+
+	if !reflect.DeepEqual(oldSettings.Networks, newSettings.Networks) {
+		ignored = append(ignored, "networks")
+	}
+
+	if !reflect.DeepEqual(oldSettings.Disks, newSettings.Disks) {
+		ignored = append(ignored, "disks")
+	}
+
+	if !reflect.DeepEqual(oldSettings.VM, newSettings.VM) {
+		ignored = append(ignored, "vm")
+	}
+
+	// The applied parts of the env are compared on their own
+	oldEnv, newEnv := oldSettings.Env, newSettings.Env
+	oldEnv.Bosh.Mbus, newEnv.Bosh.Mbus = boshsettings.MBus{}, boshsettings.MBus{}
+	oldEnv.Bosh.Blobstores, newEnv.Bosh.Blobstores = nil, nil
+	oldEnv.Bosh.NTP, newEnv.Bosh.NTP = nil, nil
+
+	if !reflect.DeepEqual(oldEnv, newEnv) {
+		ignored = append(ignored, "env")
+	}
+
+	return ignored
+}
+
+func (a RefreshSettingsAction) Resume() (interface{}, error) {
+	return RefreshSettingsResult{Applied: []string{}, Ignored: []string{}, AgentRestarted: true}, nil
+}
+
+func (a RefreshSettingsAction) Cancel() error {
+	return errors.New("not supported")
+}
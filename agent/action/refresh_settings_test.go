@@ -0,0 +1,155 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/utils/utilsfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/cert/certfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+var _ = Describe("RefreshSettings", func() {
+	var (
+		settingsService       *fakesettings.FakeSettingsService
+		platform              *platformfakes.FakePlatform
+		certManager           *certfakes.FakeManager
+		agentKiller           *utilsfakes.FakeKiller
+		newSettings           boshsettings.Settings
+		refreshSettingsAction action.RefreshSettingsAction
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		settingsService.Settings = boshsettings.Settings{
+			AgentID: "fake-agent-id",
+			Mbus:    "nats://fake-mbus",
+			NTP:     []string{"fake-ntp"},
+			Blobstore: boshsettings.Blobstore{
+				Type:    "dav",
+				Options: map[string]interface{}{"user": "fake-user"},
+			},
+		}
+		settingsService.Settings.UpdateSettings.TrustedCerts = "fake-certs"
+
+		newSettings = settingsService.Settings
+		settingsService.LoadedSettings = &newSettings
+
+		platform = &platformfakes.FakePlatform{}
+		certManager = &certfakes.FakeManager{}
+		agentKiller = &utilsfakes.FakeKiller{}
+
+		refreshSettingsAction = action.NewRefreshSettings(settingsService, platform, certManager, agentKiller, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AssertActionIsAsynchronous(refreshSettingsAction)
+	AssertActionIsPersistent(refreshSettingsAction)
+	AssertActionIsLoggable(refreshSettingsAction)
+
+	AssertActionIsNotCancelable(refreshSettingsAction)
+
+	It("loads the settings again without applying anything when they did not change", func() {
+		result, err := refreshSettingsAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(action.RefreshSettingsResult{Applied: []string{}, Ignored: []string{}}))
+
+		Expect(settingsService.SettingsWereLoaded).To(BeTrue())
+		Expect(certManager.UpdateCertificatesCallCount()).To(Equal(0))
+		Expect(platform.SetTimeWithNtpServersCallCount()).To(Equal(0))
+		Expect(agentKiller.KillAgentCallCount()).To(Equal(0))
+	})
+
+	It("updates changed trusted certificates", func() {
+		newSettings.UpdateSettings.TrustedCerts = "new-certs"
+
+		result, err := refreshSettingsAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Applied).To(Equal([]string{"trusted_certs"}))
+
+		Expect(certManager.UpdateCertificatesArgsForCall(0)).To(Equal("new-certs"))
+	})
+
+	It("sets up changed NTP servers", func() {
+		newSettings.Env.Bosh.NTP = []string{"new-ntp"}
+
+		result, err := refreshSettingsAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Applied).To(Equal([]string{"ntp"}))
+		Expect(result.Ignored).To(BeEmpty())
+
+		Expect(platform.SetTimeWithNtpServersArgsForCall(0)).To(Equal([]string{"new-ntp"}))
+	})
+
+	It("reports changes that need the VM to be recreated without applying them", func() {
+		newSettings.Networks = boshsettings.Networks{"fake-net": boshsettings.Network{IP: "10.0.0.2"}}
+		newSettings.Env.PersistentDiskFS = "xfs"
+
+		result, err := refreshSettingsAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(action.RefreshSettingsResult{Applied: []string{}, Ignored: []string{"networks", "env"}}))
+	})
+
+	It("restarts the agent when the mbus changes", func() {
+		newSettings.Mbus = "nats://new-mbus"
+
+		Expect(func() {
+			refreshSettingsAction.Run() //nolint:errcheck
+		}).To(Panic())
+		Expect(agentKiller.KillAgentCallCount()).To(Equal(1))
+	})
+
+	It("applies changed mbus certificates without restarting the agent", func() {
+		newSettings.Env.Bosh.Mbus.Cert = boshsettings.CertKeyPair{CA: "new-ca", Certificate: "new-cert", PrivateKey: "new-key"}
+
+		result, err := refreshSettingsAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Applied).To(Equal([]string{"mbus_certs"}))
+		Expect(agentKiller.KillAgentCallCount()).To(Equal(0))
+	})
+
+	It("applies changed mbus encryption keys without restarting the agent", func() {
+		newSettings.Env.Bosh.Mbus.Encryption = boshsettings.MbusEncryption{ActiveKeyID: "new-key", Keys: map[string]string{"new-key": "a2V5"}}
+
+		result, err := refreshSettingsAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Applied).To(Equal([]string{"mbus_encryption"}))
+		Expect(agentKiller.KillAgentCallCount()).To(Equal(0))
+	})
+
+	It("restarts the agent when the blobstore credentials change", func() {
+		newSettings.Blobstore.Options = map[string]interface{}{"user": "new-user"}
+
+		Expect(func() {
+			refreshSettingsAction.Run() //nolint:errcheck
+		}).To(Panic())
+		Expect(agentKiller.KillAgentCallCount()).To(Equal(1))
+	})
+
+	It("tells that the agent restarted when resumed", func() {
+		result, err := refreshSettingsAction.Resume()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(action.RefreshSettingsResult{Applied: []string{}, Ignored: []string{}, AgentRestarted: true}))
+	})
+
+	It("returns an error when loading the settings fails", func() {
+		settingsService.LoadSettingsError = errors.New("fake-load-error")
+
+		_, err := refreshSettingsAction.Run()
+		Expect(err).To(MatchError("Loading settings: fake-load-error"))
+	})
+
+	It("returns an error when updating the trusted certificates fails", func() {
+		newSettings.UpdateSettings.TrustedCerts = "new-certs"
+		certManager.UpdateCertificatesReturns(errors.New("fake-cert-error"))
+
+		_, err := refreshSettingsAction.Run()
+		Expect(err).To(MatchError("Updating trusted certificates: fake-cert-error"))
+	})
+})
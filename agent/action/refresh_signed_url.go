@@ -0,0 +1,58 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	blobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+)
+
+type RefreshSignedURLRequest struct {
+	RequestID        string            `json:"request_id"`
+	SignedURL        string            `json:"signed_url"`
+	BlobstoreHeaders map[string]string `json:"blobstore_headers"`
+}
+
+// RefreshSignedURLAction receives the director's answer to a signed URL
+// refresh requested by a blob transfer that is waiting for it.
+type RefreshSignedURLAction struct {
+	refresher blobdelegator.SignedURLRefresher
+}
+
+func NewRefreshSignedURL(refresher blobdelegator.SignedURLRefresher) RefreshSignedURLAction {
+	return RefreshSignedURLAction{refresher: refresher}
+}
+
+func (a RefreshSignedURLAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a RefreshSignedURLAction) IsPersistent() bool {
+	return false
+}
+
+func (a RefreshSignedURLAction) IsLoggable() bool {
+	return false
+}
+
+func (a RefreshSignedURLAction) Run(request RefreshSignedURLRequest) (string, error) {
+	if request.SignedURL == "" {
+		return "", errors.New("Refreshed signed URL must not be empty") //nolint:staticcheck
+	}
+
+	err := a.refresher.Deliver(request.RequestID, request.SignedURL, request.BlobstoreHeaders)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Delivering refreshed signed URL")
+	}
+
+	return "refreshed", nil
+}
+
+func (a RefreshSignedURLAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RefreshSignedURLAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,61 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+)
+
+var _ = Describe("RefreshSignedURL", func() {
+	var (
+		refresher        *fakeblobdelegator.FakeSignedURLRefresher
+		refreshSignedURL action.RefreshSignedURLAction
+	)
+
+	BeforeEach(func() {
+		refresher = &fakeblobdelegator.FakeSignedURLRefresher{}
+		refreshSignedURL = action.NewRefreshSignedURL(refresher)
+	})
+
+	AssertActionIsNotAsynchronous(refreshSignedURL)
+	AssertActionIsNotPersistent(refreshSignedURL)
+	AssertActionIsNotResumable(refreshSignedURL)
+	AssertActionIsNotCancelable(refreshSignedURL)
+
+	It("is not loggable because signed URLs carry credentials", func() {
+		Expect(refreshSignedURL.IsLoggable()).To(BeFalse())
+	})
+
+	It("delivers the refreshed signed URL to the waiting transfer", func() {
+		result, err := refreshSignedURL.Run(action.RefreshSignedURLRequest{
+			RequestID:        "fake-request-id",
+			SignedURL:        "fresh-signed-url",
+			BlobstoreHeaders: map[string]string{"key": "value"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal("refreshed"))
+
+		Expect(refresher.DeliverCallCount()).To(Equal(1))
+		requestID, signedURL, headers := refresher.DeliverArgsForCall(0)
+		Expect(requestID).To(Equal("fake-request-id"))
+		Expect(signedURL).To(Equal("fresh-signed-url"))
+		Expect(headers).To(Equal(map[string]string{"key": "value"}))
+	})
+
+	It("returns an error when the signed URL is empty", func() {
+		_, err := refreshSignedURL.Run(action.RefreshSignedURLRequest{RequestID: "fake-request-id"})
+		Expect(err).To(HaveOccurred())
+		Expect(refresher.DeliverCallCount()).To(Equal(0))
+	})
+
+	It("returns an error when delivering fails", func() {
+		refresher.DeliverReturns(errors.New("fake-deliver-err"))
+
+		_, err := refreshSignedURL.Run(action.RefreshSignedURLRequest{RequestID: "fake-request-id", SignedURL: "fresh-signed-url"})
+		Expect(err).To(MatchError(ContainSubstring("fake-deliver-err")))
+	})
+})
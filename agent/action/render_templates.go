@@ -0,0 +1,198 @@
+package action
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/templaterenderer"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+// RenderTemplatesRequest carries the unrendered templates of the jobs of an
+// instance. The rendered templates are stored under BlobID.
+type RenderTemplatesRequest struct {
+	BlobID   string                    `json:"blob_id"`
+	Instance templaterenderer.Instance `json:"instance"`
+	Jobs     []templaterenderer.Job    `json:"jobs"`
+}
+
+// RenderTemplatesValue references the rendered templates archive the same
+// way the rendered_templates_archive of an apply spec does.
+type RenderTemplatesValue struct {
+	BlobstoreID string `json:"blobstore_id"`
+	Sha1        string `json:"sha1"`
+}
+
+// RenderTemplatesAction renders job templates on the instance instead of the
+// director and keeps the archive like upload_blob does, so that the next
+// apply installs the jobs from it without a blobstore download.
+type RenderTemplatesAction struct {
+	renderer        templaterenderer.Renderer
+	settingsService boshsettings.Service
+	compressor      fileutil.Compressor
+	blobManager     boshagentblobstore.BlobManagerInterface
+	fs              boshsys.FileSystem
+}
+
+func NewRenderTemplates(
+	renderer templaterenderer.Renderer,
+	settingsService boshsettings.Service,
+	compressor fileutil.Compressor,
+	blobManager boshagentblobstore.BlobManagerInterface,
+	fs boshsys.FileSystem,
+) RenderTemplatesAction {
+	return RenderTemplatesAction{
+		renderer:        renderer,
+		settingsService: settingsService,
+		compressor:      compressor,
+		blobManager:     blobManager,
+		fs:              fs,
+	}
+}
+
+func (a RenderTemplatesAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RenderTemplatesAction) IsPersistent() bool {
+	return false
+}
+
+// IsLoggable is false since properties contain credentials
+func (a RenderTemplatesAction) IsLoggable() bool {
+	return false
+}
+
+func (a RenderTemplatesAction) Run(request RenderTemplatesRequest) (RenderTemplatesValue, error) {
+	if request.BlobID == "" {
+		return RenderTemplatesValue{}, errors.New("Missing blob id")
+	}
+
+	for _, job := range request.Jobs {
+		err := validJobName(job.Name)
+		if err != nil {
+			return RenderTemplatesValue{}, err
+		}
+	}
+
+	instance := a.withLocalValues(request.Instance)
+
+	renderDir, err := a.fs.TempDir("bosh-agent-render-templates")
+	if err != nil {
+		return RenderTemplatesValue{}, bosherr.WrapError(err, "Creating render directory")
+	}
+
+	defer a.fs.RemoveAll(renderDir) //nolint:errcheck
+
+	for _, job := range request.Jobs {
+		rendered, err := a.renderer.RenderJob(job, instance)
+		if err != nil {
+			return RenderTemplatesValue{}, err
+		}
+
+		for destination, contents := range rendered {
+			renderedPath := path.Join(renderDir, job.Name, destination)
+
+			err = a.fs.MkdirAll(path.Dir(renderedPath), os.FileMode(0750))
+			if err != nil {
+				return RenderTemplatesValue{}, bosherr.WrapErrorf(err, "Creating directory of rendered template '%s'", renderedPath)
+			}
+
+			err = a.fs.WriteFile(renderedPath, contents)
+			if err != nil {
+				return RenderTemplatesValue{}, bosherr.WrapErrorf(err, "Writing rendered template '%s'", renderedPath)
+			}
+		}
+	}
+
+	archivePath, err := a.compressor.CompressFilesInDir(renderDir, fileutil.CompressorOptions{})
+	if err != nil {
+		return RenderTemplatesValue{}, bosherr.WrapError(err, "Compressing rendered templates")
+	}
+
+	defer a.compressor.CleanUp(archivePath) //nolint:errcheck
+
+	digest, err := a.computeDigest(archivePath)
+	if err != nil {
+		return RenderTemplatesValue{}, err
+	}
+
+	archive, err := a.fs.OpenFile(archivePath, os.O_RDONLY, 0)
+	if err != nil {
+		return RenderTemplatesValue{}, bosherr.WrapError(err, "Opening rendered templates archive")
+	}
+
+	defer archive.Close()
+
+	err = a.blobManager.Write(request.BlobID, archive)
+	if err != nil {
+		return RenderTemplatesValue{}, bosherr.WrapError(err, "Storing rendered templates archive")
+	}
+
+	return RenderTemplatesValue{BlobstoreID: request.BlobID, Sha1: digest.String()}, nil
+}
+
+// withLocalValues fills in the networks of the instance as the agent knows
+// them when the director left them out
+func (a RenderTemplatesAction) withLocalValues(instance templaterenderer.Instance) templaterenderer.Instance {
+	networks := a.settingsService.GetSettings().Networks
+
+	if len(instance.Networks) == 0 && len(networks) > 0 {
+		instance.Networks = map[string]templaterenderer.Network{}
+		for name, network := range networks {
+			instance.Networks[name] = templaterenderer.Network{
+				IP:      network.IP,
+				Netmask: network.Netmask,
+				Gateway: network.Gateway,
+			}
+		}
+	}
+
+	if instance.Address == "" {
+		instance.Address, _ = networks.DefaultIP()
+	}
+
+	return instance
+}
+
+// validJobName keeps the rendered templates of a job inside its own directory
+// of the archive
+func validJobName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return bosherr.Errorf("Invalid job name '%s'", name)
+	}
+
+	return nil
+}
+
+func (a RenderTemplatesAction) computeDigest(archivePath string) (boshcrypto.Digest, error) {
+	archive, err := a.fs.OpenFile(archivePath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Opening rendered templates archive")
+	}
+
+	defer archive.Close()
+
+	digest, err := boshcrypto.DigestAlgorithmSHA1.CreateDigest(archive)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Computing digest of rendered templates archive")
+	}
+
+	return digest, nil
+}
+
+func (a RenderTemplatesAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RenderTemplatesAction) Cancel() error {
+	return errors.New("not supported")
+}
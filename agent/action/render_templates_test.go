@@ -0,0 +1,178 @@
+package action_test
+
+import (
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	fakefileutil "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/blobstore/blobstorefakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/templaterenderer"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/templaterenderer/templaterendererfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+var _ = Describe("RenderTemplatesAction", func() {
+	var (
+		renderer        *templaterendererfakes.FakeRenderer
+		settingsService *fakesettings.FakeSettingsService
+		compressor      *fakefileutil.FakeCompressor
+		blobManager     *blobstorefakes.FakeBlobManagerInterface
+		fs              *fakesys.FakeFileSystem
+		renderAction    action.RenderTemplatesAction
+
+		request      action.RenderTemplatesRequest
+		storedBlobs  map[string]string
+		renderedDirs map[string]string
+	)
+
+	BeforeEach(func() {
+		renderer = &templaterendererfakes.FakeRenderer{}
+		settingsService = &fakesettings.FakeSettingsService{}
+		compressor = fakefileutil.NewFakeCompressor()
+		blobManager = &blobstorefakes.FakeBlobManagerInterface{}
+		fs = fakesys.NewFakeFileSystem()
+		renderAction = action.NewRenderTemplates(renderer, settingsService, compressor, blobManager, fs)
+
+		settingsService.Settings.Networks = boshsettings.Networks{
+			"default": {IP: "10.0.0.5", Netmask: "255.255.255.0", Gateway: "10.0.0.1"},
+		}
+
+		fs.TempDirDir = "/tmp/render"
+
+		request = action.RenderTemplatesRequest{
+			BlobID:   "fake-blob-id",
+			Instance: templaterenderer.Instance{ID: "fake-instance-id"},
+			Jobs: []templaterenderer.Job{
+				{Name: "fake-job-1", Templates: []templaterenderer.Template{{Name: "config.yml", Destination: "config/config.yml"}}},
+				{Name: "fake-job-2"},
+			},
+		}
+
+		renderer.RenderJobStub = func(job templaterenderer.Job, _ templaterenderer.Instance) (map[string][]byte, error) {
+			return map[string][]byte{"config/config.yml": []byte("rendered " + job.Name)}, nil
+		}
+
+		renderedDirs = map[string]string{}
+		compressor.CompressFilesInDirTarballPath = "/tmp/rendered.tgz"
+		compressor.CompressFilesInDirCallBack = func() {
+			for _, job := range []string{"fake-job-1", "fake-job-2"} {
+				contents, err := fs.ReadFileString("/tmp/render/" + job + "/config/config.yml")
+				Expect(err).ToNot(HaveOccurred())
+				renderedDirs[job] = contents
+			}
+
+			err := fs.WriteFileString("/tmp/rendered.tgz", "fake-archive")
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		storedBlobs = map[string]string{}
+		blobManager.WriteStub = func(blobID string, reader io.Reader) error {
+			contents, err := io.ReadAll(reader)
+			Expect(err).ToNot(HaveOccurred())
+			storedBlobs[blobID] = string(contents)
+			return nil
+		}
+	})
+
+	AssertActionIsAsynchronous(renderAction)
+	AssertActionIsNotPersistent(renderAction)
+	AssertActionIsNotLoggable(renderAction)
+
+	AssertActionIsNotResumable(renderAction)
+	AssertActionIsNotCancelable(renderAction)
+
+	It("renders the templates of each job into an archive and stores it as a blob", func() {
+		value, err := renderAction.Run(request)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(renderedDirs).To(Equal(map[string]string{
+			"fake-job-1": "rendered fake-job-1",
+			"fake-job-2": "rendered fake-job-2",
+		}))
+		Expect(compressor.CompressFilesInDirDir).To(Equal("/tmp/render"))
+
+		Expect(storedBlobs).To(Equal(map[string]string{"fake-blob-id": "fake-archive"}))
+		Expect(value).To(Equal(action.RenderTemplatesValue{
+			BlobstoreID: "fake-blob-id",
+			// echo -n 'fake-archive' | shasum
+			Sha1: "2502a91e6ecade84a30e70b8c44df443e6bbed66",
+		}))
+
+		Expect(fs.FileExists("/tmp/render")).To(BeFalse())
+		Expect(compressor.CleanUpTarballPath).To(Equal("/tmp/rendered.tgz"))
+	})
+
+	It("renders with the networks the agent knows when the director left them out", func() {
+		_, err := renderAction.Run(request)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, instance := renderer.RenderJobArgsForCall(0)
+		Expect(instance).To(Equal(templaterenderer.Instance{
+			ID:      "fake-instance-id",
+			Address: "10.0.0.5",
+			Networks: map[string]templaterenderer.Network{
+				"default": {IP: "10.0.0.5", Netmask: "255.255.255.0", Gateway: "10.0.0.1"},
+			},
+		}))
+	})
+
+	It("keeps the instance values the director sent", func() {
+		request.Instance.Address = "fake-instance.internal"
+		request.Instance.Networks = map[string]templaterenderer.Network{"other": {IP: "10.1.0.5"}}
+
+		_, err := renderAction.Run(request)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, instance := renderer.RenderJobArgsForCall(0)
+		Expect(instance).To(Equal(request.Instance))
+	})
+
+	It("returns an error without a blob id", func() {
+		request.BlobID = ""
+
+		_, err := renderAction.Run(request)
+		Expect(err).To(MatchError("Missing blob id"))
+		Expect(renderer.RenderJobCallCount()).To(BeZero())
+	})
+
+	DescribeTable("returns an error without rendering for job names that are not a single directory",
+		func(name string) {
+			request.Jobs[1].Name = name
+
+			_, err := renderAction.Run(request)
+			Expect(err).To(MatchError("Invalid job name '" + name + "'"))
+			Expect(renderer.RenderJobCallCount()).To(BeZero())
+			Expect(blobManager.WriteCallCount()).To(BeZero())
+		},
+		Entry("empty", ""),
+		Entry("current directory", "."),
+		Entry("parent directory", ".."),
+		Entry("path outside of the archive", "../../etc"),
+		Entry("nested path", "fake-job/nested"),
+	)
+
+	It("returns an error and stores nothing when rendering fails", func() {
+		renderer.RenderJobStub = nil
+		renderer.RenderJobReturns(nil, errors.New("fake-render-error"))
+
+		_, err := renderAction.Run(request)
+		Expect(err).To(MatchError("fake-render-error"))
+		Expect(blobManager.WriteCallCount()).To(BeZero())
+		Expect(fs.FileExists("/tmp/render")).To(BeFalse())
+	})
+
+	It("returns an error when storing the archive fails", func() {
+		blobManager.WriteStub = nil
+		blobManager.WriteReturns(errors.New("fake-write-error"))
+
+		_, err := renderAction.Run(request)
+		Expect(err).To(MatchError("Storing rendered templates archive: fake-write-error"))
+	})
+})
@@ -0,0 +1,241 @@
+package action
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+// Keeps the result of the task well below the default NATS payload limit
+const maxRerunJobScriptOutputBytes = 16 * 1024
+
+var rerunnableJobScripts = map[string]bool{
+	"pre-start":   true,
+	"post-start":  true,
+	"post-deploy": true,
+}
+
+// RerunJobScriptRequest selects the lifecycle script to run again and the
+// jobs to run it for, all jobs without Jobs. Scripts only get Env, none of
+// the environment of the deploy that last ran them.
+type RerunJobScriptRequest struct {
+	Script         string            `json:"script"`
+	Jobs           []string          `json:"jobs"`
+	Env            map[string]string `json:"env"`
+	TimeoutSeconds int               `json:"timeout_seconds"`
+}
+
+// RerunJobScriptResult holds what the script of a job wrote to its logs
+// during the run, at most the last 16KiB of each.
+type RerunJobScriptResult struct {
+	Job    string `json:"job"`
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RerunJobScriptAction runs the pre-start, post-start or post-deploy script
+// of deployed jobs outside of a deploy, e.g. to initialize a job again after
+// fixing it up by hand. Jobs without the script are left out of the results.
+type RerunJobScriptAction struct {
+	scriptProvider boshscript.JobScriptProvider
+	specService    boshas.V1Service
+	applier        boshappl.Applier
+	fs             boshsys.FileSystem
+	dirProvider    boshdir.Provider
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewRerunJobScript(
+	scriptProvider boshscript.JobScriptProvider,
+	specService boshas.V1Service,
+	applier boshappl.Applier,
+	fs boshsys.FileSystem,
+	dirProvider boshdir.Provider,
+	logger boshlog.Logger,
+) RerunJobScriptAction {
+	return RerunJobScriptAction{
+		scriptProvider: scriptProvider,
+		specService:    specService,
+		applier:        applier,
+		fs:             fs,
+		dirProvider:    dirProvider,
+
+		logTag: "RerunJobScript Action",
+		logger: logger,
+	}
+}
+
+func (a RerunJobScriptAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RerunJobScriptAction) IsPersistent() bool {
+	return false
+}
+
+func (a RerunJobScriptAction) IsLoggable() bool {
+	return true
+}
+
+func (a RerunJobScriptAction) Run(request RerunJobScriptRequest) ([]RerunJobScriptResult, error) {
+	if !rerunnableJobScripts[request.Script] {
+		return nil, bosherr.Errorf("Script '%s' can not be re-run, expected pre-start, post-start or post-deploy", request.Script)
+	}
+
+	err := validateScriptEnv(request.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.TimeoutSeconds < 0 {
+		return nil, bosherr.Errorf("Invalid script timeout %ds", request.TimeoutSeconds)
+	}
+
+	timeout := time.Duration(request.TimeoutSeconds) * time.Second
+
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting current spec")
+	}
+
+	jobs, err := a.selectJobs(currentSpec.Jobs(), request.Jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		scripts     []boshscript.Script
+		runningJobs []models.Job
+	)
+
+	for _, job := range jobs {
+		script := a.scriptProvider.NewScript(job.BundleName(), request.Script, request.Env, nil, timeout)
+		if script.Exists() {
+			scripts = append(scripts, script)
+			runningJobs = append(runningJobs, job)
+		}
+	}
+
+	err = a.applier.ApplyDeferredPackages(runningJobs)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Applying packages of jobs with %s scripts", request.Script)
+	}
+
+	a.logger.Info(a.logTag, "Re-running %d %s scripts", len(scripts), request.Script)
+
+	results := make([]RerunJobScriptResult, len(scripts))
+
+	var wg sync.WaitGroup
+
+	for i, script := range scripts {
+		wg.Add(1)
+		go func(i int, script boshscript.Script) {
+			defer wg.Done()
+			results[i] = a.run(script, request.Script)
+		}(i, script)
+	}
+
+	wg.Wait()
+
+	return results, nil
+}
+
+// selectJobs returns the jobs with the given names in spec order, or all
+// jobs without names
+func (a RerunJobScriptAction) selectJobs(jobs []models.Job, names []string) ([]models.Job, error) {
+	if len(names) == 0 {
+		return jobs, nil
+	}
+
+	selected := map[string]bool{}
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	var selectedJobs []models.Job
+
+	for _, job := range jobs {
+		if selected[job.Name] {
+			selectedJobs = append(selectedJobs, job)
+			delete(selected, job.Name)
+		}
+	}
+
+	for _, name := range names {
+		if selected[name] {
+			return nil, bosherr.Errorf("Job '%s' is not deployed on this instance", name)
+		}
+	}
+
+	return selectedJobs, nil
+}
+
+func (a RerunJobScriptAction) run(script boshscript.Script, scriptName string) RerunJobScriptResult {
+	stdoutLogPath, stderrLogPath := boshscript.LogPaths(a.dirProvider, script.Tag(), scriptName)
+
+	// Scripts append to their logs, so only what was written since the
+	// script started belongs to this run
+	stdoutOffset := a.logSize(stdoutLogPath)
+	stderrOffset := a.logSize(stderrLogPath)
+
+	result := RerunJobScriptResult{Job: script.Tag()}
+
+	err := script.Run()
+	if err != nil {
+		a.logger.Error(a.logTag, "'%s' script has failed with error: %s", script.Path(), err)
+		result.Error = err.Error()
+	}
+
+	result.Stdout = a.logSince(stdoutLogPath, stdoutOffset)
+	result.Stderr = a.logSince(stderrLogPath, stderrOffset)
+
+	return result
+}
+
+func (a RerunJobScriptAction) logSize(path string) int64 {
+	if !a.fs.FileExists(path) {
+		return 0
+	}
+
+	info, err := a.fs.Stat(path)
+	if err != nil {
+		return 0
+	}
+
+	return info.Size()
+}
+
+func (a RerunJobScriptAction) logSince(path string, offset int64) string {
+	contents, err := a.fs.ReadFileWithOpts(path, boshsys.ReadOpts{Quiet: true})
+	if err != nil || int64(len(contents)) < offset {
+		return ""
+	}
+
+	contents = contents[offset:]
+	if len(contents) > maxRerunJobScriptOutputBytes {
+		contents = contents[len(contents)-maxRerunJobScriptOutputBytes:]
+	}
+
+	return string(contents)
+}
+
+func (a RerunJobScriptAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RerunJobScriptAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,186 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeapplyspec "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/script/scriptfakes"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+var _ = Describe("RerunJobScript", func() {
+	var (
+		fakeJobScriptProvider *scriptfakes.FakeJobScriptProvider
+		specService           *fakeapplyspec.FakeV1Service
+		applier               *fakeappl.FakeApplier
+		fs                    *fakesys.FakeFileSystem
+		rerunJobScriptAction  action.RerunJobScriptAction
+		request               action.RerunJobScriptRequest
+		scripts               map[string]*scriptfakes.FakeScript
+	)
+
+	BeforeEach(func() {
+		fakeJobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
+		specService = fakeapplyspec.NewFakeV1Service()
+		specService.Spec.RenderedTemplatesArchiveSpec = &applyspec.RenderedTemplatesArchiveSpec{}
+		applier = fakeappl.NewFakeApplier()
+		fs = fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		rerunJobScriptAction = action.NewRerunJobScript(fakeJobScriptProvider, specService, applier, fs, boshdir.NewProvider("/var/vcap"), logger)
+
+		request = action.RerunJobScriptRequest{
+			Script:         "post-start",
+			Env:            map[string]string{"FOO": "foo"},
+			TimeoutSeconds: 90,
+		}
+
+		scripts = map[string]*scriptfakes.FakeScript{}
+		for _, jobName := range []string{"fake-job-1", "fake-job-2", "fake-job-3"} {
+			specService.Spec.JobSpec.JobTemplateSpecs = append(specService.Spec.JobSpec.JobTemplateSpecs, applyspec.JobTemplateSpec{Name: jobName})
+
+			script := &scriptfakes.FakeScript{}
+			script.TagReturns(jobName)
+			script.ExistsReturns(jobName != "fake-job-3")
+			scripts[jobName] = script
+		}
+
+		fakeJobScriptProvider.NewScriptStub = func(jobName, _ string, _ map[string]string, _ []string, _ time.Duration) boshscript.Script {
+			return scripts[jobName]
+		}
+	})
+
+	AssertActionIsAsynchronous(rerunJobScriptAction)
+	AssertActionIsNotPersistent(rerunJobScriptAction)
+	AssertActionIsLoggable(rerunJobScriptAction)
+
+	AssertActionIsNotResumable(rerunJobScriptAction)
+	AssertActionIsNotCancelable(rerunJobScriptAction)
+
+	Describe("Run", func() {
+		act := func() ([]action.RerunJobScriptResult, error) { return rerunJobScriptAction.Run(request) }
+
+		It("runs the script of every job that has it with the requested env", func() {
+			results, err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(Equal([]action.RerunJobScriptResult{
+				{Job: "fake-job-1"},
+				{Job: "fake-job-2"},
+			}))
+
+			Expect(fakeJobScriptProvider.NewScriptCallCount()).To(Equal(3))
+
+			jobName, scriptName, scriptEnv, scriptArgs, timeout := fakeJobScriptProvider.NewScriptArgsForCall(0)
+			Expect(jobName).To(Equal("fake-job-1"))
+			Expect(scriptName).To(Equal("post-start"))
+			Expect(scriptEnv).To(Equal(map[string]string{"FOO": "foo"}))
+			Expect(scriptArgs).To(BeEmpty())
+			Expect(timeout).To(Equal(90 * time.Second))
+
+			Expect(scripts["fake-job-1"].RunCallCount()).To(Equal(1))
+			Expect(scripts["fake-job-2"].RunCallCount()).To(Equal(1))
+			Expect(scripts["fake-job-3"].RunCallCount()).To(Equal(0))
+		})
+
+		It("only runs the script of the requested jobs", func() {
+			request.Jobs = []string{"fake-job-2"}
+
+			results, err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(Equal([]action.RerunJobScriptResult{{Job: "fake-job-2"}}))
+
+			Expect(scripts["fake-job-1"].RunCallCount()).To(Equal(0))
+		})
+
+		It("returns what the scripts logged during the run", func() {
+			stdoutLogPath := "/var/vcap/sys/log/fake-job-1/post-start.stdout.log"
+			stderrLogPath := "/var/vcap/sys/log/fake-job-1/post-start.stderr.log"
+
+			err := fs.WriteFileString(stdoutLogPath, "previous-stdout\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			scripts["fake-job-1"].RunStub = func() error {
+				err := fs.WriteFileString(stdoutLogPath, "previous-stdout\nfake-stdout\n")
+				Expect(err).ToNot(HaveOccurred())
+
+				err = fs.WriteFileString(stderrLogPath, "fake-stderr\n")
+				Expect(err).ToNot(HaveOccurred())
+
+				return errors.New("fake-run-error")
+			}
+
+			results, err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results[0]).To(Equal(action.RerunJobScriptResult{
+				Job:    "fake-job-1",
+				Stdout: "fake-stdout\n",
+				Stderr: "fake-stderr\n",
+				Error:  "fake-run-error",
+			}))
+		})
+
+		It("applies the deferred packages of jobs that have the script", func() {
+			_, err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(applier.DeferredPackagesJobs).To(HaveLen(2))
+			Expect(applier.DeferredPackagesJobs[0].Name).To(Equal("fake-job-1"))
+			Expect(applier.DeferredPackagesJobs[1].Name).To(Equal("fake-job-2"))
+		})
+
+		It("returns an error without running scripts when applying deferred packages fails", func() {
+			applier.DeferredPackagesError = errors.New("fake-deferred-packages-error")
+
+			_, err := act()
+			Expect(err).To(MatchError(ContainSubstring("fake-deferred-packages-error")))
+			Expect(scripts["fake-job-1"].RunCallCount()).To(Equal(0))
+		})
+
+		It("returns an error for jobs that are not deployed", func() {
+			request.Jobs = []string{"fake-job-1", "unknown-job"}
+
+			_, err := act()
+			Expect(err).To(MatchError("Job 'unknown-job' is not deployed on this instance"))
+			Expect(fakeJobScriptProvider.NewScriptCallCount()).To(Equal(0))
+		})
+
+		It("returns an error for scripts other than lifecycle scripts", func() {
+			request.Script = "drain"
+
+			_, err := act()
+			Expect(err).To(MatchError("Script 'drain' can not be re-run, expected pre-start, post-start or post-deploy"))
+			Expect(fakeJobScriptProvider.NewScriptCallCount()).To(Equal(0))
+		})
+
+		It("returns an error for invalid environment variable names", func() {
+			request.Env = map[string]string{"FOO=BAR": "foo"}
+
+			_, err := act()
+			Expect(err).To(MatchError("Invalid environment variable name 'FOO=BAR'"))
+		})
+
+		It("returns an error for negative timeouts", func() {
+			request.TimeoutSeconds = -1
+
+			_, err := act()
+			Expect(err).To(MatchError("Invalid script timeout -1s"))
+		})
+
+		It("returns an error when the current spec cannot be retrieved", func() {
+			specService.GetErr = errors.New("fake-spec-get-error")
+
+			_, err := act()
+			Expect(err).To(MatchError(ContainSubstring("fake-spec-get-error")))
+		})
+	})
+})
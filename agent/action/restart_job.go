@@ -0,0 +1,66 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+)
+
+// RestartJobRequest names the job process to restart. With Wait the action
+// only finishes once the process is running again.
+type RestartJobRequest struct {
+	Process string `json:"process"`
+	Wait    bool   `json:"wait"`
+}
+
+// RestartJobAction restarts a single job process, unlike stop and start which
+// act on all processes of the instance
+type RestartJobAction struct {
+	jobSupervisor boshjobsuper.JobSupervisor
+}
+
+func NewRestartJob(jobSupervisor boshjobsuper.JobSupervisor) (action RestartJobAction) {
+	action.jobSupervisor = jobSupervisor
+	return
+}
+
+func (a RestartJobAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RestartJobAction) IsPersistent() bool {
+	return false
+}
+
+func (a RestartJobAction) IsLoggable() bool {
+	return true
+}
+
+func (a RestartJobAction) Run(request RestartJobRequest) (string, error) {
+	if request.Process == "" {
+		return "", bosherr.Error("Process to restart is not given")
+	}
+
+	var err error
+	if request.Wait {
+		err = a.jobSupervisor.RestartProcessAndWait(request.Process)
+	} else {
+		err = a.jobSupervisor.RestartProcess(request.Process)
+	}
+
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Restarting process '%s'", request.Process)
+	}
+
+	return "restarted", nil
+}
+
+func (a RestartJobAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RestartJobAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,60 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
+)
+
+var _ = Describe("RestartJob", func() {
+	var (
+		jobSupervisor    *fakejobsuper.FakeJobSupervisor
+		restartJobAction action.RestartJobAction
+	)
+
+	BeforeEach(func() {
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		restartJobAction = action.NewRestartJob(jobSupervisor)
+	})
+
+	AssertActionIsAsynchronous(restartJobAction)
+	AssertActionIsNotPersistent(restartJobAction)
+	AssertActionIsLoggable(restartJobAction)
+
+	AssertActionIsNotResumable(restartJobAction)
+	AssertActionIsNotCancelable(restartJobAction)
+
+	It("restarts the process", func() {
+		restarted, err := restartJobAction.Run(action.RestartJobRequest{Process: "fake-process"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restarted).To(Equal("restarted"))
+
+		Expect(jobSupervisor.RestartedProcesses).To(Equal([]string{"fake-process"}))
+		Expect(jobSupervisor.RestartedAndWaited).To(BeFalse())
+	})
+
+	It("waits for the process to be running when asked to", func() {
+		_, err := restartJobAction.Run(action.RestartJobRequest{Process: "fake-process", Wait: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(jobSupervisor.RestartedProcesses).To(Equal([]string{"fake-process"}))
+		Expect(jobSupervisor.RestartedAndWaited).To(BeTrue())
+	})
+
+	It("returns an error when no process is given", func() {
+		_, err := restartJobAction.Run(action.RestartJobRequest{})
+		Expect(err).To(MatchError("Process to restart is not given"))
+		Expect(jobSupervisor.RestartedProcesses).To(BeEmpty())
+	})
+
+	It("returns the error restarting the process", func() {
+		jobSupervisor.RestartProcessErr = errors.New("fake-restart-err")
+
+		_, err := restartJobAction.Run(action.RestartJobRequest{Process: "fake-process"})
+		Expect(err).To(MatchError("Restarting process 'fake-process': fake-restart-err"))
+	})
+})
@@ -0,0 +1,66 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+)
+
+// RevertApplyAction applies the previously applied spec again without the
+// director, e.g. when a deploy broke the instance and the director is not
+// reachable. Jobs have to be started afterwards like after an apply.
+type RevertApplyAction struct {
+	applyAction ApplyAction
+	specService boshas.V1Service
+}
+
+func NewRevertApply(applyAction ApplyAction, specService boshas.V1Service) RevertApplyAction {
+	return RevertApplyAction{
+		applyAction: applyAction,
+		specService: specService,
+	}
+}
+
+func (a RevertApplyAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RevertApplyAction) IsPersistent() bool {
+	return false
+}
+
+func (a RevertApplyAction) IsLoggable() bool {
+	return true
+}
+
+func (a RevertApplyAction) Run() (interface{}, error) {
+	history, err := a.specService.History()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting previously applied specs")
+	}
+
+	if len(history) == 0 {
+		return "", errors.New("No previously applied spec to revert to")
+	}
+
+	previousSpec, err := a.applyAction.resolve(history[0])
+	if err != nil {
+		return "", err
+	}
+
+	// The reverted spec is not added to the history again so that reverting
+	// repeatedly goes further back
+	return a.applyAction.apply(previousSpec, func(boshas.V1ApplySpec) error {
+		return a.specService.Revert()
+	}, nil)
+}
+
+func (a RevertApplyAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RevertApplyAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,103 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/linkverifier/linkverifierfakes"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+var _ = Describe("RevertApplyAction", func() {
+	var (
+		applier           *fakeappl.FakeApplier
+		specService       *fakeas.FakeV1Service
+		revertApplyAction action.RevertApplyAction
+	)
+
+	BeforeEach(func() {
+		applier = fakeappl.NewFakeApplier()
+		specService = fakeas.NewFakeV1Service()
+		applyAction := action.NewApply(
+			applier,
+			specService,
+			&fakesettings.FakeSettingsService{},
+			boshdir.NewProvider("/var/vcap"),
+			fakesys.NewFakeFileSystem(),
+			&linkverifierfakes.FakeVerifier{},
+			&fakeappl.FakeHookRunner{},
+			faketask.NewFakeService(),
+		)
+		revertApplyAction = action.NewRevertApply(applyAction, specService)
+	})
+
+	AssertActionIsAsynchronous(revertApplyAction)
+	AssertActionIsNotPersistent(revertApplyAction)
+	AssertActionIsLoggable(revertApplyAction)
+	AssertActionIsNotCancelable(revertApplyAction)
+	AssertActionIsNotResumable(revertApplyAction)
+
+	Describe("Run", func() {
+		currentSpec := boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
+		previousSpec := boshas.V1ApplySpec{ConfigurationHash: "fake-previous-config-hash"}
+		olderSpec := boshas.V1ApplySpec{ConfigurationHash: "fake-older-config-hash"}
+
+		BeforeEach(func() {
+			specService.Spec = currentSpec
+			specService.HistorySpecs = []boshas.V1ApplySpec{previousSpec, olderSpec}
+			specService.PopulateDHCPNetworksResultSpec = previousSpec
+		})
+
+		It("applies the previously applied spec and makes it the current spec", func() {
+			value, err := revertApplyAction.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal("applied"))
+
+			Expect(specService.PopulateDHCPNetworksSpec).To(Equal(previousSpec))
+			Expect(applier.Applied).To(BeTrue())
+			Expect(applier.ApplyDesiredApplySpec).To(Equal(previousSpec))
+
+			Expect(specService.ActionsCalled).To(ContainElement("Revert"))
+			Expect(specService.ActionsCalled).ToNot(ContainElement("Set"))
+			Expect(specService.Spec).To(Equal(previousSpec))
+			Expect(specService.HistorySpecs).To(Equal([]boshas.V1ApplySpec{olderSpec}))
+		})
+
+		It("rolls back to the current spec when applying the previous spec fails", func() {
+			applier.ApplyError = errors.New("fake-apply-error")
+
+			_, err := revertApplyAction.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-apply-error"))
+
+			Expect(specService.ActionsCalled).ToNot(ContainElement("Revert"))
+			Expect(specService.Spec).To(Equal(currentSpec))
+		})
+
+		It("returns error when there is no previously applied spec", func() {
+			specService.HistorySpecs = nil
+
+			_, err := revertApplyAction.Run()
+			Expect(err).To(MatchError("No previously applied spec to revert to"))
+			Expect(applier.Applied).To(BeFalse())
+		})
+
+		It("returns error when the previously applied specs cannot be read", func() {
+			specService.HistoryErr = errors.New("fake-history-error")
+
+			_, err := revertApplyAction.Run()
+			Expect(err).To(MatchError(ContainSubstring("fake-history-error")))
+			Expect(applier.Applied).To(BeFalse())
+		})
+	})
+})
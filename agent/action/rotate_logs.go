@@ -0,0 +1,62 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+// RotateLogsRequest asks to empty the job logs in place with Truncate instead
+// of rotating them, which also frees the space taken by the current logs.
+type RotateLogsRequest struct {
+	Truncate bool `json:"truncate"`
+}
+
+// RotateLogsAction rotates the job logs right away instead of waiting for
+// logrotate to find them too big, e.g. to start from empty logs before
+// reproducing an issue
+type RotateLogsAction struct {
+	platform platform.Platform
+}
+
+func NewRotateLogs(platform platform.Platform) RotateLogsAction {
+	return RotateLogsAction{
+		platform: platform,
+	}
+}
+
+func (a RotateLogsAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RotateLogsAction) IsPersistent() bool {
+	return false
+}
+
+func (a RotateLogsAction) IsLoggable() bool {
+	return true
+}
+
+func (a RotateLogsAction) Run(request RotateLogsRequest) (string, error) {
+	err := a.platform.RotateLogs(boshsettings.VCAPUsername, request.Truncate)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Rotating job logs")
+	}
+
+	if request.Truncate {
+		return "truncated", nil
+	}
+
+	return "rotated", nil
+}
+
+func (a RotateLogsAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RotateLogsAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,57 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
+)
+
+var _ = Describe("RotateLogs", func() {
+	var (
+		platform         *platformfakes.FakePlatform
+		rotateLogsAction action.RotateLogsAction
+	)
+
+	BeforeEach(func() {
+		platform = &platformfakes.FakePlatform{}
+		rotateLogsAction = action.NewRotateLogs(platform)
+	})
+
+	AssertActionIsAsynchronous(rotateLogsAction)
+	AssertActionIsNotPersistent(rotateLogsAction)
+	AssertActionIsLoggable(rotateLogsAction)
+
+	AssertActionIsNotCancelable(rotateLogsAction)
+	AssertActionIsNotResumable(rotateLogsAction)
+
+	It("rotates the logs set up for vcap", func() {
+		result, err := rotateLogsAction.Run(action.RotateLogsRequest{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("rotated"))
+
+		Expect(platform.RotateLogsCallCount()).To(Equal(1))
+		groupName, truncate := platform.RotateLogsArgsForCall(0)
+		Expect(groupName).To(Equal("vcap"))
+		Expect(truncate).To(BeFalse())
+	})
+
+	It("truncates the logs when asked to", func() {
+		result, err := rotateLogsAction.Run(action.RotateLogsRequest{Truncate: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("truncated"))
+
+		_, truncate := platform.RotateLogsArgsForCall(0)
+		Expect(truncate).To(BeTrue())
+	})
+
+	It("returns an error when rotating fails", func() {
+		platform.RotateLogsReturns(errors.New("fake-rotate-error"))
+
+		_, err := rotateLogsAction.Run(action.RotateLogsRequest{})
+		Expect(err).To(MatchError("Rotating job logs: fake-rotate-error"))
+	})
+})
@@ -9,36 +9,41 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/script/cmd"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 const runErrandActionLogTag = "runErrandAction"
 
 type RunErrandAction struct {
 	specService boshas.V1Service
+	applier     boshappl.Applier
 	jobsDir     string
 	cmdRunner   boshsys.CmdRunner
 	logger      boshlog.Logger
 
-	cancelCh chan struct{}
+	cancelSignals boshtask.CancelSignals
 }
 
 func NewRunErrand(
 	specService boshas.V1Service,
+	applier boshappl.Applier,
 	jobsDir string,
 	cmdRunner boshsys.CmdRunner,
 	logger boshlog.Logger,
+	cancelSignals boshtask.CancelSignals,
 ) RunErrandAction {
 	return RunErrandAction{
 		specService: specService,
+		applier:     applier,
 		jobsDir:     jobsDir,
 		cmdRunner:   cmdRunner,
 		logger:      logger,
 
-		// Initialize channel in a constructor to avoid race
-		// between initializing in Run()/Cancel()
-		cancelCh: make(chan struct{}, 1),
+		cancelSignals: cancelSignals,
 	}
 }
 
@@ -89,6 +94,15 @@ func (a RunErrandAction) Run(errandName ...string) (ErrandResult, error) {
 		templateName = errandName[0]
 	}
 
+	for _, job := range currentSpec.Jobs() {
+		if job.Name == templateName {
+			err = a.applier.ApplyDeferredPackages([]models.Job{job})
+			if err != nil {
+				return ErrandResult{}, bosherr.WrapError(err, "Applying errand packages")
+			}
+		}
+	}
+
 	command := cmd.BuildCommand(path.Join(a.jobsDir, templateName, "bin", "run"))
 
 	process, err := a.cmdRunner.RunComplexCommandAsync(command)
@@ -98,12 +112,16 @@ func (a RunErrandAction) Run(errandName ...string) (ErrandResult, error) {
 
 	var result boshsys.Result
 
-	// Can only wait once on a process but cancelling can happen multiple times
+	cancelCh := a.cancelSignals.RunningCancelSignal().Done()
+
+	// Can only wait once on a process and is only terminated once
 	for processExitedCh := process.Wait(); processExitedCh != nil; {
 		select {
 		case result = <-processExitedCh:
 			processExitedCh = nil
-		case <-a.cancelCh:
+		case <-cancelCh:
+			cancelCh = nil
+
 			// Ignore possible TerminateNicely error since we cannot return it
 			err := process.TerminateNicely(10 * time.Second)
 			if err != nil {
@@ -127,22 +145,8 @@ func (a RunErrandAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
 
-// Cancelling rules:
-// 1. Cancel action MUST take constant time even if another cancel is pending/running
-// 2. Cancel action DOES NOT have to cancel if another cancel is pending/running
-// 3. Cancelling errand before it starts should cancel errand when it runs
-//    - possible optimization - do not even start errand
-// (e.g. send 5 cancels, 1 is actually doing cancelling, other 4 exit immediately)
-
-// Cancel satisfies above rules though it never returns any error
+// Cancel never returns an error. The task service signals the running
+// errand, which is then terminated nicely.
 func (a RunErrandAction) Cancel() error {
-	select {
-	case a.cancelCh <- struct{}{}:
-		// Always return no error since we cannot wait until
-		// errand runs in the future and potentially fails to cancel
-
-	default:
-		// Cancel action is already queued up
-	}
 	return nil
 }
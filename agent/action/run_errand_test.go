@@ -15,23 +15,30 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
 	boshenv "github.com/cloudfoundry/bosh-agent/v2/agent/script/pathenv"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 )
 
 var _ = Describe("RunErrand", func() {
 	var (
 		specService     *fakeas.FakeV1Service
+		applier         *fakeappl.FakeApplier
 		cmdRunner       *fakesys.FakeCmdRunner
 		runErrandAction action.RunErrandAction
 		errandName      string
 		fullCommand     string
+		taskService     *faketask.FakeService
 	)
 
 	BeforeEach(func() {
 		specService = fakeas.NewFakeV1Service()
+		applier = fakeappl.NewFakeApplier()
 		cmdRunner = fakesys.NewFakeCmdRunner()
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		runErrandAction = action.NewRunErrand(specService, "/fake-jobs-dir", cmdRunner, logger)
+		taskService = faketask.NewFakeService()
+		runErrandAction = action.NewRunErrand(specService, applier, "/fake-jobs-dir", cmdRunner, logger, taskService)
 		errandName = "fake-job-name"
 		if runtime.GOOS == "windows" {
 			fullCommand = "powershell /fake-jobs-dir/fake-job-name/bin/run"
@@ -112,6 +119,25 @@ var _ = Describe("RunErrand", func() {
 						))
 					})
 
+					It("applies the deferred packages of the errand job before running it", func() {
+						specService.Spec.RenderedTemplatesArchiveSpec = &boshas.RenderedTemplatesArchiveSpec{}
+
+						_, err := runErrandAction.Run(errandName)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(applier.DeferredPackagesJobs).To(HaveLen(1))
+						Expect(applier.DeferredPackagesJobs[0].Name).To(Equal("fake-job-name"))
+					})
+
+					It("returns an error without running the errand when applying its packages fails", func() {
+						specService.Spec.RenderedTemplatesArchiveSpec = &boshas.RenderedTemplatesArchiveSpec{}
+						applier.DeferredPackagesError = errors.New("fake-deferred-packages-error")
+
+						_, err := runErrandAction.Run(errandName)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-deferred-packages-error"))
+						Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+					})
+
 					It("runs errand script with properly configured environment", func() {
 						_, err := runErrandAction.Run(errandName)
 						Expect(err).ToNot(HaveOccurred())
@@ -204,7 +230,12 @@ var _ = Describe("RunErrand", func() {
 	})
 
 	Describe("Cancel", func() {
+		var cancelCh chan struct{}
+
 		BeforeEach(func() {
+			cancelCh = make(chan struct{})
+			taskService.RunningCancelSignalResult = boshtask.NewCancelSignal(cancelCh)
+
 			currentSpec := boshas.V1ApplySpec{
 				JobSpec: boshas.JobSpec{
 					JobTemplateSpecs: []boshas.JobTemplateSpec{
@@ -220,7 +251,7 @@ var _ = Describe("RunErrand", func() {
 			specService.Spec = currentSpec
 		})
 
-		Context("when runErrandAction was not cancelled yet", func() {
+		Context("when the task is cancelled", func() {
 			It("terminates errand nicely giving it 10 secs to exit on its own", func() {
 				process := &fakesys.FakeProcess{
 					TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
@@ -234,10 +265,9 @@ var _ = Describe("RunErrand", func() {
 
 				cmdRunner.AddProcess(fullCommand, process)
 
-				err := runErrandAction.Cancel()
-				Expect(err).ToNot(HaveOccurred())
+				close(cancelCh)
 
-				_, err = runErrandAction.Run(errandName)
+				_, err := runErrandAction.Run(errandName)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
@@ -257,8 +287,7 @@ var _ = Describe("RunErrand", func() {
 				})
 
 				It("returns errand result without error after running an errand", func() {
-					err := runErrandAction.Cancel()
-					Expect(err).ToNot(HaveOccurred())
+					close(cancelCh)
 
 					result, err := runErrandAction.Run(errandName)
 					Expect(err).ToNot(HaveOccurred())
@@ -287,8 +316,7 @@ var _ = Describe("RunErrand", func() {
 				})
 
 				It("returns errand result without an error", func() {
-					err := runErrandAction.Cancel()
-					Expect(err).ToNot(HaveOccurred())
+					close(cancelCh)
 
 					result, err := runErrandAction.Run(errandName)
 					Expect(err).ToNot(HaveOccurred())
@@ -315,8 +343,7 @@ var _ = Describe("RunErrand", func() {
 				})
 
 				It("returns error because script failed to execute", func() {
-					err := runErrandAction.Cancel()
-					Expect(err).ToNot(HaveOccurred())
+					close(cancelCh)
 
 					result, err := runErrandAction.Run(errandName)
 					Expect(err).To(HaveOccurred())
@@ -0,0 +1,77 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/healthcheck"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+type RunHealthChecksResult struct {
+	Healthy bool                 `json:"healthy"`
+	Checks  []healthcheck.Result `json:"checks"`
+}
+
+// RunHealthChecksAction runs the health checks jobs of the applied spec
+// provide, which tell more about their health than whether their processes
+// are running. The instance is healthy when all checks pass.
+type RunHealthChecksAction struct {
+	specService boshas.V1Service
+	checker     healthcheck.Checker
+
+	cancelSignals boshtask.CancelSignals
+}
+
+func NewRunHealthChecks(specService boshas.V1Service, checker healthcheck.Checker, cancelSignals boshtask.CancelSignals) (action RunHealthChecksAction) {
+	action.specService = specService
+	action.checker = checker
+	action.cancelSignals = cancelSignals
+	return
+}
+
+func (a RunHealthChecksAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RunHealthChecksAction) IsPersistent() bool {
+	return false
+}
+
+func (a RunHealthChecksAction) IsLoggable() bool {
+	return true
+}
+
+func (a RunHealthChecksAction) Run() (RunHealthChecksResult, error) {
+	cancel := a.cancelSignals.RunningCancelSignal()
+
+	spec, err := a.specService.Get()
+	if err != nil {
+		return RunHealthChecksResult{}, bosherr.WrapError(err, "Getting applied spec")
+	}
+
+	checks, err := a.checker.Check(spec.Jobs(), cancel)
+	if err != nil {
+		return RunHealthChecksResult{}, bosherr.WrapError(err, "Running health checks")
+	}
+
+	result := RunHealthChecksResult{Healthy: true, Checks: checks}
+
+	for _, check := range checks {
+		if !check.Healthy {
+			result.Healthy = false
+		}
+	}
+
+	return result, nil
+}
+
+func (a RunHealthChecksAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RunHealthChecksAction) Cancel() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/healthcheck"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/healthcheck/healthcheckfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+)
+
+var _ = Describe("RunHealthChecks", func() {
+	var (
+		specService           *fakeas.FakeV1Service
+		checker               *healthcheckfakes.FakeChecker
+		runHealthChecksAction action.RunHealthChecksAction
+	)
+
+	BeforeEach(func() {
+		specService = fakeas.NewFakeV1Service()
+		archiveSha1 := boshcrypto.MustParseMultipleDigest("sha1:fakearchivesha1")
+		specService.Spec = boshas.V1ApplySpec{
+			JobSpec: boshas.JobSpec{
+				JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-job", Version: "fake-job-version"}},
+			},
+			RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{Sha1: &archiveSha1},
+		}
+
+		checker = &healthcheckfakes.FakeChecker{}
+		runHealthChecksAction = action.NewRunHealthChecks(specService, checker, faketask.NewFakeService())
+	})
+
+	AssertActionIsAsynchronous(runHealthChecksAction)
+	AssertActionIsNotPersistent(runHealthChecksAction)
+	AssertActionIsLoggable(runHealthChecksAction)
+
+	AssertActionIsNotResumable(runHealthChecksAction)
+	AssertActionIsCancelable(runHealthChecksAction)
+
+	It("runs the health checks of the jobs of the applied spec", func() {
+		checks := []healthcheck.Result{
+			{Job: "fake-job", Probe: healthcheck.ProbeScript, Target: "/var/vcap/jobs/fake-job/bin/health-check", Healthy: true},
+		}
+		checker.CheckReturns(checks, nil)
+
+		result, err := runHealthChecksAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(action.RunHealthChecksResult{Healthy: true, Checks: checks}))
+
+		jobs, _ := checker.CheckArgsForCall(0)
+		Expect(jobs).To(Equal(specService.Spec.Jobs()))
+	})
+
+	It("is unhealthy when any health check fails", func() {
+		checker.CheckReturns([]healthcheck.Result{
+			{Job: "fake-job", Probe: healthcheck.ProbeScript, Healthy: true},
+			{Job: "fake-job", Probe: healthcheck.ProbeHTTP, Healthy: false, Error: "Responded with status 503"},
+		}, nil)
+
+		result, err := runHealthChecksAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Healthy).To(BeFalse())
+	})
+
+	It("is healthy when jobs have no health checks", func() {
+		checker.CheckReturns([]healthcheck.Result{}, nil)
+
+		result, err := runHealthChecksAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(action.RunHealthChecksResult{Healthy: true, Checks: []healthcheck.Result{}}))
+	})
+
+	It("returns an error when getting the applied spec fails", func() {
+		specService.GetErr = errors.New("fake-get-err")
+
+		_, err := runHealthChecksAction.Run()
+		Expect(err).To(MatchError("Getting applied spec: fake-get-err"))
+		Expect(checker.CheckCallCount()).To(Equal(0))
+	})
+
+	It("returns an error when the health checks are cancelled", func() {
+		checker.CheckReturns(nil, boshtask.ErrCancelled)
+
+		_, err := runHealthChecksAction.Run()
+		Expect(err).To(MatchError(ContainSubstring("Running health checks")))
+	})
+})
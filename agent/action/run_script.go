@@ -2,21 +2,41 @@ package action
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
+// RunScriptOptions parameterize a single run of the job scripts. Env is added
+// to the environment of every script and Args are passed to them as
+// positional arguments. Scripts still running after TimeoutSeconds are
+// terminated, without it they run until they finish.
 type RunScriptOptions struct {
-	Env map[string]string `json:"env"`
+	Env            map[string]string `json:"env"`
+	Args           []string          `json:"args"`
+	TimeoutSeconds int               `json:"timeout_seconds"`
+}
+
+// streamedScripts publish their output as task output while they run, so
+// that deploys hanging in them can be followed from the director
+var streamedScripts = map[string]bool{
+	"pre-start":  true,
+	"post-start": true,
 }
 
 type RunScriptAction struct {
 	scriptProvider boshscript.JobScriptProvider
 	specService    boshas.V1Service
+	applier        boshappl.Applier
+	outputReporter boshtask.OutputReporter
 
 	logTag string
 	logger boshlog.Logger
@@ -25,11 +45,15 @@ type RunScriptAction struct {
 func NewRunScript(
 	scriptProvider boshscript.JobScriptProvider,
 	specService boshas.V1Service,
+	applier boshappl.Applier,
+	outputReporter boshtask.OutputReporter,
 	logger boshlog.Logger,
 ) RunScriptAction {
 	return RunScriptAction{
 		scriptProvider: scriptProvider,
 		specService:    specService,
+		applier:        applier,
+		outputReporter: outputReporter,
 
 		logTag: "RunScript Action",
 		logger: logger,
@@ -52,15 +76,41 @@ func (a RunScriptAction) Run(scriptName string, options RunScriptOptions) (map[s
 	// May be used in future to return more information
 	emptyResults := map[string]string{}
 
+	err := validateScriptEnv(options.Env)
+	if err != nil {
+		return emptyResults, err
+	}
+
+	if options.TimeoutSeconds < 0 {
+		return emptyResults, bosherr.Errorf("Invalid script timeout %ds", options.TimeoutSeconds)
+	}
+
+	timeout := time.Duration(options.TimeoutSeconds) * time.Second
+
 	currentSpec, err := a.specService.Get()
 	if err != nil {
 		return emptyResults, bosherr.WrapError(err, "Getting current spec")
 	}
 
 	scripts := make([]boshscript.Script, 0, len(currentSpec.Jobs()))
+	var runningJobs []models.Job
 	for _, job := range currentSpec.Jobs() {
-		script := a.scriptProvider.NewScript(job.BundleName(), scriptName, options.Env)
+		var script boshscript.Script
+		if streamedScripts[scriptName] {
+			script = a.scriptProvider.NewStreamingScript(job.BundleName(), scriptName, options.Env, options.Args, timeout, a.reportOutput)
+		} else {
+			script = a.scriptProvider.NewScript(job.BundleName(), scriptName, options.Env, options.Args, timeout)
+		}
 		scripts = append(scripts, script)
+
+		if script.Exists() {
+			runningJobs = append(runningJobs, job)
+		}
+	}
+
+	err = a.applier.ApplyDeferredPackages(runningJobs)
+	if err != nil {
+		return emptyResults, bosherr.WrapErrorf(err, "Applying packages of jobs with %s scripts", scriptName)
 	}
 
 	parallelScript := a.scriptProvider.NewParallelScript(scriptName, scripts)
@@ -68,6 +118,19 @@ func (a RunScriptAction) Run(scriptName string, options RunScriptOptions) (map[s
 	return emptyResults, parallelScript.Run()
 }
 
+func (a RunScriptAction) reportOutput(line boshscript.OutputLine) {
+	a.outputReporter.ReportOutput(line)
+}
+
+func validateScriptEnv(env map[string]string) error {
+	for name := range env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return bosherr.Errorf("Invalid environment variable name '%s'", name)
+		}
+	}
+	return nil
+}
+
 func (a RunScriptAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
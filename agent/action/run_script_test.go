@@ -2,6 +2,7 @@ package action_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -11,14 +12,18 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeapplyspec "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
 	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/script/scriptfakes"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 )
 
 var _ = Describe("RunScript", func() {
 	var (
 		fakeJobScriptProvider *scriptfakes.FakeJobScriptProvider
 		specService           *fakeapplyspec.FakeV1Service
+		applier               *fakeappl.FakeApplier
+		outputReporter        *faketask.FakeOutputReporter
 		runScriptAction       action.RunScriptAction
 		options               action.RunScriptOptions
 	)
@@ -27,12 +32,16 @@ var _ = Describe("RunScript", func() {
 		fakeJobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
 		specService = fakeapplyspec.NewFakeV1Service()
 		specService.Spec.RenderedTemplatesArchiveSpec = &applyspec.RenderedTemplatesArchiveSpec{}
+		applier = fakeappl.NewFakeApplier()
+		outputReporter = &faketask.FakeOutputReporter{}
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		runScriptAction = action.NewRunScript(fakeJobScriptProvider, specService, logger)
+		runScriptAction = action.NewRunScript(fakeJobScriptProvider, specService, applier, outputReporter, logger)
 		options = action.RunScriptOptions{
 			Env: map[string]string{
 				"FOO": "foo",
 			},
+			Args:           []string{"--verbose", "fake-arg"},
+			TimeoutSeconds: 90,
 		}
 	})
 
@@ -68,9 +77,11 @@ var _ = Describe("RunScript", func() {
 				script2 := &scriptfakes.FakeScript{}
 				script2.TagReturns("fake-job-2")
 
-				fakeJobScriptProvider.NewScriptStub = func(jobName, scriptName string, scriptEnv map[string]string) boshscript.Script {
+				fakeJobScriptProvider.NewScriptStub = func(jobName, scriptName string, scriptEnv map[string]string, scriptArgs []string, timeout time.Duration) boshscript.Script {
 					Expect(scriptName).To(Equal("run-me"))
 					Expect(scriptEnv["FOO"]).To(Equal("foo"))
+					Expect(scriptArgs).To(Equal([]string{"--verbose", "fake-arg"}))
+					Expect(timeout).To(Equal(90 * time.Second))
 
 					if jobName == "fake-job-1" { //nolint:staticcheck
 						return script1
@@ -94,6 +105,69 @@ var _ = Describe("RunScript", func() {
 				Expect(scripts).To(Equal([]boshscript.Script{script1, script2}))
 			})
 
+			It("streams the output of pre-start and post-start scripts as task output", func() {
+				createFakeJob("fake-job-1")
+				script1 := &scriptfakes.FakeScript{}
+				fakeJobScriptProvider.NewStreamingScriptReturns(script1)
+
+				_, err := runScriptAction.Run("pre-start", options)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeJobScriptProvider.NewScriptCallCount()).To(Equal(0))
+				Expect(fakeJobScriptProvider.NewStreamingScriptCallCount()).To(Equal(1))
+
+				jobName, scriptName, scriptEnv, scriptArgs, timeout, output := fakeJobScriptProvider.NewStreamingScriptArgsForCall(0)
+				Expect(jobName).To(Equal("fake-job-1"))
+				Expect(scriptName).To(Equal("pre-start"))
+				Expect(scriptEnv["FOO"]).To(Equal("foo"))
+				Expect(scriptArgs).To(Equal([]string{"--verbose", "fake-arg"}))
+				Expect(timeout).To(Equal(90 * time.Second))
+
+				line := boshscript.OutputLine{Job: "fake-job-1", Script: "pre-start", Stream: "stdout", Text: "fake-text"}
+				output(line)
+				Expect(outputReporter.Reported).To(Equal([]interface{}{line}))
+
+				_, scripts := fakeJobScriptProvider.NewParallelScriptArgsForCall(0)
+				Expect(scripts).To(Equal([]boshscript.Script{script1}))
+			})
+
+			It("does not stream the output of other scripts", func() {
+				createFakeJob("fake-job-1")
+				fakeJobScriptProvider.NewScriptReturns(&scriptfakes.FakeScript{})
+
+				_, err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeJobScriptProvider.NewScriptCallCount()).To(Equal(1))
+				Expect(fakeJobScriptProvider.NewStreamingScriptCallCount()).To(Equal(0))
+			})
+
+			It("applies the deferred packages of jobs that have the script", func() {
+				createFakeJob("fake-job-1")
+				createFakeJob("fake-job-2")
+
+				fakeJobScriptProvider.NewScriptStub = func(jobName, _ string, _ map[string]string, _ []string, _ time.Duration) boshscript.Script {
+					script := &scriptfakes.FakeScript{}
+					script.ExistsReturns(jobName == "fake-job-2")
+					return script
+				}
+
+				_, err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(applier.DeferredPackagesJobs).To(HaveLen(1))
+				Expect(applier.DeferredPackagesJobs[0].Name).To(Equal("fake-job-2"))
+			})
+
+			It("returns an error without running scripts when applying deferred packages fails", func() {
+				applier.DeferredPackagesError = errors.New("fake-deferred-packages-error")
+
+				_, err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-deferred-packages-error"))
+				Expect(parallelScript.RunCallCount()).To(Equal(0))
+			})
+
 			It("returns an error when parallel script fails", func() {
 				parallelScript.RunReturns(errors.New("fake-error"))
 
@@ -104,6 +178,22 @@ var _ = Describe("RunScript", func() {
 			})
 		})
 
+		It("returns an error without running scripts for invalid environment variable names", func() {
+			options.Env = map[string]string{"FOO=BAR": "foo"}
+
+			_, err := act()
+			Expect(err).To(MatchError("Invalid environment variable name 'FOO=BAR'"))
+			Expect(fakeJobScriptProvider.NewScriptCallCount()).To(Equal(0))
+		})
+
+		It("returns an error without running scripts for negative timeouts", func() {
+			options.TimeoutSeconds = -1
+
+			_, err := act()
+			Expect(err).To(MatchError("Invalid script timeout -1s"))
+			Expect(fakeJobScriptProvider.NewScriptCallCount()).To(Equal(0))
+		})
+
 		Context("when current spec cannot be retrieved", func() {
 			It("without current spec", func() {
 				specService.GetErr = errors.New("fake-spec-get-error")
@@ -0,0 +1,101 @@
+package action
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/loglevel"
+)
+
+// SetLogLevelOptions name the level, one of debug, info, warn and error.
+// A positive RevertAfterSeconds reverts to the configured level afterwards.
+type SetLogLevelOptions struct {
+	Level              string `json:"level"`
+	RevertAfterSeconds int    `json:"revert_after_seconds"`
+}
+
+type SetLogLevelResult struct {
+	Level         string     `json:"level"`
+	PreviousLevel string     `json:"previous_level"`
+	RevertAt      *time.Time `json:"revert_at,omitempty"`
+}
+
+// SetLogLevelAction changes the verbosity of the agent logs while the agent
+// runs, so intermittent issues can be debugged without restarting it
+type SetLogLevelAction struct {
+	logLevel *loglevel.Logger
+}
+
+func NewSetLogLevel(logLevel *loglevel.Logger) SetLogLevelAction {
+	return SetLogLevelAction{logLevel: logLevel}
+}
+
+func (a SetLogLevelAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a SetLogLevelAction) IsPersistent() bool {
+	return false
+}
+
+func (a SetLogLevelAction) IsLoggable() bool {
+	return true
+}
+
+func (a SetLogLevelAction) Run(options SetLogLevelOptions) (SetLogLevelResult, error) {
+	level, err := parseLogLevel(options.Level)
+	if err != nil {
+		return SetLogLevelResult{}, err
+	}
+
+	if options.RevertAfterSeconds < 0 {
+		return SetLogLevelResult{}, bosherr.Errorf("Invalid revert_after_seconds %d", options.RevertAfterSeconds)
+	}
+
+	previousLevel, _ := a.logLevel.Level()
+
+	a.logLevel.SetLevel(level, time.Duration(options.RevertAfterSeconds)*time.Second)
+
+	result := SetLogLevelResult{
+		Level:         logLevelName(level),
+		PreviousLevel: logLevelName(previousLevel),
+	}
+
+	_, revertAt := a.logLevel.Level()
+	if !revertAt.IsZero() {
+		result.RevertAt = &revertAt
+	}
+
+	return result, nil
+}
+
+func (a SetLogLevelAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a SetLogLevelAction) Cancel() error {
+	return errors.New("not supported")
+}
+
+func parseLogLevel(name string) (boshlog.LogLevel, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return boshlog.LevelDebug, nil
+	case "info":
+		return boshlog.LevelInfo, nil
+	case "warn":
+		return boshlog.LevelWarn, nil
+	case "error":
+		return boshlog.LevelError, nil
+	default:
+		return 0, bosherr.Errorf("Invalid log level '%s', expected one of debug, info, warn, error", name)
+	}
+}
+
+func logLevelName(level boshlog.LogLevel) string {
+	return strings.ToLower(boshlog.AsString(level))
+}
@@ -0,0 +1,65 @@
+package action_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/loglevel"
+)
+
+var _ = Describe("SetLogLevel", func() {
+	var (
+		timeService       *fakeclock.FakeClock
+		logLevel          *loglevel.Logger
+		setLogLevelAction action.SetLogLevelAction
+	)
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+		logLevel = loglevel.NewLogger(boshlog.NewLogger(boshlog.LevelNone), boshlog.LevelInfo, timeService)
+		setLogLevelAction = action.NewSetLogLevel(logLevel)
+	})
+
+	AssertActionIsNotAsynchronous(setLogLevelAction)
+	AssertActionIsNotPersistent(setLogLevelAction)
+	AssertActionIsLoggable(setLogLevelAction)
+
+	AssertActionIsNotCancelable(setLogLevelAction)
+	AssertActionIsNotResumable(setLogLevelAction)
+
+	It("changes the log level", func() {
+		result, err := setLogLevelAction.Run(action.SetLogLevelOptions{Level: "debug"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(action.SetLogLevelResult{Level: "debug", PreviousLevel: "info"}))
+
+		level, _ := logLevel.Level()
+		Expect(level).To(Equal(boshlog.LevelDebug))
+	})
+
+	It("tells when the log level is reverted", func() {
+		result, err := setLogLevelAction.Run(action.SetLogLevelOptions{Level: "WARN", RevertAfterSeconds: 600})
+		Expect(err).ToNot(HaveOccurred())
+
+		revertAt := time.Date(2026, 1, 2, 3, 14, 5, 0, time.UTC)
+		Expect(result).To(Equal(action.SetLogLevelResult{Level: "warn", PreviousLevel: "info", RevertAt: &revertAt}))
+	})
+
+	It("returns an error for unknown levels", func() {
+		_, err := setLogLevelAction.Run(action.SetLogLevelOptions{Level: "none"})
+		Expect(err).To(MatchError("Invalid log level 'none', expected one of debug, info, warn, error"))
+
+		level, _ := logLevel.Level()
+		Expect(level).To(Equal(boshlog.LevelInfo))
+	})
+
+	It("returns an error for negative durations", func() {
+		_, err := setLogLevelAction.Run(action.SetLogLevelOptions{Level: "debug", RevertAfterSeconds: -1})
+		Expect(err).To(MatchError("Invalid revert_after_seconds -1"))
+	})
+})
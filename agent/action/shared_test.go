@@ -63,6 +63,12 @@ func AssertActionIsNotCancelable(a action.Action) {
 	})
 }
 
+func AssertActionIsCancelable(a action.Action) {
+	It("can be cancelled", func() {
+		Expect(a.Cancel()).To(Succeed())
+	})
+}
+
 func AssertActionIsResumable(a action.Action) {
 	It("can be resumed", func() {
 		value, err := a.Resume()
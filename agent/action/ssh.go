@@ -2,20 +2,36 @@ package action
 
 import (
 	"errors"
+	"fmt"
 	"path"
+	"strings"
+	"time"
 
+	"code.cloudfoundry.org/clock"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"golang.org/x/crypto/ssh"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/sshusers"
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
 
+const (
+	defaultSSHCertificateTTL = time.Hour
+	maxSSHCertificateTTL     = 24 * time.Hour
+
+	defaultSSHUserTTL = 24 * time.Hour
+	maxSSHUserTTL     = 7 * 24 * time.Hour
+)
+
 type SSHAction struct {
 	settingsService boshsettings.Service
 	platform        boshplatform.Platform
 	dirProvider     boshdirs.Provider
+	timeService     clock.Clock
+	users           sshusers.Tracker
 	logger          boshlog.Logger
 }
 
@@ -23,11 +39,15 @@ func NewSSH(
 	settingsService boshsettings.Service,
 	platform boshplatform.Platform,
 	dirProvider boshdirs.Provider,
+	timeService clock.Clock,
+	users sshusers.Tracker,
 	logger boshlog.Logger,
 ) (action SSHAction) {
 	action.settingsService = settingsService
 	action.platform = platform
 	action.dirProvider = dirProvider
+	action.timeService = timeService
+	action.users = users
 	action.logger = logger
 	return
 }
@@ -48,6 +68,16 @@ type SSHParams struct {
 	UserRegex string `json:"user_regex"`
 	User      string
 	PublicKey string `json:"public_key"`
+
+	// CAPublicKey is trusted to sign certificates for the user instead of
+	// trusting PublicKey. The CA is trusted for CertificateTTLSeconds, so
+	// only short-lived certificates issued for the session are accepted.
+	CAPublicKey           string `json:"ca_public_key"`
+	CertificateTTLSeconds int    `json:"certificate_ttl_seconds"`
+
+	// TTLSeconds is how long the user is kept when cleanup is never
+	// requested, e.g. because the director lost connectivity to the agent
+	TTLSeconds int `json:"ttl_seconds"`
 }
 
 type SSHResult struct {
@@ -73,6 +103,19 @@ func (a SSHAction) setupSSH(params SSHParams) (SSHResult, error) {
 
 	boshSSHPath := path.Join(a.dirProvider.BaseDir(), "bosh_ssh")
 
+	authorizedKey, err := a.authorizedKey(params)
+	if err != nil {
+		return result, err
+	}
+
+	userTTL := defaultSSHUserTTL
+	if params.TTLSeconds != 0 {
+		userTTL = time.Duration(params.TTLSeconds) * time.Second
+		if params.TTLSeconds < 0 || userTTL > maxSSHUserTTL {
+			return result, bosherr.Errorf("SSH user TTL %ds is not between 1s and %s", params.TTLSeconds, maxSSHUserTTL)
+		}
+	}
+
 	// this must happen first so that unfulfilled prerequisites on Windows
 	// can stop the creation of new users
 	publicKey, err := a.platform.GetHostPublicKey()
@@ -80,6 +123,13 @@ func (a SSHAction) setupSSH(params SSHParams) (SSHResult, error) {
 		return result, bosherr.WrapError(err, "Getting host public key")
 	}
 
+	// the user is tracked before it is created so that it is removed
+	// even when setting it up fails half-way
+	err = a.users.Track(params.User, userTTL)
+	if err != nil {
+		return result, bosherr.WrapError(err, "Tracking user expiry")
+	}
+
 	err = a.platform.CreateUser(params.User, boshSSHPath)
 	if err != nil {
 		return result, bosherr.WrapError(err, "Creating user")
@@ -90,7 +140,7 @@ func (a SSHAction) setupSSH(params SSHParams) (SSHResult, error) {
 		return result, bosherr.WrapError(err, "Adding user to groups")
 	}
 
-	err = a.platform.SetupSSH([]string{params.PublicKey}, params.User)
+	err = a.platform.SetupSSH([]string{authorizedKey}, params.User)
 	if err != nil {
 		return result, bosherr.WrapError(err, "Setting ssh public key") //nolint:staticcheck
 	}
@@ -112,6 +162,37 @@ func (a SSHAction) setupSSH(params SSHParams) (SSHResult, error) {
 	return result, nil
 }
 
+// authorizedKey returns the authorized_keys entry letting the user in with
+// their public key, or with certificates of the CA when one is given
+func (a SSHAction) authorizedKey(params SSHParams) (string, error) {
+	if params.CAPublicKey == "" {
+		return params.PublicKey, nil
+	}
+
+	caPublicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(params.CAPublicKey))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Parsing SSH CA public key")
+	}
+
+	ttl := defaultSSHCertificateTTL
+	if params.CertificateTTLSeconds != 0 {
+		ttl = time.Duration(params.CertificateTTLSeconds) * time.Second
+		if params.CertificateTTLSeconds < 0 || ttl > maxSSHCertificateTTL {
+			return "", bosherr.Errorf("SSH certificate TTL %ds is not between 1s and %s", params.CertificateTTLSeconds, maxSSHCertificateTTL)
+		}
+	}
+
+	// sshd reads the expiry time in the time zone of the VM
+	expiresAt := a.timeService.Now().Add(ttl).Format("200601021504")
+
+	return fmt.Sprintf(
+		`cert-authority,principals="%s",expiry-time="%s" %s`,
+		params.User,
+		expiresAt,
+		strings.TrimSpace(string(ssh.MarshalAuthorizedKey(caPublicKey))),
+	), nil
+}
+
 func (a SSHAction) cleanupSSH(params SSHParams) (SSHResult, error) {
 	err := a.platform.DeleteEphemeralUsersMatching(params.UserRegex)
 	if err != nil {
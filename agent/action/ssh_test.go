@@ -1,15 +1,22 @@
 package action_test
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"errors"
+	"strings"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/sshusers/sshusersfakes"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
@@ -20,6 +27,8 @@ var _ = Describe("SSHAction", func() {
 	var (
 		platform        *platformfakes.FakePlatform
 		settingsService boshsettings.Service
+		timeService     *fakeclock.FakeClock
+		sshUsers        *sshusersfakes.FakeTracker
 		sshAction       action.SSHAction
 	)
 
@@ -27,9 +36,11 @@ var _ = Describe("SSHAction", func() {
 		settingsService = &fakesettings.FakeSettingsService{}
 
 		platform = &platformfakes.FakePlatform{}
+		timeService = fakeclock.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local))
+		sshUsers = &sshusersfakes.FakeTracker{}
 		dirProvider := boshdirs.NewProvider("/foo")
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		sshAction = action.NewSSH(settingsService, platform, dirProvider, logger)
+		sshAction = action.NewSSH(settingsService, platform, dirProvider, timeService, sshUsers, logger)
 	})
 
 	AssertActionIsNotAsynchronous(sshAction)
@@ -50,6 +61,11 @@ var _ = Describe("SSHAction", func() {
 
 				platformPublicKeyValue string
 				platformPublicKeyErr   error
+
+				caPublicKey           string
+				certificateTTLSeconds int
+
+				ttlSeconds int
 			)
 
 			BeforeEach(func() {
@@ -57,6 +73,11 @@ var _ = Describe("SSHAction", func() {
 
 				platformPublicKeyValue = ""
 				platformPublicKeyErr = nil
+
+				caPublicKey = ""
+				certificateTTLSeconds = 0
+
+				ttlSeconds = 0
 			})
 
 			JustBeforeEach(func() {
@@ -70,11 +91,16 @@ var _ = Describe("SSHAction", func() {
 				params = action.SSHParams{
 					User:      "fake-user",
 					PublicKey: "fake-public-key",
+
+					CAPublicKey:           caPublicKey,
+					CertificateTTLSeconds: certificateTTLSeconds,
+
+					TTLSeconds: ttlSeconds,
 				}
 
 				dirProvider := boshdirs.NewProvider("/foo")
 				logger := boshlog.NewLogger(boshlog.LevelNone)
-				sshAction = action.NewSSH(settingsService, platform, dirProvider, logger)
+				sshAction = action.NewSSH(settingsService, platform, dirProvider, timeService, sshUsers, logger)
 				response, err = sshAction.Run("setup", params)
 			})
 
@@ -112,6 +138,110 @@ var _ = Describe("SSHAction", func() {
 				})
 			})
 
+			It("tracks the user to remove it after a day", func() {
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(sshUsers.TrackCallCount()).To(Equal(1))
+				user, ttl := sshUsers.TrackArgsForCall(0)
+				Expect(user).To(Equal("fake-user"))
+				Expect(ttl).To(Equal(24 * time.Hour))
+			})
+
+			Context("with a TTL", func() {
+				BeforeEach(func() {
+					ttlSeconds = 600
+				})
+
+				It("tracks the user to remove it after the TTL", func() {
+					Expect(err).ToNot(HaveOccurred())
+
+					_, ttl := sshUsers.TrackArgsForCall(0)
+					Expect(ttl).To(Equal(10 * time.Minute))
+				})
+			})
+
+			Context("with a TTL longer than a week", func() {
+				BeforeEach(func() {
+					ttlSeconds = 604801
+				})
+
+				It("returns an error without creating the user", func() {
+					Expect(err).To(MatchError("SSH user TTL 604801s is not between 1s and 168h0m0s"))
+					Expect(sshUsers.TrackCallCount()).To(BeZero())
+					Expect(platform.CreateUserCallCount()).To(BeZero())
+				})
+			})
+
+			Context("when the user cannot be tracked", func() {
+				BeforeEach(func() {
+					sshUsers.TrackReturns(errors.New("fake-track-err"))
+				})
+
+				It("returns an error without creating the user", func() {
+					Expect(err).To(MatchError(ContainSubstring("fake-track-err")))
+					Expect(platform.CreateUserCallCount()).To(BeZero())
+				})
+			})
+
+			Context("with an SSH CA public key", func() {
+				var marshalledCAPublicKey string
+
+				BeforeEach(func() {
+					publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+					Expect(err).ToNot(HaveOccurred())
+
+					sshPublicKey, err := ssh.NewPublicKey(publicKey)
+					Expect(err).ToNot(HaveOccurred())
+
+					marshalledCAPublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublicKey)))
+					caPublicKey = marshalledCAPublicKey + " fake-ca-comment\n"
+				})
+
+				It("trusts certificates of the CA for the user for an hour instead of the public key", func() {
+					Expect(err).ToNot(HaveOccurred())
+
+					publicKeys, _ := platform.SetupSSHArgsForCall(0)
+					Expect(publicKeys).To(ConsistOf(
+						`cert-authority,principals="fake-user",expiry-time="202601020404" ` + marshalledCAPublicKey,
+					))
+				})
+
+				Context("with a certificate TTL", func() {
+					BeforeEach(func() {
+						certificateTTLSeconds = 300
+					})
+
+					It("trusts the CA for the TTL", func() {
+						Expect(err).ToNot(HaveOccurred())
+
+						publicKeys, _ := platform.SetupSSHArgsForCall(0)
+						Expect(publicKeys[0]).To(ContainSubstring(`expiry-time="202601020309"`))
+					})
+				})
+
+				Context("with a certificate TTL longer than a day", func() {
+					BeforeEach(func() {
+						certificateTTLSeconds = 86401
+					})
+
+					It("returns an error without creating the user", func() {
+						Expect(err).To(MatchError("SSH certificate TTL 86401s is not between 1s and 24h0m0s"))
+						Expect(platform.CreateUserCallCount()).To(BeZero())
+					})
+				})
+
+				Context("when the CA public key is malformed", func() {
+					BeforeEach(func() {
+						caPublicKey = "fake-ca-public-key"
+					})
+
+					It("returns an error without creating the user", func() {
+						Expect(err).To(MatchError(ContainSubstring("Parsing SSH CA public key")))
+						Expect(platform.CreateUserCallCount()).To(BeZero())
+					})
+				})
+			})
+
 			Context("with a host public key available", func() {
 				It("should return SSH Result with HostPublicKey", func() {
 					hostPublicKey, _ := platform.GetHostPublicKey() //nolint:errcheck
@@ -2,6 +2,7 @@ package action
 
 import (
 	"errors"
+	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
@@ -37,7 +38,19 @@ func (a StartAction) IsLoggable() bool {
 	return true
 }
 
-func (a StartAction) Run() (value string, err error) {
+// Run starts all jobs, or only the named jobs without configuring them again
+func (a StartAction) Run(jobNames ...string) (value string, err error) {
+	if len(jobNames) > 0 {
+		err = a.jobSupervisor.StartJobs(jobNames)
+		if err != nil {
+			err = bosherr.WrapErrorf(err, "Starting jobs %s", strings.Join(jobNames, ", "))
+			return
+		}
+
+		value = "started"
+		return
+	}
+
 	desiredApplySpec, err := a.specService.Get()
 	if err != nil {
 		err = bosherr.WrapError(err, "Getting apply spec")
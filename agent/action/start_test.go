@@ -59,4 +59,23 @@ var _ = Describe("Start", func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("Configuring jobs"))
 	})
+
+	Context("when jobs are named", func() {
+		It("only starts the named jobs without configuring jobs", func() {
+			started, err := startAction.Run("fake-job-1", "fake-job-2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(started).To(Equal("started"))
+
+			Expect(jobSupervisor.StartedJobs).To(Equal([]string{"fake-job-1", "fake-job-2"}))
+			Expect(jobSupervisor.Started).To(BeFalse())
+			Expect(applier.Configured).To(BeFalse())
+		})
+
+		It("returns an error when starting the jobs fails", func() {
+			jobSupervisor.StartJobsErr = errors.New("fake-start-jobs-error")
+
+			_, err := startAction.Run("fake-job-1", "fake-job-2")
+			Expect(err).To(MatchError("Starting jobs fake-job-1, fake-job-2: fake-start-jobs-error"))
+		})
+	})
 })
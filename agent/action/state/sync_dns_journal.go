@@ -0,0 +1,182 @@
+package state
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	journalSuffix = ".journal"
+
+	// Once the journal holds more than maxJournalEntries generations
+	// it is rewritten to only hold the newest compactedJournalEntries.
+	maxJournalEntries       = 10
+	compactedJournalEntries = 3
+)
+
+// journalEntry is a single generation of DNS records.
+// Each entry is written as one line of JSON.
+type journalEntry struct {
+	Version uint64 `json:"version"`
+	SHA256  string `json:"sha256"`
+	Records []byte `json:"records"`
+}
+
+func newJournalEntry(records []byte) (journalEntry, error) {
+	var localVersion struct {
+		Version uint64 `json:"version"`
+	}
+
+	err := json.Unmarshal(records, &localVersion)
+	if err != nil {
+		return journalEntry{}, bosherr.WrapError(err, "unmarshalling DNS records version")
+	}
+
+	return journalEntry{
+		Version: localVersion.Version,
+		SHA256:  checksum(records),
+		Records: records,
+	}, nil
+}
+
+func (e journalEntry) isValid() bool {
+	return e.SHA256 != "" && checksum(e.Records) == e.SHA256
+}
+
+type syncDNSJournal struct {
+	fs   boshsys.FileSystem
+	path string
+}
+
+// entries returns valid generations from oldest to newest. Torn or
+// corrupted lines (e.g. left behind by a crash) are skipped.
+func (j syncDNSJournal) entries() ([]journalEntry, bool, error) {
+	if !j.fs.FileExists(j.path) {
+		return nil, true, nil
+	}
+
+	contents, err := j.fs.ReadFileWithOpts(j.path, boshsys.ReadOpts{Quiet: true})
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "reading DNS records journal")
+	}
+
+	var entries []journalEntry
+
+	for _, line := range bytes.Split(contents, []byte("\n")) {
+		var entry journalEntry
+
+		if len(line) == 0 || json.Unmarshal(line, &entry) != nil || !entry.isValid() {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	endsWithNewline := len(contents) == 0 || contents[len(contents)-1] == '\n'
+
+	return entries, endsWithNewline, nil
+}
+
+func (j syncDNSJournal) lastEntry() (journalEntry, bool, error) {
+	entries, _, err := j.entries()
+	if err != nil || len(entries) == 0 {
+		return journalEntry{}, false, err
+	}
+
+	return entries[len(entries)-1], true, nil
+}
+
+func (j syncDNSJournal) append(entry journalEntry) error {
+	entries, endsWithNewline, err := j.entries()
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return bosherr.WrapError(err, "marshalling DNS records journal entry")
+	}
+
+	line = append(line, '\n')
+
+	// Terminate a torn line so that it does not swallow the new entry
+	if !endsWithNewline {
+		line = append([]byte("\n"), line...)
+	}
+
+	file, err := j.fs.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return bosherr.WrapError(err, "opening DNS records journal")
+	}
+
+	_, err = file.Write(line)
+	if err == nil {
+		err = syncFile(file)
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return bosherr.WrapError(err, "appending to DNS records journal")
+	}
+
+	if len(entries)+1 > maxJournalEntries {
+		return j.compact(append(entries, entry))
+	}
+
+	return nil
+}
+
+func (j syncDNSJournal) compact(entries []journalEntry) error {
+	if len(entries) > compactedJournalEntries {
+		entries = entries[len(entries)-compactedJournalEntries:]
+	}
+
+	var contents []byte
+
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return bosherr.WrapError(err, "marshalling DNS records journal entry")
+		}
+
+		contents = append(contents, line...)
+		contents = append(contents, '\n')
+	}
+
+	tmpPath := j.path + ".compacting"
+
+	err := j.fs.WriteFileQuietly(tmpPath, contents)
+	if err != nil {
+		return bosherr.WrapError(err, "writing compacted DNS records journal")
+	}
+
+	err = j.fs.Rename(tmpPath, j.path)
+	if err != nil {
+		return bosherr.WrapError(err, "renaming compacted DNS records journal")
+	}
+
+	return nil
+}
+
+func checksum(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
+
+func syncFile(file boshsys.File) error {
+	if syncer, ok := file.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+
+	return nil
+}
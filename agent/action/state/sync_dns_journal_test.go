@@ -0,0 +1,136 @@
+package state_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action/state"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
+)
+
+var _ = Describe("SyncDNSState journal", func() {
+	var (
+		path         string
+		journalPath  string
+		syncDNSState state.SyncDNSState
+	)
+
+	records := func(version int) []byte {
+		return []byte(fmt.Sprintf(`{"version": %d, "records": [["rec", "ip"]]}`, version))
+	}
+
+	journalLines := func() []string {
+		contents, err := os.ReadFile(journalPath)
+		Expect(err).ToNot(HaveOccurred())
+		return strings.Split(strings.TrimSpace(string(contents)), "\n")
+	}
+
+	BeforeEach(func() {
+		dir := GinkgoT().TempDir()
+		path = filepath.Join(dir, "records.json")
+		journalPath = path + ".journal"
+
+		fakePlatform := &platformfakes.FakePlatform{}
+		fakePlatform.GetFsReturns(boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone)))
+
+		syncDNSState = state.NewSyncDNSState(fakePlatform, path, boshuuid.NewGenerator())
+	})
+
+	It("appends each saved generation to the journal", func() {
+		Expect(syncDNSState.SaveState(records(1))).To(Succeed())
+		Expect(syncDNSState.SaveState(records(2))).To(Succeed())
+
+		Expect(journalLines()).To(HaveLen(2))
+		Expect(os.ReadFile(path)).To(Equal(records(2)))
+	})
+
+	It("compacts the journal once it grows too long", func() {
+		for i := 1; i <= 11; i++ {
+			Expect(syncDNSState.SaveState(records(i))).To(Succeed())
+		}
+
+		lines := journalLines()
+		Expect(lines).To(HaveLen(3))
+		Expect(lines[2]).To(ContainSubstring(`"version":11`))
+	})
+
+	It("rejects records without a version", func() {
+		Expect(syncDNSState.SaveState([]byte(`garbage`))).To(MatchError(ContainSubstring("creating DNS records journal entry")))
+	})
+
+	Describe("#Recover", func() {
+		It("does nothing without a journal", func() {
+			recovered, err := syncDNSState.Recover()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(BeFalse())
+		})
+
+		It("does nothing when the records file matches the journal", func() {
+			Expect(syncDNSState.SaveState(records(1))).To(Succeed())
+
+			recovered, err := syncDNSState.Recover()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(BeFalse())
+		})
+
+		It("restores a partially written records file from the last valid generation", func() {
+			Expect(syncDNSState.SaveState(records(1))).To(Succeed())
+			Expect(syncDNSState.SaveState(records(2))).To(Succeed())
+			Expect(os.WriteFile(path, records(2)[:10], 0600)).To(Succeed())
+
+			recovered, err := syncDNSState.Recover()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(BeTrue())
+			Expect(os.ReadFile(path)).To(Equal(records(2)))
+		})
+
+		It("restores a missing records file", func() {
+			Expect(syncDNSState.SaveState(records(1))).To(Succeed())
+			Expect(os.Remove(path)).To(Succeed())
+
+			recovered, err := syncDNSState.Recover()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(BeTrue())
+			Expect(os.ReadFile(path)).To(Equal(records(1)))
+		})
+
+		It("ignores torn journal entries", func() {
+			Expect(syncDNSState.SaveState(records(1))).To(Succeed())
+
+			journal, err := os.OpenFile(journalPath, os.O_WRONLY|os.O_APPEND, 0600)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = journal.WriteString(`{"version":2,"sha256":"abc`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(journal.Close()).To(Succeed())
+
+			Expect(os.WriteFile(path, []byte("{"), 0600)).To(Succeed())
+
+			recovered, err := syncDNSState.Recover()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(BeTrue())
+			Expect(os.ReadFile(path)).To(Equal(records(1)))
+
+			Expect(syncDNSState.SaveState(records(3))).To(Succeed())
+			Expect(journalLines()).To(HaveLen(3))
+			Expect(syncDNSState.NeedsUpdate(3)).To(BeFalse())
+		})
+
+		It("keeps newer records written without a journal entry", func() {
+			Expect(syncDNSState.SaveState(records(1))).To(Succeed())
+			Expect(os.WriteFile(path, records(5), 0600)).To(Succeed())
+
+			recovered, err := syncDNSState.Recover()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(BeFalse())
+			Expect(os.ReadFile(path)).To(Equal(records(5)))
+		})
+	})
+})
@@ -10,6 +10,11 @@ import (
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 )
 
+const RecordsFileName = "records.json"
+
+// SyncDNSState persists DNS records for consumption by bosh-dns. Every
+// generation is first appended to a checksummed journal so that a
+// damaged records file can be restored with Recover.
 type SyncDNSState struct {
 	platform      boshplatform.Platform
 	fs            boshsys.FileSystem
@@ -27,6 +32,55 @@ func NewSyncDNSState(platform boshplatform.Platform, path string, generator bosh
 }
 
 func (s SyncDNSState) SaveState(localDNSState []byte) error {
+	entry, err := newJournalEntry(localDNSState)
+	if err != nil {
+		return bosherr.WrapError(err, "creating DNS records journal entry")
+	}
+
+	err = s.journal().append(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.writeRecords(localDNSState)
+}
+
+// Recover restores the records file from the newest valid journal generation
+// when the file is missing or does not match it (e.g. after a crash during a write).
+// It returns whether the records file had to be restored.
+func (s SyncDNSState) Recover() (bool, error) {
+	entry, found, err := s.journal().lastEntry()
+	if err != nil || !found {
+		return false, err
+	}
+
+	if s.fs.FileExists(s.path) {
+		contents, err := s.fs.ReadFileWithOpts(s.path, boshsys.ReadOpts{Quiet: true})
+		if err == nil && checksum(contents) == entry.SHA256 {
+			return false, nil
+		}
+
+		// Records saved by an agent without journal support
+		if err == nil && json.Valid(contents) {
+			if version, err := s.loadVersion(); err == nil && version >= entry.Version {
+				return false, nil
+			}
+		}
+	}
+
+	err = s.writeRecords(entry.Records)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "restoring DNS records version %d", entry.Version)
+	}
+
+	return true, nil
+}
+
+func (s SyncDNSState) journal() syncDNSJournal {
+	return syncDNSJournal{fs: s.fs, path: s.path + journalSuffix}
+}
+
+func (s SyncDNSState) writeRecords(localDNSState []byte) error {
 	uuid, err := s.uuidGenerator.Generate()
 	if err != nil {
 		return bosherr.WrapError(err, "generating uuid for temp file")
@@ -2,6 +2,7 @@ package action
 
 import (
 	"errors"
+	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
@@ -31,7 +32,20 @@ func (a StopAction) IsLoggable() bool {
 	return true
 }
 
-func (a StopAction) Run(protocolVersion ProtocolVersion) (value string, err error) {
+// Run stops all jobs, or only the named jobs. Jobs stopped by name are not
+// reported as failing until they are started again.
+func (a StopAction) Run(protocolVersion ProtocolVersion, jobNames ...string) (value string, err error) {
+	if len(jobNames) > 0 {
+		err = a.jobSupervisor.StopJobs(jobNames)
+		if err != nil {
+			err = bosherr.WrapErrorf(err, "Stopping jobs %s", strings.Join(jobNames, ", "))
+			return
+		}
+
+		value = "stopped"
+		return
+	}
+
 	if protocolVersion > 2 {
 		err = a.jobSupervisor.StopAndWait()
 	} else {
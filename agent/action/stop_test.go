@@ -1,6 +1,8 @@
 package action_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -49,4 +51,22 @@ var _ = Describe("Stop", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(jobSupervisor.StoppedAndWaited).To(BeTrue())
 	})
+
+	Context("when jobs are named", func() {
+		It("only stops the named jobs", func() {
+			stopped, err := stopAction.Run(action.ProtocolVersion(3), "fake-job")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stopped).To(Equal("stopped"))
+
+			Expect(jobSupervisor.StoppedJobs).To(Equal([]string{"fake-job"}))
+			Expect(jobSupervisor.Stopped).To(BeFalse())
+		})
+
+		It("returns an error when stopping the jobs fails", func() {
+			jobSupervisor.StopJobsErr = errors.New("fake-stop-jobs-error")
+
+			_, err := stopAction.Run(action.ProtocolVersion(3), "fake-job")
+			Expect(err).To(MatchError("Stopping jobs fake-job: fake-stop-jobs-error"))
+		})
+	})
 })
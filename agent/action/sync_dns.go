@@ -19,8 +19,6 @@ import (
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 )
 
-const localDNSStateFilename = "records.json"
-
 type SyncDNS struct {
 	blobstore       blobstore_delegator.BlobstoreDelegator
 	settingsService boshsettings.Service
@@ -116,7 +114,7 @@ func (a SyncDNS) Run(blobID string, multiDigest boshcrypto.MultipleDigest, versi
 }
 
 func (a SyncDNS) createSyncDNSState() state.SyncDNSState {
-	stateFilePath := filepath.Join(a.platform.GetDirProvider().InstanceDNSDir(), localDNSStateFilename)
+	stateFilePath := filepath.Join(a.platform.GetDirProvider().InstanceDNSDir(), state.RecordsFileName)
 	return state.NewSyncDNSState(a.platform, stateFilePath, boshuuid.NewGenerator())
 }
 
@@ -124,5 +122,14 @@ func (a SyncDNS) needsUpdateWithLock(version uint64) bool {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 
-	return a.createSyncDNSState().NeedsUpdate(version)
+	syncDNSState := a.createSyncDNSState()
+
+	recovered, err := syncDNSState.Recover()
+	if err != nil {
+		a.logger.Error(a.logTag, "Failed to recover local DNS state: %s", err.Error())
+	} else if recovered {
+		a.logger.Info(a.logTag, "Recovered local DNS state from journal")
+	}
+
+	return syncDNSState.NeedsUpdate(version)
 }
@@ -122,7 +122,7 @@ func (a SyncDNSWithSignedURL) Run(request SyncDNSWithSignedURLRequest) (string,
 }
 
 func (a SyncDNSWithSignedURL) createSyncDNSState() state.SyncDNSState {
-	stateFilePath := filepath.Join(a.platform.GetDirProvider().InstanceDNSDir(), localDNSStateFilename)
+	stateFilePath := filepath.Join(a.platform.GetDirProvider().InstanceDNSDir(), state.RecordsFileName)
 	return state.NewSyncDNSState(a.platform, stateFilePath, boshuuid.NewGenerator())
 }
 
@@ -130,5 +130,14 @@ func (a SyncDNSWithSignedURL) needsUpdateWithLock(version uint64) bool {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 
-	return a.createSyncDNSState().NeedsUpdate(version)
+	syncDNSState := a.createSyncDNSState()
+
+	recovered, err := syncDNSState.Recover()
+	if err != nil {
+		a.logger.Error(a.logTag, "Failed to recover local DNS state: %s", err.Error())
+	} else if recovered {
+		a.logger.Info(a.logTag, "Recovered local DNS state from journal")
+	}
+
+	return syncDNSState.NeedsUpdate(version)
 }
@@ -0,0 +1,172 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	blobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logtail"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+const (
+	defaultTailLogsDuration = time.Minute
+
+	// Tasks run one at a time, so other tasks wait for tail_logs
+	maxTailLogsDuration = 5 * time.Minute
+)
+
+// TailLogsRequest selects the job logs to follow with globs relative to the
+// job logs directory, all of them are followed without globs. The followed
+// lines are streamed to SignedURL when given and published as task output
+// otherwise.
+type TailLogsRequest struct {
+	Files            []string          `json:"files"`
+	DurationSeconds  int               `json:"duration_seconds"`
+	SignedURL        string            `json:"signed_url"`
+	BlobstoreHeaders map[string]string `json:"blobstore_headers"`
+}
+
+type TailLogsResponse struct {
+	logtail.Summary
+	SHA1Digest string `json:"sha1,omitempty"`
+}
+
+// TailLogsAction follows job logs for a bounded duration, like tail -f
+// without having to ssh into the VM. Cancelling the task stops following
+// them early.
+type TailLogsAction struct {
+	follower       logtail.Follower
+	outputReporter boshtask.OutputReporter
+	blobDelegator  blobdelegator.BlobstoreDelegator
+
+	cancelSignals boshtask.CancelSignals
+}
+
+func NewTailLogs(
+	follower logtail.Follower,
+	outputReporter boshtask.OutputReporter,
+	blobDelegator blobdelegator.BlobstoreDelegator,
+	cancelSignals boshtask.CancelSignals,
+) (action TailLogsAction) {
+	action.follower = follower
+	action.outputReporter = outputReporter
+	action.blobDelegator = blobDelegator
+	action.cancelSignals = cancelSignals
+	return
+}
+
+func (a TailLogsAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a TailLogsAction) IsPersistent() bool {
+	return false
+}
+
+func (a TailLogsAction) IsLoggable() bool {
+	return true
+}
+
+func (a TailLogsAction) Run(request TailLogsRequest) (TailLogsResponse, error) {
+	cancel := a.cancelSignals.RunningCancelSignal()
+
+	duration, err := tailLogsDuration(request.DurationSeconds)
+	if err != nil {
+		return TailLogsResponse{}, err
+	}
+
+	if request.SignedURL != "" {
+		return a.streamToSignedURL(request, duration, cancel)
+	}
+
+	summary, err := a.follower.Follow(request.Files, duration, cancel, func(chunk logtail.Chunk) error {
+		a.outputReporter.ReportOutput(chunk)
+		return nil
+	})
+	if err != nil {
+		return TailLogsResponse{}, err
+	}
+
+	return TailLogsResponse{Summary: summary}, nil
+}
+
+// streamToSignedURL uploads the followed lines as plain text prefixed with
+// the log they were appended to. Lines are only followed once, so failed
+// uploads cannot be retried.
+func (a TailLogsAction) streamToSignedURL(request TailLogsRequest, duration time.Duration, cancel *boshtask.CancelSignal) (TailLogsResponse, error) {
+	reader, writer := io.Pipe()
+	uploadWriter := &uploadPipeWriter{writer: writer}
+
+	var (
+		summary   logtail.Summary
+		followErr error
+	)
+
+	followed := make(chan struct{})
+
+	go func() {
+		defer close(followed)
+
+		summary, followErr = a.follower.Follow(request.Files, duration, cancel, func(chunk logtail.Chunk) error {
+			for _, line := range chunk.Lines {
+				if _, err := fmt.Fprintf(uploadWriter, "%s: %s\n", line.File, line.Text); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+		writer.CloseWithError(followErr) //nolint:errcheck
+	}()
+
+	opened := false
+	open := func() (io.ReadCloser, error) {
+		if opened {
+			return nil, errors.New("Followed log lines can only be uploaded once") //nolint:staticcheck
+		}
+		opened = true
+		return reader, nil
+	}
+
+	digest, err := a.blobDelegator.WriteStream(request.SignedURL, open, request.BlobstoreHeaders)
+
+	// Stop following when the upload gave up
+	_ = reader.Close()
+	<-followed
+
+	if followErr != nil && !uploadWriter.abandoned {
+		return TailLogsResponse{}, followErr
+	}
+
+	if err != nil {
+		return TailLogsResponse{}, bosherr.WrapError(err, "Streaming followed logs to blobstore")
+	}
+
+	return TailLogsResponse{Summary: summary, SHA1Digest: digest.String()}, nil
+}
+
+func tailLogsDuration(seconds int) (time.Duration, error) {
+	if seconds == 0 {
+		return defaultTailLogsDuration, nil
+	}
+
+	duration := time.Duration(seconds) * time.Second
+	if seconds < 0 || duration > maxTailLogsDuration {
+		return 0, bosherr.Errorf("Following logs for %ds, which is not between 1s and %s", seconds, maxTailLogsDuration)
+	}
+
+	return duration, nil
+}
+
+func (a TailLogsAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a TailLogsAction) Cancel() error {
+	return nil
+}
@@ -0,0 +1,168 @@
+package action_test
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logtail"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logtail/logtailfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+)
+
+var _ = Describe("TailLogsAction", func() {
+	var (
+		follower       *logtailfakes.FakeFollower
+		outputReporter *faketask.FakeOutputReporter
+		blobstore      *fakeblobdelegator.FakeBlobstoreDelegator
+		taskService    *faketask.FakeService
+
+		chunk  logtail.Chunk
+		action TailLogsAction
+	)
+
+	BeforeEach(func() {
+		follower = &logtailfakes.FakeFollower{}
+		outputReporter = &faketask.FakeOutputReporter{}
+		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
+		taskService = faketask.NewFakeService()
+
+		chunk = logtail.Chunk{Sequence: 1, Lines: []logtail.Line{
+			{File: "web/web.log", Text: "first"},
+			{File: "db/db.log", Text: "second"},
+		}}
+		follower.FollowStub = func(_ []string, _ time.Duration, _ *boshtask.CancelSignal, emit func(logtail.Chunk) error) (logtail.Summary, error) {
+			return logtail.Summary{Chunks: 1, Lines: 2}, emit(chunk)
+		}
+
+		action = NewTailLogs(follower, outputReporter, blobstore, taskService)
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotPersistent(action)
+	AssertActionIsNotResumable(action)
+	AssertActionIsCancelable(action)
+
+	Describe("Run", func() {
+		It("publishes the followed lines as task output", func() {
+			response, err := action.Run(TailLogsRequest{Files: []string{"web/*.log"}, DurationSeconds: 30})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response).To(Equal(TailLogsResponse{Summary: logtail.Summary{Chunks: 1, Lines: 2}}))
+
+			Expect(outputReporter.Reported).To(Equal([]interface{}{chunk}))
+
+			globs, duration, _, _ := follower.FollowArgsForCall(0)
+			Expect(globs).To(Equal([]string{"web/*.log"}))
+			Expect(duration).To(Equal(30 * time.Second))
+		})
+
+		It("follows the logs for a minute by default", func() {
+			_, err := action.Run(TailLogsRequest{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, duration, _, _ := follower.FollowArgsForCall(0)
+			Expect(duration).To(Equal(time.Minute))
+		})
+
+		It("does not follow the logs for longer than five minutes", func() {
+			_, err := action.Run(TailLogsRequest{DurationSeconds: 301})
+			Expect(err).To(MatchError("Following logs for 301s, which is not between 1s and 5m0s"))
+
+			_, err = action.Run(TailLogsRequest{DurationSeconds: -1})
+			Expect(err).To(HaveOccurred())
+
+			Expect(follower.FollowCallCount()).To(BeZero())
+		})
+
+		It("stops following the logs when cancelled", func() {
+			cancelCh := make(chan struct{})
+			taskService.RunningCancelSignalResult = boshtask.NewCancelSignal(cancelCh)
+
+			follower.FollowStub = func(_ []string, _ time.Duration, cancel *boshtask.CancelSignal, _ func(logtail.Chunk) error) (logtail.Summary, error) {
+				close(cancelCh)
+				return logtail.Summary{}, cancel.Err()
+			}
+
+			_, err := action.Run(TailLogsRequest{})
+			Expect(err).To(MatchError(boshtask.ErrCancelled))
+		})
+
+		Context("when a signed URL is given", func() {
+			var uploaded string
+
+			BeforeEach(func() {
+				blobstore.WriteStreamStub = func(_ string, open httpblobprovider.OpenStream, _ map[string]string) (boshcrypto.MultipleDigest, error) {
+					stream, err := open()
+					if err != nil {
+						return boshcrypto.MultipleDigest{}, err
+					}
+
+					contents, err := io.ReadAll(stream)
+					uploaded = string(contents)
+					if err != nil {
+						return boshcrypto.MultipleDigest{}, err
+					}
+
+					return boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil
+				}
+			})
+
+			It("streams the followed lines to it", func() {
+				response, err := action.Run(TailLogsRequest{
+					SignedURL:        "fake-signed-url",
+					BlobstoreHeaders: map[string]string{"key": "value"},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response).To(Equal(TailLogsResponse{Summary: logtail.Summary{Chunks: 1, Lines: 2}, SHA1Digest: "fake-sha1"}))
+
+				Expect(uploaded).To(Equal("web/web.log: first\ndb/db.log: second\n"))
+				Expect(outputReporter.Reported).To(BeEmpty())
+
+				signedURL, _, headers := blobstore.WriteStreamArgsForCall(0)
+				Expect(signedURL).To(Equal("fake-signed-url"))
+				Expect(headers).To(Equal(map[string]string{"key": "value"}))
+			})
+
+			It("returns the error following the logs", func() {
+				follower.FollowStub = func([]string, time.Duration, *boshtask.CancelSignal, func(logtail.Chunk) error) (logtail.Summary, error) {
+					return logtail.Summary{}, errors.New("fake-follow-err")
+				}
+
+				_, err := action.Run(TailLogsRequest{SignedURL: "fake-signed-url"})
+				Expect(err).To(MatchError("fake-follow-err"))
+			})
+
+			It("returns the error uploading the lines", func() {
+				blobstore.WriteStreamStub = nil
+				blobstore.WriteStreamReturns(boshcrypto.MultipleDigest{}, errors.New("fake-upload-err"))
+
+				_, err := action.Run(TailLogsRequest{SignedURL: "fake-signed-url"})
+				Expect(err).To(MatchError("Streaming followed logs to blobstore: fake-upload-err"))
+			})
+
+			It("does not upload the lines again when retried", func() {
+				blobstore.WriteStreamStub = func(_ string, open httpblobprovider.OpenStream, _ map[string]string) (boshcrypto.MultipleDigest, error) {
+					stream, err := open()
+					Expect(err).ToNot(HaveOccurred())
+					_ = stream.Close()
+
+					_, err = open()
+					return boshcrypto.MultipleDigest{}, err
+				}
+
+				_, err := action.Run(TailLogsRequest{SignedURL: "fake-signed-url"})
+				Expect(err).To(MatchError(ContainSubstring("Followed log lines can only be uploaded once")))
+			})
+		})
+	})
+})
@@ -10,6 +10,14 @@ import (
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 )
 
+// UnmountDiskOptions handle persistent disks kept busy by processes instead
+// of retrying until they let go. Force kills the processes, Lazy detaches
+// the disk while they still use it.
+type UnmountDiskOptions struct {
+	Force bool `json:"force"`
+	Lazy  bool `json:"lazy"`
+}
+
 type UnmountDiskAction struct {
 	settingsService boshsettings.Service
 	platform        boshplatform.Platform
@@ -36,14 +44,20 @@ func (a UnmountDiskAction) IsLoggable() bool {
 	return true
 }
 
-func (a UnmountDiskAction) Run(diskID string) (value interface{}, err error) {
+func (a UnmountDiskAction) Run(diskID string, options ...UnmountDiskOptions) (value interface{}, err error) {
 	diskSettings, err := a.settingsService.GetPersistentDiskSettings(diskID)
 	if err != nil {
 		err = bosherr.WrapError(err, "Getting persistent disk settings")
 		return
 	}
 
-	didUnmount, err := a.platform.UnmountPersistentDisk(diskSettings)
+	var unmountOptions boshplatform.UnmountOptions
+	if len(options) > 0 {
+		unmountOptions.Force = options[0].Force
+		unmountOptions.Lazy = options[0].Lazy
+	}
+
+	report, err := a.platform.UnmountPersistentDisk(diskSettings, unmountOptions)
 	if err != nil {
 		err = bosherr.WrapError(err, "Unmounting persistent disk")
 		return
@@ -51,15 +65,26 @@ func (a UnmountDiskAction) Run(diskID string) (value interface{}, err error) {
 
 	msg := fmt.Sprintf("Partition of %+v is not mounted", diskSettings)
 
-	if didUnmount {
+	switch {
+	case report.Lazy:
+		msg = fmt.Sprintf("Lazily unmounted partition of %+v", diskSettings)
+	case report.DidUnmount:
 		msg = fmt.Sprintf("Unmounted partition of %+v", diskSettings)
 	}
 
 	type valueType struct {
-		Message string `json:"message"`
+		Message           string                     `json:"message"`
+		Lazy              bool                       `json:"lazy,omitempty"`
+		KilledProcesses   []boshplatform.MountHolder `json:"killed_processes,omitempty"`
+		BlockingProcesses []boshplatform.MountHolder `json:"blocking_processes,omitempty"`
 	}
 
-	value = valueType{Message: msg}
+	value = valueType{
+		Message:           msg,
+		Lazy:              report.Lazy,
+		KilledProcesses:   report.KilledProcesses,
+		BlockingProcesses: report.BlockingProcesses,
+	}
 	return
 }
 
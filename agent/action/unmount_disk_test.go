@@ -9,6 +9,7 @@ import (
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/disk"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
@@ -72,25 +73,53 @@ var _ = Describe("UnmountDiskAction", func() {
 	AssertActionIsNotCancelable(unmountDiskAction)
 
 	It("unmount disk when the disk is mounted", func() {
-		platform.UnmountPersistentDiskReturns(true, nil)
+		platform.UnmountPersistentDiskReturns(boshplatform.UnmountReport{DidUnmount: true}, nil)
 
 		result, err := unmountDiskAction.Run("vol-123")
 		Expect(err).ToNot(HaveOccurred())
 		boshassert.MatchesJSONString(GinkgoT(), result, `{"message":"Unmounted partition of {ID:vol-123 DeviceID: VolumeID:2 Lun:0 HostDeviceID:fake-host-device-id Path:/dev/sdf ISCSISettings:{InitiatorName:fake-initiator-name Username:fake-username Target:fake-target Password:fake-password} FileSystemType:ext4 MountOptions:[] Partitioner:}"}`)
 
 		Expect(platform.UnmountPersistentDiskCallCount()).To(Equal(1))
-		Expect(platform.UnmountPersistentDiskArgsForCall(0)).To(Equal(expectedDiskSettings))
+		diskSettings, options := platform.UnmountPersistentDiskArgsForCall(0)
+		Expect(diskSettings).To(Equal(expectedDiskSettings))
+		Expect(options).To(Equal(boshplatform.UnmountOptions{}))
 	})
 
 	It("unmount disk when the disk is not mounted", func() {
-		platform.UnmountPersistentDiskReturns(false, nil)
+		platform.UnmountPersistentDiskReturns(boshplatform.UnmountReport{}, nil)
 
 		result, err := unmountDiskAction.Run("vol-123")
 		Expect(err).ToNot(HaveOccurred())
 		boshassert.MatchesJSONString(GinkgoT(), result, `{"message":"Partition of {ID:vol-123 DeviceID: VolumeID:2 Lun:0 HostDeviceID:fake-host-device-id Path:/dev/sdf ISCSISettings:{InitiatorName:fake-initiator-name Username:fake-username Target:fake-target Password:fake-password} FileSystemType:ext4 MountOptions:[] Partitioner:} is not mounted"}`)
 
 		Expect(platform.UnmountPersistentDiskCallCount()).To(Equal(1))
-		Expect(platform.UnmountPersistentDiskArgsForCall(0)).To(Equal(expectedDiskSettings))
+		diskSettings, options := platform.UnmountPersistentDiskArgsForCall(0)
+		Expect(diskSettings).To(Equal(expectedDiskSettings))
+		Expect(options).To(Equal(boshplatform.UnmountOptions{}))
+	})
+
+	It("passes force and lazy options and reports the processes holding the disk", func() {
+		platform.UnmountPersistentDiskReturns(boshplatform.UnmountReport{
+			DidUnmount:        true,
+			Lazy:              true,
+			KilledProcesses:   []boshplatform.MountHolder{{PID: 42, Command: "fake-db"}},
+			BlockingProcesses: []boshplatform.MountHolder{{PID: 43, Command: "fake-zombie"}},
+		}, nil)
+
+		result, err := unmountDiskAction.Run("vol-123", action.UnmountDiskOptions{Force: true, Lazy: true})
+		Expect(err).ToNot(HaveOccurred())
+		boshassert.MatchesJSONString(GinkgoT(), result, `{"message":"Lazily unmounted partition of {ID:vol-123 DeviceID: VolumeID:2 Lun:0 HostDeviceID:fake-host-device-id Path:/dev/sdf ISCSISettings:{InitiatorName:fake-initiator-name Username:fake-username Target:fake-target Password:fake-password} FileSystemType:ext4 MountOptions:[] Partitioner:}","lazy":true,"killed_processes":[{"pid":42,"command":"fake-db"}],"blocking_processes":[{"pid":43,"command":"fake-zombie"}]}`)
+
+		_, options := platform.UnmountPersistentDiskArgsForCall(0)
+		Expect(options).To(Equal(boshplatform.UnmountOptions{Force: true, Lazy: true}))
+	})
+
+	It("returns error when unmounting fails", func() {
+		platform.UnmountPersistentDiskReturns(boshplatform.UnmountReport{}, errors.New("fake-unmount-err"))
+
+		_, err := unmountDiskAction.Run("vol-123")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Unmounting persistent disk: fake-unmount-err"))
 	})
 
 	Context("error getting persistent disk settings", func() {
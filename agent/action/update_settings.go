@@ -66,6 +66,13 @@ func (a UpdateSettingsAction) Run(newUpdateSettings boshsettings.UpdateSettings)
 		return "", err
 	}
 
+	if newUpdateSettings.AccountPolicy != nil {
+		err = a.platform.SetAccountPolicy(*newUpdateSettings.AccountPolicy)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Applying account policy")
+		}
+	}
+
 	existingSettings := a.settingsService.GetSettings().UpdateSettings
 	restartNeeded = existingSettings.MergeSettings(newUpdateSettings)
 	err = a.settingsService.SaveUpdateSettings(existingSettings)
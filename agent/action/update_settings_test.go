@@ -91,6 +91,34 @@ var _ = Describe("UpdateSettings", func() {
 		})
 	})
 
+	Context("when an account policy is given", func() {
+		BeforeEach(func() {
+			newUpdateSettings.AccountPolicy = &boshsettings.AccountPolicy{LockoutThreshold: 5}
+		})
+
+		It("applies the policy to the platform", func() {
+			_, err := updateSettingsAction.Run(newUpdateSettings)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(platform.SetAccountPolicyCallCount()).To(Equal(1))
+			Expect(platform.SetAccountPolicyArgsForCall(0)).To(Equal(boshsettings.AccountPolicy{LockoutThreshold: 5}))
+		})
+
+		It("returns an error without saving the settings when applying the policy fails", func() {
+			platform.SetAccountPolicyReturns(errors.New("fake-policy-error"))
+
+			_, err := updateSettingsAction.Run(newUpdateSettings)
+			Expect(err).To(MatchError(ContainSubstring("fake-policy-error")))
+			Expect(settingsService.SaveUpdateSettingsCallCount).To(Equal(0))
+		})
+	})
+
+	It("does not touch the account policy when none is given", func() {
+		_, err := updateSettingsAction.Run(newUpdateSettings)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(platform.SetAccountPolicyCallCount()).To(Equal(0))
+	})
+
 	It("loads settings", func() {
 		_, err := updateSettingsAction.Run(newUpdateSettings)
 		Expect(err).ToNot(HaveOccurred())
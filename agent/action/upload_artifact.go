@@ -0,0 +1,47 @@
+package action
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/artifact"
+)
+
+// UploadArtifactRequest names a file in the data or store directory of Job.
+type UploadArtifactRequest struct {
+	Job  string `json:"job"`
+	Path string `json:"path"`
+}
+
+// UploadArtifactAction uploads a file a job produced, e.g. a backup, to the
+// blobstore. Jobs request the same through the agent's artifact socket.
+type UploadArtifactAction struct {
+	uploader artifact.Uploader
+}
+
+func NewUploadArtifact(uploader artifact.Uploader) UploadArtifactAction {
+	return UploadArtifactAction{uploader: uploader}
+}
+
+func (a UploadArtifactAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a UploadArtifactAction) IsPersistent() bool {
+	return false
+}
+
+func (a UploadArtifactAction) IsLoggable() bool {
+	return true
+}
+
+func (a UploadArtifactAction) Run(request UploadArtifactRequest) (artifact.Artifact, error) {
+	return a.uploader.Upload(request.Job, request.Path)
+}
+
+func (a UploadArtifactAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a UploadArtifactAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,51 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/artifact"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/artifact/artifactfakes"
+)
+
+var _ = Describe("UploadArtifact", func() {
+	var (
+		uploader             *artifactfakes.FakeUploader
+		uploadArtifactAction action.UploadArtifactAction
+	)
+
+	BeforeEach(func() {
+		uploader = &artifactfakes.FakeUploader{}
+		uploadArtifactAction = action.NewUploadArtifact(uploader)
+	})
+
+	AssertActionIsAsynchronous(uploadArtifactAction)
+	AssertActionIsNotPersistent(uploadArtifactAction)
+	AssertActionIsLoggable(uploadArtifactAction)
+
+	AssertActionIsNotCancelable(uploadArtifactAction)
+	AssertActionIsNotResumable(uploadArtifactAction)
+
+	It("uploads the artifact of the job", func() {
+		uploaded := artifact.Artifact{Job: "fake-job", Path: "/var/vcap/store/fake-job/backup.tgz", BlobstoreID: "fake-blob-id"}
+		uploader.UploadReturns(uploaded, nil)
+
+		result, err := uploadArtifactAction.Run(action.UploadArtifactRequest{Job: "fake-job", Path: "/var/vcap/store/fake-job/backup.tgz"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(uploaded))
+
+		job, path := uploader.UploadArgsForCall(0)
+		Expect(job).To(Equal("fake-job"))
+		Expect(path).To(Equal("/var/vcap/store/fake-job/backup.tgz"))
+	})
+
+	It("returns an error when uploading fails", func() {
+		uploader.UploadReturns(artifact.Artifact{}, errors.New("fake-upload-error"))
+
+		_, err := uploadArtifactAction.Run(action.UploadArtifactRequest{Job: "fake-job", Path: "/etc/shadow"})
+		Expect(err).To(MatchError("fake-upload-error"))
+	})
+})
@@ -0,0 +1,70 @@
+package action
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+)
+
+type VerifyBundlesResponse struct {
+	OK            bool                         `json:"ok"`
+	Discrepancies []boshappl.BundleDiscrepancy `json:"discrepancies"`
+}
+
+// VerifyBundlesAction checks that the job and package bundles of the applied
+// spec were not modified since they were installed.
+type VerifyBundlesAction struct {
+	bundleVerifier boshappl.BundleVerifier
+	specService    boshas.V1Service
+}
+
+func NewVerifyBundles(bundleVerifier boshappl.BundleVerifier, specService boshas.V1Service) VerifyBundlesAction {
+	return VerifyBundlesAction{
+		bundleVerifier: bundleVerifier,
+		specService:    specService,
+	}
+}
+
+func (a VerifyBundlesAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a VerifyBundlesAction) IsPersistent() bool {
+	return false
+}
+
+func (a VerifyBundlesAction) IsLoggable() bool {
+	return true
+}
+
+func (a VerifyBundlesAction) Run() (VerifyBundlesResponse, error) {
+	appliedSpec, err := a.specService.Get()
+	if err != nil {
+		return VerifyBundlesResponse{}, bosherr.WrapError(err, "Getting applied spec")
+	}
+
+	discrepancies, err := a.bundleVerifier.Verify(appliedSpec)
+	if err != nil {
+		return VerifyBundlesResponse{}, bosherr.WrapError(err, "Verifying bundles")
+	}
+
+	if discrepancies == nil {
+		discrepancies = []boshappl.BundleDiscrepancy{}
+	}
+
+	return VerifyBundlesResponse{
+		OK:            len(discrepancies) == 0,
+		Discrepancies: discrepancies,
+	}, nil
+}
+
+func (a VerifyBundlesAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a VerifyBundlesAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,75 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+)
+
+var _ = Describe("VerifyBundles", func() {
+	var (
+		bundleVerifier *fakeappl.FakeBundleVerifier
+		specService    *fakeas.FakeV1Service
+		verifyAction   action.VerifyBundlesAction
+	)
+
+	BeforeEach(func() {
+		bundleVerifier = &fakeappl.FakeBundleVerifier{}
+		specService = fakeas.NewFakeV1Service()
+		specService.Spec = boshas.V1ApplySpec{ConfigurationHash: "fake-configuration-hash"}
+		verifyAction = action.NewVerifyBundles(bundleVerifier, specService)
+	})
+
+	AssertActionIsAsynchronous(verifyAction)
+	AssertActionIsNotPersistent(verifyAction)
+	AssertActionIsLoggable(verifyAction)
+
+	AssertActionIsNotResumable(verifyAction)
+	AssertActionIsNotCancelable(verifyAction)
+
+	It("verifies the bundles of the applied spec", func() {
+		response, err := verifyAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response).To(Equal(action.VerifyBundlesResponse{
+			OK:            true,
+			Discrepancies: []boshappl.BundleDiscrepancy{},
+		}))
+
+		Expect(bundleVerifier.VerifyAppliedSpec).To(Equal(specService.Spec))
+	})
+
+	It("reports discrepancies", func() {
+		discrepancies := []boshappl.BundleDiscrepancy{
+			{Bundle: "job fake-job", Problems: []string{"modified: bin/run"}},
+		}
+		bundleVerifier.VerifyResult = discrepancies
+
+		response, err := verifyAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response).To(Equal(action.VerifyBundlesResponse{
+			OK:            false,
+			Discrepancies: discrepancies,
+		}))
+	})
+
+	It("returns error when getting the applied spec fails", func() {
+		specService.GetErr = errors.New("fake-get-error")
+
+		_, err := verifyAction.Run()
+		Expect(err).To(MatchError("Getting applied spec: fake-get-error"))
+	})
+
+	It("returns error when verifying fails", func() {
+		bundleVerifier.VerifyError = errors.New("fake-verify-error")
+
+		_, err := verifyAction.Run()
+		Expect(err).To(MatchError("Verifying bundles: fake-verify-error"))
+	})
+})
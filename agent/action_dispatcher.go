@@ -7,10 +7,40 @@ import (
 	boshaction "github.com/cloudfoundry/bosh-agent/v2/agent/action"
 	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
+	boshnotif "github.com/cloudfoundry/bosh-agent/v2/notification"
 )
 
 const actionDispatcherLogTag = "Action Dispatcher"
 
+// exclusiveActions change job state and must not run concurrently with each
+// other. A request for one of them is rejected while another is queued.
+var exclusiveActions = map[string]bool{
+	"apply":            true,
+	"apply_async":      true,
+	"drain":            true,
+	"finish_snapshot":  true,
+	"prepare_snapshot": true,
+	"rerun_job_script": true,
+	"revert_apply":     true,
+	"run_script":       true,
+	"start":            true,
+	"stop":             true,
+}
+
+// applyActions may wait for a previous apply instead of being rejected when
+// applies are queued. Only a single apply waits at a time.
+var applyActions = map[string]bool{
+	"apply":        true,
+	"apply_async":  true,
+	"revert_apply": true,
+}
+
+// callbackActions publish their outcome to the director once their task
+// ends so that it does not have to poll get_task.
+var callbackActions = map[string]bool{
+	"apply_async": true,
+}
+
 type ActionDispatcher interface {
 	ResumePreviouslyDispatchedTasks()
 	Dispatch(req boshhandler.Request) (resp boshhandler.Response)
@@ -22,6 +52,9 @@ type concreteActionDispatcher struct {
 	taskManager   boshtask.Manager
 	actionFactory boshaction.Factory
 	actionRunner  boshaction.Runner
+	notifier      boshnotif.Notifier
+	queueApplies  bool
+	idempotency   *idempotencyCache
 }
 
 func NewActionDispatcher(
@@ -30,6 +63,8 @@ func NewActionDispatcher(
 	taskManager boshtask.Manager,
 	actionFactory boshaction.Factory,
 	actionRunner boshaction.Runner,
+	notifier boshnotif.Notifier,
+	queueApplies bool,
 ) (dispatcher ActionDispatcher) {
 	return concreteActionDispatcher{
 		logger:        logger,
@@ -37,6 +72,9 @@ func NewActionDispatcher(
 		taskManager:   taskManager,
 		actionFactory: actionFactory,
 		actionRunner:  actionRunner,
+		notifier:      notifier,
+		queueApplies:  queueApplies,
+		idempotency:   newIdempotencyCache(),
 	}
 }
 
@@ -70,7 +108,9 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 			func(_ boshtask.Task) error { return action.Cancel() },
 			dispatcher.removeInfo,
 		)
+		task.Method = taskInfo.Method
 
+		dispatcher.idempotency.restore(taskInfo.IdempotencyKey, taskInfo.Method, taskID)
 		dispatcher.taskService.StartTask(task)
 	}
 }
@@ -87,21 +127,58 @@ func (dispatcher concreteActionDispatcher) Dispatch(req boshhandler.Request) bos
 		dispatcher.logger.DebugWithDetails(actionDispatcherLogTag, "Payload", req.Payload)
 	}
 
+	request, repeated := dispatcher.idempotency.begin(req.IdempotencyKey, req.Method)
+	if repeated {
+		return dispatcher.repeatResponse(request, req)
+	}
+
 	if action.IsAsynchronous(boshaction.ProtocolVersion(req.ProtocolVersion)) {
-		return dispatcher.dispatchAsynchronousAction(action, req)
+		resp, taskID := dispatcher.dispatchAsynchronousAction(action, req)
+		dispatcher.idempotency.finish(request, resp, taskID, taskID != "")
+		return resp
 	}
 
-	return dispatcher.dispatchSynchronousAction(action, req)
+	resp, ran := dispatcher.dispatchSynchronousAction(action, req)
+	dispatcher.idempotency.finish(request, resp, "", ran)
+	return resp
 }
 
+// repeatResponse responds to a retried request without running its action
+// again. Retries of asynchronous actions get the current state of the task.
+func (dispatcher concreteActionDispatcher) repeatResponse(request *idempotentRequest, req boshhandler.Request) boshhandler.Response {
+	if request.method != req.Method {
+		err := bosherr.Errorf("Idempotency key '%s' was already used for action %s", req.IdempotencyKey, request.method)
+		dispatcher.logger.Warn(actionDispatcherLogTag, "Rejecting action %s: %s", req.Method, err.Error())
+		return boshhandler.NewExceptionResponse(err)
+	}
+
+	dispatcher.logger.Info(actionDispatcherLogTag, "Repeating response to action %s with idempotency key %s", req.Method, req.IdempotencyKey)
+
+	<-request.done
+
+	if request.taskID != "" {
+		if task, found := dispatcher.taskService.FindTaskWithID(request.taskID); found {
+			return boshhandler.NewValueResponse(boshtask.StateValue{
+				AgentTaskID: task.ID,
+				State:       task.State,
+			})
+		}
+	}
+
+	return request.response
+}
+
+// dispatchAsynchronousAction returns the id of the started task, if any.
 func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	action boshaction.Action,
 	req boshhandler.Request,
-) boshhandler.Response {
+) (boshhandler.Response, string) {
 	dispatcher.logger.Info(actionDispatcherLogTag, "Running async action %s", req.Method)
 
-	var task boshtask.Task
-	var err error
+	var (
+		task boshtask.Task
+		err  error
+	)
 
 	runTask := func() (interface{}, error) {
 		return dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
@@ -109,61 +186,151 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 
 	cancelTask := func(_ boshtask.Task) error { return action.Cancel() }
 
+	var endTask boshtask.EndFunc
+	if callbackActions[req.Method] {
+		endTask = dispatcher.notifyCompleted
+	}
+
 	// Certain long-running tasks (e.g. configure_networks) must be resumed
 	// after agent restart so that API consumers do not need to know
 	// if agent is restarted midway through the task.
 	if action.IsPersistent() {
 		dispatcher.logger.Info(actionDispatcherLogTag, "Running persistent action %s", req.Method)
-		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, dispatcher.removeInfo)
+		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, func(task boshtask.Task) {
+			dispatcher.removeInfo(task)
+			if endTask != nil {
+				endTask(task)
+			}
+		})
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Create Task Failed %s", req.Method)
 			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-			return boshhandler.NewExceptionResponse(err)
+			return boshhandler.NewExceptionResponse(err), ""
 		}
 
 		taskInfo := boshtask.Info{
 			TaskID:  task.ID,
 			Method:  req.Method,
 			Payload: req.GetPayload(),
+
+			IdempotencyKey: req.IdempotencyKey,
 		}
 
 		err = dispatcher.taskManager.AddInfo(taskInfo)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Action Failed %s", req.Method)
 			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-			return boshhandler.NewExceptionResponse(err)
+			return boshhandler.NewExceptionResponse(err), ""
 		}
 	} else {
-		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, nil)
+		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, endTask)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Create Task Failed %s", req.Method)
 			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-			return boshhandler.NewExceptionResponse(err)
+			return boshhandler.NewExceptionResponse(err), ""
 		}
 	}
 
-	dispatcher.taskService.StartTask(task)
+	task.Method = req.Method
+
+	// Checked when the task is queued so that concurrent requests cannot
+	// both pass the check before either task is queued
+	var waitingFor string
+
+	err = dispatcher.taskService.StartTaskIf(task, func(queued []boshtask.Task) error {
+		var checkErr error
+		waitingFor, checkErr = dispatcher.checkNotBusy(req.Method, queued)
+		return checkErr
+	})
+	if err != nil {
+		dispatcher.logger.Warn(actionDispatcherLogTag, "Rejecting action %s: %s", req.Method, err.Error())
+		if action.IsPersistent() {
+			dispatcher.removeInfo(task)
+		}
+		return boshhandler.NewExceptionResponse(err), ""
+	}
+
+	if waitingFor != "" {
+		dispatcher.logger.Info(actionDispatcherLogTag, "Queued action %s behind apply task %s", req.Method, waitingFor)
+	}
 
 	return boshhandler.NewValueResponse(boshtask.StateValue{
 		AgentTaskID: task.ID,
 		State:       task.State,
-	})
+		WaitingFor:  waitingFor,
+	}), task.ID
 }
 
+// dispatchSynchronousAction returns whether the action ran.
 func (dispatcher concreteActionDispatcher) dispatchSynchronousAction(
 	action boshaction.Action,
 	req boshhandler.Request,
-) boshhandler.Response {
+) (boshhandler.Response, bool) {
 	dispatcher.logger.Info(actionDispatcherLogTag, "Running sync action %s", req.Method)
 
+	// Synchronous actions cannot wait in the task queue
+	if _, err := dispatcher.checkNotBusy(req.Method, dispatcher.taskService.QueuedTasks()); err != nil {
+		dispatcher.logger.Warn(actionDispatcherLogTag, "Rejecting action %s: %s", req.Method, err.Error())
+		return boshhandler.NewExceptionResponse(err), false
+	}
+
 	value, err := dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
 	if err != nil {
 		err = bosherr.WrapErrorf(err, "Action Failed %s", req.Method)
 		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-		return boshhandler.NewExceptionResponse(err)
+		return boshhandler.NewExceptionResponse(err), true
+	}
+
+	return boshhandler.NewValueResponse(value), true
+}
+
+// checkNotBusy rejects exclusive actions while another one is queued. When
+// applies are queued an apply may wait for a single previous apply, whose
+// task id is returned.
+func (dispatcher concreteActionDispatcher) checkNotBusy(method string, queued []boshtask.Task) (string, error) {
+	if !exclusiveActions[method] {
+		return "", nil
+	}
+
+	var waitingFor string
+
+	for i, task := range queued {
+		if !exclusiveActions[task.Method] {
+			continue
+		}
+
+		if dispatcher.queueApplies && applyActions[method] && applyActions[task.Method] && waitingFor == "" {
+			waitingFor = task.ID
+			continue
+		}
+
+		return "", boshhandler.BusyError{
+			TaskID:   task.ID,
+			Method:   task.Method,
+			Position: i + 1,
+		}
+	}
+
+	return waitingFor, nil
+}
+
+func (dispatcher concreteActionDispatcher) notifyCompleted(task boshtask.Task) {
+	completion := boshnotif.TaskCompletion{
+		AgentTaskID: task.ID,
+		Method:      task.Method,
+		State:       string(task.State),
+		Value:       task.Value,
 	}
 
-	return boshhandler.NewValueResponse(value)
+	if task.Error != nil {
+		completion.Exception = task.Error.Error()
+	}
+
+	err := dispatcher.notifier.NotifyApplyCompleted(completion)
+	if err != nil {
+		// The director can still find out how the task ended through get_task
+		dispatcher.logger.Error(actionDispatcherLogTag, "Notifying completion of task %s: %s", task.ID, err.Error())
+	}
 }
 
 func (dispatcher concreteActionDispatcher) removeInfo(task boshtask.Task) {
@@ -17,6 +17,8 @@ import (
 	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
+	boshnotif "github.com/cloudfoundry/bosh-agent/v2/notification"
+	fakenotif "github.com/cloudfoundry/bosh-agent/v2/notification/fakes"
 )
 
 func init() { //nolint:funlen,gochecknoinits
@@ -27,6 +29,7 @@ func init() { //nolint:funlen,gochecknoinits
 			taskManager   *faketask.FakeManager
 			actionFactory *fakeaction.FakeFactory
 			actionRunner  *fakeaction.FakeRunner
+			notifier      *fakenotif.FakeNotifier
 			dispatcher    agent.ActionDispatcher
 		)
 
@@ -36,7 +39,8 @@ func init() { //nolint:funlen,gochecknoinits
 			taskManager = faketask.NewFakeManager()
 			actionFactory = fakeaction.NewFakeFactory()
 			actionRunner = &fakeaction.FakeRunner{}
-			dispatcher = agent.NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner)
+			notifier = fakenotif.NewFakeNotifier()
+			dispatcher = agent.NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner, notifier, false)
 		})
 
 		It("responds with exception when the method is unknown", func() {
@@ -177,6 +181,17 @@ func init() { //nolint:funlen,gochecknoinits
 				expectedJSON := fmt.Sprintf("{\"exception\":{\"message\":\"Action Failed %s: fake-run-error\"}}", req.Method)
 				boshassert.MatchesJSONString(GinkgoT(), resp, expectedJSON)
 			})
+
+			It("responds busy without running an action that changes job state while a conflicting task is queued", func() {
+				req = boshhandler.NewRequest("fake-reply", "start", []byte("fake-payload"), 0)
+				actionFactory.RegisterAction("start", &fakeaction.TestAction{Asynchronous: false})
+				taskService.QueuedTasksResult = []boshtask.Task{{ID: "fake-apply-task", Method: "apply"}}
+
+				resp := dispatcher.Dispatch(req)
+				boshassert.MatchesJSONString(GinkgoT(), resp,
+					`{"exception":{"message":"busy: task fake-apply-task in progress, position 1","busy":{"agent_task_id":"fake-apply-task","method":"apply","position":1}}}`)
+				Expect(actionRunner.RunPayload).To(BeNil())
+			})
 		})
 
 		Context("when action is asynchronous", func() {
@@ -211,6 +226,152 @@ func init() { //nolint:funlen,gochecknoinits
 				})
 			}
 
+			It("records the method on the started task", func() {
+				dispatcher.Dispatch(req)
+				Expect(taskService.StartedTasks["fake-generated-task-id"].Method).To(Equal("fake-action"))
+			})
+
+			Context("when the action changes job state", func() {
+				BeforeEach(func() {
+					req = boshhandler.NewRequest("fake-reply", "apply", []byte("fake-payload"), 0)
+					actionFactory.RegisterAction("apply", action)
+				})
+
+				It("responds busy with the blocking task when a conflicting task is queued", func() {
+					taskService.QueuedTasksResult = []boshtask.Task{
+						{ID: "fake-get-state-task", Method: "get_state"},
+						{ID: "fake-run-script-task", Method: "run_script"},
+					}
+
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"exception":{"message":"busy: task fake-run-script-task in progress, position 2","busy":{"agent_task_id":"fake-run-script-task","method":"run_script","position":2}}}`)
+					Expect(taskService.StartedTasks).To(BeEmpty())
+				})
+
+				It("responds busy when a previous apply is queued", func() {
+					taskService.QueuedTasksResult = []boshtask.Task{{ID: "fake-apply-task", Method: "apply"}}
+
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks).To(BeEmpty())
+				})
+
+				Context("when applies are queued", func() {
+					BeforeEach(func() {
+						dispatcher = agent.NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner, notifier, true)
+					})
+
+					It("queues the apply behind a previous apply and reports what it is waiting for", func() {
+						taskService.QueuedTasksResult = []boshtask.Task{
+							{ID: "fake-compile-task", Method: "compile_package"},
+							{ID: "fake-apply-task", Method: "revert_apply"},
+						}
+
+						resp := dispatcher.Dispatch(req)
+						boshassert.MatchesJSONString(GinkgoT(), resp,
+							`{"value":{"agent_task_id":"fake-generated-task-id","state":"running","waiting_for":"fake-apply-task"}}`)
+						Expect(taskService.StartedTasks).To(HaveKey("fake-generated-task-id"))
+					})
+
+					It("responds busy when another apply is waiting already", func() {
+						taskService.QueuedTasksResult = []boshtask.Task{
+							{ID: "fake-apply-task", Method: "apply"},
+							{ID: "fake-waiting-apply-task", Method: "apply_async"},
+						}
+
+						resp := dispatcher.Dispatch(req)
+						boshassert.MatchesJSONString(GinkgoT(), resp,
+							`{"exception":{"message":"busy: task fake-waiting-apply-task in progress, position 2","busy":{"agent_task_id":"fake-waiting-apply-task","method":"apply_async","position":2}}}`)
+						Expect(taskService.StartedTasks).To(BeEmpty())
+					})
+
+					It("responds busy when a conflicting task other than an apply is queued", func() {
+						taskService.QueuedTasksResult = []boshtask.Task{{ID: "fake-stop-task", Method: "stop"}}
+
+						dispatcher.Dispatch(req)
+						Expect(taskService.StartedTasks).To(BeEmpty())
+					})
+				})
+
+				It("starts the task when only non-conflicting tasks are queued", func() {
+					taskService.QueuedTasksResult = []boshtask.Task{
+						{ID: "fake-compile-task", Method: "compile_package"},
+					}
+
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks).To(HaveKey("fake-generated-task-id"))
+				})
+			})
+
+			Context("when the action calls back on completion", func() {
+				BeforeEach(func() {
+					req = boshhandler.NewRequest("fake-reply", "apply_async", []byte("fake-payload"), 0)
+					actionFactory.RegisterAction("apply_async", action)
+				})
+
+				It("acks with the task id right away", func() {
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"value":{"agent_task_id":"fake-generated-task-id","state":"running"}}`)
+					Expect(notifier.NotifiedApplyCompletions).To(BeEmpty())
+				})
+
+				It("notifies the director when the task succeeds", func() {
+					dispatcher.Dispatch(req)
+
+					task := taskService.StartedTasks["fake-generated-task-id"]
+					task.State = boshtask.StateDone
+					task.Value = "applied"
+					task.EndFunc(task)
+
+					Expect(notifier.NotifiedApplyCompletions).To(Equal([]boshnotif.TaskCompletion{{
+						AgentTaskID: "fake-generated-task-id",
+						Method:      "apply_async",
+						State:       "done",
+						Value:       "applied",
+					}}))
+				})
+
+				It("notifies the director when the task fails", func() {
+					dispatcher.Dispatch(req)
+
+					task := taskService.StartedTasks["fake-generated-task-id"]
+					task.State = boshtask.StateFailed
+					task.Error = errors.New("fake-apply-error")
+					task.EndFunc(task)
+
+					Expect(notifier.NotifiedApplyCompletions).To(HaveLen(1))
+					Expect(notifier.NotifiedApplyCompletions[0].State).To(Equal("failed"))
+					Expect(notifier.NotifiedApplyCompletions[0].Exception).To(Equal("fake-apply-error"))
+				})
+
+				It("logs when the director cannot be notified", func() {
+					notifier.NotifyApplyCompletedErr = errors.New("fake-send-error")
+					dispatcher.Dispatch(req)
+
+					task := taskService.StartedTasks["fake-generated-task-id"]
+					task.EndFunc(task)
+
+					Expect(logger.ErrorCallCount()).To(Equal(1))
+				})
+
+				It("conflicts with other actions that change job state", func() {
+					taskService.QueuedTasksResult = []boshtask.Task{{ID: "fake-apply-task", Method: "apply"}}
+
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks).To(BeEmpty())
+				})
+			})
+
+			It("does not check for conflicts for actions that do not change job state", func() {
+				taskService.QueuedTasksResult = []boshtask.Task{
+					{ID: "fake-apply-task", Method: "apply"},
+				}
+
+				dispatcher.Dispatch(req)
+				Expect(taskService.StartedTasks).To(HaveKey("fake-generated-task-id"))
+			})
+
 			Context("when action is not persistent", func() {
 				BeforeEach(func() {
 					action.Persistent = false
@@ -300,6 +461,19 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(string(respJSON)).To(ContainSubstring("fake-create-task-error"))
 				})
 
+				It("forgets the task when it is rejected as busy", func() {
+					req = boshhandler.NewRequest("fake-reply", "apply", []byte("fake-payload"), 0)
+					actionFactory.RegisterAction("apply", action)
+					taskService.QueuedTasksResult = []boshtask.Task{{ID: "fake-apply-task", Method: "apply"}}
+
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks).To(BeEmpty())
+
+					infos, err := taskManager.GetInfos()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(infos).To(BeEmpty())
+				})
+
 				It("return run value to the task", func() {
 					actionRunner.RunValue = "fake-value"
 					dispatcher.Dispatch(req)
@@ -359,6 +533,134 @@ func init() { //nolint:funlen,gochecknoinits
 			})
 		})
 
+		Context("when the request has an idempotency key", func() {
+			var req boshhandler.Request
+
+			BeforeEach(func() {
+				req = boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0)
+				req.IdempotencyKey = "fake-idempotency-key"
+			})
+
+			Context("when action is synchronous", func() {
+				BeforeEach(func() {
+					actionFactory.RegisterAction("fake-action", &fakeaction.TestAction{Asynchronous: false})
+				})
+
+				It("responds to retries with the original result without running the action again", func() {
+					actionRunner.RunValue = "fake-value"
+					Expect(dispatcher.Dispatch(req)).To(Equal(boshhandler.NewValueResponse("fake-value")))
+
+					actionRunner.RunPayload = nil
+					actionRunner.RunValue = "fake-other-value"
+					Expect(dispatcher.Dispatch(req)).To(Equal(boshhandler.NewValueResponse("fake-value")))
+					Expect(actionRunner.RunPayload).To(BeNil())
+				})
+
+				It("responds to retries with the original error without running the action again", func() {
+					actionRunner.RunErr = errors.New("fake-run-error")
+					dispatcher.Dispatch(req)
+
+					actionRunner.RunPayload = nil
+					actionRunner.RunErr = nil
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"exception":{"message":"Action Failed fake-action: fake-run-error"}}`)
+					Expect(actionRunner.RunPayload).To(BeNil())
+				})
+
+				It("runs the action for requests with other idempotency keys", func() {
+					dispatcher.Dispatch(req)
+
+					actionRunner.RunPayload = nil
+					req.IdempotencyKey = "fake-other-idempotency-key"
+					dispatcher.Dispatch(req)
+					Expect(actionRunner.RunPayload).To(Equal([]byte("fake-payload")))
+				})
+
+				It("runs the action on retry when the original request was rejected as busy", func() {
+					req = boshhandler.NewRequest("fake-reply", "start", []byte("fake-payload"), 0)
+					req.IdempotencyKey = "fake-idempotency-key"
+					actionFactory.RegisterAction("start", &fakeaction.TestAction{Asynchronous: false})
+					taskService.QueuedTasksResult = []boshtask.Task{{ID: "fake-apply-task", Method: "apply"}}
+					dispatcher.Dispatch(req)
+					Expect(actionRunner.RunPayload).To(BeNil())
+
+					taskService.QueuedTasksResult = nil
+					actionRunner.RunValue = "fake-value"
+					Expect(dispatcher.Dispatch(req)).To(Equal(boshhandler.NewValueResponse("fake-value")))
+					Expect(actionRunner.RunPayload).To(Equal([]byte("fake-payload")))
+				})
+
+				It("responds with exception when the idempotency key was used for another action", func() {
+					dispatcher.Dispatch(req)
+
+					actionRunner.RunPayload = nil
+					req.Method = "fake-other-action"
+					actionFactory.RegisterAction("fake-other-action", &fakeaction.TestAction{Asynchronous: false})
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"exception":{"message":"Idempotency key 'fake-idempotency-key' was already used for action fake-action"}}`)
+					Expect(actionRunner.RunPayload).To(BeNil())
+				})
+			})
+
+			Context("when action is asynchronous", func() {
+				BeforeEach(func() {
+					actionFactory.RegisterAction("fake-action", &fakeaction.TestAction{Asynchronous: true, Persistent: true})
+				})
+
+				It("responds to retries with the current state of the original task without starting another", func() {
+					dispatcher.Dispatch(req)
+
+					task := taskService.StartedTasks["fake-generated-task-id"]
+					task.State = boshtask.StateDone
+					taskService.StartedTasks["fake-generated-task-id"] = task
+					taskService.CreateTaskErr = errors.New("fake-create-task-error")
+
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"value":{"agent_task_id":"fake-generated-task-id","state":"done"}}`)
+				})
+
+				It("responds to retries with the original response when the task is gone", func() {
+					dispatcher.Dispatch(req)
+					delete(taskService.StartedTasks, "fake-generated-task-id")
+
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"value":{"agent_task_id":"fake-generated-task-id","state":"running"}}`)
+					Expect(taskService.StartedTasks).To(BeEmpty())
+				})
+
+				It("records the idempotency key with persistent tasks", func() {
+					dispatcher.Dispatch(req)
+
+					taskInfos, err := taskManager.GetInfos()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(taskInfos).To(Equal([]boshtask.Info{
+						{
+							TaskID:         "fake-generated-task-id",
+							Method:         "fake-action",
+							Payload:        []byte("fake-payload"),
+							IdempotencyKey: "fake-idempotency-key",
+						},
+					}))
+				})
+
+				It("starts a task on retry when the original task could not be created", func() {
+					taskService.CreateTaskErr = errors.New("fake-create-task-error")
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks).To(BeEmpty())
+
+					taskService.CreateTaskErr = nil
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"value":{"agent_task_id":"fake-generated-task-id","state":"running"}}`)
+					Expect(taskService.StartedTasks).To(HaveLen(1))
+				})
+			})
+		})
+
 		Describe("ResumePreviouslyDispatchedTasks", func() {
 			var firstAction, secondAction *fakeaction.TestAction
 
@@ -511,6 +813,30 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-cancel-err-2"))
 			})
+
+			It("responds to retries of resumed tasks with their state without starting another task", func() {
+				err := taskManager.AddInfo(boshtask.Info{
+					TaskID:         "fake-task-id-3",
+					Method:         "fake-action-1",
+					Payload:        []byte("fake-task-payload-3"),
+					IdempotencyKey: "fake-idempotency-key",
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				actionFactory.RegisterAction("fake-action-1", &fakeaction.TestAction{Asynchronous: true})
+				actionFactory.RegisterAction("fake-action-2", secondAction)
+
+				dispatcher.ResumePreviouslyDispatchedTasks()
+				Expect(len(taskService.StartedTasks)).To(Equal(3))
+
+				req := boshhandler.NewRequest("fake-reply", "fake-action-1", []byte("fake-task-payload-3"), 0)
+				req.IdempotencyKey = "fake-idempotency-key"
+
+				resp := dispatcher.Dispatch(req)
+				boshassert.MatchesJSONString(GinkgoT(), resp,
+					`{"value":{"agent_task_id":"fake-task-id-3","state":"running"}}`)
+				Expect(len(taskService.StartedTasks)).To(Equal(3))
+			})
 		})
 	})
 }
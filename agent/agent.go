@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/clock"
@@ -11,9 +13,14 @@ import (
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
 	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	boshapplier "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/coredump"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/sshusers"
 	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
+	boshmbus "github.com/cloudfoundry/bosh-agent/v2/mbus"
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 )
@@ -21,6 +28,9 @@ import (
 const (
 	agentLogTag         = "agent"
 	heartbeatMaxRetries = 60
+
+	sshUsersExpiryInterval = time.Minute
+	coreDumpsPruneInterval = time.Minute
 )
 
 var (
@@ -35,6 +45,17 @@ type StartManager interface {
 	RegisterStart() error
 }
 
+// BlobTransferMetrics are reported with every heartbeat.
+type BlobTransferMetrics interface {
+	Snapshot() httpblobprovider.TransferMetricsSnapshot
+}
+
+// MbusConnectionMetrics are reported with every heartbeat when the mbus
+// handler keeps a connection, e.g. to NATS.
+type MbusConnectionMetrics interface {
+	ConnectionMetrics() boshmbus.ConnectionMetricsSnapshot
+}
+
 type Agent struct {
 	logger            boshlog.Logger
 	mbusHandler       boshhandler.Handler
@@ -47,6 +68,10 @@ type Agent struct {
 	uuidGenerator     boshuuid.Generator
 	timeService       clock.Clock
 	startManager      StartManager
+	transferMetrics   BlobTransferMetrics
+	bundleVerifier    boshapplier.BundleVerifier
+	sshUsers          sshusers.Tracker
+	coreDumps         coredump.Collector
 }
 
 func New(
@@ -61,6 +86,10 @@ func New(
 	uuidGenerator boshuuid.Generator,
 	timeService clock.Clock,
 	startManager StartManager,
+	transferMetrics BlobTransferMetrics,
+	bundleVerifier boshapplier.BundleVerifier,
+	sshUsers sshusers.Tracker,
+	coreDumps coredump.Collector,
 ) Agent {
 	return Agent{
 		logger:            logger,
@@ -74,6 +103,10 @@ func New(
 		uuidGenerator:     uuidGenerator,
 		timeService:       timeService,
 		startManager:      startManager,
+		transferMetrics:   transferMetrics,
+		bundleVerifier:    bundleVerifier,
+		sshUsers:          sshUsers,
+		coreDumps:         coreDumps,
 	}
 }
 
@@ -93,6 +126,18 @@ func (a Agent) Run() error {
 
 	go a.generateHeartbeats(errCh)
 
+	if a.bundleVerifier != nil {
+		go a.verifyBundles(errCh)
+	}
+
+	if a.sshUsers != nil {
+		go a.expireSSHUsers()
+	}
+
+	if a.coreDumps != nil {
+		go a.pruneCoreDumps()
+	}
+
 	go func() {
 		err := a.jobSupervisor.MonitorJobFailures(a.handleJobFailure(errCh))
 		if err != nil {
@@ -192,9 +237,103 @@ func (a Agent) getHeartbeat(status string) (Heartbeat, error) {
 		NodeID:     spec.NodeID,
 	}
 
+	if a.transferMetrics != nil {
+		transfers := a.transferMetrics.Snapshot()
+		hb.BlobTransfers = &transfers
+	}
+
+	if connectionMetrics, ok := a.mbusHandler.(MbusConnectionMetrics); ok {
+		mbus := connectionMetrics.ConnectionMetrics()
+		hb.Mbus = &mbus
+	}
+
 	return hb, nil
 }
 
+// verifyBundles alerts the health monitor when the installed bundles of the
+// applied spec were modified, e.g. while the agent was not running
+func (a Agent) verifyBundles(errCh chan error) {
+	defer a.logger.HandlePanic("Agent Verify Bundles")
+
+	spec, err := a.specService.Get()
+	if err != nil {
+		a.logger.Error(agentLogTag, "Getting spec to verify bundles: %s", err.Error())
+		return
+	}
+
+	discrepancies, err := a.bundleVerifier.Verify(spec)
+	if err != nil {
+		a.logger.Error(agentLogTag, "Verifying bundles: %s", err.Error())
+		return
+	}
+
+	if len(discrepancies) == 0 {
+		return
+	}
+
+	summaries := make([]string, 0, len(discrepancies))
+	for _, discrepancy := range discrepancies {
+		summary := fmt.Sprintf("%s (%s)", discrepancy.Bundle, strings.Join(discrepancy.Problems, ", "))
+		a.logger.Error(agentLogTag, "Bundle was modified after installation: %s", summary)
+		summaries = append(summaries, summary)
+	}
+
+	alertID, err := a.uuidGenerator.Generate()
+	if err != nil {
+		errCh <- bosherr.WrapError(err, "Generating bundle verification alert id")
+		return
+	}
+
+	alert := boshalert.Alert{
+		ID:        alertID,
+		Severity:  boshalert.SeverityCritical,
+		Title:     "Installed bundles were modified",
+		Summary:   strings.Join(summaries, "; "),
+		CreatedAt: a.timeService.Now().Unix(),
+	}
+
+	err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+	if err != nil {
+		errCh <- bosherr.WrapError(err, "Sending bundle verification alert")
+	}
+}
+
+// expireSSHUsers removes the users created for bosh ssh once they expire,
+// including users which expired while the agent was not running
+func (a Agent) expireSSHUsers() {
+	defer a.logger.HandlePanic("Agent Expire SSH Users")
+
+	ticker := a.timeService.NewTicker(sshUsersExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		err := a.sshUsers.RemoveExpired()
+		if err != nil {
+			a.logger.Error(agentLogTag, "Removing expired ssh users: %s", err.Error())
+		}
+
+		<-ticker.C()
+	}
+}
+
+// pruneCoreDumps keeps the core dumps the kernel writes to the core dumps
+// directory within the configured size
+func (a Agent) pruneCoreDumps() {
+	defer a.logger.HandlePanic("Agent Prune Core Dumps")
+
+	ticker := a.timeService.NewTicker(coreDumpsPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		err := a.coreDumps.Prune(a.settingsService.GetSettings().Env.GetCoreDumpsMaxSizeInBytes())
+		if err != nil {
+			a.logger.Error(agentLogTag, "Pruning core dumps: %s", err.Error())
+		}
+
+		<-ticker.C()
+	}
+}
+
 func (a Agent) handleJobFailure(errCh chan error) boshjobsuper.JobFailureHandler {
 	return func(monitAlert boshalert.MonitAlert) error {
 		alertAdapter := boshalert.NewMonitAdapter(monitAlert, a.settingsService, a.timeService)
@@ -14,11 +14,17 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/agentfakes"
 	boshalert "github.com/cloudfoundry/bosh-agent/v2/agent/alert"
+	boshapplier "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/coredump/coredumpfakes"
 	fakeagent "github.com/cloudfoundry/bosh-agent/v2/agent/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/sshusers/sshusersfakes"
 	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
+	boshmbus "github.com/cloudfoundry/bosh-agent/v2/mbus"
 	fakembus "github.com/cloudfoundry/bosh-agent/v2/mbus/fakes"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
 	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
@@ -72,6 +78,10 @@ func init() { //nolint:funlen,gochecknoinits
 				uuidGenerator,
 				timeService,
 				startManager,
+				nil,
+				nil,
+				nil,
+				nil,
 			)
 		})
 
@@ -158,6 +168,10 @@ func init() { //nolint:funlen,gochecknoinits
 						uuidGenerator,
 						timeService,
 						startManager,
+						nil,
+						nil,
+						nil,
+						nil,
 					)
 
 					// Immediately exit after sending initial heartbeat
@@ -178,6 +192,75 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(jobSupervisor.GetHealthRecorded()).To(Equal(1))
 				})
 
+				It("reports blob transfer metrics in heartbeats", func() {
+					metrics := httpblobprovider.NewTransferMetrics(0, logger)
+					metrics.RecordTransfer(httpblobprovider.TransferDownload, "https://blobs.example.com/blob", 2048, time.Second, nil)
+
+					boshAgent = agent.New(
+						logger,
+						handler,
+						platform,
+						actionDispatcher,
+						jobSupervisor,
+						specService,
+						5*time.Hour,
+						settingsService,
+						uuidGenerator,
+						timeService,
+						startManager,
+						metrics,
+						nil,
+						nil,
+						nil,
+					)
+
+					handler.SendErr = errors.New("stop")
+
+					err := boshAgent.Run()
+					Expect(err).To(HaveOccurred())
+
+					heartbeat := handler.SendInputs()[0].Message.(agent.Heartbeat)
+					Expect(heartbeat.BlobTransfers).To(Equal(&httpblobprovider.TransferMetricsSnapshot{
+						Download: httpblobprovider.TransferStats{
+							Transfers:          1,
+							Bytes:              2048,
+							DurationMS:         1000,
+							LastBytesPerSecond: 2048,
+						},
+					}))
+				})
+
+				It("reports mbus connection metrics in heartbeats", func() {
+					boshAgent = agent.New(
+						logger,
+						connectionMetricsHandler{
+							FakeHandler: handler,
+							metrics:     boshmbus.ConnectionMetricsSnapshot{Disconnects: 2, Reconnects: 1, ReconnectAttempts: 3},
+						},
+						platform,
+						actionDispatcher,
+						jobSupervisor,
+						specService,
+						5*time.Hour,
+						settingsService,
+						uuidGenerator,
+						timeService,
+						startManager,
+						nil,
+						nil,
+						nil,
+						nil,
+					)
+
+					handler.SendErr = errors.New("stop")
+
+					err := boshAgent.Run()
+					Expect(err).To(HaveOccurred())
+
+					heartbeat := handler.SendInputs()[0].Message.(agent.Heartbeat)
+					Expect(heartbeat.Mbus).To(Equal(&boshmbus.ConnectionMetricsSnapshot{Disconnects: 2, Reconnects: 1, ReconnectAttempts: 3}))
+				})
+
 				It("sends periodic heartbeats, with retry", func() {
 					sentRequests := 0
 					handler.SendCallback = func(_ fakembus.SendInput) {
@@ -285,6 +368,165 @@ func init() { //nolint:funlen,gochecknoinits
 					Message: expectedAlert,
 				}))
 			})
+
+			Context("when bundles are verified on start", func() {
+				var bundleVerifier *fakeappl.FakeBundleVerifier
+
+				BeforeEach(func() {
+					handler.KeepOnRunning()
+
+					bundleVerifier = &fakeappl.FakeBundleVerifier{}
+					uuidGenerator.GeneratedUUID = "fake-alert-id"
+
+					boshAgent = agent.New(
+						logger,
+						handler,
+						platform,
+						actionDispatcher,
+						jobSupervisor,
+						specService,
+						5*time.Hour,
+						settingsService,
+						uuidGenerator,
+						timeService,
+						startManager,
+						nil,
+						bundleVerifier,
+						nil,
+						nil,
+					)
+				})
+
+				It("sends an alert about modified bundles to health manager", func() {
+					bundleVerifier.VerifyResult = []boshapplier.BundleDiscrepancy{
+						{Bundle: "job fake-job", Problems: []string{"modified: bin/run", "unexpected: bin/backdoor"}},
+						{Bundle: "package fake-package", Problems: []string{"missing: lib/lib.so"}},
+					}
+
+					handler.SendCallback = func(input fakembus.SendInput) {
+						if input.Topic == boshhandler.Alert {
+							handler.SendErr = errors.New("stop")
+						}
+					}
+
+					err := boshAgent.Run()
+					Expect(err).To(MatchError(ContainSubstring("stop")))
+
+					Expect(bundleVerifier.VerifyAppliedSpec).To(Equal(specService.Spec))
+					Expect(handler.SendInputs()).To(ContainElement(fakembus.SendInput{
+						Target: boshhandler.HealthMonitor,
+						Topic:  boshhandler.Alert,
+						Message: boshalert.Alert{
+							ID:        "fake-alert-id",
+							Severity:  boshalert.SeverityCritical,
+							Title:     "Installed bundles were modified",
+							Summary:   "job fake-job (modified: bin/run, unexpected: bin/backdoor); package fake-package (missing: lib/lib.so)",
+							CreatedAt: timeService.Now().Unix(),
+						},
+					}))
+				})
+			})
+
+			Context("when ssh users are tracked", func() {
+				var sshUsers *sshusersfakes.FakeTracker
+
+				BeforeEach(func() {
+					sshUsers = &sshusersfakes.FakeTracker{}
+
+					boshAgent = agent.New(
+						logger,
+						handler,
+						platform,
+						actionDispatcher,
+						jobSupervisor,
+						specService,
+						5*time.Hour,
+						settingsService,
+						uuidGenerator,
+						timeService,
+						startManager,
+						nil,
+						nil,
+						sshUsers,
+						nil,
+					)
+				})
+
+				It("removes expired ssh users on start and then every minute", func() {
+					stop := make(chan struct{})
+					handler.RunCallBack = func() { <-stop }
+					handler.RunErr = errors.New("stop")
+					sshUsers.RemoveExpiredReturns(errors.New("fake-remove-err"))
+
+					runErr := make(chan error, 1)
+					go func() { runErr <- boshAgent.Run() }()
+
+					Eventually(sshUsers.RemoveExpiredCallCount).Should(Equal(1))
+
+					timeService.WaitForWatcherAndIncrement(time.Minute)
+					Eventually(sshUsers.RemoveExpiredCallCount).Should(Equal(2))
+
+					close(stop)
+					Eventually(runErr).Should(Receive(MatchError(ContainSubstring("stop"))))
+				})
+			})
+
+			Context("when core dumps are enabled", func() {
+				var coreDumps *coredumpfakes.FakeCollector
+
+				BeforeEach(func() {
+					coreDumps = &coredumpfakes.FakeCollector{}
+					maxSize := uint64(64)
+					settingsService.Settings.Env.Bosh.Agent.Settings.CoreDumps.MaxSizeInMB = &maxSize
+
+					boshAgent = agent.New(
+						logger,
+						handler,
+						platform,
+						actionDispatcher,
+						jobSupervisor,
+						specService,
+						5*time.Hour,
+						settingsService,
+						uuidGenerator,
+						timeService,
+						startManager,
+						nil,
+						nil,
+						nil,
+						coreDumps,
+					)
+				})
+
+				It("prunes core dumps to the configured size on start and then every minute", func() {
+					stop := make(chan struct{})
+					handler.RunCallBack = func() { <-stop }
+					handler.RunErr = errors.New("stop")
+					coreDumps.PruneReturns(errors.New("fake-prune-err"))
+
+					runErr := make(chan error, 1)
+					go func() { runErr <- boshAgent.Run() }()
+
+					Eventually(coreDumps.PruneCallCount).Should(Equal(1))
+					Expect(coreDumps.PruneArgsForCall(0)).To(Equal(uint64(64 * 1024 * 1024)))
+
+					timeService.WaitForWatcherAndIncrement(time.Minute)
+					Eventually(coreDumps.PruneCallCount).Should(Equal(2))
+
+					close(stop)
+					Eventually(runErr).Should(Receive(MatchError(ContainSubstring("stop"))))
+				})
+			})
 		})
 	})
 }
+
+// connectionMetricsHandler is a handler that keeps a connection to the mbus
+type connectionMetricsHandler struct {
+	*fakembus.FakeHandler
+	metrics boshmbus.ConnectionMetricsSnapshot
+}
+
+func (h connectionMetricsHandler) ConnectionMetrics() boshmbus.ConnectionMetricsSnapshot {
+	return h.metrics
+}
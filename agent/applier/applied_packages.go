@@ -0,0 +1,77 @@
+package applier
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+const appliedPackagesFileName = "applied_packages.json"
+
+// appliedPackages stores the fingerprints of the packages of the last
+// successful apply, i.e. their versions and blob digests, so that the first
+// apply after the agent restarts does not apply unchanged packages again.
+// The fingerprints are forgotten as soon as an apply starts since a failed
+// apply may leave the packages in between.
+type appliedPackages struct {
+	fs   boshsys.FileSystem
+	path string
+}
+
+func newAppliedPackages(fs boshsys.FileSystem, boshDir string) appliedPackages {
+	return appliedPackages{fs: fs, path: filepath.Join(boshDir, appliedPackagesFileName)}
+}
+
+// Fingerprints returns nothing when none are stored or they cannot be read,
+// the packages are applied again then
+func (p appliedPackages) Fingerprints() map[string]string {
+	if !p.fs.FileExists(p.path) {
+		return nil
+	}
+
+	contents, err := p.fs.ReadFile(p.path)
+	if err != nil {
+		return nil
+	}
+
+	var fingerprints map[string]string
+
+	err = json.Unmarshal(contents, &fingerprints)
+	if err != nil {
+		return nil
+	}
+
+	return fingerprints
+}
+
+func (p appliedPackages) Forget() error {
+	err := p.fs.RemoveAll(p.path)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing applied package fingerprints")
+	}
+
+	return nil
+}
+
+func (p appliedPackages) Remember(pkgs []models.Package) error {
+	fingerprints := map[string]string{}
+	for _, pkg := range pkgs {
+		fingerprints[pkg.Name] = packageFingerprint(pkg)
+	}
+
+	contents, err := json.Marshal(fingerprints)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling applied package fingerprints")
+	}
+
+	err = p.fs.WriteFile(p.path, contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing applied package fingerprints")
+	}
+
+	return nil
+}
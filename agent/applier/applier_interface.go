@@ -2,10 +2,23 @@ package applier
 
 import (
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 type Applier interface {
 	Prepare(desiredApplySpec boshas.ApplySpec) error
 	ConfigureJobs(desiredApplySpec boshas.ApplySpec) error
-	Apply(desiredApplySpec boshas.ApplySpec) error
+
+	// Apply stops with task.ErrCancelled between jobs and packages once the
+	// cancel signal is cancelled; a nil signal never cancels
+	Apply(desiredApplySpec boshas.ApplySpec, cancel *boshtask.CancelSignal) error
+
+	// CleanUp uninstalls the bundles the applied spec does not use that are
+	// no longer retained, or all of them
+	CleanUp(appliedSpec boshas.ApplySpec, all bool) error
+
+	// ApplyDeferredPackages applies the packages of the given jobs that were
+	// deferred until they run because packages are lazy
+	ApplyDeferredPackages(jobs []models.Job) error
 }
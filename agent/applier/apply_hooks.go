@@ -0,0 +1,123 @@
+package applier
+
+import (
+	"path"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/script/cmd"
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+const (
+	// PreApplyHook runs before the currently applied jobs are torn down
+	PreApplyHook = "pre-apply"
+
+	// PostApplyHook runs after the desired jobs were enabled
+	PostApplyHook = "post-apply"
+
+	// Only the end of long hook output is kept so that apply results stay
+	// below the maximum response size
+	maxHookOutputLength = 8 * 1024
+
+	hookRunnerLogTag = "HookRunner"
+)
+
+// HookResult is the outcome of a single hook script.
+type HookResult struct {
+	Hook       string `json:"hook"`
+	Script     string `json:"script"`
+	ExitStatus int    `json:"exit_status"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+}
+
+// HookRunner runs the hook scripts the stemcell and jobs provide, e.g.
+// /var/vcap/bosh/etc/pre-apply.d/* and /var/vcap/jobs/JOB/bin/pre-apply.
+type HookRunner interface {
+	// RunHooks runs the stemcell hook scripts in lexical order followed by
+	// the hook scripts of the jobs in the given order. It stops at the first
+	// script that fails.
+	RunHooks(hook string, jobs []models.Job) ([]HookResult, error)
+}
+
+type hookRunner struct {
+	fs          boshsys.FileSystem
+	cmdRunner   boshsys.CmdRunner
+	dirProvider boshdirs.Provider
+	logger      boshlog.Logger
+}
+
+func NewHookRunner(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	dirProvider boshdirs.Provider,
+	logger boshlog.Logger,
+) HookRunner {
+	return hookRunner{
+		fs:          fs,
+		cmdRunner:   cmdRunner,
+		dirProvider: dirProvider,
+		logger:      logger,
+	}
+}
+
+func (r hookRunner) RunHooks(hook string, jobs []models.Job) ([]HookResult, error) {
+	scripts, err := r.hookScripts(hook, jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []HookResult{}
+
+	for _, script := range scripts {
+		r.logger.Info(hookRunnerLogTag, "Running %s hook '%s'", hook, script)
+
+		stdout, stderr, exitStatus, err := r.cmdRunner.RunComplexCommand(cmd.BuildCommand(script))
+
+		results = append(results, HookResult{
+			Hook:       hook,
+			Script:     script,
+			ExitStatus: exitStatus,
+			Stdout:     truncateHookOutput(stdout),
+			Stderr:     truncateHookOutput(stderr),
+		})
+
+		if err != nil {
+			return results, bosherr.WrapErrorf(err, "Running %s hook '%s'", hook, script)
+		}
+	}
+
+	return results, nil
+}
+
+func (r hookRunner) hookScripts(hook string, jobs []models.Job) ([]string, error) {
+	scripts, err := r.fs.Glob(path.Join(r.dirProvider.EtcDir(), hook+".d", "*"))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Globbing stemcell %s hooks", hook)
+	}
+
+	sort.Strings(scripts)
+
+	for _, job := range jobs {
+		script := path.Join(r.dirProvider.JobBinDir(job.Name), hook+boshscript.ScriptExt)
+		if r.fs.FileExists(script) {
+			scripts = append(scripts, script)
+		}
+	}
+
+	return scripts, nil
+}
+
+func truncateHookOutput(output string) string {
+	if len(output) <= maxHookOutputLength {
+		return output
+	}
+
+	return output[len(output)-maxHookOutputLength:]
+}
@@ -0,0 +1,122 @@
+package applier_test
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/script/cmd"
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+var _ = Describe("HookRunner", func() {
+	var (
+		fs         *fakesys.FakeFileSystem
+		cmdRunner  *fakesys.FakeCmdRunner
+		hookRunner applier.HookRunner
+
+		jobs []models.Job
+
+		stemcellScripts []string
+		jobScript       string
+	)
+
+	fullCmd := func(script string) string {
+		command := cmd.BuildCommand(script)
+		return strings.Join(append([]string{command.Name}, command.Args...), " ")
+	}
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		dirProvider := boshdirs.NewProvider("/var/vcap")
+		hookRunner = applier.NewHookRunner(fs, cmdRunner, dirProvider, boshlog.NewLogger(boshlog.LevelNone))
+
+		jobs = []models.Job{{Name: "fake-job-1"}, {Name: "fake-job-2"}}
+
+		stemcellScripts = []string{
+			"/var/vcap/bosh/etc/pre-apply.d/10-first",
+			"/var/vcap/bosh/etc/pre-apply.d/20-second",
+		}
+		fs.SetGlob("/var/vcap/bosh/etc/pre-apply.d/*", []string{stemcellScripts[1], stemcellScripts[0]})
+
+		jobScript = "/var/vcap/jobs/fake-job-2/bin/pre-apply" + boshscript.ScriptExt
+		err := fs.WriteFileString(jobScript, "fake-script")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("runs the stemcell hooks in lexical order followed by the hooks of the jobs that provide one", func() {
+		for _, script := range append(stemcellScripts, jobScript) {
+			cmdRunner.AddCmdResult(fullCmd(script), fakesys.FakeCmdResult{Stdout: "fake-stdout"})
+		}
+
+		results, err := hookRunner.RunHooks(applier.PreApplyHook, jobs)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cmdRunner.RunComplexCommands).To(Equal([]boshsys.Command{
+			cmd.BuildCommand(stemcellScripts[0]),
+			cmd.BuildCommand(stemcellScripts[1]),
+			cmd.BuildCommand(jobScript),
+		}))
+
+		Expect(results).To(Equal([]applier.HookResult{
+			{Hook: applier.PreApplyHook, Script: stemcellScripts[0], Stdout: "fake-stdout"},
+			{Hook: applier.PreApplyHook, Script: stemcellScripts[1], Stdout: "fake-stdout"},
+			{Hook: applier.PreApplyHook, Script: jobScript, Stdout: "fake-stdout"},
+		}))
+	})
+
+	It("returns no results when no hooks are provided", func() {
+		results, err := hookRunner.RunHooks(applier.PostApplyHook, jobs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(BeEmpty())
+		Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+	})
+
+	It("stops at the first hook that fails and returns its output", func() {
+		cmdRunner.AddCmdResult(fullCmd(stemcellScripts[0]), fakesys.FakeCmdResult{
+			Stdout:     "fake-stdout",
+			Stderr:     "fake-stderr",
+			ExitStatus: 1,
+			Error:      errors.New("fake-run-error"),
+		})
+
+		results, err := hookRunner.RunHooks(applier.PreApplyHook, jobs)
+		Expect(err).To(MatchError("Running pre-apply hook '" + stemcellScripts[0] + "': fake-run-error"))
+
+		Expect(cmdRunner.RunComplexCommands).To(HaveLen(1))
+		Expect(results).To(Equal([]applier.HookResult{
+			{Hook: applier.PreApplyHook, Script: stemcellScripts[0], ExitStatus: 1, Stdout: "fake-stdout", Stderr: "fake-stderr"},
+		}))
+	})
+
+	It("only keeps the end of long output", func() {
+		cmdRunner.AddCmdResult(fullCmd(jobScript), fakesys.FakeCmdResult{
+			Stdout: strings.Repeat("a", 10*1024) + "fake-end",
+		})
+
+		results, err := hookRunner.RunHooks(applier.PreApplyHook, jobs)
+		Expect(err).ToNot(HaveOccurred())
+
+		stdout := results[2].Stdout
+		Expect(stdout).To(HaveLen(8 * 1024))
+		Expect(stdout).To(HaveSuffix("fake-end"))
+	})
+
+	It("returns an error when looking up the stemcell hooks fails", func() {
+		fs.GlobErr = errors.New("fake-glob-error")
+
+		_, err := hookRunner.RunHooks(applier.PreApplyHook, jobs)
+		Expect(err).To(MatchError("Globbing stemcell pre-apply hooks: fake-glob-error"))
+		Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+	})
+})
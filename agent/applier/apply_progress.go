@@ -0,0 +1,78 @@
+package applier
+
+import (
+	"sync"
+)
+
+const (
+	ApplyStepStarting        = "starting"
+	ApplyStepApplyingJob     = "applying_job"
+	ApplyStepApplyingPackage = "applying_package"
+	ApplyStepReloading       = "reloading_job_supervisor"
+	ApplyStepCleaningUp      = "cleaning_up"
+	ApplyStepDone            = "done"
+)
+
+// ApplyProgress describes what an apply is currently doing. Job and Package
+// name the bundle the step is about, if any.
+type ApplyProgress struct {
+	Step    string `json:"step"`
+	Job     string `json:"job,omitempty"`
+	Package string `json:"package,omitempty"`
+	Percent int    `json:"percent"`
+}
+
+// ProgressReporter is told about the progress of applies as they happen.
+type ProgressReporter interface {
+	ReportProgress(progress ApplyProgress)
+}
+
+// applyProgress counts the applied jobs and packages of a single apply.
+// Packages are applied in parallel so it has to be safe for concurrent use.
+type applyProgress struct {
+	reporter  ProgressReporter
+	total     int
+	completed int
+	lock      sync.Mutex
+}
+
+func newApplyProgress(reporter ProgressReporter, jobs, packages int) *applyProgress {
+	return &applyProgress{
+		reporter: reporter,
+		// Reloading and cleaning up count as one more
+		total: jobs + packages + 1,
+	}
+}
+
+// Start reports a step without counting it as completed
+func (p *applyProgress) Start(progress ApplyProgress) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.report(progress)
+}
+
+// Complete counts one job or package as applied
+func (p *applyProgress) Complete() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.completed++
+}
+
+func (p *applyProgress) Done() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.completed = p.total
+	p.report(ApplyProgress{Step: ApplyStepDone})
+}
+
+func (p *applyProgress) report(progress ApplyProgress) {
+	if p.reporter == nil {
+		return
+	}
+
+	progress.Percent = p.completed * 100 / p.total
+	p.reporter.ReportProgress(progress)
+}
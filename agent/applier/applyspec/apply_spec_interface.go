@@ -8,4 +8,5 @@ type ApplySpec interface {
 	Jobs() []models.Job
 	Packages() []models.Package
 	MaxLogFileSize() string
+	FileWatches() []models.FileWatch
 }
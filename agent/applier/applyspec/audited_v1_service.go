@@ -0,0 +1,58 @@
+package applyspec
+
+import (
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
+)
+
+type auditedV1Service struct {
+	V1Service
+
+	auditLog audit.Log
+}
+
+// NewAuditedV1Service records changes of the applied spec in the audit log.
+func NewAuditedV1Service(service V1Service, auditLog audit.Log) V1Service {
+	return auditedV1Service{V1Service: service, auditLog: auditLog}
+}
+
+func (s auditedV1Service) Set(spec V1ApplySpec) error {
+	event := specEvent(audit.OperationSetSpec, spec)
+
+	err := s.V1Service.Set(spec)
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	s.auditLog.Record(event)
+
+	return err
+}
+
+// Revert records the spec that became current again, unless it cannot be
+// read afterwards
+func (s auditedV1Service) Revert() error {
+	event := audit.Event{Operation: audit.OperationRevert}
+
+	err := s.V1Service.Revert()
+	if err != nil {
+		event.Error = err.Error()
+	} else if spec, getErr := s.Get(); getErr == nil {
+		event = specEvent(audit.OperationRevert, spec)
+	}
+
+	s.auditLog.Record(event)
+
+	return err
+}
+
+func specEvent(operation string, spec V1ApplySpec) audit.Event {
+	// The digest only adds detail, the event is recorded either way
+	digest, _ := spec.Digest() //nolint:errcheck
+
+	return audit.Event{
+		Operation: operation,
+		Name:      spec.Name,
+		Version:   spec.ConfigurationHash,
+		Digest:    digest,
+	}
+}
@@ -0,0 +1,80 @@
+package applyspec_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit/auditfakes"
+)
+
+var _ = Describe("auditedV1Service", func() {
+	var (
+		specService *fakeas.FakeV1Service
+		auditLog    *auditfakes.FakeLog
+		service     boshas.V1Service
+		spec        boshas.V1ApplySpec
+	)
+
+	BeforeEach(func() {
+		specService = fakeas.NewFakeV1Service()
+		auditLog = &auditfakes.FakeLog{}
+		service = boshas.NewAuditedV1Service(specService, auditLog)
+		spec = boshas.V1ApplySpec{Name: "fake-instance", ConfigurationHash: "fake-configuration-hash"}
+	})
+
+	Describe("Set", func() {
+		It("sets the spec and records it by its digest", func() {
+			err := service.Set(spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(specService.Spec).To(Equal(spec))
+
+			digest, err := spec.Digest()
+			Expect(err).ToNot(HaveOccurred())
+
+			event := auditLog.RecordArgsForCall(0)
+			Expect(event.Operation).To(Equal("set_spec"))
+			Expect(event.Name).To(Equal("fake-instance"))
+			Expect(event.Version).To(Equal("fake-configuration-hash"))
+			Expect(event.Digest).To(Equal(digest))
+			Expect(event.Error).To(BeEmpty())
+		})
+
+		It("records failing to set the spec", func() {
+			specService.SetErr = errors.New("fake-set-error")
+
+			err := service.Set(spec)
+			Expect(err).To(HaveOccurred())
+
+			Expect(auditLog.RecordArgsForCall(0).Error).To(Equal("fake-set-error"))
+		})
+	})
+
+	Describe("Revert", func() {
+		It("records the spec that is current again", func() {
+			specService.HistorySpecs = []boshas.V1ApplySpec{spec}
+
+			err := service.Revert()
+			Expect(err).ToNot(HaveOccurred())
+
+			event := auditLog.RecordArgsForCall(0)
+			Expect(event.Operation).To(Equal("revert_spec"))
+			Expect(event.Version).To(Equal("fake-configuration-hash"))
+			Expect(event.Digest).ToNot(BeEmpty())
+		})
+
+		It("records failing to revert", func() {
+			specService.RevertErr = errors.New("fake-revert-error")
+
+			err := service.Revert()
+			Expect(err).To(HaveOccurred())
+
+			event := auditLog.RecordArgsForCall(0)
+			Expect(event.Operation).To(Equal("revert_spec"))
+			Expect(event.Error).To(Equal("fake-revert-error"))
+		})
+	})
+})
@@ -2,6 +2,9 @@ package applyspec
 
 import (
 	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -9,13 +12,25 @@ import (
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 )
 
+// MaxHistory is the number of previously applied specs that are kept
+const MaxHistory = 5
+
 type concreteV1Service struct {
-	fs           boshsys.FileSystem
-	specFilePath string
+	fs              boshsys.FileSystem
+	specFilePath    string
+	historyFilePath string
 }
 
+// NewConcreteV1Service keeps the previously applied specs next to the spec
+// file, e.g. in spec-history.json for spec.json.
 func NewConcreteV1Service(fs boshsys.FileSystem, specFilePath string) V1Service {
-	return concreteV1Service{fs: fs, specFilePath: specFilePath}
+	ext := filepath.Ext(specFilePath)
+
+	return concreteV1Service{
+		fs:              fs,
+		specFilePath:    specFilePath,
+		historyFilePath: strings.TrimSuffix(specFilePath, ext) + "-history" + ext,
+	}
 }
 
 // Get reads and marshals the file contents.
@@ -39,8 +54,98 @@ func (s concreteV1Service) Get() (V1ApplySpec, error) {
 	return spec, nil
 }
 
-// Set unmarshals and writes to the file.
+// Set unmarshals and writes to the file. The replaced spec is added to the
+// history unless it was empty, is applied again or cannot be read anymore.
 func (s concreteV1Service) Set(spec V1ApplySpec) error {
+	currentSpec, err := s.Get()
+	if err == nil && currentSpec.ConfigurationHash != "" && currentSpec.ConfigurationHash != spec.ConfigurationHash {
+		history, err := s.History()
+		if err != nil {
+			return err
+		}
+
+		history = append([]V1ApplySpec{currentSpec}, history...)
+		if len(history) > MaxHistory {
+			history = history[:MaxHistory]
+		}
+
+		err = s.writeHistory(history)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.write(spec)
+}
+
+func (s concreteV1Service) AppliedAt() (time.Time, error) {
+	if !s.fs.FileExists(s.specFilePath) {
+		return time.Time{}, nil
+	}
+
+	info, err := s.fs.Stat(s.specFilePath)
+	if err != nil {
+		return time.Time{}, bosherr.WrapError(err, "Checking json spec file")
+	}
+
+	return info.ModTime(), nil
+}
+
+func (s concreteV1Service) History() ([]V1ApplySpec, error) {
+	var history []V1ApplySpec
+
+	if !s.fs.FileExists(s.historyFilePath) {
+		return history, nil
+	}
+
+	contents, err := s.fs.ReadFile(s.historyFilePath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading spec history file")
+	}
+
+	err = json.Unmarshal(contents, &history)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling spec history file")
+	}
+
+	return history, nil
+}
+
+func (s concreteV1Service) Revert() error {
+	history, err := s.History()
+	if err != nil {
+		return err
+	}
+
+	if len(history) == 0 {
+		return bosherr.Error("No previously applied spec to revert to")
+	}
+
+	// The previous spec is written first so that a failure in between
+	// keeps it in the history rather than losing it
+	err = s.write(history[0])
+	if err != nil {
+		return err
+	}
+
+	return s.writeHistory(history[1:])
+}
+
+func (s concreteV1Service) writeHistory(history []V1ApplySpec) error {
+	historyBytes, err := json.Marshal(history)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling spec history")
+	}
+
+	err = s.fs.WriteFile(s.historyFilePath, historyBytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing spec history to disk")
+	}
+
+	return nil
+}
+
+func (s concreteV1Service) write(spec V1ApplySpec) error {
 	specBytes, err := json.Marshal(spec)
 	if err != nil {
 		return bosherr.WrapError(err, "Marshalling apply spec")
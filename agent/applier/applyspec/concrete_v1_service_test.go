@@ -2,6 +2,7 @@ package applyspec_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -79,6 +80,110 @@ func init() { //nolint:gochecknoinits
 			})
 		})
 
+		Describe("AppliedAt", func() {
+			It("returns when the spec file was written", func() {
+				err := service.Set(V1ApplySpec{ConfigurationHash: "fake-hash"})
+				Expect(err).ToNot(HaveOccurred())
+
+				appliedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+				fs.GetFileTestStat(specPath).ModTime = appliedAt
+
+				Expect(service.AppliedAt()).To(Equal(appliedAt))
+			})
+
+			It("returns zero time when no spec was applied", func() {
+				Expect(service.AppliedAt()).To(BeZero())
+			})
+		})
+
+		Describe("History", func() {
+			historyPath := "/spec-history.json"
+
+			applySpecs := func(hashes ...string) {
+				for _, hash := range hashes {
+					err := service.Set(V1ApplySpec{ConfigurationHash: hash})
+					Expect(err).ToNot(HaveOccurred())
+				}
+			}
+
+			It("is empty before anything was applied", func() {
+				history, err := service.History()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(history).To(BeEmpty())
+			})
+
+			It("records replaced specs most recent first", func() {
+				applySpecs("hash-1", "hash-2", "hash-3")
+
+				history, err := service.History()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(history).To(Equal([]V1ApplySpec{
+					{ConfigurationHash: "hash-2"},
+					{ConfigurationHash: "hash-1"},
+				}))
+
+				Expect(fs.FileExists(historyPath)).To(BeTrue())
+			})
+
+			It("does not record empty specs or specs that are applied again", func() {
+				applySpecs("", "hash-1", "hash-1", "hash-2")
+
+				history, err := service.History()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(history).To(Equal([]V1ApplySpec{{ConfigurationHash: "hash-1"}}))
+			})
+
+			It("keeps only the most recent specs", func() {
+				applySpecs("hash-1", "hash-2", "hash-3", "hash-4", "hash-5", "hash-6", "hash-7")
+
+				history, err := service.History()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(history).To(HaveLen(MaxHistory))
+				Expect(history[0]).To(Equal(V1ApplySpec{ConfigurationHash: "hash-6"}))
+				Expect(history[MaxHistory-1]).To(Equal(V1ApplySpec{ConfigurationHash: "hash-2"}))
+			})
+
+			It("returns error if the history cannot be unmarshalled", func() {
+				err := fs.WriteFileString(historyPath, "not-json")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = service.History()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Unmarshalling spec history file"))
+			})
+		})
+
+		Describe("Revert", func() {
+			BeforeEach(func() {
+				for _, hash := range []string{"hash-1", "hash-2", "hash-3"} {
+					err := service.Set(V1ApplySpec{ConfigurationHash: hash})
+					Expect(err).ToNot(HaveOccurred())
+				}
+			})
+
+			It("makes the previous spec current and removes it from the history", func() {
+				err := service.Revert()
+				Expect(err).ToNot(HaveOccurred())
+
+				spec, err := service.Get()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(spec).To(Equal(V1ApplySpec{ConfigurationHash: "hash-2"}))
+
+				history, err := service.History()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(history).To(Equal([]V1ApplySpec{{ConfigurationHash: "hash-1"}}))
+			})
+
+			It("returns error when there is no previous spec", func() {
+				Expect(service.Revert()).To(Succeed())
+				Expect(service.Revert()).To(Succeed())
+
+				err := service.Revert()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("No previously applied spec to revert to"))
+			})
+		})
+
 		Describe("PopulateDHCPNetworks", func() {
 			var settings boshsettings.Settings
 			var unresolvedSpec V1ApplySpec
@@ -8,6 +8,7 @@ type FakeApplySpec struct {
 	JobResults           []models.Job
 	PackageResults       []models.Package
 	MaxLogFileSizeResult string
+	FileWatchResults     []models.FileWatch
 }
 
 func (s FakeApplySpec) Jobs() []models.Job {
@@ -21,3 +22,7 @@ func (s FakeApplySpec) Packages() []models.Package {
 func (s FakeApplySpec) MaxLogFileSize() string {
 	return s.MaxLogFileSizeResult
 }
+
+func (s FakeApplySpec) FileWatches() []models.FileWatch {
+	return s.FileWatchResults
+}
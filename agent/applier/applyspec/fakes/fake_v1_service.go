@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"time"
+
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 )
@@ -12,6 +14,13 @@ type FakeV1Service struct {
 	GetErr error
 	SetErr error
 
+	AppliedAtTime time.Time
+	AppliedAtErr  error
+
+	HistorySpecs []boshas.V1ApplySpec
+	HistoryErr   error
+	RevertErr    error
+
 	PopulateDHCPNetworksSpec       boshas.V1ApplySpec
 	PopulateDHCPNetworksSettings   boshsettings.Settings
 	PopulateDHCPNetworksResultSpec boshas.V1ApplySpec
@@ -33,6 +42,30 @@ func (s *FakeV1Service) Set(spec boshas.V1ApplySpec) error {
 	return s.SetErr
 }
 
+func (s *FakeV1Service) AppliedAt() (time.Time, error) {
+	s.ActionsCalled = append(s.ActionsCalled, "AppliedAt")
+	return s.AppliedAtTime, s.AppliedAtErr
+}
+
+func (s *FakeV1Service) History() ([]boshas.V1ApplySpec, error) {
+	s.ActionsCalled = append(s.ActionsCalled, "History")
+	return s.HistorySpecs, s.HistoryErr
+}
+
+func (s *FakeV1Service) Revert() error {
+	s.ActionsCalled = append(s.ActionsCalled, "Revert")
+	if s.RevertErr != nil {
+		return s.RevertErr
+	}
+
+	if len(s.HistorySpecs) > 0 {
+		s.Spec = s.HistorySpecs[0]
+		s.HistorySpecs = s.HistorySpecs[1:]
+	}
+
+	return nil
+}
+
 func (s *FakeV1Service) PopulateDHCPNetworks(spec boshas.V1ApplySpec, settings boshsettings.Settings) (boshas.V1ApplySpec, error) {
 	s.ActionsCalled = append(s.ActionsCalled, "PopulateDHCPNetworks")
 	s.PopulateDHCPNetworksSpec = spec
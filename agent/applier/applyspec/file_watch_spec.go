@@ -0,0 +1,25 @@
+package applyspec
+
+import (
+	models "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+type FileWatchSpec struct {
+	Job     string   `json:"job"`
+	Path    string   `json:"path"`
+	Action  string   `json:"action"`
+	Signal  string   `json:"signal,omitempty"`
+	PidFile string   `json:"pid_file,omitempty"`
+	Command []string `json:"command,omitempty"`
+}
+
+func (s FileWatchSpec) AsFileWatch() models.FileWatch {
+	return models.FileWatch{
+		Job:     s.Job,
+		Path:    s.Path,
+		Action:  s.Action,
+		Signal:  s.Signal,
+		PidFile: s.PidFile,
+		Command: s.Command,
+	}
+}
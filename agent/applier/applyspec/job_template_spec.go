@@ -1,17 +1,90 @@
 package applyspec
 
 import (
+	"time"
+
 	models "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 )
 
+const (
+	DrainOnTimeoutFail     = "fail"
+	DrainOnTimeoutContinue = "continue"
+)
+
 type JobTemplateSpec struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
+
+	// Packages the job depends on, optional
+	Packages []string `json:"packages,omitempty"`
+
+	// Directories the agent manages for the job, optional
+	Directories []models.JobDirectory `json:"directories,omitempty"`
+
+	// Names of colocated jobs that have to be enabled first, optional
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// Bounds the drain script of the job, optional
+	Drain *JobDrainSpec `json:"drain,omitempty"`
+
+	// HTTP probe of the job for run_health_checks, optional
+	HealthCheck *JobHealthCheckSpec `json:"health_check,omitempty"`
+}
+
+// JobDrainSpec limits the drain script of a job to MaxSeconds. OnTimeout is
+// either fail, the default, or continue to go on draining the other jobs.
+type JobDrainSpec struct {
+	MaxSeconds int    `json:"max_seconds"`
+	OnTimeout  string `json:"on_timeout,omitempty"`
+}
+
+// JobHealthCheckSpec bounds each health check of the job by TimeoutSeconds
+// and adds an HTTP probe of a port the job listens on locally.
+type JobHealthCheckSpec struct {
+	HTTP           *JobHTTPProbeSpec `json:"http,omitempty"`
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
+}
+
+type JobHTTPProbeSpec struct {
+	Port int    `json:"port"`
+	Path string `json:"path,omitempty"`
 }
 
 func (s *JobTemplateSpec) AsJob() models.Job {
 	return models.Job{
-		Name:    s.Name,
-		Version: s.Version,
+		Name:             s.Name,
+		Version:          s.Version,
+		DeclaredPackages: s.Packages,
+		Directories:      s.Directories,
+		DependsOn:        s.DependsOn,
+		Drain:            s.Drain.AsJobDrain(),
+		HealthCheck:      s.HealthCheck.AsJobHealthCheck(),
 	}
 }
+
+func (s *JobDrainSpec) AsJobDrain() models.JobDrain {
+	if s == nil {
+		return models.JobDrain{}
+	}
+
+	return models.JobDrain{
+		MaxDuration:       time.Duration(s.MaxSeconds) * time.Second,
+		ContinueOnTimeout: s.OnTimeout == DrainOnTimeoutContinue,
+	}
+}
+
+func (s *JobHealthCheckSpec) AsJobHealthCheck() models.JobHealthCheck {
+	if s == nil {
+		return models.JobHealthCheck{}
+	}
+
+	healthCheck := models.JobHealthCheck{
+		Timeout: time.Duration(s.TimeoutSeconds) * time.Second,
+	}
+
+	if s.HTTP != nil {
+		healthCheck.HTTP = &models.JobHTTPProbe{Port: s.HTTP.Port, Path: s.HTTP.Path}
+	}
+
+	return healthCheck
+}
@@ -0,0 +1,10 @@
+package applyspec
+
+// LinkAddressSpec names an address provided to this instance through a link
+// that should be verified once the apply has completed. Port is optional;
+// without it only name resolution is checked.
+type LinkAddressSpec struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Port    int    `json:"port,omitempty"`
+}
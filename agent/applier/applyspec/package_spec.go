@@ -13,6 +13,7 @@ type PackageSpec struct {
 	BlobstoreID      string                `json:"blobstore_id"`
 	SignedURL        string                `json:"signed_url"`
 	BlobstoreHeaders map[string]string     `json:"blobstore_headers"`
+	Signature        *models.Signature     `json:"signature,omitempty"`
 }
 
 func (s *PackageSpec) AsPackage() models.Package {
@@ -24,6 +25,7 @@ func (s *PackageSpec) AsPackage() models.Package {
 			SignedURL:        s.SignedURL,
 			BlobstoreID:      s.BlobstoreID,
 			BlobstoreHeaders: s.BlobstoreHeaders,
+			Signature:        s.Signature,
 		},
 	}
 }
@@ -11,6 +11,7 @@ import (
 type RenderedTemplatesArchiveSpec struct {
 	Sha1        *crypto.MultipleDigest `json:"sha1"`
 	BlobstoreID string                 `json:"blobstore_id"`
+	Signature   *models.Signature      `json:"signature,omitempty"`
 }
 
 func (s RenderedTemplatesArchiveSpec) AsSource(job models.Job) models.Source {
@@ -22,12 +23,14 @@ func (s RenderedTemplatesArchiveSpec) AsSource(job models.Job) models.Source {
 		Sha1:          sha1,
 		BlobstoreID:   s.BlobstoreID,
 		PathInArchive: job.Name,
+		Signature:     s.Signature,
 	}
 }
 
 type renderedTemplatesArchiveJSONStruct struct {
-	Sha1        string `json:"sha1"`
-	BlobstoreID string `json:"blobstore_id"`
+	Sha1        string            `json:"sha1"`
+	BlobstoreID string            `json:"blobstore_id"`
+	Signature   *models.Signature `json:"signature"`
 }
 
 func (s *RenderedTemplatesArchiveSpec) UnmarshalJSON(data []byte) error {
@@ -50,6 +53,7 @@ func (s *RenderedTemplatesArchiveSpec) UnmarshalJSON(data []byte) error {
 	*s = RenderedTemplatesArchiveSpec{
 		Sha1:        &digest,
 		BlobstoreID: jsonStruct.BlobstoreID,
+		Signature:   jsonStruct.Signature,
 	}
 
 	return nil
@@ -82,6 +82,17 @@ var _ = Describe("RenderedTemplatesArchive", func() {
 				BlobstoreID: "",
 			}),
 		)
+
+		It("keeps the signature of the archive for its jobs", func() {
+			data := []byte(`{"blobstore_id": "123", "sha1": "abc", "signature": {"key_id": "some-key", "value": "c2lnbmF0dXJl"}}`)
+
+			rendered := &RenderedTemplatesArchiveSpec{}
+			Expect(json.Unmarshal(data, rendered)).To(Succeed())
+			Expect(rendered.AsSource(models.Job{Name: "foo"}).Signature).To(Equal(&models.Signature{
+				KeyID: "some-key",
+				Value: []byte("signature"),
+			}))
+		})
 	})
 })
 
@@ -1,11 +1,24 @@
 package applyspec
 
 import (
+	"time"
+
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 )
 
 type V1Service interface {
 	Get() (V1ApplySpec, error)
 	Set(V1ApplySpec) error
+
+	// AppliedAt returns when the current spec was set, zero if there is none
+	AppliedAt() (time.Time, error)
+
+	// History returns the previously applied specs, most recent first
+	History() ([]V1ApplySpec, error)
+
+	// Revert makes the most recent previously applied spec the current one
+	// again and removes it from the history
+	Revert() error
+
 	PopulateDHCPNetworks(V1ApplySpec, boshsettings.Settings) (V1ApplySpec, error)
 }
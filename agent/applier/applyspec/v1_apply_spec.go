@@ -1,8 +1,12 @@
 package applyspec
 
 import (
+	"bytes"
 	"encoding/json"
 
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 )
 
@@ -26,6 +30,14 @@ type V1ApplySpec struct {
 	PersistentDisk int `json:"persistent_disk"`
 
 	RenderedTemplatesArchiveSpec *RenderedTemplatesArchiveSpec `json:"rendered_templates_archive"`
+
+	FileWatchSpecs []FileWatchSpec `json:"file_watches,omitempty"`
+
+	LinkAddressSpecs []LinkAddressSpec `json:"link_addresses,omitempty"`
+
+	// Overrides the agent's policy for the permissions of installed jobs
+	// and packages
+	BundlePermissions *models.PermissionPolicy `json:"bundle_permissions,omitempty"`
 }
 
 type PropertiesSpec struct {
@@ -51,6 +63,21 @@ type NetworkSpec struct {
 	Fields map[string]interface{}
 }
 
+// Digest identifies the spec by the SHA256 digest of its JSON
+func (s V1ApplySpec) Digest() (string, error) {
+	specBytes, err := json.Marshal(s)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Marshalling spec")
+	}
+
+	digest, err := boshcrypto.DigestAlgorithmSHA256.CreateDigest(bytes.NewReader(specBytes))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Computing digest of spec")
+	}
+
+	return digest.String(), nil
+}
+
 // Jobs returns a list of pre-rendered job templates
 // extracted from a single tarball provided by BOSH director.
 func (s V1ApplySpec) Jobs() []models.Job {
@@ -60,6 +87,9 @@ func (s V1ApplySpec) Jobs() []models.Job {
 		for _, j := range s.JobSpec.JobTemplateSpecsAsJobs() {
 			j.Source = s.RenderedTemplatesArchiveSpec.AsSource(j)
 			j.Packages = s.Packages()
+			if s.BundlePermissions != nil {
+				j.Permissions = s.BundlePermissions.Job(j.Name)
+			}
 			jobsWithSource = append(jobsWithSource, j)
 		}
 	}
@@ -70,11 +100,23 @@ func (s V1ApplySpec) Jobs() []models.Job {
 func (s V1ApplySpec) Packages() []models.Package {
 	packages := []models.Package{}
 	for _, value := range s.PackageSpecs {
-		packages = append(packages, value.AsPackage())
+		pkg := value.AsPackage()
+		if s.BundlePermissions != nil {
+			pkg.Permissions = s.BundlePermissions.Package(pkg.Name)
+		}
+		packages = append(packages, pkg)
 	}
 	return packages
 }
 
+func (s V1ApplySpec) FileWatches() []models.FileWatch {
+	fileWatches := []models.FileWatch{}
+	for _, value := range s.FileWatchSpecs {
+		fileWatches = append(fileWatches, value.AsFileWatch())
+	}
+	return fileWatches
+}
+
 func (s V1ApplySpec) MaxLogFileSize() string {
 	fileSize := s.PropertiesSpec.LoggingSpec.MaxLogFileSize
 	if len(fileSize) > 0 {
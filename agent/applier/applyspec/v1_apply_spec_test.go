@@ -2,6 +2,7 @@ package applyspec_test
 
 import (
 	"encoding/json"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -28,7 +29,7 @@ var _ = Describe("V1ApplySpec", func() {
 					"sha1": "sha1:routersha1;sha256:routersha256",
 					"blobstore_id": "router-blob-id-1",
 					"templates": [
-						{"name": "template 1", "version": "0.1"},
+						{"name": "template 1", "version": "0.1", "packages": ["package 1"]},
 						{"name": "template 2", "version": "0.2"}
 					]
 				},
@@ -88,7 +89,7 @@ var _ = Describe("V1ApplySpec", func() {
 					Template: "router template",
 					Version:  "1.0",
 					JobTemplateSpecs: []JobTemplateSpec{
-						{Name: "template 1", Version: "0.1"},
+						{Name: "template 1", Version: "0.1", Packages: []string{"package 1"}},
 						{Name: "template 2", Version: "0.2"},
 					},
 				},
@@ -188,8 +189,9 @@ var _ = Describe("V1ApplySpec", func() {
 					Version: "fake-job-legacy-version",
 					JobTemplateSpecs: []JobTemplateSpec{
 						{
-							Name:    "fake-job1-name",
-							Version: "fake-job1-version",
+							Name:     "fake-job1-name",
+							Version:  "fake-job1-version",
+							Packages: []string{"fake-package1-name"},
 						},
 						{
 							Name:    "fake-job2-name",
@@ -253,7 +255,8 @@ var _ = Describe("V1ApplySpec", func() {
 						BlobstoreID:   "fake-rendered-templates-archive-blobstore-id",
 						PathInArchive: "fake-job1-name",
 					},
-					Packages: actualJobs[0].Packages, // tested above
+					Packages:         actualJobs[0].Packages, // tested above
+					DeclaredPackages: []string{"fake-package1-name"},
 				},
 				{
 					Name:    "fake-job2-name",
@@ -268,6 +271,68 @@ var _ = Describe("V1ApplySpec", func() {
 			}))
 		})
 
+		It("returns jobs with the permissions the spec asks for", func() {
+			sha1 := crypto.MustParseMultipleDigest("sha1:fakerenderedtemplatesarchivesha1")
+			spec := V1ApplySpec{
+				JobSpec: JobSpec{
+					JobTemplateSpecs: []JobTemplateSpec{{Name: "fake-job1-name", Version: "fake-job1-version"}},
+				},
+				PackageSpecs: map[string]PackageSpec{
+					"fake-package1": {Name: "fake-package1-name", Version: "fake-package1-version"},
+				},
+				RenderedTemplatesArchiveSpec: &RenderedTemplatesArchiveSpec{Sha1: &sha1},
+				BundlePermissions: &models.PermissionPolicy{
+					Jobs:     map[string]models.Permissions{"fake-job1-name": {FileMode: 0600}},
+					Packages: map[string]models.Permissions{"*": {Owner: "vcap:vcap"}},
+				},
+			}
+
+			jobs := spec.Jobs()
+			Expect(jobs).To(HaveLen(1))
+			Expect(jobs[0].Permissions).To(Equal(models.Permissions{FileMode: 0600}))
+			Expect(jobs[0].Packages[0].Permissions).To(Equal(models.Permissions{Owner: "vcap:vcap"}))
+		})
+
+		It("returns jobs with the drain limits the spec asks for", func() {
+			sha1 := crypto.MustParseMultipleDigest("sha1:fakerenderedtemplatesarchivesha1")
+			spec := V1ApplySpec{
+				JobSpec: JobSpec{
+					JobTemplateSpecs: []JobTemplateSpec{
+						{Name: "fake-job1-name", Version: "fake-job1-version", Drain: &JobDrainSpec{MaxSeconds: 90, OnTimeout: DrainOnTimeoutContinue}},
+						{Name: "fake-job2-name", Version: "fake-job2-version", Drain: &JobDrainSpec{MaxSeconds: 30}},
+						{Name: "fake-job3-name", Version: "fake-job3-version"},
+					},
+				},
+				RenderedTemplatesArchiveSpec: &RenderedTemplatesArchiveSpec{Sha1: &sha1},
+			}
+
+			jobs := spec.Jobs()
+			Expect(jobs).To(HaveLen(3))
+			Expect(jobs[0].Drain).To(Equal(models.JobDrain{MaxDuration: 90 * time.Second, ContinueOnTimeout: true}))
+			Expect(jobs[1].Drain).To(Equal(models.JobDrain{MaxDuration: 30 * time.Second}))
+			Expect(jobs[2].Drain).To(Equal(models.JobDrain{}))
+		})
+
+		It("returns jobs with the health checks the spec asks for", func() {
+			sha1 := crypto.MustParseMultipleDigest("sha1:fakerenderedtemplatesarchivesha1")
+			spec := V1ApplySpec{
+				JobSpec: JobSpec{
+					JobTemplateSpecs: []JobTemplateSpec{
+						{Name: "fake-job1-name", Version: "fake-job1-version", HealthCheck: &JobHealthCheckSpec{HTTP: &JobHTTPProbeSpec{Port: 8080, Path: "/health"}, TimeoutSeconds: 5}},
+						{Name: "fake-job2-name", Version: "fake-job2-version", HealthCheck: &JobHealthCheckSpec{TimeoutSeconds: 30}},
+						{Name: "fake-job3-name", Version: "fake-job3-version"},
+					},
+				},
+				RenderedTemplatesArchiveSpec: &RenderedTemplatesArchiveSpec{Sha1: &sha1},
+			}
+
+			jobs := spec.Jobs()
+			Expect(jobs).To(HaveLen(3))
+			Expect(jobs[0].HealthCheck).To(Equal(models.JobHealthCheck{HTTP: &models.JobHTTPProbe{Port: 8080, Path: "/health"}, Timeout: 5 * time.Second}))
+			Expect(jobs[1].HealthCheck).To(Equal(models.JobHealthCheck{Timeout: 30 * time.Second}))
+			Expect(jobs[2].HealthCheck).To(Equal(models.JobHealthCheck{}))
+		})
+
 		It("returns no jobs when no jobs specified", func() {
 			spec := V1ApplySpec{}
 			Expect(spec.Jobs()).To(Equal([]models.Job{}))
@@ -305,6 +370,37 @@ var _ = Describe("V1ApplySpec", func() {
 		})
 	})
 
+	Describe("FileWatches", func() {
+		It("returns file watches", func() {
+			spec := V1ApplySpec{
+				FileWatchSpecs: []FileWatchSpec{
+					{
+						Job:     "fake-job",
+						Path:    "/var/vcap/data/fake-job/certs/*.pem",
+						Action:  "signal",
+						Signal:  "HUP",
+						PidFile: "/var/vcap/sys/run/fake-job/fake-job.pid",
+					},
+				},
+			}
+
+			Expect(spec.FileWatches()).To(Equal([]models.FileWatch{
+				{
+					Job:     "fake-job",
+					Path:    "/var/vcap/data/fake-job/certs/*.pem",
+					Action:  models.FileWatchActionSignal,
+					Signal:  "HUP",
+					PidFile: "/var/vcap/sys/run/fake-job/fake-job.pid",
+				},
+			}))
+		})
+
+		It("returns no file watches when none are specified", func() {
+			spec := V1ApplySpec{}
+			Expect(spec.FileWatches()).To(Equal([]models.FileWatch{}))
+		})
+	})
+
 	Describe("MaxLogFileSize", func() {
 		It("returns 50M if size is not provided", func() {
 			spec := V1ApplySpec{}
@@ -0,0 +1,273 @@
+package applyspec
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/filewatcher"
+)
+
+// Validate reports every malformed field of the spec by its path, e.g.
+// packages.NAME.sha1, since applying it would fail far from the cause
+func (s V1ApplySpec) Validate() error {
+	v := &specValidator{}
+
+	if s.Index != nil && *s.Index < 0 {
+		v.problem("index", "must not be negative, got %d", *s.Index)
+	}
+
+	if s.PersistentDisk < 0 {
+		v.problem("persistent_disk", "must not be negative, got %d", s.PersistentDisk)
+	}
+
+	v.validateNetworks(s.NetworkSpecs)
+	packageNames := v.validatePackages(s.PackageSpecs)
+	templateNames := v.validateTemplates(s.JobSpec.JobTemplateSpecs, packageNames)
+
+	// Jobs are only applied along with their rendered templates
+	archive := s.RenderedTemplatesArchiveSpec
+	if len(s.JobSpec.JobTemplateSpecs) > 0 && archive != nil && archive.Sha1 != nil && archive.BlobstoreID == "" {
+		v.problem("rendered_templates_archive.blobstore_id", "missing")
+	}
+
+	if archive != nil {
+		v.validateSignature("rendered_templates_archive.signature", archive.Signature)
+	}
+
+	for i, fileWatchSpec := range s.FileWatchSpecs {
+		field := fmt.Sprintf("file_watches[%d]", i)
+
+		if !templateNames[fileWatchSpec.Job] {
+			v.problem(field+".job", "'%s' is not a job template of the spec", fileWatchSpec.Job)
+			continue
+		}
+
+		if err := filewatcher.Validate(fileWatchSpec.AsFileWatch()); err != nil {
+			v.problem(field, "%s", err.Error())
+		}
+	}
+
+	return v.err()
+}
+
+type specValidator struct {
+	problems []error
+}
+
+func (v *specValidator) problem(field, format string, args ...interface{}) {
+	v.problems = append(v.problems, bosherr.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+}
+
+func (v *specValidator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+
+	return bosherr.WrapError(bosherr.NewMultiError(v.problems...), "Invalid apply spec")
+}
+
+func (v *specValidator) validateNetworks(networkSpecs map[string]NetworkSpec) {
+	networkNames := make([]string, 0, len(networkSpecs))
+	for name := range networkSpecs {
+		networkNames = append(networkNames, name)
+	}
+	sort.Strings(networkNames)
+
+	for _, name := range networkNames {
+		field := "networks." + name
+
+		if name == "" {
+			v.problem("networks", "network without a name")
+		}
+
+		fields := networkSpecs[name].Fields
+
+		for _, key := range []string{"ip", "netmask", "gateway"} {
+			value, found := fields[key]
+			if !found || value == nil {
+				continue
+			}
+
+			str, ok := value.(string)
+			if !ok {
+				v.problem(field+"."+key, "expected a string, got %T", value)
+				continue
+			}
+
+			if str != "" && net.ParseIP(str) == nil {
+				v.problem(field+"."+key, "'%s' is not an IP address", str)
+			}
+		}
+
+		for _, key := range []string{"type", "mac"} {
+			if value, found := fields[key]; found && value != nil {
+				if _, ok := value.(string); !ok {
+					v.problem(field+"."+key, "expected a string, got %T", value)
+				}
+			}
+		}
+
+		for _, key := range []string{"default", "dns"} {
+			value, found := fields[key]
+			if !found || value == nil {
+				continue
+			}
+
+			list, ok := value.([]interface{})
+			if !ok {
+				v.problem(field+"."+key, "expected a list of strings, got %T", value)
+				continue
+			}
+
+			for i, item := range list {
+				if _, ok := item.(string); !ok {
+					v.problem(fmt.Sprintf("%s.%s[%d]", field, key, i), "expected a string, got %T", item)
+				}
+			}
+		}
+	}
+}
+
+// validatePackages returns the names of the packages
+func (v *specValidator) validatePackages(packageSpecs map[string]PackageSpec) map[string]bool {
+	names := map[string]bool{}
+
+	keys := make([]string, 0, len(packageSpecs))
+	for key := range packageSpecs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := "packages." + key
+		pkg := packageSpecs[key]
+
+		switch {
+		case pkg.Name == "":
+			v.problem(field+".name", "missing")
+		case names[pkg.Name]:
+			v.problem(field+".name", "package '%s' is given more than once", pkg.Name)
+		}
+		names[pkg.Name] = true
+
+		if pkg.Version == "" {
+			v.problem(field+".version", "missing")
+		}
+
+		// Digests without any algorithm fail to marshal instead of panicking
+		if _, err := pkg.Sha1.MarshalJSON(); err != nil {
+			v.problem(field+".sha1", "missing digest")
+		}
+
+		if pkg.BlobstoreID == "" && pkg.SignedURL == "" {
+			v.problem(field+".blobstore_id", "missing, neither a blobstore id nor a signed url is given")
+		}
+
+		v.validateSignature(field+".signature", pkg.Signature)
+	}
+
+	return names
+}
+
+func (v *specValidator) validateSignature(field string, signature *models.Signature) {
+	if signature == nil {
+		return
+	}
+
+	if signature.KeyID == "" {
+		v.problem(field+".key_id", "missing")
+	}
+
+	if len(signature.Value) == 0 {
+		v.problem(field+".value", "missing")
+	}
+}
+
+// validateTemplates returns the names of the job templates
+func (v *specValidator) validateTemplates(templateSpecs []JobTemplateSpec, packageNames map[string]bool) map[string]bool {
+	names := map[string]bool{}
+
+	for i, template := range templateSpecs {
+		field := fmt.Sprintf("job.templates[%d]", i)
+
+		switch {
+		case template.Name == "":
+			v.problem(field+".name", "missing")
+		case names[template.Name]:
+			v.problem(field+".name", "job '%s' is given more than once", template.Name)
+		}
+		names[template.Name] = true
+
+		if template.Version == "" {
+			v.problem(field+".version", "missing")
+		}
+
+		for j, pkg := range template.Packages {
+			if !packageNames[pkg] {
+				v.problem(fmt.Sprintf("%s.packages[%d]", field, j), "'%s' is not a package of the spec", pkg)
+			}
+		}
+
+		for j, dir := range template.Directories {
+			dirField := fmt.Sprintf("%s.directories[%d]", field, j)
+
+			if dir.Base != models.JobDirectoryBaseRun && dir.Base != models.JobDirectoryBaseData {
+				v.problem(dirField+".base", "expected '%s' or '%s', got '%s'", models.JobDirectoryBaseRun, models.JobDirectoryBaseData, dir.Base)
+			}
+
+			if !filepath.IsLocal(dir.Path) {
+				v.problem(dirField+".path", "'%s' is not a relative path within the base", dir.Path)
+			}
+		}
+
+		if template.Drain != nil {
+			if template.Drain.MaxSeconds < 0 {
+				v.problem(field+".drain.max_seconds", "must not be negative, got %d", template.Drain.MaxSeconds)
+			}
+
+			switch template.Drain.OnTimeout {
+			case "", DrainOnTimeoutFail, DrainOnTimeoutContinue:
+			default:
+				v.problem(field+".drain.on_timeout", "expected '%s' or '%s', got '%s'", DrainOnTimeoutFail, DrainOnTimeoutContinue, template.Drain.OnTimeout)
+			}
+		}
+
+		if template.HealthCheck != nil {
+			if template.HealthCheck.TimeoutSeconds < 0 {
+				v.problem(field+".health_check.timeout_seconds", "must not be negative, got %d", template.HealthCheck.TimeoutSeconds)
+			}
+
+			if probe := template.HealthCheck.HTTP; probe != nil {
+				if probe.Port < 1 || probe.Port > 65535 {
+					v.problem(field+".health_check.http.port", "expected a port between 1 and 65535, got %d", probe.Port)
+				}
+
+				if probe.Path != "" && !strings.HasPrefix(probe.Path, "/") {
+					v.problem(field+".health_check.http.path", "'%s' does not start with /", probe.Path)
+				}
+			}
+		}
+	}
+
+	// Jobs may depend on jobs that come later in the spec
+	for i, template := range templateSpecs {
+		for j, dependency := range template.DependsOn {
+			field := fmt.Sprintf("job.templates[%d].depends_on[%d]", i, j)
+
+			switch {
+			case dependency == template.Name:
+				v.problem(field, "job '%s' depends on itself", dependency)
+			case !names[dependency]:
+				v.problem(field, "'%s' is not a job template of the spec", dependency)
+			}
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,97 @@
+package applyspec_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+)
+
+var _ = Describe("V1ApplySpec", func() {
+	Describe("Validate", func() {
+		parse := func(specJSON string) V1ApplySpec {
+			var spec V1ApplySpec
+			Expect(json.Unmarshal([]byte(specJSON), &spec)).To(Succeed())
+			return spec
+		}
+
+		It("accepts well-formed specs", func() {
+			spec := parse(`{
+				"index": 0,
+				"job": {"name": "fake-job", "templates": [{"name": "fake-template", "version": "1", "packages": ["fake-pkg"], "drain": {"max_seconds": 60, "on_timeout": "continue"}, "health_check": {"http": {"port": 8080, "path": "/health"}, "timeout_seconds": 5}}]},
+				"packages": {"fake-pkg": {"name": "fake-pkg", "version": "1", "sha1": "sha256:abc", "blobstore_id": "fake-blob-id"}},
+				"networks": {"default": {"ip": "10.0.0.2", "netmask": "255.255.255.0", "gateway": "10.0.0.1", "default": ["dns", "gateway"], "dns": ["8.8.8.8"]}},
+				"rendered_templates_archive": {"sha1": "abc", "blobstore_id": "fake-archive-id"},
+				"file_watches": [{"job": "fake-template", "path": "/var/vcap/jobs/fake-template/config/*.pem", "action": "exec", "command": ["/var/vcap/jobs/fake-template/bin/reload", "--all"]}]
+			}`)
+
+			Expect(spec.Validate()).To(Succeed())
+		})
+
+		It("accepts empty specs", func() {
+			Expect(V1ApplySpec{}.Validate()).To(Succeed())
+		})
+
+		It("reports every malformed field by its path", func() {
+			spec := parse(`{
+				"index": -1,
+				"job": {"templates": [
+					{"name": "fake-template", "depends_on": ["fake-template", "unknown-template"]},
+					{"name": "fake-template", "version": "1", "packages": ["unknown-pkg"], "directories": [{"base": "log", "path": "../escape"}], "drain": {"max_seconds": -1, "on_timeout": "ignore"}, "health_check": {"http": {"port": 0, "path": "health"}, "timeout_seconds": -1}}
+				]},
+				"packages": {"fake-pkg": {"name": "fake-pkg", "signature": {"value": "c2lnbmF0dXJl"}}},
+				"networks": {"default": {"ip": "10.0.0.300", "gateway": 10, "dns": ["8.8.8.8", 1]}},
+				"file_watches": [{"job": "unknown-template", "path": "/some/path", "action": "exec", "command": ["/var/vcap/jobs/fake-template/bin/reload", "--all"]}]
+			}`)
+
+			err := spec.Validate()
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(ContainSubstring("index: must not be negative, got -1"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[0].version: missing"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[0].depends_on[0]: job 'fake-template' depends on itself"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[0].depends_on[1]: 'unknown-template' is not a job template of the spec"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[1].name: job 'fake-template' is given more than once"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[1].packages[0]: 'unknown-pkg' is not a package of the spec"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[1].directories[0].base: expected 'run' or 'data', got 'log'"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[1].directories[0].path: '../escape' is not a relative path within the base"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[1].drain.max_seconds: must not be negative, got -1"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[1].drain.on_timeout: expected 'fail' or 'continue', got 'ignore'"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[1].health_check.timeout_seconds: must not be negative, got -1"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[1].health_check.http.port: expected a port between 1 and 65535, got 0"))
+			Expect(err.Error()).To(ContainSubstring("job.templates[1].health_check.http.path: 'health' does not start with /"))
+			Expect(err.Error()).To(ContainSubstring("packages.fake-pkg.version: missing"))
+			Expect(err.Error()).To(ContainSubstring("packages.fake-pkg.sha1: missing digest"))
+			Expect(err.Error()).To(ContainSubstring("packages.fake-pkg.blobstore_id: missing"))
+			Expect(err.Error()).To(ContainSubstring("packages.fake-pkg.signature.key_id: missing"))
+			Expect(err.Error()).To(ContainSubstring("networks.default.ip: '10.0.0.300' is not an IP address"))
+			Expect(err.Error()).To(ContainSubstring("networks.default.gateway: expected a string, got float64"))
+			Expect(err.Error()).To(ContainSubstring("networks.default.dns[1]: expected a string, got float64"))
+			Expect(err.Error()).To(ContainSubstring("file_watches[0].job: 'unknown-template' is not a job template of the spec"))
+		})
+
+		It("reports malformed file watches of known jobs", func() {
+			spec := parse(`{
+				"job": {"templates": [{"name": "fake-template", "version": "1"}]},
+				"file_watches": [{"job": "fake-template", "path": "relative/path", "action": "exec", "command": ["/var/vcap/jobs/fake-template/bin/reload", "--all"]}]
+			}`)
+
+			err := spec.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("file_watches[0]: File watch path 'relative/path' must be absolute"))
+		})
+
+		It("reports rendered templates archives without a blobstore id", func() {
+			spec := parse(`{
+				"job": {"templates": [{"name": "fake-template", "version": "1"}]},
+				"rendered_templates_archive": {"sha1": "abc"}
+			}`)
+
+			err := spec.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("rendered_templates_archive.blobstore_id: missing"))
+		})
+	})
+})
@@ -0,0 +1,254 @@
+package applier
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	as "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	bc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
+)
+
+const bundleVerifierLogTag = "bundleVerifier"
+
+// BundleDiscrepancy lists how an installed bundle differs from the files
+// that were installed for it.
+type BundleDiscrepancy struct {
+	Bundle   string   `json:"bundle"`
+	Problems []string `json:"problems"`
+}
+
+const (
+	BundleAuditPassed     = "passed"
+	BundleAuditFailed     = "failed"
+	BundleAuditUnverified = "unverified"
+)
+
+// BundleAudit is the outcome of verifying a single bundle. Bundles installed
+// without recording digests are unverified, they neither pass nor fail.
+type BundleAudit struct {
+	Bundle   string   `json:"bundle"`
+	Version  string   `json:"version"`
+	Status   string   `json:"status"`
+	Problems []string `json:"problems"`
+}
+
+// BundleVerification is the outcome of a completed verification.
+type BundleVerification struct {
+	VerifiedAt    time.Time           `json:"verified_at"`
+	OK            bool                `json:"ok"`
+	Discrepancies []BundleDiscrepancy `json:"discrepancies"`
+}
+
+type BundleVerifier interface {
+	// Verify checks the installed job and package bundles of the applied
+	// spec against the digests recorded when they were installed
+	Verify(appliedSpec as.ApplySpec) ([]BundleDiscrepancy, error)
+
+	// Audit verifies every bundle of the applied spec like Verify, but
+	// reports the outcome of each bundle and fails bundles that cannot be
+	// verified instead of stopping at them
+	Audit(appliedSpec as.ApplySpec) []BundleAudit
+
+	// LastVerification returns the outcome of the most recent verification
+	// that completed, nil if none did yet
+	LastVerification() *BundleVerification
+
+	// JobFingerprints returns the content digests of the installed job
+	// bundles of the applied spec by job name. They identify the rendered
+	// templates of the jobs. Jobs whose contents are not stored by digest
+	// are left out.
+	JobFingerprints(appliedSpec as.ApplySpec) (map[string]string, error)
+}
+
+type bundleVerifier struct {
+	jobsBc       bc.BundleCollection
+	packagesBc   bc.BundleCollection
+	timeProvider clock.Clock
+	logger       boshlog.Logger
+
+	last     *BundleVerification
+	lastLock sync.Mutex
+}
+
+func NewBundleVerifier(jobsBc, packagesBc bc.BundleCollection, timeProvider clock.Clock, logger boshlog.Logger) BundleVerifier {
+	return &bundleVerifier{
+		jobsBc:       jobsBc,
+		packagesBc:   packagesBc,
+		timeProvider: timeProvider,
+		logger:       logger,
+	}
+}
+
+func (v *bundleVerifier) LastVerification() *BundleVerification {
+	v.lastLock.Lock()
+	defer v.lastLock.Unlock()
+
+	if v.last == nil {
+		return nil
+	}
+
+	last := *v.last
+	return &last
+}
+
+func (v *bundleVerifier) Verify(appliedSpec as.ApplySpec) ([]BundleDiscrepancy, error) {
+	discrepancies, err := v.verifyAll(appliedSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	v.recordVerification(discrepancies)
+
+	return discrepancies, nil
+}
+
+func (v *bundleVerifier) Audit(appliedSpec as.ApplySpec) []BundleAudit {
+	var audits []BundleAudit
+
+	for _, job := range appliedSpec.Jobs() {
+		audits = append(audits, v.audit(v.jobsBc, fmt.Sprintf("job %s", job.Name), job.Version, job))
+	}
+
+	for _, pkg := range appliedSpec.Packages() {
+		audits = append(audits, v.audit(v.packagesBc, fmt.Sprintf("package %s", pkg.Name), pkg.Version, pkg))
+	}
+
+	var discrepancies []BundleDiscrepancy
+
+	for _, audit := range audits {
+		if audit.Status == BundleAuditFailed {
+			discrepancies = append(discrepancies, BundleDiscrepancy{Bundle: audit.Bundle, Problems: audit.Problems})
+		}
+	}
+
+	v.recordVerification(discrepancies)
+
+	return audits
+}
+
+func (v *bundleVerifier) JobFingerprints(appliedSpec as.ApplySpec) (map[string]string, error) {
+	fingerprints := map[string]string{}
+
+	for _, job := range appliedSpec.Jobs() {
+		bundle, err := v.jobsBc.Get(job)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Getting bundle of job %s", job.Name)
+		}
+
+		if digest := bundle.ContentDigest(); digest != "" {
+			fingerprints[job.Name] = digest
+		}
+	}
+
+	return fingerprints, nil
+}
+
+func (v *bundleVerifier) recordVerification(discrepancies []BundleDiscrepancy) {
+	v.lastLock.Lock()
+	defer v.lastLock.Unlock()
+
+	v.last = &BundleVerification{
+		VerifiedAt:    v.timeProvider.Now(),
+		OK:            len(discrepancies) == 0,
+		Discrepancies: append([]BundleDiscrepancy{}, discrepancies...),
+	}
+}
+
+func (v *bundleVerifier) verifyAll(appliedSpec as.ApplySpec) ([]BundleDiscrepancy, error) {
+	var discrepancies []BundleDiscrepancy
+
+	for _, job := range appliedSpec.Jobs() {
+		discrepancy, err := v.verify(v.jobsBc, fmt.Sprintf("job %s", job.Name), job)
+		if err != nil {
+			return nil, err
+		}
+
+		if discrepancy != nil {
+			discrepancies = append(discrepancies, *discrepancy)
+		}
+	}
+
+	for _, pkg := range appliedSpec.Packages() {
+		discrepancy, err := v.verify(v.packagesBc, fmt.Sprintf("package %s", pkg.Name), pkg)
+		if err != nil {
+			return nil, err
+		}
+
+		if discrepancy != nil {
+			discrepancies = append(discrepancies, *discrepancy)
+		}
+	}
+
+	return discrepancies, nil
+}
+
+func (v *bundleVerifier) verify(collection bc.BundleCollection, name string, definition bc.BundleDefinition) (*BundleDiscrepancy, error) {
+	bundle, err := collection.Get(definition)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Getting bundle of %s", name)
+	}
+
+	problems, err := bundle.Verify()
+	if err != nil {
+		// Bundles installed by previous agent versions cannot be verified
+		if errors.Is(err, bc.ErrDigestsNotRecorded) {
+			v.logger.Warn(bundleVerifierLogTag, "Skipping verification of %s: %s", name, err.Error())
+			return nil, nil
+		}
+		return nil, bosherr.WrapErrorf(err, "Verifying bundle of %s", name)
+	}
+
+	if len(problems) == 0 {
+		return nil, nil
+	}
+
+	return &BundleDiscrepancy{Bundle: name, Problems: problems}, nil
+}
+
+func (v *bundleVerifier) audit(collection bc.BundleCollection, name, version string, definition bc.BundleDefinition) BundleAudit {
+	audit := BundleAudit{Bundle: name, Version: version, Status: BundleAuditFailed, Problems: []string{}}
+
+	bundle, err := collection.Get(definition)
+	if err != nil {
+		audit.Problems = []string{bosherr.WrapError(err, "Getting bundle").Error()}
+		return audit
+	}
+
+	installed, err := bundle.IsInstalled()
+	if err != nil {
+		audit.Problems = []string{bosherr.WrapError(err, "Checking if bundle is installed").Error()}
+		return audit
+	}
+
+	if !installed {
+		audit.Problems = []string{"not installed"}
+		return audit
+	}
+
+	problems, err := bundle.Verify()
+	if err != nil {
+		if errors.Is(err, bc.ErrDigestsNotRecorded) {
+			audit.Status = BundleAuditUnverified
+			return audit
+		}
+
+		v.logger.Error(bundleVerifierLogTag, "Verifying bundle of %s: %s", name, err.Error())
+		audit.Problems = []string{bosherr.WrapError(err, "Verifying bundle").Error()}
+		return audit
+	}
+
+	if len(problems) > 0 {
+		audit.Problems = problems
+		return audit
+	}
+
+	audit.Status = BundleAuditPassed
+
+	return audit
+}
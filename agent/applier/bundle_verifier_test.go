@@ -0,0 +1,209 @@
+package applier_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	boshbc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
+	fakebc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+var _ = Describe("BundleVerifier", func() {
+	var (
+		jobsBc      *fakebc.FakeBundleCollection
+		packagesBc  *fakebc.FakeBundleCollection
+		job         models.Job
+		pkg         models.Package
+		spec        fakeas.FakeApplySpec
+		verifier    applier.BundleVerifier
+		timeService *fakeclock.FakeClock
+	)
+
+	BeforeEach(func() {
+		jobsBc = fakebc.NewFakeBundleCollection()
+		packagesBc = fakebc.NewFakeBundleCollection()
+		job = buildJob()
+		job.Source.Sha1 = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-job-sha1")
+		pkg = buildPackage()
+		pkg.Source.Sha1 = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-package-sha1")
+		spec = fakeas.FakeApplySpec{
+			JobResults:     []models.Job{job},
+			PackageResults: []models.Package{pkg},
+		}
+		timeService = fakeclock.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+		verifier = applier.NewBundleVerifier(jobsBc, packagesBc, timeService, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("returns no discrepancies when all bundles are unchanged", func() {
+		discrepancies, err := verifier.Verify(spec)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(discrepancies).To(BeEmpty())
+	})
+
+	It("returns the problems of modified job and package bundles", func() {
+		jobsBc.FakeGet(job).VerifyProblems = []string{"modified: bin/run"}
+		packagesBc.FakeGet(pkg).VerifyProblems = []string{"missing: lib/lib.so"}
+
+		discrepancies, err := verifier.Verify(spec)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(discrepancies).To(Equal([]applier.BundleDiscrepancy{
+			{Bundle: "job " + job.Name, Problems: []string{"modified: bin/run"}},
+			{Bundle: "package " + pkg.Name, Problems: []string{"missing: lib/lib.so"}},
+		}))
+	})
+
+	It("skips bundles installed without recording digests", func() {
+		jobsBc.FakeGet(job).VerifyErr = boshbc.ErrDigestsNotRecorded
+
+		discrepancies, err := verifier.Verify(spec)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(discrepancies).To(BeEmpty())
+	})
+
+	It("returns an error when a bundle cannot be verified", func() {
+		packagesBc.FakeGet(pkg).VerifyErr = errors.New("fake-verify-err")
+
+		_, err := verifier.Verify(spec)
+		Expect(err).To(MatchError(ContainSubstring("fake-verify-err")))
+	})
+
+	Describe("Audit", func() {
+		BeforeEach(func() {
+			jobsBc.FakeGet(job).Installed = true
+			packagesBc.FakeGet(pkg).Installed = true
+		})
+
+		It("reports every bundle as passed when all bundles are unchanged", func() {
+			Expect(verifier.Audit(spec)).To(Equal([]applier.BundleAudit{
+				{Bundle: "job " + job.Name, Version: job.Version, Status: applier.BundleAuditPassed, Problems: []string{}},
+				{Bundle: "package " + pkg.Name, Version: pkg.Version, Status: applier.BundleAuditPassed, Problems: []string{}},
+			}))
+		})
+
+		It("reports modified bundles as failed with their problems", func() {
+			packagesBc.FakeGet(pkg).VerifyProblems = []string{"missing: lib/lib.so"}
+
+			audits := verifier.Audit(spec)
+			Expect(audits[0].Status).To(Equal(applier.BundleAuditPassed))
+			Expect(audits[1]).To(Equal(applier.BundleAudit{
+				Bundle:   "package " + pkg.Name,
+				Version:  pkg.Version,
+				Status:   applier.BundleAuditFailed,
+				Problems: []string{"missing: lib/lib.so"},
+			}))
+		})
+
+		It("reports bundles installed without recording digests as unverified", func() {
+			jobsBc.FakeGet(job).VerifyErr = boshbc.ErrDigestsNotRecorded
+
+			Expect(verifier.Audit(spec)[0].Status).To(Equal(applier.BundleAuditUnverified))
+		})
+
+		It("reports bundles that are not installed as failed", func() {
+			jobsBc.FakeGet(job).Installed = false
+
+			audits := verifier.Audit(spec)
+			Expect(audits[0].Status).To(Equal(applier.BundleAuditFailed))
+			Expect(audits[0].Problems).To(Equal([]string{"not installed"}))
+		})
+
+		It("reports bundles that cannot be verified as failed and audits the others", func() {
+			jobsBc.FakeGet(job).VerifyErr = errors.New("fake-verify-err")
+
+			audits := verifier.Audit(spec)
+			Expect(audits).To(HaveLen(2))
+			Expect(audits[0].Status).To(Equal(applier.BundleAuditFailed))
+			Expect(audits[0].Problems).To(Equal([]string{"Verifying bundle: fake-verify-err"}))
+			Expect(audits[1].Status).To(Equal(applier.BundleAuditPassed))
+		})
+
+		It("records the outcome as the last verification", func() {
+			jobsBc.FakeGet(job).VerifyProblems = []string{"modified: bin/run"}
+
+			verifier.Audit(spec)
+
+			Expect(verifier.LastVerification()).To(Equal(&applier.BundleVerification{
+				VerifiedAt:    timeService.Now(),
+				OK:            false,
+				Discrepancies: []applier.BundleDiscrepancy{{Bundle: "job " + job.Name, Problems: []string{"modified: bin/run"}}},
+			}))
+		})
+	})
+
+	Describe("LastVerification", func() {
+		It("is nil before bundles were verified", func() {
+			Expect(verifier.LastVerification()).To(BeNil())
+		})
+
+		It("returns the outcome of the most recent verification", func() {
+			_, err := verifier.Verify(spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(verifier.LastVerification()).To(Equal(&applier.BundleVerification{
+				VerifiedAt:    timeService.Now(),
+				OK:            true,
+				Discrepancies: []applier.BundleDiscrepancy{},
+			}))
+
+			timeService.Increment(time.Hour)
+			jobsBc.FakeGet(job).VerifyProblems = []string{"modified: bin/run"}
+
+			_, err = verifier.Verify(spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(verifier.LastVerification()).To(Equal(&applier.BundleVerification{
+				VerifiedAt:    timeService.Now(),
+				OK:            false,
+				Discrepancies: []applier.BundleDiscrepancy{{Bundle: "job " + job.Name, Problems: []string{"modified: bin/run"}}},
+			}))
+		})
+
+		It("keeps the previous outcome when a verification fails", func() {
+			_, err := verifier.Verify(spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			verifiedAt := timeService.Now()
+			timeService.Increment(time.Hour)
+			packagesBc.FakeGet(pkg).VerifyErr = errors.New("fake-verify-err")
+
+			_, err = verifier.Verify(spec)
+			Expect(err).To(HaveOccurred())
+
+			Expect(verifier.LastVerification().VerifiedAt).To(Equal(verifiedAt))
+		})
+	})
+
+	Describe("JobFingerprints", func() {
+		It("returns the content digests of the installed job bundles by job name", func() {
+			jobsBc.FakeGet(job).ContentDigestValue = "fake-content-digest"
+
+			fingerprints, err := verifier.JobFingerprints(spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fingerprints).To(Equal(map[string]string{job.Name: "fake-content-digest"}))
+		})
+
+		It("leaves out jobs whose contents are not stored by digest", func() {
+			fingerprints, err := verifier.JobFingerprints(spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fingerprints).To(BeEmpty())
+		})
+
+		It("returns an error when getting a job bundle fails", func() {
+			jobsBc.GetErr = errors.New("fake-get-err")
+
+			_, err := verifier.JobFingerprints(spec)
+			Expect(err).To(MatchError(ContainSubstring("fake-get-err")))
+		})
+	})
+})
@@ -0,0 +1,124 @@
+package bundlecollection
+
+import (
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
+)
+
+type auditedBundleCollection struct {
+	collection BundleCollection
+	name       string
+	auditLog   audit.Log
+}
+
+// NewAuditedBundleCollection records installing, enabling, disabling and
+// uninstalling the bundles of collection in the audit log.
+func NewAuditedBundleCollection(collection BundleCollection, name string, auditLog audit.Log) BundleCollection {
+	return auditedBundleCollection{
+		collection: collection,
+		name:       name,
+		auditLog:   auditLog,
+	}
+}
+
+func (c auditedBundleCollection) Get(definition BundleDefinition) (Bundle, error) {
+	bundle, err := c.collection.Get(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	return auditedBundle{
+		Bundle:     bundle,
+		collection: c.name,
+		name:       definition.BundleName(),
+		version:    definition.BundleVersion(),
+		auditLog:   c.auditLog,
+	}, nil
+}
+
+// List returns bundles that are only recorded by their install path since
+// the versions they were installed for are not known anymore
+func (c auditedBundleCollection) List() ([]Bundle, error) {
+	bundles, err := c.collection.List()
+
+	auditedBundles := make([]Bundle, 0, len(bundles))
+	for _, bundle := range bundles {
+		auditedBundles = append(auditedBundles, auditedBundle{
+			Bundle:     bundle,
+			collection: c.name,
+			auditLog:   c.auditLog,
+		})
+	}
+
+	return auditedBundles, err
+}
+
+type auditedBundle struct {
+	Bundle
+
+	collection string
+	name       string
+	version    string
+	auditLog   audit.Log
+}
+
+func (b auditedBundle) Install(sourcePath, pathInBundle string) (string, error) {
+	installPath, err := b.Bundle.Install(sourcePath, pathInBundle)
+	b.record(audit.OperationInstall, err)
+	return installPath, err
+}
+
+func (b auditedBundle) InstallWithoutContents() (string, error) {
+	installPath, err := b.Bundle.InstallWithoutContents()
+	b.record(audit.OperationInstall, err)
+	return installPath, err
+}
+
+func (b auditedBundle) Enable() (string, error) {
+	enablePath, err := b.Bundle.Enable()
+	b.record(audit.OperationEnable, err)
+	return enablePath, err
+}
+
+func (b auditedBundle) Disable() error {
+	err := b.Bundle.Disable()
+	b.record(audit.OperationDisable, err)
+	return err
+}
+
+// Uninstall looks up the install path and digest beforehand since neither
+// is known afterwards
+func (b auditedBundle) Uninstall() error {
+	event := b.event(audit.OperationUninstall)
+
+	err := b.Bundle.Uninstall()
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	b.auditLog.Record(event)
+
+	return err
+}
+
+func (b auditedBundle) record(operation string, err error) {
+	event := b.event(operation)
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	b.auditLog.Record(event)
+}
+
+func (b auditedBundle) event(operation string) audit.Event {
+	// The install path is unknown when the bundle is not installed
+	installPath, _ := b.GetInstallPath() //nolint:errcheck
+
+	return audit.Event{
+		Operation:  operation,
+		Collection: b.collection,
+		Name:       b.name,
+		Version:    b.version,
+		Path:       installPath,
+		Digest:     b.ContentDigest(),
+	}
+}
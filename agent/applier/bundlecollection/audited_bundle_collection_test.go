@@ -0,0 +1,101 @@
+package bundlecollection_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshbc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
+	fakebc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit/auditfakes"
+)
+
+var _ = Describe("auditedBundleCollection", func() {
+	var (
+		collection *fakebc.FakeBundleCollection
+		auditLog   *auditfakes.FakeLog
+		audited    boshbc.BundleCollection
+		pkg        models.LocalPackage
+		fakeBundle *fakebc.FakeBundle
+	)
+
+	BeforeEach(func() {
+		collection = fakebc.NewFakeBundleCollection()
+		auditLog = &auditfakes.FakeLog{}
+		audited = boshbc.NewAuditedBundleCollection(collection, "packages", auditLog)
+
+		pkg = models.LocalPackage{Name: "fake-package", Version: "fake-version"}
+		fakeBundle = collection.FakeGet(pkg)
+		fakeBundle.GetDirPath = "/fake-install-path"
+		fakeBundle.ContentDigestValue = "fake-content-digest"
+	})
+
+	It("records installing and enabling bundles with their contents", func() {
+		bundle, err := audited.Get(pkg)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = bundle.Install("/fake-source", "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = bundle.Enable()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeBundle.ActionsCalled).To(Equal([]string{"Install", "Enable"}))
+		Expect(auditLog.RecordCallCount()).To(Equal(2))
+		Expect(auditLog.RecordArgsForCall(0)).To(Equal(audit.Event{
+			Operation:  "install",
+			Collection: "packages",
+			Name:       "fake-package",
+			Version:    "fake-version",
+			Path:       "/fake-install-path",
+			Digest:     "fake-content-digest",
+		}))
+		Expect(auditLog.RecordArgsForCall(1).Operation).To(Equal("enable"))
+	})
+
+	It("records failed operations with their error", func() {
+		fakeBundle.DisableErr = errors.New("fake-disable-error")
+
+		bundle, err := audited.Get(pkg)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = bundle.Disable()
+		Expect(err).To(MatchError("fake-disable-error"))
+
+		event := auditLog.RecordArgsForCall(0)
+		Expect(event.Operation).To(Equal("disable"))
+		Expect(event.Error).To(Equal("fake-disable-error"))
+	})
+
+	It("records uninstalling listed bundles by their install path", func() {
+		collection.ListBundles = []boshbc.Bundle{fakeBundle}
+
+		bundles, err := audited.List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bundles).To(HaveLen(1))
+
+		err = bundles[0].Uninstall()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(auditLog.RecordArgsForCall(0)).To(Equal(audit.Event{
+			Operation:  "uninstall",
+			Collection: "packages",
+			Path:       "/fake-install-path",
+			Digest:     "fake-content-digest",
+		}))
+	})
+
+	It("does not record looking at bundles", func() {
+		bundle, err := audited.Get(pkg)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = bundle.IsInstalled()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = bundle.Verify()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(auditLog.RecordCallCount()).To(BeZero())
+	})
+})
@@ -1,5 +1,9 @@
 package bundlecollection
 
+import (
+	"time"
+)
+
 // BundleDefinition uniquely identifies an asset within a BundleCollection (e.g. Job, Package)
 type BundleDefinition interface {
 	BundleName() string
@@ -18,7 +22,20 @@ type Bundle interface {
 
 	IsInstalled() (bool, error)
 	GetInstallPath() (path string, err error)
+	InstalledAt() (time.Time, error)
+
+	// ContentDigest identifies the installed contents, it is empty when they
+	// are not stored by digest
+	ContentDigest() string
+
+	// Verify returns the differences between the installed files and the
+	// ones that were installed
+	Verify() (problems []string, err error)
 
 	Enable() (path string, err error)
 	Disable() (err error)
+
+	// MountReadOnly protects an enabled bundle from modifications until it
+	// is enabled again, disabled or uninstalled
+	MountReadOnly() (err error)
 }
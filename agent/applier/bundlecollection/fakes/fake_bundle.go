@@ -1,5 +1,9 @@
 package fakes
 
+import (
+	"time"
+)
+
 type FakeBundleInstallCallBack func()
 
 type FakeBundle struct {
@@ -17,12 +21,23 @@ type FakeBundle struct {
 	GetDirPath  string
 	GetDirError error
 
+	InstalledAtTime time.Time
+	InstalledAtErr  error
+
+	ContentDigestValue string
+
+	VerifyProblems []string
+	VerifyErr      error
+
 	EnablePath  string
 	EnableError error
 	Enabled     bool
 
 	DisableErr error
 
+	MountReadOnlyErr error
+	MountedReadOnly  bool
+
 	UninstallErr error
 }
 
@@ -54,6 +69,18 @@ func (s *FakeBundle) GetInstallPath() (string, error) {
 	return s.GetDirPath, s.GetDirError
 }
 
+func (s *FakeBundle) InstalledAt() (time.Time, error) {
+	return s.InstalledAtTime, s.InstalledAtErr
+}
+
+func (s *FakeBundle) ContentDigest() string {
+	return s.ContentDigestValue
+}
+
+func (s *FakeBundle) Verify() ([]string, error) {
+	return s.VerifyProblems, s.VerifyErr
+}
+
 func (s *FakeBundle) IsInstalled() (bool, error) {
 	return s.Installed, s.IsInstalledErr
 }
@@ -69,6 +96,12 @@ func (s *FakeBundle) Disable() error {
 	return s.DisableErr
 }
 
+func (s *FakeBundle) MountReadOnly() error {
+	s.MountedReadOnly = true
+	s.ActionsCalled = append(s.ActionsCalled, "MountReadOnly")
+	return s.MountReadOnlyErr
+}
+
 func (s *FakeBundle) Uninstall() error {
 	s.ActionsCalled = append(s.ActionsCalled, "Uninstall")
 	return s.UninstallErr
@@ -1,23 +1,48 @@
 package bundlecollection
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"code.cloudfoundry.org/clock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"github.com/cloudfoundry/bosh-utils/fileutil"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/tarpath"
+	boshdisk "github.com/cloudfoundry/bosh-agent/v2/platform/disk"
 )
 
 const (
 	fileBundleLogTag = "FileBundle"
+
+	// digestsFileSuffix names the file next to the install dir that records
+	// the digests of the installed files
+	digestsFileSuffix = ".digests"
+
+	// storeDirName names the directory next to the bundles of a collection
+	// that holds their contents by bundle name and content digest. Installed
+	// versions link to it so that versions of a bundle with identical
+	// contents share them. Different bundles never share contents since they
+	// are changed after installation, e.g. job permissions and package links.
+	storeDirName = ".store"
+
+	stagingDirSuffix = ".staging"
 )
 
+// ErrDigestsNotRecorded is returned when verifying a bundle that was
+// installed without recording digests of its files.
+var ErrDigestsNotRecorded = errors.New("digests of bundle files were not recorded")
+
 type FileBundle struct {
 	installPath  string
 	enablePath   string
@@ -26,7 +51,15 @@ type FileBundle struct {
 	timeProvider clock.Clock
 	compressor   fileutil.Compressor
 	detector     tarpath.Detector
-	logger       boshlog.Logger
+
+	// Bind mounts enabled bundles read-only when set
+	mounter boshdisk.Mounter
+
+	// Maximum size of the stored contents of the collection in bytes, zero
+	// means unlimited
+	quota uint64
+
+	logger boshlog.Logger
 }
 
 func NewFileBundle(
@@ -36,6 +69,8 @@ func NewFileBundle(
 	timeProvider clock.Clock,
 	compressor fileutil.Compressor,
 	detector tarpath.Detector,
+	mounter boshdisk.Mounter,
+	quota uint64,
 	logger boshlog.Logger,
 ) FileBundle {
 	return FileBundle{
@@ -46,6 +81,8 @@ func NewFileBundle(
 		timeProvider: timeProvider,
 		compressor:   compressor,
 		detector:     detector,
+		mounter:      mounter,
+		quota:        quota,
 		logger:       logger,
 	}
 }
@@ -68,13 +105,12 @@ func (b FileBundle) InstallWithoutContents() (string, error) {
 	return b.installPath, nil
 }
 
+// Install extracts the bundle into a staging directory and moves it into
+// the store by the digest of its contents, unless identical contents are
+// stored already. The install path then becomes a link to them.
 func (b FileBundle) Install(sourcePath, pathInBundle string) (string, error) {
 	b.logger.Debug(fileBundleLogTag, "Installing %v", b)
 
-	if _, err := b.InstallWithoutContents(); err != nil {
-		return "", err
-	}
-
 	stripComponents := 0
 	if pathInBundle != "" {
 		// Job bundles contain more than one job. We receive the individual job's
@@ -89,7 +125,6 @@ func (b FileBundle) Install(sourcePath, pathInBundle string) (string, error) {
 		var err error
 		hasSlash, err := b.detector.Detect(sourcePath, pathInBundle)
 		if err != nil {
-			_ = b.Uninstall() //nolint:errcheck
 			return "", bosherr.WrapError(err, "Detecting prefix of package files")
 		}
 
@@ -99,23 +134,93 @@ func (b FileBundle) Install(sourcePath, pathInBundle string) (string, error) {
 		}
 	}
 
-	installPathWithoutSymlinks, err := b.fs.ReadAndFollowLink(b.installPath)
+	if err := b.fs.MkdirAll(path.Dir(b.installPath), b.fileMode); err != nil {
+		return "", bosherr.WrapError(err, "Creating parent installation directory")
+	}
+	if err := b.fs.Chown(path.Dir(b.installPath), "root:vcap"); err != nil {
+		return "", bosherr.WrapError(err, "Setting ownership on parent installation directory")
+	}
+
+	stagingPath := b.stagingPath()
+
+	// Leftovers of an interrupted installation
+	if err := b.fs.RemoveAll(stagingPath); err != nil {
+		return "", bosherr.WrapError(err, "Removing staging directory")
+	}
+
+	if err := b.fs.MkdirAll(stagingPath, b.fileMode); err != nil {
+		return "", bosherr.WrapError(err, "Creating staging directory")
+	}
+
+	storedPath, err := b.stageAndStore(sourcePath, stagingPath, pathInBundle, stripComponents)
+	_ = b.fs.RemoveAll(stagingPath) //nolint:errcheck
+	if err != nil {
+		return "", err
+	}
+
+	err = b.fs.Symlink(storedPath, b.installPath)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Linking installation directory to stored contents")
+	}
+
+	err = b.recordDigests(storedPath)
+	if err != nil {
+		_ = b.Uninstall() //nolint:errcheck
+		return "", bosherr.WrapError(err, "Recording digests of package files")
+	}
+
+	b.logger.Debug(fileBundleLogTag, "Installed %v into %s", b, storedPath)
+	return b.installPath, nil
+}
+
+func (b FileBundle) stageAndStore(sourcePath, stagingPath, pathInBundle string, stripComponents int) (string, error) {
+	if err := b.fs.Chown(stagingPath, "root:vcap"); err != nil {
+		return "", bosherr.WrapError(err, "Setting ownership on installation directory")
+	}
+
+	stagingPathWithoutSymlinks, err := b.fs.ReadAndFollowLink(stagingPath)
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Following Install Path Symlink")
 	}
 
 	err = b.compressor.DecompressFileToDir(
 		sourcePath,
-		installPathWithoutSymlinks,
+		stagingPathWithoutSymlinks,
 		fileutil.CompressorOptions{PathInArchive: pathInBundle, StripComponents: stripComponents},
 	)
 	if err != nil {
-		_ = b.Uninstall() //nolint:errcheck
 		return "", bosherr.WrapError(err, "Decompressing package files")
 	}
 
-	b.logger.Debug(fileBundleLogTag, "Installing %v", b)
-	return b.installPath, nil
+	contentDigest, err := b.computeContentDigest(stagingPathWithoutSymlinks)
+	if err != nil {
+		return "", err
+	}
+
+	storedPath := path.Join(b.storePath(), contentDigest)
+
+	if b.fs.FileExists(storedPath) {
+		b.logger.Debug(fileBundleLogTag, "Reusing stored contents %s for %v", storedPath, b)
+		return storedPath, nil
+	}
+
+	err = b.checkQuota()
+	if err != nil {
+		return "", err
+	}
+
+	err = b.fs.Rename(stagingPath, storedPath)
+	if err != nil {
+		// Identical contents were stored by another install in the meantime
+		if b.fs.FileExists(storedPath) {
+			b.logger.Debug(fileBundleLogTag, "Reusing stored contents %s for %v", storedPath, b)
+			return storedPath, nil
+		}
+
+		return "", bosherr.WrapError(err, "Moving package files into the store")
+	}
+
+	return storedPath, nil
 }
 
 func (b FileBundle) GetInstallPath() (string, error) {
@@ -131,6 +236,70 @@ func (b FileBundle) IsInstalled() (bool, error) {
 	return b.fs.FileExists(b.installPath), nil
 }
 
+// InstalledAt does not follow the install path link since the linked
+// contents may have been stored for an earlier version.
+func (b FileBundle) InstalledAt() (time.Time, error) {
+	info, err := b.fs.Lstat(b.installPath)
+	if err != nil {
+		return time.Time{}, bosherr.WrapError(err, "Checking install dir")
+	}
+
+	return info.ModTime(), nil
+}
+
+// Verify compares the installed files with the digests recorded when the
+// bundle was installed. Symlinks and directories are not verified since they
+// are changed after installation, e.g. to link job specific packages.
+func (b FileBundle) Verify() ([]string, error) {
+	b.logger.Debug(fileBundleLogTag, "Verifying %v", b)
+
+	if !b.fs.FileExists(b.digestsPath()) {
+		return nil, ErrDigestsNotRecorded
+	}
+
+	contents, err := b.fs.ReadFile(b.digestsPath())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading recorded digests")
+	}
+
+	var recorded map[string]string
+	err = json.Unmarshal(contents, &recorded)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling recorded digests")
+	}
+
+	installPathWithoutSymlinks, err := b.fs.ReadAndFollowLink(b.installPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Following Install Path Symlink")
+	}
+
+	current, err := b.computeDigests(installPathWithoutSymlinks)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+
+	for file, digest := range recorded {
+		currentDigest, found := current[file]
+		if !found {
+			problems = append(problems, fmt.Sprintf("missing: %s", file))
+		} else if currentDigest != digest {
+			problems = append(problems, fmt.Sprintf("modified: %s", file))
+		}
+	}
+
+	for file := range current {
+		if _, found := recorded[file]; !found {
+			problems = append(problems, fmt.Sprintf("unexpected: %s", file))
+		}
+	}
+
+	sort.Strings(problems)
+
+	return problems, nil
+}
+
 func (b FileBundle) Enable() (string, error) {
 	b.logger.Debug(fileBundleLogTag, "Enabling %v", b)
 
@@ -138,6 +307,12 @@ func (b FileBundle) Enable() (string, error) {
 		return "", bosherr.Error("bundle must be installed")
 	}
 
+	// The previously enabled bundle becomes writable again, including this
+	// bundle when it is enabled again, until MountReadOnly is called
+	if err := b.unmountEnabled(); err != nil {
+		return "", err
+	}
+
 	err := b.fs.MkdirAll(filepath.Dir(b.enablePath), b.fileMode)
 	if err != nil {
 		return "", bosherr.WrapError(err, "failed to create enable dir")
@@ -182,9 +357,306 @@ func (b FileBundle) Disable() error {
 		return bosherr.WrapError(err, "Determining absolute path")
 	}
 
-	if targetAbsPath == installAbsPath {
-		return b.fs.RemoveAll(b.enablePath)
+	if targetAbsPath != installAbsPath {
+		return nil
+	}
+
+	// Other versions may link to the same stored contents, so the enabled
+	// version has to link to this install path itself
+	if _, isStored := b.storedPath(); isStored {
+		linkTarget, err := b.fs.Readlink(b.enablePath)
+		if err != nil {
+			return bosherr.WrapError(err, "Reading symlink")
+		}
+
+		linkTargetAbsPath, err := filepath.Abs(linkTarget)
+		if err != nil {
+			return bosherr.WrapError(err, "Determining absolute path")
+		}
+
+		ownAbsPath, err := filepath.Abs(b.installPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Determining absolute path")
+		}
+
+		if linkTargetAbsPath != ownAbsPath {
+			return nil
+		}
+	}
+
+	if err := b.unmount(installPath); err != nil {
+		return err
+	}
+	return b.fs.RemoveAll(b.enablePath)
+}
+
+// MountReadOnly bind mounts the installed bundle read-only onto itself so
+// that running jobs cannot modify it. It does nothing unless the bundle was
+// created with a mounter.
+func (b FileBundle) MountReadOnly() error {
+	if b.mounter == nil {
+		return nil
+	}
+
+	b.logger.Debug(fileBundleLogTag, "Mounting read-only %v", b)
+
+	// Mount points are listed by the path the install path links to
+	installPath, err := b.fs.ReadAndFollowLink(b.installPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading symlink")
+	}
+
+	_, isMountPoint, err := b.mounter.IsMountPoint(installPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking if bundle is mounted")
+	}
+
+	if isMountPoint {
+		return nil
+	}
+
+	err = b.mounter.Mount(installPath, installPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Bind mounting bundle")
+	}
+
+	err = b.mounter.RemountInPlace(installPath, "bind", "ro")
+	if err != nil {
+		_, _ = b.mounter.Unmount(installPath) //nolint:errcheck
+		return bosherr.WrapError(err, "Remounting bundle read-only")
+	}
+
+	return nil
+}
+
+func (b FileBundle) unmountEnabled() error {
+	if b.mounter == nil {
+		return nil
+	}
+
+	target, err := b.fs.ReadAndFollowLink(b.enablePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return bosherr.WrapError(err, "Reading symlink")
+	}
+
+	return b.unmount(target)
+}
+
+func (b FileBundle) unmount(installPath string) error {
+	if b.mounter == nil {
+		return nil
+	}
+
+	_, isMountPoint, err := b.mounter.IsMountPoint(installPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking if bundle is mounted")
+	}
+
+	if !isMountPoint {
+		return nil
+	}
+
+	_, err = b.mounter.Unmount(installPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmounting read-only bundle")
 	}
 
 	return nil
 }
+
+// collectionStorePath is the store of the collection the bundle belongs to,
+// e.g. /var/vcap/data/packages/.store for /var/vcap/data/packages/NAME/VERSION
+func (b FileBundle) collectionStorePath() string {
+	return path.Join(path.Dir(path.Dir(b.installPath)), storeDirName)
+}
+
+// storePath holds the contents of the versions of the bundle, e.g.
+// /var/vcap/data/packages/.store/NAME
+func (b FileBundle) storePath() string {
+	return path.Join(b.collectionStorePath(), path.Base(path.Dir(b.installPath)))
+}
+
+func (b FileBundle) stagingPath() string {
+	return path.Join(b.storePath(), path.Base(b.installPath)+stagingDirSuffix)
+}
+
+func (b FileBundle) ContentDigest() string {
+	storedPath, stored := b.storedPath()
+	if !stored {
+		return ""
+	}
+
+	return path.Base(storedPath)
+}
+
+// storedPath returns the stored contents the install path links to. Bundles
+// installed without contents or by earlier agent versions are plain
+// directories instead.
+func (b FileBundle) storedPath() (string, bool) {
+	target, err := b.fs.Readlink(b.installPath)
+	if err != nil {
+		return "", false
+	}
+
+	target = filepath.ToSlash(target)
+	if path.Dir(target) != filepath.ToSlash(b.storePath()) {
+		return "", false
+	}
+
+	return target, true
+}
+
+// removeStoredContents removes the stored contents unless other installed
+// versions of the bundle still link to them. They are only unmounted then
+// since a version sharing them may be enabled.
+func (b FileBundle) removeStoredContents(storedPath string) error {
+	versionPaths, err := b.fs.Glob(path.Join(path.Dir(b.installPath), "*"))
+	if err != nil {
+		return bosherr.WrapError(err, "Globbing bundles")
+	}
+
+	for _, versionPath := range versionPaths {
+		target, err := b.fs.Readlink(versionPath)
+		if err != nil {
+			// Not a link, e.g. recorded digests
+			continue
+		}
+
+		if filepath.ToSlash(target) == storedPath {
+			return nil
+		}
+	}
+
+	if err := b.unmount(storedPath); err != nil {
+		return err
+	}
+
+	return b.fs.RemoveAll(storedPath)
+}
+
+func (b FileBundle) digestsPath() string {
+	return b.installPath + digestsFileSuffix
+}
+
+func (b FileBundle) recordDigests(installPath string) error {
+	digests, err := b.computeDigests(installPath)
+	if err != nil {
+		return err
+	}
+
+	contents, err := json.Marshal(digests)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling digests")
+	}
+
+	return b.fs.WriteFile(b.digestsPath(), contents)
+}
+
+// computeContentDigest identifies the contents below installPath by the
+// names, types and permissions of all entries, the digests of regular files
+// and the targets of symlinks.
+func (b FileBundle) computeContentDigest(installPath string) (string, error) {
+	fileDigests, err := b.computeDigests(installPath)
+	if err != nil {
+		return "", err
+	}
+
+	var manifest strings.Builder
+
+	err = b.fs.Walk(installPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(installPath, filePath)
+		if err != nil {
+			return err
+		}
+
+		relPath = filepath.ToSlash(relPath)
+		if relPath == "." {
+			return nil
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := b.fs.Readlink(filePath)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&manifest, "link %s %s\n", relPath, target)
+		case info.IsDir():
+			fmt.Fprintf(&manifest, "dir %s %o\n", relPath, info.Mode().Perm())
+		case info.Mode().IsRegular():
+			// Files only differing in their extended attributes, e.g. file
+			// capabilities, must not share stored contents
+			xattrs, err := readXattrs(filePath)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Reading extended attributes of '%s'", filePath)
+			}
+			if len(xattrs) > 0 {
+				fmt.Fprintf(&manifest, "file %s %o %s %s\n", relPath, info.Mode().Perm(), fileDigests[relPath], strings.Join(xattrs, " "))
+			} else {
+				fmt.Fprintf(&manifest, "file %s %o %s\n", relPath, info.Mode().Perm(), fileDigests[relPath])
+			}
+		default:
+			fmt.Fprintf(&manifest, "other %s %s\n", relPath, info.Mode().String())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", bosherr.WrapError(err, "Computing digest of bundle contents")
+	}
+
+	digest, err := boshcrypto.DigestAlgorithmSHA1.CreateDigest(strings.NewReader(manifest.String()))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Computing digest of bundle contents")
+	}
+
+	return digest.String(), nil
+}
+
+// computeDigests returns the digests of all regular files below installPath
+// keyed by their path relative to it
+func (b FileBundle) computeDigests(installPath string) (map[string]string, error) {
+	digests := map[string]string{}
+
+	err := b.fs.Walk(installPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(installPath, filePath)
+		if err != nil {
+			return err
+		}
+
+		file, err := b.fs.OpenFile(filePath, os.O_RDONLY, 0)
+		if err != nil {
+			return err
+		}
+		defer file.Close() //nolint:errcheck
+
+		digest, err := boshcrypto.DigestAlgorithmSHA256.CreateDigest(file)
+		if err != nil {
+			return err
+		}
+
+		digests[filepath.ToSlash(relPath)] = digest.String()
+
+		return nil
+	})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Computing digests of bundle files")
+	}
+
+	return digests, nil
+}
@@ -14,6 +14,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/tarpath"
+	boshdisk "github.com/cloudfoundry/bosh-agent/v2/platform/disk"
 )
 
 const fileBundleCollectionLogTag = "FileBundleCollection"
@@ -53,15 +54,22 @@ type FileBundleCollection struct {
 	fs           boshsys.FileSystem
 	timeProvider clock.Clock
 	compressor   fileutil.Compressor
+	mounter      boshdisk.Mounter
+	quota        uint64
 	logger       boshlog.Logger
 }
 
+// NewFileBundleCollection only mounts its bundles read-only when mounter is
+// not nil. Installs fail once the stored contents of the collection would
+// exceed quota bytes, unless quota is zero.
 func NewFileBundleCollection(
 	installPath, enablePath, name string,
 	fileMode os.FileMode,
 	fs boshsys.FileSystem,
 	timeProvider clock.Clock,
 	compressor fileutil.Compressor,
+	mounter boshdisk.Mounter,
+	quota uint64,
 	logger boshlog.Logger,
 ) FileBundleCollection {
 	return FileBundleCollection{
@@ -72,6 +80,8 @@ func NewFileBundleCollection(
 		fs:           fs,
 		timeProvider: timeProvider,
 		compressor:   compressor,
+		mounter:      mounter,
+		quota:        quota,
 		logger:       logger,
 	}
 }
@@ -93,7 +103,7 @@ func (bc FileBundleCollection) Get(definition BundleDefinition) (Bundle, error)
 	installPath := path.Join(bc.installPath, bc.name, definition.BundleName(), bundleVersionDigest.String())
 	enablePath := path.Join(bc.enablePath, bc.name, definition.BundleName())
 
-	return NewFileBundle(installPath, enablePath, bc.fileMode, bc.fs, bc.timeProvider, bc.compressor, tarpath.NewPrefixDetector(), bc.logger), nil
+	return NewFileBundle(installPath, enablePath, bc.fileMode, bc.fs, bc.timeProvider, bc.compressor, tarpath.NewPrefixDetector(), bc.mounter, bc.quota, bc.logger), nil
 }
 
 func (bc FileBundleCollection) getDigested(definition BundleDefinition) (Bundle, error) {
@@ -107,7 +117,7 @@ func (bc FileBundleCollection) getDigested(definition BundleDefinition) (Bundle,
 
 	installPath := path.Join(bc.installPath, bc.name, definition.BundleName(), definition.BundleVersion())
 	enablePath := path.Join(bc.enablePath, bc.name, definition.BundleName())
-	return NewFileBundle(installPath, enablePath, bc.fileMode, bc.fs, bc.timeProvider, bc.compressor, tarpath.NewPrefixDetector(), bc.logger), nil
+	return NewFileBundle(installPath, enablePath, bc.fileMode, bc.fs, bc.timeProvider, bc.compressor, tarpath.NewPrefixDetector(), bc.mounter, bc.quota, bc.logger), nil
 }
 
 func (bc FileBundleCollection) List() ([]Bundle, error) {
@@ -118,6 +128,10 @@ func (bc FileBundleCollection) List() ([]Bundle, error) {
 
 	bundles := make([]Bundle, 0, len(bundleInstallPaths))
 	for _, path := range bundleInstallPaths {
+		if strings.HasSuffix(path, digestsFileSuffix) || filepath.Base(filepath.Dir(path)) == storeDirName {
+			continue
+		}
+
 		bundle, err := bc.getDigested(newFileBundleDefinition(path))
 		if err != nil {
 			return bundles, bosherr.WrapError(err, "Getting bundle")
@@ -46,6 +46,8 @@ var _ = Describe("FileBundleCollection", func() {
 			fs,
 			fakeClock,
 			fakeCompressor,
+			nil,
+			0,
 			logger,
 		)
 	})
@@ -68,6 +70,8 @@ var _ = Describe("FileBundleCollection", func() {
 				fakeClock,
 				fakeCompressor,
 				tarpath.NewPrefixDetector(),
+				nil,
+				0,
 				logger,
 			)
 
@@ -98,6 +102,8 @@ var _ = Describe("FileBundleCollection", func() {
 		It("returns list of installed bundles", func() {
 			fs.SetGlob(installPath+"/*/*", []string{
 				installPath + "/fake-bundle-1-name/fake-bundle-1-version-1",
+				installPath + "/fake-bundle-1-name/fake-bundle-1-version-1.digests",
+				installPath + "/.store/fake-content-digest",
 				installPath + "/fake-bundle-1-name/fake-bundle-1-version-2",
 				installPath + "/fake-bundle-2-name/fake-bundle-2-version-1",
 			})
@@ -114,6 +120,8 @@ var _ = Describe("FileBundleCollection", func() {
 					fakeClock,
 					fakeCompressor,
 					tarpath.NewPrefixDetector(),
+					nil,
+					0,
 					logger,
 				),
 				NewFileBundle(
@@ -124,6 +132,8 @@ var _ = Describe("FileBundleCollection", func() {
 					fakeClock,
 					fakeCompressor,
 					tarpath.NewPrefixDetector(),
+					nil,
+					0,
 					logger,
 				),
 				NewFileBundle(
@@ -134,6 +144,8 @@ var _ = Describe("FileBundleCollection", func() {
 					fakeClock,
 					fakeCompressor,
 					tarpath.NewPrefixDetector(),
+					nil,
+					0,
 					logger,
 				),
 			}
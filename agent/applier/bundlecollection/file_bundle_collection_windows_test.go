@@ -38,6 +38,8 @@ var _ = Describe("FileBundleCollection", func() {
 			fs,
 			fakeClock,
 			fakeCompressor,
+			nil,
+			0,
 			logger,
 		)
 	})
@@ -60,6 +62,8 @@ var _ = Describe("FileBundleCollection", func() {
 				fakeClock,
 				fakeCompressor,
 				tarpath.NewPrefixDetector(),
+				nil,
+				0,
 				logger,
 			)
 
@@ -106,6 +110,8 @@ var _ = Describe("FileBundleCollection", func() {
 					fakeClock,
 					fakeCompressor,
 					tarpath.NewPrefixDetector(),
+					nil,
+					0,
 					logger,
 				),
 				NewFileBundle(
@@ -116,6 +122,8 @@ var _ = Describe("FileBundleCollection", func() {
 					fakeClock,
 					fakeCompressor,
 					tarpath.NewPrefixDetector(),
+					nil,
+					0,
 					logger,
 				),
 				NewFileBundle(
@@ -126,6 +134,8 @@ var _ = Describe("FileBundleCollection", func() {
 					fakeClock,
 					fakeCompressor,
 					tarpath.NewPrefixDetector(),
+					nil,
+					0,
 					logger,
 				),
 			}
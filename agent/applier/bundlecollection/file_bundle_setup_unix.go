@@ -3,10 +3,36 @@
 
 package bundlecollection
 
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
 func (b FileBundle) Uninstall() error {
 	b.logger.Debug(fileBundleLogTag, "Uninstalling %v", b)
 
+	storedPath, isStored := b.storedPath()
+
+	if !isStored {
+		if err := b.unmount(b.installPath); err != nil {
+			return err
+		}
+	}
+
+	if err := b.fs.RemoveAll(b.digestsPath()); err != nil {
+		return bosherr.WrapError(err, "Removing recorded digests")
+	}
+
 	// RemoveAll MUST be the last possibly-failing operation
 	// because IsInstalled() relies on installPath presence.
-	return b.fs.RemoveAll(b.installPath)
+	if err := b.fs.RemoveAll(b.installPath); err != nil {
+		return err
+	}
+
+	if isStored {
+		if err := b.removeStoredContents(storedPath); err != nil {
+			b.logger.Warn(fileBundleLogTag, "Failed to remove stored contents '%s': %s", storedPath, err.Error())
+		}
+	}
+
+	return nil
 }
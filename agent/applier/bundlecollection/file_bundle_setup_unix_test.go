@@ -4,6 +4,7 @@
 package bundlecollection_test
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent/tarpath/tarpathfakes"
 
 	. "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/disk/diskfakes"
 )
 
 var _ = Describe("FileBundle", func() {
@@ -49,6 +51,8 @@ var _ = Describe("FileBundle", func() {
 			fakeClock,
 			fakeCompressor,
 			fakeDetector,
+			nil,
+			0,
 			logger,
 		)
 	})
@@ -92,4 +96,126 @@ var _ = Describe("FileBundle", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(installed).To(BeFalse(), "Bundle was not uninstalled")
 	})
+
+	Describe("read-only mounts", func() {
+		var (
+			mounter    *diskfakes.FakeMounter
+			storedPath string
+		)
+
+		BeforeEach(func() {
+			mounter = &diskfakes.FakeMounter{}
+			fileBundle = NewFileBundle(
+				installPath,
+				enablePath,
+				os.FileMode(0750),
+				fs,
+				fakeClock,
+				fakeCompressor,
+				fakeDetector,
+				mounter,
+				0,
+				logger,
+			)
+
+			_, err := fileBundle.Install(sourcePath, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			storedPath, err = fs.ReadAndFollowLink(installPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			mounter.IsMountPointStub = func(path string) (string, bool, error) {
+				return "", mounter.MountCallCount() > mounter.UnmountCallCount(), nil
+			}
+		})
+
+		It("bind mounts the stored contents of the bundle read-only onto themselves", func() {
+			err := fileBundle.MountReadOnly()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(mounter.MountCallCount()).To(Equal(1))
+			partitionPath, mountPoint, _ := mounter.MountArgsForCall(0)
+			Expect(partitionPath).To(Equal(storedPath))
+			Expect(mountPoint).To(Equal(storedPath))
+
+			Expect(mounter.RemountInPlaceCallCount()).To(Equal(1))
+			mountPoint, mountOptions := mounter.RemountInPlaceArgsForCall(0)
+			Expect(mountPoint).To(Equal(storedPath))
+			Expect(mountOptions).To(Equal([]string{"bind", "ro"}))
+		})
+
+		It("does not mount the bundle again", func() {
+			Expect(fileBundle.MountReadOnly()).To(Succeed())
+			Expect(fileBundle.MountReadOnly()).To(Succeed())
+
+			Expect(mounter.MountCallCount()).To(Equal(1))
+		})
+
+		It("unmounts the bundle again when remounting it read-only fails", func() {
+			mounter.RemountInPlaceReturns(errors.New("fake-remount-error"))
+
+			err := fileBundle.MountReadOnly()
+			Expect(err).To(MatchError(ContainSubstring("fake-remount-error")))
+			Expect(mounter.UnmountCallCount()).To(Equal(1))
+		})
+
+		It("makes the previously enabled bundle writable when enabling", func() {
+			_, err := fileBundle.Enable()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileBundle.MountReadOnly()).To(Succeed())
+
+			_, err = fileBundle.Enable()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(mounter.UnmountCallCount()).To(Equal(1))
+			Expect(mounter.UnmountArgsForCall(0)).To(Equal(storedPath))
+		})
+
+		It("unmounts the bundle when disabling it", func() {
+			_, err := fileBundle.Enable()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileBundle.MountReadOnly()).To(Succeed())
+
+			err = fileBundle.Disable()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(mounter.UnmountCallCount()).To(Equal(1))
+			Expect(fs.FileExists(enablePath)).To(BeFalse())
+		})
+
+		It("unmounts the stored contents before removing them", func() {
+			Expect(fileBundle.MountReadOnly()).To(Succeed())
+
+			err := fileBundle.Uninstall()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(mounter.UnmountCallCount()).To(Equal(1))
+			Expect(mounter.UnmountArgsForCall(0)).To(Equal(storedPath))
+			Expect(fs.FileExists(storedPath)).To(BeFalse())
+		})
+
+		It("keeps the stored contents when they cannot be unmounted", func() {
+			Expect(fileBundle.MountReadOnly()).To(Succeed())
+
+			mounter.UnmountReturns(false, errors.New("fake-unmount-error"))
+
+			err := fileBundle.Uninstall()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.FileExists(installPath)).To(BeFalse())
+			Expect(fs.FileExists(storedPath)).To(BeTrue())
+		})
+
+		It("unmounts bundles installed without contents before uninstalling them", func() {
+			Expect(fileBundle.Uninstall()).To(Succeed())
+			_, err := fileBundle.InstallWithoutContents()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileBundle.MountReadOnly()).To(Succeed())
+
+			mounter.UnmountReturns(false, errors.New("fake-unmount-error"))
+
+			err = fileBundle.Uninstall()
+			Expect(err).To(MatchError(ContainSubstring("fake-unmount-error")))
+			Expect(fs.FileExists(installPath)).To(BeTrue())
+		})
+	})
 })
@@ -2,6 +2,8 @@ package bundlecollection
 
 import (
 	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 const BundleSetupTimeout = 2 * time.Minute
@@ -9,19 +11,35 @@ const BundleSetupTimeout = 2 * time.Minute
 func (b FileBundle) Uninstall() error {
 	b.logger.Debug(fileBundleLogTag, "Uninstalling %v", b)
 
+	storedPath, isStored := b.storedPath()
+
 	// RemoveAll MUST be the last possibly-failing operation
 	// because IsInstalled() relies on installPath presence.
 
-	var err error
+	err := b.fs.RemoveAll(b.digestsPath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing recorded digests")
+	}
+
 	startTime := b.timeProvider.Now()
 
 	for b.timeProvider.Since(startTime) < BundleSetupTimeout {
 		err = b.fs.RemoveAll(b.installPath)
 		if err == nil {
-			return nil
+			break
 		}
 		b.timeProvider.Sleep(time.Second * 5)
 	}
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	if isStored {
+		if err := b.removeStoredContents(storedPath); err != nil {
+			b.logger.Warn(fileBundleLogTag, "Failed to remove stored contents '%s': %s", storedPath, err.Error())
+		}
+	}
+
+	return nil
 }
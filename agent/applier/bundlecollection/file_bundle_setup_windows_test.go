@@ -48,6 +48,8 @@ var _ = Describe("FileBundle uninstallation", func() {
 			fakeClock,
 			fakeCompressor,
 			fakeDetector,
+			nil,
+			0,
 			logger,
 		)
 	})
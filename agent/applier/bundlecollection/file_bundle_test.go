@@ -4,10 +4,12 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	fakefileutil "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -19,6 +21,21 @@ import (
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fakes/fake_clock.go code.cloudfoundry.org/clock.Clock
 
+// racingFileSystem fails to move staged contents into the store because
+// another install stored identical contents there first
+type racingFileSystem struct {
+	*fakesys.FakeFileSystem
+}
+
+func (fs racingFileSystem) Rename(_, newPath string) error {
+	err := fs.MkdirAll(newPath, os.FileMode(0750))
+	if err != nil {
+		return err
+	}
+
+	return errors.New("fake-rename-error: directory not empty")
+}
+
 var _ = Describe("FileBundle", func() {
 	var (
 		fs             *fakesys.FakeFileSystem
@@ -48,6 +65,8 @@ var _ = Describe("FileBundle", func() {
 			fakeClock,
 			fakeCompressor,
 			fakeDetector,
+			nil,
+			0,
 			logger,
 		)
 	})
@@ -67,6 +86,16 @@ var _ = Describe("FileBundle", func() {
 		sourcePath = createSourcePath()
 	})
 
+	storedPath := func() string {
+		path, err := fs.ReadAndFollowLink(installPath)
+		Expect(err).ToNot(HaveOccurred())
+		return path
+	}
+
+	decompressPath := func() string {
+		return fakeCompressor.DecompressFileToDirDirs[len(fakeCompressor.DecompressFileToDirDirs)-1]
+	}
+
 	Describe("InstallWithoutContents", func() {
 		It("installs the bundle at the given path with the correct permissions", func() {
 			path, err := fileBundle.InstallWithoutContents()
@@ -116,7 +145,7 @@ var _ = Describe("FileBundle", func() {
 			_, err = fileBundle.Install(sourcePath, "")
 			Expect(err).NotTo(HaveOccurred())
 
-			fileStats := fs.GetFileTestStat(installPath)
+			fileStats := fs.GetFileTestStat(storedPath())
 			Expect(fileStats).ToNot(BeNil())
 			Expect(fileStats.FileType).To(Equal(fakesys.FakeFileTypeDir))
 			Expect(fileStats.FileMode).To(Equal(os.FileMode(0750)))
@@ -137,10 +166,9 @@ var _ = Describe("FileBundle", func() {
 	Describe("Install", func() {
 		It("installs the bundle from source at the given path", func() {
 			fakeCompressor.DecompressFileToDirCallBack = func() {
-				decompressPath := fakeCompressor.DecompressFileToDirDirs[len(fakeCompressor.DecompressFileToDirDirs)-1]
 				contents, err := fs.ReadFileString(filepath.Join(sourcePath, "config.go"))
 				Expect(err).NotTo(HaveOccurred())
-				err = fs.WriteFileString(filepath.Join(decompressPath, "config.go"), contents)
+				err = fs.WriteFileString(filepath.Join(decompressPath(), "config.go"), contents)
 				Expect(err).ToNot(HaveOccurred())
 			}
 
@@ -156,7 +184,7 @@ var _ = Describe("FileBundle", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(installed).To(BeTrue(), "Bundle not installed")
 
-			contents, err := fs.ReadFileString(filepath.Join(path, "config.go"))
+			contents, err := fs.ReadFileString(filepath.Join(storedPath(), "config.go"))
 			Expect(err).NotTo(HaveOccurred())
 			Expect(contents).To(Equal("package go"))
 		})
@@ -212,7 +240,7 @@ var _ = Describe("FileBundle", func() {
 			_, err = fileBundle.Install(sourcePath, "")
 			Expect(err).NotTo(HaveOccurred())
 
-			fileStats := fs.GetFileTestStat(installPath)
+			fileStats := fs.GetFileTestStat(storedPath())
 			Expect(fileStats).ToNot(BeNil())
 			Expect(fileStats.FileType).To(Equal(fakesys.FakeFileTypeDir))
 			Expect(fileStats.FileMode).To(Equal(os.FileMode(0750)))
@@ -234,6 +262,180 @@ var _ = Describe("FileBundle", func() {
 				Expect(opts.StripComponents).To(Equal(2))
 			})
 		})
+
+		It("records the digests of the installed files", func() {
+			fakeCompressor.DecompressFileToDirCallBack = func() {
+				err := fs.WriteFileString(filepath.Join(decompressPath(), "bin", "run"), "run")
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := fileBundle.Install(sourcePath, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			digests, err := fs.ReadFileString(installPath + ".digests")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digests).To(MatchJSON(`{"bin/run": "sha256:acba25512100f80b56fc3ccd14c65be55d94800cda77585c5f41a887e398f9be"}`))
+		})
+	})
+
+	Describe("sharing stored contents", func() {
+		var otherBundle FileBundle
+
+		BeforeEach(func() {
+			installPath = "/data/jobs/job-name/version-1"
+			fileBundle = NewFileBundle(installPath, enablePath, os.FileMode(0750), fs, fakeClock, fakeCompressor, fakeDetector, nil, 0, logger)
+			otherBundle = NewFileBundle("/data/jobs/job-name/version-2", enablePath, os.FileMode(0750), fs, fakeClock, fakeCompressor, fakeDetector, nil, 0, logger)
+
+			contents := "run"
+			fakeCompressor.DecompressFileToDirCallBack = func() {
+				err := fs.WriteFileString(filepath.Join(decompressPath(), "bin", "run"), contents)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := fileBundle.Install(sourcePath, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		installOtherBundle := func(contents string) string {
+			fakeCompressor.DecompressFileToDirCallBack = func() {
+				err := fs.WriteFileString(filepath.Join(decompressPath(), "bin", "run"), contents)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := otherBundle.Install(sourcePath, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			path, err := fs.ReadAndFollowLink("/data/jobs/job-name/version-2")
+			Expect(err).NotTo(HaveOccurred())
+			return path
+		}
+
+		It("stores the contents by their digest and links the install path to them", func() {
+			Expect(fs.GetFileTestStat(installPath).FileType).To(Equal(fakesys.FakeFileTypeSymlink))
+			Expect(filepath.ToSlash(filepath.Dir(storedPath()))).To(HaveSuffix("/data/jobs/.store/job-name"))
+			Expect(fs.FileExists("/data/jobs/.store/job-name/version-1.staging")).To(BeFalse())
+		})
+
+		It("links versions with identical contents to the same stored contents", func() {
+			Expect(installOtherBundle("run")).To(Equal(storedPath()))
+			Expect(fs.FileExists("/data/jobs/.store/job-name/version-2.staging")).To(BeFalse())
+		})
+
+		It("does not share stored contents with other bundles", func() {
+			otherJob := NewFileBundle("/data/jobs/other-job-name/version-1", enablePath, os.FileMode(0750), fs, fakeClock, fakeCompressor, fakeDetector, nil, 0, logger)
+
+			_, err := otherJob.Install(sourcePath, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			otherJobPath, err := fs.ReadAndFollowLink("/data/jobs/other-job-name/version-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(otherJobPath).ToNot(Equal(storedPath()))
+			Expect(filepath.Base(otherJobPath)).To(Equal(filepath.Base(storedPath())))
+		})
+
+		It("reuses the contents another install stored while it was staging", func() {
+			racingFs := racingFileSystem{FakeFileSystem: fs}
+			racingBundle := NewFileBundle("/data/jobs/job-name/version-3", enablePath, os.FileMode(0750), racingFs, fakeClock, fakeCompressor, fakeDetector, nil, 0, logger)
+
+			fakeCompressor.DecompressFileToDirCallBack = func() {
+				err := fs.WriteFileString(filepath.Join(decompressPath(), "bin", "run"), "changed")
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := racingBundle.Install(sourcePath, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			path, err := fs.ReadAndFollowLink("/data/jobs/job-name/version-3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).ToNot(Equal(storedPath()))
+			Expect(fs.FileExists(path)).To(BeTrue())
+			Expect(fs.FileExists("/data/jobs/.store/job-name/version-3.staging")).To(BeFalse())
+		})
+
+		It("stores versions with different contents separately", func() {
+			Expect(installOtherBundle("changed")).ToNot(Equal(storedPath()))
+		})
+
+		It("keeps stored contents that other versions still link to when uninstalling", func() {
+			sharedPath := installOtherBundle("run")
+			fs.SetGlob("/data/jobs/job-name/*", []string{"/data/jobs/job-name/version-2", "/data/jobs/job-name/version-2.digests"})
+
+			err := fileBundle.Uninstall()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.FileExists(installPath)).To(BeFalse())
+			Expect(fs.FileExists(sharedPath)).To(BeTrue())
+		})
+
+		It("removes stored contents once no version links to them", func() {
+			path := storedPath()
+
+			err := fileBundle.Uninstall()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.FileExists(path)).To(BeFalse())
+		})
+
+		It("does not disable another version that shares the stored contents", func() {
+			installOtherBundle("run")
+
+			_, err := otherBundle.Enable()
+			Expect(err).NotTo(HaveOccurred())
+
+			err = fileBundle.Disable()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.FileExists(enablePath)).To(BeTrue())
+
+			err = otherBundle.Disable()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.FileExists(enablePath)).To(BeFalse())
+		})
+	})
+
+	Describe("enforcing the disk quota of the collection", func() {
+		installVersion := func(version, contents string, quota uint64) error {
+			bundle := NewFileBundle("/data/jobs/job-name/"+version, enablePath, os.FileMode(0750), fs, fakeClock, fakeCompressor, fakeDetector, nil, quota, logger)
+
+			fakeCompressor.DecompressFileToDirCallBack = func() {
+				err := fs.WriteFileString(filepath.Join(decompressPath(), "bin", "run"), contents)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := bundle.Install(sourcePath, "")
+			return err
+		}
+
+		BeforeEach(func() {
+			err := installVersion("version-1", "12345", 0)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("installs bundles as long as the stored contents stay within the quota", func() {
+			err := installVersion("version-2", "67890", 10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.FileExists("/data/jobs/job-name/version-2")).To(BeTrue())
+		})
+
+		It("refuses to install bundles that would exceed the quota and removes their staged contents", func() {
+			err := installVersion("version-2", "678901", 10)
+			Expect(err).To(MatchError(QuotaExceededError{Collection: "/data/jobs", Quota: 10, Used: 11}))
+			Expect(IsQuotaExceeded(bosherr.WrapError(err, "Installing job"))).To(BeTrue())
+
+			Expect(fs.FileExists("/data/jobs/job-name/version-2")).To(BeFalse())
+			Expect(fs.FileExists("/data/jobs/.store/job-name/version-2.staging")).To(BeFalse())
+		})
+
+		It("counts the stored contents of all bundles in the collection", func() {
+			bundle := NewFileBundle("/data/jobs/other-job-name/version-1", enablePath, os.FileMode(0750), fs, fakeClock, fakeCompressor, fakeDetector, nil, 9, logger)
+
+			_, err := bundle.Install(sourcePath, "")
+			Expect(err).To(MatchError(QuotaExceededError{Collection: "/data/jobs", Quota: 9, Used: 10}))
+		})
+
+		It("installs bundles that share stored contents regardless of the quota", func() {
+			err := installVersion("version-2", "12345", 1)
+			Expect(err).NotTo(HaveOccurred())
+		})
 	})
 
 	Describe("GetInstallPath", func() {
@@ -269,6 +471,82 @@ var _ = Describe("FileBundle", func() {
 		})
 	})
 
+	Describe("InstalledAt", func() {
+		It("returns the modification time of the install path link", func() {
+			_, err := fileBundle.Install(sourcePath, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			installedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+			fs.GetFileTestStat(installPath).ModTime = installedAt
+
+			actualInstalledAt, err := fileBundle.InstalledAt()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actualInstalledAt).To(Equal(installedAt))
+		})
+	})
+
+	Describe("ContentDigest", func() {
+		It("returns the digest the installed contents are stored by", func() {
+			_, err := fileBundle.Install(sourcePath, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileBundle.ContentDigest()).ToNot(BeEmpty())
+			Expect(fileBundle.ContentDigest()).To(Equal(filepath.Base(storedPath())))
+		})
+
+		It("returns an empty digest when installed without contents", func() {
+			_, err := fileBundle.InstallWithoutContents()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileBundle.ContentDigest()).To(BeEmpty())
+		})
+	})
+
+	Describe("Verify", func() {
+		BeforeEach(func() {
+			fakeCompressor.DecompressFileToDirCallBack = func() {
+				err := fs.WriteFileString(filepath.Join(decompressPath(), "bin", "run"), "run")
+				Expect(err).ToNot(HaveOccurred())
+				err = fs.WriteFileString(filepath.Join(decompressPath(), "lib", "lib.so"), "lib")
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := fileBundle.Install(sourcePath, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns no problems when the files are unchanged", func() {
+			problems, err := fileBundle.Verify()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+		})
+
+		It("returns modified, missing and unexpected files", func() {
+			err := fs.WriteFileString(filepath.Join(storedPath(), "bin", "run"), "tampered")
+			Expect(err).NotTo(HaveOccurred())
+			err = fs.RemoveAll(filepath.Join(storedPath(), "lib", "lib.so"))
+			Expect(err).NotTo(HaveOccurred())
+			err = fs.WriteFileString(filepath.Join(storedPath(), "bin", "backdoor"), "backdoor")
+			Expect(err).NotTo(HaveOccurred())
+
+			problems, err := fileBundle.Verify()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(Equal([]string{
+				"missing: lib/lib.so",
+				"modified: bin/run",
+				"unexpected: bin/backdoor",
+			}))
+		})
+
+		It("returns an error when no digests were recorded", func() {
+			err := fs.RemoveAll(installPath + ".digests")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = fileBundle.Verify()
+			Expect(err).To(Equal(ErrDigestsNotRecorded))
+		})
+	})
+
 	Describe("Enable", func() {
 		Context("when bundle is installed", func() {
 			BeforeEach(func() {
@@ -399,6 +677,8 @@ var _ = Describe("FileBundle", func() {
 					fakeClock,
 					fakeCompressor,
 					fakeDetector,
+					nil,
+					0,
 					logger,
 				)
 
@@ -441,6 +721,7 @@ var _ = Describe("FileBundle", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fs.FileExists(installPath)).To(BeFalse())
+			Expect(fs.FileExists(installPath + ".digests")).To(BeFalse())
 		})
 
 		It("is idempotent", func() {
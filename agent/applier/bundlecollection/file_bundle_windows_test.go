@@ -60,7 +60,7 @@ var _ = Describe("FileBundle", func() {
 		fakeCompressor = new(fakefileutil.FakeCompressor)
 		fakeDetector = &tarpathfakes.FakeDetector{}
 
-		fileBundle = NewFileBundle(installPath, enablePath, os.FileMode(0750), fs, fakeClock, fakeCompressor, fakeDetector, logger)
+		fileBundle = NewFileBundle(installPath, enablePath, os.FileMode(0750), fs, fakeClock, fakeCompressor, fakeDetector, nil, 0, logger)
 	})
 
 	createSourcePath := func() string {
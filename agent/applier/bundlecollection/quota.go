@@ -0,0 +1,80 @@
+package bundlecollection
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// QuotaExceededError is returned when installing a bundle would make the
+// stored contents of its collection exceed the collection's disk quota.
+type QuotaExceededError struct {
+	Collection string
+	Quota      uint64
+	Used       uint64
+}
+
+func (e QuotaExceededError) Error() string {
+	return fmt.Sprintf(
+		"Installing the bundle would use %d bytes in collection '%s' which exceeds its disk quota of %d bytes, uninstall unused bundles to make room",
+		e.Used, e.Collection, e.Quota,
+	)
+}
+
+// IsQuotaExceeded tells whether the cause of err is a QuotaExceededError.
+func IsQuotaExceeded(err error) bool {
+	for err != nil {
+		complexErr, ok := err.(bosherr.ComplexError)
+		if !ok {
+			var quotaErr QuotaExceededError
+			return errors.As(err, &quotaErr)
+		}
+
+		err = complexErr.Cause
+	}
+
+	return false
+}
+
+// checkQuota measures the store including the contents being staged. The
+// staged contents of concurrent installs count as well so that they cannot
+// exceed the quota together.
+func (b FileBundle) checkQuota() error {
+	if b.quota == 0 {
+		return nil
+	}
+
+	var used uint64
+
+	err := b.fs.Walk(b.collectionStorePath(), func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Concurrent installs move their staged contents in the meantime
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			used += uint64(info.Size())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Measuring stored bundle contents")
+	}
+
+	if used > b.quota {
+		return QuotaExceededError{
+			Collection: path.Dir(path.Dir(b.installPath)),
+			Quota:      b.quota,
+			Used:       used,
+		}
+	}
+
+	return nil
+}
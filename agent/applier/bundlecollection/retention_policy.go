@@ -0,0 +1,114 @@
+package bundlecollection
+
+import (
+	"path"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// RetentionPolicy decides which bundles stay installed after they are no
+// longer used, e.g. to roll back to them without downloading them again.
+// The zero value retains none of them.
+type RetentionPolicy struct {
+	keepVersions int
+	keepFor      time.Duration
+	timeProvider clock.Clock
+}
+
+// NewRetentionPolicy keeps the keepVersions most recent unused versions of
+// every bundle as well as all unused versions that were superseded less than
+// keepFor ago.
+func NewRetentionPolicy(keepVersions int, keepFor time.Duration, timeProvider clock.Clock) RetentionPolicy {
+	return RetentionPolicy{
+		keepVersions: keepVersions,
+		keepFor:      keepFor,
+		timeProvider: timeProvider,
+	}
+}
+
+type installedVersion struct {
+	bundle      Bundle
+	installedAt time.Time
+	unused      bool
+}
+
+// Expired returns the unused bundles that are not retained. A version is
+// superseded once the next more recent version of the same bundle was
+// installed.
+func (p RetentionPolicy) Expired(installed []Bundle, isUnused func(Bundle) bool) ([]Bundle, error) {
+	var expired []Bundle
+
+	if p.keepVersions <= 0 && p.keepFor <= 0 {
+		for _, bundle := range installed {
+			if isUnused(bundle) {
+				expired = append(expired, bundle)
+			}
+		}
+		return expired, nil
+	}
+
+	versionsByName := map[string][]installedVersion{}
+	var names []string
+
+	for _, bundle := range installed {
+		installPath, err := bundle.GetInstallPath()
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Getting bundle install path")
+		}
+
+		installedAt, err := bundle.InstalledAt()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Getting installation time of bundle '%s'", installPath)
+		}
+
+		name := path.Dir(installPath)
+		if _, found := versionsByName[name]; !found {
+			names = append(names, name)
+		}
+
+		versionsByName[name] = append(versionsByName[name], installedVersion{
+			bundle:      bundle,
+			installedAt: installedAt,
+			unused:      isUnused(bundle),
+		})
+	}
+
+	now := p.timeProvider.Now()
+
+	for _, name := range names {
+		versions := versionsByName[name]
+
+		sort.SliceStable(versions, func(i, j int) bool {
+			return versions[i].installedAt.After(versions[j].installedAt)
+		})
+
+		keptVersions := 0
+
+		for i, version := range versions {
+			if !version.unused {
+				continue
+			}
+
+			if keptVersions < p.keepVersions {
+				keptVersions++
+				continue
+			}
+
+			supersededAt := version.installedAt
+			if i > 0 {
+				supersededAt = versions[i-1].installedAt
+			}
+
+			if now.Sub(supersededAt) < p.keepFor {
+				continue
+			}
+
+			expired = append(expired, version.bundle)
+		}
+	}
+
+	return expired, nil
+}
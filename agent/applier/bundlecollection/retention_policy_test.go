@@ -0,0 +1,84 @@
+package bundlecollection_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection/fakes"
+)
+
+var _ = Describe("RetentionPolicy", func() {
+	var (
+		now       time.Time
+		current   *fakes.FakeBundle
+		previous  *fakes.FakeBundle
+		oldest    *fakes.FakeBundle
+		other     *fakes.FakeBundle
+		installed []Bundle
+		unused    map[Bundle]bool
+	)
+
+	buildBundle := func(installPath string, age time.Duration) *fakes.FakeBundle {
+		bundle := fakes.NewFakeBundle()
+		bundle.GetDirPath = installPath
+		bundle.InstalledAtTime = now.Add(-age)
+		return bundle
+	}
+
+	isUnused := func(bundle Bundle) bool {
+		return unused[bundle]
+	}
+
+	BeforeEach(func() {
+		now = time.Now()
+		current = buildBundle("/data/jobs/job/3", 1*time.Hour)
+		previous = buildBundle("/data/jobs/job/2", 5*time.Hour)
+		oldest = buildBundle("/data/jobs/job/1", 30*time.Hour)
+		other = buildBundle("/data/jobs/other-job/1", 30*time.Hour)
+
+		installed = []Bundle{oldest, current, other, previous}
+		unused = map[Bundle]bool{oldest: true, previous: true, other: true}
+	})
+
+	It("retains no unused bundles by default", func() {
+		expired, err := RetentionPolicy{}.Expired(installed, isUnused)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expired).To(ConsistOf(oldest, previous, other))
+	})
+
+	It("retains the most recent unused versions of every bundle", func() {
+		policy := NewRetentionPolicy(1, 0, fakeclock.NewFakeClock(now))
+
+		expired, err := policy.Expired(installed, isUnused)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expired).To(ConsistOf(oldest))
+	})
+
+	It("retains unused versions until they were superseded for long enough", func() {
+		policy := NewRetentionPolicy(0, 2*time.Hour, fakeclock.NewFakeClock(now))
+
+		expired, err := policy.Expired(installed, isUnused)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expired).To(ConsistOf(oldest, other))
+
+		policy = NewRetentionPolicy(0, 6*time.Hour, fakeclock.NewFakeClock(now))
+
+		expired, err = policy.Expired(installed, isUnused)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expired).To(ConsistOf(other))
+	})
+
+	It("returns error when the installation time cannot be determined", func() {
+		previous.InstalledAtErr = errors.New("fake-installed-at-error")
+
+		policy := NewRetentionPolicy(1, 0, fakeclock.NewFakeClock(now))
+
+		_, err := policy.Expired(installed, isUnused)
+		Expect(err).To(MatchError(ContainSubstring("fake-installed-at-error")))
+	})
+})
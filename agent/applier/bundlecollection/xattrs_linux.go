@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package bundlecollection
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXattrs returns the extended attributes of a file, e.g. its
+// security.capability, as name=hex value pairs sorted by name. Files on file
+// systems without extended attributes have none.
+func readXattrs(filePath string) ([]string, error) {
+	size, err := unix.Llistxattr(filePath, nil)
+	if err != nil || size == 0 {
+		return nil, ignoreUnsupportedXattrs(err)
+	}
+
+	buf := make([]byte, size)
+
+	size, err = unix.Llistxattr(filePath, buf)
+	if err != nil {
+		return nil, ignoreUnsupportedXattrs(err)
+	}
+
+	var xattrs []string
+
+	for _, name := range strings.Split(strings.TrimRight(string(buf[:size]), "\x00"), "\x00") {
+		valueSize, err := unix.Lgetxattr(filePath, name, nil)
+		if err != nil {
+			return nil, ignoreUnsupportedXattrs(err)
+		}
+
+		value := make([]byte, valueSize)
+
+		valueSize, err = unix.Lgetxattr(filePath, name, value)
+		if err != nil {
+			return nil, ignoreUnsupportedXattrs(err)
+		}
+
+		xattrs = append(xattrs, fmt.Sprintf("%s=%x", name, value[:valueSize]))
+	}
+
+	sort.Strings(xattrs)
+
+	return xattrs, nil
+}
+
+// ignoreUnsupportedXattrs also ignores files that are not on disk, e.g.
+// the ones of fake file systems
+func ignoreUnsupportedXattrs(err error) error {
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.ENOENT) || errors.Is(err, unix.ENODATA) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package bundlecollection
+
+func readXattrs(filePath string) ([]string, error) {
+	return nil, nil
+}
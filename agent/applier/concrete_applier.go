@@ -1,12 +1,19 @@
 package applier
 
 import (
+	"sync"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	"github.com/cloudfoundry/bosh-utils/work"
 
 	as "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	bc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/jobs"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/filewatcher"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
@@ -17,8 +24,26 @@ type concreteApplier struct {
 	packageApplier    packages.Applier
 	logrotateDelegate LogrotateDelegate
 	jobSupervisor     boshjobsuper.JobSupervisor
+	fileWatcher       filewatcher.Watcher
 	dirProvider       boshdirs.Provider
 	settings          boshsettings.Settings
+	retention         bc.RetentionPolicy
+	specService       as.V1Service
+	progressReporter  ProgressReporter
+
+	// lastApplied is the spec of the last successful apply. It is unset
+	// while applying so that jobs and packages of a failed apply are
+	// applied again.
+	lastApplied as.ApplySpec
+
+	// appliedPackages stands in for lastApplied after the agent restarted
+	appliedPackages appliedPackages
+
+	// applyLock makes operations on the installed jobs and packages wait
+	// for each other, e.g. a synchronous start for a running apply
+	applyLock sync.Mutex
+
+	gcLock sync.Mutex
 }
 
 func NewConcreteApplier(
@@ -26,20 +51,33 @@ func NewConcreteApplier(
 	packageApplier packages.Applier,
 	logrotateDelegate LogrotateDelegate,
 	jobSupervisor boshjobsuper.JobSupervisor,
+	fileWatcher filewatcher.Watcher,
 	dirProvider boshdirs.Provider,
 	settings boshsettings.Settings,
+	retention bc.RetentionPolicy,
+	specService as.V1Service,
+	progressReporter ProgressReporter,
+	fs boshsys.FileSystem,
 ) Applier {
 	return &concreteApplier{
 		jobApplier:        jobApplier,
 		packageApplier:    packageApplier,
 		logrotateDelegate: logrotateDelegate,
 		jobSupervisor:     jobSupervisor,
+		fileWatcher:       fileWatcher,
 		dirProvider:       dirProvider,
 		settings:          settings,
+		retention:         retention,
+		specService:       specService,
+		progressReporter:  progressReporter,
+		appliedPackages:   newAppliedPackages(fs, dirProvider.BoshDir()),
 	}
 }
 
 func (a *concreteApplier) Prepare(desiredApplySpec as.ApplySpec) error {
+	a.applyLock.Lock()
+	defer a.applyLock.Unlock()
+
 	tasks := make([]func() error, 0, len(desiredApplySpec.Jobs())+len(desiredApplySpec.Packages()))
 
 	pool := work.Pool{
@@ -50,6 +88,10 @@ func (a *concreteApplier) Prepare(desiredApplySpec as.ApplySpec) error {
 		job := job
 		tasks = append(tasks, func() error {
 			jobErr := a.jobApplier.Prepare(job)
+			if a.emergencyGCEnabled(jobErr) {
+				// Applying installs it after uninstalling unused bundles
+				return nil
+			}
 			if jobErr != nil {
 				return bosherr.WrapErrorf(jobErr, "Preparing job %s", job.Name)
 			}
@@ -61,6 +103,9 @@ func (a *concreteApplier) Prepare(desiredApplySpec as.ApplySpec) error {
 		pkg := pkg
 		tasks = append(tasks, func() error {
 			pkgErr := a.packageApplier.Prepare(pkg)
+			if a.emergencyGCEnabled(pkgErr) {
+				return nil
+			}
 			if pkgErr != nil {
 				return bosherr.WrapErrorf(pkgErr, "Preparing package %s", pkg.Name)
 			}
@@ -81,17 +126,112 @@ func (a *concreteApplier) Prepare(desiredApplySpec as.ApplySpec) error {
 	return nil
 }
 
-func (a *concreteApplier) Apply(desiredApplySpec as.ApplySpec) error {
-	err := a.jobSupervisor.RemoveAllJobs()
+func (a *concreteApplier) Apply(desiredApplySpec as.ApplySpec, cancel *boshtask.CancelSignal) error {
+	a.applyLock.Lock()
+	defer a.applyLock.Unlock()
+
+	// The current spec is only replaced once the desired one is applied
+	currentSpec, err := a.specService.Get()
 	if err != nil {
-		return bosherr.WrapError(err, "Removing all jobs")
+		return bosherr.WrapError(err, "Getting current spec")
+	}
+
+	// Jobs and packages that did not change since the last successful apply
+	// are still installed and enabled
+	changes := DiffSpecs(a.lastApplied, desiredApplySpec)
+	packageUnchanged := changes.PackageUnchanged
+
+	if a.lastApplied == nil {
+		fingerprints := a.appliedPackages.Fingerprints()
+		packageUnchanged = func(pkg models.Package) bool {
+			fingerprint, found := fingerprints[pkg.Name]
+			return found && fingerprint == packageFingerprint(pkg)
+		}
+	}
+
+	a.lastApplied = nil
+
+	err = a.appliedPackages.Forget()
+	if err != nil {
+		return err
 	}
 
 	jobs := desiredApplySpec.Jobs()
+
+	levels, err := jobLevels(jobs)
+	if err != nil {
+		return bosherr.WrapError(err, "Ordering jobs by their dependencies")
+	}
+
+	var changedJobs []models.Job
 	for _, job := range jobs {
-		err = a.jobApplier.Apply(job)
+		if !changes.JobUnchanged(job) {
+			changedJobs = append(changedJobs, job)
+		}
+	}
+
+	var changedPackages []models.Package
+	for _, pkg := range desiredApplySpec.Packages() {
+		if !packageUnchanged(pkg) {
+			changedPackages = append(changedPackages, pkg)
+		}
+	}
+
+	progress := newApplyProgress(a.progressReporter, len(changedJobs), len(changedPackages))
+	progress.Start(ApplyProgress{Step: ApplyStepStarting})
+
+	err = a.jobSupervisor.RemoveAllJobs()
+	if err != nil {
+		return bosherr.WrapError(err, "Removing all jobs")
+	}
+
+	// Jobs are only added to the job supervisor when configuring them, which
+	// happens one at a time in dependency order after applying
+	jobPool := work.Pool{
+		Count: a.settings.Env.GetJobParallel(),
+	}
+
+	// Jobs are enabled once the jobs they depend on are
+	for _, level := range levels {
+		err = cancel.Err()
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Applying job %s", job.Name)
+			return err
+		}
+
+		jobTasks := make([]func() error, 0, len(level))
+
+		for _, job := range level {
+			if changes.JobUnchanged(job) {
+				continue
+			}
+
+			job := job
+			jobTasks = append(jobTasks, func() error {
+				err := cancel.Err()
+				if err != nil {
+					return err
+				}
+
+				progress.Start(ApplyProgress{Step: ApplyStepApplyingJob, Job: job.Name})
+
+				jobErr := a.jobApplier.Apply(job)
+				if a.emergencyGCEnabled(jobErr) {
+					jobErr = a.retryAfterEmergencyGC(desiredApplySpec, jobErr, func() error {
+						return a.jobApplier.Apply(job)
+					})
+				}
+				if jobErr != nil {
+					return bosherr.WrapErrorf(jobErr, "Applying job %s", job.Name)
+				}
+
+				progress.Complete()
+				return nil
+			})
+		}
+
+		err = jobPool.ParallelDo(jobTasks...)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -100,43 +240,282 @@ func (a *concreteApplier) Apply(desiredApplySpec as.ApplySpec) error {
 		return bosherr.WrapError(err, "Failed removing job source blobs")
 	}
 
-	err = a.jobApplier.KeepOnly(desiredApplySpec.Jobs())
+	if a.settings.Env.Bosh.Agent.Settings.LazyPackages {
+		changedPackages, err = a.withoutDeferredPackages(jobs, changedPackages, progress)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Packages are independent of each other, so their downloads and
+	// extractions do not have to wait for one another
+	pool := work.Pool{
+		Count: *a.settings.Env.GetParallel(),
+	}
+
+	tasks := make([]func() error, 0, len(changedPackages))
+
+	for _, pkg := range changedPackages {
+		pkg := pkg
+		tasks = append(tasks, func() error {
+			err := cancel.Err()
+			if err != nil {
+				return err
+			}
+
+			progress.Start(ApplyProgress{Step: ApplyStepApplyingPackage, Package: pkg.Name})
+
+			pkgErr := a.packageApplier.Apply(pkg)
+			if a.emergencyGCEnabled(pkgErr) {
+				pkgErr = a.retryAfterEmergencyGC(desiredApplySpec, pkgErr, func() error {
+					return a.packageApplier.Apply(pkg)
+				})
+			}
+			if pkgErr != nil {
+				return bosherr.WrapErrorf(pkgErr, "Applying package %s", pkg.Name)
+			}
+
+			progress.Complete()
+			return nil
+		})
+	}
+
+	err = pool.ParallelDo(tasks...)
 	if err != nil {
-		return bosherr.WrapError(err, "Keeping only needed jobs")
+		return err
 	}
 
-	for _, pkg := range desiredApplySpec.Packages() {
-		err = a.packageApplier.Apply(pkg)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Applying package %s", pkg.Name)
-		}
+	// Jobs are not switched over to the desired spec once cancelled, so that
+	// rolling back only has to restore the previously applied bundles
+	err = cancel.Err()
+	if err != nil {
+		return err
 	}
 
-	err = a.packageApplier.KeepOnly(desiredApplySpec.Packages())
+	err = a.jobApplier.SwitchOver(jobs)
 	if err != nil {
-		return bosherr.WrapError(err, "Keeping only needed packages")
+		return bosherr.WrapError(err, "Switching over jobs")
 	}
 
+	progress.Start(ApplyProgress{Step: ApplyStepReloading})
+
 	err = a.jobSupervisor.Reload()
 	if err != nil {
 		return bosherr.WrapError(err, "Reloading jobSupervisor")
 	}
 
-	return a.setUpLogrotate(desiredApplySpec)
+	progress.Start(ApplyProgress{Step: ApplyStepCleaningUp})
+
+	// Previously applied bundles stay installed until the desired ones are
+	// running so that a failed apply can be rolled back without downloads
+	err = a.keepOnly(desiredApplySpec, a.retention, true)
+	if err != nil {
+		return err
+	}
+
+	err = a.removeJobDirectories(currentSpec.Jobs(), jobs)
+	if err != nil {
+		return err
+	}
+
+	err = a.setUpLogrotate(desiredApplySpec)
+	if err != nil {
+		return err
+	}
+
+	err = a.fileWatcher.Watch(desiredApplySpec.FileWatches())
+	if err != nil {
+		return bosherr.WrapError(err, "Watching job files")
+	}
+
+	err = a.appliedPackages.Remember(desiredApplySpec.Packages())
+	if err != nil {
+		return err
+	}
+
+	a.lastApplied = desiredApplySpec
+	progress.Done()
+
+	return nil
 }
 
-func (a *concreteApplier) ConfigureJobs(desiredApplySpec as.ApplySpec) error {
-	jobs := desiredApplySpec.Jobs()
-	for i := 0; i < len(jobs); i++ {
-		job := jobs[len(jobs)-1-i]
+// removeJobDirectories removes the declared directories of the current jobs
+// that are not desired anymore
+func (a *concreteApplier) removeJobDirectories(currentJobs, desiredJobs []models.Job) error {
+	desired := map[string]bool{}
+	for _, job := range desiredJobs {
+		desired[job.Name] = true
+	}
+
+	for _, job := range currentJobs {
+		if desired[job.Name] {
+			continue
+		}
+
+		err := a.jobApplier.RemoveDirectories(job)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing directories of job %s", job.Name)
+		}
+	}
+
+	return nil
+}
+
+// withoutDeferredPackages leaves out the packages only jobs use that are not
+// supervised. They count as applied for the progress.
+func (a *concreteApplier) withoutDeferredPackages(jobs []models.Job, pkgs []models.Package, progress *applyProgress) ([]models.Package, error) {
+	needed := map[string]bool{}
+
+	for _, job := range jobs {
+		supervised, err := a.jobApplier.Supervised(job)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Checking whether job %s is supervised", job.Name)
+		}
+
+		if supervised {
+			for _, pkg := range job.Packages {
+				needed[pkg.Name] = true
+			}
+		}
+	}
+
+	var neededPkgs []models.Package
+
+	for _, pkg := range pkgs {
+		if needed[pkg.Name] {
+			neededPkgs = append(neededPkgs, pkg)
+		} else {
+			progress.Complete()
+		}
+	}
+
+	return neededPkgs, nil
+}
+
+func (a *concreteApplier) ApplyDeferredPackages(jobs []models.Job) error {
+	if !a.settings.Env.Bosh.Agent.Settings.LazyPackages {
+		return nil
+	}
+
+	a.applyLock.Lock()
+	defer a.applyLock.Unlock()
+
+	for _, job := range jobs {
+		supervised, err := a.jobApplier.Supervised(job)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Checking whether job %s is supervised", job.Name)
+		}
+
+		// Packages of supervised jobs were applied with them
+		if supervised {
+			continue
+		}
+
+		for _, pkg := range job.Packages {
+			err = a.packageApplier.Apply(pkg)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Applying package %s", pkg.Name)
+			}
+		}
 
-		err := a.jobApplier.Configure(job, i)
+		err = a.jobApplier.ApplyPackages(job)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Configuring job %s", job.Name)
+			return bosherr.WrapErrorf(err, "Applying packages of job %s", job.Name)
+		}
+	}
+
+	return nil
+}
+
+func (a *concreteApplier) CleanUp(appliedSpec as.ApplySpec, all bool) error {
+	a.applyLock.Lock()
+	defer a.applyLock.Unlock()
+
+	retention := a.retention
+	if all {
+		retention = bc.RetentionPolicy{}
+	}
+
+	return a.keepOnly(appliedSpec, retention, !all)
+}
+
+// emergencyGCEnabled tells whether err is caused by exceeding a bundle quota
+// that unused bundles may be uninstalled for
+func (a *concreteApplier) emergencyGCEnabled(err error) bool {
+	return err != nil && a.settings.Env.Bosh.Agent.Settings.BundleQuota.EmergencyGC && bc.IsQuotaExceeded(err)
+}
+
+// retryAfterEmergencyGC uninstalls all bundles the desired spec does not use,
+// including retained ones, and retries once. The jobs were removed from the
+// job supervisor already so none of them are in use anymore.
+func (a *concreteApplier) retryAfterEmergencyGC(desiredApplySpec as.ApplySpec, quotaErr error, retry func() error) error {
+	a.gcLock.Lock()
+	err := a.keepOnly(desiredApplySpec, bc.RetentionPolicy{}, false)
+	a.gcLock.Unlock()
+	if err != nil {
+		return bosherr.WrapErrorf(quotaErr, "Uninstalling unused bundles to stay within the quota failed: %s", err.Error())
+	}
+
+	return retry()
+}
+
+// keepOnly also keeps the bundles of the previously applied specs when
+// asked to and enabled so that reverting to them needs no downloads
+func (a *concreteApplier) keepOnly(applySpec as.ApplySpec, retention bc.RetentionPolicy, keepHistory bool) error {
+	jobs := applySpec.Jobs()
+	pkgs := applySpec.Packages()
+
+	if keepHistory && a.settings.Env.Bosh.Agent.Settings.BundleRetention.KeepHistory {
+		history, err := a.specService.History()
+		if err != nil {
+			return bosherr.WrapError(err, "Getting previously applied specs")
+		}
+
+		for _, spec := range history {
+			jobs = append(jobs, spec.Jobs()...)
+			pkgs = append(pkgs, spec.Packages()...)
 		}
 	}
 
-	err := a.jobSupervisor.Reload()
+	err := a.jobApplier.KeepOnly(jobs, retention)
+	if err != nil {
+		return bosherr.WrapError(err, "Keeping only needed jobs")
+	}
+
+	err = a.packageApplier.KeepOnly(pkgs, retention)
+	if err != nil {
+		return bosherr.WrapError(err, "Keeping only needed packages")
+	}
+
+	return nil
+}
+
+func (a *concreteApplier) ConfigureJobs(desiredApplySpec as.ApplySpec) error {
+	a.applyLock.Lock()
+	defer a.applyLock.Unlock()
+
+	levels, err := jobLevels(desiredApplySpec.Jobs())
+	if err != nil {
+		return bosherr.WrapError(err, "Ordering jobs by their dependencies")
+	}
+
+	// Jobs are added after the jobs they depend on and with higher indices.
+	// Within a level the last job of the spec gets the lowest index.
+	index := 0
+	for _, level := range levels {
+		for i := len(level) - 1; i >= 0; i-- {
+			job := level[i]
+
+			err = a.jobApplier.Configure(job, index)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Configuring job %s", job.Name)
+			}
+
+			index++
+		}
+	}
+
+	err = a.jobSupervisor.Reload()
 	if err != nil {
 		return bosherr.WrapError(err, "Reloading jobSupervisor")
 	}
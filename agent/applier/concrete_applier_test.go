@@ -3,18 +3,30 @@ package applier_test
 import (
 	"errors"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 	"github.com/stretchr/testify/assert"
 
+	"code.cloudfoundry.org/clock/fakeclock"
+
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	boshbc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
+	fakeappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier/fakes"
 	fakejobs "github.com/cloudfoundry/bosh-agent/v2/agent/applier/jobs/jobsfakes"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	fakepackages "github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/filewatcher/filewatcherfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
@@ -57,8 +69,13 @@ var _ = Describe("concreteApplier", func() {
 		packageApplier    *fakepackages.FakeApplier
 		logRotateDelegate *FakeLogRotateDelegate
 		jobSupervisor     *fakejobsuper.FakeJobSupervisor
+		fileWatcher       *filewatcherfakes.FakeWatcher
 		agentApplier      applier.Applier
 		settingsService   boshsettings.Service
+		retention         boshbc.RetentionPolicy
+		specService       *fakeas.FakeV1Service
+		progressReporter  *fakeappl.FakeProgressReporter
+		fs                *fakesys.FakeFileSystem
 	)
 
 	BeforeEach(func() {
@@ -66,14 +83,24 @@ var _ = Describe("concreteApplier", func() {
 		packageApplier = fakepackages.NewFakeApplier()
 		logRotateDelegate = &FakeLogRotateDelegate{}
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		fileWatcher = &filewatcherfakes.FakeWatcher{}
 		settingsService = &fakesettings.FakeSettingsService{}
+		retention = boshbc.NewRetentionPolicy(2, time.Hour, fakeclock.NewFakeClock(time.Now()))
+		specService = fakeas.NewFakeV1Service()
+		progressReporter = &fakeappl.FakeProgressReporter{}
+		fs = fakesys.NewFakeFileSystem()
 		agentApplier = applier.NewConcreteApplier(
 			jobApplier,
 			packageApplier,
 			logRotateDelegate,
 			jobSupervisor,
+			fileWatcher,
 			boshdirs.NewProvider("/fake-base-dir"),
 			settingsService.GetSettings(),
+			retention,
+			specService,
+			progressReporter,
+			fs,
 		)
 	})
 
@@ -138,7 +165,7 @@ var _ = Describe("concreteApplier", func() {
 			job := buildJob()
 			jobApplier.DeleteSourceBlobsReturns(errors.New("boom"))
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}, nil)
 			Expect(err).To(HaveOccurred())
 
 			Expect(jobApplier.DeleteSourceBlobsCallCount()).To(Equal(1))
@@ -172,11 +199,35 @@ var _ = Describe("concreteApplier", func() {
 			job, _ = jobApplier.ConfigureArgsForCall(1)
 			Expect(job).To(Equal(job1))
 		})
+		It("configures jobs after the jobs they depend on", func() {
+			job1 := models.Job{Name: "fake-job-name-1", Version: "fake-version-name-1", DependsOn: []string{"fake-job-name-3"}}
+			job2 := models.Job{Name: "fake-job-name-2", Version: "fake-version-name-2"}
+			job3 := models.Job{Name: "fake-job-name-3", Version: "fake-version-name-3"}
+
+			err := agentApplier.ConfigureJobs(&fakeas.FakeApplySpec{JobResults: []models.Job{job1, job2, job3}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobApplier.ConfigureCallCount()).To(Equal(3))
+			for i, expected := range []models.Job{job3, job2, job1} {
+				job, index := jobApplier.ConfigureArgsForCall(i)
+				Expect(job).To(Equal(expected))
+				Expect(index).To(Equal(i))
+			}
+		})
+
+		It("returns error when jobs depend on each other", func() {
+			job1 := models.Job{Name: "fake-job-name-1", Version: "fake-version-name-1", DependsOn: []string{"fake-job-name-2"}}
+			job2 := models.Job{Name: "fake-job-name-2", Version: "fake-version-name-2", DependsOn: []string{"fake-job-name-1"}}
+
+			err := agentApplier.ConfigureJobs(&fakeas.FakeApplySpec{JobResults: []models.Job{job1, job2}})
+			Expect(err).To(MatchError(ContainSubstring("Jobs fake-job-name-1, fake-job-name-2 depend on each other")))
+			Expect(jobApplier.ConfigureCallCount()).To(Equal(0))
+		})
 	})
 
 	Describe("Apply", func() {
 		It("removes all jobs from job supervisor", func() {
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{}, nil)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(jobSupervisor.RemovedAllJobs).To(BeTrue())
@@ -187,7 +238,7 @@ var _ = Describe("concreteApplier", func() {
 			jobSupervisor.RemovedAllJobsErr = errors.New("fake-remove-all-jobs-error")
 
 			job := buildJob()
-			agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}) //nolint:errcheck
+			agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}, nil) //nolint:errcheck
 
 			// check that jobs were not applied before removing other jobs
 			Expect(jobApplier.ApplyCallCount()).To(Equal(0))
@@ -196,15 +247,47 @@ var _ = Describe("concreteApplier", func() {
 		It("returns error if removing all jobs from job supervisor fails", func() {
 			jobSupervisor.RemovedAllJobsErr = errors.New("fake-remove-all-jobs-error")
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{}, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-remove-all-jobs-error"))
 		})
 
+		It("removes the directories of jobs that are not applied anymore", func() {
+			specService.Spec = boshas.V1ApplySpec{
+				JobSpec: boshas.JobSpec{
+					JobTemplateSpecs: []boshas.JobTemplateSpec{
+						{Name: "removed-job", Version: "fake-version"},
+						{Name: "kept-job", Version: "fake-version"},
+					},
+				},
+				RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{},
+			}
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{{Name: "kept-job", Version: "fake-version"}}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobApplier.RemoveDirectoriesCallCount()).To(Equal(1))
+			Expect(jobApplier.RemoveDirectoriesArgsForCall(0).Name).To(Equal("removed-job"))
+		})
+
+		It("returns error when removing the directories of a job fails", func() {
+			specService.Spec = boshas.V1ApplySpec{
+				JobSpec: boshas.JobSpec{
+					JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "removed-job", Version: "fake-version"}},
+				},
+				RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{},
+			}
+			jobApplier.RemoveDirectoriesReturns(errors.New("fake-remove-directories-error"))
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-remove-directories-error"))
+		})
+
 		It("apply applies jobs", func() {
 			job := buildJob()
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}, nil)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(jobApplier.ApplyCallCount()).To(Equal(1))
@@ -216,21 +299,404 @@ var _ = Describe("concreteApplier", func() {
 
 			jobApplier.ApplyReturns(errors.New("fake-apply-job-error"))
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-apply-job-error"))
 		})
 
+		It("switches over to the applied jobs before reloading the job supervisor", func() {
+			job := buildJob()
+
+			jobApplier.SwitchOverStub = func([]models.Job) error {
+				Expect(jobSupervisor.Reloaded).To(BeFalse())
+				return nil
+			}
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobApplier.SwitchOverCallCount()).To(Equal(1))
+			Expect(jobApplier.SwitchOverArgsForCall(0)).To(Equal([]models.Job{job}))
+			Expect(jobSupervisor.Reloaded).To(BeTrue())
+		})
+
+		It("apply errs when switching over jobs errs", func() {
+			job := buildJob()
+
+			jobApplier.SwitchOverReturns(errors.New("fake-switch-over-error"))
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-switch-over-error"))
+			Expect(jobSupervisor.Reloaded).To(BeFalse())
+		})
+
+		It("stops applying without switching over when cancelled", func() {
+			job := buildJob()
+			pkg := buildPackage()
+
+			cancelRequests := make(chan struct{}, 1)
+			jobApplier.ApplyStub = func(models.Job) error {
+				cancelRequests <- struct{}{}
+				return nil
+			}
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{
+				JobResults:     []models.Job{job},
+				PackageResults: []models.Package{pkg},
+			}, boshtask.NewCancelSignal(cancelRequests))
+			Expect(err).To(MatchError(boshtask.ErrCancelled.Error()))
+
+			Expect(packageApplier.AppliedPackages).To(BeEmpty())
+			Expect(jobApplier.SwitchOverCallCount()).To(BeZero())
+			Expect(jobSupervisor.Reloaded).To(BeFalse())
+		})
+
+		It("applies jobs one at a time by default", func() {
+			var applying int32
+
+			jobApplier.ApplyStub = func(models.Job) error {
+				defer atomic.AddInt32(&applying, -1)
+				if atomic.AddInt32(&applying, 1) > 1 {
+					return errors.New("fake-applied-concurrently")
+				}
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			}
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob(), buildJob()}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jobApplier.ApplyCallCount()).To(Equal(2))
+		})
+
+		It("applies jobs concurrently when configured", func() {
+			jobParallel := 2
+			settings := boshsettings.Settings{}
+			settings.Env.Bosh.JobParallel = &jobParallel
+
+			agentApplier = applier.NewConcreteApplier(
+				jobApplier,
+				packageApplier,
+				logRotateDelegate,
+				jobSupervisor,
+				fileWatcher,
+				boshdirs.NewProvider("/fake-base-dir"),
+				settings,
+				retention,
+				specService,
+				progressReporter,
+				fs,
+			)
+
+			var applying int32
+			bothApplying := make(chan struct{})
+
+			jobApplier.ApplyStub = func(models.Job) error {
+				if atomic.AddInt32(&applying, 1) == 2 {
+					close(bothApplying)
+				}
+
+				select {
+				case <-bothApplying:
+					return nil
+				case <-time.After(5 * time.Second):
+					return errors.New("fake-applied-one-after-another")
+				}
+			}
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob(), buildJob()}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("applies jobs after the jobs they depend on", func() {
+			jobParallel := 2
+			settings := boshsettings.Settings{}
+			settings.Env.Bosh.JobParallel = &jobParallel
+
+			agentApplier = applier.NewConcreteApplier(
+				jobApplier,
+				packageApplier,
+				logRotateDelegate,
+				jobSupervisor,
+				fileWatcher,
+				boshdirs.NewProvider("/fake-base-dir"),
+				settings,
+				retention,
+				specService,
+				progressReporter,
+				fs,
+			)
+
+			database := buildJob()
+			cache := buildJob()
+			web := buildJob()
+			web.DependsOn = []string{database.Name, cache.Name}
+
+			applied := map[string]bool{}
+			var lock sync.Mutex
+
+			jobApplier.ApplyStub = func(job models.Job) error {
+				lock.Lock()
+				defer lock.Unlock()
+
+				for _, dependency := range job.DependsOn {
+					if !applied[dependency] {
+						return errors.New("fake-applied-before-dependency")
+					}
+				}
+
+				applied[job.Name] = true
+				return nil
+			}
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{web, database, cache}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jobApplier.ApplyCallCount()).To(Equal(3))
+		})
+
+		It("returns error without applying anything when jobs depend on unknown jobs", func() {
+			job := buildJob()
+			job.DependsOn = []string{"unknown-job"}
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}, nil)
+			Expect(err).To(MatchError(ContainSubstring("depends on unknown job unknown-job")))
+			Expect(jobApplier.ApplyCallCount()).To(Equal(0))
+			Expect(jobSupervisor.RemovedAllJobs).To(BeFalse())
+		})
+
+		Context("when installing a bundle exceeds its quota", func() {
+			quotaErr := boshbc.QuotaExceededError{Collection: "/fake-base-dir/data/jobs", Quota: 10, Used: 11}
+
+			buildApplier := func(emergencyGC bool) applier.Applier {
+				settings := boshsettings.Settings{}
+				settings.Env.Bosh.Agent.Settings.BundleQuota.EmergencyGC = emergencyGC
+
+				return applier.NewConcreteApplier(
+					jobApplier,
+					packageApplier,
+					logRotateDelegate,
+					jobSupervisor,
+					fileWatcher,
+					boshdirs.NewProvider("/fake-base-dir"),
+					settings,
+					retention,
+					specService,
+					progressReporter,
+					fs,
+				)
+			}
+
+			BeforeEach(func() {
+				jobApplier.ApplyReturnsOnCall(0, quotaErr)
+			})
+
+			It("returns the error", func() {
+				err := buildApplier(false).Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}}, nil)
+				Expect(err).To(MatchError(ContainSubstring(quotaErr.Error())))
+
+				Expect(jobApplier.ApplyCallCount()).To(Equal(1))
+				Expect(jobApplier.KeepOnlyCallCount()).To(BeZero())
+			})
+
+			It("uninstalls all bundles the desired spec does not use and retries when emergency GC is enabled", func() {
+				job := buildJob()
+				pkg := buildPackage()
+
+				err := buildApplier(true).Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}, PackageResults: []models.Package{pkg}}, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobApplier.ApplyCallCount()).To(Equal(2))
+				Expect(jobApplier.ApplyArgsForCall(1)).To(Equal(job))
+
+				jobs, jobsRetention := jobApplier.KeepOnlyArgsForCall(0)
+				Expect(jobs).To(Equal([]models.Job{job}))
+				Expect(jobsRetention).To(Equal(boshbc.RetentionPolicy{}))
+				Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{pkg}))
+			})
+
+			It("returns the error when uninstalling unused bundles fails", func() {
+				jobApplier.KeepOnlyReturns(errors.New("fake-keep-only-error"))
+
+				err := buildApplier(true).Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}}, nil)
+				Expect(err).To(MatchError(ContainSubstring("Uninstalling unused bundles to stay within the quota failed: Keeping only needed jobs: fake-keep-only-error")))
+				Expect(jobApplier.ApplyCallCount()).To(Equal(1))
+			})
+
+			It("leaves the installation to applying when preparing and emergency GC is enabled", func() {
+				jobApplier.PrepareReturns(bosherr.WrapError(quotaErr, "Installing job"))
+
+				err := buildApplier(true).Prepare(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(jobApplier.KeepOnlyCallCount()).To(BeZero())
+			})
+		})
+
+		It("reports the progress of applying jobs and packages", func() {
+			job := buildJob()
+			pkg := buildPackage()
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}, PackageResults: []models.Package{pkg}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(progressReporter.Reported()).To(Equal([]applier.ApplyProgress{
+				{Step: applier.ApplyStepStarting, Percent: 0},
+				{Step: applier.ApplyStepApplyingJob, Job: job.Name, Percent: 0},
+				{Step: applier.ApplyStepApplyingPackage, Package: pkg.Name, Percent: 33},
+				{Step: applier.ApplyStepReloading, Percent: 66},
+				{Step: applier.ApplyStepCleaningUp, Percent: 66},
+				{Step: applier.ApplyStepDone, Percent: 100},
+			}))
+		})
+
+		It("does not report the apply as done when it fails", func() {
+			jobApplier.ApplyReturns(errors.New("fake-apply-job-error"))
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}}, nil)
+			Expect(err).To(HaveOccurred())
+
+			reported := progressReporter.Reported()
+			Expect(reported[len(reported)-1].Step).To(Equal(applier.ApplyStepApplyingJob))
+		})
+
+		Context("when a spec was applied before", func() {
+			var (
+				job  models.Job
+				pkg  models.Package
+				spec *fakeas.FakeApplySpec
+			)
+
+			BeforeEach(func() {
+				pkg = buildPackage()
+				job = buildJob()
+				job.Packages = []models.Package{pkg}
+				spec = &fakeas.FakeApplySpec{JobResults: []models.Job{job}, PackageResults: []models.Package{pkg}}
+
+				err := agentApplier.Apply(spec, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				jobApplier.ApplyReturns(nil)
+				packageApplier.AppliedPackages = []models.Package{}
+			})
+
+			It("skips jobs and packages that did not change", func() {
+				err := agentApplier.Apply(spec, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobApplier.ApplyCallCount()).To(Equal(1))
+				Expect(packageApplier.AppliedPackages).To(BeEmpty())
+				Expect(jobSupervisor.Reloaded).To(BeTrue())
+			})
+
+			It("only counts changed jobs and packages towards the progress", func() {
+				err := agentApplier.Apply(spec, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				reported := progressReporter.Reported()
+				Expect(reported[len(reported)-3:]).To(Equal([]applier.ApplyProgress{
+					{Step: applier.ApplyStepReloading, Percent: 0},
+					{Step: applier.ApplyStepCleaningUp, Percent: 0},
+					{Step: applier.ApplyStepDone, Percent: 100},
+				}))
+			})
+
+			It("applies jobs and packages whose fingerprints changed", func() {
+				changedPkg := pkg
+				changedPkg.Version = "fake-changed-version"
+				changedJob := job
+				changedJob.Packages = []models.Package{changedPkg}
+
+				err := agentApplier.Apply(&fakeas.FakeApplySpec{
+					JobResults:     []models.Job{changedJob},
+					PackageResults: []models.Package{changedPkg},
+				}, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobApplier.ApplyCallCount()).To(Equal(2))
+				Expect(jobApplier.ApplyArgsForCall(1)).To(Equal(changedJob))
+				Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{changedPkg}))
+			})
+
+			It("applies everything again after a failed apply", func() {
+				jobSupervisor.ReloadErr = errors.New("fake-reload-error")
+				err := agentApplier.Apply(spec, nil)
+				Expect(err).To(HaveOccurred())
+
+				jobSupervisor.ReloadErr = nil
+				err = agentApplier.Apply(spec, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobApplier.ApplyCallCount()).To(Equal(2))
+				Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{pkg}))
+			})
+
+			Context("when the agent restarted", func() {
+				restart := func() {
+					agentApplier = applier.NewConcreteApplier(
+						jobApplier,
+						packageApplier,
+						logRotateDelegate,
+						jobSupervisor,
+						fileWatcher,
+						boshdirs.NewProvider("/fake-base-dir"),
+						settingsService.GetSettings(),
+						retention,
+						specService,
+						progressReporter,
+						fs,
+					)
+				}
+
+				It("skips packages that did not change by their stored fingerprints", func() {
+					restart()
+
+					err := agentApplier.Apply(spec, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(jobApplier.ApplyCallCount()).To(Equal(2))
+					Expect(packageApplier.AppliedPackages).To(BeEmpty())
+				})
+
+				It("applies packages whose stored fingerprints changed", func() {
+					restart()
+
+					changedPkg := pkg
+					changedPkg.Version = "fake-changed-version"
+
+					err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{changedPkg}}, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{changedPkg}))
+				})
+
+				It("applies packages again after a failed apply", func() {
+					jobSupervisor.ReloadErr = errors.New("fake-reload-error")
+					err := agentApplier.Apply(spec, nil)
+					Expect(err).To(HaveOccurred())
+					packageApplier.AppliedPackages = []models.Package{}
+
+					restart()
+
+					jobSupervisor.ReloadErr = nil
+					err = agentApplier.Apply(spec, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{pkg}))
+				})
+			})
+		})
+
 		It("asked jobApplier to keep only the jobs in the desired specs", func() {
 			desiredJob := buildJob()
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{desiredJob}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{desiredJob}}, nil)
 
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(jobApplier.KeepOnlyCallCount()).To(Equal(1))
-			Expect(jobApplier.KeepOnlyArgsForCall(0)).To(Equal([]models.Job{desiredJob}))
+			jobs, jobsRetention := jobApplier.KeepOnlyArgsForCall(0)
+			Expect(jobs).To(Equal([]models.Job{desiredJob}))
+			Expect(jobsRetention).To(Equal(retention))
 		})
 
 		It("returns error when jobApplier fails to keep only the jobs in the desired specs", func() {
@@ -238,7 +704,7 @@ var _ = Describe("concreteApplier", func() {
 
 			desiredJob := buildJob()
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{desiredJob}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{desiredJob}}, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
 		})
@@ -247,9 +713,33 @@ var _ = Describe("concreteApplier", func() {
 			pkg1 := buildPackage()
 			pkg2 := buildPackage()
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{pkg1, pkg2}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{pkg1, pkg2}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(packageApplier.AppliedPackages).To(ConsistOf(pkg1, pkg2))
+		})
+
+		It("applies packages concurrently", func() {
+			pkg1 := buildPackage()
+			pkg2 := buildPackage()
+
+			var applying int32
+			bothApplying := make(chan struct{})
+
+			packageApplier.ApplyStub = func(models.Package) error {
+				if atomic.AddInt32(&applying, 1) == 2 {
+					close(bothApplying)
+				}
+
+				select {
+				case <-bothApplying:
+					return nil
+				case <-time.After(5 * time.Second):
+					return errors.New("fake-applied-one-after-another")
+				}
+			}
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{pkg1, pkg2}}, nil)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{pkg1, pkg2}))
 		})
 
 		It("apply errs when applying packages errs", func() {
@@ -257,7 +747,7 @@ var _ = Describe("concreteApplier", func() {
 
 			packageApplier.ApplyError = errors.New("fake-apply-package-error")
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{pkg}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{pkg}}, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-apply-package-error"))
 		})
@@ -265,9 +755,10 @@ var _ = Describe("concreteApplier", func() {
 		It("asked packageApplier to keep only the packages in the desired specs", func() {
 			desiredPkg := buildPackage()
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{desiredPkg}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{desiredPkg}}, nil)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{desiredPkg}))
+			Expect(packageApplier.KeptOnlyRetention).To(Equal(retention))
 		})
 
 		It("returns error when packageApplier fails to keep only the packages in the desired specs", func() {
@@ -275,7 +766,7 @@ var _ = Describe("concreteApplier", func() {
 
 			desiredPkg := buildPackage()
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{desiredPkg}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{PackageResults: []models.Package{desiredPkg}}, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
 		})
@@ -285,7 +776,7 @@ var _ = Describe("concreteApplier", func() {
 			job2 := models.Job{Name: "fake-job-name-2", Version: "fake-version-name-2"}
 			jobs := []models.Job{job1, job2}
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: jobs})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: jobs}, nil)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(jobApplier.ConfigureCallCount()).To(Equal(0))
@@ -297,13 +788,23 @@ var _ = Describe("concreteApplier", func() {
 			var jobs []models.Job
 			jobSupervisor.ReloadErr = errors.New("error reloading monit")
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: jobs})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: jobs}, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("error reloading monit"))
 		})
 
+		It("keeps the previously applied jobs and packages when monit fails to reload", func() {
+			jobSupervisor.ReloadErr = errors.New("error reloading monit")
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{buildJob()}, PackageResults: []models.Package{buildPackage()}}, nil)
+			Expect(err).To(HaveOccurred())
+
+			Expect(jobApplier.KeepOnlyCallCount()).To(BeZero())
+			Expect(packageApplier.KeptOnlyPackages).To(BeNil())
+		})
+
 		It("apply sets up logrotation", func() {
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{MaxLogFileSizeResult: "fake-size"})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{MaxLogFileSizeResult: "fake-size"}, nil)
 			Expect(err).ToNot(HaveOccurred())
 
 			assert.Equal(GinkgoT(), logRotateDelegate.SetupLogrotateArgs, SetupLogrotateArgs{
@@ -316,15 +817,35 @@ var _ = Describe("concreteApplier", func() {
 		It("apply errs if setup logrotate fails", func() {
 			logRotateDelegate.SetupLogrotateErr = errors.New("fake-set-up-logrotate-error")
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{}, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-set-up-logrotate-error"))
 		})
 
+		It("apply watches the files declared in the desired spec", func() {
+			fileWatches := []models.FileWatch{
+				{Job: "fake-job", Path: "/fake/path/*.pem", Action: models.FileWatchActionExec, Command: []string{"/fake/reload"}},
+			}
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{FileWatchResults: fileWatches}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fileWatcher.WatchCallCount()).To(Equal(1))
+			Expect(fileWatcher.WatchArgsForCall(0)).To(Equal(fileWatches))
+		})
+
+		It("apply errs if watching files fails", func() {
+			fileWatcher.WatchReturns(errors.New("fake-watch-error"))
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-watch-error"))
+		})
+
 		It("deletes the job source from the blobstore after applying", func() {
 			job := buildJob()
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}, nil)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(jobApplier.DeleteSourceBlobsCallCount()).To(Equal(1))
@@ -335,11 +856,213 @@ var _ = Describe("concreteApplier", func() {
 			job := buildJob()
 			jobApplier.DeleteSourceBlobsReturns(errors.New("boom"))
 
-			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}})
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}, nil)
 			Expect(err).To(HaveOccurred())
 
 			Expect(jobApplier.DeleteSourceBlobsCallCount()).To(Equal(1))
 			Expect(jobApplier.DeleteSourceBlobsArgsForCall(0)).To(Equal([]models.Job{job}))
 		})
 	})
+
+	Context("when packages are lazy", func() {
+		var (
+			supervisedJob, errandJob models.Job
+			sharedPkg, errandPkg     models.Package
+		)
+
+		BeforeEach(func() {
+			settings := boshsettings.Settings{}
+			settings.Env.Bosh.Agent.Settings.LazyPackages = true
+
+			agentApplier = applier.NewConcreteApplier(
+				jobApplier,
+				packageApplier,
+				logRotateDelegate,
+				jobSupervisor,
+				fileWatcher,
+				boshdirs.NewProvider("/fake-base-dir"),
+				settings,
+				retention,
+				specService,
+				progressReporter,
+				fs,
+			)
+
+			sharedPkg = buildPackage()
+			errandPkg = buildPackage()
+
+			supervisedJob = buildJob()
+			supervisedJob.Packages = []models.Package{sharedPkg}
+			errandJob = buildJob()
+			errandJob.Packages = []models.Package{sharedPkg, errandPkg}
+
+			jobApplier.SupervisedStub = func(job models.Job) (bool, error) {
+				return job.Name == supervisedJob.Name, nil
+			}
+		})
+
+		Describe("Apply", func() {
+			It("only applies the packages supervised jobs use", func() {
+				err := agentApplier.Apply(&fakeas.FakeApplySpec{
+					JobResults:     []models.Job{supervisedJob, errandJob},
+					PackageResults: []models.Package{sharedPkg, errandPkg},
+				}, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{sharedPkg}))
+				Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{sharedPkg, errandPkg}))
+			})
+
+			It("returns error when it cannot tell whether a job is supervised", func() {
+				jobApplier.SupervisedReturns(false, errors.New("fake-supervised-error"))
+				jobApplier.SupervisedStub = nil
+
+				err := agentApplier.Apply(&fakeas.FakeApplySpec{
+					JobResults:     []models.Job{supervisedJob},
+					PackageResults: []models.Package{sharedPkg},
+				}, nil)
+				Expect(err).To(MatchError(ContainSubstring("fake-supervised-error")))
+			})
+		})
+
+		Describe("ApplyDeferredPackages", func() {
+			It("applies the packages of jobs that are not supervised", func() {
+				err := agentApplier.ApplyDeferredPackages([]models.Job{supervisedJob, errandJob})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{sharedPkg, errandPkg}))
+				Expect(jobApplier.ApplyPackagesCallCount()).To(Equal(1))
+				Expect(jobApplier.ApplyPackagesArgsForCall(0)).To(Equal(errandJob))
+			})
+
+			It("returns error when applying a package fails", func() {
+				packageApplier.ApplyError = errors.New("fake-apply-error")
+
+				err := agentApplier.ApplyDeferredPackages([]models.Job{errandJob})
+				Expect(err).To(MatchError(ContainSubstring("fake-apply-error")))
+				Expect(jobApplier.ApplyPackagesCallCount()).To(BeZero())
+			})
+		})
+	})
+
+	Describe("ApplyDeferredPackages", func() {
+		It("does nothing unless packages are lazy", func() {
+			err := agentApplier.ApplyDeferredPackages([]models.Job{buildJob()})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobApplier.SupervisedCallCount()).To(BeZero())
+			Expect(jobApplier.ApplyPackagesCallCount()).To(BeZero())
+		})
+	})
+
+	Describe("CleanUp", func() {
+		It("keeps only the jobs and packages of the applied spec with the retention policy", func() {
+			job := buildJob()
+			pkg := buildPackage()
+
+			err := agentApplier.CleanUp(&fakeas.FakeApplySpec{JobResults: []models.Job{job}, PackageResults: []models.Package{pkg}}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			jobs, jobsRetention := jobApplier.KeepOnlyArgsForCall(0)
+			Expect(jobs).To(Equal([]models.Job{job}))
+			Expect(jobsRetention).To(Equal(retention))
+
+			Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{pkg}))
+			Expect(packageApplier.KeptOnlyRetention).To(Equal(retention))
+		})
+
+		It("waits for a running apply", func() {
+			job := buildJob()
+			applying := make(chan struct{})
+			finishApply := make(chan struct{})
+			jobApplier.ApplyStub = func(models.Job) error {
+				close(applying)
+				<-finishApply
+				return nil
+			}
+
+			go func() {
+				defer GinkgoRecover()
+				err := agentApplier.Apply(&fakeas.FakeApplySpec{JobResults: []models.Job{job}}, nil)
+				Expect(err).ToNot(HaveOccurred())
+			}()
+			Eventually(applying).Should(BeClosed())
+
+			cleanedUp := make(chan error)
+			go func() { cleanedUp <- agentApplier.CleanUp(&fakeas.FakeApplySpec{}, false) }()
+			Consistently(cleanedUp).ShouldNot(Receive())
+
+			close(finishApply)
+			Eventually(cleanedUp).Should(Receive(BeNil()))
+		})
+
+		It("retains no unused bundles when cleaning up all of them", func() {
+			err := agentApplier.CleanUp(&fakeas.FakeApplySpec{}, true)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, jobsRetention := jobApplier.KeepOnlyArgsForCall(0)
+			Expect(jobsRetention).To(Equal(boshbc.RetentionPolicy{}))
+			Expect(packageApplier.KeptOnlyRetention).To(Equal(boshbc.RetentionPolicy{}))
+		})
+
+		Context("when the bundles of previously applied specs are kept", func() {
+			var previousPkg boshas.PackageSpec
+
+			BeforeEach(func() {
+				settings := boshsettings.Settings{}
+				settings.Env.Bosh.Agent.Settings.BundleRetention.KeepHistory = true
+
+				agentApplier = applier.NewConcreteApplier(
+					jobApplier,
+					packageApplier,
+					logRotateDelegate,
+					jobSupervisor,
+					fileWatcher,
+					boshdirs.NewProvider("/fake-base-dir"),
+					settings,
+					retention,
+					specService,
+					progressReporter,
+					fs,
+				)
+
+				previousPkg = boshas.PackageSpec{Name: "fake-previous-package", Version: "fake-previous-version"}
+				specService.HistorySpecs = []boshas.V1ApplySpec{
+					{PackageSpecs: map[string]boshas.PackageSpec{"fake-previous-package": previousPkg}},
+				}
+			})
+
+			It("keeps the packages of the previously applied specs as well", func() {
+				pkg := buildPackage()
+
+				err := agentApplier.CleanUp(&fakeas.FakeApplySpec{PackageResults: []models.Package{pkg}}, false)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{pkg, previousPkg.AsPackage()}))
+			})
+
+			It("does not keep them when cleaning up all unused bundles", func() {
+				pkg := buildPackage()
+
+				err := agentApplier.CleanUp(&fakeas.FakeApplySpec{PackageResults: []models.Package{pkg}}, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{pkg}))
+			})
+
+			It("returns error when the previously applied specs cannot be read", func() {
+				specService.HistoryErr = errors.New("fake-history-error")
+
+				err := agentApplier.CleanUp(&fakeas.FakeApplySpec{}, false)
+				Expect(err).To(MatchError(ContainSubstring("fake-history-error")))
+			})
+		})
+
+		It("returns error when keeping only the applied packages fails", func() {
+			packageApplier.KeepOnlyErr = errors.New("fake-keep-only-error")
+
+			err := agentApplier.CleanUp(&fakeas.FakeApplySpec{}, false)
+			Expect(err).To(MatchError(ContainSubstring("Keeping only needed packages: fake-keep-only-error")))
+		})
+	})
 })
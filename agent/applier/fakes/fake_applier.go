@@ -3,6 +3,7 @@ package fakes
 import (
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 type FakeApplier struct {
@@ -10,14 +11,25 @@ type FakeApplier struct {
 	PrepareDesiredApplySpec boshas.ApplySpec
 	PrepareError            error
 
-	Applied               bool
-	ApplyDesiredApplySpec boshas.ApplySpec
-	ApplyError            error
+	Applied                bool
+	ApplyDesiredApplySpec  boshas.ApplySpec
+	ApplyDesiredApplySpecs []boshas.ApplySpec
+	ApplyCancelSignal      *boshtask.CancelSignal
+	ApplyError             error
+	ApplyStub              func(desiredApplySpec boshas.ApplySpec) error
 
 	Configured                 bool
 	ConfiguredDesiredApplySpec boshas.ApplySpec
 	ConfiguredJobs             []models.Job
 	ConfiguredError            error
+
+	CleanedUp          bool
+	CleanUpAppliedSpec boshas.ApplySpec
+	CleanUpAll         bool
+	CleanUpError       error
+
+	DeferredPackagesJobs  []models.Job
+	DeferredPackagesError error
 }
 
 func NewFakeApplier() *FakeApplier {
@@ -36,8 +48,25 @@ func (s *FakeApplier) ConfigureJobs(desiredApplySpec boshas.ApplySpec) error {
 	return s.ConfiguredError
 }
 
-func (s *FakeApplier) Apply(desiredApplySpec boshas.ApplySpec) error {
+func (s *FakeApplier) Apply(desiredApplySpec boshas.ApplySpec, cancel *boshtask.CancelSignal) error {
 	s.Applied = true
+	s.ApplyCancelSignal = cancel
 	s.ApplyDesiredApplySpec = desiredApplySpec
+	s.ApplyDesiredApplySpecs = append(s.ApplyDesiredApplySpecs, desiredApplySpec)
+	if s.ApplyStub != nil {
+		return s.ApplyStub(desiredApplySpec)
+	}
 	return s.ApplyError
 }
+
+func (s *FakeApplier) CleanUp(appliedSpec boshas.ApplySpec, all bool) error {
+	s.CleanedUp = true
+	s.CleanUpAppliedSpec = appliedSpec
+	s.CleanUpAll = all
+	return s.CleanUpError
+}
+
+func (s *FakeApplier) ApplyDeferredPackages(jobs []models.Job) error {
+	s.DeferredPackagesJobs = append(s.DeferredPackagesJobs, jobs...)
+	return s.DeferredPackagesError
+}
@@ -0,0 +1,42 @@
+package fakes
+
+import (
+	boshapplier "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+)
+
+type FakeBundleVerifier struct {
+	Verified          bool
+	VerifyAppliedSpec boshas.ApplySpec
+	VerifyResult      []boshapplier.BundleDiscrepancy
+	VerifyError       error
+
+	AuditAppliedSpec boshas.ApplySpec
+	AuditResult      []boshapplier.BundleAudit
+
+	LastVerificationResult *boshapplier.BundleVerification
+
+	JobFingerprintsAppliedSpec boshas.ApplySpec
+	JobFingerprintsResult      map[string]string
+	JobFingerprintsErr         error
+}
+
+func (v *FakeBundleVerifier) Verify(appliedSpec boshas.ApplySpec) ([]boshapplier.BundleDiscrepancy, error) {
+	v.Verified = true
+	v.VerifyAppliedSpec = appliedSpec
+	return v.VerifyResult, v.VerifyError
+}
+
+func (v *FakeBundleVerifier) Audit(appliedSpec boshas.ApplySpec) []boshapplier.BundleAudit {
+	v.AuditAppliedSpec = appliedSpec
+	return v.AuditResult
+}
+
+func (v *FakeBundleVerifier) LastVerification() *boshapplier.BundleVerification {
+	return v.LastVerificationResult
+}
+
+func (v *FakeBundleVerifier) JobFingerprints(appliedSpec boshas.ApplySpec) (map[string]string, error) {
+	v.JobFingerprintsAppliedSpec = appliedSpec
+	return v.JobFingerprintsResult, v.JobFingerprintsErr
+}
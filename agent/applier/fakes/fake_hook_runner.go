@@ -0,0 +1,21 @@
+package fakes
+
+import (
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+type FakeHookRunner struct {
+	RunHooksHooks []string
+	RunHooksJobs  [][]models.Job
+
+	RunHooksResults map[string][]boshappl.HookResult
+	RunHooksErrors  map[string]error
+}
+
+func (r *FakeHookRunner) RunHooks(hook string, jobs []models.Job) ([]boshappl.HookResult, error) {
+	r.RunHooksHooks = append(r.RunHooksHooks, hook)
+	r.RunHooksJobs = append(r.RunHooksJobs, jobs)
+
+	return r.RunHooksResults[hook], r.RunHooksErrors[hook]
+}
@@ -0,0 +1,26 @@
+package fakes
+
+import (
+	"sync"
+
+	boshappl "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+)
+
+type FakeProgressReporter struct {
+	reported []boshappl.ApplyProgress
+	lock     sync.Mutex
+}
+
+func (r *FakeProgressReporter) ReportProgress(progress boshappl.ApplyProgress) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.reported = append(r.reported, progress)
+}
+
+func (r *FakeProgressReporter) Reported() []boshappl.ApplyProgress {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]boshappl.ApplyProgress{}, r.reported...)
+}
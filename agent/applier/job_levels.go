@@ -0,0 +1,109 @@
+package applier
+
+import (
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+// jobLevels orders jobs by their dependencies. Jobs of a level only depend on
+// jobs of previous levels, so the jobs of a level can be enabled in parallel.
+// Jobs keep their spec order within a level, and jobs without dependencies
+// all end up in the first level.
+func jobLevels(jobs []models.Job) ([][]models.Job, error) {
+	known := map[string]bool{}
+	for _, job := range jobs {
+		known[job.Name] = true
+	}
+
+	for _, job := range jobs {
+		for _, dependency := range job.DependsOn {
+			if !known[dependency] {
+				return nil, bosherr.Errorf("Job %s depends on unknown job %s", job.Name, dependency)
+			}
+		}
+	}
+
+	var levels [][]models.Job
+
+	enabled := map[string]bool{}
+	remaining := jobs
+
+	for len(remaining) > 0 {
+		var level, blocked []models.Job
+
+		for _, job := range remaining {
+			if dependenciesEnabled(job, enabled) {
+				level = append(level, job)
+			} else {
+				blocked = append(blocked, job)
+			}
+		}
+
+		if len(level) == 0 {
+			names := make([]string, 0, len(blocked))
+			for _, job := range blocked {
+				names = append(names, job.Name)
+			}
+			sort.Strings(names)
+
+			return nil, bosherr.Errorf("Jobs %s depend on each other", strings.Join(names, ", "))
+		}
+
+		for _, job := range level {
+			enabled[job.Name] = true
+		}
+
+		levels = append(levels, level)
+		remaining = blocked
+	}
+
+	return levels, nil
+}
+
+func dependenciesEnabled(job models.Job, enabled map[string]bool) bool {
+	for _, dependency := range job.DependsOn {
+		if !enabled[dependency] {
+			return false
+		}
+	}
+	return true
+}
+
+// DrainLevels orders jobs for draining, the reverse of enabling them: jobs of
+// a level are only depended on by jobs of previous levels, so they can be
+// drained in parallel once those are drained.
+func DrainLevels(jobs []models.Job) ([][]models.Job, error) {
+	dependents := map[string][]string{}
+	for _, job := range jobs {
+		for _, dependency := range job.DependsOn {
+			dependents[dependency] = append(dependents[dependency], job.Name)
+		}
+	}
+
+	byName := map[string]models.Job{}
+	inverted := make([]models.Job, 0, len(jobs))
+
+	for _, job := range jobs {
+		byName[job.Name] = job
+
+		job.DependsOn = dependents[job.Name]
+		inverted = append(inverted, job)
+	}
+
+	levels, err := jobLevels(inverted)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, level := range levels {
+		for i, job := range level {
+			level[i] = byName[job.Name]
+		}
+	}
+
+	return levels, nil
+}
@@ -0,0 +1,42 @@
+package applier_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+var _ = Describe("DrainLevels", func() {
+	It("drains jobs without dependencies in a single level", func() {
+		jobs := []models.Job{{Name: "job-a"}, {Name: "job-b"}}
+
+		levels, err := applier.DrainLevels(jobs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(levels).To(Equal([][]models.Job{jobs}))
+	})
+
+	It("drains jobs before the jobs they depend on", func() {
+		db := models.Job{Name: "db"}
+		api := models.Job{Name: "api", DependsOn: []string{"db"}}
+		worker := models.Job{Name: "worker", DependsOn: []string{"db", "api"}}
+		logs := models.Job{Name: "logs"}
+
+		levels, err := applier.DrainLevels([]models.Job{db, api, worker, logs})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(levels).To(Equal([][]models.Job{
+			{worker, logs},
+			{api},
+			{db},
+		}))
+	})
+
+	It("returns an error when jobs depend on each other", func() {
+		_, err := applier.DrainLevels([]models.Job{
+			{Name: "job-a", DependsOn: []string{"job-b"}},
+			{Name: "job-b", DependsOn: []string{"job-a"}},
+		})
+		Expect(err).To(MatchError("Jobs job-a, job-b depend on each other"))
+	})
+})
@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	boshbc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 )
 
@@ -9,7 +10,22 @@ import (
 type Applier interface {
 	Prepare(job models.Job) error
 	Apply(job models.Job) error
+
+	// ApplyPackages links the packages of an applied job, which Apply
+	// defers for jobs that are not supervised when packages are lazy
+	ApplyPackages(job models.Job) error
+
+	// Supervised tells whether an installed job has monit configuration
+	Supervised(job models.Job) (bool, error)
+
 	Configure(job models.Job, jobIndex int) error
-	KeepOnly(jobs []models.Job) error
+	KeepOnly(jobs []models.Job, retention boshbc.RetentionPolicy) error
 	DeleteSourceBlobs(jobs []models.Job) error
+
+	// RemoveDirectories removes the directories a job declared once it is
+	// not applied anymore
+	RemoveDirectories(job models.Job) error
+
+	// SwitchOver makes the given jobs the ones visible in the jobs directory
+	SwitchOver(jobs []models.Job) error
 }
@@ -4,6 +4,7 @@ package jobsfakes
 import (
 	"sync"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/jobs"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 )
@@ -20,6 +21,17 @@ type FakeApplier struct {
 	applyReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ApplyPackagesStub        func(models.Job) error
+	applyPackagesMutex       sync.RWMutex
+	applyPackagesArgsForCall []struct {
+		arg1 models.Job
+	}
+	applyPackagesReturns struct {
+		result1 error
+	}
+	applyPackagesReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ConfigureStub        func(models.Job, int) error
 	configureMutex       sync.RWMutex
 	configureArgsForCall []struct {
@@ -43,10 +55,11 @@ type FakeApplier struct {
 	deleteSourceBlobsReturnsOnCall map[int]struct {
 		result1 error
 	}
-	KeepOnlyStub        func([]models.Job) error
+	KeepOnlyStub        func([]models.Job, bundlecollection.RetentionPolicy) error
 	keepOnlyMutex       sync.RWMutex
 	keepOnlyArgsForCall []struct {
 		arg1 []models.Job
+		arg2 bundlecollection.RetentionPolicy
 	}
 	keepOnlyReturns struct {
 		result1 error
@@ -65,6 +78,41 @@ type FakeApplier struct {
 	prepareReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RemoveDirectoriesStub        func(models.Job) error
+	removeDirectoriesMutex       sync.RWMutex
+	removeDirectoriesArgsForCall []struct {
+		arg1 models.Job
+	}
+	removeDirectoriesReturns struct {
+		result1 error
+	}
+	removeDirectoriesReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SupervisedStub        func(models.Job) (bool, error)
+	supervisedMutex       sync.RWMutex
+	supervisedArgsForCall []struct {
+		arg1 models.Job
+	}
+	supervisedReturns struct {
+		result1 bool
+		result2 error
+	}
+	supervisedReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	SwitchOverStub        func([]models.Job) error
+	switchOverMutex       sync.RWMutex
+	switchOverArgsForCall []struct {
+		arg1 []models.Job
+	}
+	switchOverReturns struct {
+		result1 error
+	}
+	switchOverReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -130,6 +178,67 @@ func (fake *FakeApplier) ApplyReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeApplier) ApplyPackages(arg1 models.Job) error {
+	fake.applyPackagesMutex.Lock()
+	ret, specificReturn := fake.applyPackagesReturnsOnCall[len(fake.applyPackagesArgsForCall)]
+	fake.applyPackagesArgsForCall = append(fake.applyPackagesArgsForCall, struct {
+		arg1 models.Job
+	}{arg1})
+	stub := fake.ApplyPackagesStub
+	fakeReturns := fake.applyPackagesReturns
+	fake.recordInvocation("ApplyPackages", []interface{}{arg1})
+	fake.applyPackagesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeApplier) ApplyPackagesCallCount() int {
+	fake.applyPackagesMutex.RLock()
+	defer fake.applyPackagesMutex.RUnlock()
+	return len(fake.applyPackagesArgsForCall)
+}
+
+func (fake *FakeApplier) ApplyPackagesCalls(stub func(models.Job) error) {
+	fake.applyPackagesMutex.Lock()
+	defer fake.applyPackagesMutex.Unlock()
+	fake.ApplyPackagesStub = stub
+}
+
+func (fake *FakeApplier) ApplyPackagesArgsForCall(i int) models.Job {
+	fake.applyPackagesMutex.RLock()
+	defer fake.applyPackagesMutex.RUnlock()
+	argsForCall := fake.applyPackagesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeApplier) ApplyPackagesReturns(result1 error) {
+	fake.applyPackagesMutex.Lock()
+	defer fake.applyPackagesMutex.Unlock()
+	fake.ApplyPackagesStub = nil
+	fake.applyPackagesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeApplier) ApplyPackagesReturnsOnCall(i int, result1 error) {
+	fake.applyPackagesMutex.Lock()
+	defer fake.applyPackagesMutex.Unlock()
+	fake.ApplyPackagesStub = nil
+	if fake.applyPackagesReturnsOnCall == nil {
+		fake.applyPackagesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.applyPackagesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeApplier) Configure(arg1 models.Job, arg2 int) error {
 	fake.configureMutex.Lock()
 	ret, specificReturn := fake.configureReturnsOnCall[len(fake.configureArgsForCall)]
@@ -258,7 +367,7 @@ func (fake *FakeApplier) DeleteSourceBlobsReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeApplier) KeepOnly(arg1 []models.Job) error {
+func (fake *FakeApplier) KeepOnly(arg1 []models.Job, arg2 bundlecollection.RetentionPolicy) error {
 	var arg1Copy []models.Job
 	if arg1 != nil {
 		arg1Copy = make([]models.Job, len(arg1))
@@ -268,13 +377,14 @@ func (fake *FakeApplier) KeepOnly(arg1 []models.Job) error {
 	ret, specificReturn := fake.keepOnlyReturnsOnCall[len(fake.keepOnlyArgsForCall)]
 	fake.keepOnlyArgsForCall = append(fake.keepOnlyArgsForCall, struct {
 		arg1 []models.Job
-	}{arg1Copy})
+		arg2 bundlecollection.RetentionPolicy
+	}{arg1Copy, arg2})
 	stub := fake.KeepOnlyStub
 	fakeReturns := fake.keepOnlyReturns
-	fake.recordInvocation("KeepOnly", []interface{}{arg1Copy})
+	fake.recordInvocation("KeepOnly", []interface{}{arg1Copy, arg2})
 	fake.keepOnlyMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -288,17 +398,17 @@ func (fake *FakeApplier) KeepOnlyCallCount() int {
 	return len(fake.keepOnlyArgsForCall)
 }
 
-func (fake *FakeApplier) KeepOnlyCalls(stub func([]models.Job) error) {
+func (fake *FakeApplier) KeepOnlyCalls(stub func([]models.Job, bundlecollection.RetentionPolicy) error) {
 	fake.keepOnlyMutex.Lock()
 	defer fake.keepOnlyMutex.Unlock()
 	fake.KeepOnlyStub = stub
 }
 
-func (fake *FakeApplier) KeepOnlyArgsForCall(i int) []models.Job {
+func (fake *FakeApplier) KeepOnlyArgsForCall(i int) ([]models.Job, bundlecollection.RetentionPolicy) {
 	fake.keepOnlyMutex.RLock()
 	defer fake.keepOnlyMutex.RUnlock()
 	argsForCall := fake.keepOnlyArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeApplier) KeepOnlyReturns(result1 error) {
@@ -385,19 +495,200 @@ func (fake *FakeApplier) PrepareReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeApplier) RemoveDirectories(arg1 models.Job) error {
+	fake.removeDirectoriesMutex.Lock()
+	ret, specificReturn := fake.removeDirectoriesReturnsOnCall[len(fake.removeDirectoriesArgsForCall)]
+	fake.removeDirectoriesArgsForCall = append(fake.removeDirectoriesArgsForCall, struct {
+		arg1 models.Job
+	}{arg1})
+	stub := fake.RemoveDirectoriesStub
+	fakeReturns := fake.removeDirectoriesReturns
+	fake.recordInvocation("RemoveDirectories", []interface{}{arg1})
+	fake.removeDirectoriesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeApplier) RemoveDirectoriesCallCount() int {
+	fake.removeDirectoriesMutex.RLock()
+	defer fake.removeDirectoriesMutex.RUnlock()
+	return len(fake.removeDirectoriesArgsForCall)
+}
+
+func (fake *FakeApplier) RemoveDirectoriesCalls(stub func(models.Job) error) {
+	fake.removeDirectoriesMutex.Lock()
+	defer fake.removeDirectoriesMutex.Unlock()
+	fake.RemoveDirectoriesStub = stub
+}
+
+func (fake *FakeApplier) RemoveDirectoriesArgsForCall(i int) models.Job {
+	fake.removeDirectoriesMutex.RLock()
+	defer fake.removeDirectoriesMutex.RUnlock()
+	argsForCall := fake.removeDirectoriesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeApplier) RemoveDirectoriesReturns(result1 error) {
+	fake.removeDirectoriesMutex.Lock()
+	defer fake.removeDirectoriesMutex.Unlock()
+	fake.RemoveDirectoriesStub = nil
+	fake.removeDirectoriesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeApplier) RemoveDirectoriesReturnsOnCall(i int, result1 error) {
+	fake.removeDirectoriesMutex.Lock()
+	defer fake.removeDirectoriesMutex.Unlock()
+	fake.RemoveDirectoriesStub = nil
+	if fake.removeDirectoriesReturnsOnCall == nil {
+		fake.removeDirectoriesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeDirectoriesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeApplier) Supervised(arg1 models.Job) (bool, error) {
+	fake.supervisedMutex.Lock()
+	ret, specificReturn := fake.supervisedReturnsOnCall[len(fake.supervisedArgsForCall)]
+	fake.supervisedArgsForCall = append(fake.supervisedArgsForCall, struct {
+		arg1 models.Job
+	}{arg1})
+	stub := fake.SupervisedStub
+	fakeReturns := fake.supervisedReturns
+	fake.recordInvocation("Supervised", []interface{}{arg1})
+	fake.supervisedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeApplier) SupervisedCallCount() int {
+	fake.supervisedMutex.RLock()
+	defer fake.supervisedMutex.RUnlock()
+	return len(fake.supervisedArgsForCall)
+}
+
+func (fake *FakeApplier) SupervisedCalls(stub func(models.Job) (bool, error)) {
+	fake.supervisedMutex.Lock()
+	defer fake.supervisedMutex.Unlock()
+	fake.SupervisedStub = stub
+}
+
+func (fake *FakeApplier) SupervisedArgsForCall(i int) models.Job {
+	fake.supervisedMutex.RLock()
+	defer fake.supervisedMutex.RUnlock()
+	argsForCall := fake.supervisedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeApplier) SupervisedReturns(result1 bool, result2 error) {
+	fake.supervisedMutex.Lock()
+	defer fake.supervisedMutex.Unlock()
+	fake.SupervisedStub = nil
+	fake.supervisedReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeApplier) SupervisedReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.supervisedMutex.Lock()
+	defer fake.supervisedMutex.Unlock()
+	fake.SupervisedStub = nil
+	if fake.supervisedReturnsOnCall == nil {
+		fake.supervisedReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.supervisedReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeApplier) SwitchOver(arg1 []models.Job) error {
+	var arg1Copy []models.Job
+	if arg1 != nil {
+		arg1Copy = make([]models.Job, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.switchOverMutex.Lock()
+	ret, specificReturn := fake.switchOverReturnsOnCall[len(fake.switchOverArgsForCall)]
+	fake.switchOverArgsForCall = append(fake.switchOverArgsForCall, struct {
+		arg1 []models.Job
+	}{arg1Copy})
+	stub := fake.SwitchOverStub
+	fakeReturns := fake.switchOverReturns
+	fake.recordInvocation("SwitchOver", []interface{}{arg1Copy})
+	fake.switchOverMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeApplier) SwitchOverCallCount() int {
+	fake.switchOverMutex.RLock()
+	defer fake.switchOverMutex.RUnlock()
+	return len(fake.switchOverArgsForCall)
+}
+
+func (fake *FakeApplier) SwitchOverCalls(stub func([]models.Job) error) {
+	fake.switchOverMutex.Lock()
+	defer fake.switchOverMutex.Unlock()
+	fake.SwitchOverStub = stub
+}
+
+func (fake *FakeApplier) SwitchOverArgsForCall(i int) []models.Job {
+	fake.switchOverMutex.RLock()
+	defer fake.switchOverMutex.RUnlock()
+	argsForCall := fake.switchOverArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeApplier) SwitchOverReturns(result1 error) {
+	fake.switchOverMutex.Lock()
+	defer fake.switchOverMutex.Unlock()
+	fake.SwitchOverStub = nil
+	fake.switchOverReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeApplier) SwitchOverReturnsOnCall(i int, result1 error) {
+	fake.switchOverMutex.Lock()
+	defer fake.switchOverMutex.Unlock()
+	fake.SwitchOverStub = nil
+	if fake.switchOverReturnsOnCall == nil {
+		fake.switchOverReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.switchOverReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeApplier) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.applyMutex.RLock()
-	defer fake.applyMutex.RUnlock()
-	fake.configureMutex.RLock()
-	defer fake.configureMutex.RUnlock()
-	fake.deleteSourceBlobsMutex.RLock()
-	defer fake.deleteSourceBlobsMutex.RUnlock()
-	fake.keepOnlyMutex.RLock()
-	defer fake.keepOnlyMutex.RUnlock()
-	fake.prepareMutex.RLock()
-	defer fake.prepareMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
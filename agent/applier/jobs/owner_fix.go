@@ -1,41 +1,52 @@
 package jobs
 
 import (
-	"fmt"
 	"os"
 	gopath "path"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 )
 
 // FixPermissions changes the permissions of the rendered job templates to be
 // consistent for every job. The path is the root of the job templates
-// directory e.g. /var/vcap/data/jobs/JOBNAME.
-func FixPermissions(fs boshsys.FileSystem, path string, user string, group string) error {
-	ug := fmt.Sprintf("%s:%s", user, group)
+// directory e.g. /var/vcap/data/jobs/JOBNAME, which may be a link to the
+// stored contents of the job. Files in bin are executables, unset
+// permissions are left as they are.
+func FixPermissions(fs boshsys.FileSystem, path string, permissions models.Permissions) error {
+	path, err := fs.ReadAndFollowLink(path)
+	if err != nil {
+		return bosherr.WrapError(err, "Following job directory symlink")
+	}
+
 	binPath := gopath.Join(path, "bin") + "/"
 
-	err := fs.Walk(path, func(path string, info os.FileInfo, err error) error {
+	err = fs.Walk(path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if err := fs.Chown(path, ug); err != nil {
-			return bosherr.WrapError(err, "Failed to chown dir")
+		if permissions.Owner != "" {
+			if err := fs.Chown(path, permissions.Owner); err != nil {
+				return bosherr.WrapError(err, "Failed to chown dir")
+			}
 		}
 
-		if info.IsDir() {
-			return fs.Chmod(path, os.FileMode(0750))
-		}
+		mode := permissions.FileMode
 
 		// If the file is in /var/vcap/jobs/JOB/bin.
-		if strings.HasPrefix(path, binPath) {
-			return fs.Chmod(path, os.FileMode(0750))
+		if info.IsDir() || strings.HasPrefix(path, binPath) {
+			mode = permissions.DirMode
+		}
+
+		if mode == 0 {
+			return nil
 		}
 
-		return fs.Chmod(path, os.FileMode(0640))
+		return fs.Chmod(path, os.FileMode(mode))
 	})
 
 	if err != nil {
@@ -8,6 +8,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 )
 
 var _ = Describe("fixing job template permissions and ownership", func() {
@@ -33,7 +35,7 @@ var _ = Describe("fixing job template permissions and ownership", func() {
 	})
 
 	It("makes the binary executable", func() {
-		err := FixPermissions(fs, "/jobs", "root", "vcap")
+		err := FixPermissions(fs, "/jobs", models.DefaultJobPermissions)
 		Expect(err).NotTo(HaveOccurred())
 
 		runStat := fs.GetFileTestStat("/jobs/bin/run.sh")
@@ -62,10 +64,37 @@ var _ = Describe("fixing job template permissions and ownership", func() {
 		Expect(configFileStat.Groupname).To(Equal("vcap"))
 	})
 
+	It("fixes the contents a linked job directory points to", func() {
+		err := fs.Symlink("/jobs", "/job-link")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = FixPermissions(fs, "/job-link", models.DefaultJobPermissions)
+		Expect(err).NotTo(HaveOccurred())
+
+		runStat := fs.GetFileTestStat("/jobs/bin/run.sh")
+		Expect(runStat.FileMode).To(Equal(os.FileMode(0750)))
+		Expect(runStat.Username).To(Equal("root"))
+
+		linkStat := fs.GetFileTestStat("/job-link")
+		Expect(linkStat.FileType).To(Equal(fakesys.FakeFileTypeSymlink))
+	})
+
+	It("leaves unset permissions as they are", func() {
+		err := FixPermissions(fs, "/jobs", models.Permissions{FileMode: 0600})
+		Expect(err).NotTo(HaveOccurred())
+
+		configFileStat := fs.GetFileTestStat("/jobs/config/file.ini")
+		Expect(configFileStat.FileMode).To(Equal(os.FileMode(0600)))
+		Expect(configFileStat.Username).To(BeEmpty())
+
+		configDirStat := fs.GetFileTestStat("/jobs/config")
+		Expect(configDirStat.FileMode).To(Equal(os.FileMode(0700)))
+	})
+
 	Context("when the walk fails", func() {
 		It("errors", func() {
 			fs.WalkErr = errors.New("disaster")
-			err := FixPermissions(fs, "/jobs", "root", "vcap")
+			err := FixPermissions(fs, "/jobs", models.DefaultJobPermissions)
 			Expect(err).To(HaveOccurred())
 		})
 	})
@@ -73,7 +102,7 @@ var _ = Describe("fixing job template permissions and ownership", func() {
 	Context("when chowning something fails", func() {
 		It("errors", func() {
 			fs.ChownErr = errors.New("disaster")
-			err := FixPermissions(fs, "/jobs", "root", "vcap")
+			err := FixPermissions(fs, "/jobs", models.DefaultJobPermissions)
 			Expect(err).To(HaveOccurred())
 		})
 	})
@@ -2,11 +2,14 @@ package jobs
 
 import (
 	"fmt"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/signatures"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
 	"github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 
@@ -21,7 +24,7 @@ import (
 
 const logTag = "renderedJobApplier"
 
-type FixPermissionsFunc func(boshsys.FileSystem, string, string, string) error
+type FixPermissionsFunc func(boshsys.FileSystem, string, models.Permissions) error
 
 type renderedJobApplier struct {
 	blobstore              blobstore_delegator.BlobstoreDelegator
@@ -32,14 +35,35 @@ type renderedJobApplier struct {
 	jobsBc                 boshbc.BundleCollection
 	logger                 boshlog.Logger
 	packageApplierProvider packages.ApplierProvider
+	verifier               signatures.Verifier
+
+	// Only link the packages a job declares into its packages directory
+	jobScopedPackages bool
+
+	// Jobs are enabled in a staging directory and the jobs directory is
+	// switched over to all of them at once
+	atomicSwitchover bool
+
+	// Packages of jobs that are not supervised are only applied once the
+	// job is about to run
+	lazyPackages bool
+
+	// Permissions of the installed jobs, those the apply spec asks for
+	// override them
+	permissions models.PermissionPolicy
 }
 
 func NewRenderedJobApplier(
 	blobstore blobstore_delegator.BlobstoreDelegator,
+	verifier signatures.Verifier,
 	dirProvider directories.Provider,
 	jobsBc boshbc.BundleCollection,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	packageApplierProvider packages.ApplierProvider,
+	jobScopedPackages bool,
+	atomicSwitchover bool,
+	lazyPackages bool,
+	permissions models.PermissionPolicy,
 	fixPermissions FixPermissionsFunc,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
@@ -53,6 +77,11 @@ func NewRenderedJobApplier(
 		jobsBc:                 jobsBc,
 		logger:                 logger,
 		packageApplierProvider: packageApplierProvider,
+		verifier:               verifier,
+		jobScopedPackages:      jobScopedPackages,
+		atomicSwitchover:       atomicSwitchover,
+		lazyPackages:           lazyPackages,
+		permissions:            permissions,
 	}
 }
 
@@ -101,7 +130,71 @@ func (s *renderedJobApplier) Apply(job models.Job) error {
 		return bosherr.WrapError(err, "Enabling job")
 	}
 
-	return s.applyPackages(job)
+	if s.lazyPackages {
+		supervised, err := s.Supervised(job)
+		if err != nil {
+			return err
+		}
+
+		if !supervised {
+			s.logger.Debug(logTag, "Deferring packages of job %s until it runs", job.Name)
+			return nil
+		}
+	}
+
+	return s.ApplyPackages(job)
+}
+
+func (s *renderedJobApplier) ApplyPackages(job models.Job) error {
+	err := s.applyPackages(job)
+	if err != nil {
+		return err
+	}
+
+	jobBundle, err := s.jobsBc.Get(job)
+	if err != nil {
+		return bosherr.WrapError(err, "Getting job bundle")
+	}
+
+	// Job specific packages are linked from within the job bundle
+	// so it can only be mounted read-only afterwards
+	err = jobBundle.MountReadOnly()
+	if err != nil {
+		return bosherr.WrapError(err, "Mounting job read-only")
+	}
+
+	return nil
+}
+
+func (s *renderedJobApplier) RemoveDirectories(job models.Job) error {
+	s.logger.Debug(logTag, "Removing directories of job %v", job)
+
+	return job.RemoveDirectories(s.fs, s.dirProvider)
+}
+
+// Supervised tells whether the job brings monit configuration, jobs without
+// one like errands only run on request
+func (s *renderedJobApplier) Supervised(job models.Job) (bool, error) {
+	jobBundle, err := s.jobsBc.Get(job)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Getting job bundle")
+	}
+
+	jobDir, err := jobBundle.GetInstallPath()
+	if err != nil {
+		return false, bosherr.WrapError(err, "Looking up job directory")
+	}
+
+	if s.fs.FileExists(path.Join(jobDir, "monit")) {
+		return true, nil
+	}
+
+	monitFilePaths, err := s.fs.Glob(path.Join(jobDir, "*.monit"))
+	if err != nil {
+		return false, bosherr.WrapError(err, "Looking for additional monit files")
+	}
+
+	return len(monitFilePaths) > 0, nil
 }
 
 func (s *renderedJobApplier) downloadAndInstall(job models.Job, jobBundle boshbc.Bundle) error {
@@ -116,6 +209,11 @@ func (s *renderedJobApplier) downloadAndInstall(job models.Job, jobBundle boshbc
 		}
 	}()
 
+	err = s.verifier.Verify(file, job.Source.Signature)
+	if err != nil {
+		return bosherr.WrapError(err, "Verifying job source signature")
+	}
+
 	_, err = jobBundle.Install(file, job.Source.PathInArchive)
 	if err != nil {
 		return bosherr.WrapError(err, "Installing job bundle")
@@ -126,7 +224,9 @@ func (s *renderedJobApplier) downloadAndInstall(job models.Job, jobBundle boshbc
 		return bosherr.WrapError(err, "Getting the install path")
 	}
 
-	err = s.fixPermissions(s.fs, installPath, "root", "vcap")
+	permissions := models.DefaultJobPermissions.Override(s.permissions.Job(job.Name)).Override(job.Permissions)
+
+	err = s.fixPermissions(s.fs, installPath, permissions)
 	if err != nil {
 		return bosherr.WrapError(err, "Fixing job bundle permissions")
 	}
@@ -139,14 +239,19 @@ func (s *renderedJobApplier) downloadAndInstall(job models.Job, jobBundle boshbc
 func (s *renderedJobApplier) applyPackages(job models.Job) error {
 	packageApplier := s.packageApplierProvider.JobSpecific(job.Name)
 
-	for _, pkg := range job.Packages {
+	pkgs := job.Packages
+	if s.jobScopedPackages {
+		pkgs = job.DependencyPackages()
+	}
+
+	for _, pkg := range pkgs {
 		err := packageApplier.Apply(pkg)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Applying package %s for job %s", pkg.Name, job.Name)
 		}
 	}
 
-	err := packageApplier.KeepOnly(job.Packages)
+	err := packageApplier.KeepOnly(pkgs, boshbc.RetentionPolicy{})
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Keeping only needed packages for job %s", job.Name)
 	}
@@ -198,7 +303,7 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 	return nil
 }
 
-func (s *renderedJobApplier) KeepOnly(jobs []models.Job) error {
+func (s *renderedJobApplier) KeepOnly(jobs []models.Job, retention boshbc.RetentionPolicy) error {
 	s.logger.Debug(logTag, "Keeping only jobs %v", jobs)
 
 	installedBundles, err := s.jobsBc.List()
@@ -206,6 +311,8 @@ func (s *renderedJobApplier) KeepOnly(jobs []models.Job) error {
 		return bosherr.WrapError(err, "Retrieving installed bundles")
 	}
 
+	unusedBundles := map[boshbc.Bundle]bool{}
+
 	for _, installedBundle := range installedBundles {
 		var shouldKeep bool
 
@@ -227,13 +334,24 @@ func (s *renderedJobApplier) KeepOnly(jobs []models.Job) error {
 				return bosherr.WrapError(err, "Disabling job bundle")
 			}
 
-			// If we uninstall the bundle first, and the disable failed (leaving the symlink),
-			// then the next time bundle collection will not include bundle in its list
-			// which means that symlink will never be deleted.
-			err = installedBundle.Uninstall()
-			if err != nil {
-				return bosherr.WrapError(err, "Uninstalling job bundle")
-			}
+			unusedBundles[installedBundle] = true
+		}
+	}
+
+	expiredBundles, err := retention.Expired(installedBundles, func(bundle boshbc.Bundle) bool {
+		return unusedBundles[bundle]
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Applying job bundle retention policy")
+	}
+
+	for _, expiredBundle := range expiredBundles {
+		// If we uninstall the bundle first, and the disable failed (leaving the symlink),
+		// then the next time bundle collection will not include bundle in its list
+		// which means that symlink will never be deleted.
+		err = expiredBundle.Uninstall()
+		if err != nil {
+			return bosherr.WrapError(err, "Uninstalling job bundle")
 		}
 	}
 
@@ -248,7 +366,7 @@ func (s *renderedJobApplier) DeleteSourceBlobs(jobs []models.Job) error {
 			continue
 		}
 
-		err := s.blobstore.Delete("", job.Source.BlobstoreID)
+		err := s.blobstore.Delete("", job.Source.BlobstoreID, nil)
 		if err != nil {
 			return err
 		}
@@ -258,3 +376,85 @@ func (s *renderedJobApplier) DeleteSourceBlobs(jobs []models.Job) error {
 
 	return nil
 }
+
+// SwitchOver links the jobs from a new root directory and then replaces the
+// jobs directory with a link to it in a single rename, so that a failed apply
+// never leaves a mix of previous and desired jobs visible. The jobs directory
+// alternates between two roots so that the one being built is never in use.
+func (s *renderedJobApplier) SwitchOver(jobs []models.Job) error {
+	if !s.atomicSwitchover {
+		return nil
+	}
+
+	jobsDir := s.dirProvider.JobsDir()
+	rootDir := filepath.Join(s.dirProvider.JobsRootsDir(), "a")
+
+	currentRootDir, err := s.fs.Readlink(jobsDir)
+	if err == nil && filepath.Base(currentRootDir) == "a" {
+		rootDir = filepath.Join(s.dirProvider.JobsRootsDir(), "b")
+	}
+
+	s.logger.Debug(logTag, "Switching over to jobs %v in %s", jobs, rootDir)
+
+	err = s.fs.RemoveAll(rootDir)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing previous jobs root")
+	}
+
+	err = s.fs.MkdirAll(rootDir, os.FileMode(0750))
+	if err != nil {
+		return bosherr.WrapError(err, "Creating jobs root")
+	}
+
+	err = s.fs.Chown(rootDir, "root:vcap")
+	if err != nil {
+		return bosherr.WrapError(err, "Setting ownership on jobs root")
+	}
+
+	for _, job := range jobs {
+		jobBundle, err := s.jobsBc.Get(job)
+		if err != nil {
+			return bosherr.WrapError(err, "Getting job bundle")
+		}
+
+		installPath, err := jobBundle.GetInstallPath()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Getting install path of job %s", job.Name)
+		}
+
+		err = s.fs.Symlink(installPath, filepath.Join(rootDir, job.Name))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Linking job %s", job.Name)
+		}
+	}
+
+	// Jobs were enabled directly in the jobs directory before, it cannot be
+	// replaced by a rename
+	if s.fs.FileExists(jobsDir) {
+		info, err := s.fs.Lstat(jobsDir)
+		if err != nil {
+			return bosherr.WrapError(err, "Checking jobs directory")
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			err = s.fs.RemoveAll(jobsDir)
+			if err != nil {
+				return bosherr.WrapError(err, "Removing jobs directory")
+			}
+		}
+	}
+
+	nextJobsDir := jobsDir + ".next"
+
+	err = s.fs.Symlink(rootDir, nextJobsDir)
+	if err != nil {
+		return bosherr.WrapError(err, "Linking jobs root")
+	}
+
+	err = s.fs.Rename(nextJobsDir, jobsDir)
+	if err != nil {
+		return bosherr.WrapError(err, "Switching over jobs directory")
+	}
+
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 
 	fakebc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection/fakes"
 	fakepackages "github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/signatures/signaturesfakes"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor/fakes"
 )
@@ -32,6 +33,7 @@ var _ = Describe("renderedJobApplier", func() {
 		jobSupervisor          *fakejobsuper.FakeJobSupervisor
 		packageApplierProvider *fakepackages.FakeApplierProvider
 		blobstore              *fakeblobdelegator.FakeBlobstoreDelegator
+		verifier               *signaturesfakes.FakeVerifier
 		fs                     *fakesys.FakeFileSystem
 		applier                jobs.Applier
 		fixPermissions         *fakeFixer
@@ -42,6 +44,7 @@ var _ = Describe("renderedJobApplier", func() {
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		packageApplierProvider = fakepackages.NewFakeApplierProvider()
 		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
+		verifier = &signaturesfakes.FakeVerifier{}
 		fs = fakesys.NewFakeFileSystem()
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		dirProvider := directories.NewProvider("/fakebasedir")
@@ -49,10 +52,15 @@ var _ = Describe("renderedJobApplier", func() {
 
 		applier = jobs.NewRenderedJobApplier(
 			blobstore,
+			verifier,
 			dirProvider,
 			jobsBc,
 			jobSupervisor,
 			packageApplierProvider,
+			false,
+			false,
+			false,
+			models.PermissionPolicy{},
 			fixPermissions.Fix,
 			fs,
 			logger,
@@ -133,6 +141,28 @@ var _ = Describe("renderedJobApplier", func() {
 				Expect(fingerPrint).To(Equal(boshcrypto.MustNewMultipleDigest(job.Source.Sha1)))
 			})
 
+			It("verifies the signature of the job template blob before installing it", func() {
+				blobstore.GetReturns("/fake-blobstore-file-name", nil)
+				job.Source.Signature = &models.Signature{KeyID: "fake-key-id", Value: []byte("fake-signature")}
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(verifier.VerifyCallCount()).To(Equal(1))
+				archivePath, signature := verifier.VerifyArgsForCall(0)
+				Expect(archivePath).To(Equal("/fake-blobstore-file-name"))
+				Expect(signature).To(Equal(job.Source.Signature))
+			})
+
+			It("does not install job template blobs with invalid signatures", func() {
+				verifier.VerifyReturns(errors.New("fake-verify-error"))
+
+				err := act()
+				Expect(err).To(MatchError(ContainSubstring("Verifying job source signature: fake-verify-error")))
+				Expect(bundle.ActionsCalled).ToNot(ContainElement("Install"))
+				Expect(blobstore.CleanUpCallCount()).To(Equal(1))
+			})
+
 			It("installs bundle from decompressed tmp path of a job template", func() {
 				blobstore.GetReturns("/fake-blobstore-file-name", nil)
 
@@ -148,8 +178,32 @@ var _ = Describe("renderedJobApplier", func() {
 				err := act()
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fixPermissions.fakePathArg).To(Equal("job-install-path"))
-				Expect(fixPermissions.fakeUserArg).To(Equal("root"))
-				Expect(fixPermissions.fakeGroupArg).To(Equal("vcap"))
+				Expect(fixPermissions.fakePermissionsArg).To(Equal(models.DefaultJobPermissions))
+			})
+
+			It("fixes the permissions according to the policy and the apply spec", func() {
+				applier = jobs.NewRenderedJobApplier(
+					blobstore,
+					verifier,
+					directories.NewProvider("/fakebasedir"),
+					jobsBc,
+					jobSupervisor,
+					packageApplierProvider,
+					false,
+					false,
+					false,
+					models.PermissionPolicy{
+						Jobs: map[string]models.Permissions{"*": {DirMode: 0700, FileMode: 0600}},
+					},
+					fixPermissions.Fix,
+					fs,
+					boshlog.NewLogger(boshlog.LevelNone),
+				)
+				job.Permissions = models.Permissions{Owner: "root:root"}
+
+				err := act()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fixPermissions.fakePermissionsArg).To(Equal(models.Permissions{Owner: "root:root", DirMode: 0700, FileMode: 0600}))
 			})
 
 			It("returns an errors when fixing permissions fails", func() {
@@ -326,7 +380,15 @@ var _ = Describe("renderedJobApplier", func() {
 				It("does not install but only enables job", func() {
 					err := act()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(bundle.ActionsCalled).To(Equal([]string{"Enable"})) // no Install
+					Expect(bundle.ActionsCalled).To(Equal([]string{"Enable", "MountReadOnly"})) // no Install
+				})
+
+				It("returns error when mounting the job read-only fails", func() {
+					bundle.MountReadOnlyErr = errors.New("fake-mount-error")
+
+					err := act()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Mounting job read-only: fake-mount-error"))
 				})
 
 				It("returns error when job enable fails", func() {
@@ -355,7 +417,7 @@ var _ = Describe("renderedJobApplier", func() {
 				It("installs and enables job", func() {
 					err := act()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(bundle.ActionsCalled).To(Equal([]string{"Install", "Enable"}))
+					Expect(bundle.ActionsCalled).To(Equal([]string{"Install", "Enable", "MountReadOnly"}))
 				})
 
 				It("returns error when job enable fails", func() {
@@ -372,6 +434,154 @@ var _ = Describe("renderedJobApplier", func() {
 
 				ItCreatesDirectories(act)
 			})
+
+			Context("when packages are job scoped", func() {
+				var packageApplier *fakepackages.FakeApplier
+
+				BeforeEach(func() {
+					applier = jobs.NewRenderedJobApplier(
+						blobstore,
+						verifier,
+						directories.NewProvider("/fakebasedir"),
+						jobsBc,
+						jobSupervisor,
+						packageApplierProvider,
+						true,
+						false,
+						false,
+						models.PermissionPolicy{},
+						fixPermissions.Fix,
+						fs,
+						boshlog.NewLogger(boshlog.LevelNone),
+					)
+
+					packageApplier = fakepackages.NewFakeApplier()
+					packageApplierProvider.JobSpecificAppliers[job.Name] = packageApplier
+				})
+
+				It("only links the packages the job declares", func() {
+					job.DeclaredPackages = []string{job.Packages[1].Name}
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{job.Packages[1]}))
+					Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{job.Packages[1]}))
+				})
+
+				It("links all packages when the job does not declare them", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(packageApplier.AppliedPackages).To(Equal(job.Packages))
+				})
+			})
+			Context("when packages are lazy", func() {
+				var packageApplier *fakepackages.FakeApplier
+
+				BeforeEach(func() {
+					applier = jobs.NewRenderedJobApplier(
+						blobstore,
+						verifier,
+						directories.NewProvider("/fakebasedir"),
+						jobsBc,
+						jobSupervisor,
+						packageApplierProvider,
+						false,
+						false,
+						true,
+						models.PermissionPolicy{},
+						fixPermissions.Fix,
+						fs,
+						boshlog.NewLogger(boshlog.LevelNone),
+					)
+
+					packageApplier = fakepackages.NewFakeApplier()
+					packageApplierProvider.JobSpecificAppliers[job.Name] = packageApplier
+
+					bundle.Installed = true
+					bundle.GetDirPath = "/fake/install/path"
+				})
+
+				It("defers the packages of jobs that are not supervised", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(bundle.ActionsCalled).To(Equal([]string{"Enable"}))
+					Expect(packageApplier.AppliedPackages).To(BeEmpty())
+				})
+
+				It("applies the packages of supervised jobs", func() {
+					err := fs.WriteFileString("/fake/install/path/monit", "fake-monit")
+					Expect(err).ToNot(HaveOccurred())
+
+					err = act()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(bundle.ActionsCalled).To(Equal([]string{"Enable", "MountReadOnly"}))
+					Expect(packageApplier.AppliedPackages).To(Equal(job.Packages))
+				})
+
+				It("applies deferred packages once asked to", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					err = applier.ApplyPackages(job)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(bundle.ActionsCalled).To(Equal([]string{"Enable", "MountReadOnly"}))
+					Expect(packageApplier.AppliedPackages).To(Equal(job.Packages))
+				})
+			})
+		})
+	})
+
+	Describe("RemoveDirectories", func() {
+		It("removes the directories the job declared", func() {
+			job := models.Job{
+				Name:        "fake-job",
+				Directories: []models.JobDirectory{{Base: models.JobDirectoryBaseRun, Path: "sockets"}},
+			}
+			err := fs.MkdirAll("/fakebasedir/data/sys/run/fake-job/sockets", 0750)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = applier.RemoveDirectories(job)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists("/fakebasedir/data/sys/run/fake-job/sockets")).To(BeFalse())
+		})
+	})
+
+	Describe("Supervised", func() {
+		var (
+			job    models.Job
+			bundle *fakebc.FakeBundle
+		)
+
+		BeforeEach(func() {
+			job, bundle = buildJob(jobsBc)
+			bundle.GetDirPath = "/fake/install/path"
+		})
+
+		It("is true for jobs with a monit file", func() {
+			err := fs.WriteFileString("/fake/install/path/monit", "fake-monit")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(applier.Supervised(job)).To(BeTrue())
+		})
+
+		It("is true for jobs with additional monit files only", func() {
+			fs.SetGlob("/fake/install/path/*.monit", []string{"/fake/install/path/subjob.monit"})
+
+			Expect(applier.Supervised(job)).To(BeTrue())
+		})
+
+		It("is false for jobs without monit files", func() {
+			Expect(applier.Supervised(job)).To(BeFalse())
+		})
+
+		It("returns error when the job is not installed", func() {
+			bundle.GetDirError = errors.New("fake-get-dir-error")
+
+			_, err := applier.Supervised(job)
+			Expect(err).To(MatchError(ContainSubstring("fake-get-dir-error")))
 		})
 	})
 
@@ -421,7 +631,7 @@ var _ = Describe("renderedJobApplier", func() {
 
 			jobsBc.ListBundles = []boshbc.Bundle{bundle1, bundle2, bundle3, bundle4}
 
-			err := applier.KeepOnly([]models.Job{job4, job2})
+			err := applier.KeepOnly([]models.Job{job4, job2}, boshbc.RetentionPolicy{})
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(bundle1.ActionsCalled).To(Equal([]string{"Disable", "Uninstall"}))
@@ -433,7 +643,7 @@ var _ = Describe("renderedJobApplier", func() {
 		It("returns error when bundle collection fails to return list of installed bundles", func() {
 			jobsBc.ListErr = errors.New("fake-bc-list-error")
 
-			err := applier.KeepOnly([]models.Job{})
+			err := applier.KeepOnly([]models.Job{}, boshbc.RetentionPolicy{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-bc-list-error"))
 		})
@@ -444,7 +654,7 @@ var _ = Describe("renderedJobApplier", func() {
 			jobsBc.ListBundles = []boshbc.Bundle{bundle1}
 			jobsBc.GetErr = errors.New("fake-bc-get-error")
 
-			err := applier.KeepOnly([]models.Job{job1})
+			err := applier.KeepOnly([]models.Job{job1}, boshbc.RetentionPolicy{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-bc-get-error"))
 		})
@@ -455,7 +665,7 @@ var _ = Describe("renderedJobApplier", func() {
 			jobsBc.ListBundles = []boshbc.Bundle{bundle1}
 			bundle1.DisableErr = errors.New("fake-bc-disable-error")
 
-			err := applier.KeepOnly([]models.Job{})
+			err := applier.KeepOnly([]models.Job{}, boshbc.RetentionPolicy{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-bc-disable-error"))
 		})
@@ -466,12 +676,123 @@ var _ = Describe("renderedJobApplier", func() {
 			jobsBc.ListBundles = []boshbc.Bundle{bundle1}
 			bundle1.UninstallErr = errors.New("fake-bc-uninstall-error")
 
-			err := applier.KeepOnly([]models.Job{})
+			err := applier.KeepOnly([]models.Job{}, boshbc.RetentionPolicy{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-bc-uninstall-error"))
 		})
 	})
 
+	Describe("SwitchOver", func() {
+		var (
+			job1, job2       models.Job
+			bundle1, bundle2 *fakebc.FakeBundle
+		)
+
+		BeforeEach(func() {
+			job1, bundle1 = buildJob(jobsBc)
+			job2, bundle2 = buildJob(jobsBc)
+
+			bundle1.GetDirPath = "/fakebasedir/data/jobs/job1/fake-version"
+			bundle2.GetDirPath = "/fakebasedir/data/jobs/job2/fake-version"
+		})
+
+		It("does nothing when atomic switchover is disabled", func() {
+			err := applier.SwitchOver([]models.Job{job1, job2})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/fakebasedir/jobs")).To(BeFalse())
+			Expect(fs.FileExists("/fakebasedir/data/jobs-roots/a")).To(BeFalse())
+		})
+
+		Context("when atomic switchover is enabled", func() {
+			BeforeEach(func() {
+				applier = jobs.NewRenderedJobApplier(
+					blobstore,
+					verifier,
+					directories.NewProvider("/fakebasedir"),
+					jobsBc,
+					jobSupervisor,
+					packageApplierProvider,
+					false,
+					true,
+					false,
+					models.PermissionPolicy{},
+					fixPermissions.Fix,
+					fs,
+					boshlog.NewLogger(boshlog.LevelNone),
+				)
+			})
+
+			It("links the jobs directory to a root linking all jobs", func() {
+				err := applier.SwitchOver([]models.Job{job1, job2})
+				Expect(err).ToNot(HaveOccurred())
+
+				target, err := fs.Readlink("/fakebasedir/jobs")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(target).To(Equal("/fakebasedir/data/jobs-roots/a"))
+
+				target, err = fs.Readlink("/fakebasedir/data/jobs-roots/a/" + job1.Name)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(target).To(Equal("/fakebasedir/data/jobs/job1/fake-version"))
+
+				target, err = fs.Readlink("/fakebasedir/data/jobs-roots/a/" + job2.Name)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(target).To(Equal("/fakebasedir/data/jobs/job2/fake-version"))
+
+				Expect(fs.FileExists("/fakebasedir/jobs.next")).To(BeFalse())
+			})
+
+			It("alternates between roots so that the live root is never modified", func() {
+				err := applier.SwitchOver([]models.Job{job1, job2})
+				Expect(err).ToNot(HaveOccurred())
+
+				err = applier.SwitchOver([]models.Job{job2})
+				Expect(err).ToNot(HaveOccurred())
+
+				target, err := fs.Readlink("/fakebasedir/jobs")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(target).To(Equal("/fakebasedir/data/jobs-roots/b"))
+
+				Expect(fs.FileExists("/fakebasedir/data/jobs-roots/b/" + job1.Name)).To(BeFalse())
+				Expect(fs.FileExists("/fakebasedir/data/jobs-roots/b/" + job2.Name)).To(BeTrue())
+
+				err = applier.SwitchOver([]models.Job{job1})
+				Expect(err).ToNot(HaveOccurred())
+
+				target, err = fs.Readlink("/fakebasedir/jobs")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(target).To(Equal("/fakebasedir/data/jobs-roots/a"))
+
+				Expect(fs.FileExists("/fakebasedir/data/jobs-roots/a/" + job1.Name)).To(BeTrue())
+				Expect(fs.FileExists("/fakebasedir/data/jobs-roots/a/" + job2.Name)).To(BeFalse())
+			})
+
+			It("replaces a jobs directory jobs were enabled in directly", func() {
+				err := fs.MkdirAll("/fakebasedir/jobs/old-job", os.ModePerm)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = applier.SwitchOver([]models.Job{job1})
+				Expect(err).ToNot(HaveOccurred())
+
+				target, err := fs.Readlink("/fakebasedir/jobs")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(target).To(Equal("/fakebasedir/data/jobs-roots/a"))
+
+				Expect(fs.FileExists("/fakebasedir/jobs/old-job")).To(BeFalse())
+			})
+
+			It("returns an error and keeps the jobs directory when a job is not installed", func() {
+				bundle2.GetDirError = errors.New("fake-get-dir-error")
+
+				err := applier.SwitchOver([]models.Job{job1, job2})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-get-dir-error"))
+
+				Expect(fs.FileExists("/fakebasedir/jobs")).To(BeFalse())
+			})
+		})
+	})
+
 	Describe("DeleteSourceBlobs", func() {
 		var jobOne, jobTwo, jobThree models.Job
 
@@ -500,9 +821,9 @@ var _ = Describe("renderedJobApplier", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(blobstore.DeleteCallCount()).To(Equal(2))
-			_, deleteArg := blobstore.DeleteArgsForCall(0)
+			_, deleteArg, _ := blobstore.DeleteArgsForCall(0)
 			Expect(deleteArg).To(Equal("blob-id"))
-			_, deleteArg = blobstore.DeleteArgsForCall(1)
+			_, deleteArg, _ = blobstore.DeleteArgsForCall(1)
 			Expect(deleteArg).To(Equal("another-blob-id"))
 		})
 
@@ -524,15 +845,13 @@ var _ = Describe("renderedJobApplier", func() {
 type fakeFixer struct {
 	fakeFixError error
 
-	fakePathArg  string
-	fakeUserArg  string
-	fakeGroupArg string
+	fakePathArg        string
+	fakePermissionsArg models.Permissions
 }
 
-func (f *fakeFixer) Fix(fs boshsys.FileSystem, path, user, group string) error {
+func (f *fakeFixer) Fix(fs boshsys.FileSystem, path string, permissions models.Permissions) error {
 	f.fakePathArg = path
-	f.fakeUserArg = user
-	f.fakeGroupArg = group
+	f.fakePermissionsArg = permissions
 
 	return f.fakeFixError
 }
@@ -0,0 +1,25 @@
+package models
+
+const (
+	FileWatchActionSignal = "signal"
+	FileWatchActionExec   = "exec"
+)
+
+// FileWatch describes files that a job wants the agent to watch and what
+// to do when one of them changes.
+type FileWatch struct {
+	Job string
+
+	// Path is a glob; only its last element may contain wildcards.
+	Path string
+
+	Action string
+
+	// Used with FileWatchActionSignal
+	Signal  string
+	PidFile string
+
+	// Used with FileWatchActionExec. The executable, given by its absolute
+	// path, and its arguments; it is not run through a shell.
+	Command []string
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"os"
+	"path/filepath"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
@@ -14,6 +16,72 @@ type Job struct {
 	// Packages that this job depends on; however,
 	// currently it will contain packages from all jobs
 	Packages []Package
+
+	// DeclaredPackages names the packages the job declares as dependencies
+	// when the director sends them
+	DeclaredPackages []string
+
+	// Permissions the apply spec asks for, they override the agent's policy
+	Permissions Permissions
+
+	// Directories the agent manages for the job below its run and data
+	// directories
+	Directories []JobDirectory
+
+	// DependsOn names colocated jobs that are enabled and added to the job
+	// supervisor before this job, and drained after it
+	DependsOn []string
+
+	Drain JobDrain
+
+	HealthCheck JobHealthCheck
+}
+
+// JobDrain bounds how long the drain script of a job runs, including the
+// waits it asks for. A zero MaxDuration lets it run until it finishes.
+type JobDrain struct {
+	MaxDuration time.Duration
+
+	// ContinueOnTimeout considers the job drained once its script times out
+	// instead of failing the drain
+	ContinueOnTimeout bool
+}
+
+// JobHealthCheck is how run_health_checks probes a job besides running its
+// health-check script. A zero Timeout uses the default timeout of probes.
+type JobHealthCheck struct {
+	HTTP    *JobHTTPProbe
+	Timeout time.Duration
+}
+
+// JobHTTPProbe considers a job healthy when GET http://127.0.0.1:PORT/PATH
+// answers with a 2xx or 3xx status
+type JobHTTPProbe struct {
+	Port int
+	Path string
+}
+
+const (
+	JobDirectoryBaseRun  = "run"
+	JobDirectoryBaseData = "data"
+)
+
+// JobDirectory is a directory a job declares so that the agent creates it
+// with its owner and mode when applying and starting, and removes it along
+// with the job, instead of the job's pre-start script.
+type JobDirectory struct {
+	// Base is either the run directory of the job, e.g.
+	// /var/vcap/sys/run/JOB, or its data directory, e.g. /var/vcap/data/JOB
+	Base string `json:"base"`
+
+	// Path is relative to the base and must stay within it
+	Path string `json:"path"`
+
+	// Owner is given as user:group and defaults to vcap:vcap
+	Owner string `json:"owner,omitempty"`
+
+	// Mode defaults to 0750
+	Mode FileMode `json:"mode,omitempty"`
 }
 
 func (s Job) BundleName() string {
@@ -31,6 +99,28 @@ func (s Job) BundleVersion() string {
 	return s.Version + "-" + s.Source.Sha1.String()
 }
 
+// DependencyPackages returns the declared packages of the job, or all
+// packages when the job does not declare them.
+func (s Job) DependencyPackages() []Package {
+	if len(s.DeclaredPackages) == 0 {
+		return s.Packages
+	}
+
+	declared := map[string]bool{}
+	for _, name := range s.DeclaredPackages {
+		declared[name] = true
+	}
+
+	pkgs := []Package{}
+	for _, pkg := range s.Packages {
+		if declared[pkg.Name] {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	return pkgs
+}
+
 type JobDirectoryCreator interface {
 	MkdirAll(path string, perm os.FileMode) error
 	Chown(path, username string) error
@@ -38,6 +128,10 @@ type JobDirectoryCreator interface {
 	FileExists(path string) bool
 }
 
+type JobDirectoryRemover interface {
+	RemoveAll(path string) error
+}
+
 type JobDirectoryProvider interface {
 	JobLogDir(jobName string) string
 	JobRunDir(jobName string) string
@@ -74,5 +168,76 @@ func (s Job) CreateDirectories(jobDirectoryCreator JobDirectoryCreator, jobDirPr
 		}
 	}
 
+	// Declared directories get their owner and mode even when they exist
+	// already since the job may have changed them
+	for _, dir := range s.Directories {
+		path, err := s.directoryPath(dir, jobDirProvider)
+		if err != nil {
+			return err
+		}
+
+		owner := dir.Owner
+		if owner == "" {
+			owner = "vcap:vcap"
+		}
+
+		mode := os.FileMode(dir.Mode)
+		if mode == 0 {
+			mode = os.FileMode(0750)
+		}
+
+		if err := jobDirectoryCreator.MkdirAll(path, mode); err != nil {
+			return bosherr.WrapErrorf(err, "Creating declared directory %s", path)
+		}
+
+		if err := jobDirectoryCreator.Chmod(path, mode); err != nil {
+			return bosherr.WrapErrorf(err, "Changing mode of declared directory %s", path)
+		}
+
+		if err := jobDirectoryCreator.Chown(path, owner); err != nil {
+			return bosherr.WrapErrorf(err, "Changing owner of declared directory %s", path)
+		}
+	}
+
 	return nil
 }
+
+// RemoveDirectories removes the declared directories of a job that is not
+// used anymore
+func (s Job) RemoveDirectories(jobDirectoryRemover JobDirectoryRemover, jobDirProvider JobDirectoryProvider) error {
+	for _, dir := range s.Directories {
+		path, err := s.directoryPath(dir, jobDirProvider)
+		if err != nil {
+			return err
+		}
+
+		if err := jobDirectoryRemover.RemoveAll(path); err != nil {
+			return bosherr.WrapErrorf(err, "Removing declared directory %s", path)
+		}
+	}
+
+	return nil
+}
+
+func (s Job) directoryPath(dir JobDirectory, jobDirProvider JobDirectoryProvider) (string, error) {
+	if len(s.Name) < 1 {
+		return "", bosherr.Error("Job name cannot be empty")
+	}
+
+	var base string
+
+	switch dir.Base {
+	case JobDirectoryBaseRun:
+		base = jobDirProvider.JobRunDir(s.Name)
+	case JobDirectoryBaseData:
+		base = jobDirProvider.JobDir(s.Name)
+	default:
+		return "", bosherr.Errorf("Unknown base '%s' of declared directory '%s' of job %s", dir.Base, dir.Path, s.Name)
+	}
+
+	if !filepath.IsLocal(dir.Path) {
+		return "", bosherr.Errorf("Declared directory '%s' of job %s is not within its base", dir.Path, s.Name)
+	}
+
+	return filepath.Join(base, dir.Path), nil
+}
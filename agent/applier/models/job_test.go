@@ -41,6 +41,36 @@ var _ = Describe("Job", func() {
 		})
 	})
 
+	Describe("DependencyPackages", func() {
+		BeforeEach(func() {
+			job.Packages = []Package{{Name: "pkg-a"}, {Name: "pkg-b"}, {Name: "pkg-c"}}
+		})
+
+		It("returns the declared packages", func() {
+			job.DeclaredPackages = []string{"pkg-c", "pkg-a"}
+			Expect(job.DependencyPackages()).To(Equal([]Package{{Name: "pkg-a"}, {Name: "pkg-c"}}))
+		})
+
+		It("returns all packages when the job does not declare them", func() {
+			Expect(job.DependencyPackages()).To(Equal(job.Packages))
+		})
+	})
+
+	Describe("RemoveDirectories", func() {
+		It("removes the declared directories", func() {
+			fs := fakesys.NewFakeFileSystem()
+			dirProvider := directories.NewProvider("/fakebasedir")
+
+			job.Directories = []JobDirectory{{Base: JobDirectoryBaseData, Path: "cache"}}
+			Expect(job.CreateDirectories(fs, dirProvider)).To(Succeed())
+
+			err := job.RemoveDirectories(fs, dirProvider)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists("/fakebasedir/data/" + job.Name + "/cache")).To(BeFalse())
+			Expect(fs.FileExists("/fakebasedir/data/" + job.Name)).To(BeTrue())
+		})
+	})
+
 	Describe("CreateDirectories", func() {
 		var (
 			fs          *fakesys.FakeFileSystem
@@ -147,6 +177,60 @@ var _ = Describe("Job", func() {
 			})
 		})
 
+		Context("when the job declares directories", func() {
+			BeforeEach(func() {
+				job.Directories = []JobDirectory{
+					{Base: JobDirectoryBaseRun, Path: "sockets"},
+					{Base: JobDirectoryBaseData, Path: "cache/blobs", Owner: "root:vcap", Mode: 0700},
+				}
+			})
+
+			It("creates them with their owner and mode", func() {
+				err := job.CreateDirectories(fs, dirProvider)
+				Expect(err).ToNot(HaveOccurred())
+
+				stat := fs.GetFileTestStat("/fakebasedir/data/sys/run/" + job.Name + "/sockets")
+				Expect(stat).ToNot(BeNil())
+				Expect(stat.FileType).To(Equal(fakesys.FakeFileTypeDir))
+				Expect(stat.FileMode).To(Equal(os.FileMode(0750)))
+				Expect(stat.Username).To(Equal("vcap"))
+				Expect(stat.Groupname).To(Equal("vcap"))
+
+				stat = fs.GetFileTestStat("/fakebasedir/data/" + job.Name + "/cache/blobs")
+				Expect(stat).ToNot(BeNil())
+				Expect(stat.FileMode).To(Equal(os.FileMode(0700)))
+				Expect(stat.Username).To(Equal("root"))
+				Expect(stat.Groupname).To(Equal("vcap"))
+			})
+
+			It("restores the owner and mode of existing ones", func() {
+				err := fs.MkdirAll("/fakebasedir/data/sys/run/"+job.Name+"/sockets", 0777)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = job.CreateDirectories(fs, dirProvider)
+				Expect(err).ToNot(HaveOccurred())
+
+				stat := fs.GetFileTestStat("/fakebasedir/data/sys/run/" + job.Name + "/sockets")
+				Expect(stat.FileMode).To(Equal(os.FileMode(0750)))
+				Expect(stat.Username).To(Equal("vcap"))
+			})
+
+			It("rejects directories outside of their base", func() {
+				job.Directories = []JobDirectory{{Base: JobDirectoryBaseData, Path: "../other-job"}}
+
+				err := job.CreateDirectories(fs, dirProvider)
+				Expect(err).To(HaveOccurred())
+				Expect(fs.FileExists("/fakebasedir/data/other-job")).To(BeFalse())
+			})
+
+			It("rejects unknown bases", func() {
+				job.Directories = []JobDirectory{{Base: "log", Path: "sockets"}}
+
+				err := job.CreateDirectories(fs, dirProvider)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
 		Context("when an invalid jobname is provided", func() {
 			BeforeEach(func() {
 				job.Name = ""
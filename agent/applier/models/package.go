@@ -4,6 +4,9 @@ type Package struct {
 	Name    string
 	Version string
 	Source  Source
+
+	// Permissions the apply spec asks for, they override the agent's policy
+	Permissions Permissions
 }
 
 func (s Package) BundleName() string {
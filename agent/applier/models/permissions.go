@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// PermissionPolicyDefault names the entry of a PermissionPolicy that
+// applies to jobs or packages without an entry of their own
+const PermissionPolicyDefault = "*"
+
+// DefaultJobPermissions are given to the rendered templates of jobs unless
+// a policy says otherwise
+var DefaultJobPermissions = Permissions{
+	Owner:    "root:vcap",
+	DirMode:  0750,
+	FileMode: 0640,
+}
+
+// Permissions describes the ownership and modes given to the contents of
+// an installed job or package bundle. Directories and executables get
+// DirMode, other files get FileMode. Unset fields are left as they are.
+type Permissions struct {
+	// Owner is given as user:group, e.g. root:vcap
+	Owner    string   `json:"owner,omitempty"`
+	DirMode  FileMode `json:"dir_mode,omitempty"`
+	FileMode FileMode `json:"file_mode,omitempty"`
+}
+
+func (p Permissions) IsZero() bool {
+	return p == Permissions{}
+}
+
+// Override returns p with the fields that are set in override replaced
+func (p Permissions) Override(override Permissions) Permissions {
+	if override.Owner != "" {
+		p.Owner = override.Owner
+	}
+	if override.DirMode != 0 {
+		p.DirMode = override.DirMode
+	}
+	if override.FileMode != 0 {
+		p.FileMode = override.FileMode
+	}
+	return p
+}
+
+// PermissionPolicy assigns permissions to jobs and packages by name, e.g.
+// to satisfy hardening baselines
+type PermissionPolicy struct {
+	Jobs     map[string]Permissions `json:"jobs,omitempty"`
+	Packages map[string]Permissions `json:"packages,omitempty"`
+}
+
+func (p PermissionPolicy) Job(name string) Permissions {
+	return lookupPermissions(p.Jobs, name)
+}
+
+func (p PermissionPolicy) Package(name string) Permissions {
+	return lookupPermissions(p.Packages, name)
+}
+
+func lookupPermissions(permissions map[string]Permissions, name string) Permissions {
+	if named, found := permissions[name]; found {
+		return permissions[PermissionPolicyDefault].Override(named)
+	}
+	return permissions[PermissionPolicyDefault]
+}
+
+// FileMode is written in octal, e.g. "0750", since JSON has no octal numbers
+type FileMode os.FileMode
+
+func (m FileMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("%04o", uint32(m)))
+}
+
+func (m *FileMode) UnmarshalJSON(data []byte) error {
+	var str string
+
+	err := json.Unmarshal(data, &str)
+	if err != nil {
+		return err
+	}
+
+	mode, err := strconv.ParseUint(str, 8, 32)
+	if err != nil || mode > 0777 {
+		return fmt.Errorf("invalid file mode %q, expected octal permission bits e.g. \"0750\"", str)
+	}
+
+	*m = FileMode(mode)
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package models_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+var _ = Describe("PermissionPolicy", func() {
+	var policy PermissionPolicy
+
+	BeforeEach(func() {
+		err := json.Unmarshal([]byte(`{
+			"jobs": {
+				"*": {"owner": "root:vcap", "dir_mode": "0750"},
+				"some-job": {"file_mode": "0600"}
+			},
+			"packages": {
+				"some-package": {"owner": "vcap:vcap"}
+			}
+		}`), &policy)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("applies the default entry to jobs without an entry of their own", func() {
+		Expect(policy.Job("other-job")).To(Equal(Permissions{Owner: "root:vcap", DirMode: 0750}))
+	})
+
+	It("overrides the default entry with the entry of the job", func() {
+		Expect(policy.Job("some-job")).To(Equal(Permissions{Owner: "root:vcap", DirMode: 0750, FileMode: 0600}))
+	})
+
+	It("leaves packages without entries as they are", func() {
+		Expect(policy.Package("some-package")).To(Equal(Permissions{Owner: "vcap:vcap"}))
+		Expect(policy.Package("other-package").IsZero()).To(BeTrue())
+	})
+
+	It("writes modes in octal", func() {
+		data, err := json.Marshal(Permissions{DirMode: 0750})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`{"dir_mode":"0750"}`))
+	})
+
+	It("rejects modes that are not permission bits", func() {
+		var permissions Permissions
+		Expect(json.Unmarshal([]byte(`{"dir_mode": "750x"}`), &permissions)).ToNot(Succeed())
+		Expect(json.Unmarshal([]byte(`{"dir_mode": "4755"}`), &permissions)).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("Permissions", func() {
+	It("overrides only the fields that are set", func() {
+		permissions := DefaultJobPermissions.Override(Permissions{Owner: "root:root"})
+		Expect(permissions).To(Equal(Permissions{Owner: "root:root", DirMode: 0750, FileMode: 0640}))
+	})
+})
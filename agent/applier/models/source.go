@@ -10,4 +10,16 @@ type Source struct {
 	PathInArchive    string
 	SignedURL        string
 	BlobstoreHeaders map[string]string
+
+	// Signature of the archive, verified before the bundle is installed
+	Signature *Signature
+}
+
+// Signature is a detached signature over the SHA-256 digest of a job or
+// package archive made with one of the keys the director signs with.
+type Signature struct {
+	KeyID string `json:"key_id"`
+
+	// Base64 encoded in JSON
+	Value []byte `json:"value"`
 }
@@ -1,11 +1,12 @@
 package packages
 
 import (
+	bc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
 	models "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 )
 
 type Applier interface {
 	Prepare(pkg models.Package) error
 	Apply(pkg models.Package) error
-	KeepOnly(pkgs []models.Package) error
+	KeepOnly(pkgs []models.Package, retention bc.RetentionPolicy) error
 }
@@ -6,6 +6,7 @@ import (
 
 type ApplierProvider interface {
 	Root() Applier
+	JobScopedRoot() Applier
 	JobSpecific(jobName string) Applier
 	RootBundleCollection() boshbc.BundleCollection
 }
@@ -0,0 +1,54 @@
+package packages
+
+import (
+	"sync"
+
+	models "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+// bundleLocks keep a package from being installed twice at the same time.
+// Root and job specific appliers install packages into the same directories,
+// so the appliers of a provider share them.
+type bundleLocks struct {
+	lock  sync.Mutex
+	locks map[string]*bundleLock
+}
+
+type bundleLock struct {
+	sync.Mutex
+
+	// Number of callers holding or waiting for the lock
+	users int
+}
+
+func newBundleLocks() *bundleLocks {
+	return &bundleLocks{locks: map[string]*bundleLock{}}
+}
+
+// Lock returns the function that unlocks pkg again. The lock of a package
+// is removed once nobody holds or waits for it.
+func (l *bundleLocks) Lock(pkg models.Package) func() {
+	key := pkg.BundleName() + "/" + pkg.BundleVersion()
+
+	l.lock.Lock()
+	pkgLock, found := l.locks[key]
+	if !found {
+		pkgLock = &bundleLock{}
+		l.locks[key] = pkgLock
+	}
+	pkgLock.users++
+	l.lock.Unlock()
+
+	pkgLock.Lock()
+
+	return func() {
+		pkgLock.Unlock()
+
+		l.lock.Lock()
+		pkgLock.users--
+		if pkgLock.users == 0 {
+			delete(l.locks, key)
+		}
+		l.lock.Unlock()
+	}
+}
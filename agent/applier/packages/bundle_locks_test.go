@@ -0,0 +1,97 @@
+package packages
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+func newLockedPackage(name string) models.Package {
+	return models.Package{
+		Name:    name,
+		Version: "fake-package-version",
+		Source: models.Source{
+			Sha1: boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-blob-sha1")),
+		},
+	}
+}
+
+var _ = Describe("bundleLocks", func() {
+	var (
+		locks *bundleLocks
+		pkg   models.Package
+	)
+
+	BeforeEach(func() {
+		locks = newBundleLocks()
+		pkg = newLockedPackage("fake-package-name")
+	})
+
+	It("makes the next caller wait until the package is unlocked", func() {
+		unlock := locks.Lock(pkg)
+
+		locked := make(chan func())
+		go func() { locked <- locks.Lock(pkg) }()
+
+		Consistently(locked).ShouldNot(Receive())
+
+		unlock()
+
+		var unlockNext func()
+		Eventually(locked).Should(Receive(&unlockNext))
+		unlockNext()
+	})
+
+	It("does not make callers for other packages wait", func() {
+		unlock := locks.Lock(pkg)
+		defer unlock()
+
+		locks.Lock(newLockedPackage("other-package-name"))()
+	})
+
+	It("removes the lock of a package once it is unlocked", func() {
+		unlock := locks.Lock(pkg)
+		Expect(locks.locks).To(HaveLen(1))
+
+		unlock()
+		Expect(locks.locks).To(BeEmpty())
+	})
+
+	It("keeps the lock of a package while others wait for it", func() {
+		unlock := locks.Lock(pkg)
+
+		locked := make(chan func())
+		go func() { locked <- locks.Lock(pkg) }()
+
+		Eventually(func() int {
+			locks.lock.Lock()
+			defer locks.lock.Unlock()
+			return locks.locks[pkg.BundleName()+"/"+pkg.BundleVersion()].users
+		}).Should(Equal(2))
+
+		unlock()
+
+		var unlockNext func()
+		Eventually(locked).Should(Receive(&unlockNext))
+
+		locks.lock.Lock()
+		Expect(locks.locks).To(HaveLen(1))
+		locks.lock.Unlock()
+
+		unlockNext()
+		Expect(locks.locks).To(BeEmpty())
+	})
+})
+
+var _ = Describe("compiledPackageApplierProvider installLocks", func() {
+	It("shares the install locks between the appliers it provides", func() {
+		provider := NewCompiledPackageApplierProvider("", "", "", "", nil, nil, nil, nil, nil, nil, nil, 0, models.PermissionPolicy{}, nil, nil)
+
+		rootLocks := provider.Root().(*compiledPackageApplier).installLocks
+		Expect(provider.JobScopedRoot().(*compiledPackageApplier).installLocks).To(BeIdenticalTo(rootLocks))
+		Expect(provider.JobSpecific("fake-job-name").(*compiledPackageApplier).installLocks).To(BeIdenticalTo(rootLocks))
+	})
+})
@@ -1,16 +1,31 @@
 package packages
 
 import (
+	"os"
+	"path"
+	"path/filepath"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	bc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
 	models "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/signatures"
+	boshrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/script/cmd"
 )
 
-const logTag = "compiledPackageApplier"
+const (
+	logTag = "compiledPackageApplier"
+
+	// PostInstallScript is run from the root of a package every time the
+	// package is applied, e.g. to regenerate the ld cache, so it has to be
+	// idempotent. Unlike the packaging script it runs on the job VM.
+	PostInstallScript = "post-install"
+)
 
 type compiledPackageApplier struct {
 	packagesBc bc.BundleCollection
@@ -18,30 +33,78 @@ type compiledPackageApplier struct {
 	// KeepOnly will permanently uninstall packages when operating as owner
 	packagesBcOwner bool
 
+	// Packages are only installed, jobs link them into their job specific
+	// packages directories
+	jobScoped bool
+
+	// Permissions of the installed packages, those the apply spec asks for
+	// override them. Packages keep the ownership and modes of their
+	// archives by default.
+	permissions models.PermissionPolicy
+
 	blobstore blobstore_delegator.BlobstoreDelegator
+	verifier  signatures.Verifier
 	fs        boshsys.FileSystem
+	cmdRunner boshrunner.CmdRunner
 	logger    boshlog.Logger
+
+	installLocks *bundleLocks
 }
 
 func NewCompiledPackageApplier(
 	packagesBc bc.BundleCollection,
 	packagesBcOwner bool,
 	blobstore blobstore_delegator.BlobstoreDelegator,
+	verifier signatures.Verifier,
+	permissions models.PermissionPolicy,
 	fs boshsys.FileSystem,
+	cmdRunner boshrunner.CmdRunner,
 	logger boshlog.Logger,
 ) Applier {
 	return &compiledPackageApplier{
 		packagesBc:      packagesBc,
 		packagesBcOwner: packagesBcOwner,
+		permissions:     permissions,
 		blobstore:       blobstore,
+		verifier:        verifier,
 		fs:              fs,
+		cmdRunner:       cmdRunner,
 		logger:          logger,
+		installLocks:    newBundleLocks(),
+	}
+}
+
+// NewJobScopedCompiledPackageApplier installs packages without enabling
+// them so that every job only finds its own packages. Packages enabled by
+// previous applies are disabled.
+func NewJobScopedCompiledPackageApplier(
+	packagesBc bc.BundleCollection,
+	blobstore blobstore_delegator.BlobstoreDelegator,
+	verifier signatures.Verifier,
+	permissions models.PermissionPolicy,
+	fs boshsys.FileSystem,
+	cmdRunner boshrunner.CmdRunner,
+	logger boshlog.Logger,
+) Applier {
+	return &compiledPackageApplier{
+		packagesBc:      packagesBc,
+		packagesBcOwner: true,
+		jobScoped:       true,
+		permissions:     permissions,
+		blobstore:       blobstore,
+		verifier:        verifier,
+		fs:              fs,
+		cmdRunner:       cmdRunner,
+		logger:          logger,
+		installLocks:    newBundleLocks(),
 	}
 }
 
 func (s compiledPackageApplier) Prepare(pkg models.Package) error {
 	s.logger.Debug(logTag, "Preparing package %v", pkg)
 
+	defer s.installLocks.Lock(pkg)()
+
 	pkgBundle, err := s.packagesBc.Get(pkg)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting package bundle")
@@ -75,9 +138,30 @@ func (s compiledPackageApplier) Apply(pkg models.Package) error {
 		return bosherr.WrapError(err, "Getting package bundle")
 	}
 
-	_, err = pkgBundle.Enable()
+	if s.jobScoped {
+		err = pkgBundle.Disable()
+		if err != nil {
+			return bosherr.WrapError(err, "Disabling package")
+		}
+	} else {
+		_, err = pkgBundle.Enable()
+		if err != nil {
+			return bosherr.WrapError(err, "Enabling package")
+		}
+	}
+
+	// Job specific appliers share the packages of the owner, which already
+	// ran their post-install scripts
+	if s.packagesBcOwner {
+		err = s.runPostInstall(pkg, pkgBundle)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = pkgBundle.MountReadOnly()
 	if err != nil {
-		return bosherr.WrapError(err, "Enabling package")
+		return bosherr.WrapError(err, "Mounting package read-only")
 	}
 
 	return nil
@@ -95,15 +179,106 @@ func (s *compiledPackageApplier) downloadAndInstall(pkg models.Package, pkgBundl
 		}
 	}()
 
+	// Enabling happens only after installing, so tampered archives never
+	// make it onto the VM
+	err = s.verifier.Verify(file, pkg.Source.Signature)
+	if err != nil {
+		return bosherr.WrapError(err, "Verifying package signature")
+	}
+
 	_, err = pkgBundle.Install(file, "")
 	if err != nil {
 		return bosherr.WrapError(err, "Installing package directory")
 	}
 
+	permissions := s.permissions.Package(pkg.Name).Override(pkg.Permissions)
+	if permissions.IsZero() {
+		return nil
+	}
+
+	installPath, err := pkgBundle.GetInstallPath()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting the install path")
+	}
+
+	err = s.fixPermissions(installPath, permissions)
+	if err != nil {
+		return bosherr.WrapError(err, "Fixing package bundle permissions")
+	}
+
 	return nil
 }
 
-func (s *compiledPackageApplier) KeepOnly(pkgs []models.Package) error {
+// runPostInstall runs the post-install script of the package, if it has
+// one, before the package is mounted read-only. Its output is logged like
+// the output of packaging scripts, e.g. to
+// /var/vcap/sys/log/packages/NAME/post-install.stdout.log, and is part of
+// the apply error when the script fails.
+func (s *compiledPackageApplier) runPostInstall(pkg models.Package, pkgBundle bc.Bundle) error {
+	installPath, err := pkgBundle.GetInstallPath()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting the install path")
+	}
+
+	script := filepath.Join(installPath, PostInstallScript+boshscript.ScriptExt)
+	if !s.fs.FileExists(script) {
+		return nil
+	}
+
+	s.logger.Info(logTag, "Running post-install script of package %s", pkg.Name)
+
+	command := cmd.BuildCommand(script)
+	command.WorkingDir = installPath
+	command.Env["BOSH_PACKAGE_NAME"] = pkg.Name
+	command.Env["BOSH_PACKAGE_VERSION"] = pkg.Version
+	command.Env["BOSH_INSTALL_TARGET"] = installPath
+
+	_, err = s.cmdRunner.RunCommand(path.Join("packages", pkg.Name), PostInstallScript, command)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running post-install script of package %s", pkg.Name)
+	}
+
+	return nil
+}
+
+// fixPermissions gives the contents of the package the permissions, files
+// that are executable by anyone are treated like directories
+func (s *compiledPackageApplier) fixPermissions(installPath string, permissions models.Permissions) error {
+	installPath, err := s.fs.ReadAndFollowLink(installPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Following package directory symlink")
+	}
+
+	return s.fs.Walk(installPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Links are left alone since changing them would change their target
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+
+		if permissions.Owner != "" {
+			if err := s.fs.Chown(path, permissions.Owner); err != nil {
+				return bosherr.WrapErrorf(err, "Changing owner of %s", path)
+			}
+		}
+
+		mode := permissions.FileMode
+		if info.IsDir() || info.Mode()&0111 != 0 {
+			mode = permissions.DirMode
+		}
+
+		if mode == 0 {
+			return nil
+		}
+
+		return s.fs.Chmod(path, os.FileMode(mode))
+	})
+}
+
+func (s *compiledPackageApplier) KeepOnly(pkgs []models.Package, retention bc.RetentionPolicy) error {
 	s.logger.Debug(logTag, "Keeping only packages %v", pkgs)
 
 	installedBundles, err := s.packagesBc.List()
@@ -111,6 +286,8 @@ func (s *compiledPackageApplier) KeepOnly(pkgs []models.Package) error {
 		return bosherr.WrapError(err, "Retrieving installed bundles")
 	}
 
+	unusedBundles := map[bc.Bundle]bool{}
+
 	for _, installedBundle := range installedBundles {
 		var shouldKeep bool
 
@@ -132,15 +309,28 @@ func (s *compiledPackageApplier) KeepOnly(pkgs []models.Package) error {
 				return bosherr.WrapError(err, "Disabling package bundle")
 			}
 
-			if s.packagesBcOwner {
-				// If we uninstall the bundle first, and the disable failed (leaving the symlink),
-				// then the next time bundle collection will not include bundle in its list
-				// which means that symlink will never be deleted.
-				err = installedBundle.Uninstall()
-				if err != nil {
-					return bosherr.WrapError(err, "Uninstalling package bundle")
-				}
-			}
+			unusedBundles[installedBundle] = true
+		}
+	}
+
+	if !s.packagesBcOwner {
+		return nil
+	}
+
+	expiredBundles, err := retention.Expired(installedBundles, func(bundle bc.Bundle) bool {
+		return unusedBundles[bundle]
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Applying package bundle retention policy")
+	}
+
+	for _, expiredBundle := range expiredBundles {
+		// If we uninstall the bundle first, and the disable failed (leaving the symlink),
+		// then the next time bundle collection will not include bundle in its list
+		// which means that symlink will never be deleted.
+		err = expiredBundle.Uninstall()
+		if err != nil {
+			return bosherr.WrapError(err, "Uninstalling package bundle")
 		}
 	}
 
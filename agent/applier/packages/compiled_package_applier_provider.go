@@ -10,7 +10,12 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	boshbc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/signatures"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
+	boshrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshdisk "github.com/cloudfoundry/bosh-agent/v2/platform/disk"
 )
 
 type compiledPackageApplierProvider struct {
@@ -20,18 +25,34 @@ type compiledPackageApplierProvider struct {
 	name                  string
 
 	blobstore    blobstore_delegator.BlobstoreDelegator
+	verifier     signatures.Verifier
 	compressor   boshcmd.Compressor
 	fs           boshsys.FileSystem
+	cmdRunner    boshrunner.CmdRunner
 	timeProvider clock.Clock
+	mounter      boshdisk.Mounter
+	quota        uint64
+	permissions  models.PermissionPolicy
+	auditLog     audit.Log
 	logger       boshlog.Logger
+
+	// Shared by the appliers of the provider since they install packages
+	// into the same directories
+	installLocks *bundleLocks
 }
 
 func NewCompiledPackageApplierProvider(
 	installPath, rootEnablePath, jobSpecificEnablePath, name string,
 	blobstore blobstore_delegator.BlobstoreDelegator,
+	verifier signatures.Verifier,
 	compressor boshcmd.Compressor,
 	fs boshsys.FileSystem,
+	cmdRunner boshrunner.CmdRunner,
 	timeProvider clock.Clock,
+	mounter boshdisk.Mounter,
+	quota uint64,
+	permissions models.PermissionPolicy,
+	auditLog audit.Log,
 	logger boshlog.Logger,
 ) ApplierProvider {
 	return compiledPackageApplierProvider{
@@ -40,21 +61,37 @@ func NewCompiledPackageApplierProvider(
 		jobSpecificEnablePath: jobSpecificEnablePath,
 		name:                  name,
 		blobstore:             blobstore,
+		verifier:              verifier,
 		compressor:            compressor,
 		fs:                    fs,
+		cmdRunner:             cmdRunner,
 		timeProvider:          timeProvider,
+		mounter:               mounter,
+		quota:                 quota,
+		permissions:           permissions,
+		auditLog:              auditLog,
 		logger:                logger,
+		installLocks:          newBundleLocks(),
 	}
 }
 
 // Root provides package applier that operates on system-wide packages.
 // (e.g manages /var/vcap/packages/pkg-a -> /var/vcap/data/packages/pkg-a)
 func (p compiledPackageApplierProvider) Root() Applier {
-	return NewCompiledPackageApplier(p.RootBundleCollection(), true, p.blobstore, p.fs, p.logger)
+	return p.withInstallLocks(NewCompiledPackageApplier(p.RootBundleCollection(), true, p.blobstore, p.verifier, p.permissions, p.fs, p.cmdRunner, p.logger))
+}
+
+// JobScopedRoot provides package applier that installs system-wide packages
+// without enabling them, so that jobs only reach the packages linked into
+// their job specific packages directories.
+func (p compiledPackageApplierProvider) JobScopedRoot() Applier {
+	return p.withInstallLocks(NewJobScopedCompiledPackageApplier(p.RootBundleCollection(), p.blobstore, p.verifier, p.permissions, p.fs, p.cmdRunner, p.logger))
 }
 
 // JobSpecific provides package applier that operates on job-specific packages.
 // (e.g manages /var/vcap/jobs/job-name/packages/pkg-a -> /var/vcap/data/packages/pkg-a)
+// Read-only mounts are left to the root package applier since both share
+// the installed packages.
 func (p compiledPackageApplierProvider) JobSpecific(jobName string) Applier {
 	enablePath := path.Join(p.jobSpecificEnablePath, jobName)
 	packagesBc := boshbc.NewFileBundleCollection(
@@ -65,13 +102,20 @@ func (p compiledPackageApplierProvider) JobSpecific(jobName string) Applier {
 		p.fs,
 		p.timeProvider,
 		p.compressor,
+		nil,
+		p.quota,
 		p.logger,
 	)
-	return NewCompiledPackageApplier(packagesBc, false, p.blobstore, p.fs, p.logger)
+	return p.withInstallLocks(NewCompiledPackageApplier(p.audited(packagesBc), false, p.blobstore, p.verifier, p.permissions, p.fs, p.cmdRunner, p.logger))
+}
+
+func (p compiledPackageApplierProvider) withInstallLocks(applier Applier) Applier {
+	applier.(*compiledPackageApplier).installLocks = p.installLocks
+	return applier
 }
 
 func (p compiledPackageApplierProvider) RootBundleCollection() boshbc.BundleCollection {
-	return boshbc.NewFileBundleCollection(
+	return p.audited(boshbc.NewFileBundleCollection(
 		p.installPath,
 		p.rootEnablePath,
 		p.name,
@@ -79,6 +123,17 @@ func (p compiledPackageApplierProvider) RootBundleCollection() boshbc.BundleColl
 		p.fs,
 		p.timeProvider,
 		p.compressor,
+		p.mounter,
+		p.quota,
 		p.logger,
-	)
+	))
+}
+
+// audited records changes of the packages when there is an audit log
+func (p compiledPackageApplierProvider) audited(packagesBc boshbc.BundleCollection) boshbc.BundleCollection {
+	if p.auditLog == nil {
+		return packagesBc
+	}
+
+	return boshbc.NewAuditedBundleCollection(packagesBc, p.name, p.auditLog)
 }
@@ -12,25 +12,38 @@ import (
 
 	boshbc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	. "github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/signatures/signaturesfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit/auditfakes"
+	fakecmdrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner/fakes"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/disk/diskfakes"
 )
 
 var _ = Describe("compiledPackageApplierProvider", func() {
 	var (
-		blobstore  *fakeblobdelegator.FakeBlobstoreDelegator
-		compressor *fakecmd.FakeCompressor
-		fs         *fakesys.FakeFileSystem
-		fakeClock  *fakes.FakeClock
-		logger     boshlog.Logger
-		provider   ApplierProvider
+		blobstore   *fakeblobdelegator.FakeBlobstoreDelegator
+		verifier    *signaturesfakes.FakeVerifier
+		compressor  *fakecmd.FakeCompressor
+		fs          *fakesys.FakeFileSystem
+		fakeClock   *fakes.FakeClock
+		mounter     *diskfakes.FakeMounter
+		permissions models.PermissionPolicy
+		cmdRunner   *fakecmdrunner.FakeFileLoggingCmdRunner
+		logger      boshlog.Logger
+		provider    ApplierProvider
 	)
 
 	BeforeEach(func() {
 		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
+		verifier = &signaturesfakes.FakeVerifier{}
 		compressor = fakecmd.NewFakeCompressor()
 		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakecmdrunner.NewFakeFileLoggingCmdRunner()
 		fakeClock = new(fakes.FakeClock)
+		mounter = &diskfakes.FakeMounter{}
+		permissions = models.PermissionPolicy{Packages: map[string]models.Permissions{"*": {Owner: "vcap:vcap"}}}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 		provider = NewCompiledPackageApplierProvider(
 			"fake-install-path",
@@ -38,9 +51,15 @@ var _ = Describe("compiledPackageApplierProvider", func() {
 			"fake-job-specific-enable-path",
 			"fake-name",
 			blobstore,
+			verifier,
 			compressor,
 			fs,
+			cmdRunner,
 			fakeClock,
+			mounter,
+			1024,
+			permissions,
+			nil,
 			logger,
 		)
 	})
@@ -56,17 +75,48 @@ var _ = Describe("compiledPackageApplierProvider", func() {
 					fs,
 					fakeClock,
 					compressor,
+					mounter,
+					1024,
 					logger,
 				),
 				true,
 				blobstore,
+				verifier,
+				permissions,
 				fs,
+				cmdRunner,
 				logger,
 			)
 			Expect(provider.Root()).To(Equal(expected))
 		})
 	})
 
+	Describe("JobScopedRoot", func() {
+		It("returns package applier that installs system wide packages without enabling them", func() {
+			expected := NewJobScopedCompiledPackageApplier(
+				boshbc.NewFileBundleCollection(
+					"fake-install-path",
+					"fake-root-enable-path",
+					"fake-name",
+					os.FileMode(0755),
+					fs,
+					fakeClock,
+					compressor,
+					mounter,
+					1024,
+					logger,
+				),
+				blobstore,
+				verifier,
+				permissions,
+				fs,
+				cmdRunner,
+				logger,
+			)
+			Expect(provider.JobScopedRoot()).To(Equal(expected))
+		})
+	})
+
 	Describe("JobSpecific", func() {
 		It("returns package applier that is configured to only update job specific packages", func() {
 			expected := NewCompiledPackageApplier(
@@ -78,6 +128,8 @@ var _ = Describe("compiledPackageApplierProvider", func() {
 					fs,
 					fakeClock,
 					compressor,
+					nil,
+					1024,
 					logger,
 				),
 
@@ -86,10 +138,52 @@ var _ = Describe("compiledPackageApplierProvider", func() {
 				false,
 
 				blobstore,
+				verifier,
+				permissions,
 				fs,
+				cmdRunner,
 				logger,
 			)
 			Expect(provider.JobSpecific("fake-job-name")).To(Equal(expected))
 		})
 	})
+	Context("when there is an audit log", func() {
+		It("records changes of the packages in it", func() {
+			auditLog := &auditfakes.FakeLog{}
+			provider = NewCompiledPackageApplierProvider(
+				"fake-install-path",
+				"fake-root-enable-path",
+				"fake-job-specific-enable-path",
+				"fake-name",
+				blobstore,
+				verifier,
+				compressor,
+				fs,
+				cmdRunner,
+				fakeClock,
+				mounter,
+				1024,
+				permissions,
+				auditLog,
+				logger,
+			)
+
+			Expect(provider.RootBundleCollection()).To(Equal(boshbc.NewAuditedBundleCollection(
+				boshbc.NewFileBundleCollection(
+					"fake-install-path",
+					"fake-root-enable-path",
+					"fake-name",
+					os.FileMode(0755),
+					fs,
+					fakeClock,
+					compressor,
+					mounter,
+					1024,
+					logger,
+				),
+				"fake-name",
+				auditLog,
+			)))
+		})
+	})
 })
@@ -2,7 +2,10 @@ package packages_test
 
 import (
 	"errors"
+	"os"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -15,6 +18,8 @@ import (
 	fakebc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection/fakes"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	. "github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/signatures/signaturesfakes"
+	fakecmdrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner/fakes"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
 )
 
@@ -44,7 +49,9 @@ func init() { //nolint:funlen,gochecknoinits
 		var (
 			packagesBc *fakebc.FakeBundleCollection
 			blobstore  *fakeblobdelegator.FakeBlobstoreDelegator
+			verifier   *signaturesfakes.FakeVerifier
 			fs         *fakesys.FakeFileSystem
+			cmdRunner  *fakecmdrunner.FakeFileLoggingCmdRunner
 			logger     boshlog.Logger
 			applier    Applier
 		)
@@ -52,9 +59,11 @@ func init() { //nolint:funlen,gochecknoinits
 		BeforeEach(func() {
 			packagesBc = fakebc.NewFakeBundleCollection()
 			blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
+			verifier = &signaturesfakes.FakeVerifier{}
 			fs = fakesys.NewFakeFileSystem()
+			cmdRunner = fakecmdrunner.NewFakeFileLoggingCmdRunner()
 			logger = boshlog.NewLogger(boshlog.LevelNone)
-			applier = NewCompiledPackageApplier(packagesBc, true, blobstore, fs, logger)
+			applier = NewCompiledPackageApplier(packagesBc, true, blobstore, verifier, models.PermissionPolicy{}, fs, cmdRunner, logger)
 		})
 
 		Describe("Prepare & Apply", func() {
@@ -126,6 +135,71 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(fingerPrint).To(Equal(boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, "sha256:fake-blob-sha256"))))
 				})
 
+				It("leaves the permissions of the package contents as they are by default", func() {
+					bundle.GetDirPath = "/fake-install-path"
+					Expect(fs.WriteFileString("/fake-install-path/lib/file", "")).To(Succeed())
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(fs.GetFileTestStat("/fake-install-path/lib/file").Username).To(BeEmpty())
+				})
+
+				It("gives the package contents the permissions of the policy and the apply spec", func() {
+					applier = NewCompiledPackageApplier(packagesBc, true, blobstore, verifier, models.PermissionPolicy{
+						Packages: map[string]models.Permissions{"*": {DirMode: 0750, FileMode: 0640}},
+					}, fs, cmdRunner, logger)
+					pkg.Permissions = models.Permissions{Owner: "vcap:vcap"}
+
+					bundle.GetDirPath = "/fake-install-path"
+					Expect(fs.WriteFileString("/fake-install-path/bin/run", "")).To(Succeed())
+					Expect(fs.Chmod("/fake-install-path/bin/run", 0755)).To(Succeed())
+					Expect(fs.WriteFileString("/fake-install-path/lib/file", "")).To(Succeed())
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					runStat := fs.GetFileTestStat("/fake-install-path/bin/run")
+					Expect(runStat.FileMode).To(Equal(os.FileMode(0750)))
+					Expect(runStat.Username).To(Equal("vcap"))
+					Expect(runStat.Groupname).To(Equal("vcap"))
+
+					fileStat := fs.GetFileTestStat("/fake-install-path/lib/file")
+					Expect(fileStat.FileMode).To(Equal(os.FileMode(0640)))
+					Expect(fileStat.Username).To(Equal("vcap"))
+				})
+
+				It("returns an error when fixing the permissions fails", func() {
+					pkg.Permissions = models.Permissions{Owner: "vcap:vcap"}
+					bundle.GetDirPath = "/fake-install-path"
+					Expect(fs.WriteFileString("/fake-install-path/lib/file", "")).To(Succeed())
+					fs.ChownErr = errors.New("fake-chown-error")
+
+					err := act()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-chown-error"))
+				})
+
+				It("verifies the signature of the package blob before installing it", func() {
+					blobstore.GetReturns("/fake-blobstore-file-name", nil)
+					pkg.Source.Signature = &models.Signature{KeyID: "fake-key-id", Value: []byte("fake-signature")}
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(verifier.VerifyCallCount()).To(Equal(1))
+					archivePath, signature := verifier.VerifyArgsForCall(0)
+					Expect(archivePath).To(Equal("/fake-blobstore-file-name"))
+					Expect(signature).To(Equal(pkg.Source.Signature))
+				})
+
+				It("does not install package blobs with invalid signatures", func() {
+					verifier.VerifyReturns(errors.New("fake-verify-error"))
+
+					err := act()
+					Expect(err).To(MatchError(ContainSubstring("Verifying package signature: fake-verify-error")))
+					Expect(bundle.ActionsCalled).ToNot(ContainElement("Install"))
+				})
+
 				It("installs bundle from archive", func() {
 					blobstore.GetReturns("/fake-blobstore-file-name", nil)
 					err := act()
@@ -185,6 +259,18 @@ func init() { //nolint:funlen,gochecknoinits
 						Expect(bundle.ActionsCalled).To(Equal([]string{"Install"}))
 					})
 
+					It("installs the package once when it is prepared concurrently", func() {
+						errs := make(chan error, 2)
+						for i := 0; i < 2; i++ {
+							go func() { errs <- act() }()
+						}
+
+						Expect(<-errs).ToNot(HaveOccurred())
+						Expect(<-errs).ToNot(HaveOccurred())
+						Expect(blobstore.GetCallCount()).To(Equal(1))
+						Expect(bundle.ActionsCalled).To(Equal([]string{"Install"}))
+					})
+
 					ItInstallsPkg(act)
 				})
 			})
@@ -216,7 +302,15 @@ func init() { //nolint:funlen,gochecknoinits
 					It("does not install but only enables package", func() {
 						err := act()
 						Expect(err).ToNot(HaveOccurred())
-						Expect(bundle.ActionsCalled).To(Equal([]string{"Enable"})) // no Install
+						Expect(bundle.ActionsCalled).To(Equal([]string{"Enable", "MountReadOnly"})) // no Install
+					})
+
+					It("returns error when mounting the package read-only fails", func() {
+						bundle.MountReadOnlyErr = errors.New("fake-mount-error")
+
+						err := act()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("Mounting package read-only: fake-mount-error"))
 					})
 
 					It("returns error when package enable fails", func() {
@@ -242,7 +336,7 @@ func init() { //nolint:funlen,gochecknoinits
 					It("installs and enables package", func() {
 						err := act()
 						Expect(err).ToNot(HaveOccurred())
-						Expect(bundle.ActionsCalled).To(Equal([]string{"Install", "Enable"}))
+						Expect(bundle.ActionsCalled).To(Equal([]string{"Install", "Enable", "MountReadOnly"}))
 					})
 
 					It("returns error when package enable fails", func() {
@@ -255,6 +349,72 @@ func init() { //nolint:funlen,gochecknoinits
 
 					ItInstallsPkg(act)
 				})
+
+				Context("when the package has a post-install script", func() {
+					BeforeEach(func() {
+						bundle.Installed = true
+						bundle.GetDirPath = "/fake-install-path"
+						Expect(fs.WriteFileString("/fake-install-path/post-install", "")).To(Succeed())
+					})
+
+					It("runs it from the package after enabling the package", func() {
+						err := act()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(cmdRunner.RunCommands).To(HaveLen(1))
+						Expect(cmdRunner.RunCommandJobName).To(Equal("packages/" + pkg.Name))
+						Expect(cmdRunner.RunCommandTaskName).To(Equal("post-install"))
+
+						command := cmdRunner.RunCommands[0]
+						Expect(command.Name).To(Equal("/fake-install-path/post-install"))
+						Expect(command.WorkingDir).To(Equal("/fake-install-path"))
+						Expect(command.Env).To(HaveKeyWithValue("BOSH_PACKAGE_NAME", pkg.Name))
+						Expect(command.Env).To(HaveKeyWithValue("BOSH_PACKAGE_VERSION", pkg.Version))
+						Expect(command.Env).To(HaveKeyWithValue("BOSH_INSTALL_TARGET", "/fake-install-path"))
+					})
+
+					It("returns an error when the script fails", func() {
+						cmdRunner.RunCommandErr = errors.New("fake-run-error")
+
+						err := act()
+						Expect(err).To(MatchError(ContainSubstring("Running post-install script of package")))
+						Expect(err).To(MatchError(ContainSubstring("fake-run-error")))
+						Expect(bundle.ActionsCalled).To(Equal([]string{"Enable"}))
+					})
+
+					It("leaves it to the owner of the packages to run it", func() {
+						applier = NewCompiledPackageApplier(packagesBc, false, blobstore, verifier, models.PermissionPolicy{}, fs, cmdRunner, logger)
+
+						err := act()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(cmdRunner.RunCommands).To(BeEmpty())
+					})
+				})
+
+				It("does not run anything for packages without a post-install script", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(cmdRunner.RunCommands).To(BeEmpty())
+				})
+
+				Context("when packages are job scoped", func() {
+					BeforeEach(func() {
+						applier = NewJobScopedCompiledPackageApplier(packagesBc, blobstore, verifier, models.PermissionPolicy{}, fs, cmdRunner, logger)
+					})
+
+					It("installs the package and disables it instead of enabling it", func() {
+						err := act()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(bundle.ActionsCalled).To(Equal([]string{"Install", "Disable", "MountReadOnly"}))
+					})
+
+					It("returns error when disabling the package fails", func() {
+						bundle.DisableErr = errors.New("fake-disable-error")
+
+						err := act()
+						Expect(err).To(MatchError(ContainSubstring("Disabling package: fake-disable-error")))
+					})
+				})
 			})
 		})
 
@@ -263,7 +423,7 @@ func init() { //nolint:funlen,gochecknoinits
 				It("returns error when bundle collection fails to return list of installed bundles", func() {
 					packagesBc.ListErr = errors.New("fake-bc-list-error")
 
-					err := applier.KeepOnly([]models.Package{})
+					err := applier.KeepOnly([]models.Package{}, boshbc.RetentionPolicy{})
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-bc-list-error"))
 				})
@@ -274,7 +434,7 @@ func init() { //nolint:funlen,gochecknoinits
 					packagesBc.ListBundles = []boshbc.Bundle{bundle1}
 					packagesBc.GetErr = errors.New("fake-bc-get-error")
 
-					err := applier.KeepOnly([]models.Package{pkg1})
+					err := applier.KeepOnly([]models.Package{pkg1}, boshbc.RetentionPolicy{})
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-bc-get-error"))
 				})
@@ -285,7 +445,7 @@ func init() { //nolint:funlen,gochecknoinits
 					packagesBc.ListBundles = []boshbc.Bundle{bundle1}
 					bundle1.DisableErr = errors.New("fake-bc-disable-error")
 
-					err := applier.KeepOnly([]models.Package{})
+					err := applier.KeepOnly([]models.Package{}, boshbc.RetentionPolicy{})
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-bc-disable-error"))
 				})
@@ -293,7 +453,7 @@ func init() { //nolint:funlen,gochecknoinits
 
 			Context("when operating on packages as a package owner", func() {
 				BeforeEach(func() {
-					applier = NewCompiledPackageApplier(packagesBc, true, blobstore, fs, logger)
+					applier = NewCompiledPackageApplier(packagesBc, true, blobstore, verifier, models.PermissionPolicy{}, fs, cmdRunner, logger)
 				})
 
 				It("first disables and then uninstalls packages that are not in keeponly list", func() {
@@ -304,7 +464,7 @@ func init() { //nolint:funlen,gochecknoinits
 
 					packagesBc.ListBundles = []boshbc.Bundle{bundle1, bundle2, bundle3, bundle4}
 
-					err := applier.KeepOnly([]models.Package{pkg4, pkg2})
+					err := applier.KeepOnly([]models.Package{pkg4, pkg2}, boshbc.RetentionPolicy{})
 					Expect(err).ToNot(HaveOccurred())
 
 					Expect(bundle1.ActionsCalled).To(Equal([]string{"Disable", "Uninstall"}))
@@ -313,6 +473,26 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(bundle4.ActionsCalled).To(Equal([]string{}))
 				})
 
+				It("keeps unused packages installed that the retention policy retains", func() {
+					_, bundle1 := buildPkg(packagesBc)
+					pkg2, bundle2 := buildPkg(packagesBc)
+					_, bundle3 := buildPkg(packagesBc)
+
+					now := time.Now()
+					bundle1.GetDirPath, bundle1.InstalledAtTime = "/packages/pkg/1", now.Add(-3*time.Hour)
+					bundle2.GetDirPath, bundle2.InstalledAtTime = "/packages/pkg/3", now.Add(-1*time.Hour)
+					bundle3.GetDirPath, bundle3.InstalledAtTime = "/packages/pkg/2", now.Add(-2*time.Hour)
+
+					packagesBc.ListBundles = []boshbc.Bundle{bundle1, bundle2, bundle3}
+
+					err := applier.KeepOnly([]models.Package{pkg2}, boshbc.NewRetentionPolicy(1, 0, fakeclock.NewFakeClock(now)))
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(bundle1.ActionsCalled).To(Equal([]string{"Disable", "Uninstall"}))
+					Expect(bundle2.ActionsCalled).To(Equal([]string{}))
+					Expect(bundle3.ActionsCalled).To(Equal([]string{"Disable"}))
+				})
+
 				ItReturnsErrors()
 
 				It("returns error when at least one bundle cannot be uninstalled", func() {
@@ -321,7 +501,7 @@ func init() { //nolint:funlen,gochecknoinits
 					packagesBc.ListBundles = []boshbc.Bundle{bundle1}
 					bundle1.UninstallErr = errors.New("fake-bc-uninstall-error")
 
-					err := applier.KeepOnly([]models.Package{})
+					err := applier.KeepOnly([]models.Package{}, boshbc.RetentionPolicy{})
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-bc-uninstall-error"))
 				})
@@ -329,7 +509,7 @@ func init() { //nolint:funlen,gochecknoinits
 
 			Context("when operating on packages not as a package owner", func() {
 				BeforeEach(func() {
-					applier = NewCompiledPackageApplier(packagesBc, false, blobstore, fs, logger)
+					applier = NewCompiledPackageApplier(packagesBc, false, blobstore, verifier, models.PermissionPolicy{}, fs, cmdRunner, logger)
 				})
 
 				It("disables and but does not uninstall packages that are not in keeponly list", func() {
@@ -340,7 +520,7 @@ func init() { //nolint:funlen,gochecknoinits
 
 					packagesBc.ListBundles = []boshbc.Bundle{bundle1, bundle2, bundle3, bundle4}
 
-					err := applier.KeepOnly([]models.Package{pkg4, pkg2})
+					err := applier.KeepOnly([]models.Package{pkg4, pkg2}, boshbc.RetentionPolicy{})
 					Expect(err).ToNot(HaveOccurred())
 
 					Expect(bundle1.ActionsCalled).To(Equal([]string{"Disable"})) // no Uninstall
@@ -3,6 +3,7 @@ package fakes
 import (
 	"sync"
 
+	bc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
 	models "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 )
 
@@ -15,10 +16,12 @@ type FakeApplier struct {
 	AppliedPackages []models.Package
 	ApplyError      error
 
-	KeptOnlyPackages []models.Package
-	KeepOnlyErr      error
-	applyMutex       sync.Mutex
-	PrepareStub      func(pkg models.Package) error
+	KeptOnlyPackages  []models.Package
+	KeptOnlyRetention bc.RetentionPolicy
+	KeepOnlyErr       error
+	applyMutex        sync.Mutex
+	PrepareStub       func(pkg models.Package) error
+	ApplyStub         func(pkg models.Package) error
 }
 
 func NewFakeApplier() *FakeApplier {
@@ -39,13 +42,19 @@ func (s *FakeApplier) Prepare(pkg models.Package) error {
 }
 
 func (s *FakeApplier) Apply(pkg models.Package) error {
+	s.applyMutex.Lock()
 	s.ActionsCalled = append(s.ActionsCalled, "Apply")
 	s.AppliedPackages = append(s.AppliedPackages, pkg)
+	s.applyMutex.Unlock()
+	if s.ApplyStub != nil {
+		return s.ApplyStub(pkg)
+	}
 	return s.ApplyError
 }
 
-func (s *FakeApplier) KeepOnly(pkgs []models.Package) error {
+func (s *FakeApplier) KeepOnly(pkgs []models.Package, retention bc.RetentionPolicy) error {
 	s.ActionsCalled = append(s.ActionsCalled, "KeepOnly")
 	s.KeptOnlyPackages = pkgs
+	s.KeptOnlyRetention = retention
 	return s.KeepOnlyErr
 }
@@ -7,6 +7,7 @@ import (
 
 type FakeApplierProvider struct {
 	RootApplier                          *FakeApplier
+	JobScopedRootApplier                 *FakeApplier
 	JobSpecificAppliers                  map[string]*FakeApplier
 	RootBundleCollectionBundleCollection boshbc.BundleCollection
 }
@@ -24,6 +25,13 @@ func (p *FakeApplierProvider) Root() boshpackages.Applier {
 	return p.RootApplier
 }
 
+func (p *FakeApplierProvider) JobScopedRoot() boshpackages.Applier {
+	if p.JobScopedRootApplier == nil {
+		panic("Job scoped root package applier not found")
+	}
+	return p.JobScopedRootApplier
+}
+
 func (p *FakeApplierProvider) JobSpecific(jobName string) boshpackages.Applier {
 	applier := p.JobSpecificAppliers[jobName]
 	if applier == nil {
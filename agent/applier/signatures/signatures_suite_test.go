@@ -0,0 +1,13 @@
+package signatures_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSignatures(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Signatures Suite")
+}
@@ -0,0 +1,127 @@
+package signatures
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"os"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Verifier
+
+// Verifier checks job and package archives against their detached
+// signatures before they are installed.
+type Verifier interface {
+	// Verify returns an error unless the archive is signed by one of the
+	// keys. Archives without a signature pass unless signatures are
+	// required.
+	Verify(archivePath string, signature *models.Signature) error
+}
+
+type verifier struct {
+	keys     map[string]crypto.PublicKey
+	required bool
+	fs       boshsys.FileSystem
+}
+
+func NewVerifier(settings boshsettings.BundleSigning, fs boshsys.FileSystem) (Verifier, error) {
+	keys := map[string]crypto.PublicKey{}
+
+	for keyID, encodedKey := range settings.PublicKeys {
+		block, _ := pem.Decode([]byte(encodedKey))
+		if block == nil {
+			return nil, bosherr.Errorf("Decoding bundle signing key '%s': no PEM block found", keyID)
+		}
+
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing bundle signing key '%s'", keyID)
+		}
+
+		switch key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		default:
+			return nil, bosherr.Errorf("Unsupported type %T of bundle signing key '%s'", key, keyID)
+		}
+
+		keys[keyID] = key
+	}
+
+	if settings.Required && len(keys) == 0 {
+		return nil, bosherr.Error("Bundle signatures are required but there are no keys to verify them with")
+	}
+
+	return verifier{keys: keys, required: settings.Required, fs: fs}, nil
+}
+
+func (v verifier) Verify(archivePath string, signature *models.Signature) error {
+	if signature == nil {
+		if v.required {
+			return bosherr.Error("Archive is not signed")
+		}
+		return nil
+	}
+
+	// Without keys the director's signatures cannot be checked
+	if len(v.keys) == 0 {
+		return nil
+	}
+
+	key, found := v.keys[signature.KeyID]
+	if !found {
+		return bosherr.Errorf("Archive is signed with unknown key '%s'", signature.KeyID)
+	}
+
+	digest, err := v.digest(archivePath)
+	if err != nil {
+		return err
+	}
+
+	var valid bool
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature.Value) == nil
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest, signature.Value)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, digest, signature.Value)
+	}
+
+	if !valid {
+		return bosherr.Errorf("Archive signature does not match key '%s'", signature.KeyID)
+	}
+
+	return nil
+}
+
+func (v verifier) digest(archivePath string) ([]byte, error) {
+	file, err := v.fs.OpenFile(archivePath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Opening archive")
+	}
+
+	defer func() {
+		_ = file.Close() //nolint:errcheck
+	}()
+
+	hash := sha256.New()
+
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading archive")
+	}
+
+	return hash.Sum(nil), nil
+}
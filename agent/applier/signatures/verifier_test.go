@@ -0,0 +1,154 @@
+package signatures_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/signatures"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+func encodePublicKey(key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	Expect(err).ToNot(HaveOccurred())
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+var _ = Describe("Verifier", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		digest []byte
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		Expect(fs.WriteFileString("/archive.tgz", "archive")).To(Succeed())
+
+		sum := sha256.Sum256([]byte("archive"))
+		digest = sum[:]
+	})
+
+	Context("with keys", func() {
+		var (
+			verifier     signatures.Verifier
+			rsaKey       *rsa.PrivateKey
+			ecdsaKey     *ecdsa.PrivateKey
+			ed25519Key   ed25519.PrivateKey
+			rsaSignature []byte
+		)
+
+		BeforeEach(func() {
+			var err error
+
+			rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).ToNot(HaveOccurred())
+
+			ecdsaKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, ed25519Key, err = ed25519.GenerateKey(rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+
+			rsaSignature, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest)
+			Expect(err).ToNot(HaveOccurred())
+
+			verifier, err = signatures.NewVerifier(boshsettings.BundleSigning{
+				PublicKeys: map[string]string{
+					"rsa-key":     encodePublicKey(rsaKey.Public()),
+					"ecdsa-key":   encodePublicKey(ecdsaKey.Public()),
+					"ed25519-key": encodePublicKey(ed25519Key.Public()),
+				},
+			}, fs)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("accepts archives signed with any type of key", func() {
+			ecdsaSignature, err := ecdsa.SignASN1(rand.Reader, ecdsaKey, digest)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(verifier.Verify("/archive.tgz", &models.Signature{KeyID: "rsa-key", Value: rsaSignature})).To(Succeed())
+			Expect(verifier.Verify("/archive.tgz", &models.Signature{KeyID: "ecdsa-key", Value: ecdsaSignature})).To(Succeed())
+			Expect(verifier.Verify("/archive.tgz", &models.Signature{KeyID: "ed25519-key", Value: ed25519.Sign(ed25519Key, digest)})).To(Succeed())
+		})
+
+		It("rejects tampered archives", func() {
+			Expect(fs.WriteFileString("/archive.tgz", "tampered")).To(Succeed())
+
+			err := verifier.Verify("/archive.tgz", &models.Signature{KeyID: "rsa-key", Value: rsaSignature})
+			Expect(err).To(MatchError("Archive signature does not match key 'rsa-key'"))
+		})
+
+		It("rejects signatures of other keys", func() {
+			err := verifier.Verify("/archive.tgz", &models.Signature{KeyID: "ecdsa-key", Value: rsaSignature})
+			Expect(err).To(MatchError("Archive signature does not match key 'ecdsa-key'"))
+		})
+
+		It("rejects signatures of unknown keys", func() {
+			err := verifier.Verify("/archive.tgz", &models.Signature{KeyID: "other-key", Value: rsaSignature})
+			Expect(err).To(MatchError("Archive is signed with unknown key 'other-key'"))
+		})
+
+		It("accepts archives without signatures", func() {
+			Expect(verifier.Verify("/archive.tgz", nil)).To(Succeed())
+		})
+
+		It("returns an error when the archive cannot be read", func() {
+			fs.OpenFileErr = errors.New("fake-open-error")
+
+			err := verifier.Verify("/archive.tgz", &models.Signature{KeyID: "rsa-key", Value: rsaSignature})
+			Expect(err).To(MatchError(ContainSubstring("Opening archive: fake-open-error")))
+		})
+	})
+
+	Context("when signatures are required", func() {
+		It("rejects archives without signatures", func() {
+			key, _, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+
+			verifier, err := signatures.NewVerifier(boshsettings.BundleSigning{
+				PublicKeys: map[string]string{"some-key": encodePublicKey(key)},
+				Required:   true,
+			}, fs)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(verifier.Verify("/archive.tgz", nil)).To(MatchError("Archive is not signed"))
+		})
+
+		It("needs keys", func() {
+			_, err := signatures.NewVerifier(boshsettings.BundleSigning{Required: true}, fs)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("does not verify anything without keys", func() {
+		verifier, err := signatures.NewVerifier(boshsettings.BundleSigning{}, fs)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(verifier.Verify("/archive.tgz", &models.Signature{KeyID: "some-key"})).To(Succeed())
+	})
+
+	It("returns an error for keys that are not PEM encoded public keys", func() {
+		_, err := signatures.NewVerifier(boshsettings.BundleSigning{PublicKeys: map[string]string{"some-key": "not-pem"}}, fs)
+		Expect(err).To(MatchError(ContainSubstring("Decoding bundle signing key 'some-key'")))
+
+		_, err = signatures.NewVerifier(boshsettings.BundleSigning{PublicKeys: map[string]string{
+			"some-key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("garbage")})),
+		}}, fs)
+		Expect(err).To(MatchError(ContainSubstring("Parsing bundle signing key 'some-key'")))
+	})
+})
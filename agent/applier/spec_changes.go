@@ -0,0 +1,143 @@
+package applier
+
+import (
+	"sort"
+	"strings"
+
+	as "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+// SpecChanges describes what applying a desired spec changes compared to the
+// currently applied spec.
+type SpecChanges struct {
+	Jobs     BundleChanges `json:"jobs"`
+	Packages BundleChanges `json:"packages"`
+
+	// Monit lists the jobs whose monit configuration is added, replaced or
+	// removed, unchanged jobs keep theirs
+	Monit MonitChanges `json:"monit"`
+}
+
+// BundleChanges lists jobs or packages by name.
+type BundleChanges struct {
+	Added     []string `json:"added"`
+	Upgraded  []string `json:"upgraded"`
+	Removed   []string `json:"removed"`
+	Unchanged []string `json:"unchanged"`
+}
+
+type MonitChanges struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Removed []string `json:"removed"`
+}
+
+// DiffSpecs compares the jobs and packages by name and fingerprint. Without
+// a current spec everything is added.
+func DiffSpecs(currentSpec, desiredSpec as.ApplySpec) SpecChanges {
+	currentJobs := map[string]string{}
+	currentPackages := map[string]string{}
+
+	if currentSpec != nil {
+		for _, job := range currentSpec.Jobs() {
+			currentJobs[job.Name] = jobFingerprint(job)
+		}
+
+		for _, pkg := range currentSpec.Packages() {
+			currentPackages[pkg.Name] = packageFingerprint(pkg)
+		}
+	}
+
+	desiredJobs := map[string]string{}
+	for _, job := range desiredSpec.Jobs() {
+		desiredJobs[job.Name] = jobFingerprint(job)
+	}
+
+	desiredPackages := map[string]string{}
+	for _, pkg := range desiredSpec.Packages() {
+		desiredPackages[pkg.Name] = packageFingerprint(pkg)
+	}
+
+	jobs := diffFingerprints(currentJobs, desiredJobs)
+
+	return SpecChanges{
+		Jobs:     jobs,
+		Packages: diffFingerprints(currentPackages, desiredPackages),
+		Monit: MonitChanges{
+			Added:   jobs.Added,
+			Updated: jobs.Upgraded,
+			Removed: jobs.Removed,
+		},
+	}
+}
+
+func (c SpecChanges) JobUnchanged(job models.Job) bool {
+	return contains(c.Jobs.Unchanged, job.Name)
+}
+
+func (c SpecChanges) PackageUnchanged(pkg models.Package) bool {
+	return contains(c.Packages.Unchanged, pkg.Name)
+}
+
+func diffFingerprints(current, desired map[string]string) BundleChanges {
+	changes := BundleChanges{
+		Added:     []string{},
+		Upgraded:  []string{},
+		Removed:   []string{},
+		Unchanged: []string{},
+	}
+
+	for name, fingerprint := range desired {
+		currentFingerprint, found := current[name]
+
+		switch {
+		case !found:
+			changes.Added = append(changes.Added, name)
+		case currentFingerprint != fingerprint:
+			changes.Upgraded = append(changes.Upgraded, name)
+		default:
+			changes.Unchanged = append(changes.Unchanged, name)
+		}
+	}
+
+	for name := range current {
+		if _, found := desired[name]; !found {
+			changes.Removed = append(changes.Removed, name)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Upgraded)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Unchanged)
+
+	return changes
+}
+
+func contains(sortedNames []string, name string) bool {
+	i := sort.SearchStrings(sortedNames, name)
+	return i < len(sortedNames) && sortedNames[i] == name
+}
+
+// jobFingerprint includes the job's packages since they are linked from
+// within the job
+func jobFingerprint(job models.Job) string {
+	parts := []string{job.Version, sourceFingerprint(job.Source)}
+	for _, pkg := range job.Packages {
+		parts = append(parts, pkg.Name+":"+packageFingerprint(pkg))
+	}
+	parts = append(parts, job.DeclaredPackages...)
+	return strings.Join(parts, ",")
+}
+
+func packageFingerprint(pkg models.Package) string {
+	return pkg.Version + "-" + sourceFingerprint(pkg.Source)
+}
+
+func sourceFingerprint(source models.Source) string {
+	if source.Sha1 == nil {
+		return ""
+	}
+	return source.Sha1.String()
+}
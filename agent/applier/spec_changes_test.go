@@ -0,0 +1,77 @@
+package applier_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+var _ = Describe("DiffSpecs", func() {
+	It("adds every job and package without a current spec", func() {
+		changes := applier.DiffSpecs(nil, &fakeas.FakeApplySpec{
+			JobResults:     []models.Job{{Name: "job-b", Version: "1"}, {Name: "job-a", Version: "1"}},
+			PackageResults: []models.Package{{Name: "pkg", Version: "1"}},
+		})
+
+		Expect(changes.Jobs).To(Equal(applier.BundleChanges{
+			Added:     []string{"job-a", "job-b"},
+			Upgraded:  []string{},
+			Removed:   []string{},
+			Unchanged: []string{},
+		}))
+		Expect(changes.Packages.Added).To(Equal([]string{"pkg"}))
+		Expect(changes.Monit.Added).To(Equal([]string{"job-a", "job-b"}))
+	})
+
+	It("compares jobs and packages by name and fingerprint", func() {
+		pkg := models.Package{Name: "pkg", Version: "1"}
+		upgradedPkg := models.Package{Name: "pkg", Version: "2"}
+
+		currentSpec := &fakeas.FakeApplySpec{
+			JobResults: []models.Job{
+				{Name: "unchanged-job", Version: "1"},
+				{Name: "upgraded-job", Version: "1"},
+				{Name: "job-with-upgraded-package", Version: "1", Packages: []models.Package{pkg}},
+				{Name: "removed-job", Version: "1"},
+			},
+			PackageResults: []models.Package{pkg, {Name: "removed-pkg", Version: "1"}},
+		}
+		desiredSpec := &fakeas.FakeApplySpec{
+			JobResults: []models.Job{
+				{Name: "unchanged-job", Version: "1"},
+				{Name: "upgraded-job", Version: "2"},
+				{Name: "job-with-upgraded-package", Version: "1", Packages: []models.Package{upgradedPkg}},
+				{Name: "added-job", Version: "1"},
+			},
+			PackageResults: []models.Package{upgradedPkg, {Name: "added-pkg", Version: "1"}},
+		}
+
+		changes := applier.DiffSpecs(currentSpec, desiredSpec)
+
+		Expect(changes).To(Equal(applier.SpecChanges{
+			Jobs: applier.BundleChanges{
+				Added:     []string{"added-job"},
+				Upgraded:  []string{"job-with-upgraded-package", "upgraded-job"},
+				Removed:   []string{"removed-job"},
+				Unchanged: []string{"unchanged-job"},
+			},
+			Packages: applier.BundleChanges{
+				Added:     []string{"added-pkg"},
+				Upgraded:  []string{"pkg"},
+				Removed:   []string{"removed-pkg"},
+				Unchanged: []string{},
+			},
+			Monit: applier.MonitChanges{
+				Added:   []string{"added-job"},
+				Updated: []string{"job-with-upgraded-package", "upgraded-job"},
+				Removed: []string{"removed-job"},
+			},
+		}))
+
+		Expect(changes.JobUnchanged(models.Job{Name: "unchanged-job"})).To(BeTrue())
+		Expect(changes.JobUnchanged(models.Job{Name: "upgraded-job"})).To(BeFalse())
+	})
+})
@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package artifact
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// openArtifact does not follow a symlink replacing the resolved file
+func openArtifact(resolvedPath string) (*os.File, error) {
+	return os.OpenFile(resolvedPath, os.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK, 0)
+}
+
+// openFilePath opens the file that is already open, even when its path now
+// leads somewhere else, and tells where the file is when read as a link
+func openFilePath(file *os.File) string {
+	return fmt.Sprintf("/proc/self/fd/%d", file.Fd())
+}
+
+func onlyReadableByRoot(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+
+	return stat.Uid == 0 && info.Mode().Perm()&0044 == 0
+}
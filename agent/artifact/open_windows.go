@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package artifact
+
+import (
+	"os"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// The artifact server only runs on Linux
+func openArtifact(_ string) (*os.File, error) {
+	return nil, bosherr.Error("Uploading artifacts is not supported on Windows")
+}
+
+func openFilePath(file *os.File) string {
+	return file.Name()
+}
+
+func onlyReadableByRoot(_ os.FileInfo) bool {
+	return true
+}
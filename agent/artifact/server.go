@@ -0,0 +1,123 @@
+package artifact
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const serverLogTag = "artifactServer"
+
+// UploadRequest is the body jobs POST to /artifacts.
+type UploadRequest struct {
+	Job  string `json:"job"`
+	Path string `json:"path"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server lets jobs on the VM request artifact uploads over a unix socket that
+// only root and the vcap group can connect to. It answers POST /artifacts
+// with the uploaded Artifact.
+type Server struct {
+	uploader   Uploader
+	fs         boshsys.FileSystem
+	socketPath string
+	logger     boshlog.Logger
+}
+
+func NewServer(uploader Uploader, fs boshsys.FileSystem, socketPath string, logger boshlog.Logger) *Server {
+	return &Server{
+		uploader:   uploader,
+		fs:         fs,
+		socketPath: socketPath,
+		logger:     logger,
+	}
+}
+
+// ListenAndServe replaces a socket left over by a previous agent and serves
+// requests until listening fails.
+func (s *Server) ListenAndServe() error {
+	err := s.fs.RemoveAll(s.socketPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing stale artifact socket")
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Listening on artifact socket")
+	}
+
+	defer listener.Close() //nolint:errcheck
+
+	err = s.fs.Chown(s.socketPath, "root:vcap")
+	if err != nil {
+		return bosherr.WrapError(err, "Chowning artifact socket")
+	}
+
+	err = s.fs.Chmod(s.socketPath, os.FileMode(0770))
+	if err != nil {
+		return bosherr.WrapError(err, "Chmoding artifact socket")
+	}
+
+	s.logger.Info(serverLogTag, "Serving artifact uploads on %s", s.socketPath)
+
+	server := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	err = server.Serve(listener)
+	if err != nil {
+		return bosherr.WrapError(err, "Serving artifact uploads")
+	}
+
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/artifacts" {
+		s.respond(w, http.StatusNotFound, errorResponse{Error: "Not found"})
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		s.respond(w, http.StatusMethodNotAllowed, errorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	var request UploadRequest
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&request)
+	if err != nil {
+		s.respond(w, http.StatusBadRequest, errorResponse{Error: "Decoding upload request: " + err.Error()})
+		return
+	}
+
+	artifact, err := s.uploader.Upload(request.Job, request.Path)
+	if err != nil {
+		s.logger.Error(serverLogTag, "Uploading artifact %s of job %s: %s", request.Path, request.Job, err.Error())
+		s.respond(w, http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+		return
+	}
+
+	s.respond(w, http.StatusOK, artifact)
+}
+
+func (s *Server) respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		s.logger.Warn(serverLogTag, "Writing response: %s", err.Error())
+	}
+}
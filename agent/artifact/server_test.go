@@ -0,0 +1,87 @@
+package artifact_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/artifact"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/artifact/artifactfakes"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		uploader *artifactfakes.FakeUploader
+		server   *artifact.Server
+		recorder *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		uploader = &artifactfakes.FakeUploader{}
+		server = artifact.NewServer(uploader, fakesys.NewFakeFileSystem(), "/var/vcap/bosh/artifacts.sock", boshlog.NewLogger(boshlog.LevelNone))
+		recorder = httptest.NewRecorder()
+	})
+
+	serve := func(method, path, body string) {
+		server.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	}
+
+	It("uploads the requested artifact", func() {
+		uploader.UploadReturns(artifact.Artifact{
+			Job:         "fake-job",
+			Path:        "/var/vcap/store/fake-job/backup.tgz",
+			Size:        11,
+			BlobstoreID: "fake-blob-id",
+			SHA1Digest:  "fake-sha1",
+		}, nil)
+
+		serve("POST", "/artifacts", `{"job":"fake-job","path":"/var/vcap/store/fake-job/backup.tgz"}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(MatchJSON(`{
+			"job": "fake-job",
+			"path": "/var/vcap/store/fake-job/backup.tgz",
+			"size": 11,
+			"blobstore_id": "fake-blob-id",
+			"sha1": "fake-sha1"
+		}`))
+
+		job, path := uploader.UploadArgsForCall(0)
+		Expect(job).To(Equal("fake-job"))
+		Expect(path).To(Equal("/var/vcap/store/fake-job/backup.tgz"))
+	})
+
+	It("responds with the error when uploading fails", func() {
+		uploader.UploadReturns(artifact.Artifact{}, errors.New("fake-upload-error"))
+
+		serve("POST", "/artifacts", `{"job":"fake-job","path":"/etc/shadow"}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(recorder.Body.String()).To(MatchJSON(`{"error": "fake-upload-error"}`))
+	})
+
+	It("rejects malformed requests", func() {
+		serve("POST", "/artifacts", `not-json`)
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(uploader.UploadCallCount()).To(Equal(0))
+	})
+
+	It("rejects other methods", func() {
+		serve("GET", "/artifacts", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("rejects other paths", func() {
+		serve("POST", "/other", "{}")
+
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,177 @@
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	blobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+const uploaderLogTag = "artifactUploader"
+
+var jobNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// Artifact is a file of a job the agent uploaded to the blobstore.
+type Artifact struct {
+	Job         string `json:"job"`
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	BlobstoreID string `json:"blobstore_id"`
+	SHA1Digest  string `json:"sha1"`
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Uploader
+
+// Uploader uploads files jobs produced, e.g. backups, to the blobstore with
+// the credentials of the agent.
+type Uploader interface {
+	// Upload uploads the regular file at path, which must be in the data or
+	// store directory of one of the applied jobs after following symlinks,
+	// so that jobs can not have the agent upload files they can not read
+	// themselves. Files owned by root must be readable by group or others.
+	Upload(job, path string) (Artifact, error)
+}
+
+type uploader struct {
+	blobstore   blobdelegator.BlobstoreDelegator
+	specService boshas.V1Service
+	fs          boshsys.FileSystem
+	dirProvider boshdirs.Provider
+	logger      boshlog.Logger
+}
+
+func NewUploader(
+	blobstore blobdelegator.BlobstoreDelegator,
+	specService boshas.V1Service,
+	fs boshsys.FileSystem,
+	dirProvider boshdirs.Provider,
+	logger boshlog.Logger,
+) Uploader {
+	return uploader{
+		blobstore:   blobstore,
+		specService: specService,
+		fs:          fs,
+		dirProvider: dirProvider,
+		logger:      logger,
+	}
+}
+
+func (u uploader) Upload(job, path string) (Artifact, error) {
+	if !jobNamePattern.MatchString(job) {
+		return Artifact{}, bosherr.Errorf("Invalid job name '%s'", job)
+	}
+
+	// Other directories in the data directory, e.g. the blob cache, are
+	// only readable by root
+	applied, err := u.isAppliedJob(job)
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	if !applied {
+		return Artifact{}, bosherr.Errorf("Job '%s' is not applied", job)
+	}
+
+	if !filepath.IsAbs(path) {
+		return Artifact{}, bosherr.Errorf("Artifact path '%s' must be absolute", path)
+	}
+
+	resolvedPath, err := u.fs.ReadAndFollowLink(path)
+	if err != nil {
+		return Artifact{}, bosherr.WrapErrorf(err, "Resolving artifact path '%s'", path)
+	}
+
+	if !u.inJobDirs(job, resolvedPath) {
+		return Artifact{}, bosherr.Errorf("Artifact path '%s' is not in the data or store directory of job '%s'", path, job)
+	}
+
+	// The job may replace the file with a symlink after it was resolved, so
+	// the file is checked again once it is open and uploaded from there
+	file, err := openArtifact(resolvedPath)
+	if err != nil {
+		return Artifact{}, bosherr.WrapErrorf(err, "Opening artifact '%s'", path)
+	}
+
+	defer file.Close() //nolint:errcheck
+
+	info, err := file.Stat()
+	if err != nil {
+		return Artifact{}, bosherr.WrapErrorf(err, "Checking artifact '%s'", path)
+	}
+
+	if !info.Mode().IsRegular() {
+		return Artifact{}, bosherr.Errorf("Artifact '%s' is not a regular file", path)
+	}
+
+	if onlyReadableByRoot(info) {
+		return Artifact{}, bosherr.Errorf("Artifact '%s' is only readable by root", path)
+	}
+
+	openedPath, err := os.Readlink(openFilePath(file))
+	if err != nil {
+		return Artifact{}, bosherr.WrapErrorf(err, "Checking artifact '%s'", path)
+	}
+
+	if !u.inJobDirs(job, openedPath) {
+		return Artifact{}, bosherr.Errorf("Artifact path '%s' is not in the data or store directory of job '%s'", path, job)
+	}
+
+	u.logger.Info(uploaderLogTag, "Uploading artifact %s of job %s", openedPath, job)
+
+	blobID, digest, err := u.blobstore.Write("", openFilePath(file), nil)
+	if err != nil {
+		return Artifact{}, bosherr.WrapError(err, "Create file on blobstore")
+	}
+
+	return Artifact{
+		Job:         job,
+		Path:        path,
+		Size:        info.Size(),
+		BlobstoreID: blobID,
+		SHA1Digest:  digest.String(),
+	}, nil
+}
+
+func (u uploader) isAppliedJob(job string) (bool, error) {
+	spec, err := u.specService.Get()
+	if err != nil {
+		return false, bosherr.WrapError(err, "Getting applied spec")
+	}
+
+	for _, appliedJob := range spec.Jobs() {
+		if appliedJob.Name == job {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (u uploader) inJobDirs(job, resolvedPath string) bool {
+	jobDirs := []string{
+		u.dirProvider.JobDir(job),
+		filepath.Join(u.dirProvider.StoreDir(), job),
+	}
+
+	for _, dir := range jobDirs {
+		// The directories themselves may be symlinks, e.g. when the store
+		// is mounted elsewhere
+		if resolvedDir, err := u.fs.ReadAndFollowLink(dir); err == nil {
+			dir = resolvedDir
+		}
+
+		if strings.HasPrefix(resolvedPath, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,182 @@
+package artifact_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/artifact"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+var _ = Describe("Uploader", func() {
+	var (
+		blobstore   *blobstore_delegatorfakes.FakeBlobstoreDelegator
+		specService *fakeas.FakeV1Service
+		dirProvider boshdirs.Provider
+		uploader    artifact.Uploader
+		backupPath  string
+	)
+
+	BeforeEach(func() {
+		blobstore = &blobstore_delegatorfakes.FakeBlobstoreDelegator{}
+		blobstore.WriteReturns("fake-blob-id", boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil)
+
+		specService = fakeas.NewFakeV1Service()
+		specService.Spec = boshas.V1ApplySpec{
+			JobSpec: boshas.JobSpec{
+				JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-job"}, {Name: "other-job"}},
+			},
+			RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{},
+		}
+
+		dirProvider = boshdirs.NewProvider(GinkgoT().TempDir())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		uploader = artifact.NewUploader(blobstore, specService, boshsys.NewOsFileSystem(logger), dirProvider, logger)
+
+		Expect(os.MkdirAll(filepath.Join(dirProvider.StoreDir(), "fake-job", "backups"), 0700)).To(Succeed())
+		backupPath = filepath.Join(dirProvider.StoreDir(), "fake-job", "backups", "backup.tgz")
+		Expect(os.WriteFile(backupPath, []byte("fake-backup"), 0640)).To(Succeed())
+	})
+
+	It("uploads files in the store directory of the job", func() {
+		var uploadedContents []byte
+		blobstore.WriteStub = func(_, path string, _ map[string]string) (string, boshcrypto.MultipleDigest, error) {
+			var err error
+			uploadedContents, err = os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			return "fake-blob-id", boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil
+		}
+
+		uploaded, err := uploader.Upload("fake-job", backupPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(uploaded).To(Equal(artifact.Artifact{
+			Job:         "fake-job",
+			Path:        backupPath,
+			Size:        11,
+			BlobstoreID: "fake-blob-id",
+			SHA1Digest:  "fake-sha1",
+		}))
+
+		signedURL, _, headers := blobstore.WriteArgsForCall(0)
+		Expect(signedURL).To(BeEmpty())
+		Expect(headers).To(BeNil())
+		Expect(string(uploadedContents)).To(Equal("fake-backup"))
+	})
+
+	It("uploads the file it checked even when the path is replaced with a symlink meanwhile", func() {
+		secretPath := filepath.Join(dirProvider.BoshDir(), "settings.json")
+		Expect(os.MkdirAll(dirProvider.BoshDir(), 0700)).To(Succeed())
+		Expect(os.WriteFile(secretPath, []byte("fake-secret"), 0644)).To(Succeed())
+
+		var uploadedContents []byte
+		blobstore.WriteStub = func(_, path string, _ map[string]string) (string, boshcrypto.MultipleDigest, error) {
+			Expect(os.Remove(backupPath)).To(Succeed())
+			Expect(os.Symlink(secretPath, backupPath)).To(Succeed())
+
+			var err error
+			uploadedContents, err = os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			return "fake-blob-id", boshcrypto.MultipleDigest{}, nil
+		}
+
+		_, err := uploader.Upload("fake-job", backupPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(uploadedContents)).To(Equal("fake-backup"))
+	})
+
+	It("refuses jobs that are not applied, e.g. other directories in the data directory", func() {
+		Expect(os.MkdirAll(dirProvider.JobDir("blob_cache"), 0700)).To(Succeed())
+		cachedPath := filepath.Join(dirProvider.JobDir("blob_cache"), "fake-blob")
+		Expect(os.WriteFile(cachedPath, []byte("fake-blob"), 0640)).To(Succeed())
+
+		_, err := uploader.Upload("blob_cache", cachedPath)
+		Expect(err).To(MatchError("Job 'blob_cache' is not applied"))
+		Expect(blobstore.WriteCallCount()).To(Equal(0))
+	})
+
+	It("returns an error when the applied spec cannot be read", func() {
+		specService.GetErr = errors.New("fake-get-error")
+
+		_, err := uploader.Upload("fake-job", backupPath)
+		Expect(err).To(MatchError("Getting applied spec: fake-get-error"))
+	})
+
+	It("refuses files only root can read", func() {
+		if os.Geteuid() != 0 {
+			Skip("only root can create files owned by root")
+		}
+
+		Expect(os.Chmod(backupPath, 0600)).To(Succeed())
+
+		_, err := uploader.Upload("fake-job", backupPath)
+		Expect(err).To(MatchError(ContainSubstring("is only readable by root")))
+		Expect(blobstore.WriteCallCount()).To(Equal(0))
+	})
+
+	It("uploads files in the data directory of the job", func() {
+		Expect(os.MkdirAll(dirProvider.JobDir("fake-job"), 0700)).To(Succeed())
+		dataPath := filepath.Join(dirProvider.JobDir("fake-job"), "artifact.tgz")
+		Expect(os.WriteFile(dataPath, []byte("fake-artifact"), 0640)).To(Succeed())
+
+		uploaded, err := uploader.Upload("fake-job", dataPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(uploaded.BlobstoreID).To(Equal("fake-blob-id"))
+	})
+
+	It("refuses files of other jobs", func() {
+		_, err := uploader.Upload("other-job", backupPath)
+		Expect(err).To(MatchError(ContainSubstring("is not in the data or store directory of job 'other-job'")))
+		Expect(blobstore.WriteCallCount()).To(Equal(0))
+	})
+
+	It("refuses symlinks pointing out of the job directories", func() {
+		secretPath := filepath.Join(dirProvider.BoshDir(), "settings.json")
+		Expect(os.MkdirAll(dirProvider.BoshDir(), 0700)).To(Succeed())
+		Expect(os.WriteFile(secretPath, []byte("fake-secret"), 0600)).To(Succeed())
+
+		linkPath := filepath.Join(dirProvider.StoreDir(), "fake-job", "backups", "link.tgz")
+		Expect(os.Symlink(secretPath, linkPath)).To(Succeed())
+
+		_, err := uploader.Upload("fake-job", linkPath)
+		Expect(err).To(MatchError(ContainSubstring("is not in the data or store directory of job 'fake-job'")))
+		Expect(blobstore.WriteCallCount()).To(Equal(0))
+	})
+
+	It("refuses paths escaping the job directories", func() {
+		_, err := uploader.Upload("fake-job", filepath.Join(dirProvider.StoreDir(), "fake-job", "..", "fake-job"))
+		Expect(err).To(MatchError(ContainSubstring("is not in the data or store directory of job 'fake-job'")))
+	})
+
+	It("refuses directories", func() {
+		_, err := uploader.Upload("fake-job", filepath.Dir(backupPath))
+		Expect(err).To(MatchError(ContainSubstring("is not a regular file")))
+	})
+
+	It("refuses relative paths", func() {
+		_, err := uploader.Upload("fake-job", "backups/backup.tgz")
+		Expect(err).To(MatchError("Artifact path 'backups/backup.tgz' must be absolute"))
+	})
+
+	It("refuses invalid job names", func() {
+		_, err := uploader.Upload("..", backupPath)
+		Expect(err).To(MatchError("Invalid job name '..'"))
+	})
+
+	It("returns an error when uploading fails", func() {
+		blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-write-error"))
+
+		_, err := uploader.Upload("fake-job", backupPath)
+		Expect(err).To(MatchError("Create file on blobstore: fake-write-error"))
+	})
+})
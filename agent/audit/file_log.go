@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+const (
+	fileLogLogTag = "AuditLog"
+
+	// Once the log grows beyond DefaultMaxSize bytes it is moved aside to
+	// the rotated log, replacing the previously rotated one
+	DefaultMaxSize = 10 * 1024 * 1024
+
+	rotatedSuffix = ".1"
+)
+
+type fileLog struct {
+	fs           boshsys.FileSystem
+	path         string
+	maxSize      int64
+	taskService  boshtask.Service
+	timeProvider clock.Clock
+	logger       boshlog.Logger
+
+	lock sync.Mutex
+}
+
+// NewFileLog appends events as lines of JSON to the file at path. Events
+// are attributed to the task the task service is running at the time.
+func NewFileLog(
+	fs boshsys.FileSystem,
+	path string,
+	maxSize int64,
+	taskService boshtask.Service,
+	timeProvider clock.Clock,
+	logger boshlog.Logger,
+) Log {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	return &fileLog{
+		fs:           fs,
+		path:         path,
+		maxSize:      maxSize,
+		taskService:  taskService,
+		timeProvider: timeProvider,
+		logger:       logger,
+	}
+}
+
+func (l *fileLog) Record(event Event) {
+	event.Time = l.timeProvider.Now().UTC()
+
+	// Tasks are processed one at a time, the first queued one is running
+	if queued := l.taskService.QueuedTasks(); len(queued) > 0 {
+		event.TaskID = queued[0].ID
+	}
+
+	err := l.append(event)
+	if err != nil {
+		l.logger.Error(fileLogLogTag, "Recording %s event: %s", event.Operation, err.Error())
+	}
+}
+
+func (l *fileLog) append(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling audit event")
+	}
+
+	line = append(line, '\n')
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	err = l.rotateIfFull()
+	if err != nil {
+		return err
+	}
+
+	file, err := l.fs.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening audit log")
+	}
+
+	_, err = file.Write(line)
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return bosherr.WrapError(err, "Appending to audit log")
+	}
+
+	return nil
+}
+
+func (l *fileLog) rotateIfFull() error {
+	if !l.fs.FileExists(l.path) {
+		return nil
+	}
+
+	info, err := l.fs.Stat(l.path)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking size of audit log")
+	}
+
+	if info.Size() < l.maxSize {
+		return nil
+	}
+
+	err = l.fs.Rename(l.path, l.path+rotatedSuffix)
+	if err != nil {
+		return bosherr.WrapError(err, "Rotating audit log")
+	}
+
+	return nil
+}
+
+func (l *fileLog) Events() ([]Event, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	events := []Event{}
+
+	for _, path := range []string{l.path + rotatedSuffix, l.path} {
+		if !l.fs.FileExists(path) {
+			continue
+		}
+
+		contents, err := l.fs.ReadFileWithOpts(path, boshsys.ReadOpts{Quiet: true})
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Reading audit log")
+		}
+
+		for _, line := range bytes.Split(contents, []byte("\n")) {
+			var event Event
+
+			// Lines torn by a crash are skipped
+			if len(line) == 0 || json.Unmarshal(line, &event) != nil {
+				continue
+			}
+
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,97 @@
+package audit_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+)
+
+var _ = Describe("fileLog", func() {
+	var (
+		path        string
+		taskService *faketask.FakeService
+		now         time.Time
+		auditLog    audit.Log
+	)
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "audit.log")
+		taskService = faketask.NewFakeService()
+		now = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		auditLog = audit.NewFileLog(boshsys.NewOsFileSystem(logger), path, 0, taskService, fakeclock.NewFakeClock(now), logger)
+	})
+
+	It("returns no events before any were recorded", func() {
+		Expect(auditLog.Events()).To(BeEmpty())
+	})
+
+	It("appends events with the time and the running task", func() {
+		taskService.QueuedTasksResult = []boshtask.Task{
+			{ID: "fake-apply-task", Method: "apply"},
+			{ID: "fake-queued-task", Method: "apply"},
+		}
+
+		auditLog.Record(audit.Event{Operation: audit.OperationInstall, Collection: "jobs", Name: "fake-job", Digest: "fake-digest"})
+		auditLog.Record(audit.Event{Operation: audit.OperationEnable, Collection: "jobs", Name: "fake-job"})
+
+		Expect(auditLog.Events()).To(Equal([]audit.Event{
+			{Time: now, TaskID: "fake-apply-task", Operation: "install", Collection: "jobs", Name: "fake-job", Digest: "fake-digest"},
+			{Time: now, TaskID: "fake-apply-task", Operation: "enable", Collection: "jobs", Name: "fake-job"},
+		}))
+	})
+
+	It("records events outside of tasks without a task id", func() {
+		auditLog.Record(audit.Event{Operation: audit.OperationSetSpec})
+
+		events, err := auditLog.Events()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].TaskID).To(BeEmpty())
+	})
+
+	It("skips lines torn by a crash", func() {
+		auditLog.Record(audit.Event{Operation: audit.OperationInstall})
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = file.WriteString(`{"operation":"uninst`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		events, err := auditLog.Events()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+	})
+
+	It("rotates the log once it is full and keeps returning the rotated events", func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		auditLog = audit.NewFileLog(boshsys.NewOsFileSystem(logger), path, 1, taskService, fakeclock.NewFakeClock(now), logger)
+
+		auditLog.Record(audit.Event{Operation: audit.OperationInstall})
+		auditLog.Record(audit.Event{Operation: audit.OperationEnable})
+		auditLog.Record(audit.Event{Operation: audit.OperationDisable})
+
+		Expect(path + ".1").To(BeARegularFile())
+
+		events, err := auditLog.Events()
+		Expect(err).ToNot(HaveOccurred())
+
+		var operations []string
+		for _, event := range events {
+			operations = append(operations, event.Operation)
+		}
+		Expect(operations).To(Equal([]string{"enable", "disable"}))
+	})
+})
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"time"
+)
+
+const (
+	OperationInstall   = "install"
+	OperationUninstall = "uninstall"
+	OperationEnable    = "enable"
+	OperationDisable   = "disable"
+	OperationSetSpec   = "set_spec"
+	OperationRevert    = "revert_spec"
+
+	OperationExecCommand = "exec_command"
+)
+
+// Event is a single change to the installed bundles or the applied spec, or
+// a command run by exec_command. Failed operations are recorded as well,
+// with the error they failed with.
+type Event struct {
+	Time   time.Time `json:"time"`
+	TaskID string    `json:"agent_task_id,omitempty"`
+
+	Operation  string `json:"operation"`
+	Collection string `json:"collection,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Path       string `json:"path,omitempty"`
+
+	// Args of an executed command
+	Args []string `json:"args,omitempty"`
+
+	// Digest identifies the contents of a bundle or the applied spec
+	Digest string `json:"digest,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Log
+
+type Log interface {
+	// Record appends the event with the current time and the id of the task
+	// that is running. Failing to record only gets logged so that auditing
+	// does not fail the operations it records.
+	Record(event Event)
+
+	// Events returns the recorded events from oldest to newest
+	Events() ([]Event, error)
+}
@@ -88,6 +88,15 @@ func (boot bootstrap) Run() (err error) { //nolint:gocyclo
 		return bosherr.WrapError(err, "Settings user password")
 	}
 
+	// Reapply the account policy last received via update_settings so it
+	// takes precedence over the env password and survives instance recreation.
+	// A policy that cannot be applied must not keep the VM from coming up.
+	if settings.UpdateSettings.AccountPolicy != nil {
+		if err = boot.platform.SetAccountPolicy(*settings.UpdateSettings.AccountPolicy); err != nil {
+			boot.logger.Error(boot.logTag, "Applying account policy: %s", err.Error())
+		}
+	}
+
 	if err = boot.platform.SetupIPv6(settings.Env.Bosh.IPv6); err != nil {
 		return bosherr.WrapError(err, "Setting up IPv6")
 	}
@@ -159,6 +168,13 @@ func (boot bootstrap) Run() (err error) { //nolint:gocyclo
 		return bosherr.WrapError(err, "Setting up blobs dir")
 	}
 
+	// Stemcells running in containers do not allow changing the core pattern
+	if settings.Env.Bosh.Agent.Settings.CoreDumps.Enabled {
+		if err = boot.platform.SetupCoreDumpsDir(); err != nil {
+			boot.logger.Warn(boot.logTag, "Setting up core dumps dir: %s", err.Error())
+		}
+	}
+
 	if err := boot.checkLastMountedCid(settings); err != nil {
 		return bosherr.WrapError(err, "Checking last mounted CID")
 	}
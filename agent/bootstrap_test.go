@@ -443,6 +443,31 @@ var _ = Describe("bootstrap", func() {
 			Expect(platform.SetupLoggingAndAuditingCallCount()).To(Equal(1))
 		})
 
+		It("leaves the kernel core pattern alone by default", func() {
+			err := bootstrap()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platform.SetupCoreDumpsDirCallCount()).To(Equal(0))
+		})
+
+		Context("when core dumps are enabled", func() {
+			BeforeEach(func() {
+				settingsService.Settings.Env.Bosh.Agent.Settings.CoreDumps.Enabled = true
+			})
+
+			It("sets up the core dumps directory", func() {
+				err := bootstrap()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(platform.SetupCoreDumpsDirCallCount()).To(Equal(1))
+			})
+
+			It("continues when setting up the core dumps directory fails", func() {
+				platform.SetupCoreDumpsDirReturns(errors.New("fake-setup-core-dumps-dir-err"))
+
+				err := bootstrap()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
 		Context("when setting up the tmp directory fails", func() {
 			BeforeEach(func() {
 				platform.SetupTmpDirReturns(errors.New("fake-setup-tmp-dir-err"))
@@ -544,6 +569,35 @@ var _ = Describe("bootstrap", func() {
 			})
 		})
 
+		Context("when update settings contain an account policy", func() {
+			BeforeEach(func() {
+				settingsService.Settings.UpdateSettings.AccountPolicy = &boshsettings.AccountPolicy{
+					PasswordHashes: map[string]string{"vcap": "$6$rotated"},
+				}
+			})
+
+			It("reapplies the account policy", func() {
+				err := bootstrap()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(platform.SetAccountPolicyCallCount()).To(Equal(1))
+				Expect(platform.SetAccountPolicyArgsForCall(0).PasswordHashes).To(Equal(map[string]string{"vcap": "$6$rotated"}))
+			})
+
+			It("logs and continues if applying the account policy fails", func() {
+				platform.SetAccountPolicyReturns(errors.New("fake-policy-error"))
+
+				err := bootstrap()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.ErrorCallCount()).To(Equal(1))
+				tag, msg, args := logger.ErrorArgsForCall(0)
+				Expect(tag).To(Equal("bootstrap"))
+				Expect(fmt.Sprintf(msg, args...)).To(ContainSubstring("fake-policy-error"))
+				Expect(platform.SetupMonitUserCallCount()).To(Equal(1))
+			})
+		})
+
 		It("setups up monit", func() {
 			err := bootstrap()
 			Expect(err).NotTo(HaveOccurred())
@@ -1106,7 +1160,7 @@ var _ = Describe("bootstrap", func() {
 
 				compressor := boshcmd.NewTarballCompressor(runner, fs)
 				copier := boshcmd.NewGenericCpCopier(fs, logger)
-				logsTarProvider := boshlogstarprovider.NewLogsTarProvider(compressor, copier, dirProvider)
+				logsTarProvider := boshlogstarprovider.NewLogsTarProvider(compressor, copier, dirProvider, fs)
 
 				sigarCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{})
 
@@ -0,0 +1,53 @@
+package compiler
+
+import (
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+const (
+	CompileStageInstallingDependencies = "installing_dependencies"
+	CompileStageFetching               = "fetching_package"
+	CompileStagePackaging              = "running_packaging_script"
+	CompileStageCompressing            = "compressing"
+	CompileStageUploading              = "uploading"
+	CompileStageDone                   = "done"
+)
+
+// compileProgress counts the steps of compiling a single package. Every
+// dependency counts as a step besides fetching, packaging, compressing and
+// uploading the package itself.
+type compileProgress struct {
+	reporter  boshtask.StageReporter
+	total     int
+	completed int
+}
+
+func newCompileProgress(reporter boshtask.StageReporter, dependencies int) *compileProgress {
+	return &compileProgress{
+		reporter: reporter,
+		total:    dependencies + 4,
+	}
+}
+
+// Start reports a step, which counts as completed once the next one starts
+func (p *compileProgress) Start(stage, message string) {
+	p.report(stage, message)
+	p.completed++
+}
+
+func (p *compileProgress) Done() {
+	p.completed = p.total
+	p.report(CompileStageDone, "")
+}
+
+func (p *compileProgress) report(stage, message string) {
+	if p.reporter == nil {
+		return
+	}
+
+	p.reporter.ReportStage(boshtask.StageProgress{
+		Stage:   stage,
+		Percent: p.completed * 100 / p.total,
+		Message: message,
+	})
+}
@@ -4,18 +4,24 @@ import (
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 type Compiler interface {
-	Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error)
+	// Compile stops with task.ErrCancelled before the next step once the
+	// cancel signal is cancelled and removes what it installed so far; a nil
+	// signal never cancels
+	Compile(pkg Package, deps []boshmodels.Package, cancel *boshtask.CancelSignal) (blobID string, digest boshcrypto.Digest, err error)
 }
 
 type Package struct {
 	BlobstoreID         string `json:"blobstore_id"`
 	Name                string
-	PackageGetSignedURL string            `json:"package_get_signed_url"`
-	UploadSignedURL     string            `json:"upload_signed_url"`
-	BlobstoreHeaders    map[string]string `json:"blobstore_headers"`
+	PackageGetSignedURL string                            `json:"package_get_signed_url"`
+	UploadSignedURL     string                            `json:"upload_signed_url"`
+	UploadMultipart     *httpblobprovider.MultipartUpload `json:"upload_multipart,omitempty"`
+	BlobstoreHeaders    map[string]string                 `json:"blobstore_headers"`
 	Sha1                boshcrypto.MultipleDigest
 	Version             string
 }
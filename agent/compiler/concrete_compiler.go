@@ -18,6 +18,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages"
 	boshcmdrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 var (
@@ -44,6 +45,7 @@ type concreteCompiler struct {
 	packageApplier     packages.Applier
 	packagesBc         boshbc.BundleCollection
 	timeProvider       clock.Clock
+	stageReporter      boshtask.StageReporter
 }
 
 func NewConcreteCompiler(
@@ -55,6 +57,7 @@ func NewConcreteCompiler(
 	packageApplier packages.Applier,
 	packagesBc boshbc.BundleCollection,
 	timeProvider clock.Clock,
+	stageReporter boshtask.StageReporter,
 ) Compiler {
 	return concreteCompiler{
 		compressor:         compressor,
@@ -65,22 +68,39 @@ func NewConcreteCompiler(
 		packageApplier:     packageApplier,
 		packagesBc:         packagesBc,
 		timeProvider:       timeProvider,
+		stageReporter:      stageReporter,
 	}
 }
 
-func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error) {
-	err = c.packageApplier.KeepOnly([]boshmodels.Package{})
+func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package, cancel *boshtask.CancelSignal) (blobID string, digest boshcrypto.Digest, err error) {
+	err = c.packageApplier.KeepOnly([]boshmodels.Package{}, boshbc.RetentionPolicy{})
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Removing packages")
 	}
 
+	progress := newCompileProgress(c.stageReporter, len(deps))
+
 	for _, dep := range deps {
-		err := c.packageApplier.Apply(dep)
+		err := c.checkCancelled(cancel, nil)
+		if err != nil {
+			return "", nil, err
+		}
+
+		progress.Start(CompileStageInstallingDependencies, fmt.Sprintf("Installing dependent package %s", dep.Name))
+
+		err = c.packageApplier.Apply(dep)
 		if err != nil {
 			return "", nil, bosherr.WrapErrorf(err, "Installing dependent package: '%s'", dep.Name)
 		}
 	}
 
+	err = c.checkCancelled(cancel, nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	progress.Start(CompileStageFetching, fmt.Sprintf("Fetching package %s", pkg.Name))
+
 	compilePath := path.Join(c.compileDirProvider.CompileDir(), pkg.Name)
 
 	depFilePath, err := c.fetchAndUncompress(pkg, compilePath)
@@ -115,6 +135,13 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 		return "", nil, bosherr.WrapError(err, "Enabling new package bundle")
 	}
 
+	err = c.checkCancelled(cancel, compiledPkgBundle)
+	if err != nil {
+		return "", nil, err
+	}
+
+	progress.Start(CompileStagePackaging, fmt.Sprintf("Running packaging script of package %s", pkg.Name))
+
 	scriptPath := path.Join(compilePath, PackagingScriptName)
 
 	if c.fs.FileExists(scriptPath) {
@@ -123,6 +150,13 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 		}
 	}
 
+	err = c.checkCancelled(cancel, compiledPkgBundle)
+	if err != nil {
+		return "", nil, err
+	}
+
+	progress.Start(CompileStageCompressing, fmt.Sprintf("Compressing compiled package %s", pkg.Name))
+
 	tmpPackageTar, err := c.compressor.CompressFilesInDir(installPath, boshcmd.CompressorOptions{NoCompression: c.isNonCompressedTarball(depFilePath)})
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Compressing compiled package")
@@ -132,7 +166,14 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 		_ = c.compressor.CleanUp(tmpPackageTar) //nolint:errcheck
 	}()
 
-	uploadedBlobID, digest, err := c.blobstore.Write(pkg.UploadSignedURL, tmpPackageTar, pkg.BlobstoreHeaders)
+	err = c.checkCancelled(cancel, compiledPkgBundle)
+	if err != nil {
+		return "", nil, err
+	}
+
+	progress.Start(CompileStageUploading, fmt.Sprintf("Uploading compiled package %s", pkg.Name))
+
+	uploadedBlobID, digest, err := c.upload(pkg, tmpPackageTar)
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Uploading compiled package")
 	}
@@ -147,14 +188,57 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 		return "", nil, bosherr.WrapError(err, "Uninstalling compiled package")
 	}
 
-	err = c.packageApplier.KeepOnly([]boshmodels.Package{})
+	err = c.packageApplier.KeepOnly([]boshmodels.Package{}, boshbc.RetentionPolicy{})
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Removing packages")
 	}
 
+	progress.Done()
+
 	return uploadedBlobID, digest, nil
 }
 
+// checkCancelled removes the installed dependencies and the compiled package
+// bundle, when it is installed already, once compiling is cancelled. The
+// compile directory and the compressed package are removed on return.
+func (c concreteCompiler) checkCancelled(cancel *boshtask.CancelSignal, compiledPkgBundle boshbc.Bundle) error {
+	err := cancel.Err()
+	if err == nil {
+		return nil
+	}
+
+	if compiledPkgBundle != nil {
+		_ = compiledPkgBundle.Disable()   //nolint:errcheck
+		_ = compiledPkgBundle.Uninstall() //nolint:errcheck
+	}
+
+	_ = c.packageApplier.KeepOnly([]boshmodels.Package{}, boshbc.RetentionPolicy{}) //nolint:errcheck
+
+	return err
+}
+
+// upload writes the compiled package using the presigned multipart URLs when
+// the director provided them and the tarball does not fit in a single part.
+func (c concreteCompiler) upload(pkg Package, tarballPath string) (string, boshcrypto.MultipleDigest, error) {
+	if pkg.UploadMultipart == nil {
+		return c.blobstore.Write(pkg.UploadSignedURL, tarballPath, pkg.BlobstoreHeaders)
+	}
+
+	if pkg.UploadSignedURL != "" {
+		info, err := c.fs.Stat(tarballPath)
+		if err != nil {
+			return "", boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Checking compiled package size")
+		}
+
+		if info.Size() <= pkg.UploadMultipart.PartSize {
+			return c.blobstore.Write(pkg.UploadSignedURL, tarballPath, pkg.BlobstoreHeaders)
+		}
+	}
+
+	digest, err := c.blobstore.WriteMultipart(*pkg.UploadMultipart, tarballPath, pkg.BlobstoreHeaders)
+	return "", digest, err
+}
+
 func (c concreteCompiler) fetchAndUncompress(pkg Package, targetDir string) (string, error) {
 	if pkg.BlobstoreID == "" && pkg.PackageGetSignedURL == "" {
 		return "", bosherr.Error(fmt.Sprintf("No blobstore reference for package '%s'", pkg.Name))
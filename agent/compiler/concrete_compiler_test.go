@@ -20,7 +20,10 @@ import (
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	fakepackages "github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages/fakes"
 	fakecmdrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
 )
 
 type FakeCompileDirProvider struct {
@@ -75,6 +78,7 @@ func init() { //nolint:funlen,gochecknoinits
 			runner         *fakecmdrunner.FakeFileLoggingCmdRunner
 			packageApplier *fakepackages.FakeApplier
 			packagesBc     *fakebc.FakeBundleCollection
+			stageReporter  *faketask.FakeStageReporter
 		)
 
 		BeforeEach(func() {
@@ -84,6 +88,7 @@ func init() { //nolint:funlen,gochecknoinits
 			runner = fakecmdrunner.NewFakeFileLoggingCmdRunner()
 			packageApplier = fakepackages.NewFakeApplier()
 			packagesBc = fakebc.NewFakeBundleCollection()
+			stageReporter = &faketask.FakeStageReporter{}
 
 			compiler = NewConcreteCompiler(
 				compressor,
@@ -94,6 +99,7 @@ func init() { //nolint:funlen,gochecknoinits
 				packageApplier,
 				packagesBc,
 				new(fakebc.FakeClock),
+				stageReporter,
 			)
 
 			err := fs.MkdirAll("/real-compile-dir", os.ModePerm)
@@ -132,7 +138,7 @@ func init() { //nolint:funlen,gochecknoinits
 					),
 				), nil)
 
-				blobID, digest, err := compiler.Compile(pkg, pkgDeps)
+				blobID, digest, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(blobID).To(Equal("fake-blob-id"))
@@ -150,7 +156,7 @@ func init() { //nolint:funlen,gochecknoinits
 				// Currently algo of source package is used for compilation pkg algo
 				pkg.Sha1 = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, "fakesha"))
 
-				_, digest, err := compiler.Compile(pkg, pkgDeps)
+				_, digest, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 				// echo -n fake-contents|shasum -a 256
 				Expect(digest.String()).To(Equal("sha256:d12d3a3ee8dcdc9e7ea3416fd618298ea50abde2cf434313c6c3edb213f441cd"))
@@ -164,7 +170,7 @@ func init() { //nolint:funlen,gochecknoinits
 			})
 
 			It("cleans up all packages before and after applying dependent packages", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packageApplier.ActionsCalled).To(Equal([]string{"KeepOnly", "Apply", "Apply", "KeepOnly"}))
 				Expect(packageApplier.KeptOnlyPackages).To(BeEmpty())
@@ -173,7 +179,7 @@ func init() { //nolint:funlen,gochecknoinits
 			It("returns an error if cleaning up packages fails", func() {
 				packageApplier.KeepOnlyErr = errors.New("fake-keep-only-error")
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
 			})
@@ -186,7 +192,7 @@ func init() { //nolint:funlen,gochecknoinits
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -199,7 +205,7 @@ func init() { //nolint:funlen,gochecknoinits
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 			})
@@ -212,7 +218,7 @@ func init() { //nolint:funlen,gochecknoinits
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -220,7 +226,7 @@ func init() { //nolint:funlen,gochecknoinits
 			It("returns an error if creating temporary compile target directory during uncompression fails", func() {
 				fs.RegisterMkdirAllError("/fake-compile-dir/pkg_name-bosh-agent-unpack", errors.New("fake-mkdir-error"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 			})
@@ -229,25 +235,25 @@ func init() { //nolint:funlen,gochecknoinits
 				pkg.BlobstoreID = ""
 				pkg.PackageGetSignedURL = ""
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("No blobstore reference for package '%s'", pkg.Name))
 			})
 
 			It("installs dependent packages", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packageApplier.AppliedPackages).To(Equal(pkgDeps))
 			})
 
 			It("cleans up the compile directory", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(fs.FileExists("/fake-compile-dir/pkg_name")).To(BeFalse())
 			})
 
 			It("installs, enables and later cleans up bundle", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(bundle.ActionsCalled).To(Equal([]string{
 					"InstallWithoutContents",
@@ -257,6 +263,67 @@ func init() { //nolint:funlen,gochecknoinits
 				}))
 			})
 
+			It("reports the stages of compiling", func() {
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(stageReporter.Reported).To(Equal([]boshtask.StageProgress{
+					{Stage: CompileStageInstallingDependencies, Percent: 0, Message: "Installing dependent package first_dep_name"},
+					{Stage: CompileStageInstallingDependencies, Percent: 16, Message: "Installing dependent package sec_dep_name"},
+					{Stage: CompileStageFetching, Percent: 33, Message: "Fetching package pkg_name"},
+					{Stage: CompileStagePackaging, Percent: 50, Message: "Running packaging script of package pkg_name"},
+					{Stage: CompileStageCompressing, Percent: 66, Message: "Compressing compiled package pkg_name"},
+					{Stage: CompileStageUploading, Percent: 83, Message: "Uploading compiled package pkg_name"},
+					{Stage: CompileStageDone, Percent: 100},
+				}))
+			})
+
+			It("does not report compiling as done when it fails", func() {
+				blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-upload-error"))
+
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
+				Expect(err).To(HaveOccurred())
+				Expect(stageReporter.Reported[len(stageReporter.Reported)-1].Stage).To(Equal(CompileStageUploading))
+			})
+
+			Context("when cancelled", func() {
+				var cancelRequests chan struct{}
+
+				BeforeEach(func() {
+					cancelRequests = make(chan struct{}, 1)
+				})
+
+				It("removes the dependent packages it installed", func() {
+					packageApplier.ApplyStub = func(boshmodels.Package) error {
+						cancelRequests <- struct{}{}
+						return nil
+					}
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NewCancelSignal(cancelRequests))
+					Expect(err).To(Equal(boshtask.ErrCancelled))
+					Expect(packageApplier.ActionsCalled).To(Equal([]string{"KeepOnly", "Apply", "KeepOnly"}))
+					Expect(blobstore.GetCallCount()).To(BeZero())
+				})
+
+				It("removes the compiled package bundle without uploading it", func() {
+					blobstore.GetStub = func(boshcrypto.Digest, string, string, map[string]string) (string, error) {
+						cancelRequests <- struct{}{}
+						return "/tmp/fake-package", nil
+					}
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NewCancelSignal(cancelRequests))
+					Expect(err).To(Equal(boshtask.ErrCancelled))
+					Expect(bundle.ActionsCalled).To(Equal([]string{
+						"InstallWithoutContents",
+						"Enable",
+						"Disable",
+						"Uninstall",
+					}))
+					Expect(packageApplier.ActionsCalled).To(Equal([]string{"KeepOnly", "Apply", "Apply", "KeepOnly"}))
+					Expect(fs.FileExists("/fake-compile-dir/pkg_name")).To(BeFalse())
+					Expect(blobstore.WriteCallCount()).To(BeZero())
+				})
+			})
+
 			It("returns an error if removing the compile directory fails", func() {
 				callCount := 0
 				fs.RemoveAllStub = func(path string) error {
@@ -269,7 +336,7 @@ func init() { //nolint:funlen,gochecknoinits
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -285,7 +352,7 @@ func init() { //nolint:funlen,gochecknoinits
 				})
 
 				It("runs packaging script ", func() {
-					_, _, err := compiler.Compile(pkg, pkgDeps)
+					_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 					Expect(err).ToNot(HaveOccurred())
 
 					expectedCmd := boshsys.Command{
@@ -316,20 +383,20 @@ func init() { //nolint:funlen,gochecknoinits
 				It("propagates the error from packaging script", func() {
 					runner.RunCommandErr = errors.New("fake-packaging-error")
 
-					_, _, err := compiler.Compile(pkg, pkgDeps)
+					_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-packaging-error"))
 				})
 			})
 
 			It("does not run packaging script when script does not exist", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(runner.RunCommands).To(BeEmpty())
 			})
 
 			It("compresses compiled package", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				// archive was downloaded from the blobstore and decompress to this temp dir
@@ -349,7 +416,7 @@ func init() { //nolint:funlen,gochecknoinits
 			It("uploads compressed package to blobstore", func() {
 				compressor.CompressFilesInDirTarballPath = "/tmp/compressed-compiled-package"
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				_, filePathArg, headers := blobstore.WriteArgsForCall(0)
@@ -357,10 +424,68 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(headers).To(Equal(map[string]string{"key": "value"}))
 			})
 
+			Context("when the director provides a multipart upload", func() {
+				BeforeEach(func() {
+					pkg.UploadMultipart = &httpblobprovider.MultipartUpload{
+						PartSignedURLs:    []string{"part-1-url", "part-2-url"},
+						PartSize:          5,
+						CompleteSignedURL: "complete-url",
+					}
+				})
+
+				It("uploads compressed package in parts", func() {
+					blobstore.WriteMultipartReturns(boshcrypto.MustNewMultipleDigest(
+						boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"),
+					), nil)
+
+					blobID, digest, err := compiler.Compile(pkg, pkgDeps, nil)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(blobID).To(BeEmpty())
+					Expect(digest.String()).To(Equal("fake-sha1"))
+
+					Expect(blobstore.WriteCallCount()).To(Equal(0))
+					Expect(blobstore.WriteMultipartCallCount()).To(Equal(1))
+					upload, filePathArg, headers := blobstore.WriteMultipartArgsForCall(0)
+					Expect(upload).To(Equal(*pkg.UploadMultipart))
+					Expect(filePathArg).To(Equal("/tmp/compressed-compiled-package"))
+					Expect(headers).To(Equal(map[string]string{"key": "value"}))
+				})
+
+				It("uploads compressed package in parts when it is larger than a part", func() {
+					pkg.UploadSignedURL = "/upload/signed/url"
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(blobstore.WriteCallCount()).To(Equal(0))
+					Expect(blobstore.WriteMultipartCallCount()).To(Equal(1))
+				})
+
+				It("uploads compressed package with a single PUT when it fits in a part", func() {
+					pkg.UploadSignedURL = "/upload/signed/url"
+					pkg.UploadMultipart.PartSize = 1024
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(blobstore.WriteMultipartCallCount()).To(Equal(0))
+					Expect(blobstore.WriteCallCount()).To(Equal(1))
+					signedURL, _, _ := blobstore.WriteArgsForCall(0)
+					Expect(signedURL).To(Equal("/upload/signed/url"))
+				})
+
+				It("returns error if uploading the parts fails", func() {
+					blobstore.WriteMultipartReturns(boshcrypto.MultipleDigest{}, errors.New("fake-multipart-err"))
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, nil)
+					Expect(err).To(MatchError(ContainSubstring("fake-multipart-err")))
+				})
+			})
+
 			It("returs error if uploading compressed package fails", func() {
 				blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-create-err"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-create-err"))
 			})
@@ -373,7 +498,7 @@ func init() { //nolint:funlen,gochecknoinits
 					return "my-blob-id", boshcrypto.MultipleDigest{}, nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				// Compressed package is not cleaned up before blobstore upload
@@ -50,6 +50,7 @@ func init() { //nolint:gochecknoinits
 				packageApplier,
 				packagesBc,
 				fakeClock,
+				nil,
 			)
 
 			err := fs.MkdirAll("/fake-compile-dir", os.ModePerm)
@@ -93,7 +94,7 @@ func init() { //nolint:gochecknoinits
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fs.RenameOldPaths[0]).To(Equal("/fake-compile-dir/pkg_name-bosh-agent-unpack"))
@@ -117,7 +118,7 @@ func init() { //nolint:gochecknoinits
 				fakeClock.NowReturns(startTime)
 				fakeClock.SinceReturns(CompileTimeout + time.Second)
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, nil)
 				Expect(err).To(MatchError(ContainSubstring("can't perform filesystem rename")))
 
 				Expect(fakeClock.SinceCallCount()).To(Equal(1))
@@ -5,11 +5,13 @@ import (
 
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
 type FakeCompiler struct {
 	CompilePkg    boshcomp.Package
 	CompileDeps   []boshmodels.Package
+	CompileCancel *boshtask.CancelSignal
 	CompileBlobID string
 	CompileDigest boshcrypto.Digest
 	CompileErr    error
@@ -20,9 +22,10 @@ func NewFakeCompiler() (c *FakeCompiler) {
 	return
 }
 
-func (c *FakeCompiler) Compile(pkg boshcomp.Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error) {
+func (c *FakeCompiler) Compile(pkg boshcomp.Package, deps []boshmodels.Package, cancel *boshtask.CancelSignal) (blobID string, digest boshcrypto.Digest, err error) {
 	c.CompilePkg = pkg
 	c.CompileDeps = deps
+	c.CompileCancel = cancel
 	blobID = c.CompileBlobID
 	digest = c.CompileDigest
 	err = c.CompileErr
@@ -0,0 +1,320 @@
+package coredump
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha1" //nolint:gosec
+	"debug/elf"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+const (
+	// CorePattern names core dumps after the path of the executable, with
+	// slashes replaced by exclamation marks, the PID and the time of the dump
+	CorePattern = "core.%E.%p.%t"
+
+	corePrefix = "core."
+)
+
+type collector struct {
+	fs          boshsys.FileSystem
+	dirProvider boshdir.Provider
+}
+
+func NewCollector(fs boshsys.FileSystem, dirProvider boshdir.Provider) Collector {
+	return collector{fs: fs, dirProvider: dirProvider}
+}
+
+func (c collector) List() ([]CoreDump, error) {
+	paths, err := c.fs.Glob(filepath.Join(c.dirProvider.CoreDumpsDir(), corePrefix+"*"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding core dumps")
+	}
+
+	dumps := []CoreDump{}
+
+	for _, dumpPath := range paths {
+		info, err := c.fs.Lstat(dumpPath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Checking core dump %s", dumpPath)
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		dumps = append(dumps, c.describe(filepath.Base(dumpPath), info))
+	}
+
+	sort.SliceStable(dumps, func(i, j int) bool {
+		return dumps[i].DumpedAt.Before(dumps[j].DumpedAt)
+	})
+
+	return dumps, nil
+}
+
+// describe fills in what the name of core dumps named after CorePattern
+// tells about the crashed process
+func (c collector) describe(name string, info os.FileInfo) CoreDump {
+	dump := CoreDump{Name: name, Size: info.Size(), DumpedAt: info.ModTime()}
+
+	parts := strings.Split(strings.TrimPrefix(name, corePrefix), ".")
+	if len(parts) < 3 {
+		return dump
+	}
+
+	pid, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil {
+		return dump
+	}
+
+	dumpedAt, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return dump
+	}
+
+	dump.Executable = strings.ReplaceAll(strings.Join(parts[:len(parts)-2], "."), "!", "/")
+	dump.PID = pid
+	dump.DumpedAt = time.Unix(dumpedAt, 0).UTC()
+	dump.Job = ownerIn(dump.Executable, c.dirProvider.JobsDir(), c.dirProvider.DataJobsDir())
+	dump.Package = ownerIn(dump.Executable, filepath.Join(c.dirProvider.BaseDir(), "packages"), c.dirProvider.PkgDir())
+
+	return dump
+}
+
+// ownerIn returns the name of the job or package directory in dirs that
+// executable is in
+func ownerIn(executable string, dirs ...string) string {
+	for _, dir := range dirs {
+		rest := strings.TrimPrefix(executable, filepath.ToSlash(dir)+"/")
+		if rest == executable {
+			continue
+		}
+
+		if owner, _, found := strings.Cut(rest, "/"); found {
+			return owner
+		}
+	}
+
+	return ""
+}
+
+func (c collector) Package(dump CoreDump) (string, error) {
+	tarball, err := c.fs.TempFile("bosh-agent-core-dump")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating core dump tarball")
+	}
+
+	err = c.writeTarball(tarball, dump)
+
+	closeErr := tarball.Close()
+	if err == nil && closeErr != nil {
+		err = bosherr.WrapError(closeErr, "Closing core dump tarball")
+	}
+
+	if err != nil {
+		_ = c.fs.RemoveAll(tarball.Name()) //nolint:errcheck
+		return "", err
+	}
+
+	return tarball.Name(), nil
+}
+
+func (c collector) writeTarball(w io.Writer, dump CoreDump) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	metadata := Metadata{CoreDump: dump}
+
+	if dump.Executable != "" {
+		err := c.addExecutable(tarWriter, &metadata)
+		if err != nil {
+			return err
+		}
+	}
+
+	dumpPath := filepath.Join(c.dirProvider.CoreDumpsDir(), dump.Name)
+
+	_, err := c.addFile(tarWriter, dumpPath, "./"+dump.Name)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Adding core dump %s", dump.Name)
+	}
+
+	metadataBytes, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling core dump metadata")
+	}
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "./metadata.json",
+		Mode:     0644,
+		Size:     int64(len(metadataBytes)),
+		ModTime:  time.Now(),
+	})
+	if err == nil {
+		_, err = tarWriter.Write(metadataBytes)
+	}
+	if err != nil {
+		return bosherr.WrapError(err, "Adding core dump metadata")
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Finishing core dump tarball")
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Compressing core dump tarball")
+	}
+
+	return nil
+}
+
+// addExecutable adds the executable that dumped core so the dump can be
+// analysed without the VM. Executables removed since are left out.
+func (c collector) addExecutable(tarWriter *tar.Writer, metadata *Metadata) error {
+	info, err := c.fs.Stat(metadata.Executable)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	digest, err := c.addFile(tarWriter, metadata.Executable, "./executable/"+path.Base(metadata.Executable))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Adding executable %s", metadata.Executable)
+	}
+
+	modifiedAt := info.ModTime()
+
+	metadata.ExecutableSHA1 = digest
+	metadata.ExecutableModifiedAt = &modifiedAt
+	metadata.ExecutableBuildID = c.buildID(metadata.Executable)
+
+	return nil
+}
+
+// addFile adds the file at filePath to the tarball as name and returns its
+// SHA1 digest
+func (c collector) addFile(tarWriter *tar.Writer, filePath, name string) (string, error) {
+	file, err := c.fs.OpenFile(filePath, os.O_RDONLY, 0)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close() //nolint:errcheck
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     int64(info.Mode().Perm()),
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha1.New() //nolint:gosec
+
+	_, err = io.CopyN(io.MultiWriter(tarWriter, digest), file, info.Size())
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// buildID returns the GNU build ID of ELF executables, which debuggers use
+// to find their symbols, or an empty string if they do not have one
+func (c collector) buildID(executable string) string {
+	file, err := c.fs.OpenFile(executable, os.O_RDONLY, 0)
+	if err != nil {
+		return ""
+	}
+	defer file.Close() //nolint:errcheck
+
+	elfFile, err := elf.NewFile(file)
+	if err != nil {
+		return ""
+	}
+
+	section := elfFile.Section(".note.gnu.build-id")
+	if section == nil {
+		return ""
+	}
+
+	note, err := section.Data()
+	if err != nil || len(note) < 12 {
+		return ""
+	}
+
+	// The note has the sizes of its name and of the ID, its type and its
+	// name, padded to 4 bytes, before the ID
+	nameSize := int(elfFile.ByteOrder.Uint32(note[0:4]))
+	idSize := int(elfFile.ByteOrder.Uint32(note[4:8]))
+	idStart := 12 + (nameSize+3)&^3
+
+	if idStart+idSize > len(note) {
+		return ""
+	}
+
+	return hex.EncodeToString(note[idStart : idStart+idSize])
+}
+
+func (c collector) CleanUp(tarballPath string) error {
+	return c.fs.RemoveAll(tarballPath)
+}
+
+func (c collector) Remove(dump CoreDump) error {
+	err := c.fs.RemoveAll(filepath.Join(c.dirProvider.CoreDumpsDir(), dump.Name))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing core dump %s", dump.Name)
+	}
+
+	return nil
+}
+
+func (c collector) Prune(maxSizeInBytes uint64) error {
+	dumps, err := c.List()
+	if err != nil {
+		return err
+	}
+
+	var size uint64
+	for _, dump := range dumps {
+		size += uint64(dump.Size) //nolint:gosec
+	}
+
+	for _, dump := range dumps {
+		if size <= maxSizeInBytes {
+			break
+		}
+
+		if err := c.Remove(dump); err != nil {
+			return err
+		}
+
+		size -= uint64(dump.Size) //nolint:gosec
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package coredump
+
+import (
+	"time"
+)
+
+//go:generate counterfeiter . Collector
+
+type Collector interface {
+	// List returns the core dumps written to the core dumps directory, oldest
+	// first
+	List() ([]CoreDump, error)
+
+	// Package writes a gzipped tarball with the core dump, the executable that
+	// dumped it and a metadata.json describing both, and returns its path
+	Package(dump CoreDump) (string, error)
+
+	CleanUp(tarballPath string) error
+	Remove(dump CoreDump) error
+
+	// Prune removes the oldest core dumps until all of them take up at most
+	// maxSizeInBytes
+	Prune(maxSizeInBytes uint64) error
+}
+
+// CoreDump is a core dump written by the kernel. Executable, PID and the
+// owning job or package are only known for core dumps named after
+// CorePattern.
+type CoreDump struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	DumpedAt   time.Time `json:"dumped_at"`
+	Executable string    `json:"executable,omitempty"`
+	PID        int       `json:"pid,omitempty"`
+	Job        string    `json:"job,omitempty"`
+	Package    string    `json:"package,omitempty"`
+}
+
+// Metadata is saved as metadata.json next to the core dump in its tarball.
+// The digest and GNU build ID of the executable tell whether it is the one
+// that dumped core, or whether it was replaced since and matching symbols
+// need to be found elsewhere.
+type Metadata struct {
+	CoreDump
+
+	ExecutableSHA1       string     `json:"executable_sha1,omitempty"`
+	ExecutableBuildID    string     `json:"executable_build_id,omitempty"`
+	ExecutableModifiedAt *time.Time `json:"executable_modified_at,omitempty"`
+}
@@ -0,0 +1,215 @@
+package coredump_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/coredump"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+var _ = Describe("Collector", func() {
+	var (
+		fs          boshsys.FileSystem
+		dirProvider boshdir.Provider
+		collector   coredump.Collector
+
+		executable string
+	)
+
+	coreName := func(executable string, pid int, dumpedAt int64) string {
+		return fmt.Sprintf("core.%s.%d.%d", strings.ReplaceAll(executable, "/", "!"), pid, dumpedAt)
+	}
+
+	writeCore := func(name, contents string) {
+		Expect(fs.WriteFileString(filepath.Join(dirProvider.CoreDumpsDir(), name), contents)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		dirProvider = boshdir.NewProvider(GinkgoT().TempDir())
+		collector = coredump.NewCollector(fs, dirProvider)
+
+		executable = filepath.ToSlash(filepath.Join(dirProvider.DataJobsDir(), "web", "fake-hash", "bin", "server"))
+		Expect(fs.WriteFileString(executable, "#!/bin/bash\n")).To(Succeed())
+		Expect(fs.MkdirAll(dirProvider.CoreDumpsDir(), 0755)).To(Succeed())
+	})
+
+	Describe("List", func() {
+		It("returns the core dumps oldest first with what their names tell", func() {
+			writeCore(coreName(executable, 42, 1700000100), "newer-core")
+			writeCore(coreName("/usr/sbin/fake-daemon.v2", 7, 1700000000), "older-core")
+			writeCore("not-a-core", "")
+
+			dumps, err := collector.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dumps).To(Equal([]coredump.CoreDump{
+				{
+					Name:       coreName("/usr/sbin/fake-daemon.v2", 7, 1700000000),
+					Size:       10,
+					DumpedAt:   time.Unix(1700000000, 0).UTC(),
+					Executable: "/usr/sbin/fake-daemon.v2",
+					PID:        7,
+				},
+				{
+					Name:       coreName(executable, 42, 1700000100),
+					Size:       10,
+					DumpedAt:   time.Unix(1700000100, 0).UTC(),
+					Executable: executable,
+					PID:        42,
+					Job:        "web",
+				},
+			}))
+		})
+
+		It("tells which package the executable is in", func() {
+			packaged := filepath.ToSlash(filepath.Join(dirProvider.BaseDir(), "packages", "nginx", "sbin", "nginx"))
+			writeCore(coreName(packaged, 1, 1700000000), "core")
+
+			dumps, err := collector.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dumps).To(HaveLen(1))
+			Expect(dumps[0].Package).To(Equal("nginx"))
+			Expect(dumps[0].Job).To(BeEmpty())
+		})
+
+		It("returns core dumps not named after the core pattern as they are", func() {
+			writeCore("core", "core")
+			writeCore("core.1234", "core")
+
+			dumps, err := collector.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dumps).To(HaveLen(1))
+			Expect(dumps[0].Name).To(Equal("core.1234"))
+			Expect(dumps[0].Executable).To(BeEmpty())
+			Expect(dumps[0].DumpedAt).ToNot(BeZero())
+		})
+
+		It("returns no core dumps without the core dumps directory", func() {
+			Expect(fs.RemoveAll(dirProvider.CoreDumpsDir())).To(Succeed())
+
+			dumps, err := collector.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dumps).To(BeEmpty())
+		})
+	})
+
+	Describe("Package", func() {
+		readTarball := func(tarballPath string) map[string]string {
+			file, err := os.Open(tarballPath)
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close() //nolint:errcheck
+
+			gzipReader, err := gzip.NewReader(file)
+			Expect(err).ToNot(HaveOccurred())
+
+			entries := map[string]string{}
+
+			tarReader := tar.NewReader(gzipReader)
+			for {
+				header, err := tarReader.Next()
+				if err == io.EOF {
+					return entries
+				}
+				Expect(err).ToNot(HaveOccurred())
+
+				contents, err := io.ReadAll(tarReader)
+				Expect(err).ToNot(HaveOccurred())
+				entries[header.Name] = string(contents)
+			}
+		}
+
+		It("packages the core dump with the executable and their metadata", func() {
+			writeCore(coreName(executable, 42, 1700000000), "fake-core")
+
+			dumps, err := collector.List()
+			Expect(err).ToNot(HaveOccurred())
+
+			tarballPath, err := collector.Package(dumps[0])
+			Expect(err).ToNot(HaveOccurred())
+
+			entries := readTarball(tarballPath)
+			Expect(entries).To(HaveLen(3))
+			Expect(entries["./"+dumps[0].Name]).To(Equal("fake-core"))
+			Expect(entries["./executable/server"]).To(Equal("#!/bin/bash\n"))
+
+			var metadata coredump.Metadata
+			Expect(json.Unmarshal([]byte(entries["./metadata.json"]), &metadata)).To(Succeed())
+			Expect(metadata.CoreDump).To(Equal(dumps[0]))
+			Expect(metadata.ExecutableSHA1).To(Equal("33d4cf9b1f2d3c42b2c4cfd507626057d20d7c52"))
+			Expect(metadata.ExecutableModifiedAt).ToNot(BeNil())
+			Expect(metadata.ExecutableBuildID).To(BeEmpty())
+
+			Expect(collector.CleanUp(tarballPath)).To(Succeed())
+			Expect(fs.FileExists(tarballPath)).To(BeFalse())
+		})
+
+		It("leaves out executables removed since", func() {
+			Expect(fs.RemoveAll(executable)).To(Succeed())
+			writeCore(coreName(executable, 42, 1700000000), "fake-core")
+
+			dumps, err := collector.List()
+			Expect(err).ToNot(HaveOccurred())
+
+			tarballPath, err := collector.Package(dumps[0])
+			Expect(err).ToNot(HaveOccurred())
+
+			entries := readTarball(tarballPath)
+			Expect(entries).To(HaveKey("./" + dumps[0].Name))
+			Expect(entries).To(HaveKey("./metadata.json"))
+			Expect(entries).To(HaveLen(2))
+		})
+
+		It("returns an error when the core dump is gone", func() {
+			_, err := collector.Package(coredump.CoreDump{Name: "core.gone"})
+			Expect(err).To(MatchError(ContainSubstring("Adding core dump core.gone")))
+		})
+	})
+
+	Describe("Remove", func() {
+		It("removes the core dump", func() {
+			writeCore("core.1234", "core")
+
+			Expect(collector.Remove(coredump.CoreDump{Name: "core.1234"})).To(Succeed())
+			Expect(fs.FileExists(filepath.Join(dirProvider.CoreDumpsDir(), "core.1234"))).To(BeFalse())
+		})
+	})
+
+	Describe("Prune", func() {
+		It("removes the oldest core dumps until the rest fit into the size", func() {
+			writeCore(coreName(executable, 1, 1700000000), "oldest")
+			writeCore(coreName(executable, 2, 1700000100), "older")
+			writeCore(coreName(executable, 3, 1700000200), "newest")
+
+			Expect(collector.Prune(12)).To(Succeed())
+
+			dumps, err := collector.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dumps).To(HaveLen(2))
+			Expect(dumps[0].Name).To(Equal(coreName(executable, 2, 1700000100)))
+			Expect(dumps[1].Name).To(Equal(coreName(executable, 3, 1700000200)))
+		})
+
+		It("keeps core dumps that fit into the size", func() {
+			writeCore(coreName(executable, 1, 1700000000), "core")
+
+			Expect(collector.Prune(4)).To(Succeed())
+
+			dumps, err := collector.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dumps).To(HaveLen(1))
+		})
+	})
+})
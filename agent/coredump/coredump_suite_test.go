@@ -0,0 +1,13 @@
+package coredump_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCoreDump(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Core Dump Suite")
+}
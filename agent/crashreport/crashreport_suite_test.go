@@ -0,0 +1,13 @@
+package crashreport_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCrashReport(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Crash Report Suite")
+}
@@ -0,0 +1,162 @@
+package crashreport
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	DefaultLogTailSize = 200
+
+	maxGoroutinesDumpSize = 1024 * 1024
+)
+
+// Logger wraps another logger, remembering the most recent log lines so that
+// a crash report can be saved when a panic is handled.
+type Logger struct {
+	boshlog.Logger
+
+	store   Store
+	version string
+	exit    func(int)
+
+	tailSize int
+	tailLock sync.Mutex
+	tail     []string
+}
+
+func NewLogger(logger boshlog.Logger, store Store, version string) *Logger {
+	return &Logger{
+		Logger:   logger,
+		store:    store,
+		version:  version,
+		exit:     os.Exit,
+		tailSize: DefaultLogTailSize,
+	}
+}
+
+func (l *Logger) Debug(tag, msg string, args ...interface{}) {
+	l.remember("DEBUG", tag, msg, args...)
+	l.Logger.Debug(tag, msg, args...)
+}
+
+func (l *Logger) DebugWithDetails(tag, msg string, args ...interface{}) {
+	l.remember("DEBUG", tag, msg+"\n%s", args...)
+	l.Logger.DebugWithDetails(tag, msg, args...)
+}
+
+func (l *Logger) Info(tag, msg string, args ...interface{}) {
+	l.remember("INFO", tag, msg, args...)
+	l.Logger.Info(tag, msg, args...)
+}
+
+func (l *Logger) Warn(tag, msg string, args ...interface{}) {
+	l.remember("WARN", tag, msg, args...)
+	l.Logger.Warn(tag, msg, args...)
+}
+
+func (l *Logger) Error(tag, msg string, args ...interface{}) {
+	l.remember("ERROR", tag, msg, args...)
+	l.Logger.Error(tag, msg, args...)
+}
+
+func (l *Logger) ErrorWithDetails(tag, msg string, args ...interface{}) {
+	l.remember("ERROR", tag, msg+"\n%s", args...)
+	l.Logger.ErrorWithDetails(tag, msg, args...)
+}
+
+// HandlePanic must be deferred directly so that recover can stop the panic.
+func (l *Logger) HandlePanic(tag string) {
+	if e := recover(); e != nil {
+		l.RecordPanic(tag, e, debug.Stack())
+		_ = l.Logger.FlushTimeout(5 * time.Second)
+		l.exit(2)
+	}
+}
+
+// RecordPanic logs the recovered value and saves a crash report for it.
+func (l *Logger) RecordPanic(tag string, recovered interface{}, stack []byte) {
+	msg := panicMessage(recovered)
+
+	l.Logger.ErrorWithDetails(tag, "Panic: %s", msg, stack)
+
+	report := Report{
+		Timestamp:  time.Now().UTC(),
+		Tag:        tag,
+		Panic:      msg,
+		Stack:      string(stack),
+		Goroutines: goroutinesDump(),
+		LogTail:    l.LogTail(),
+		BuildInfo:  l.buildInfo(),
+	}
+
+	err := l.store.Save(report)
+	if err != nil {
+		l.Logger.Error(tag, "Saving crash report: %s", err.Error())
+	}
+}
+
+func (l *Logger) LogTail() []string {
+	l.tailLock.Lock()
+	defer l.tailLock.Unlock()
+
+	tail := make([]string, len(l.tail))
+	copy(tail, l.tail)
+
+	return tail
+}
+
+func (l *Logger) remember(level, tag, msg string, args ...interface{}) {
+	line := fmt.Sprintf("[%s] %s %s - %s", tag, time.Now().UTC().Format(time.RFC3339), level, fmt.Sprintf(msg, args...))
+
+	l.tailLock.Lock()
+	defer l.tailLock.Unlock()
+
+	l.tail = append(l.tail, line)
+	if len(l.tail) > l.tailSize {
+		l.tail = l.tail[len(l.tail)-l.tailSize:]
+	}
+}
+
+func (l *Logger) buildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   l.version,
+		GoVersion: runtime.Version(),
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		info.Path = buildInfo.Path
+		for _, setting := range buildInfo.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Revision = setting.Value
+			}
+		}
+	}
+
+	return info
+}
+
+func panicMessage(recovered interface{}) string {
+	switch obj := recovered.(type) {
+	case string:
+		return obj
+	case fmt.Stringer:
+		return obj.String()
+	case error:
+		return obj.Error()
+	default:
+		return fmt.Sprintf("%#v", obj)
+	}
+}
+
+func goroutinesDump() string {
+	buf := make([]byte, maxGoroutinesDumpSize)
+	n := runtime.Stack(buf, true)
+	return string(buf[:n])
+}
@@ -0,0 +1,71 @@
+package crashreport_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/crashreport"
+)
+
+var _ = Describe("Logger", func() {
+	var (
+		outBuf *bytes.Buffer
+		store  crashreport.Store
+		logger *crashreport.Logger
+	)
+
+	BeforeEach(func() {
+		outBuf = new(bytes.Buffer)
+		fs := boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		store = crashreport.NewStore(fs, filepath.Join(GinkgoT().TempDir(), "crash_reports"), 5)
+		logger = crashreport.NewLogger(boshlog.NewWriterLogger(boshlog.LevelDebug, outBuf), store, "1.2.3")
+	})
+
+	It("passes log lines through to the wrapped logger", func() {
+		logger.Info("tag", "hello %s", "world")
+		Expect(outBuf.String()).To(ContainSubstring("INFO - hello world"))
+	})
+
+	It("remembers recent log lines", func() {
+		logger.Debug("tag", "first")
+		logger.Warn("tag", "second %d", 2)
+
+		tail := logger.LogTail()
+		Expect(tail).To(HaveLen(2))
+		Expect(tail[0]).To(ContainSubstring("DEBUG - first"))
+		Expect(tail[1]).To(ContainSubstring("WARN - second 2"))
+	})
+
+	Describe("RecordPanic", func() {
+		It("saves a crash report with the panic details", func() {
+			logger.Info("tag", "before the crash")
+
+			logger.RecordPanic("Main", errors.New("boom"), []byte("fake-stack"))
+
+			reports, err := store.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reports).To(HaveLen(1))
+
+			report := reports[0]
+			Expect(report.Tag).To(Equal("Main"))
+			Expect(report.Panic).To(Equal("boom"))
+			Expect(report.Stack).To(Equal("fake-stack"))
+			Expect(report.Goroutines).To(ContainSubstring("goroutine"))
+			Expect(report.LogTail).To(ContainElement(ContainSubstring("before the crash")))
+			Expect(report.BuildInfo.Version).To(Equal("1.2.3"))
+			Expect(report.BuildInfo.GoVersion).ToNot(BeEmpty())
+		})
+
+		It("logs the panic", func() {
+			logger.RecordPanic("Main", "boom", []byte("fake-stack"))
+			Expect(outBuf.String()).To(ContainSubstring("Panic: boom"))
+			Expect(outBuf.String()).To(ContainSubstring("fake-stack"))
+		})
+	})
+})
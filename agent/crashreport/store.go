@@ -0,0 +1,131 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	DefaultMaxReports = 10
+
+	reportFilePrefix = "crash-"
+	reportFileSuffix = ".json"
+)
+
+type Report struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Tag        string    `json:"tag"`
+	Panic      string    `json:"panic"`
+	Stack      string    `json:"stack"`
+	Goroutines string    `json:"goroutines"`
+	LogTail    []string  `json:"log_tail"`
+	BuildInfo  BuildInfo `json:"build_info"`
+}
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	Path      string `json:"path,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+}
+
+// Store keeps at most maxReports crash reports in dir, discarding the
+// oldest ones when a new report is saved.
+type Store struct {
+	fs         boshsys.FileSystem
+	dir        string
+	maxReports int
+}
+
+func NewStore(fs boshsys.FileSystem, dir string, maxReports int) Store {
+	if maxReports <= 0 {
+		maxReports = DefaultMaxReports
+	}
+
+	return Store{fs: fs, dir: dir, maxReports: maxReports}
+}
+
+func (s Store) Save(report Report) error {
+	err := s.fs.MkdirAll(s.dir, 0700)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating crash reports directory")
+	}
+
+	contents, err := json.Marshal(report)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling crash report")
+	}
+
+	fileName := fmt.Sprintf("%s%d%s", reportFilePrefix, report.Timestamp.UnixNano(), reportFileSuffix)
+
+	err = s.fs.WriteFile(filepath.Join(s.dir, fileName), contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing crash report")
+	}
+
+	return s.prune()
+}
+
+// List returns saved crash reports ordered from newest to oldest.
+// Reports that cannot be read are skipped.
+func (s Store) List() ([]Report, error) {
+	paths, err := s.reportPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []Report{}
+
+	for i := len(paths) - 1; i >= 0; i-- {
+		contents, err := s.fs.ReadFile(paths[i])
+		if err != nil {
+			continue
+		}
+
+		var report Report
+
+		err = json.Unmarshal(contents, &report)
+		if err != nil {
+			continue
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (s Store) prune() error {
+	paths, err := s.reportPaths()
+	if err != nil {
+		return err
+	}
+
+	for len(paths) > s.maxReports {
+		err = s.fs.RemoveAll(paths[0])
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing old crash report %s", paths[0])
+		}
+
+		paths = paths[1:]
+	}
+
+	return nil
+}
+
+func (s Store) reportPaths() ([]string, error) {
+	paths, err := s.fs.Glob(filepath.Join(s.dir, reportFilePrefix+"*"+reportFileSuffix))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing crash reports")
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
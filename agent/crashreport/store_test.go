@@ -0,0 +1,71 @@
+package crashreport_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/crashreport"
+)
+
+var _ = Describe("Store", func() {
+	var (
+		dir   string
+		fs    boshsys.FileSystem
+		store crashreport.Store
+	)
+
+	BeforeEach(func() {
+		dir = filepath.Join(GinkgoT().TempDir(), "crash_reports")
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		store = crashreport.NewStore(fs, dir, 2)
+	})
+
+	It("returns no reports when nothing has been saved", func() {
+		reports, err := store.List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(BeEmpty())
+	})
+
+	It("lists saved reports from newest to oldest", func() {
+		first := crashreport.Report{Timestamp: time.Unix(100, 0).UTC(), Panic: "first"}
+		second := crashreport.Report{Timestamp: time.Unix(200, 0).UTC(), Panic: "second"}
+
+		Expect(store.Save(first)).To(Succeed())
+		Expect(store.Save(second)).To(Succeed())
+
+		reports, err := store.List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(Equal([]crashreport.Report{second, first}))
+	})
+
+	It("keeps only the most recent reports", func() {
+		for i := int64(1); i <= 3; i++ {
+			Expect(store.Save(crashreport.Report{Timestamp: time.Unix(i, 0).UTC()})).To(Succeed())
+		}
+
+		entries, err := os.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+
+		reports, err := store.List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports[0].Timestamp).To(Equal(time.Unix(3, 0).UTC()))
+		Expect(reports[1].Timestamp).To(Equal(time.Unix(2, 0).UTC()))
+	})
+
+	It("skips reports that cannot be parsed", func() {
+		Expect(store.Save(crashreport.Report{Timestamp: time.Unix(1, 0).UTC(), Panic: "valid"})).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "crash-2000000000.json"), []byte("{truncated"), 0600)).To(Succeed())
+
+		reports, err := store.List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		Expect(reports[0].Panic).To(Equal("valid"))
+	})
+})
@@ -0,0 +1,105 @@
+package diskusage
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+type analyzer struct {
+	fs boshsys.FileSystem
+}
+
+func NewAnalyzer(fs boshsys.FileSystem) Analyzer {
+	return analyzer{fs: fs}
+}
+
+func (a analyzer) Analyze(root string, top int, cancel *boshtask.CancelSignal) (DirectoryUsage, error) {
+	// Directories like /var/vcap/sys/log are symlinks into the data disk
+	resolvedRoot, err := a.fs.ReadAndFollowLink(root)
+	if err != nil {
+		return DirectoryUsage{}, bosherr.WrapErrorf(err, "Resolving %s", root)
+	}
+
+	subtrees := map[string]*Subtree{}
+
+	err = a.fs.Walk(resolvedRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Files are created and removed while walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			if err := cancel.Err(); err != nil {
+				return err
+			}
+		}
+
+		relativePath, err := filepath.Rel(resolvedRoot, path)
+		if err != nil {
+			return err
+		}
+
+		// Every directory up to the root uses the space of the entry
+		for dir := relativePath; ; dir = filepath.Dir(dir) {
+			if info.IsDir() || dir != relativePath {
+				subtree, found := subtrees[dir]
+				if !found {
+					subtree = &Subtree{Path: filepath.Join(root, dir)}
+					subtrees[dir] = subtree
+				}
+
+				subtree.Inodes++
+				if !info.IsDir() {
+					subtree.Bytes += info.Size()
+				}
+			}
+
+			if dir == "." {
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return DirectoryUsage{}, bosherr.WrapErrorf(err, "Walking %s", root)
+	}
+
+	rootSubtree, found := subtrees["."]
+	if !found {
+		return DirectoryUsage{}, bosherr.Errorf("%s is not a directory", root)
+	}
+	delete(subtrees, ".")
+
+	largest := make([]Subtree, 0, len(subtrees))
+	for _, subtree := range subtrees {
+		largest = append(largest, *subtree)
+	}
+
+	sort.Slice(largest, func(i, j int) bool {
+		if largest[i].Bytes != largest[j].Bytes {
+			return largest[i].Bytes > largest[j].Bytes
+		}
+		return largest[i].Path < largest[j].Path
+	})
+
+	if len(largest) > top {
+		largest = largest[:top]
+	}
+
+	return DirectoryUsage{
+		Path:    root,
+		Bytes:   rootSubtree.Bytes,
+		Inodes:  rootSubtree.Inodes,
+		Largest: largest,
+	}, nil
+}
@@ -0,0 +1,29 @@
+package diskusage
+
+import (
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+//go:generate counterfeiter . Analyzer
+
+type Analyzer interface {
+	// Analyze walks the directory at root and reports the space and inodes
+	// used beneath it and by its top largest subtrees
+	Analyze(root string, top int, cancel *boshtask.CancelSignal) (DirectoryUsage, error)
+}
+
+// DirectoryUsage sums up the apparent sizes of the files beneath Path and
+// counts the inodes they use. Largest are the largest directories at any
+// depth beneath it, like du reports them, so they include each other.
+type DirectoryUsage struct {
+	Path    string    `json:"path"`
+	Bytes   int64     `json:"bytes"`
+	Inodes  int64     `json:"inodes"`
+	Largest []Subtree `json:"largest"`
+}
+
+type Subtree struct {
+	Path   string `json:"path"`
+	Bytes  int64  `json:"bytes"`
+	Inodes int64  `json:"inodes"`
+}
@@ -0,0 +1,82 @@
+package diskusage_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/diskusage"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+var _ = Describe("Analyzer", func() {
+	var (
+		fs       boshsys.FileSystem
+		root     string
+		analyzer diskusage.Analyzer
+	)
+
+	writeFile := func(path string, size int) {
+		Expect(fs.WriteFileString(filepath.Join(root, path), strings.Repeat("x", size))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		root = filepath.Join(GinkgoT().TempDir(), "data")
+		analyzer = diskusage.NewAnalyzer(fs)
+
+		writeFile("jobs/web/web.log", 300)
+		writeFile("jobs/web/web.err.log", 100)
+		writeFile("jobs/db/db.log", 200)
+		writeFile("tmp/upload", 50)
+		writeFile("settings.json", 10)
+	})
+
+	It("reports the usage of the directory and its largest subtrees", func() {
+		usage, err := analyzer.Analyze(root, 3, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(usage).To(Equal(diskusage.DirectoryUsage{
+			Path:   root,
+			Bytes:  660,
+			Inodes: 10,
+			Largest: []diskusage.Subtree{
+				{Path: filepath.Join(root, "jobs"), Bytes: 600, Inodes: 6},
+				{Path: filepath.Join(root, "jobs", "web"), Bytes: 400, Inodes: 3},
+				{Path: filepath.Join(root, "jobs", "db"), Bytes: 200, Inodes: 2},
+			},
+		}))
+	})
+
+	It("follows the directory when it is a symlink", func() {
+		link := filepath.Join(filepath.Dir(root), "link")
+		Expect(os.Symlink(root, link)).To(Succeed())
+
+		usage, err := analyzer.Analyze(link, 1, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(usage.Path).To(Equal(link))
+		Expect(usage.Bytes).To(Equal(int64(660)))
+		Expect(usage.Largest).To(Equal([]diskusage.Subtree{
+			{Path: filepath.Join(link, "jobs"), Bytes: 600, Inodes: 6},
+		}))
+	})
+
+	It("returns an error for files", func() {
+		_, err := analyzer.Analyze(filepath.Join(root, "settings.json"), 3, nil)
+		Expect(err).To(MatchError(filepath.Join(root, "settings.json") + " is not a directory"))
+	})
+
+	It("stops walking when cancelled", func() {
+		cancelCh := make(chan struct{}, 1)
+		cancelCh <- struct{}{}
+
+		_, err := analyzer.Analyze(root, 3, boshtask.NewCancelSignal(cancelCh))
+		Expect(err).To(MatchError(ContainSubstring(boshtask.ErrCancelled.Error())))
+	})
+})
@@ -0,0 +1,13 @@
+package filewatcher_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFileWatcher(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "File Watcher Suite")
+}
@@ -0,0 +1,122 @@
+package filewatcher
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Watcher
+
+// Watcher keeps track of the files declared in the apply spec and reacts
+// to changes in them on behalf of the jobs.
+type Watcher interface {
+	// Watch replaces all currently watched files with the given ones.
+	Watch(fileWatches []models.FileWatch) error
+}
+
+const logTag = "fileWatcher"
+
+func Validate(fileWatch models.FileWatch) error {
+	if fileWatch.Job == "" {
+		return bosherr.Errorf("File watch for '%s' must specify a job", fileWatch.Path)
+	}
+
+	if !filepath.IsAbs(fileWatch.Path) {
+		return bosherr.Errorf("File watch path '%s' must be absolute", fileWatch.Path)
+	}
+
+	if hasMeta(filepath.Dir(fileWatch.Path)) {
+		return bosherr.Errorf("File watch path '%s' may only use wildcards in its last element", fileWatch.Path)
+	}
+
+	if _, err := filepath.Match(fileWatch.Path, fileWatch.Path); err != nil {
+		return bosherr.WrapErrorf(err, "Parsing file watch path '%s'", fileWatch.Path)
+	}
+
+	switch fileWatch.Action {
+	case models.FileWatchActionSignal:
+		if fileWatch.PidFile == "" {
+			return bosherr.Errorf("File watch for '%s' must specify a pid file to signal", fileWatch.Path)
+		}
+
+		if _, err := signalByName(fileWatch.Signal); err != nil {
+			return err
+		}
+	case models.FileWatchActionExec:
+		if len(fileWatch.Command) == 0 {
+			return bosherr.Errorf("File watch for '%s' must specify a command to execute", fileWatch.Path)
+		}
+
+		if !filepath.IsAbs(fileWatch.Command[0]) {
+			return bosherr.Errorf("File watch command '%s' must be an absolute path", fileWatch.Command[0])
+		}
+	default:
+		return bosherr.Errorf("Unknown file watch action '%s'", fileWatch.Action)
+	}
+
+	return nil
+}
+
+type reactor struct {
+	fs     boshsys.FileSystem
+	runner boshsys.CmdRunner
+	logger boshlog.Logger
+}
+
+func (r reactor) react(fileWatch models.FileWatch) {
+	r.logger.Info(logTag, "Detected change of '%s' for job '%s', running %s action", fileWatch.Path, fileWatch.Job, fileWatch.Action)
+
+	var err error
+
+	switch fileWatch.Action {
+	case models.FileWatchActionSignal:
+		err = r.signal(fileWatch)
+	case models.FileWatchActionExec:
+		_, _, _, err = r.runner.RunCommand(fileWatch.Command[0], fileWatch.Command[1:]...)
+	}
+
+	if err != nil {
+		r.logger.Error(logTag, "Reacting to change of '%s' for job '%s': %s", fileWatch.Path, fileWatch.Job, err.Error())
+	}
+}
+
+func (r reactor) signal(fileWatch models.FileWatch) error {
+	sig, err := signalByName(fileWatch.Signal)
+	if err != nil {
+		return err
+	}
+
+	contents, err := r.fs.ReadFileString(fileWatch.PidFile)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading pid file")
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(contents))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing pid file '%s'", fileWatch.PidFile)
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Finding process %d", pid)
+	}
+
+	err = process.Signal(sig)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Sending %s to process %d", fileWatch.Signal, pid)
+	}
+
+	return nil
+}
+
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
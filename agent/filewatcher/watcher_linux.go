@@ -0,0 +1,202 @@
+//go:build linux
+// +build linux
+
+package filewatcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"golang.org/x/sys/unix"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+const (
+	inotifyMask = unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE |
+		unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_ATTRIB
+
+	// Changes are coalesced so that writing several files
+	// (e.g. a certificate and its key) triggers a single reaction.
+	DefaultDebounce = 2 * time.Second
+)
+
+type inotifyWatcher struct {
+	reactor  reactor
+	debounce time.Duration
+	logger   boshlog.Logger
+
+	lock    sync.Mutex
+	file    *os.File
+	stopped chan struct{}
+	timers  map[int]*time.Timer
+}
+
+func NewWatcher(fs boshsys.FileSystem, runner boshsys.CmdRunner, debounce time.Duration, logger boshlog.Logger) Watcher {
+	return &inotifyWatcher{
+		reactor:  reactor{fs: fs, runner: runner, logger: logger},
+		debounce: debounce,
+		logger:   logger,
+	}
+}
+
+func (w *inotifyWatcher) Watch(fileWatches []models.FileWatch) error {
+	for _, fileWatch := range fileWatches {
+		if err := Validate(fileWatch); err != nil {
+			return err
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.stop()
+
+	if len(fileWatches) == 0 {
+		return nil
+	}
+
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return bosherr.WrapError(err, "Initializing inotify")
+	}
+
+	// Go's poller takes care of non-blocking reads and lets Close interrupt them
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	dirs := map[int]string{}
+
+	for _, fileWatch := range fileWatches {
+		dir := filepath.Dir(fileWatch.Path)
+
+		wd, err := unix.InotifyAddWatch(fd, dir, inotifyMask)
+		if err != nil {
+			if errors.Is(err, syscall.ENOENT) {
+				w.logger.Warn(logTag, "Not watching '%s' for job '%s': directory does not exist", fileWatch.Path, fileWatch.Job)
+				continue
+			}
+
+			_ = file.Close()
+			return bosherr.WrapErrorf(err, "Watching directory '%s'", dir)
+		}
+
+		dirs[wd] = dir
+	}
+
+	w.file = file
+	w.stopped = make(chan struct{})
+	w.timers = map[int]*time.Timer{}
+
+	go w.readEvents(file, w.stopped, dirs, fileWatches)
+
+	return nil
+}
+
+func (w *inotifyWatcher) stop() {
+	if w.file == nil {
+		return
+	}
+
+	close(w.stopped)
+	_ = w.file.Close()
+
+	for _, timer := range w.timers {
+		timer.Stop()
+	}
+
+	w.file = nil
+}
+
+func (w *inotifyWatcher) readEvents(file *os.File, stopped chan struct{}, dirs map[int]string, fileWatches []models.FileWatch) {
+	defer w.logger.HandlePanic("File Watcher Read Events")
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			select {
+			case <-stopped:
+			default:
+				w.logger.Error(logTag, "Reading inotify events: %s", err.Error())
+			}
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+
+			dir, found := dirs[int(event.Wd)]
+			if !found {
+				continue
+			}
+
+			path := filepath.Join(dir, strings.TrimRight(string(nameBytes), "\x00"))
+
+			for i, fileWatch := range fileWatches {
+				if matched, _ := filepath.Match(fileWatch.Path, path); matched {
+					w.schedule(stopped, i, fileWatch)
+				}
+			}
+		}
+	}
+}
+
+func (w *inotifyWatcher) schedule(stopped chan struct{}, i int, fileWatch models.FileWatch) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	select {
+	case <-stopped:
+		return
+	default:
+	}
+
+	if timer, found := w.timers[i]; found {
+		timer.Reset(w.debounce)
+		return
+	}
+
+	w.timers[i] = time.AfterFunc(w.debounce, func() {
+		w.lock.Lock()
+		select {
+		case <-stopped:
+			w.lock.Unlock()
+			return
+		default:
+			delete(w.timers, i)
+		}
+		w.lock.Unlock()
+
+		w.reactor.react(fileWatch)
+	})
+}
+
+func signalByName(name string) (os.Signal, error) {
+	if name == "" {
+		return nil, bosherr.Error("Signal must be specified")
+	}
+
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	sig := unix.SignalNum(name)
+	if sig == 0 {
+		return nil, bosherr.Errorf("Unknown signal '%s'", name)
+	}
+
+	return sig, nil
+}
@@ -0,0 +1,108 @@
+//go:build linux
+// +build linux
+
+package filewatcher_test
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/filewatcher"
+)
+
+var _ = Describe("inotify watcher", func() {
+	var (
+		dir     string
+		runner  *fakesys.FakeCmdRunner
+		watcher filewatcher.Watcher
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		runner = fakesys.NewFakeCmdRunner()
+		watcher = filewatcher.NewWatcher(boshsys.NewOsFileSystem(logger), runner, 50*time.Millisecond, logger)
+	})
+
+	AfterEach(func() {
+		Expect(watcher.Watch(nil)).To(Succeed())
+	})
+
+	It("runs the command once a matching file changes", func() {
+		err := watcher.Watch([]models.FileWatch{
+			{Job: "fake-job", Path: filepath.Join(dir, "*.pem"), Action: models.FileWatchActionExec, Command: []string{"/fake/reload", "--certs", "a b"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(dir, "cert.pem"), []byte("cert"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "key.pem"), []byte("key"), 0600)).To(Succeed())
+
+		Eventually(func() [][]string { return runner.RunCommands }).Should(Equal([][]string{{"/fake/reload", "--certs", "a b"}}))
+		Consistently(func() [][]string { return runner.RunCommands }, 200*time.Millisecond).Should(HaveLen(1))
+	})
+
+	It("ignores files that do not match", func() {
+		err := watcher.Watch([]models.FileWatch{
+			{Job: "fake-job", Path: filepath.Join(dir, "*.pem"), Action: models.FileWatchActionExec, Command: []string{"/fake/reload"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0600)).To(Succeed())
+
+		Consistently(func() [][]string { return runner.RunCommands }, 200*time.Millisecond).Should(BeEmpty())
+	})
+
+	It("signals the process from the pid file", func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGUSR2)
+		defer signal.Stop(signals)
+
+		pidFile := filepath.Join(dir, "fake-job.pid")
+		Expect(os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0600)).To(Succeed())
+
+		err := watcher.Watch([]models.FileWatch{
+			{Job: "fake-job", Path: filepath.Join(dir, "config.yml"), Action: models.FileWatchActionSignal, Signal: "USR2", PidFile: pidFile},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(dir, "config.yml"), []byte("config"), 0600)).To(Succeed())
+
+		Eventually(signals).Should(Receive(Equal(syscall.SIGUSR2)))
+	})
+
+	It("stops reacting once watches are replaced", func() {
+		err := watcher.Watch([]models.FileWatch{
+			{Job: "fake-job", Path: filepath.Join(dir, "*.pem"), Action: models.FileWatchActionExec, Command: []string{"/fake/reload"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(watcher.Watch([]models.FileWatch{})).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(dir, "cert.pem"), []byte("cert"), 0600)).To(Succeed())
+
+		Consistently(func() [][]string { return runner.RunCommands }, 200*time.Millisecond).Should(BeEmpty())
+	})
+
+	It("skips watches whose directory does not exist", func() {
+		err := watcher.Watch([]models.FileWatch{
+			{Job: "fake-job", Path: filepath.Join(dir, "missing", "*.pem"), Action: models.FileWatchActionExec, Command: []string{"/fake/reload"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns an error for invalid watches", func() {
+		err := watcher.Watch([]models.FileWatch{{Job: "fake-job", Path: "relative", Action: models.FileWatchActionExec, Command: []string{"/fake/reload"}}})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,37 @@
+//go:build !linux
+// +build !linux
+
+package filewatcher
+
+import (
+	"os"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+)
+
+const DefaultDebounce = 2 * time.Second
+
+type unsupportedWatcher struct {
+	logger boshlog.Logger
+}
+
+func NewWatcher(_ boshsys.FileSystem, _ boshsys.CmdRunner, _ time.Duration, logger boshlog.Logger) Watcher {
+	return unsupportedWatcher{logger: logger}
+}
+
+func (w unsupportedWatcher) Watch(fileWatches []models.FileWatch) error {
+	if len(fileWatches) > 0 {
+		w.logger.Warn(logTag, "Ignoring %d file watches: not supported on this platform", len(fileWatches))
+	}
+
+	return nil
+}
+
+func signalByName(name string) (os.Signal, error) {
+	return nil, bosherr.Errorf("Signal '%s' is not supported on this platform", name)
+}
@@ -0,0 +1,65 @@
+//go:build !windows
+// +build !windows
+
+package filewatcher_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/filewatcher"
+)
+
+var _ = Describe("Validate", func() {
+	var fileWatch models.FileWatch
+
+	BeforeEach(func() {
+		fileWatch = models.FileWatch{
+			Job:     "fake-job",
+			Path:    "/var/vcap/data/fake-job/certs/*.pem",
+			Action:  models.FileWatchActionExec,
+			Command: []string{"/var/vcap/jobs/fake-job/bin/reload", "--certs"},
+		}
+	})
+
+	It("accepts a valid exec watch", func() {
+		Expect(filewatcher.Validate(fileWatch)).To(Succeed())
+	})
+
+	It("requires a job", func() {
+		fileWatch.Job = ""
+		Expect(filewatcher.Validate(fileWatch)).To(MatchError(ContainSubstring("must specify a job")))
+	})
+
+	It("requires an absolute path", func() {
+		fileWatch.Path = "certs/*.pem"
+		Expect(filewatcher.Validate(fileWatch)).To(MatchError(ContainSubstring("must be absolute")))
+	})
+
+	It("only allows wildcards in the last path element", func() {
+		fileWatch.Path = "/var/vcap/data/*/certs/cert.pem"
+		Expect(filewatcher.Validate(fileWatch)).To(MatchError(ContainSubstring("only use wildcards in its last element")))
+	})
+
+	It("requires a command for exec watches", func() {
+		fileWatch.Command = nil
+		Expect(filewatcher.Validate(fileWatch)).To(MatchError(ContainSubstring("must specify a command")))
+	})
+
+	It("requires the command to be an absolute path", func() {
+		fileWatch.Command = []string{"reload", "--certs"}
+		Expect(filewatcher.Validate(fileWatch)).To(MatchError(ContainSubstring("File watch command 'reload' must be an absolute path")))
+	})
+
+	It("requires a pid file for signal watches", func() {
+		fileWatch.Action = models.FileWatchActionSignal
+		fileWatch.Signal = "HUP"
+		Expect(filewatcher.Validate(fileWatch)).To(MatchError(ContainSubstring("must specify a pid file")))
+	})
+
+	It("rejects unknown actions", func() {
+		fileWatch.Action = "fake-action"
+		Expect(filewatcher.Validate(fileWatch)).To(MatchError(ContainSubstring("Unknown file watch action 'fake-action'")))
+	})
+})
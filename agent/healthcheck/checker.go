@@ -0,0 +1,114 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+type checker struct {
+	scriptProvider boshscript.JobScriptProvider
+	httpClient     *http.Client
+	timeService    clock.Clock
+}
+
+func NewChecker(scriptProvider boshscript.JobScriptProvider, timeService clock.Clock) Checker {
+	return checker{
+		scriptProvider: scriptProvider,
+		httpClient: &http.Client{
+			// Redirects are answers of the job, following them may leave the VM
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		timeService: timeService,
+	}
+}
+
+func (c checker) Check(jobs []models.Job, cancel *boshtask.CancelSignal) ([]Result, error) {
+	type probe struct {
+		job    string
+		name   string
+		target string
+		run    func() error
+	}
+
+	var probes []probe
+
+	for _, job := range jobs {
+		timeout := job.HealthCheck.Timeout
+		if timeout == 0 {
+			timeout = DefaultTimeout
+		}
+
+		script := c.scriptProvider.NewScript(job.Name, ScriptName, nil, nil, timeout)
+		if script.Exists() {
+			probes = append(probes, probe{job.Name, ProbeScript, script.Path(), script.Run})
+		}
+
+		if job.HealthCheck.HTTP != nil {
+			probeURL := "http://127.0.0.1:" + strconv.Itoa(job.HealthCheck.HTTP.Port) + job.HealthCheck.HTTP.Path
+			probes = append(probes, probe{job.Name, ProbeHTTP, probeURL, func() error {
+				return c.get(probeURL, timeout)
+			}})
+		}
+	}
+
+	results := []Result{}
+
+	for _, probe := range probes {
+		err := cancel.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		startedAt := c.timeService.Now()
+		err = probe.run()
+
+		result := Result{
+			Job:            probe.job,
+			Probe:          probe.name,
+			Target:         probe.target,
+			Healthy:        err == nil,
+			DurationMillis: c.timeService.Since(startedAt).Milliseconds(),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (c checker) get(probeURL string, timeout time.Duration) error {
+	ctx, cancelRequest := context.WithTimeout(context.Background(), timeout)
+	defer cancelRequest()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL, nil)
+	if err != nil {
+		return bosherr.WrapError(err, "Building probe request")
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode < 200 || response.StatusCode > 399 {
+		return bosherr.Errorf("Responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package healthcheck
+
+import (
+	"time"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+const (
+	ProbeScript = "script"
+	ProbeHTTP   = "http"
+)
+
+// ScriptName is the name of the health-check script in the bin directory of
+// a job. It exits non-zero when the job is unhealthy.
+const ScriptName = "health-check"
+
+// DefaultTimeout bounds probes of jobs that do not declare a timeout
+const DefaultTimeout = 10 * time.Second
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Checker
+
+type Checker interface {
+	// Check runs the health-check script and the HTTP probe of each job that
+	// has them one after the other and returns their results. Failing
+	// probes do not stop the others.
+	Check(jobs []models.Job, cancel *boshtask.CancelSignal) ([]Result, error)
+}
+
+type Result struct {
+	Job     string `json:"job"`
+	Probe   string `json:"probe"`
+	Target  string `json:"target"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+
+	DurationMillis int64 `json:"duration_ms"`
+}
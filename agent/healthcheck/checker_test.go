@@ -0,0 +1,155 @@
+package healthcheck_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/healthcheck"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/script/scriptfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+var _ = Describe("Checker", func() {
+	var (
+		scriptProvider *scriptfakes.FakeJobScriptProvider
+		scripts        map[string]*scriptfakes.FakeScript
+		server         *httptest.Server
+		serverStatus   int
+		serverPort     int
+		checker        healthcheck.Checker
+	)
+
+	BeforeEach(func() {
+		scripts = map[string]*scriptfakes.FakeScript{}
+		scriptProvider = &scriptfakes.FakeJobScriptProvider{}
+		scriptProvider.NewScriptStub = func(jobName, scriptName string, _ map[string]string, _ []string, _ time.Duration) script.Script {
+			fakeScript, found := scripts[jobName]
+			if !found {
+				fakeScript = &scriptfakes.FakeScript{}
+			}
+			fakeScript.PathReturns("/var/vcap/jobs/" + jobName + "/bin/" + scriptName)
+			return fakeScript
+		}
+
+		serverStatus = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/health" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(serverStatus)
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		serverPort, err = strconv.Atoi(serverURL.Port())
+		Expect(err).ToNot(HaveOccurred())
+
+		checker = healthcheck.NewChecker(scriptProvider, fakeclock.NewFakeClock(time.Now()))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	existingScript := func(jobName string) *scriptfakes.FakeScript {
+		fakeScript := &scriptfakes.FakeScript{}
+		fakeScript.ExistsReturns(true)
+		scripts[jobName] = fakeScript
+		return fakeScript
+	}
+
+	It("runs the health-check scripts of jobs with the timeout of the job", func() {
+		existingScript("fake-job")
+		existingScript("other-job").RunReturns(errors.New("fake-run-err"))
+
+		results, err := checker.Check([]models.Job{
+			{Name: "fake-job", HealthCheck: models.JobHealthCheck{Timeout: 5 * time.Second}},
+			{Name: "other-job"},
+		}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(results).To(Equal([]healthcheck.Result{
+			{Job: "fake-job", Probe: healthcheck.ProbeScript, Target: "/var/vcap/jobs/fake-job/bin/health-check", Healthy: true},
+			{Job: "other-job", Probe: healthcheck.ProbeScript, Target: "/var/vcap/jobs/other-job/bin/health-check", Healthy: false, Error: "fake-run-err"},
+		}))
+
+		jobName, scriptName, _, _, timeout := scriptProvider.NewScriptArgsForCall(0)
+		Expect(jobName).To(Equal("fake-job"))
+		Expect(scriptName).To(Equal("health-check"))
+		Expect(timeout).To(Equal(5 * time.Second))
+
+		_, _, _, _, timeout = scriptProvider.NewScriptArgsForCall(1)
+		Expect(timeout).To(Equal(healthcheck.DefaultTimeout))
+	})
+
+	It("probes jobs that declare an HTTP probe", func() {
+		existingScript("fake-job")
+
+		results, err := checker.Check([]models.Job{
+			{Name: "fake-job", HealthCheck: models.JobHealthCheck{HTTP: &models.JobHTTPProbe{Port: serverPort, Path: "/health"}}},
+		}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(results).To(Equal([]healthcheck.Result{
+			{Job: "fake-job", Probe: healthcheck.ProbeScript, Target: "/var/vcap/jobs/fake-job/bin/health-check", Healthy: true},
+			{Job: "fake-job", Probe: healthcheck.ProbeHTTP, Target: "http://127.0.0.1:" + strconv.Itoa(serverPort) + "/health", Healthy: true},
+		}))
+	})
+
+	It("considers jobs answering probes with an error status unhealthy", func() {
+		serverStatus = http.StatusServiceUnavailable
+
+		results, err := checker.Check([]models.Job{
+			{Name: "fake-job", HealthCheck: models.JobHealthCheck{HTTP: &models.JobHTTPProbe{Port: serverPort, Path: "/health"}}},
+		}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Healthy).To(BeFalse())
+		Expect(results[0].Error).To(Equal("Responded with status 503"))
+	})
+
+	It("considers jobs not answering probes within the timeout unhealthy", func() {
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		})
+
+		results, err := checker.Check([]models.Job{
+			{Name: "fake-job", HealthCheck: models.JobHealthCheck{HTTP: &models.JobHTTPProbe{Port: serverPort, Path: "/health"}, Timeout: 50 * time.Millisecond}},
+		}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Healthy).To(BeFalse())
+		Expect(results[0].Error).To(ContainSubstring("context deadline exceeded"))
+	})
+
+	It("leaves out jobs without health checks", func() {
+		results, err := checker.Check([]models.Job{{Name: "fake-job"}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(BeEmpty())
+	})
+
+	It("stops running probes when cancelled", func() {
+		fakeScript := existingScript("fake-job")
+
+		cancelCh := make(chan struct{}, 1)
+		cancelCh <- struct{}{}
+
+		_, err := checker.Check([]models.Job{{Name: "fake-job"}}, boshtask.NewCancelSignal(cancelCh))
+		Expect(err).To(Equal(boshtask.ErrCancelled))
+		Expect(fakeScript.RunCallCount()).To(Equal(0))
+	})
+})
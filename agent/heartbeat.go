@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	boshmbus "github.com/cloudfoundry/bosh-agent/v2/mbus"
 	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
 )
 
@@ -14,6 +16,9 @@ type Heartbeat struct {
 	JobState   string            `json:"job_state"`
 	Vitals     boshvitals.Vitals `json:"vitals"`
 	NodeID     string            `json:"node_id"`
+
+	BlobTransfers *httpblobprovider.TransferMetricsSnapshot `json:"blob_transfers,omitempty"`
+	Mbus          *boshmbus.ConnectionMetricsSnapshot       `json:"mbus,omitempty"`
 }
 
 // Heartbeat payload example:
@@ -34,5 +39,11 @@ type Heartbeat struct {
 //   "ntp": {
 //       "offset": "-0.06423",
 //       "timestamp": "14 Oct 11:13:19"
-//   }
+//   },
+//   "blob_transfers": {
+//       "download": {"transfers": 12, "failures": 1, "retries": 1, "fallbacks": 0, "resumes": 1, "bytes": 734003200, "duration_ms": 61000, "last_bytes_per_second": 12582912},
+//       "upload": {"transfers": 2, "failures": 0, "retries": 0, "fallbacks": 0, "resumes": 0, "bytes": 52428800, "duration_ms": 4000, "last_bytes_per_second": 13107200},
+//       "signed_url_degraded": false
+//   },
+//   "mbus": {"disconnects": 2, "reconnects": 2, "reconnect_attempts": 0}
 // }
@@ -0,0 +1,72 @@
+package azure
+
+import (
+	"encoding/json"
+	"net/url"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	BlobstoreTypeAzure = "azure-storage"
+
+	defaultEnvironment = "AzureCloud"
+)
+
+var blobDomains = map[string]string{
+	"AzureCloud":        "blob.core.windows.net",
+	"AzureChinaCloud":   "blob.core.chinacloudapi.cn",
+	"AzureUSGovernment": "blob.core.usgovcloudapi.net",
+}
+
+// Config is the subset of the azure-storage-cli blobstore options needed to
+// address blobs directly from the agent.
+type Config struct {
+	AccountName   string `json:"account_name"`
+	AccountKey    string `json:"account_key"`
+	ContainerName string `json:"container_name"`
+	Environment   string `json:"environment"`
+}
+
+func NewConfigFromOptions(options map[string]interface{}) (Config, error) {
+	var config Config
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Marshalling azure blobstore options")
+	}
+
+	err = json.Unmarshal(optionsJSON, &config)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Unmarshalling azure blobstore options")
+	}
+
+	if config.AccountName == "" || config.ContainerName == "" {
+		return config, bosherr.Error("Azure blobstore options must include account_name and container_name")
+	}
+
+	if config.Environment == "" {
+		config.Environment = defaultEnvironment
+	}
+
+	if _, found := blobDomains[config.Environment]; !found {
+		return config, bosherr.Errorf("Unsupported azure environment '%s'", config.Environment)
+	}
+
+	return config, nil
+}
+
+// UsesManagedIdentity reports whether blobs have to be accessed with the
+// VM's managed identity because no storage account key was given.
+func (c Config) UsesManagedIdentity() bool {
+	return c.AccountKey == ""
+}
+
+func (c Config) ObjectURL(blobName string) string {
+	u := url.URL{
+		Scheme: "https",
+		Host:   c.AccountName + "." + blobDomains[c.Environment],
+		Path:   "/" + c.ContainerName + "/" + blobName,
+	}
+	return u.String()
+}
@@ -0,0 +1,56 @@
+package azure_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/azure"
+)
+
+var _ = Describe("Config", func() {
+	It("reads the azure-storage-cli options", func() {
+		config, err := azure.NewConfigFromOptions(map[string]interface{}{
+			"account_name":   "boshblobs",
+			"container_name": "bosh",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.UsesManagedIdentity()).To(BeTrue())
+		Expect(config.ObjectURL("blob-id")).To(Equal("https://boshblobs.blob.core.windows.net/bosh/blob-id"))
+	})
+
+	It("addresses blobs in sovereign clouds", func() {
+		config, err := azure.NewConfigFromOptions(map[string]interface{}{
+			"account_name":   "boshblobs",
+			"container_name": "bosh",
+			"environment":    "AzureChinaCloud",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.ObjectURL("blob-id")).To(Equal("https://boshblobs.blob.core.chinacloudapi.cn/bosh/blob-id"))
+	})
+
+	It("does not use the managed identity when an account key is given", func() {
+		config, err := azure.NewConfigFromOptions(map[string]interface{}{
+			"account_name":   "boshblobs",
+			"account_key":    "fake-key",
+			"container_name": "bosh",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.UsesManagedIdentity()).To(BeFalse())
+	})
+
+	It("rejects unknown environments", func() {
+		_, err := azure.NewConfigFromOptions(map[string]interface{}{
+			"account_name":   "boshblobs",
+			"container_name": "bosh",
+			"environment":    "AzureMoonCloud",
+		})
+		Expect(err).To(MatchError(ContainSubstring("Unsupported azure environment 'AzureMoonCloud'")))
+	})
+
+	It("requires an account and container name", func() {
+		_, err := azure.NewConfigFromOptions(map[string]interface{}{"account_name": "boshblobs"})
+		Expect(err).To(MatchError(ContainSubstring("container_name")))
+	})
+})
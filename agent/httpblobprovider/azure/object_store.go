@@ -0,0 +1,177 @@
+package azure
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"code.cloudfoundry.org/clock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+const (
+	// Compiled packages larger than this are uploaded as a list of blocks
+	DefaultBlockSize = 8 * 1024 * 1024
+
+	// Bearer token authorization needs at least 2017-11-09
+	storageAPIVersion = "2021-08-06"
+)
+
+var digestAlgorithms = []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1, boshcrypto.DigestAlgorithmSHA512}
+
+// ObjectStore addresses blobs in the configured container and authorizes
+// requests for them with the VM's managed identity.
+type ObjectStore struct {
+	config        Config
+	tokens        TokenSource
+	fs            boshsys.FileSystem
+	client        *http.Client
+	uploadLimiter *httpblobprovider.RateLimiter
+	blockSize     int64
+	clock         clock.Clock
+}
+
+func NewObjectStore(
+	config Config,
+	tokens TokenSource,
+	fs boshsys.FileSystem,
+	client *http.Client,
+	uploadLimiter *httpblobprovider.RateLimiter,
+	blockSize int64,
+	clock clock.Clock,
+) ObjectStore {
+	return ObjectStore{
+		config:        config,
+		tokens:        tokens,
+		fs:            fs,
+		client:        client,
+		uploadLimiter: uploadLimiter,
+		blockSize:     blockSize,
+		clock:         clock,
+	}
+}
+
+func (s ObjectStore) ObjectURL(blobID string) string {
+	return s.config.ObjectURL(blobID)
+}
+
+func (s ObjectStore) Authorize(method, objectURL string, headers map[string]string) (map[string]string, error) {
+	token, err := s.tokens.Token()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Retrieving azure managed identity token")
+	}
+
+	authorizedHeaders := make(map[string]string, len(headers)+3)
+	for k, v := range headers {
+		authorizedHeaders[k] = v
+	}
+	authorizedHeaders["Authorization"] = "Bearer " + token.AccessToken
+	authorizedHeaders["X-Ms-Version"] = storageAPIVersion
+	authorizedHeaders["X-Ms-Date"] = s.clock.Now().UTC().Format(http.TimeFormat)
+
+	return authorizedHeaders, nil
+}
+
+// Upload puts blobs up to the block size in a single request. Larger blobs
+// are uploaded block by block and committed with a block list, authorizing
+// every request separately so that long uploads outlive a single token.
+func (s ObjectStore) Upload(objectURL, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	digest, err := boshcrypto.NewMultipleDigestFromPath(path, s.fs, digestAlgorithms)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	stat, err := s.fs.Stat(path)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	file, err := s.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	size := stat.Size()
+
+	if size <= s.blockSize {
+		blobHeaders := map[string]string{"X-Ms-Blob-Type": "BlockBlob"}
+		for k, v := range headers {
+			blobHeaders[k] = v
+		}
+
+		err = s.put(objectURL, file, size, blobHeaders)
+		if err != nil {
+			return boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Putting blob")
+		}
+
+		return digest, nil
+	}
+
+	var blockList bytes.Buffer
+	blockList.WriteString(`<?xml version="1.0" encoding="utf-8"?><BlockList>`)
+
+	for index, offset := 0, int64(0); offset < size; index, offset = index+1, offset+s.blockSize {
+		// Block IDs within a blob must all have the same length
+		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%010d", index)))
+		length := min(s.blockSize, size-offset)
+
+		blockURL := objectURL + "?comp=block&blockid=" + url.QueryEscape(blockID)
+
+		err = s.put(blockURL, io.NewSectionReader(file, offset, length), length, headers)
+		if err != nil {
+			return boshcrypto.MultipleDigest{}, bosherr.WrapErrorf(err, "Putting block %d", index)
+		}
+
+		blockList.WriteString("<Latest>" + blockID + "</Latest>")
+	}
+
+	blockList.WriteString("</BlockList>")
+
+	err = s.put(objectURL+"?comp=blocklist", &blockList, int64(blockList.Len()), headers)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Putting block list")
+	}
+
+	return digest, nil
+}
+
+func (s ObjectStore) put(requestURL string, body io.Reader, length int64, headers map[string]string) error {
+	authorizedHeaders, err := s.Authorize(http.MethodPut, requestURL, headers)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, requestURL, httpblobprovider.NewThrottledReader(body, s.uploadLimiter)) //nolint:noctx
+	if err != nil {
+		return err
+	}
+
+	for k, v := range authorizedHeaders {
+		req.Header.Set(k, v)
+	}
+
+	req.ContentLength = length
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("response was %d", resp.StatusCode)
+	}
+
+	return nil
+}
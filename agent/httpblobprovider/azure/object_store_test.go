@@ -0,0 +1,160 @@
+package azure_test
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/azure"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/azure/azurefakes"
+)
+
+type storedRequest struct {
+	Method  string
+	Query   string
+	Headers http.Header
+	Body    string
+}
+
+var _ = Describe("ObjectStore", func() {
+	var (
+		server      *httptest.Server
+		requests    []storedRequest
+		requestLock sync.Mutex
+		status      int
+		fakeTokens  *azurefakes.FakeTokenSource
+		blobPath    string
+		store       azure.ObjectStore
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		status = http.StatusCreated
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			requestLock.Lock()
+			requests = append(requests, storedRequest{Method: r.Method, Query: r.URL.RawQuery, Headers: r.Header, Body: string(body)})
+			requestLock.Unlock()
+
+			w.WriteHeader(status)
+		}))
+
+		fakeTokens = &azurefakes.FakeTokenSource{}
+		fakeTokens.TokenReturns(azure.Token{AccessToken: "fake-access-token"}, nil)
+
+		blobPath = filepath.Join(GinkgoT().TempDir(), "blob")
+		Expect(os.WriteFile(blobPath, []byte("0123456789"), 0600)).To(Succeed())
+
+		store = azure.NewObjectStore(
+			azure.Config{AccountName: "boshblobs", ContainerName: "bosh", Environment: "AzureCloud"},
+			fakeTokens,
+			boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone)),
+			server.Client(),
+			nil,
+			4,
+			fakeclock.NewFakeClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)),
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("addresses blobs in the configured container", func() {
+		Expect(store.ObjectURL("some-blob-id")).To(Equal("https://boshblobs.blob.core.windows.net/bosh/some-blob-id"))
+	})
+
+	It("authorizes requests with the managed identity token", func() {
+		headers, err := store.Authorize("GET", store.ObjectURL("some-blob-id"), map[string]string{"X-Custom": "value"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(headers).To(Equal(map[string]string{
+			"Authorization": "Bearer fake-access-token",
+			"X-Ms-Version":  "2021-08-06",
+			"X-Ms-Date":     "Tue, 01 Jan 2030 00:00:00 GMT",
+			"X-Custom":      "value",
+		}))
+	})
+
+	It("returns an error when no token is available", func() {
+		fakeTokens.TokenReturns(azure.Token{}, errors.New("fake-token-error"))
+
+		_, err := store.Authorize("GET", store.ObjectURL("some-blob-id"), nil)
+		Expect(err).To(MatchError(ContainSubstring("fake-token-error")))
+	})
+
+	Describe("Upload", func() {
+		It("puts blobs no larger than a block in one request", func() {
+			Expect(os.WriteFile(blobPath, []byte("0123"), 0600)).To(Succeed())
+
+			digest, err := store.Upload(server.URL+"/bosh/some-blob-id", blobPath, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest.String()).To(ContainSubstring("sha512:"))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal("PUT"))
+			Expect(requests[0].Query).To(BeEmpty())
+			Expect(requests[0].Headers.Get("X-Ms-Blob-Type")).To(Equal("BlockBlob"))
+			Expect(requests[0].Headers.Get("Authorization")).To(Equal("Bearer fake-access-token"))
+			Expect(requests[0].Body).To(Equal("0123"))
+		})
+
+		It("uploads larger blobs in blocks and commits the block list", func() {
+			_, err := store.Upload(server.URL+"/bosh/some-blob-id", blobPath, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(4))
+
+			var blockIDs []string
+			for i, body := range []string{"0123", "4567", "89"} {
+				Expect(requests[i].Method).To(Equal("PUT"))
+				Expect(requests[i].Body).To(Equal(body))
+				Expect(requests[i].Headers.Get("Authorization")).To(Equal("Bearer fake-access-token"))
+
+				query, err := url.ParseQuery(requests[i].Query)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(query.Get("comp")).To(Equal("block"))
+
+				blockID, err := base64.StdEncoding.DecodeString(query.Get("blockid"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(blockID).To(HaveLen(16))
+
+				blockIDs = append(blockIDs, query.Get("blockid"))
+			}
+
+			Expect(requests[3].Query).To(Equal("comp=blocklist"))
+
+			var blockList struct {
+				Latest []string `xml:"Latest"`
+			}
+			Expect(xml.Unmarshal([]byte(requests[3].Body), &blockList)).To(Succeed())
+			Expect(blockList.Latest).To(Equal(blockIDs))
+
+			Expect(fakeTokens.TokenCallCount()).To(Equal(4))
+		})
+
+		It("returns an error when a block is rejected", func() {
+			status = http.StatusForbidden
+
+			_, err := store.Upload(server.URL+"/bosh/some-blob-id", blobPath, nil)
+			Expect(err).To(MatchError(ContainSubstring("Putting block 0: response was 403")))
+		})
+	})
+})
@@ -0,0 +1,139 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	DefaultInstanceMetadataEndpoint = "http://169.254.169.254"
+
+	// Tokens are refreshed this long before they expire so that requests
+	// authorized right before expiry still reach the storage account in time
+	tokenExpiryWindow = 5 * time.Minute
+
+	instanceMetadataTimeout = 5 * time.Second
+	identityTokenPath       = "/metadata/identity/oauth2/token"
+	identityAPIVersion      = "2018-02-01"
+	storageResource         = "https://storage.azure.com/"
+)
+
+type Token struct {
+	AccessToken string
+	Expiration  time.Time
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . TokenSource
+
+type TokenSource interface {
+	Token() (Token, error)
+}
+
+// NewDefaultTokenSource fetches storage access tokens for the VM's system
+// assigned managed identity. Tokens are cached until shortly before they
+// expire.
+func NewDefaultTokenSource(clock clock.Clock) TokenSource {
+	// The instance metadata service is link-local and must never be reached
+	// through the blobstore proxy
+	metadataClient := &http.Client{Timeout: instanceMetadataTimeout, Transport: &http.Transport{}}
+
+	return NewCachingTokenSource(NewManagedIdentityTokenSource(metadataClient, DefaultInstanceMetadataEndpoint), clock)
+}
+
+type managedIdentityTokenSource struct {
+	client   *http.Client
+	endpoint string
+}
+
+func NewManagedIdentityTokenSource(client *http.Client, endpoint string) TokenSource {
+	return managedIdentityTokenSource{client: client, endpoint: strings.TrimSuffix(endpoint, "/")}
+}
+
+func (s managedIdentityTokenSource) Token() (Token, error) {
+	query := url.Values{
+		"api-version": {identityAPIVersion},
+		"resource":    {storageResource},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.endpoint+identityTokenPath+"?"+query.Encode(), nil) //nolint:noctx
+	if err != nil {
+		return Token{}, bosherr.WrapError(err, "Building managed identity token request")
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Token{}, bosherr.WrapError(err, "Fetching managed identity token")
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Token{}, bosherr.WrapError(err, "Reading managed identity token")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return Token{}, fmt.Errorf("Fetching managed identity token, response was %d", resp.StatusCode) //nolint:staticcheck
+	}
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresOn   string `json:"expires_on"`
+	}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return Token{}, bosherr.WrapError(err, "Unmarshalling managed identity token")
+	}
+
+	if response.AccessToken == "" {
+		return Token{}, bosherr.Error("Instance metadata service returned an empty managed identity token")
+	}
+
+	expiresOn, err := strconv.ParseInt(response.ExpiresOn, 10, 64)
+	if err != nil {
+		return Token{}, bosherr.WrapErrorf(err, "Parsing managed identity token expiry '%s'", response.ExpiresOn)
+	}
+
+	return Token{AccessToken: response.AccessToken, Expiration: time.Unix(expiresOn, 0).UTC()}, nil
+}
+
+type cachingTokenSource struct {
+	source TokenSource
+	clock  clock.Clock
+
+	lock  sync.Mutex
+	token *Token
+}
+
+func NewCachingTokenSource(source TokenSource, clock clock.Clock) TokenSource {
+	return &cachingTokenSource{source: source, clock: clock}
+}
+
+func (s *cachingTokenSource) Token() (Token, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.token != nil && s.clock.Now().Add(tokenExpiryWindow).Before(s.token.Expiration) {
+		return *s.token, nil
+	}
+
+	token, err := s.source.Token()
+	if err != nil {
+		return Token{}, err
+	}
+
+	s.token = &token
+
+	return token, nil
+}
@@ -0,0 +1,101 @@
+package azure_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/azure"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/azure/azurefakes"
+)
+
+var _ = Describe("Token sources", func() {
+	Describe("ManagedIdentityTokenSource", func() {
+		var (
+			server      *httptest.Server
+			tokenStatus int
+		)
+
+		BeforeEach(func() {
+			tokenStatus = http.StatusOK
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/metadata/identity/oauth2/token"))
+				Expect(r.URL.Query().Get("api-version")).To(Equal("2018-02-01"))
+				Expect(r.URL.Query().Get("resource")).To(Equal("https://storage.azure.com/"))
+				Expect(r.Header.Get("Metadata")).To(Equal("true"))
+
+				w.WriteHeader(tokenStatus)
+				w.Write([]byte(`{"access_token": "fake-access-token", "expires_in": "3599", "expires_on": "1893459600", "token_type": "Bearer"}`)) //nolint:errcheck
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("fetches a storage token for the managed identity", func() {
+			token, err := azure.NewManagedIdentityTokenSource(server.Client(), server.URL).Token()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(token).To(Equal(azure.Token{
+				AccessToken: "fake-access-token",
+				Expiration:  time.Date(2030, 1, 1, 1, 0, 0, 0, time.UTC),
+			}))
+		})
+
+		It("returns an error when the instance metadata service fails", func() {
+			tokenStatus = http.StatusBadRequest
+
+			_, err := azure.NewManagedIdentityTokenSource(server.Client(), server.URL).Token()
+			Expect(err).To(MatchError(ContainSubstring("response was 400")))
+		})
+	})
+
+	Describe("CachingTokenSource", func() {
+		var (
+			clock      *fakeclock.FakeClock
+			fakeSource *azurefakes.FakeTokenSource
+			source     azure.TokenSource
+		)
+
+		BeforeEach(func() {
+			clock = fakeclock.NewFakeClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+
+			fakeSource = &azurefakes.FakeTokenSource{}
+			fakeSource.TokenReturns(azure.Token{AccessToken: "fake-access-token", Expiration: clock.Now().Add(time.Hour)}, nil)
+
+			source = azure.NewCachingTokenSource(fakeSource, clock)
+		})
+
+		It("reuses tokens until shortly before they expire", func() {
+			_, err := source.Token()
+			Expect(err).NotTo(HaveOccurred())
+
+			clock.Increment(50 * time.Minute)
+			_, err = source.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeSource.TokenCallCount()).To(Equal(1))
+
+			clock.Increment(6 * time.Minute)
+			_, err = source.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeSource.TokenCallCount()).To(Equal(2))
+		})
+
+		It("does not cache errors", func() {
+			fakeSource.TokenReturnsOnCall(0, azure.Token{}, errors.New("fake-token-error"))
+
+			_, err := source.Token()
+			Expect(err).To(MatchError("fake-token-error"))
+
+			_, err = source.Token()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
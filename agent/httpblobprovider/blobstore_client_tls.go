@@ -0,0 +1,58 @@
+package httpblobprovider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+// ConfigureClientTLS makes client present a certificate to signed URL
+// endpoints and trust the given CA in addition to the ones it already trusts.
+func ConfigureClientTLS(client *http.Client, tlsSettings settings.CertKeyPair) error {
+	if tlsSettings.Certificate == "" && tlsSettings.PrivateKey == "" && tlsSettings.CA == "" {
+		return nil
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return errors.New("Configuring blobstore client TLS: unsupported http transport")
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	tlsConfig := transport.TLSClientConfig
+
+	if tlsSettings.Certificate != "" || tlsSettings.PrivateKey != "" {
+		cert, err := tls.X509KeyPair([]byte(tlsSettings.Certificate), []byte(tlsSettings.PrivateKey))
+		if err != nil {
+			return bosherr.WrapError(err, "Parsing blobstore client certificate")
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if tlsSettings.CA != "" {
+		rootCAs := tlsConfig.RootCAs
+		if rootCAs == nil {
+			var err error
+			rootCAs, err = x509.SystemCertPool()
+			if err != nil {
+				rootCAs = x509.NewCertPool()
+			}
+		}
+
+		if !rootCAs.AppendCertsFromPEM([]byte(tlsSettings.CA)) {
+			return errors.New("Parsing blobstore client CA: no certificates found")
+		}
+
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	return nil
+}
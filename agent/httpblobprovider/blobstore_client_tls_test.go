@@ -0,0 +1,128 @@
+package httpblobprovider_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	httpblobprovider "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	"github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+var _ = Describe("ConfigureClientTLS", func() {
+	var (
+		client        *http.Client
+		server        *httptest.Server
+		clientCertPEM string
+		clientKeyPEM  string
+		serverCAPEM   string
+	)
+
+	BeforeEach(func() {
+		var err error
+		client, err = httpblobprovider.NewBlobstoreHTTPClient(settings.Blobstore{Type: "dav"})
+		Expect(err).NotTo(HaveOccurred())
+
+		clientCertPEM, clientKeyPEM = generateClientCertificate()
+
+		clientCAs := x509.NewCertPool()
+		Expect(clientCAs.AppendCertsFromPEM([]byte(clientCertPEM))).To(BeTrue())
+
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.TLS = &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+			MinVersion: tls.VersionTLS12,
+		}
+		server.StartTLS()
+
+		serverCAPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("leaves the client alone when nothing is configured", func() {
+		tlsConfig := client.Transport.(*http.Transport).TLSClientConfig.Clone()
+
+		err := httpblobprovider.ConfigureClientTLS(client, settings.CertKeyPair{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(client.Transport.(*http.Transport).TLSClientConfig.Certificates).To(Equal(tlsConfig.Certificates))
+		Expect(client.Transport.(*http.Transport).TLSClientConfig.RootCAs).To(BeNil())
+	})
+
+	It("presents the client certificate to endpoints requiring mutual TLS", func() {
+		err := httpblobprovider.ConfigureClientTLS(client, settings.CertKeyPair{
+			CA:          serverCAPEM,
+			Certificate: clientCertPEM,
+			PrivateKey:  clientKeyPEM,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := client.Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	})
+
+	It("fails the handshake without a client certificate", func() {
+		err := httpblobprovider.ConfigureClientTLS(client, settings.CertKeyPair{CA: serverCAPEM})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.Get(server.URL)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error when the key pair is invalid", func() {
+		err := httpblobprovider.ConfigureClientTLS(client, settings.CertKeyPair{
+			Certificate: clientCertPEM,
+			PrivateKey:  "invalid-key",
+		})
+		Expect(err).To(MatchError(ContainSubstring("Parsing blobstore client certificate")))
+	})
+
+	It("returns an error when the CA is invalid", func() {
+		err := httpblobprovider.ConfigureClientTLS(client, settings.CertKeyPair{CA: "invalid-ca"})
+		Expect(err).To(MatchError(ContainSubstring("Parsing blobstore client CA")))
+	})
+})
+
+func generateClientCertificate() (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "bosh-agent"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
@@ -0,0 +1,48 @@
+package httpblobprovider
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// ConfigureConnectionPool makes client keep connections to blobstore
+// endpoints open between blob transfers and negotiate HTTP/2 with endpoints
+// that support it, which saves a TLS handshake for most of the dozens of
+// blobs fetched during an apply.
+func ConfigureConnectionPool(client *http.Client, poolSettings settings.BlobstoreConnectionPool) error {
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return errors.New("Configuring blobstore connection pool: unsupported http transport")
+	}
+
+	transport.DisableKeepAlives = false
+	transport.MaxIdleConns = DefaultMaxIdleConns
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = DefaultIdleConnTimeout
+
+	if poolSettings.MaxIdleConns > 0 {
+		transport.MaxIdleConns = poolSettings.MaxIdleConns
+	}
+
+	if poolSettings.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = poolSettings.MaxIdleConnsPerHost
+	}
+
+	if poolSettings.IdleTimeoutSeconds > 0 {
+		transport.IdleConnTimeout = time.Duration(poolSettings.IdleTimeoutSeconds * float64(time.Second))
+	}
+
+	// A custom dialer and TLS config turn off HTTP/2 unless it is forced
+	transport.ForceAttemptHTTP2 = !poolSettings.DisableHTTP2
+
+	return nil
+}
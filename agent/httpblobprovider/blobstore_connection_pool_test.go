@@ -0,0 +1,107 @@
+package httpblobprovider_test
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	httpblobprovider "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	"github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+var _ = Describe("ConfigureConnectionPool", func() {
+	var (
+		client *http.Client
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		var err error
+		client, err = httpblobprovider.NewBlobstoreHTTPClient(settings.Blobstore{Type: "dav"})
+		Expect(err).NotTo(HaveOccurred())
+
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.EnableHTTP2 = true
+		server.StartTLS()
+
+		serverCAPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+		Expect(httpblobprovider.ConfigureClientTLS(client, settings.CertKeyPair{CA: serverCAPEM})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := func() (*http.Response, bool) {
+		var reused bool
+		trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) { reused = info.Reused }}
+
+		req, err := http.NewRequest("GET", server.URL, nil) //nolint:noctx
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := client.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = io.Copy(io.Discard, resp.Body) //nolint:errcheck
+		Expect(resp.Body.Close()).To(Succeed())
+
+		return resp, reused
+	}
+
+	It("uses the default pool settings", func() {
+		Expect(httpblobprovider.ConfigureConnectionPool(client, settings.BlobstoreConnectionPool{})).To(Succeed())
+
+		transport := client.Transport.(*http.Transport)
+		Expect(transport.DisableKeepAlives).To(BeFalse())
+		Expect(transport.MaxIdleConns).To(Equal(httpblobprovider.DefaultMaxIdleConns))
+		Expect(transport.MaxIdleConnsPerHost).To(Equal(httpblobprovider.DefaultMaxIdleConnsPerHost))
+		Expect(transport.IdleConnTimeout).To(Equal(httpblobprovider.DefaultIdleConnTimeout))
+	})
+
+	It("uses the configured pool settings", func() {
+		Expect(httpblobprovider.ConfigureConnectionPool(client, settings.BlobstoreConnectionPool{
+			MaxIdleConns:        10,
+			MaxIdleConnsPerHost: 4,
+			IdleTimeoutSeconds:  1.5,
+		})).To(Succeed())
+
+		transport := client.Transport.(*http.Transport)
+		Expect(transport.MaxIdleConns).To(Equal(10))
+		Expect(transport.MaxIdleConnsPerHost).To(Equal(4))
+		Expect(transport.IdleConnTimeout).To(Equal(1500 * time.Millisecond))
+	})
+
+	It("negotiates HTTP/2 and reuses the connection", func() {
+		Expect(httpblobprovider.ConfigureConnectionPool(client, settings.BlobstoreConnectionPool{})).To(Succeed())
+
+		resp, reused := get()
+		Expect(resp.ProtoMajor).To(Equal(2))
+		Expect(reused).To(BeFalse())
+
+		_, reused = get()
+		Expect(reused).To(BeTrue())
+	})
+
+	It("reuses HTTP/1.1 connections when HTTP/2 is disabled", func() {
+		Expect(httpblobprovider.ConfigureConnectionPool(client, settings.BlobstoreConnectionPool{DisableHTTP2: true})).To(Succeed())
+
+		resp, _ := get()
+		Expect(resp.ProtoMajor).To(Equal(1))
+
+		_, reused := get()
+		Expect(reused).To(BeTrue())
+	})
+
+	It("errors for clients without an http transport", func() {
+		err := httpblobprovider.ConfigureConnectionPool(&http.Client{Transport: http.NewFileTransport(http.Dir("/"))}, settings.BlobstoreConnectionPool{})
+		Expect(err).To(MatchError("Configuring blobstore connection pool: unsupported http transport"))
+	})
+})
@@ -0,0 +1,309 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	"container/list"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	blobCacheLogTag        = "BlobCache"
+	blobCacheInProgressExt = ".partial"
+
+	blobCacheDirMode  = os.FileMode(0700)
+	blobCacheFileMode = os.FileMode(0600)
+)
+
+// BlobCache keeps recently downloaded blobs on local disk keyed by their
+// strongest digest. Least recently used blobs are evicted once the total
+// size of the cache goes above maxSize. Cached blobs are only readable by
+// root, which the agent runs as.
+type BlobCache struct {
+	fs      boshsys.FileSystem
+	dir     string
+	maxSize int64
+	logger  boshlog.Logger
+
+	lock    sync.Mutex
+	loaded  bool
+	size    int64
+	entries *list.List
+	index   map[string]*list.Element
+}
+
+type blobCacheEntry struct {
+	key  string
+	size int64
+}
+
+func NewBlobCache(fs boshsys.FileSystem, dir string, maxSize uint64, logger boshlog.Logger) *BlobCache {
+	return &BlobCache{
+		fs:      fs,
+		dir:     dir,
+		maxSize: int64(maxSize),
+		logger:  logger,
+		entries: list.New(),
+		index:   map[string]*list.Element{},
+	}
+}
+
+// Get returns the path to a temporary copy of the cached blob. The caller
+// owns the copy and is responsible for removing it.
+func (c *BlobCache) Get(digest boshcrypto.Digest) (string, bool) {
+	key, ok := blobCacheKey(digest)
+	if !ok {
+		return "", false
+	}
+
+	c.lock.Lock()
+	c.load()
+	elem, found := c.index[key]
+	if found {
+		c.entries.MoveToFront(elem)
+	}
+	c.lock.Unlock()
+
+	if !found {
+		return "", false
+	}
+
+	file, err := c.fs.TempFile("bosh-blob-cache-GET")
+	if err != nil {
+		c.logger.Warn(blobCacheLogTag, "Creating temporary file for cached blob %s: %s", key, err.Error())
+		return "", false
+	}
+
+	fileName := file.Name()
+	_ = file.Close()
+
+	err = c.fs.CopyFile(c.path(key), fileName)
+	if err == nil {
+		err = digest.VerifyFilePath(fileName, c.fs)
+	}
+
+	if err != nil {
+		c.logger.Warn(blobCacheLogTag, "Discarding cached blob %s: %s", key, err.Error())
+		_ = c.fs.RemoveAll(fileName)
+		c.remove(key)
+		return "", false
+	}
+
+	c.logger.Debug(blobCacheLogTag, "Using cached blob %s", key)
+
+	return fileName, true
+}
+
+// Put stores a copy of the blob at path. Blobs larger than the cache are ignored.
+func (c *BlobCache) Put(digest boshcrypto.Digest, path string) error {
+	key, ok := blobCacheKey(digest)
+	if !ok {
+		return nil
+	}
+
+	stat, err := c.fs.Stat(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Checking size of blob %s", key)
+	}
+
+	if stat.Size() > c.maxSize {
+		return nil
+	}
+
+	c.lock.Lock()
+	c.load()
+	_, found := c.index[key]
+	c.lock.Unlock()
+
+	if found {
+		return nil
+	}
+
+	err = c.fs.MkdirAll(c.dir, blobCacheDirMode)
+	if err == nil {
+		err = c.fs.Chmod(c.dir, blobCacheDirMode)
+	}
+	if err != nil {
+		return bosherr.WrapError(err, "Creating blob cache directory")
+	}
+
+	inProgressPath := c.path(key) + blobCacheInProgressExt
+
+	err = c.copyPrivate(path, inProgressPath)
+	if err != nil {
+		_ = c.fs.RemoveAll(inProgressPath)
+		return bosherr.WrapErrorf(err, "Copying blob %s into cache", key)
+	}
+
+	err = c.fs.Rename(inProgressPath, c.path(key))
+	if err != nil {
+		_ = c.fs.RemoveAll(inProgressPath)
+		return bosherr.WrapErrorf(err, "Moving blob %s into cache", key)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, found := c.index[key]; !found {
+		c.index[key] = c.entries.PushFront(&blobCacheEntry{key: key, size: stat.Size()})
+		c.size += stat.Size()
+	}
+
+	c.evict()
+
+	return nil
+}
+
+// load must be called with the lock held
+func (c *BlobCache) load() {
+	if c.loaded {
+		return
+	}
+
+	c.loaded = true
+
+	paths, err := c.fs.Glob(filepath.Join(c.dir, "*"))
+	if err != nil {
+		c.logger.Warn(blobCacheLogTag, "Listing cached blobs: %s", err.Error())
+		return
+	}
+
+	type cachedBlob struct {
+		entry   *blobCacheEntry
+		modTime int64
+	}
+
+	var blobs []cachedBlob
+
+	for _, path := range paths {
+		if strings.HasSuffix(path, blobCacheInProgressExt) {
+			_ = c.fs.RemoveAll(path)
+			continue
+		}
+
+		stat, err := c.fs.Stat(path)
+		if err != nil || stat.IsDir() {
+			continue
+		}
+
+		// Blobs cached by earlier agent versions kept the mode of the download
+		if stat.Mode().Perm() != blobCacheFileMode {
+			if err := c.fs.Chmod(path, blobCacheFileMode); err != nil {
+				c.logger.Warn(blobCacheLogTag, "Discarding cached blob %s: %s", filepath.Base(path), err.Error())
+				_ = c.fs.RemoveAll(path)
+				continue
+			}
+		}
+
+		blobs = append(blobs, cachedBlob{
+			entry:   &blobCacheEntry{key: filepath.Base(path), size: stat.Size()},
+			modTime: stat.ModTime().UnixNano(),
+		})
+	}
+
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].modTime < blobs[j].modTime })
+
+	for _, blob := range blobs {
+		c.index[blob.entry.key] = c.entries.PushFront(blob.entry)
+		c.size += blob.entry.size
+	}
+
+	c.evict()
+}
+
+// evict must be called with the lock held
+func (c *BlobCache) evict() {
+	for c.size > c.maxSize {
+		elem := c.entries.Back()
+		if elem == nil {
+			return
+		}
+
+		entry := elem.Value.(*blobCacheEntry)
+
+		err := c.fs.RemoveAll(c.path(entry.key))
+		if err != nil {
+			c.logger.Warn(blobCacheLogTag, "Evicting cached blob %s: %s", entry.key, err.Error())
+		}
+
+		c.entries.Remove(elem)
+		delete(c.index, entry.key)
+		c.size -= entry.size
+	}
+}
+
+func (c *BlobCache) remove(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_ = c.fs.RemoveAll(c.path(key))
+
+	if elem, found := c.index[key]; found {
+		c.size -= elem.Value.(*blobCacheEntry).size
+		c.entries.Remove(elem)
+		delete(c.index, key)
+	}
+}
+
+// copyPrivate copies the blob at srcPath to dstPath with blobCacheFileMode
+// regardless of the mode of the blob or of a left over dstPath
+func (c *BlobCache) copyPrivate(srcPath, dstPath string) error {
+	src, err := c.fs.OpenFile(srcPath, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer src.Close() //nolint:errcheck
+
+	dst, err := c.fs.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, blobCacheFileMode)
+	if err != nil {
+		return err
+	}
+
+	err = c.fs.Chmod(dstPath, blobCacheFileMode)
+	if err == nil {
+		_, err = io.Copy(dst, src)
+	}
+
+	closeErr := dst.Close()
+	if err != nil {
+		return err
+	}
+
+	return closeErr
+}
+
+func (c *BlobCache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+func blobCacheKey(digest boshcrypto.Digest) (string, bool) {
+	if digest == nil {
+		return "", false
+	}
+
+	if multipleDigest, ok := digest.(boshcrypto.MultipleDigest); ok {
+		for _, algo := range []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA512, boshcrypto.DigestAlgorithmSHA256, boshcrypto.DigestAlgorithmSHA1} {
+			if strongest, err := multipleDigest.DigestFor(algo); err == nil {
+				return blobCacheKey(strongest)
+			}
+		}
+		return "", false
+	}
+
+	algoName := digest.Algorithm().Name()
+	value := strings.TrimPrefix(digest.String(), algoName+":")
+
+	if value == "" || strings.ContainsAny(value, `/\.:;`) {
+		return "", false
+	}
+
+	return algoName + "-" + value, true
+}
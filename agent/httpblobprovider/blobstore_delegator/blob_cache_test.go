@@ -0,0 +1,153 @@
+package blobstore_delegator_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+)
+
+var _ = Describe("BlobCache", func() {
+	var (
+		tmpDir   string
+		cacheDir string
+		fs       boshsys.FileSystem
+		logger   boshlog.Logger
+		cache    *blobstore_delegator.BlobCache
+	)
+
+	writeBlob := func(contents string) (string, boshcrypto.MultipleDigest) {
+		path := filepath.Join(tmpDir, "blob-"+contents)
+		Expect(os.WriteFile(path, []byte(contents), 0600)).To(Succeed())
+
+		digest, err := boshcrypto.NewMultipleDigest(strings.NewReader(contents), []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1, boshcrypto.DigestAlgorithmSHA256})
+		Expect(err).ToNot(HaveOccurred())
+
+		return path, digest
+	}
+
+	BeforeEach(func() {
+		tmpDir = GinkgoT().TempDir()
+		cacheDir = filepath.Join(tmpDir, "blob_cache")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		Expect(fs.ChangeTempRoot(tmpDir)).To(Succeed())
+		cache = blobstore_delegator.NewBlobCache(fs, cacheDir, 10, logger)
+	})
+
+	It("misses when the blob has not been cached", func() {
+		_, digest := writeBlob("abc")
+
+		_, found := cache.Get(digest)
+		Expect(found).To(BeFalse())
+	})
+
+	It("returns a copy of a cached blob keyed by its strongest digest", func() {
+		path, digest := writeBlob("abc")
+		Expect(cache.Put(digest, path)).To(Succeed())
+
+		Expect(filepath.Join(cacheDir, "sha256-ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad")).To(BeAnExistingFile())
+
+		fileName, found := cache.Get(digest)
+		Expect(found).To(BeTrue())
+		Expect(fileName).ToNot(Equal(path))
+		Expect(os.ReadFile(fileName)).To(Equal([]byte("abc")))
+	})
+
+	It("keeps cached blobs readable only by root", func() {
+		if runtime.GOOS == "windows" {
+			Skip("File modes are not supported on Windows")
+		}
+
+		path, digest := writeBlob("abc")
+		Expect(os.Chmod(path, 0644)).To(Succeed())
+		Expect(cache.Put(digest, path)).To(Succeed())
+
+		stat, err := os.Stat(cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0700)))
+
+		stat, err = os.Stat(filepath.Join(cacheDir, "sha256-ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("restricts blobs cached by a previous agent run to root", func() {
+		if runtime.GOOS == "windows" {
+			Skip("File modes are not supported on Windows")
+		}
+
+		_, digest := writeBlob("abc")
+		cachedPath := filepath.Join(cacheDir, "sha256-ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad")
+		Expect(os.MkdirAll(cacheDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(cachedPath, []byte("abc"), 0644)).To(Succeed())
+		Expect(os.Chmod(cachedPath, 0644)).To(Succeed())
+
+		_, found := cache.Get(digest)
+		Expect(found).To(BeTrue())
+
+		stat, err := os.Stat(cachedPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("evicts the least recently used blobs when going above the size limit", func() {
+		path1, digest1 := writeBlob("1111")
+		path2, digest2 := writeBlob("2222")
+		path3, digest3 := writeBlob("3333")
+
+		Expect(cache.Put(digest1, path1)).To(Succeed())
+		Expect(cache.Put(digest2, path2)).To(Succeed())
+
+		_, found := cache.Get(digest1)
+		Expect(found).To(BeTrue())
+
+		Expect(cache.Put(digest3, path3)).To(Succeed())
+
+		_, found = cache.Get(digest2)
+		Expect(found).To(BeFalse())
+
+		_, found = cache.Get(digest1)
+		Expect(found).To(BeTrue())
+		_, found = cache.Get(digest3)
+		Expect(found).To(BeTrue())
+	})
+
+	It("ignores blobs larger than the cache", func() {
+		path, digest := writeBlob("larger-than-ten-bytes")
+		Expect(cache.Put(digest, path)).To(Succeed())
+
+		_, found := cache.Get(digest)
+		Expect(found).To(BeFalse())
+	})
+
+	It("discards cached blobs that no longer match their digest", func() {
+		path, digest := writeBlob("abc")
+		Expect(cache.Put(digest, path)).To(Succeed())
+
+		cachedPath := filepath.Join(cacheDir, "sha256-ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad")
+		Expect(os.WriteFile(cachedPath, []byte("corrupted"), 0600)).To(Succeed())
+
+		_, found := cache.Get(digest)
+		Expect(found).To(BeFalse())
+		Expect(cachedPath).ToNot(BeAnExistingFile())
+	})
+
+	It("picks up blobs cached by a previous agent run", func() {
+		path, digest := writeBlob("abc")
+		Expect(cache.Put(digest, path)).To(Succeed())
+
+		cache = blobstore_delegator.NewBlobCache(fs, cacheDir, 10, logger)
+
+		_, found := cache.Get(digest)
+		Expect(found).To(BeTrue())
+	})
+})
@@ -0,0 +1,192 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+// Encrypted blobs start with the magic, the length prefixed key ID and a
+// random nonce prefix, followed by the plaintext sealed in chunks so that
+// blobs never have to fit into memory. The last chunk is sealed differently
+// which detects truncated blobs, and is always shorter than a full chunk.
+const (
+	blobEncryptionMagic       = "BOSHENC1"
+	blobEncryptionChunkSize   = 64 * 1024
+	blobEncryptionNoncePrefix = 8
+)
+
+// BlobEncryptionKeys encrypt blobs with the active key and decrypt blobs
+// encrypted with any of the keys.
+type BlobEncryptionKeys struct {
+	activeKeyID string
+	keys        map[string]cipher.AEAD
+}
+
+func NewBlobEncryptionKeys(settings boshsettings.BlobEncryption) (*BlobEncryptionKeys, error) {
+	keys := map[string]cipher.AEAD{}
+
+	for keyID, encodedKey := range settings.Keys {
+		if keyID == "" || len(keyID) > math.MaxUint8 {
+			return nil, bosherr.Errorf("Invalid blob encryption key ID '%s'", keyID)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Decoding blob encryption key '%s'", keyID)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Creating cipher for blob encryption key '%s'", keyID)
+		}
+
+		keys[keyID], err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Creating cipher for blob encryption key '%s'", keyID)
+		}
+	}
+
+	if _, found := keys[settings.ActiveKeyID]; settings.ActiveKeyID != "" && !found {
+		return nil, bosherr.Errorf("Active blob encryption key '%s' is not one of the keys", settings.ActiveKeyID)
+	}
+
+	return &BlobEncryptionKeys{activeKeyID: settings.ActiveKeyID, keys: keys}, nil
+}
+
+// CanEncrypt returns whether there is an active key to encrypt blobs with.
+func (k *BlobEncryptionKeys) CanEncrypt() bool {
+	return k.activeKeyID != ""
+}
+
+func (k *BlobEncryptionKeys) Encrypt(dst io.Writer, src io.Reader) error {
+	aead, found := k.keys[k.activeKeyID]
+	if !found {
+		return bosherr.Error("No active blob encryption key")
+	}
+
+	noncePrefix := make([]byte, blobEncryptionNoncePrefix)
+	if _, err := rand.Read(noncePrefix); err != nil {
+		return bosherr.WrapError(err, "Generating nonce")
+	}
+
+	header := []byte(blobEncryptionMagic)
+	header = append(header, byte(len(k.activeKeyID)))
+	header = append(header, k.activeKeyID...)
+	header = append(header, noncePrefix...)
+
+	if _, err := dst.Write(header); err != nil {
+		return bosherr.WrapError(err, "Writing encryption header")
+	}
+
+	chunk := make([]byte, blobEncryptionChunkSize)
+	sealed := make([]byte, 0, blobEncryptionChunkSize+aead.Overhead())
+
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(src, chunk)
+		last := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !last {
+			return bosherr.WrapError(err, "Reading blob")
+		}
+
+		sealed = aead.Seal(sealed[:0], chunkNonce(aead, noncePrefix, counter), chunk[:n], chunkAdditionalData(last))
+
+		if _, err = dst.Write(sealed); err != nil {
+			return bosherr.WrapError(err, "Writing encrypted blob")
+		}
+
+		if last {
+			return nil
+		}
+
+		if counter == math.MaxUint32 {
+			return bosherr.Error("Blob is too large to encrypt")
+		}
+	}
+}
+
+// isEncryptedBlob returns whether the blob read from r starts like an
+// encrypted blob.
+func isEncryptedBlob(r io.Reader) bool {
+	magic := make([]byte, len(blobEncryptionMagic))
+	_, err := io.ReadFull(r, magic)
+	return err == nil && bytes.Equal(magic, []byte(blobEncryptionMagic))
+}
+
+func (k *BlobEncryptionKeys) Decrypt(dst io.Writer, src io.Reader) error {
+	reader := bufio.NewReader(src)
+
+	if !isEncryptedBlob(reader) {
+		return bosherr.Error("Blob is not encrypted")
+	}
+
+	keyIDLength, err := reader.ReadByte()
+	if err != nil {
+		return bosherr.WrapError(err, "Reading encryption header")
+	}
+
+	keyID := make([]byte, keyIDLength)
+	noncePrefix := make([]byte, blobEncryptionNoncePrefix)
+
+	if _, err = io.ReadFull(reader, keyID); err != nil {
+		return bosherr.WrapError(err, "Reading encryption header")
+	}
+
+	if _, err = io.ReadFull(reader, noncePrefix); err != nil {
+		return bosherr.WrapError(err, "Reading encryption header")
+	}
+
+	aead, found := k.keys[string(keyID)]
+	if !found {
+		return bosherr.Errorf("Blob is encrypted with unknown key '%s'", keyID)
+	}
+
+	chunk := make([]byte, blobEncryptionChunkSize+aead.Overhead())
+	opened := make([]byte, 0, blobEncryptionChunkSize)
+
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(reader, chunk)
+		last := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !last {
+			return bosherr.WrapError(err, "Reading encrypted blob")
+		}
+
+		opened, err = aead.Open(opened[:0], chunkNonce(aead, noncePrefix, counter), chunk[:n], chunkAdditionalData(last))
+		if err != nil {
+			return bosherr.WrapError(err, "Decrypting blob")
+		}
+
+		if _, err = dst.Write(opened); err != nil {
+			return bosherr.WrapError(err, "Writing decrypted blob")
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+func chunkNonce(aead cipher.AEAD, noncePrefix []byte, counter uint32) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, noncePrefix)
+	binary.BigEndian.PutUint32(nonce[len(nonce)-4:], counter)
+	return nonce
+}
+
+func chunkAdditionalData(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
@@ -2,7 +2,6 @@ package blobstore_delegator //nolint:revive
 
 import (
 	"fmt"
-	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -15,19 +14,40 @@ import (
 )
 
 type BlobstoreDelegatorImpl struct {
-	h      httpblobprovider.HTTPBlobProvider
-	b      blobstore.DigestBlobstore
-	logger boshlog.Logger
+	h           httpblobprovider.HTTPBlobProvider
+	b           blobstore.DigestBlobstore
+	refresher   SignedURLRefresher
+	retryPolicy RetryPolicy
+	metrics     *httpblobprovider.TransferMetrics
+	logger      boshlog.Logger
 }
 
-func NewBlobstoreDelegator(hp httpblobprovider.HTTPBlobProvider, bp blobstore.DigestBlobstore, logger boshlog.Logger) *BlobstoreDelegatorImpl {
+func NewBlobstoreDelegator(hp httpblobprovider.HTTPBlobProvider, bp blobstore.DigestBlobstore, retryPolicy RetryPolicy, logger boshlog.Logger) *BlobstoreDelegatorImpl {
 	return &BlobstoreDelegatorImpl{
-		h:      hp,
-		b:      bp,
-		logger: logger,
+		h:           hp,
+		b:           bp,
+		retryPolicy: retryPolicy,
+		logger:      logger,
 	}
 }
 
+// NewRefreshingBlobstoreDelegator asks refresher for a new signed URL and
+// retries when the blobstore rejects an expired one. Retries are counted in
+// metrics.
+func NewRefreshingBlobstoreDelegator(
+	hp httpblobprovider.HTTPBlobProvider,
+	bp blobstore.DigestBlobstore,
+	refresher SignedURLRefresher,
+	retryPolicy RetryPolicy,
+	metrics *httpblobprovider.TransferMetrics,
+	logger boshlog.Logger,
+) *BlobstoreDelegatorImpl {
+	b := NewBlobstoreDelegator(hp, bp, retryPolicy, logger)
+	b.refresher = refresher
+	b.metrics = metrics
+	return b
+}
+
 func (b *BlobstoreDelegatorImpl) Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (fileName string, err error) {
 	if signedURL == "" {
 		if blobID == "" {
@@ -36,16 +56,28 @@ func (b *BlobstoreDelegatorImpl) Get(digest boshcrypto.Digest, signedURL, blobID
 		return b.b.Get(blobID, digest)
 	}
 
+	attempts := 0
+
 	getBlobRetryable := boshretry.NewRetryable(func() (bool, error) {
+		attempts++
+		if attempts > 1 {
+			b.metrics.RecordRetry(httpblobprovider.TransferDownload)
+		}
+
 		fileName, err = b.h.Get(signedURL, digest, headers)
+		if b.shouldRefresh(err) {
+			if refreshErr := b.refreshSignedURL(&signedURL, &headers); refreshErr != nil {
+				return false, refreshErr
+			}
+			fileName, err = b.h.Get(signedURL, digest, headers)
+		}
 		if err != nil {
 			return true, bosherr.WrapError(err, "Failed to download blob")
 		}
 		return false, nil
 	})
 
-	attemptRetryStrategy := boshretry.NewAttemptRetryStrategy(3, 5*time.Second, getBlobRetryable, b.logger)
-	err = attemptRetryStrategy.Try()
+	err = NewRetryStrategy(b.retryPolicy, getBlobRetryable, b.logger).Try()
 	if err != nil {
 		return "", err
 	}
@@ -58,10 +90,97 @@ func (b *BlobstoreDelegatorImpl) Write(signedURL, path string, headers map[strin
 		return b.b.Create(path)
 	}
 
-	digest, err := b.h.Upload(signedURL, path, headers)
+	var digest boshcrypto.MultipleDigest
+
+	attempts := 0
+
+	uploadBlobRetryable := boshretry.NewRetryable(func() (bool, error) {
+		attempts++
+		if attempts > 1 {
+			b.metrics.RecordRetry(httpblobprovider.TransferUpload)
+		}
+
+		var err error
+		digest, err = b.h.Upload(signedURL, path, headers)
+		if b.shouldRefresh(err) {
+			if refreshErr := b.refreshSignedURL(&signedURL, &headers); refreshErr != nil {
+				return false, refreshErr
+			}
+			digest, err = b.h.Upload(signedURL, path, headers)
+		}
+		return err != nil, err
+	})
+
+	err := NewRetryStrategy(b.retryPolicy, uploadBlobRetryable, b.logger).Try()
+
 	return "", digest, err
 }
 
+func (b *BlobstoreDelegatorImpl) WriteMultipart(upload httpblobprovider.MultipartUpload, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	var digest boshcrypto.MultipleDigest
+
+	attempts := 0
+
+	uploadBlobRetryable := boshretry.NewRetryable(func() (bool, error) {
+		attempts++
+		if attempts > 1 {
+			b.metrics.RecordRetry(httpblobprovider.TransferUpload)
+		}
+
+		var err error
+		digest, err = b.h.UploadMultipart(upload, path, headers)
+		return err != nil, err
+	})
+
+	err := NewRetryStrategy(b.retryPolicy, uploadBlobRetryable, b.logger).Try()
+
+	return digest, err
+}
+
+// WriteStream uploads a blob while it is produced. Only signed URLs can be
+// streamed to since the blobstore clients upload files. Endpoints rejecting
+// uploads without a content length are not retried.
+func (b *BlobstoreDelegatorImpl) WriteStream(signedURL string, open httpblobprovider.OpenStream, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	if signedURL == "" {
+		return boshcrypto.MultipleDigest{}, bosherr.Error("Streaming a blob needs a signed URL")
+	}
+
+	var digest boshcrypto.MultipleDigest
+
+	uploadStream := func() (boshcrypto.MultipleDigest, error) {
+		stream, err := open()
+		if err != nil {
+			return boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Opening blob stream")
+		}
+		defer stream.Close() //nolint:errcheck
+
+		return b.h.UploadStream(signedURL, stream, headers)
+	}
+
+	attempts := 0
+
+	uploadBlobRetryable := boshretry.NewRetryable(func() (bool, error) {
+		attempts++
+		if attempts > 1 {
+			b.metrics.RecordRetry(httpblobprovider.TransferUpload)
+		}
+
+		var err error
+		digest, err = uploadStream()
+		if b.shouldRefresh(err) {
+			if refreshErr := b.refreshSignedURL(&signedURL, &headers); refreshErr != nil {
+				return false, refreshErr
+			}
+			digest, err = uploadStream()
+		}
+		return err != nil && !httpblobprovider.IsStreamingRejected(err), err
+	})
+
+	err := NewRetryStrategy(b.retryPolicy, uploadBlobRetryable, b.logger).Try()
+
+	return digest, err
+}
+
 func (b *BlobstoreDelegatorImpl) CleanUp(signedURL, fileName string) (err error) {
 	if signedURL != "" {
 		return fmt.Errorf("CleanUp is not supported for signed URLs")
@@ -69,9 +188,34 @@ func (b *BlobstoreDelegatorImpl) CleanUp(signedURL, fileName string) (err error)
 	return b.b.CleanUp(fileName)
 }
 
-func (b *BlobstoreDelegatorImpl) Delete(signedURL, blobID string) (err error) {
+func (b *BlobstoreDelegatorImpl) Delete(signedURL, blobID string, headers map[string]string) (err error) {
 	if signedURL != "" {
-		return fmt.Errorf("Delete is not supported for signed URLs")
+		err = b.h.Delete(signedURL, headers)
+		if b.shouldRefresh(err) {
+			if refreshErr := b.refreshSignedURL(&signedURL, &headers); refreshErr != nil {
+				return refreshErr
+			}
+			err = b.h.Delete(signedURL, headers)
+		}
+		return err
 	}
 	return b.b.Delete(blobID)
 }
+
+func (b *BlobstoreDelegatorImpl) shouldRefresh(err error) bool {
+	return err != nil && b.refresher != nil && httpblobprovider.IsSignedURLExpired(err)
+}
+
+func (b *BlobstoreDelegatorImpl) refreshSignedURL(signedURL *string, headers *map[string]string) error {
+	b.logger.Info("BlobstoreDelegator", "Signed URL was rejected, requesting a refreshed one")
+
+	refreshedURL, refreshedHeaders, err := b.refresher.Refresh(*signedURL, *headers)
+	if err != nil {
+		return bosherr.WrapError(err, "Refreshing expired signed URL")
+	}
+
+	*signedURL = refreshedURL
+	*headers = refreshedHeaders
+
+	return nil
+}
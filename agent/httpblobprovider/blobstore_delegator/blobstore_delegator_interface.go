@@ -2,6 +2,8 @@ package blobstore_delegator //nolint:revive
 
 import (
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . BlobstoreDelegator
@@ -9,6 +11,8 @@ import (
 type BlobstoreDelegator interface {
 	Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (fileName string, err error)
 	Write(signedURL, path string, headers map[string]string) (string, boshcrypto.MultipleDigest, error)
+	WriteMultipart(upload httpblobprovider.MultipartUpload, path string, headers map[string]string) (boshcrypto.MultipleDigest, error)
+	WriteStream(signedURL string, open httpblobprovider.OpenStream, headers map[string]string) (boshcrypto.MultipleDigest, error)
 	CleanUp(signedURL, path string) error
-	Delete(signedURL, blobID string) error
+	Delete(signedURL, blobID string, headers map[string]string) error
 }
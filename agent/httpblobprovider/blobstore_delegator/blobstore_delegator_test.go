@@ -2,6 +2,10 @@ package blobstore_delegator_test
 
 import (
 	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	. "github.com/onsi/ginkgo/v2"
@@ -9,7 +13,9 @@ import (
 
 	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
 	fakeblobprovider "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/httpblobproviderfakes"
 
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
@@ -21,6 +27,7 @@ var _ = Describe("BlobstoreDelegator", func() {
 		fakeHTTPBlobProvider *fakeblobprovider.FakeHTTPBlobProvider
 		fakeBlobManager      *fakeblobstore.FakeDigestBlobstore
 		logger               boshlog.Logger
+		retryPolicy          blobstore_delegator.RetryPolicy
 
 		digest = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "some-digest"))
 	)
@@ -29,8 +36,9 @@ var _ = Describe("BlobstoreDelegator", func() {
 		fakeHTTPBlobProvider = &fakeblobprovider.FakeHTTPBlobProvider{}
 		fakeBlobManager = &fakeblobstore.FakeDigestBlobstore{}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
+		retryPolicy = blobstore_delegator.RetryPolicy{Attempts: 3, Multiplier: 1}
 
-		blobstoreDelegator = blobstore_delegator.NewBlobstoreDelegator(fakeHTTPBlobProvider, fakeBlobManager, logger)
+		blobstoreDelegator = blobstore_delegator.NewBlobstoreDelegator(fakeHTTPBlobProvider, fakeBlobManager, retryPolicy, logger)
 	})
 
 	Context("Get", func() {
@@ -135,7 +143,7 @@ var _ = Describe("BlobstoreDelegator", func() {
 				Expect(headersArg).To(Equal(map[string]string{"key": "value"}))
 			})
 
-			It("errors when there is an error", func() {
+			It("errors when there is an error with retries", func() {
 				filePath := "/some/path/to/a/file"
 				fakeError := errors.New("some error")
 				fakeHTTPBlobProvider.UploadReturns(digest, fakeError)
@@ -143,7 +151,7 @@ var _ = Describe("BlobstoreDelegator", func() {
 				_, digestResult, err := blobstoreDelegator.Write("some-signed-url", filePath, nil)
 				Expect(err).To(MatchError(fakeError))
 				Expect(fakeBlobManager.CreateCallCount()).To(Equal(0))
-				Expect(fakeHTTPBlobProvider.UploadCallCount()).To(Equal(1))
+				Expect(fakeHTTPBlobProvider.UploadCallCount()).To(Equal(3))
 
 				signedURLArg, filepathArg, _ := fakeHTTPBlobProvider.UploadArgsForCall(0)
 				Expect(signedURLArg).To(Equal("some-signed-url"))
@@ -182,6 +190,211 @@ var _ = Describe("BlobstoreDelegator", func() {
 		})
 	})
 
+	Context("WriteMultipart", func() {
+		var upload httpblobprovider.MultipartUpload
+
+		BeforeEach(func() {
+			upload = httpblobprovider.MultipartUpload{
+				PartSignedURLs:    []string{"part-1-url", "part-2-url"},
+				PartSize:          5 * 1024 * 1024,
+				CompleteSignedURL: "complete-url",
+			}
+		})
+
+		It("uploads the parts through the HTTP blobstore", func() {
+			fakeHTTPBlobProvider.UploadMultipartReturns(digest, nil)
+
+			digestResult, err := blobstoreDelegator.WriteMultipart(upload, "/some/path/to/a/file", map[string]string{"key": "value"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digestResult).To(Equal(digest))
+
+			Expect(fakeHTTPBlobProvider.UploadMultipartCallCount()).To(Equal(1))
+			uploadArg, filepathArg, headersArg := fakeHTTPBlobProvider.UploadMultipartArgsForCall(0)
+			Expect(uploadArg).To(Equal(upload))
+			Expect(filepathArg).To(Equal("/some/path/to/a/file"))
+			Expect(headersArg).To(Equal(map[string]string{"key": "value"}))
+		})
+
+		It("errors when there is an error with retries", func() {
+			fakeError := errors.New("some error")
+			fakeHTTPBlobProvider.UploadMultipartReturns(boshcrypto.MultipleDigest{}, fakeError)
+
+			_, err := blobstoreDelegator.WriteMultipart(upload, "/some/path/to/a/file", nil)
+			Expect(err).To(MatchError(fakeError))
+			Expect(fakeHTTPBlobProvider.UploadMultipartCallCount()).To(Equal(3))
+		})
+	})
+
+	Context("WriteStream", func() {
+		var opened int
+
+		open := func() (io.ReadCloser, error) {
+			opened++
+			return io.NopCloser(strings.NewReader("fake-stream")), nil
+		}
+
+		BeforeEach(func() {
+			opened = 0
+		})
+
+		It("uploads the stream through the HTTP blobstore", func() {
+			fakeHTTPBlobProvider.UploadStreamStub = func(_ string, body io.Reader, _ map[string]string) (boshcrypto.MultipleDigest, error) {
+				contents, err := io.ReadAll(body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal("fake-stream"))
+				return digest, nil
+			}
+
+			digestResult, err := blobstoreDelegator.WriteStream("some-signed-url", open, map[string]string{"key": "value"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digestResult).To(Equal(digest))
+
+			signedURLArg, _, headersArg := fakeHTTPBlobProvider.UploadStreamArgsForCall(0)
+			Expect(signedURLArg).To(Equal("some-signed-url"))
+			Expect(headersArg).To(Equal(map[string]string{"key": "value"}))
+		})
+
+		It("opens the stream again for every attempt", func() {
+			fakeHTTPBlobProvider.UploadStreamReturnsOnCall(0, boshcrypto.MultipleDigest{}, errors.New("some error"))
+			fakeHTTPBlobProvider.UploadStreamReturnsOnCall(1, digest, nil)
+
+			_, err := blobstoreDelegator.WriteStream("some-signed-url", open, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opened).To(Equal(2))
+		})
+
+		It("does not retry when the endpoint rejects streaming", func() {
+			rejected := httpblobprovider.StatusError{StatusCode: http.StatusLengthRequired}
+			fakeHTTPBlobProvider.UploadStreamReturns(boshcrypto.MultipleDigest{}, rejected)
+
+			_, err := blobstoreDelegator.WriteStream("some-signed-url", open, nil)
+			Expect(httpblobprovider.IsStreamingRejected(err)).To(BeTrue())
+			Expect(fakeHTTPBlobProvider.UploadStreamCallCount()).To(Equal(1))
+		})
+
+		It("errors without a signed URL", func() {
+			_, err := blobstoreDelegator.WriteStream("", open, nil)
+			Expect(err).To(MatchError("Streaming a blob needs a signed URL"))
+			Expect(opened).To(BeZero())
+			Expect(fakeBlobManager.CreateCallCount()).To(BeZero())
+		})
+	})
+
+	Context("with a retry policy", func() {
+		It("retries up to the configured number of attempts", func() {
+			retryPolicy.Attempts = 5
+			blobstoreDelegator = blobstore_delegator.NewBlobstoreDelegator(fakeHTTPBlobProvider, fakeBlobManager, retryPolicy, logger)
+			fakeHTTPBlobProvider.GetReturns("", errors.New("some error"))
+
+			_, err := blobstoreDelegator.Get(digest, "some-signed-url", "", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(fakeHTTPBlobProvider.GetCallCount()).To(Equal(5))
+		})
+
+		It("backs off exponentially between attempts", func() {
+			retryPolicy.InitialBackoff = 20 * time.Millisecond
+			retryPolicy.Multiplier = 2
+			blobstoreDelegator = blobstore_delegator.NewBlobstoreDelegator(fakeHTTPBlobProvider, fakeBlobManager, retryPolicy, logger)
+			fakeHTTPBlobProvider.UploadReturns(digest, errors.New("some error"))
+
+			start := time.Now()
+			_, _, err := blobstoreDelegator.Write("some-signed-url", "/some/path/to/a/file", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically(">=", 60*time.Millisecond))
+			Expect(fakeHTTPBlobProvider.UploadCallCount()).To(Equal(3))
+		})
+
+		It("counts retries in the transfer metrics", func() {
+			metrics := httpblobprovider.NewTransferMetrics(0, logger)
+			blobstoreDelegator = blobstore_delegator.NewRefreshingBlobstoreDelegator(fakeHTTPBlobProvider, fakeBlobManager, nil, retryPolicy, metrics, logger)
+			fakeHTTPBlobProvider.GetReturns("", errors.New("some error"))
+			fakeHTTPBlobProvider.UploadReturnsOnCall(0, digest, errors.New("some error"))
+
+			_, err := blobstoreDelegator.Get(digest, "some-signed-url", "", nil)
+			Expect(err).To(HaveOccurred())
+
+			_, _, err = blobstoreDelegator.Write("some-signed-url", "/some/path/to/a/file", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(metrics.Snapshot().Download.Retries).To(Equal(int64(2)))
+			Expect(metrics.Snapshot().Upload.Retries).To(Equal(int64(1)))
+		})
+
+		It("stops retrying when the next backoff would exceed the maximum elapsed time", func() {
+			retryPolicy.InitialBackoff = 20 * time.Millisecond
+			retryPolicy.MaxElapsedTime = 30 * time.Millisecond
+			blobstoreDelegator = blobstore_delegator.NewBlobstoreDelegator(fakeHTTPBlobProvider, fakeBlobManager, retryPolicy, logger)
+			fakeHTTPBlobProvider.GetReturns("", errors.New("some error"))
+
+			_, err := blobstoreDelegator.Get(digest, "some-signed-url", "", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(fakeHTTPBlobProvider.GetCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when a signed URL expires", func() {
+		var (
+			refresher *fakeblobdelegator.FakeSignedURLRefresher
+			expired   error
+		)
+
+		BeforeEach(func() {
+			refresher = &fakeblobdelegator.FakeSignedURLRefresher{}
+			refresher.RefreshReturns("fresh-signed-url", map[string]string{"fresh": "header"}, nil)
+			expired = httpblobprovider.StatusError{StatusCode: http.StatusForbidden}
+
+			blobstoreDelegator = blobstore_delegator.NewRefreshingBlobstoreDelegator(fakeHTTPBlobProvider, fakeBlobManager, refresher, retryPolicy, nil, logger)
+		})
+
+		It("downloads from a refreshed signed URL", func() {
+			fakeHTTPBlobProvider.GetReturnsOnCall(0, "", expired)
+			fakeHTTPBlobProvider.GetReturnsOnCall(1, "/some/path/to/a/file", nil)
+
+			fileName, err := blobstoreDelegator.Get(digest, "expired-signed-url", "", map[string]string{"key": "value"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileName).To(Equal("/some/path/to/a/file"))
+
+			Expect(refresher.RefreshCallCount()).To(Equal(1))
+			signedURL, headers := refresher.RefreshArgsForCall(0)
+			Expect(signedURL).To(Equal("expired-signed-url"))
+			Expect(headers).To(Equal(map[string]string{"key": "value"}))
+
+			signedURL, _, headers = fakeHTTPBlobProvider.GetArgsForCall(1)
+			Expect(signedURL).To(Equal("fresh-signed-url"))
+			Expect(headers).To(Equal(map[string]string{"fresh": "header"}))
+		})
+
+		It("uploads to a refreshed signed URL", func() {
+			fakeHTTPBlobProvider.UploadReturnsOnCall(0, boshcrypto.MultipleDigest{}, expired)
+			fakeHTTPBlobProvider.UploadReturnsOnCall(1, digest, nil)
+
+			_, digestResult, err := blobstoreDelegator.Write("expired-signed-url", "/some/path/to/a/file", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digestResult).To(Equal(digest))
+
+			signedURL, _, headers := fakeHTTPBlobProvider.UploadArgsForCall(1)
+			Expect(signedURL).To(Equal("fresh-signed-url"))
+			Expect(headers).To(Equal(map[string]string{"fresh": "header"}))
+		})
+
+		It("fails without retrying when refreshing fails", func() {
+			fakeHTTPBlobProvider.GetReturns("", expired)
+			refresher.RefreshReturns("", nil, errors.New("fake-refresh-err"))
+
+			_, err := blobstoreDelegator.Get(digest, "expired-signed-url", "", nil)
+			Expect(err).To(MatchError(ContainSubstring("fake-refresh-err")))
+			Expect(fakeHTTPBlobProvider.GetCallCount()).To(Equal(1))
+		})
+
+		It("does not refresh for other errors", func() {
+			fakeHTTPBlobProvider.UploadReturns(digest, errors.New("some error"))
+
+			_, _, err := blobstoreDelegator.Write("some-signed-url", "/some/path/to/a/file", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(refresher.RefreshCallCount()).To(Equal(0))
+		})
+	})
+
 	Context("CleanUp", func() {
 		Context("when there is a signed URL provided", func() {
 			It("errors", func() {
@@ -204,16 +417,31 @@ var _ = Describe("BlobstoreDelegator", func() {
 
 	Context("Delete", func() {
 		Context("when there is a signed URL provided", func() {
-			It("errors", func() {
-				err := blobstoreDelegator.Delete("some-signed-url", "nothing")
-				Expect(err).To(MatchError("Delete is not supported for signed URLs"))
+			It("deletes through the signed URL", func() {
+				headers := map[string]string{"key": "value"}
+
+				err := blobstoreDelegator.Delete("some-signed-url", "nothing", headers)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeHTTPBlobProvider.DeleteCallCount()).To(Equal(1))
+				signedURL, headersArg := fakeHTTPBlobProvider.DeleteArgsForCall(0)
+				Expect(signedURL).To(Equal("some-signed-url"))
+				Expect(headersArg).To(Equal(headers))
+				Expect(fakeBlobManager.DeleteCallCount()).To(Equal(0))
+			})
+
+			It("returns the error of the signed URL", func() {
+				fakeHTTPBlobProvider.DeleteReturns(errors.New("fake-delete-err"))
+
+				err := blobstoreDelegator.Delete("some-signed-url", "nothing", nil)
+				Expect(err).To(MatchError("fake-delete-err"))
 			})
 		})
 
 		Context("when there is no signed URL provided", func() {
 			It("Deletes", func() {
 				blobID := "123"
-				err := blobstoreDelegator.Delete("", blobID)
+				err := blobstoreDelegator.Delete("", blobID, nil)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeBlobManager.DeleteCallCount()).To(Equal(1))
@@ -4,6 +4,7 @@ package blobstore_delegatorfakes
 import (
 	"sync"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
 	"github.com/cloudfoundry/bosh-utils/crypto"
 )
@@ -21,11 +22,12 @@ type FakeBlobstoreDelegator struct {
 	cleanUpReturnsOnCall map[int]struct {
 		result1 error
 	}
-	DeleteStub        func(string, string) error
+	DeleteStub        func(string, string, map[string]string) error
 	deleteMutex       sync.RWMutex
 	deleteArgsForCall []struct {
 		arg1 string
 		arg2 string
+		arg3 map[string]string
 	}
 	deleteReturns struct {
 		result1 error
@@ -66,6 +68,36 @@ type FakeBlobstoreDelegator struct {
 		result2 crypto.MultipleDigest
 		result3 error
 	}
+	WriteMultipartStub        func(httpblobprovider.MultipartUpload, string, map[string]string) (crypto.MultipleDigest, error)
+	writeMultipartMutex       sync.RWMutex
+	writeMultipartArgsForCall []struct {
+		arg1 httpblobprovider.MultipartUpload
+		arg2 string
+		arg3 map[string]string
+	}
+	writeMultipartReturns struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
+	writeMultipartReturnsOnCall map[int]struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
+	WriteStreamStub        func(string, httpblobprovider.OpenStream, map[string]string) (crypto.MultipleDigest, error)
+	writeStreamMutex       sync.RWMutex
+	writeStreamArgsForCall []struct {
+		arg1 string
+		arg2 httpblobprovider.OpenStream
+		arg3 map[string]string
+	}
+	writeStreamReturns struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
+	writeStreamReturnsOnCall map[int]struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -132,19 +164,20 @@ func (fake *FakeBlobstoreDelegator) CleanUpReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeBlobstoreDelegator) Delete(arg1 string, arg2 string) error {
+func (fake *FakeBlobstoreDelegator) Delete(arg1 string, arg2 string, arg3 map[string]string) error {
 	fake.deleteMutex.Lock()
 	ret, specificReturn := fake.deleteReturnsOnCall[len(fake.deleteArgsForCall)]
 	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
 		arg1 string
 		arg2 string
-	}{arg1, arg2})
+		arg3 map[string]string
+	}{arg1, arg2, arg3})
 	stub := fake.DeleteStub
 	fakeReturns := fake.deleteReturns
-	fake.recordInvocation("Delete", []interface{}{arg1, arg2})
+	fake.recordInvocation("Delete", []interface{}{arg1, arg2, arg3})
 	fake.deleteMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -158,17 +191,17 @@ func (fake *FakeBlobstoreDelegator) DeleteCallCount() int {
 	return len(fake.deleteArgsForCall)
 }
 
-func (fake *FakeBlobstoreDelegator) DeleteCalls(stub func(string, string) error) {
+func (fake *FakeBlobstoreDelegator) DeleteCalls(stub func(string, string, map[string]string) error) {
 	fake.deleteMutex.Lock()
 	defer fake.deleteMutex.Unlock()
 	fake.DeleteStub = stub
 }
 
-func (fake *FakeBlobstoreDelegator) DeleteArgsForCall(i int) (string, string) {
+func (fake *FakeBlobstoreDelegator) DeleteArgsForCall(i int) (string, string, map[string]string) {
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
 	argsForCall := fake.deleteArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeBlobstoreDelegator) DeleteReturns(result1 error) {
@@ -330,17 +363,141 @@ func (fake *FakeBlobstoreDelegator) WriteReturnsOnCall(i int, result1 string, re
 	}{result1, result2, result3}
 }
 
+func (fake *FakeBlobstoreDelegator) WriteMultipart(arg1 httpblobprovider.MultipartUpload, arg2 string, arg3 map[string]string) (crypto.MultipleDigest, error) {
+	fake.writeMultipartMutex.Lock()
+	ret, specificReturn := fake.writeMultipartReturnsOnCall[len(fake.writeMultipartArgsForCall)]
+	fake.writeMultipartArgsForCall = append(fake.writeMultipartArgsForCall, struct {
+		arg1 httpblobprovider.MultipartUpload
+		arg2 string
+		arg3 map[string]string
+	}{arg1, arg2, arg3})
+	stub := fake.WriteMultipartStub
+	fakeReturns := fake.writeMultipartReturns
+	fake.recordInvocation("WriteMultipart", []interface{}{arg1, arg2, arg3})
+	fake.writeMultipartMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBlobstoreDelegator) WriteMultipartCallCount() int {
+	fake.writeMultipartMutex.RLock()
+	defer fake.writeMultipartMutex.RUnlock()
+	return len(fake.writeMultipartArgsForCall)
+}
+
+func (fake *FakeBlobstoreDelegator) WriteMultipartCalls(stub func(httpblobprovider.MultipartUpload, string, map[string]string) (crypto.MultipleDigest, error)) {
+	fake.writeMultipartMutex.Lock()
+	defer fake.writeMultipartMutex.Unlock()
+	fake.WriteMultipartStub = stub
+}
+
+func (fake *FakeBlobstoreDelegator) WriteMultipartArgsForCall(i int) (httpblobprovider.MultipartUpload, string, map[string]string) {
+	fake.writeMultipartMutex.RLock()
+	defer fake.writeMultipartMutex.RUnlock()
+	argsForCall := fake.writeMultipartArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeBlobstoreDelegator) WriteMultipartReturns(result1 crypto.MultipleDigest, result2 error) {
+	fake.writeMultipartMutex.Lock()
+	defer fake.writeMultipartMutex.Unlock()
+	fake.WriteMultipartStub = nil
+	fake.writeMultipartReturns = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBlobstoreDelegator) WriteMultipartReturnsOnCall(i int, result1 crypto.MultipleDigest, result2 error) {
+	fake.writeMultipartMutex.Lock()
+	defer fake.writeMultipartMutex.Unlock()
+	fake.WriteMultipartStub = nil
+	if fake.writeMultipartReturnsOnCall == nil {
+		fake.writeMultipartReturnsOnCall = make(map[int]struct {
+			result1 crypto.MultipleDigest
+			result2 error
+		})
+	}
+	fake.writeMultipartReturnsOnCall[i] = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStream(arg1 string, arg2 httpblobprovider.OpenStream, arg3 map[string]string) (crypto.MultipleDigest, error) {
+	fake.writeStreamMutex.Lock()
+	ret, specificReturn := fake.writeStreamReturnsOnCall[len(fake.writeStreamArgsForCall)]
+	fake.writeStreamArgsForCall = append(fake.writeStreamArgsForCall, struct {
+		arg1 string
+		arg2 httpblobprovider.OpenStream
+		arg3 map[string]string
+	}{arg1, arg2, arg3})
+	stub := fake.WriteStreamStub
+	fakeReturns := fake.writeStreamReturns
+	fake.recordInvocation("WriteStream", []interface{}{arg1, arg2, arg3})
+	fake.writeStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStreamCallCount() int {
+	fake.writeStreamMutex.RLock()
+	defer fake.writeStreamMutex.RUnlock()
+	return len(fake.writeStreamArgsForCall)
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStreamCalls(stub func(string, httpblobprovider.OpenStream, map[string]string) (crypto.MultipleDigest, error)) {
+	fake.writeStreamMutex.Lock()
+	defer fake.writeStreamMutex.Unlock()
+	fake.WriteStreamStub = stub
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStreamArgsForCall(i int) (string, httpblobprovider.OpenStream, map[string]string) {
+	fake.writeStreamMutex.RLock()
+	defer fake.writeStreamMutex.RUnlock()
+	argsForCall := fake.writeStreamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStreamReturns(result1 crypto.MultipleDigest, result2 error) {
+	fake.writeStreamMutex.Lock()
+	defer fake.writeStreamMutex.Unlock()
+	fake.WriteStreamStub = nil
+	fake.writeStreamReturns = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStreamReturnsOnCall(i int, result1 crypto.MultipleDigest, result2 error) {
+	fake.writeStreamMutex.Lock()
+	defer fake.writeStreamMutex.Unlock()
+	fake.WriteStreamStub = nil
+	if fake.writeStreamReturnsOnCall == nil {
+		fake.writeStreamReturnsOnCall = make(map[int]struct {
+			result1 crypto.MultipleDigest
+			result2 error
+		})
+	}
+	fake.writeStreamReturnsOnCall[i] = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBlobstoreDelegator) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.cleanUpMutex.RLock()
-	defer fake.cleanUpMutex.RUnlock()
-	fake.deleteMutex.RLock()
-	defer fake.deleteMutex.RUnlock()
-	fake.getMutex.RLock()
-	defer fake.getMutex.RUnlock()
-	fake.writeMutex.RLock()
-	defer fake.writeMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
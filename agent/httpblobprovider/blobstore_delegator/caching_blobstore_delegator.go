@@ -0,0 +1,62 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+// CachingBlobstoreDelegator serves blobs from a local BlobCache when
+// possible and populates it with the blobs downloaded by the delegate.
+type CachingBlobstoreDelegator struct {
+	delegate BlobstoreDelegator
+	cache    *BlobCache
+	logger   boshlog.Logger
+}
+
+func NewCachingBlobstoreDelegator(delegate BlobstoreDelegator, cache *BlobCache, logger boshlog.Logger) *CachingBlobstoreDelegator {
+	return &CachingBlobstoreDelegator{
+		delegate: delegate,
+		cache:    cache,
+		logger:   logger,
+	}
+}
+
+func (b *CachingBlobstoreDelegator) Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (string, error) {
+	if fileName, found := b.cache.Get(digest); found {
+		return fileName, nil
+	}
+
+	fileName, err := b.delegate.Get(digest, signedURL, blobID, headers)
+	if err != nil {
+		return fileName, err
+	}
+
+	err = b.cache.Put(digest, fileName)
+	if err != nil {
+		b.logger.Warn(blobCacheLogTag, "Caching blob: %s", err.Error())
+	}
+
+	return fileName, nil
+}
+
+func (b *CachingBlobstoreDelegator) Write(signedURL, path string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+	return b.delegate.Write(signedURL, path, headers)
+}
+
+func (b *CachingBlobstoreDelegator) WriteMultipart(upload httpblobprovider.MultipartUpload, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	return b.delegate.WriteMultipart(upload, path, headers)
+}
+
+func (b *CachingBlobstoreDelegator) WriteStream(signedURL string, open httpblobprovider.OpenStream, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	return b.delegate.WriteStream(signedURL, open, headers)
+}
+
+func (b *CachingBlobstoreDelegator) CleanUp(signedURL, path string) error {
+	return b.delegate.CleanUp(signedURL, path)
+}
+
+func (b *CachingBlobstoreDelegator) Delete(signedURL, blobID string, headers map[string]string) error {
+	return b.delegate.Delete(signedURL, blobID, headers)
+}
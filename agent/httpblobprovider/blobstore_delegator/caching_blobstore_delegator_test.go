@@ -0,0 +1,81 @@
+package blobstore_delegator_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+)
+
+var _ = Describe("CachingBlobstoreDelegator", func() {
+	var (
+		tmpDir       string
+		downloaded   string
+		digest       boshcrypto.MultipleDigest
+		fakeDelegate *blobstore_delegatorfakes.FakeBlobstoreDelegator
+		delegator    blobstore_delegator.BlobstoreDelegator
+	)
+
+	BeforeEach(func() {
+		tmpDir = GinkgoT().TempDir()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := boshsys.NewOsFileSystem(logger)
+		Expect(fs.ChangeTempRoot(tmpDir)).To(Succeed())
+
+		downloaded = filepath.Join(tmpDir, "downloaded")
+		Expect(os.WriteFile(downloaded, []byte("blob"), 0600)).To(Succeed())
+
+		var err error
+		digest, err = boshcrypto.NewMultipleDigest(strings.NewReader("blob"), []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1})
+		Expect(err).ToNot(HaveOccurred())
+
+		fakeDelegate = &blobstore_delegatorfakes.FakeBlobstoreDelegator{}
+		cache := blobstore_delegator.NewBlobCache(fs, filepath.Join(tmpDir, "blob_cache"), 1024, logger)
+		delegator = blobstore_delegator.NewCachingBlobstoreDelegator(fakeDelegate, cache, logger)
+	})
+
+	It("downloads blobs only once", func() {
+		fakeDelegate.GetReturns(downloaded, nil)
+
+		fileName, err := delegator.Get(digest, "some-signed-url", "", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileName).To(Equal(downloaded))
+
+		fileName, err = delegator.Get(digest, "some-signed-url", "", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.ReadFile(fileName)).To(Equal([]byte("blob")))
+
+		Expect(fakeDelegate.GetCallCount()).To(Equal(1))
+	})
+
+	It("returns download errors without caching", func() {
+		fakeDelegate.GetReturns("", errors.New("fake-get-error"))
+
+		_, err := delegator.Get(digest, "", "some-blob-id", nil)
+		Expect(err).To(MatchError("fake-get-error"))
+
+		_, _ = delegator.Get(digest, "", "some-blob-id", nil) //nolint:errcheck
+		Expect(fakeDelegate.GetCallCount()).To(Equal(2))
+	})
+
+	It("delegates other operations", func() {
+		Expect(delegator.CleanUp("", "some-path")).To(Succeed())
+		Expect(fakeDelegate.CleanUpCallCount()).To(Equal(1))
+
+		Expect(delegator.Delete("", "some-blob-id", nil)).To(Succeed())
+		Expect(fakeDelegate.DeleteCallCount()).To(Equal(1))
+
+		_, _, err := delegator.Write("", "some-path", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeDelegate.WriteCallCount()).To(Equal(1))
+	})
+})
@@ -0,0 +1,89 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings blobs can be compressed with before they are written.
+// zstd compresses text-heavy content such as logs noticeably better than
+// gzip does.
+const (
+	ContentEncodingGzip = "gzip"
+	ContentEncodingZstd = "zstd"
+)
+
+// WriteEncoded compresses the file at path with the given content encoding
+// and writes the compressed file to the blobstore. The returned digest is
+// the digest of the compressed content, which is what the blobstore holds.
+// An empty encoding writes the file as is.
+func WriteEncoded(
+	blobstore BlobstoreDelegator,
+	fs boshsys.FileSystem,
+	signedURL, path string,
+	headers map[string]string,
+	encoding string,
+) (string, boshcrypto.MultipleDigest, error) {
+	if encoding == "" {
+		return blobstore.Write(signedURL, path, headers)
+	}
+
+	encodedPath, err := encodeFile(fs, path, encoding)
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, err
+	}
+
+	defer fs.RemoveAll(encodedPath) //nolint:errcheck
+
+	return blobstore.Write(signedURL, encodedPath, headers)
+}
+
+func encodeFile(fs boshsys.FileSystem, path, encoding string) (string, error) {
+	if encoding != ContentEncodingGzip && encoding != ContentEncodingZstd {
+		return "", bosherr.Errorf("Unsupported content encoding '%s'", encoding)
+	}
+
+	src, err := fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening blob to encode")
+	}
+	defer src.Close() //nolint:errcheck
+
+	dst, err := fs.TempFile("bosh-agent-encoded-blob")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating encoded blob")
+	}
+
+	encoder, err := newEncoder(dst, encoding)
+	if err == nil {
+		_, err = io.Copy(encoder, src)
+		if closeErr := encoder.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = fs.RemoveAll(dst.Name()) //nolint:errcheck
+		return "", bosherr.WrapErrorf(err, "Encoding blob with %s", encoding)
+	}
+
+	return dst.Name(), nil
+}
+
+func newEncoder(w io.Writer, encoding string) (io.WriteCloser, error) {
+	if encoding == ContentEncodingZstd {
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	}
+
+	return gzip.NewWriter(w), nil
+}
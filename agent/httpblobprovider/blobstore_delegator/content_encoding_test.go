@@ -0,0 +1,115 @@
+package blobstore_delegator_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	"github.com/klauspost/compress/zstd"
+)
+
+var _ = Describe("WriteEncoded", func() {
+	var (
+		fs        boshsys.FileSystem
+		blobstore *fakeblobdelegator.FakeBlobstoreDelegator
+		digest    boshcrypto.MultipleDigest
+		logsPath  string
+	)
+
+	BeforeEach(func() {
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
+		digest = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"))
+
+		logsPath = filepath.Join(GinkgoT().TempDir(), "logs.tar")
+		Expect(os.WriteFile(logsPath, []byte("some log lines"), 0600)).To(Succeed())
+	})
+
+	It("writes the file as is without an encoding", func() {
+		blobstore.WriteReturns("blob-id", digest, nil)
+
+		blobID, digestResult, err := blobstore_delegator.WriteEncoded(blobstore, fs, "signed-url", logsPath, map[string]string{"key": "value"}, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("blob-id"))
+		Expect(digestResult).To(Equal(digest))
+
+		signedURL, path, headers := blobstore.WriteArgsForCall(0)
+		Expect(signedURL).To(Equal("signed-url"))
+		Expect(path).To(Equal(logsPath))
+		Expect(headers).To(Equal(map[string]string{"key": "value"}))
+	})
+
+	It("writes the gzipped file and removes it afterwards", func() {
+		var written []byte
+		blobstore.WriteStub = func(_, path string, _ map[string]string) (string, boshcrypto.MultipleDigest, error) {
+			var err error
+			written, err = fs.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			return "", digest, nil
+		}
+
+		_, digestResult, err := blobstore_delegator.WriteEncoded(blobstore, fs, "signed-url", logsPath, nil, blobstore_delegator.ContentEncodingGzip)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(digestResult).To(Equal(digest))
+
+		_, path, _ := blobstore.WriteArgsForCall(0)
+		Expect(path).ToNot(Equal(logsPath))
+		Expect(fs.FileExists(path)).To(BeFalse())
+
+		reader, err := gzip.NewReader(bytes.NewReader(written))
+		Expect(err).ToNot(HaveOccurred())
+		decoded, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(decoded)).To(Equal("some log lines"))
+	})
+
+	It("writes the file compressed with zstd", func() {
+		var written []byte
+		blobstore.WriteStub = func(_, path string, _ map[string]string) (string, boshcrypto.MultipleDigest, error) {
+			var err error
+			written, err = fs.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			return "", digest, nil
+		}
+
+		_, _, err := blobstore_delegator.WriteEncoded(blobstore, fs, "signed-url", logsPath, nil, blobstore_delegator.ContentEncodingZstd)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, path, _ := blobstore.WriteArgsForCall(0)
+		Expect(fs.FileExists(path)).To(BeFalse())
+
+		reader, err := zstd.NewReader(bytes.NewReader(written))
+		Expect(err).ToNot(HaveOccurred())
+		defer reader.Close()
+		decoded, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(decoded)).To(Equal("some log lines"))
+	})
+
+	It("returns the error from writing the encoded file", func() {
+		blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-write-err"))
+
+		_, _, err := blobstore_delegator.WriteEncoded(blobstore, fs, "", logsPath, nil, blobstore_delegator.ContentEncodingGzip)
+		Expect(err).To(MatchError("fake-write-err"))
+
+		_, path, _ := blobstore.WriteArgsForCall(0)
+		Expect(fs.FileExists(path)).To(BeFalse())
+	})
+
+	It("returns an error for an unsupported encoding", func() {
+		_, _, err := blobstore_delegator.WriteEncoded(blobstore, fs, "", logsPath, nil, "br")
+		Expect(err).To(MatchError("Unsupported content encoding 'br'"))
+		Expect(blobstore.WriteCallCount()).To(BeZero())
+	})
+})
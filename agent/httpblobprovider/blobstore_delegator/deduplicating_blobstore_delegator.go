@@ -0,0 +1,132 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	"sync"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+const deduplicatingLogTag = "DeduplicatingBlobstoreDelegator"
+
+// DeduplicatingBlobstoreDelegator shares a single download between callers
+// that ask for the same blob digest at the same time. Every caller still
+// gets its own copy of the blob since callers clean up the files they get.
+type DeduplicatingBlobstoreDelegator struct {
+	delegate BlobstoreDelegator
+	fs       boshsys.FileSystem
+	logger   boshlog.Logger
+
+	lock     sync.Mutex
+	inflight map[string]*inflightDownload
+}
+
+type inflightDownload struct {
+	done    chan struct{}
+	waiters int
+	results []inflightResult
+}
+
+type inflightResult struct {
+	fileName string
+	err      error
+}
+
+func NewDeduplicatingBlobstoreDelegator(delegate BlobstoreDelegator, fs boshsys.FileSystem, logger boshlog.Logger) *DeduplicatingBlobstoreDelegator {
+	return &DeduplicatingBlobstoreDelegator{
+		delegate: delegate,
+		fs:       fs,
+		logger:   logger,
+		inflight: map[string]*inflightDownload{},
+	}
+}
+
+func (b *DeduplicatingBlobstoreDelegator) Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (string, error) {
+	key, ok := blobCacheKey(digest)
+	if !ok {
+		return b.delegate.Get(digest, signedURL, blobID, headers)
+	}
+
+	b.lock.Lock()
+
+	if download, found := b.inflight[key]; found {
+		waiter := download.waiters
+		download.waiters++
+		b.lock.Unlock()
+
+		b.logger.Debug(deduplicatingLogTag, "Waiting for in-flight download of blob %s", key)
+
+		<-download.done
+
+		result := download.results[waiter]
+		return result.fileName, result.err
+	}
+
+	download := &inflightDownload{done: make(chan struct{})}
+	b.inflight[key] = download
+	b.lock.Unlock()
+
+	fileName, err := b.delegate.Get(digest, signedURL, blobID, headers)
+
+	// No more waiters can join once the download is no longer in flight
+	b.lock.Lock()
+	delete(b.inflight, key)
+	b.lock.Unlock()
+
+	download.results = make([]inflightResult, download.waiters)
+
+	for i := range download.results {
+		if err != nil {
+			download.results[i] = inflightResult{err: err}
+			continue
+		}
+
+		download.results[i] = b.copyBlob(fileName)
+	}
+
+	close(download.done)
+
+	return fileName, err
+}
+
+func (b *DeduplicatingBlobstoreDelegator) copyBlob(fileName string) inflightResult {
+	file, err := b.fs.TempFile("bosh-blobstore-delegator-GET")
+	if err != nil {
+		return inflightResult{err: bosherr.WrapError(err, "Creating temporary file for shared blob")}
+	}
+
+	copyName := file.Name()
+	_ = file.Close()
+
+	err = b.fs.CopyFile(fileName, copyName)
+	if err != nil {
+		_ = b.fs.RemoveAll(copyName)
+		return inflightResult{err: bosherr.WrapError(err, "Copying shared blob")}
+	}
+
+	return inflightResult{fileName: copyName}
+}
+
+func (b *DeduplicatingBlobstoreDelegator) Write(signedURL, path string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+	return b.delegate.Write(signedURL, path, headers)
+}
+
+func (b *DeduplicatingBlobstoreDelegator) WriteMultipart(upload httpblobprovider.MultipartUpload, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	return b.delegate.WriteMultipart(upload, path, headers)
+}
+
+func (b *DeduplicatingBlobstoreDelegator) WriteStream(signedURL string, open httpblobprovider.OpenStream, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	return b.delegate.WriteStream(signedURL, open, headers)
+}
+
+func (b *DeduplicatingBlobstoreDelegator) CleanUp(signedURL, path string) error {
+	return b.delegate.CleanUp(signedURL, path)
+}
+
+func (b *DeduplicatingBlobstoreDelegator) Delete(signedURL, blobID string, headers map[string]string) error {
+	return b.delegate.Delete(signedURL, blobID, headers)
+}
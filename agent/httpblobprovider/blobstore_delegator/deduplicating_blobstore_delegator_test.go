@@ -0,0 +1,128 @@
+package blobstore_delegator_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+)
+
+var _ = Describe("DeduplicatingBlobstoreDelegator", func() {
+	var (
+		downloaded   string
+		digest       boshcrypto.MultipleDigest
+		fakeDelegate *blobstore_delegatorfakes.FakeBlobstoreDelegator
+		logger       *loggerfakes.FakeLogger
+		release      chan struct{}
+		delegator    blobstore_delegator.BlobstoreDelegator
+	)
+
+	type getResult struct {
+		fileName string
+		err      error
+	}
+
+	get := func(results chan<- getResult) {
+		defer GinkgoRecover()
+		fileName, err := delegator.Get(digest, "some-signed-url", "", nil)
+		results <- getResult{fileName: fileName, err: err}
+	}
+
+	BeforeEach(func() {
+		tmpDir := GinkgoT().TempDir()
+		fs := boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		Expect(fs.ChangeTempRoot(tmpDir)).To(Succeed())
+
+		downloaded = filepath.Join(tmpDir, "downloaded")
+		Expect(os.WriteFile(downloaded, []byte("blob"), 0600)).To(Succeed())
+
+		var err error
+		digest, err = boshcrypto.NewMultipleDigest(strings.NewReader("blob"), []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1})
+		Expect(err).ToNot(HaveOccurred())
+
+		release = make(chan struct{})
+		fakeDelegate = &blobstore_delegatorfakes.FakeBlobstoreDelegator{}
+		fakeDelegate.GetStub = func(boshcrypto.Digest, string, string, map[string]string) (string, error) {
+			<-release
+			return downloaded, nil
+		}
+
+		logger = &loggerfakes.FakeLogger{}
+		delegator = blobstore_delegator.NewDeduplicatingBlobstoreDelegator(fakeDelegate, fs, logger)
+	})
+
+	It("shares one download between concurrent callers and gives each its own copy", func() {
+		results := make(chan getResult, 2)
+
+		go get(results)
+		Eventually(fakeDelegate.GetCallCount).Should(Equal(1))
+
+		go get(results)
+		Eventually(logger.DebugCallCount).Should(Equal(1))
+
+		close(release)
+
+		first := <-results
+		second := <-results
+		Expect(first.err).ToNot(HaveOccurred())
+		Expect(second.err).ToNot(HaveOccurred())
+
+		Expect([]string{first.fileName, second.fileName}).To(ContainElement(downloaded))
+		Expect(first.fileName).ToNot(Equal(second.fileName))
+		Expect(os.ReadFile(first.fileName)).To(Equal([]byte("blob")))
+		Expect(os.ReadFile(second.fileName)).To(Equal([]byte("blob")))
+
+		Expect(fakeDelegate.GetCallCount()).To(Equal(1))
+	})
+
+	It("shares download errors with the waiting callers", func() {
+		fakeDelegate.GetStub = func(boshcrypto.Digest, string, string, map[string]string) (string, error) {
+			<-release
+			return "", errors.New("fake-get-err")
+		}
+
+		results := make(chan getResult, 2)
+
+		go get(results)
+		Eventually(fakeDelegate.GetCallCount).Should(Equal(1))
+
+		go get(results)
+		Eventually(logger.DebugCallCount).Should(Equal(1))
+
+		close(release)
+
+		Expect((<-results).err).To(MatchError("fake-get-err"))
+		Expect((<-results).err).To(MatchError("fake-get-err"))
+		Expect(fakeDelegate.GetCallCount()).To(Equal(1))
+	})
+
+	It("downloads again once the previous download finished", func() {
+		close(release)
+
+		_, err := delegator.Get(digest, "some-signed-url", "", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = delegator.Get(digest, "some-signed-url", "", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeDelegate.GetCallCount()).To(Equal(2))
+	})
+
+	It("does not share downloads of blobs without a digest", func() {
+		close(release)
+
+		_, err := delegator.Get(nil, "", "some-blob-id", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeDelegate.GetCallCount()).To(Equal(1))
+	})
+})
@@ -0,0 +1,134 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+// Digest algorithms from weakest to strongest
+var digestAlgorithmStrength = []boshcrypto.Algorithm{
+	boshcrypto.DigestAlgorithmSHA1,
+	boshcrypto.DigestAlgorithmSHA256,
+	boshcrypto.DigestAlgorithmSHA512,
+}
+
+// DigestPolicyBlobstoreDelegator refuses to download blobs that are not
+// verified with at least the minimum digest algorithm, and fails uploads of
+// blobs the blobstore did not digest with it.
+type DigestPolicyBlobstoreDelegator struct {
+	delegate BlobstoreDelegator
+	minimum  boshcrypto.Algorithm
+}
+
+func NewDigestPolicyBlobstoreDelegator(delegate BlobstoreDelegator, minimumAlgorithm string) (*DigestPolicyBlobstoreDelegator, error) {
+	if digestStrength(minimumAlgorithm) < 0 {
+		return nil, bosherr.Errorf("Unknown minimum digest algorithm '%s'", minimumAlgorithm)
+	}
+
+	return &DigestPolicyBlobstoreDelegator{
+		delegate: delegate,
+		minimum:  digestAlgorithmStrength[digestStrength(minimumAlgorithm)],
+	}, nil
+}
+
+func (b *DigestPolicyBlobstoreDelegator) Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (string, error) {
+	if err := b.check(digest); err != nil {
+		return "", bosherr.WrapError(err, "Refusing to download blob")
+	}
+
+	return b.delegate.Get(digest, signedURL, blobID, headers)
+}
+
+func (b *DigestPolicyBlobstoreDelegator) Write(signedURL, path string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+	blobID, digest, err := b.delegate.Write(signedURL, path, headers)
+	if err != nil {
+		return blobID, digest, err
+	}
+
+	if err := b.check(digest); err != nil {
+		return "", boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Uploaded blob")
+	}
+
+	return blobID, digest, nil
+}
+
+func (b *DigestPolicyBlobstoreDelegator) WriteMultipart(upload httpblobprovider.MultipartUpload, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	digest, err := b.delegate.WriteMultipart(upload, path, headers)
+	if err != nil {
+		return digest, err
+	}
+
+	if err := b.check(digest); err != nil {
+		return boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Uploaded blob")
+	}
+
+	return digest, nil
+}
+
+func (b *DigestPolicyBlobstoreDelegator) WriteStream(signedURL string, open httpblobprovider.OpenStream, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	digest, err := b.delegate.WriteStream(signedURL, open, headers)
+	if err != nil {
+		return digest, err
+	}
+
+	if err := b.check(digest); err != nil {
+		return boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Uploaded blob")
+	}
+
+	return digest, nil
+}
+
+func (b *DigestPolicyBlobstoreDelegator) CleanUp(signedURL, path string) error {
+	return b.delegate.CleanUp(signedURL, path)
+}
+
+func (b *DigestPolicyBlobstoreDelegator) Delete(signedURL, blobID string, headers map[string]string) error {
+	return b.delegate.Delete(signedURL, blobID, headers)
+}
+
+func (b *DigestPolicyBlobstoreDelegator) check(digest boshcrypto.Digest) error {
+	strongest, ok := strongestDigestAlgorithm(digest)
+	if !ok {
+		return bosherr.Errorf("Blob has no digest but the digest policy requires %s", b.minimum.Name())
+	}
+
+	if digestStrength(strongest.Name()) < digestStrength(b.minimum.Name()) {
+		return bosherr.Errorf("Blob is only verified with %s but the digest policy requires %s", strongest.Name(), b.minimum.Name())
+	}
+
+	return nil
+}
+
+// strongestDigestAlgorithm does not rely on MultipleDigest.Algorithm since
+// it panics for empty multiple digests.
+func strongestDigestAlgorithm(digest boshcrypto.Digest) (boshcrypto.Algorithm, bool) {
+	if digest == nil {
+		return nil, false
+	}
+
+	multipleDigest, ok := digest.(boshcrypto.MultipleDigest)
+	if !ok {
+		return digest.Algorithm(), true
+	}
+
+	for i := len(digestAlgorithmStrength) - 1; i >= 0; i-- {
+		if _, err := multipleDigest.DigestFor(digestAlgorithmStrength[i]); err == nil {
+			return digestAlgorithmStrength[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// digestStrength is -1 for unknown algorithms.
+func digestStrength(algorithmName string) int {
+	for i, algo := range digestAlgorithmStrength {
+		if algo.Name() == algorithmName {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,89 @@
+package blobstore_delegator_test
+
+import (
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+)
+
+var _ = Describe("DigestPolicyBlobstoreDelegator", func() {
+	var (
+		sha1Digest   boshcrypto.Digest
+		sha256Digest boshcrypto.Digest
+		fakeDelegate *blobstore_delegatorfakes.FakeBlobstoreDelegator
+		delegator    blobstore_delegator.BlobstoreDelegator
+	)
+
+	BeforeEach(func() {
+		sha1Digest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")
+		sha256Digest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, "fake-sha256")
+		fakeDelegate = &blobstore_delegatorfakes.FakeBlobstoreDelegator{}
+		fakeDelegate.GetReturns("/some/blob", nil)
+
+		var err error
+		delegator, err = blobstore_delegator.NewDigestPolicyBlobstoreDelegator(fakeDelegate, "sha256")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("rejects unknown minimum algorithms", func() {
+		_, err := blobstore_delegator.NewDigestPolicyBlobstoreDelegator(fakeDelegate, "md5")
+		Expect(err).To(MatchError("Unknown minimum digest algorithm 'md5'"))
+	})
+
+	Describe("Get", func() {
+		It("downloads blobs verified with a strong enough digest", func() {
+			digest := boshcrypto.MustNewMultipleDigest(sha1Digest, sha256Digest)
+
+			fileName, err := delegator.Get(digest, "some-signed-url", "", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal("/some/blob"))
+			Expect(fakeDelegate.GetCallCount()).To(Equal(1))
+		})
+
+		It("refuses blobs only verified with sha1", func() {
+			_, err := delegator.Get(boshcrypto.MustNewMultipleDigest(sha1Digest), "some-signed-url", "", nil)
+			Expect(err).To(MatchError("Refusing to download blob: Blob is only verified with sha1 but the digest policy requires sha256"))
+			Expect(fakeDelegate.GetCallCount()).To(Equal(0))
+		})
+
+		It("refuses blobs without a digest", func() {
+			_, err := delegator.Get(nil, "", "some-blob-id", nil)
+			Expect(err).To(MatchError("Refusing to download blob: Blob has no digest but the digest policy requires sha256"))
+
+			_, err = delegator.Get(boshcrypto.MultipleDigest{}, "", "some-blob-id", nil)
+			Expect(err).To(MatchError(ContainSubstring("Blob has no digest")))
+
+			Expect(fakeDelegate.GetCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("Write", func() {
+		It("returns uploads digested with a strong enough algorithm", func() {
+			digest := boshcrypto.MustNewMultipleDigest(sha1Digest, sha256Digest)
+			fakeDelegate.WriteReturns("some-blob-id", digest, nil)
+
+			blobID, digestResult, err := delegator.Write("", "/some/path", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("some-blob-id"))
+			Expect(digestResult).To(Equal(digest))
+		})
+
+		It("fails uploads only digested with sha1", func() {
+			fakeDelegate.WriteReturns("some-blob-id", boshcrypto.MustNewMultipleDigest(sha1Digest), nil)
+
+			_, _, err := delegator.Write("", "/some/path", nil)
+			Expect(err).To(MatchError("Uploaded blob: Blob is only verified with sha1 but the digest policy requires sha256"))
+		})
+
+		It("fails multipart uploads only digested with sha1", func() {
+			fakeDelegate.WriteMultipartReturns(boshcrypto.MustNewMultipleDigest(sha1Digest), nil)
+
+			_, err := delegator.WriteMultipart(httpblobprovider.MultipartUpload{}, "/some/path", nil)
+			Expect(err).To(MatchError(ContainSubstring("only verified with sha1")))
+		})
+	})
+})
@@ -0,0 +1,157 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	"io"
+	"os"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+// EncryptingBlobstoreDelegator transparently decrypts downloaded blobs that
+// were encrypted by an agent and, unless it only decrypts, encrypts blobs
+// before uploading them. Digests returned by uploads are the digests of the
+// encrypted blobs since that is what the blobstore verifies downloads with.
+type EncryptingBlobstoreDelegator struct {
+	delegate      BlobstoreDelegator
+	keys          *BlobEncryptionKeys
+	encryptWrites bool
+	fs            boshsys.FileSystem
+}
+
+func NewEncryptingBlobstoreDelegator(delegate BlobstoreDelegator, keys *BlobEncryptionKeys, fs boshsys.FileSystem) *EncryptingBlobstoreDelegator {
+	return &EncryptingBlobstoreDelegator{delegate: delegate, keys: keys, encryptWrites: true, fs: fs}
+}
+
+// NewDecryptingBlobstoreDelegator decrypts downloaded blobs but uploads blobs
+// as is, for example logs the director has to read.
+func NewDecryptingBlobstoreDelegator(delegate BlobstoreDelegator, keys *BlobEncryptionKeys, fs boshsys.FileSystem) *EncryptingBlobstoreDelegator {
+	return &EncryptingBlobstoreDelegator{delegate: delegate, keys: keys, fs: fs}
+}
+
+func (b *EncryptingBlobstoreDelegator) Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (string, error) {
+	fileName, err := b.delegate.Get(digest, signedURL, blobID, headers)
+	if err != nil {
+		return "", err
+	}
+
+	encrypted, err := b.fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening downloaded blob")
+	}
+	defer encrypted.Close() //nolint:errcheck
+
+	if !isEncryptedBlob(encrypted) {
+		return fileName, nil
+	}
+
+	defer b.fs.RemoveAll(fileName) //nolint:errcheck
+
+	if _, err = encrypted.Seek(0, io.SeekStart); err != nil {
+		return "", bosherr.WrapError(err, "Rewinding downloaded blob")
+	}
+
+	decrypted, err := b.fs.TempFile("bosh-blobstore-delegator-GET")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file for decrypted blob")
+	}
+	defer decrypted.Close() //nolint:errcheck
+
+	err = b.keys.Decrypt(decrypted, encrypted)
+	if err != nil {
+		_ = b.fs.RemoveAll(decrypted.Name())
+		return "", bosherr.WrapError(err, "Decrypting downloaded blob")
+	}
+
+	return decrypted.Name(), nil
+}
+
+func (b *EncryptingBlobstoreDelegator) Write(signedURL, path string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+	if !b.encryptWrites {
+		return b.delegate.Write(signedURL, path, headers)
+	}
+
+	encryptedPath, err := b.encrypt(path)
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, err
+	}
+	defer b.fs.RemoveAll(encryptedPath) //nolint:errcheck
+
+	return b.delegate.Write(signedURL, encryptedPath, headers)
+}
+
+func (b *EncryptingBlobstoreDelegator) WriteMultipart(upload httpblobprovider.MultipartUpload, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	if !b.encryptWrites {
+		return b.delegate.WriteMultipart(upload, path, headers)
+	}
+
+	encryptedPath, err := b.encrypt(path)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+	defer b.fs.RemoveAll(encryptedPath) //nolint:errcheck
+
+	return b.delegate.WriteMultipart(upload, encryptedPath, headers)
+}
+
+// WriteStream encrypts the stream while it is uploaded
+func (b *EncryptingBlobstoreDelegator) WriteStream(signedURL string, open httpblobprovider.OpenStream, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	if !b.encryptWrites {
+		return b.delegate.WriteStream(signedURL, open, headers)
+	}
+
+	openEncrypted := func() (io.ReadCloser, error) {
+		plain, err := open()
+		if err != nil {
+			return nil, err
+		}
+
+		encrypted, encryptedWriter := io.Pipe()
+
+		go func() {
+			err := b.keys.Encrypt(encryptedWriter, plain)
+			_ = plain.Close()
+			if err != nil {
+				err = bosherr.WrapError(err, "Encrypting blob")
+			}
+			encryptedWriter.CloseWithError(err) //nolint:errcheck
+		}()
+
+		return encrypted, nil
+	}
+
+	return b.delegate.WriteStream(signedURL, openEncrypted, headers)
+}
+
+func (b *EncryptingBlobstoreDelegator) encrypt(path string) (string, error) {
+	plain, err := b.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening blob to encrypt")
+	}
+	defer plain.Close() //nolint:errcheck
+
+	encrypted, err := b.fs.TempFile("bosh-blobstore-delegator-encrypted")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file for encrypted blob")
+	}
+	defer encrypted.Close() //nolint:errcheck
+
+	err = b.keys.Encrypt(encrypted, plain)
+	if err != nil {
+		_ = b.fs.RemoveAll(encrypted.Name())
+		return "", bosherr.WrapError(err, "Encrypting blob")
+	}
+
+	return encrypted.Name(), nil
+}
+
+func (b *EncryptingBlobstoreDelegator) CleanUp(signedURL, path string) error {
+	return b.delegate.CleanUp(signedURL, path)
+}
+
+func (b *EncryptingBlobstoreDelegator) Delete(signedURL, blobID string, headers map[string]string) error {
+	return b.delegate.Delete(signedURL, blobID, headers)
+}
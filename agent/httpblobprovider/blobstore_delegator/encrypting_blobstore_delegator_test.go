@@ -0,0 +1,184 @@
+package blobstore_delegator_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+var _ = Describe("EncryptingBlobstoreDelegator", func() {
+	const (
+		key1 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+		key2 = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+	)
+
+	var (
+		fs           boshsys.FileSystem
+		tmpDir       string
+		plaintext    []byte
+		packagePath  string
+		uploaded     []byte
+		fakeDelegate *blobstore_delegatorfakes.FakeBlobstoreDelegator
+		keys         *blobstore_delegator.BlobEncryptionKeys
+		delegator    blobstore_delegator.BlobstoreDelegator
+	)
+
+	newKeys := func(activeKeyID string) *blobstore_delegator.BlobEncryptionKeys {
+		keys, err := blobstore_delegator.NewBlobEncryptionKeys(boshsettings.BlobEncryption{
+			ActiveKeyID: activeKeyID,
+			Keys:        map[string]string{"key-1": key1, "key-2": key2},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return keys
+	}
+
+	download := func(contents []byte) {
+		downloaded := filepath.Join(tmpDir, "downloaded")
+		Expect(os.WriteFile(downloaded, contents, 0600)).To(Succeed())
+		fakeDelegate.GetReturns(downloaded, nil)
+	}
+
+	BeforeEach(func() {
+		tmpDir = GinkgoT().TempDir()
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		Expect(fs.ChangeTempRoot(tmpDir)).To(Succeed())
+
+		// Spans several chunks and ends in a partial one
+		plaintext = bytes.Repeat([]byte("compiled package "), 10000)
+		packagePath = filepath.Join(tmpDir, "package.tgz")
+		Expect(os.WriteFile(packagePath, plaintext, 0600)).To(Succeed())
+
+		fakeDelegate = &blobstore_delegatorfakes.FakeBlobstoreDelegator{}
+		fakeDelegate.WriteStub = func(_, path string, _ map[string]string) (string, boshcrypto.MultipleDigest, error) {
+			var err error
+			uploaded, err = os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			return "blob-id", boshcrypto.MultipleDigest{}, nil
+		}
+
+		keys = newKeys("key-2")
+		delegator = blobstore_delegator.NewEncryptingBlobstoreDelegator(fakeDelegate, keys, fs)
+	})
+
+	It("uploads encrypted blobs and removes them afterwards", func() {
+		blobID, _, err := delegator.Write("signed-url", packagePath, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("blob-id"))
+
+		_, path, _ := fakeDelegate.WriteArgsForCall(0)
+		Expect(path).ToNot(Equal(packagePath))
+		Expect(fs.FileExists(path)).To(BeFalse())
+
+		Expect(uploaded).ToNot(ContainSubstring("compiled package"))
+	})
+
+	It("encrypts multipart uploads too", func() {
+		fakeDelegate.WriteMultipartStub = func(_ httpblobprovider.MultipartUpload, path string, _ map[string]string) (boshcrypto.MultipleDigest, error) {
+			var err error
+			uploaded, err = os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			return boshcrypto.MultipleDigest{}, nil
+		}
+
+		_, err := delegator.WriteMultipart(httpblobprovider.MultipartUpload{}, packagePath, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(uploaded).ToNot(ContainSubstring("compiled package"))
+	})
+
+	It("encrypts streamed uploads while they are uploaded", func() {
+		fakeDelegate.WriteStreamStub = func(_ string, open httpblobprovider.OpenStream, _ map[string]string) (boshcrypto.MultipleDigest, error) {
+			stream, err := open()
+			Expect(err).ToNot(HaveOccurred())
+			defer stream.Close() //nolint:errcheck
+
+			uploaded, err = io.ReadAll(stream)
+			Expect(err).ToNot(HaveOccurred())
+			return boshcrypto.MultipleDigest{}, nil
+		}
+
+		_, err := delegator.WriteStream("signed-url", func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(plaintext)), nil
+		}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(uploaded).ToNot(ContainSubstring("compiled package"))
+
+		download(uploaded)
+
+		fileName, err := delegator.Get(nil, "signed-url", "", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.ReadFile(fileName)).To(Equal(plaintext))
+	})
+
+	It("decrypts downloaded blobs encrypted with any of the keys", func() {
+		_, _, err := delegator.Write("signed-url", packagePath, nil)
+		Expect(err).ToNot(HaveOccurred())
+		download(uploaded)
+
+		rotated := blobstore_delegator.NewEncryptingBlobstoreDelegator(fakeDelegate, newKeys("key-1"), fs)
+
+		fileName, err := rotated.Get(nil, "signed-url", "", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.ReadFile(fileName)).To(Equal(plaintext))
+		Expect(fs.FileExists(filepath.Join(tmpDir, "downloaded"))).To(BeFalse())
+	})
+
+	It("returns blobs that are not encrypted as is", func() {
+		download([]byte("plain"))
+
+		fileName, err := delegator.Get(nil, "signed-url", "", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileName).To(Equal(filepath.Join(tmpDir, "downloaded")))
+		Expect(os.ReadFile(fileName)).To(Equal([]byte("plain")))
+	})
+
+	It("refuses blobs encrypted with an unknown key", func() {
+		_, _, err := delegator.Write("signed-url", packagePath, nil)
+		Expect(err).ToNot(HaveOccurred())
+		download(uploaded)
+
+		otherKeys, err := blobstore_delegator.NewBlobEncryptionKeys(boshsettings.BlobEncryption{Keys: map[string]string{"key-1": key1}})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = blobstore_delegator.NewDecryptingBlobstoreDelegator(fakeDelegate, otherKeys, fs).Get(nil, "signed-url", "", nil)
+		Expect(err).To(MatchError(ContainSubstring("Blob is encrypted with unknown key 'key-2'")))
+	})
+
+	It("refuses truncated blobs", func() {
+		_, _, err := delegator.Write("signed-url", packagePath, nil)
+		Expect(err).ToNot(HaveOccurred())
+		download(uploaded[:64*1024+100])
+
+		_, err = delegator.Get(nil, "signed-url", "", nil)
+		Expect(err).To(MatchError(ContainSubstring("Decrypting blob")))
+	})
+
+	It("uploads blobs as is when it only decrypts", func() {
+		delegator = blobstore_delegator.NewDecryptingBlobstoreDelegator(fakeDelegate, keys, fs)
+
+		_, _, err := delegator.Write("signed-url", packagePath, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, path, _ := fakeDelegate.WriteArgsForCall(0)
+		Expect(path).To(Equal(packagePath))
+	})
+
+	It("rejects an active key that is not one of the keys", func() {
+		_, err := blobstore_delegator.NewBlobEncryptionKeys(boshsettings.BlobEncryption{
+			ActiveKeyID: "key-3",
+			Keys:        map[string]string{"key-1": key1},
+		})
+		Expect(err).To(MatchError("Active blob encryption key 'key-3' is not one of the keys"))
+	})
+})
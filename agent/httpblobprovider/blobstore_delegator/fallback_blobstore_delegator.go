@@ -0,0 +1,154 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+const (
+	fallbackLogTag = "FallbackBlobstoreDelegator"
+
+	// DefaultFallbackFailureThreshold signed URL downloads have to fail in
+	// a row before downloads fall back to the blobstore client
+	DefaultFallbackFailureThreshold = 3
+
+	// DefaultFallbackCooldown is how long downloads keep using the
+	// blobstore client before signed URLs are tried again
+	DefaultFallbackCooldown = 5 * time.Minute
+)
+
+// FallbackBlobstoreDelegator downloads blobs with the blobstore client
+// instead of their signed URLs once signed URL downloads keep failing, for
+// example during an outage of the gateway in front of the blobstore. Signed
+// URLs are tried again after a cooldown. Only blobs the director also sent
+// a blobstore ID for can fall back.
+type FallbackBlobstoreDelegator struct {
+	delegate  BlobstoreDelegator
+	fallback  blobstore.DigestBlobstore
+	threshold int
+	cooldown  time.Duration
+	metrics   *httpblobprovider.TransferMetrics
+	clock     clock.Clock
+	logger    boshlog.Logger
+
+	lock      sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func NewFallbackBlobstoreDelegator(
+	delegate BlobstoreDelegator,
+	fallback blobstore.DigestBlobstore,
+	threshold int,
+	cooldown time.Duration,
+	metrics *httpblobprovider.TransferMetrics,
+	clock clock.Clock,
+	logger boshlog.Logger,
+) *FallbackBlobstoreDelegator {
+	return &FallbackBlobstoreDelegator{
+		delegate:  delegate,
+		fallback:  fallback,
+		threshold: threshold,
+		cooldown:  cooldown,
+		metrics:   metrics,
+		clock:     clock,
+		logger:    logger,
+	}
+}
+
+func (b *FallbackBlobstoreDelegator) Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (string, error) {
+	if signedURL == "" || blobID == "" {
+		return b.delegate.Get(digest, signedURL, blobID, headers)
+	}
+
+	if b.isOpen() {
+		return b.getFromFallback(digest, blobID)
+	}
+
+	fileName, err := b.delegate.Get(digest, signedURL, blobID, headers)
+	if err == nil {
+		b.recordSuccess()
+		return fileName, nil
+	}
+
+	if !b.recordFailure() {
+		return "", err
+	}
+
+	b.logger.Warn(fallbackLogTag, "Signed URL downloads failed %d times in a row, using the blobstore client for %s: %s", b.threshold, b.cooldown, err.Error())
+
+	fileName, fallbackErr := b.getFromFallback(digest, blobID)
+	if fallbackErr != nil {
+		return "", bosherr.WrapErrorf(err, "Falling back to the blobstore client also failed: %s", fallbackErr.Error())
+	}
+
+	return fileName, nil
+}
+
+func (b *FallbackBlobstoreDelegator) getFromFallback(digest boshcrypto.Digest, blobID string) (string, error) {
+	b.metrics.RecordFallback(httpblobprovider.TransferDownload)
+	return b.fallback.Get(blobID, digest)
+}
+
+func (b *FallbackBlobstoreDelegator) isOpen() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.failures >= b.threshold && b.clock.Now().Before(b.openUntil)
+}
+
+// recordFailure returns whether downloads fall back from now on.
+func (b *FallbackBlobstoreDelegator) recordFailure() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.failures++
+	if b.failures < b.threshold {
+		return false
+	}
+
+	b.openUntil = b.clock.Now().Add(b.cooldown)
+	b.metrics.SetSignedURLDegraded(true)
+
+	return true
+}
+
+func (b *FallbackBlobstoreDelegator) recordSuccess() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.failures >= b.threshold {
+		b.logger.Info(fallbackLogTag, "Signed URL downloads recovered")
+	}
+
+	b.failures = 0
+	b.metrics.SetSignedURLDegraded(false)
+}
+
+func (b *FallbackBlobstoreDelegator) Write(signedURL, path string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+	return b.delegate.Write(signedURL, path, headers)
+}
+
+func (b *FallbackBlobstoreDelegator) WriteMultipart(upload httpblobprovider.MultipartUpload, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	return b.delegate.WriteMultipart(upload, path, headers)
+}
+
+func (b *FallbackBlobstoreDelegator) WriteStream(signedURL string, open httpblobprovider.OpenStream, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	return b.delegate.WriteStream(signedURL, open, headers)
+}
+
+func (b *FallbackBlobstoreDelegator) CleanUp(signedURL, path string) error {
+	return b.delegate.CleanUp(signedURL, path)
+}
+
+func (b *FallbackBlobstoreDelegator) Delete(signedURL, blobID string, headers map[string]string) error {
+	return b.delegate.Delete(signedURL, blobID, headers)
+}
@@ -0,0 +1,102 @@
+package blobstore_delegator_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+)
+
+var _ = Describe("FallbackBlobstoreDelegator", func() {
+	var (
+		digest       boshcrypto.MultipleDigest
+		fakeDelegate *blobstore_delegatorfakes.FakeBlobstoreDelegator
+		fakeFallback *fakeblobstore.FakeDigestBlobstore
+		metrics      *httpblobprovider.TransferMetrics
+		clock        *fakeclock.FakeClock
+		delegator    blobstore_delegator.BlobstoreDelegator
+	)
+
+	BeforeEach(func() {
+		digest = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"))
+		fakeDelegate = &blobstore_delegatorfakes.FakeBlobstoreDelegator{}
+		fakeFallback = &fakeblobstore.FakeDigestBlobstore{}
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		metrics = httpblobprovider.NewTransferMetrics(0, logger)
+		clock = fakeclock.NewFakeClock(time.Now())
+
+		delegator = blobstore_delegator.NewFallbackBlobstoreDelegator(fakeDelegate, fakeFallback, 2, time.Minute, metrics, clock, logger)
+
+		fakeDelegate.GetReturns("", errors.New("fake-gateway-err"))
+		fakeFallback.GetReturns("/fallback/blob", nil)
+	})
+
+	It("returns signed url failures until the threshold is reached", func() {
+		_, err := delegator.Get(digest, "some-signed-url", "some-blob-id", nil)
+		Expect(err).To(MatchError("fake-gateway-err"))
+
+		Expect(fakeFallback.GetCallCount()).To(Equal(0))
+		Expect(metrics.Snapshot().SignedURLDegraded).To(BeFalse())
+	})
+
+	It("falls back to the blobstore client once signed urls keep failing", func() {
+		_, _ = delegator.Get(digest, "some-signed-url", "some-blob-id", nil)
+
+		fileName, err := delegator.Get(digest, "some-signed-url", "some-blob-id", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileName).To(Equal("/fallback/blob"))
+
+		blobID, digestArg := fakeFallback.GetArgsForCall(0)
+		Expect(blobID).To(Equal("some-blob-id"))
+		Expect(digestArg).To(Equal(digest))
+
+		Expect(metrics.Snapshot().SignedURLDegraded).To(BeTrue())
+		Expect(metrics.Snapshot().Download.Fallbacks).To(Equal(int64(1)))
+	})
+
+	It("skips signed urls during the cooldown and tries them again afterwards", func() {
+		_, _ = delegator.Get(digest, "some-signed-url", "some-blob-id", nil)
+		_, _ = delegator.Get(digest, "some-signed-url", "some-blob-id", nil)
+		Expect(fakeDelegate.GetCallCount()).To(Equal(2))
+
+		_, err := delegator.Get(digest, "some-signed-url", "some-blob-id", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeDelegate.GetCallCount()).To(Equal(2))
+
+		clock.Increment(time.Minute)
+		fakeDelegate.GetReturns("/signed-url/blob", nil)
+
+		fileName, err := delegator.Get(digest, "some-signed-url", "some-blob-id", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileName).To(Equal("/signed-url/blob"))
+		Expect(fakeDelegate.GetCallCount()).To(Equal(3))
+		Expect(metrics.Snapshot().SignedURLDegraded).To(BeFalse())
+	})
+
+	It("returns both errors when the fallback fails too", func() {
+		fakeFallback.GetReturns("", errors.New("fake-fallback-err"))
+
+		_, _ = delegator.Get(digest, "some-signed-url", "some-blob-id", nil)
+		_, err := delegator.Get(digest, "some-signed-url", "some-blob-id", nil)
+		Expect(err).To(MatchError(ContainSubstring("fake-gateway-err")))
+		Expect(err).To(MatchError(ContainSubstring("fake-fallback-err")))
+	})
+
+	It("does not fall back for blobs without a blobstore id", func() {
+		for i := 0; i < 3; i++ {
+			_, err := delegator.Get(digest, "some-signed-url", "", nil)
+			Expect(err).To(MatchError("fake-gateway-err"))
+		}
+
+		Expect(fakeFallback.GetCallCount()).To(Equal(0))
+	})
+})
@@ -0,0 +1,55 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+// LimitingBlobstoreDelegator bounds how many blobs are transferred at the
+// same time across all actions. Transfers beyond the limit wait for a
+// running one to finish.
+type LimitingBlobstoreDelegator struct {
+	delegate  BlobstoreDelegator
+	transfers chan struct{}
+}
+
+func NewLimitingBlobstoreDelegator(delegate BlobstoreDelegator, maxConcurrentTransfers int) *LimitingBlobstoreDelegator {
+	return &LimitingBlobstoreDelegator{
+		delegate:  delegate,
+		transfers: make(chan struct{}, maxConcurrentTransfers),
+	}
+}
+
+func (b *LimitingBlobstoreDelegator) acquire() func() {
+	b.transfers <- struct{}{}
+	return func() { <-b.transfers }
+}
+
+func (b *LimitingBlobstoreDelegator) Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (string, error) {
+	defer b.acquire()()
+	return b.delegate.Get(digest, signedURL, blobID, headers)
+}
+
+func (b *LimitingBlobstoreDelegator) Write(signedURL, path string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+	defer b.acquire()()
+	return b.delegate.Write(signedURL, path, headers)
+}
+
+func (b *LimitingBlobstoreDelegator) WriteMultipart(upload httpblobprovider.MultipartUpload, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	defer b.acquire()()
+	return b.delegate.WriteMultipart(upload, path, headers)
+}
+
+func (b *LimitingBlobstoreDelegator) WriteStream(signedURL string, open httpblobprovider.OpenStream, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	defer b.acquire()()
+	return b.delegate.WriteStream(signedURL, open, headers)
+}
+
+func (b *LimitingBlobstoreDelegator) CleanUp(signedURL, path string) error {
+	return b.delegate.CleanUp(signedURL, path)
+}
+
+func (b *LimitingBlobstoreDelegator) Delete(signedURL, blobID string, headers map[string]string) error {
+	return b.delegate.Delete(signedURL, blobID, headers)
+}
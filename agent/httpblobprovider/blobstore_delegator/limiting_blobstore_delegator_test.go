@@ -0,0 +1,73 @@
+package blobstore_delegator_test
+
+import (
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+)
+
+var _ = Describe("LimitingBlobstoreDelegator", func() {
+	var (
+		fakeDelegate *blobstore_delegatorfakes.FakeBlobstoreDelegator
+		release      chan struct{}
+		delegator    blobstore_delegator.BlobstoreDelegator
+	)
+
+	BeforeEach(func() {
+		release = make(chan struct{})
+
+		fakeDelegate = &blobstore_delegatorfakes.FakeBlobstoreDelegator{}
+		fakeDelegate.GetStub = func(boshcrypto.Digest, string, string, map[string]string) (string, error) {
+			<-release
+			return "/downloaded", nil
+		}
+		fakeDelegate.WriteStub = func(string, string, map[string]string) (string, boshcrypto.MultipleDigest, error) {
+			<-release
+			return "blob-id", boshcrypto.MultipleDigest{}, nil
+		}
+
+		delegator = blobstore_delegator.NewLimitingBlobstoreDelegator(fakeDelegate, 2)
+	})
+
+	It("lets transfers beyond the limit wait for a running one to finish", func() {
+		done := make(chan struct{}, 3)
+
+		for i := 0; i < 2; i++ {
+			go func() {
+				defer GinkgoRecover()
+				_, err := delegator.Get(nil, "some-signed-url", "", nil)
+				Expect(err).ToNot(HaveOccurred())
+				done <- struct{}{}
+			}()
+		}
+
+		Eventually(fakeDelegate.GetCallCount).Should(Equal(2))
+
+		go func() {
+			defer GinkgoRecover()
+			_, _, err := delegator.Write("some-signed-url", "/some/path", nil)
+			Expect(err).ToNot(HaveOccurred())
+			done <- struct{}{}
+		}()
+
+		Consistently(fakeDelegate.WriteCallCount).Should(BeZero())
+
+		release <- struct{}{}
+		Eventually(fakeDelegate.WriteCallCount).Should(Equal(1))
+
+		close(release)
+		for i := 0; i < 3; i++ {
+			Eventually(done).Should(Receive())
+		}
+	})
+
+	It("does not limit deletes", func() {
+		for i := 0; i < 3; i++ {
+			Expect(delegator.Delete("", "some-blob-id", nil)).To(Succeed())
+		}
+		Expect(fakeDelegate.DeleteCallCount()).To(Equal(3))
+	})
+})
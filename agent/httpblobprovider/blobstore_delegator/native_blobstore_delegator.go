@@ -0,0 +1,164 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	"fmt"
+	"net/http"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+const nativeBlobstoreDelegatorLogTag = "NativeBlobstoreDelegator"
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ObjectStore
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ObjectUploader
+
+// ObjectStore addresses blobs in an IaaS object store and authorizes
+// requests for them with the instance's own identity.
+type ObjectStore interface {
+	ObjectURL(blobID string) string
+	Authorize(method, objectURL string, headers map[string]string) (map[string]string, error)
+}
+
+// ObjectUploader is implemented by object stores that need more than a
+// single authorized PUT to upload a blob.
+type ObjectUploader interface {
+	Upload(objectURL, path string, headers map[string]string) (boshcrypto.MultipleDigest, error)
+}
+
+// NativeBlobstoreDelegator talks to the object store directly for blobs
+// addressed by blob ID. Blobs addressed by signed URL are left to the
+// delegate.
+type NativeBlobstoreDelegator struct {
+	delegate BlobstoreDelegator
+	h        httpblobprovider.HTTPBlobProvider
+	client   *http.Client
+	store    ObjectStore
+	uuidGen  boshuuid.Generator
+	logger   boshlog.Logger
+}
+
+func NewNativeBlobstoreDelegator(
+	delegate BlobstoreDelegator,
+	hp httpblobprovider.HTTPBlobProvider,
+	client *http.Client,
+	store ObjectStore,
+	uuidGen boshuuid.Generator,
+	logger boshlog.Logger,
+) *NativeBlobstoreDelegator {
+	return &NativeBlobstoreDelegator{
+		delegate: delegate,
+		h:        hp,
+		client:   client,
+		store:    store,
+		uuidGen:  uuidGen,
+		logger:   logger,
+	}
+}
+
+func (b *NativeBlobstoreDelegator) Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (string, error) {
+	if signedURL != "" || blobID == "" {
+		return b.delegate.Get(digest, signedURL, blobID, headers)
+	}
+
+	objectURL := b.store.ObjectURL(blobID)
+
+	authorizedHeaders, err := b.store.Authorize(http.MethodGet, objectURL, headers)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Authorizing blob download")
+	}
+
+	b.logger.Debug(nativeBlobstoreDelegatorLogTag, "Downloading blob '%s'", blobID)
+
+	fileName, err := b.h.Get(objectURL, digest, authorizedHeaders)
+	if err != nil {
+		return fileName, bosherr.WrapErrorf(err, "Downloading blob '%s'", blobID)
+	}
+
+	return fileName, nil
+}
+
+func (b *NativeBlobstoreDelegator) Write(signedURL, path string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+	if signedURL != "" {
+		return b.delegate.Write(signedURL, path, headers)
+	}
+
+	blobID, err := b.uuidGen.Generate()
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Generating blob ID")
+	}
+
+	objectURL := b.store.ObjectURL(blobID)
+
+	b.logger.Debug(nativeBlobstoreDelegatorLogTag, "Uploading blob '%s'", blobID)
+
+	digest, err := b.upload(objectURL, path, headers)
+	if err != nil {
+		return "", digest, bosherr.WrapErrorf(err, "Uploading blob '%s'", blobID)
+	}
+
+	return blobID, digest, nil
+}
+
+func (b *NativeBlobstoreDelegator) WriteMultipart(upload httpblobprovider.MultipartUpload, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	return b.delegate.WriteMultipart(upload, path, headers)
+}
+
+func (b *NativeBlobstoreDelegator) WriteStream(signedURL string, open httpblobprovider.OpenStream, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	return b.delegate.WriteStream(signedURL, open, headers)
+}
+
+func (b *NativeBlobstoreDelegator) upload(objectURL, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	if uploader, ok := b.store.(ObjectUploader); ok {
+		return uploader.Upload(objectURL, path, headers)
+	}
+
+	authorizedHeaders, err := b.store.Authorize(http.MethodPut, objectURL, headers)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, bosherr.WrapError(err, "Authorizing blob upload")
+	}
+
+	return b.h.Upload(objectURL, path, authorizedHeaders)
+}
+
+func (b *NativeBlobstoreDelegator) CleanUp(signedURL, path string) error {
+	return b.delegate.CleanUp(signedURL, path)
+}
+
+func (b *NativeBlobstoreDelegator) Delete(signedURL, blobID string, headers map[string]string) error {
+	if signedURL != "" {
+		return b.delegate.Delete(signedURL, blobID, headers)
+	}
+
+	objectURL := b.store.ObjectURL(blobID)
+
+	authorizedHeaders, err := b.store.Authorize(http.MethodDelete, objectURL, nil)
+	if err != nil {
+		return bosherr.WrapError(err, "Authorizing blob delete")
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, objectURL, nil) //nolint:noctx
+	if err != nil {
+		return bosherr.WrapError(err, "Creating Delete Request")
+	}
+
+	for k, v := range authorizedHeaders {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting blob '%s'", blobID)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Deleting blob '%s', response was %d", blobID, resp.StatusCode) //nolint:staticcheck
+	}
+
+	return nil
+}
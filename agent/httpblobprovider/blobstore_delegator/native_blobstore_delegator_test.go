@@ -0,0 +1,210 @@
+package blobstore_delegator_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/httpblobproviderfakes"
+)
+
+var _ = Describe("NativeBlobstoreDelegator", func() {
+	var (
+		server           *httptest.Server
+		deleteRequests   []*http.Request
+		deleteStatus     int
+		fakeDelegate     *blobstore_delegatorfakes.FakeBlobstoreDelegator
+		fakeHTTPProvider *httpblobproviderfakes.FakeHTTPBlobProvider
+		fakeObjectStore  *blobstore_delegatorfakes.FakeObjectStore
+		uuidGen          *fakeuuid.FakeGenerator
+		digest           boshcrypto.MultipleDigest
+		delegator        blobstore_delegator.BlobstoreDelegator
+	)
+
+	BeforeEach(func() {
+		deleteRequests = nil
+		deleteStatus = http.StatusNoContent
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deleteRequests = append(deleteRequests, r)
+			w.WriteHeader(deleteStatus)
+		}))
+
+		fakeDelegate = &blobstore_delegatorfakes.FakeBlobstoreDelegator{}
+		fakeHTTPProvider = &httpblobproviderfakes.FakeHTTPBlobProvider{}
+		fakeObjectStore = &blobstore_delegatorfakes.FakeObjectStore{}
+		fakeObjectStore.ObjectURLStub = func(blobID string) string {
+			return server.URL + "/bosh-blobs/" + blobID
+		}
+		fakeObjectStore.AuthorizeStub = func(method, objectURL string, headers map[string]string) (map[string]string, error) {
+			authorized := map[string]string{"Authorization": "Bearer fake-token"}
+			for k, v := range headers {
+				authorized[k] = v
+			}
+			return authorized, nil
+		}
+
+		uuidGen = fakeuuid.NewFakeGenerator()
+		uuidGen.GeneratedUUID = "fake-blob-id"
+
+		var err error
+		digest, err = boshcrypto.NewMultipleDigest(strings.NewReader("blob"), []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1})
+		Expect(err).ToNot(HaveOccurred())
+
+		delegator = blobstore_delegator.NewNativeBlobstoreDelegator(
+			fakeDelegate,
+			fakeHTTPProvider,
+			server.Client(),
+			fakeObjectStore,
+			uuidGen,
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Get", func() {
+		It("downloads blobs addressed by id with authorized requests", func() {
+			fakeHTTPProvider.GetReturns("/tmp/downloaded", nil)
+
+			fileName, err := delegator.Get(digest, "", "some-blob-id", map[string]string{"X-Custom": "value"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal("/tmp/downloaded"))
+
+			Expect(fakeHTTPProvider.GetCallCount()).To(Equal(1))
+			url, getDigest, headers := fakeHTTPProvider.GetArgsForCall(0)
+			Expect(url).To(Equal(server.URL + "/bosh-blobs/some-blob-id"))
+			Expect(getDigest).To(Equal(digest))
+			Expect(headers).To(HaveKeyWithValue("Authorization", "Bearer fake-token"))
+			Expect(headers).To(HaveKeyWithValue("X-Custom", "value"))
+
+			method, _, _ := fakeObjectStore.AuthorizeArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+
+			Expect(fakeDelegate.GetCallCount()).To(Equal(0))
+		})
+
+		It("leaves signed url downloads to the delegate", func() {
+			fakeDelegate.GetReturns("/tmp/delegated", nil)
+
+			fileName, err := delegator.Get(digest, "some-signed-url", "", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal("/tmp/delegated"))
+
+			Expect(fakeHTTPProvider.GetCallCount()).To(Equal(0))
+		})
+
+		It("returns an error when the request cannot be authorized", func() {
+			fakeObjectStore.AuthorizeStub = nil
+			fakeObjectStore.AuthorizeReturns(nil, errors.New("fake-authorize-error"))
+
+			_, err := delegator.Get(digest, "", "some-blob-id", nil)
+			Expect(err).To(MatchError(ContainSubstring("fake-authorize-error")))
+			Expect(fakeHTTPProvider.GetCallCount()).To(Equal(0))
+		})
+
+		It("returns download errors", func() {
+			fakeHTTPProvider.GetReturns("/tmp/downloaded", errors.New("fake-get-error"))
+
+			_, err := delegator.Get(digest, "", "some-blob-id", nil)
+			Expect(err).To(MatchError(ContainSubstring("fake-get-error")))
+		})
+	})
+
+	Describe("Write", func() {
+		It("uploads blobs under a new blob id with authorized requests", func() {
+			fakeHTTPProvider.UploadReturns(digest, nil)
+
+			blobID, uploadDigest, err := delegator.Write("", "/tmp/some-blob", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(uploadDigest).To(Equal(digest))
+
+			url, path, headers := fakeHTTPProvider.UploadArgsForCall(0)
+			Expect(url).To(Equal(server.URL + "/bosh-blobs/fake-blob-id"))
+			Expect(path).To(Equal("/tmp/some-blob"))
+			Expect(headers).To(HaveKeyWithValue("Authorization", "Bearer fake-token"))
+
+			method, _, _ := fakeObjectStore.AuthorizeArgsForCall(0)
+			Expect(method).To(Equal("PUT"))
+		})
+
+		It("lets object stores that upload themselves take over the upload", func() {
+			fakeUploader := &blobstore_delegatorfakes.FakeObjectUploader{}
+			fakeUploader.UploadReturns(digest, nil)
+
+			delegator = blobstore_delegator.NewNativeBlobstoreDelegator(
+				fakeDelegate,
+				fakeHTTPProvider,
+				server.Client(),
+				uploadingObjectStore{FakeObjectStore: fakeObjectStore, FakeObjectUploader: fakeUploader},
+				uuidGen,
+				boshlog.NewLogger(boshlog.LevelNone),
+			)
+
+			blobID, uploadDigest, err := delegator.Write("", "/tmp/some-blob", map[string]string{"X-Custom": "value"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(uploadDigest).To(Equal(digest))
+
+			url, path, headers := fakeUploader.UploadArgsForCall(0)
+			Expect(url).To(Equal(server.URL + "/bosh-blobs/fake-blob-id"))
+			Expect(path).To(Equal("/tmp/some-blob"))
+			Expect(headers).To(Equal(map[string]string{"X-Custom": "value"}))
+
+			Expect(fakeHTTPProvider.UploadCallCount()).To(Equal(0))
+		})
+
+		It("leaves signed url uploads to the delegate", func() {
+			_, _, err := delegator.Write("some-signed-url", "/tmp/some-blob", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeDelegate.WriteCallCount()).To(Equal(1))
+			Expect(fakeHTTPProvider.UploadCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes blobs with an authorized request", func() {
+			err := delegator.Delete("", "some-blob-id", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deleteRequests).To(HaveLen(1))
+			Expect(deleteRequests[0].Method).To(Equal("DELETE"))
+			Expect(deleteRequests[0].URL.Path).To(Equal("/bosh-blobs/some-blob-id"))
+			Expect(deleteRequests[0].Header.Get("Authorization")).To(Equal("Bearer fake-token"))
+		})
+
+		It("returns an error when the object store rejects the delete", func() {
+			deleteStatus = http.StatusForbidden
+
+			err := delegator.Delete("", "some-blob-id", nil)
+			Expect(err).To(MatchError(ContainSubstring("response was 403")))
+		})
+	})
+
+	It("delegates clean up", func() {
+		err := delegator.CleanUp("", "/tmp/some-blob")
+		Expect(err).ToNot(HaveOccurred())
+
+		signedURL, path := fakeDelegate.CleanUpArgsForCall(0)
+		Expect(signedURL).To(BeEmpty())
+		Expect(path).To(Equal("/tmp/some-blob"))
+	})
+})
+
+type uploadingObjectStore struct {
+	*blobstore_delegatorfakes.FakeObjectStore
+	*blobstore_delegatorfakes.FakeObjectUploader
+}
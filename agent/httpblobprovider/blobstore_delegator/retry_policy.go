@@ -0,0 +1,88 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+// RetryPolicy controls how often failed signed URL transfers are attempted
+// again and how long to wait in between.
+type RetryPolicy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+	Multiplier     float64
+
+	// Zero does not limit the time spent retrying
+	MaxElapsedTime time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:       3,
+	InitialBackoff: 5 * time.Second,
+	Multiplier:     1,
+}
+
+// NewRetryPolicy fills the settings left unset with the defaults.
+func NewRetryPolicy(settings boshsettings.BlobstoreRetryPolicy) RetryPolicy {
+	policy := DefaultRetryPolicy
+
+	if settings.Attempts > 0 {
+		policy.Attempts = settings.Attempts
+	}
+	if settings.InitialBackoffSeconds > 0 {
+		policy.InitialBackoff = time.Duration(settings.InitialBackoffSeconds * float64(time.Second))
+	}
+	if settings.Multiplier > 0 {
+		policy.Multiplier = settings.Multiplier
+	}
+	if settings.MaxElapsedSeconds > 0 {
+		policy.MaxElapsedTime = time.Duration(settings.MaxElapsedSeconds * float64(time.Second))
+	}
+
+	return policy
+}
+
+type retryPolicyStrategy struct {
+	policy    RetryPolicy
+	retryable boshretry.Retryable
+	logger    boshlog.Logger
+	logTag    string
+}
+
+// NewRetryStrategy attempts retryable until it succeeds, asks not to be
+// retried, runs out of attempts or the next backoff would exceed the
+// maximum elapsed time.
+func NewRetryStrategy(policy RetryPolicy, retryable boshretry.Retryable, logger boshlog.Logger) boshretry.RetryStrategy {
+	return &retryPolicyStrategy{
+		policy:    policy,
+		retryable: retryable,
+		logger:    logger,
+		logTag:    "retryPolicyStrategy",
+	}
+}
+
+func (s *retryPolicyStrategy) Try() error {
+	start := time.Now()
+	backoff := s.policy.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		s.logger.Debug(s.logTag, "Making attempt #%d for %T", attempt, s.retryable)
+
+		shouldRetry, err := s.retryable.Attempt()
+		if !shouldRetry || attempt >= s.policy.Attempts {
+			return err
+		}
+
+		if s.policy.MaxElapsedTime > 0 && time.Since(start)+backoff > s.policy.MaxElapsedTime {
+			s.logger.Debug(s.logTag, "Giving up after %d attempts, retrying would exceed %s", attempt, s.policy.MaxElapsedTime)
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff = time.Duration(float64(backoff) * s.policy.Multiplier)
+	}
+}
@@ -0,0 +1,41 @@
+package blobstore_delegator_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+var _ = Describe("NewRetryPolicy", func() {
+	It("uses the defaults when nothing is configured", func() {
+		Expect(blobstore_delegator.NewRetryPolicy(boshsettings.BlobstoreRetryPolicy{})).To(Equal(blobstore_delegator.DefaultRetryPolicy))
+	})
+
+	It("uses the configured settings", func() {
+		policy := blobstore_delegator.NewRetryPolicy(boshsettings.BlobstoreRetryPolicy{
+			Attempts:              10,
+			InitialBackoffSeconds: 0.5,
+			Multiplier:            2,
+			MaxElapsedSeconds:     300,
+		})
+
+		Expect(policy).To(Equal(blobstore_delegator.RetryPolicy{
+			Attempts:       10,
+			InitialBackoff: 500 * time.Millisecond,
+			Multiplier:     2,
+			MaxElapsedTime: 5 * time.Minute,
+		}))
+	})
+
+	It("keeps the defaults for settings left unset", func() {
+		policy := blobstore_delegator.NewRetryPolicy(boshsettings.BlobstoreRetryPolicy{Attempts: 5})
+
+		Expect(policy.Attempts).To(Equal(5))
+		Expect(policy.InitialBackoff).To(Equal(5 * time.Second))
+		Expect(policy.Multiplier).To(Equal(float64(1)))
+	})
+})
@@ -0,0 +1,134 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	"path/filepath"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+const seedingBlobstoreDelegatorLogTag = "SeedingBlobstoreDelegator"
+
+// SeedingBlobstoreDelegator prefers blobs pre-seeded into local directories,
+// e.g. baked into the stemcell, over downloading them so that instances can
+// bootstrap without reaching the blobstore. Seeds are named by any digest of
+// the blob the same way the blob cache names them, e.g. sha256-DIGEST.
+type SeedingBlobstoreDelegator struct {
+	delegate BlobstoreDelegator
+	fs       boshsys.FileSystem
+	dirs     []string
+	logger   boshlog.Logger
+}
+
+func NewSeedingBlobstoreDelegator(delegate BlobstoreDelegator, fs boshsys.FileSystem, dirs []string, logger boshlog.Logger) *SeedingBlobstoreDelegator {
+	return &SeedingBlobstoreDelegator{
+		delegate: delegate,
+		fs:       fs,
+		dirs:     dirs,
+		logger:   logger,
+	}
+}
+
+func (b *SeedingBlobstoreDelegator) Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (string, error) {
+	if fileName, found := b.seed(digest); found {
+		return fileName, nil
+	}
+
+	return b.delegate.Get(digest, signedURL, blobID, headers)
+}
+
+// seed returns the path to a temporary copy of a seed matching digest. The
+// caller owns the copy like a downloaded blob. Seeds that do not match are
+// skipped.
+func (b *SeedingBlobstoreDelegator) seed(digest boshcrypto.Digest) (string, bool) {
+	for _, key := range seedKeys(digest) {
+		for _, dir := range b.dirs {
+			seedPath := filepath.Join(dir, key)
+			if !b.fs.FileExists(seedPath) {
+				continue
+			}
+
+			fileName, err := b.copySeed(seedPath, digest)
+			if err != nil {
+				b.logger.Warn(seedingBlobstoreDelegatorLogTag, "Skipping seed %s: %s", seedPath, err.Error())
+				continue
+			}
+
+			b.logger.Info(seedingBlobstoreDelegatorLogTag, "Using seed %s", seedPath)
+
+			return fileName, true
+		}
+	}
+
+	return "", false
+}
+
+func (b *SeedingBlobstoreDelegator) copySeed(seedPath string, digest boshcrypto.Digest) (string, error) {
+	file, err := b.fs.TempFile("bosh-blob-seed-GET")
+	if err != nil {
+		return "", err
+	}
+
+	fileName := file.Name()
+	_ = file.Close()
+
+	err = b.fs.CopyFile(seedPath, fileName)
+	if err == nil {
+		err = digest.VerifyFilePath(fileName, b.fs)
+	}
+
+	if err != nil {
+		_ = b.fs.RemoveAll(fileName)
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+func (b *SeedingBlobstoreDelegator) Write(signedURL, path string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+	return b.delegate.Write(signedURL, path, headers)
+}
+
+func (b *SeedingBlobstoreDelegator) WriteMultipart(upload httpblobprovider.MultipartUpload, path string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	return b.delegate.WriteMultipart(upload, path, headers)
+}
+
+func (b *SeedingBlobstoreDelegator) WriteStream(signedURL string, open httpblobprovider.OpenStream, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	return b.delegate.WriteStream(signedURL, open, headers)
+}
+
+func (b *SeedingBlobstoreDelegator) CleanUp(signedURL, path string) error {
+	return b.delegate.CleanUp(signedURL, path)
+}
+
+func (b *SeedingBlobstoreDelegator) Delete(signedURL, blobID string, headers map[string]string) error {
+	return b.delegate.Delete(signedURL, blobID, headers)
+}
+
+// seedKeys names seeds by each digest of the blob, strongest first, since
+// seeds may have been named before the director sent stronger digests
+func seedKeys(digest boshcrypto.Digest) []string {
+	multipleDigest, ok := digest.(boshcrypto.MultipleDigest)
+	if !ok {
+		key, ok := blobCacheKey(digest)
+		if !ok {
+			return nil
+		}
+		return []string{key}
+	}
+
+	var keys []string
+
+	for _, algo := range []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA512, boshcrypto.DigestAlgorithmSHA256, boshcrypto.DigestAlgorithmSHA1} {
+		if algoDigest, err := multipleDigest.DigestFor(algo); err == nil {
+			if key, ok := blobCacheKey(algoDigest); ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return keys
+}
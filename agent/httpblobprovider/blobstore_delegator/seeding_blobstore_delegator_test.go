@@ -0,0 +1,96 @@
+package blobstore_delegator_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+)
+
+var _ = Describe("SeedingBlobstoreDelegator", func() {
+	var (
+		seedsDir     string
+		otherDir     string
+		digest       boshcrypto.MultipleDigest
+		sha1Key      string
+		fakeDelegate *blobstore_delegatorfakes.FakeBlobstoreDelegator
+		delegator    blobstore_delegator.BlobstoreDelegator
+	)
+
+	BeforeEach(func() {
+		tmpDir := GinkgoT().TempDir()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := boshsys.NewOsFileSystem(logger)
+		Expect(fs.ChangeTempRoot(tmpDir)).To(Succeed())
+
+		seedsDir = filepath.Join(tmpDir, "seeds")
+		otherDir = filepath.Join(tmpDir, "other-seeds")
+		Expect(os.MkdirAll(seedsDir, 0700)).To(Succeed())
+		Expect(os.MkdirAll(otherDir, 0700)).To(Succeed())
+
+		var err error
+		digest, err = boshcrypto.NewMultipleDigest(strings.NewReader("blob"), []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1, boshcrypto.DigestAlgorithmSHA256})
+		Expect(err).ToNot(HaveOccurred())
+
+		sha1Digest, err := digest.DigestFor(boshcrypto.DigestAlgorithmSHA1)
+		Expect(err).ToNot(HaveOccurred())
+		sha1Key = "sha1-" + strings.TrimPrefix(sha1Digest.String(), "sha1:")
+
+		fakeDelegate = &blobstore_delegatorfakes.FakeBlobstoreDelegator{}
+		delegator = blobstore_delegator.NewSeedingBlobstoreDelegator(fakeDelegate, fs, []string{seedsDir, otherDir}, logger)
+	})
+
+	It("uses a copy of a seed named by any digest of the blob", func() {
+		seedPath := filepath.Join(otherDir, sha1Key)
+		Expect(os.WriteFile(seedPath, []byte("blob"), 0600)).To(Succeed())
+
+		fileName, err := delegator.Get(digest, "some-signed-url", "", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileName).ToNot(Equal(seedPath))
+		Expect(os.ReadFile(fileName)).To(Equal([]byte("blob")))
+		Expect(seedPath).To(BeAnExistingFile())
+
+		Expect(fakeDelegate.GetCallCount()).To(Equal(0))
+	})
+
+	It("skips seeds that do not match the digest", func() {
+		Expect(os.WriteFile(filepath.Join(seedsDir, sha1Key), []byte("corrupt"), 0600)).To(Succeed())
+		fakeDelegate.GetReturns("downloaded", nil)
+
+		fileName, err := delegator.Get(digest, "some-signed-url", "", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileName).To(Equal("downloaded"))
+		Expect(fakeDelegate.GetCallCount()).To(Equal(1))
+	})
+
+	It("downloads blobs without seeds", func() {
+		fakeDelegate.GetReturns("", errors.New("fake-get-error"))
+
+		_, err := delegator.Get(digest, "", "some-blob-id", nil)
+		Expect(err).To(MatchError("fake-get-error"))
+
+		_, _, blobID, _ := fakeDelegate.GetArgsForCall(0)
+		Expect(blobID).To(Equal("some-blob-id"))
+	})
+
+	It("delegates other operations", func() {
+		Expect(delegator.CleanUp("", "some-path")).To(Succeed())
+		Expect(fakeDelegate.CleanUpCallCount()).To(Equal(1))
+
+		Expect(delegator.Delete("", "some-blob-id", nil)).To(Succeed())
+		Expect(fakeDelegate.DeleteCallCount()).To(Equal(1))
+
+		_, _, err := delegator.Write("", "some-path", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeDelegate.WriteCallCount()).To(Equal(1))
+	})
+})
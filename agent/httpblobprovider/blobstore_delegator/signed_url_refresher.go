@@ -0,0 +1,122 @@
+package blobstore_delegator //nolint:revive
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
+)
+
+const DefaultSignedURLRefreshTimeout = 2 * time.Minute
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . SignedURLRefresher
+
+// SignedURLRefresher obtains a replacement for a signed URL that the
+// blobstore rejected, typically because it expired mid-deploy.
+type SignedURLRefresher interface {
+	Refresh(signedURL string, headers map[string]string) (string, map[string]string, error)
+	Deliver(requestID, signedURL string, headers map[string]string) error
+}
+
+// SignedURLRefreshRequest is published to the director, which answers with
+// a refresh_signed_url request carrying the same request ID.
+type SignedURLRefreshRequest struct {
+	RequestID string `json:"request_id"`
+	SignedURL string `json:"signed_url"`
+}
+
+type refreshedSignedURL struct {
+	signedURL string
+	headers   map[string]string
+}
+
+type MbusSignedURLRefresher struct {
+	handler boshhandler.Handler
+	uuidGen boshuuid.Generator
+	clock   clock.Clock
+	timeout time.Duration
+	logger  boshlog.Logger
+	logTag  string
+
+	pendingLock sync.Mutex
+	pending     map[string]chan refreshedSignedURL
+}
+
+func NewMbusSignedURLRefresher(
+	handler boshhandler.Handler,
+	uuidGen boshuuid.Generator,
+	clock clock.Clock,
+	timeout time.Duration,
+	logger boshlog.Logger,
+) *MbusSignedURLRefresher {
+	return &MbusSignedURLRefresher{
+		handler: handler,
+		uuidGen: uuidGen,
+		clock:   clock,
+		timeout: timeout,
+		logger:  logger,
+		logTag:  "MbusSignedURLRefresher",
+		pending: map[string]chan refreshedSignedURL{},
+	}
+}
+
+func (r *MbusSignedURLRefresher) Refresh(signedURL string, headers map[string]string) (string, map[string]string, error) {
+	requestID, err := r.uuidGen.Generate()
+	if err != nil {
+		return "", nil, bosherr.WrapError(err, "Generating signed URL refresh request id")
+	}
+
+	refreshed := make(chan refreshedSignedURL, 1)
+
+	r.pendingLock.Lock()
+	r.pending[requestID] = refreshed
+	r.pendingLock.Unlock()
+
+	defer func() {
+		r.pendingLock.Lock()
+		delete(r.pending, requestID)
+		r.pendingLock.Unlock()
+	}()
+
+	r.logger.Info(r.logTag, "Requesting refreshed signed URL with request id %s", requestID)
+
+	err = r.handler.Send(boshhandler.Director, boshhandler.SignedURLRefresh, SignedURLRefreshRequest{
+		RequestID: requestID,
+		SignedURL: signedURL,
+	})
+	if err != nil {
+		return "", nil, bosherr.WrapError(err, "Requesting refreshed signed URL")
+	}
+
+	select {
+	case result := <-refreshed:
+		if result.headers == nil {
+			result.headers = headers
+		}
+		return result.signedURL, result.headers, nil
+	case <-r.clock.After(r.timeout):
+		return "", nil, bosherr.Errorf("Timed out after %s waiting for refreshed signed URL", r.timeout)
+	}
+}
+
+// Deliver hands a refreshed signed URL from the director to the transfer
+// waiting on requestID. Nil headers keep the headers of the original URL.
+func (r *MbusSignedURLRefresher) Deliver(requestID, signedURL string, headers map[string]string) error {
+	r.pendingLock.Lock()
+	refreshed, found := r.pending[requestID]
+	delete(r.pending, requestID)
+	r.pendingLock.Unlock()
+
+	if !found {
+		return bosherr.Errorf("No pending signed URL refresh with request id %s", requestID)
+	}
+
+	refreshed <- refreshedSignedURL{signedURL: signedURL, headers: headers}
+
+	return nil
+}
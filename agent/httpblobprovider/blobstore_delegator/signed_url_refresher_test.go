@@ -0,0 +1,96 @@
+package blobstore_delegator_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
+	fakembus "github.com/cloudfoundry/bosh-agent/v2/mbus/fakes"
+)
+
+var _ = Describe("MbusSignedURLRefresher", func() {
+	var (
+		handler   *fakembus.FakeHandler
+		uuidGen   *fakeuuid.FakeGenerator
+		clock     *fakeclock.FakeClock
+		refresher *blobstore_delegator.MbusSignedURLRefresher
+	)
+
+	BeforeEach(func() {
+		handler = fakembus.NewFakeHandler()
+		uuidGen = &fakeuuid.FakeGenerator{GeneratedUUID: "fake-request-id"}
+		clock = fakeclock.NewFakeClock(time.Now())
+		refresher = blobstore_delegator.NewMbusSignedURLRefresher(handler, uuidGen, clock, time.Minute, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("asks the director for a new signed URL and returns the delivered one", func() {
+		handler.SendCallback = func(input fakembus.SendInput) {
+			go func() {
+				defer GinkgoRecover()
+				err := refresher.Deliver("fake-request-id", "fresh-signed-url", map[string]string{"fresh": "header"})
+				Expect(err).NotTo(HaveOccurred())
+			}()
+		}
+
+		signedURL, headers, err := refresher.Refresh("expired-signed-url", map[string]string{"old": "header"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signedURL).To(Equal("fresh-signed-url"))
+		Expect(headers).To(Equal(map[string]string{"fresh": "header"}))
+
+		Expect(handler.SendInputs()).To(Equal([]fakembus.SendInput{
+			{
+				Target: boshhandler.Director,
+				Topic:  boshhandler.SignedURLRefresh,
+				Message: blobstore_delegator.SignedURLRefreshRequest{
+					RequestID: "fake-request-id",
+					SignedURL: "expired-signed-url",
+				},
+			},
+		}))
+	})
+
+	It("keeps the original headers when none are delivered", func() {
+		handler.SendCallback = func(input fakembus.SendInput) {
+			refresher.Deliver("fake-request-id", "fresh-signed-url", nil) //nolint:errcheck
+		}
+
+		_, headers, err := refresher.Refresh("expired-signed-url", map[string]string{"old": "header"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(headers).To(Equal(map[string]string{"old": "header"}))
+	})
+
+	It("times out when the director does not answer", func() {
+		errChan := make(chan error)
+		go func() {
+			_, _, err := refresher.Refresh("expired-signed-url", nil)
+			errChan <- err
+		}()
+
+		Eventually(clock.WatcherCount).Should(Equal(1))
+		clock.Increment(time.Minute)
+
+		Eventually(errChan).Should(Receive(MatchError(ContainSubstring("Timed out after 1m0s waiting for refreshed signed URL"))))
+
+		err := refresher.Deliver("fake-request-id", "late-signed-url", nil)
+		Expect(err).To(MatchError("No pending signed URL refresh with request id fake-request-id"))
+	})
+
+	It("returns an error when the request cannot be sent", func() {
+		handler.SendErr = errors.New("fake-send-err")
+
+		_, _, err := refresher.Refresh("expired-signed-url", nil)
+		Expect(err).To(MatchError(ContainSubstring("fake-send-err")))
+	})
+
+	It("returns an error when delivering an unknown request", func() {
+		err := refresher.Deliver("unknown-request-id", "fresh-signed-url", nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,80 @@
+package httpblobprovider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// ConfigureEndpointCAs makes client trust the given CA certificates only for
+// the blobstore endpoints with these host names, in addition to the CAs it
+// trusts for every endpoint. Since every endpoint gets its own copy of the
+// transport it has to be called after the transport is otherwise configured.
+func ConfigureEndpointCAs(client *http.Client, endpointCAs map[string]string) error {
+	if len(endpointCAs) == 0 {
+		return nil
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return errors.New("Configuring blobstore endpoint CAs: unsupported http transport")
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	defaultRoots := transport.TLSClientConfig.RootCAs
+	if defaultRoots == nil {
+		var err error
+		defaultRoots, err = x509.SystemCertPool()
+		if err != nil {
+			defaultRoots = x509.NewCertPool()
+		}
+	}
+
+	endpointTransports := map[string]*http.Transport{}
+
+	for host, ca := range endpointCAs {
+		roots := defaultRoots.Clone()
+		if !roots.AppendCertsFromPEM([]byte(ca)) {
+			return bosherr.Errorf("Parsing CA of blobstore endpoint '%s': no certificates found", host)
+		}
+
+		endpointTransport := transport.Clone()
+		endpointTransport.TLSClientConfig.RootCAs = roots
+		endpointTransports[strings.ToLower(host)] = endpointTransport
+	}
+
+	client.Transport = &endpointCATransport{
+		defaultTransport:   transport,
+		endpointTransports: endpointTransports,
+	}
+
+	return nil
+}
+
+type endpointCATransport struct {
+	defaultTransport   *http.Transport
+	endpointTransports map[string]*http.Transport
+}
+
+func (t *endpointCATransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transport, found := t.endpointTransports[strings.ToLower(req.URL.Hostname())]; found {
+		return transport.RoundTrip(req)
+	}
+
+	return t.defaultTransport.RoundTrip(req)
+}
+
+func (t *endpointCATransport) CloseIdleConnections() {
+	t.defaultTransport.CloseIdleConnections()
+
+	for _, transport := range t.endpointTransports {
+		transport.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,84 @@
+package httpblobprovider_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	httpblobprovider "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	"github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+var _ = Describe("ConfigureEndpointCAs", func() {
+	var (
+		client      *http.Client
+		server      *httptest.Server
+		serverCAPEM string
+	)
+
+	BeforeEach(func() {
+		var err error
+		client, err = httpblobprovider.NewBlobstoreHTTPClient(settings.Blobstore{Type: "dav"})
+		Expect(err).NotTo(HaveOccurred())
+
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		serverCAPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("leaves the client alone when nothing is configured", func() {
+		transport := client.Transport
+
+		err := httpblobprovider.ConfigureEndpointCAs(client, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Transport).To(BeIdenticalTo(transport))
+	})
+
+	It("trusts the CA of the endpoint it is configured for", func() {
+		err := httpblobprovider.ConfigureEndpointCAs(client, map[string]string{"127.0.0.1": serverCAPEM})
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := client.Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	})
+
+	It("does not trust the CA for other endpoints", func() {
+		err := httpblobprovider.ConfigureEndpointCAs(client, map[string]string{"blobstore.internal": serverCAPEM})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.Get(server.URL)
+		Expect(err).To(MatchError(ContainSubstring("certificate signed by unknown authority")))
+	})
+
+	It("keeps trusting the default CAs for other endpoints", func() {
+		client.Transport.(*http.Transport).TLSClientConfig.RootCAs = x509.NewCertPool()
+		client.Transport.(*http.Transport).TLSClientConfig.RootCAs.AddCert(server.Certificate())
+
+		otherCAPEM, _ := generateClientCertificate()
+
+		err := httpblobprovider.ConfigureEndpointCAs(client, map[string]string{"blobstore.internal": otherCAPEM})
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := client.Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	})
+
+	It("returns an error when a CA is invalid", func() {
+		err := httpblobprovider.ConfigureEndpointCAs(client, map[string]string{"blobstore.internal": "invalid-ca"})
+		Expect(err).To(MatchError("Parsing CA of blobstore endpoint 'blobstore.internal': no certificates found"))
+	})
+})
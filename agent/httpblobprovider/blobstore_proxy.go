@@ -0,0 +1,298 @@
+package httpblobprovider
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-ntlmssp"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+// ConfigureProxy points the client at the director-provided blobstore proxy
+// instead of the one derived from the process environment. Clients are left
+// untouched when no proxy URL is configured.
+func ConfigureProxy(client *http.Client, proxySettings settings.BlobstoreProxy) error {
+	if proxySettings.URL == "" {
+		return nil
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return errors.New("Configuring blobstore proxy: unsupported http transport")
+	}
+
+	proxyURL, err := url.Parse(proxySettings.URL)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing blobstore proxy url")
+	}
+
+	if proxyURL.Host == "" {
+		return bosherr.Errorf("Blobstore proxy url '%s' has no host", proxySettings.URL)
+	}
+
+	noProxy := newNoProxyMatcher(proxySettings.NoProxy)
+
+	switch proxySettings.AuthType {
+	case "", settings.BlobstoreProxyAuthBasic:
+		if proxySettings.Username != "" {
+			proxyURL.User = url.UserPassword(proxySettings.Username, proxySettings.Password)
+		}
+
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if noProxy.Matches(req.URL.Host) {
+				return nil, nil
+			}
+			return proxyURL, nil
+		}
+
+	case settings.BlobstoreProxyAuthNTLM:
+		if proxyURL.Scheme != "http" {
+			return bosherr.Errorf("NTLM blobstore proxy must use http scheme, got '%s'", proxyURL.Scheme)
+		}
+
+		dialer := &ntlmProxyDialer{
+			proxyAddr: canonicalAddr(proxyURL),
+			username:  proxySettings.Username,
+			password:  proxySettings.Password,
+			noProxy:   noProxy,
+			dial:      transport.DialContext,
+		}
+		if dialer.dial == nil {
+			dialer.dial = (&net.Dialer{}).DialContext
+		}
+
+		transport.Proxy = nil
+		transport.DialContext = dialer.DialContext
+
+	default:
+		return bosherr.Errorf("Unsupported blobstore proxy auth type '%s'", proxySettings.AuthType)
+	}
+
+	return nil
+}
+
+type noProxyMatcher struct {
+	all      bool
+	hosts    []string
+	networks []*net.IPNet
+}
+
+func newNoProxyMatcher(entries []string) noProxyMatcher {
+	var matcher noProxyMatcher
+
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if entry == "*" {
+			matcher.all = true
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			matcher.networks = append(matcher.networks, network)
+			continue
+		}
+
+		if host, _, err := net.SplitHostPort(entry); err == nil {
+			entry = host
+		}
+
+		matcher.hosts = append(matcher.hosts, strings.TrimPrefix(entry, "*"))
+	}
+
+	return matcher
+}
+
+// Matches reports whether requests to hostport should bypass the proxy.
+// Entries match exactly, as domain suffixes (with or without a leading dot)
+// or, for IP addresses, by CIDR range.
+func (m noProxyMatcher) Matches(hostport string) bool {
+	if m.all {
+		return true
+	}
+
+	host := strings.ToLower(hostport)
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = strings.ToLower(h)
+	}
+	host = strings.Trim(host, "[]")
+
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range m.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+
+	for _, entry := range m.hosts {
+		if strings.HasPrefix(entry, ".") {
+			if strings.HasSuffix(host, entry) || host == entry[1:] {
+				return true
+			}
+			continue
+		}
+
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ntlmProxyDialer tunnels connections through an HTTP proxy using CONNECT,
+// answering the proxy's NTLM challenge before handing the connection back to
+// the transport.
+type ntlmProxyDialer struct {
+	proxyAddr string
+	username  string
+	password  string
+	noProxy   noProxyMatcher
+	dial      func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+func (d *ntlmProxyDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if d.noProxy.Matches(addr) {
+		return d.dial(ctx, network, addr)
+	}
+
+	conn, err := d.dial(ctx, network, d.proxyAddr)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Dialing blobstore proxy '%s'", d.proxyAddr)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)          //nolint:errcheck
+		defer conn.SetDeadline(time.Time{}) //nolint:errcheck
+	}
+
+	tunnel, err := d.connect(conn, addr)
+	if err != nil {
+		conn.Close() //nolint:errcheck
+		return nil, err
+	}
+
+	return tunnel, nil
+}
+
+func (d *ntlmProxyDialer) connect(conn net.Conn, addr string) (net.Conn, error) {
+	user, domain, domainNeeded := ntlmssp.GetDomain(d.username)
+
+	negotiate, err := ntlmssp.NewNegotiateMessage(domain, "")
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Building NTLM negotiate message")
+	}
+
+	reader := bufio.NewReader(conn)
+
+	resp, err := d.sendConnect(conn, reader, addr, negotiate)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		challenge, err := ntlmChallenge(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		authenticate, err := ntlmssp.ProcessChallenge(challenge, user, d.password, domainNeeded)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Answering NTLM proxy challenge")
+		}
+
+		resp, err = d.sendConnect(conn, reader, addr, authenticate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, bosherr.Errorf("Blobstore proxy refused tunnel to '%s': %s", addr, resp.Status)
+	}
+
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+func (d *ntlmProxyDialer) sendConnect(conn net.Conn, reader *bufio.Reader, addr string, message []byte) (*http.Response, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{
+			"Proxy-Authorization": {"NTLM " + base64.StdEncoding.EncodeToString(message)},
+			"Proxy-Connection":    {"Keep-Alive"},
+		},
+	}
+
+	if err := req.Write(conn); err != nil {
+		return nil, bosherr.WrapError(err, "Writing CONNECT request to blobstore proxy")
+	}
+
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading CONNECT response from blobstore proxy")
+	}
+
+	// A successful CONNECT response has no body, the tunnel starts right after
+	// the headers. Otherwise drain it so the handshake can continue on the
+	// same connection.
+	if resp.StatusCode != http.StatusOK {
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close() //nolint:errcheck
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Reading CONNECT response from blobstore proxy")
+		}
+	}
+
+	return resp, nil
+}
+
+func ntlmChallenge(resp *http.Response) ([]byte, error) {
+	for _, header := range resp.Header.Values("Proxy-Authenticate") {
+		if encoded, found := strings.CutPrefix(header, "NTLM "); found {
+			challenge, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+			if err != nil {
+				return nil, bosherr.WrapError(err, "Decoding NTLM proxy challenge")
+			}
+			return challenge, nil
+		}
+	}
+
+	return nil, errors.New("Blobstore proxy did not send an NTLM challenge")
+}
+
+func canonicalAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
+}
+
+// bufferedConn hands back any bytes the handshake reader already buffered
+// before reading from the tunnel itself.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	if c.reader.Buffered() > 0 {
+		return c.reader.Read(p)
+	}
+	return c.Conn.Read(p)
+}
@@ -0,0 +1,87 @@
+package httpblobprovider
+
+import (
+	"io"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+)
+
+// DigestMismatchError is returned by digest verifying readers when the
+// stream does not match the expected digest.
+type DigestMismatchError struct {
+	err error
+}
+
+func (e DigestMismatchError) Error() string {
+	return e.err.Error()
+}
+
+func (e DigestMismatchError) Unwrap() error {
+	return e.err
+}
+
+type digestVerifyingReader struct {
+	reader io.Reader
+	writer *io.PipeWriter
+	result chan error
+
+	verified  bool
+	verifyErr error
+}
+
+// NewDigestVerifyingReader computes the digest of reader while it is being
+// consumed. Instead of io.EOF the final read returns a DigestMismatchError
+// when the stream did not match digest, so blobs are read only once.
+func NewDigestVerifyingReader(reader io.Reader, digest boshcrypto.Digest) io.Reader {
+	pipeReader, pipeWriter := io.Pipe()
+
+	r := &digestVerifyingReader{
+		reader: reader,
+		writer: pipeWriter,
+		result: make(chan error, 1),
+	}
+
+	go func() {
+		err := digest.Verify(pipeReader)
+		if err != nil {
+			err = DigestMismatchError{err: err}
+		}
+
+		// Fail any further reads right away when the digest cannot be computed
+		pipeReader.CloseWithError(err) //nolint:errcheck
+		r.result <- err
+	}()
+
+	return r
+}
+
+func (r *digestVerifyingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	if n > 0 {
+		_, writeErr := r.writer.Write(p[:n])
+		if writeErr != nil {
+			return n, r.verification()
+		}
+	}
+
+	if err == io.EOF {
+		r.writer.Close() //nolint:errcheck
+
+		if verifyErr := r.verification(); verifyErr != nil {
+			return n, verifyErr
+		}
+	} else if err != nil {
+		r.writer.CloseWithError(err) //nolint:errcheck
+	}
+
+	return n, err
+}
+
+func (r *digestVerifyingReader) verification() error {
+	if !r.verified {
+		r.verifyErr = <-r.result
+		r.verified = true
+	}
+	return r.verifyErr
+}
@@ -0,0 +1,60 @@
+package httpblobprovider_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing/iotest"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+var _ = Describe("DigestVerifyingReader", func() {
+	var (
+		// sha sums for "abc"
+		sha1   = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "a9993e364706816aba3e25717850c26c9cd0d89d")
+		sha512 = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA512, "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f")
+	)
+
+	It("passes the stream through when it matches the digest", func() {
+		read, err := io.ReadAll(NewDigestVerifyingReader(iotest.OneByteReader(strings.NewReader("abc")), boshcrypto.MustNewMultipleDigest(sha1, sha512)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(read)).To(Equal("abc"))
+	})
+
+	It("returns a digest mismatch error at the end of a stream that does not match", func() {
+		_, err := io.ReadAll(NewDigestVerifyingReader(strings.NewReader("abd"), sha1))
+		Expect(err).To(MatchError(ContainSubstring("Expected stream to have digest 'a9993e364706816aba3e25717850c26c9cd0d89d'")))
+
+		var mismatchErr DigestMismatchError
+		Expect(errors.As(err, &mismatchErr)).To(BeTrue())
+	})
+
+	It("keeps returning the mismatch once the stream is exhausted", func() {
+		reader := NewDigestVerifyingReader(strings.NewReader("abd"), sha1)
+
+		_, mismatchErr := io.ReadAll(reader)
+		Expect(mismatchErr).To(HaveOccurred())
+
+		_, err := reader.Read(make([]byte, 1))
+		Expect(err).To(Equal(mismatchErr))
+	})
+
+	It("aborts right away when the digest cannot be computed", func() {
+		unknown := boshcrypto.NewDigest(boshcrypto.NewUnknownAlgorithm("md5"), "abc")
+
+		reader := NewDigestVerifyingReader(strings.NewReader(strings.Repeat("a", 1024)), unknown)
+
+		_, err := reader.Read(make([]byte, 16))
+		Expect(err).To(MatchError(ContainSubstring("md5")))
+	})
+
+	It("returns errors from the underlying reader", func() {
+		_, err := io.ReadAll(NewDigestVerifyingReader(iotest.ErrReader(errors.New("fake-read-error")), sha1))
+		Expect(err).To(MatchError("fake-read-error"))
+	})
+})
@@ -0,0 +1,60 @@
+package gcs
+
+import (
+	"encoding/json"
+	"net/url"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	BlobstoreTypeGCS = "gcs"
+
+	// The gcscli falls back to application default credentials, i.e. the
+	// instance's service account, when no credentials source is given
+	CredentialsSourceDefault = ""
+
+	storageHost = "storage.googleapis.com"
+)
+
+// Config is the subset of the gcscli blobstore options needed to address
+// objects directly from the agent.
+type Config struct {
+	BucketName        string `json:"bucket_name"`
+	CredentialsSource string `json:"credentials_source"`
+	EncryptionKey     string `json:"encryption_key"`
+}
+
+func NewConfigFromOptions(options map[string]interface{}) (Config, error) {
+	var config Config
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Marshalling gcs blobstore options")
+	}
+
+	err = json.Unmarshal(optionsJSON, &config)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Unmarshalling gcs blobstore options")
+	}
+
+	if config.BucketName == "" {
+		return config, bosherr.Error("GCS blobstore options must include bucket_name")
+	}
+
+	return config, nil
+}
+
+// UsesWorkloadIdentity reports whether blobs can be accessed with the
+// instance's service account. Customer supplied encryption keys are left
+// to the gcscli.
+func (c Config) UsesWorkloadIdentity() bool {
+	return c.CredentialsSource == CredentialsSourceDefault && c.EncryptionKey == ""
+}
+
+// ObjectURL addresses objects through the XML API, which accepts plain
+// GET, PUT and DELETE requests on the object path.
+func (c Config) ObjectURL(key string) string {
+	u := url.URL{Scheme: "https", Host: storageHost, Path: "/" + c.BucketName + "/" + key}
+	return u.String()
+}
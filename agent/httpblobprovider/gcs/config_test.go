@@ -0,0 +1,46 @@
+package gcs_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/gcs"
+)
+
+var _ = Describe("Config", func() {
+	It("reads the gcscli options", func() {
+		config, err := gcs.NewConfigFromOptions(map[string]interface{}{
+			"bucket_name":   "bosh-blobs",
+			"storage_class": "REGIONAL",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.BucketName).To(Equal("bosh-blobs"))
+		Expect(config.UsesWorkloadIdentity()).To(BeTrue())
+		Expect(config.ObjectURL("blob-id")).To(Equal("https://storage.googleapis.com/bosh-blobs/blob-id"))
+	})
+
+	It("does not use workload identity with static credentials", func() {
+		config, err := gcs.NewConfigFromOptions(map[string]interface{}{
+			"bucket_name":        "bosh-blobs",
+			"credentials_source": "static",
+			"json_key":           "{}",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.UsesWorkloadIdentity()).To(BeFalse())
+	})
+
+	It("does not use workload identity with customer supplied encryption keys", func() {
+		config, err := gcs.NewConfigFromOptions(map[string]interface{}{
+			"bucket_name":    "bosh-blobs",
+			"encryption_key": "ZmFrZS1rZXk=",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.UsesWorkloadIdentity()).To(BeFalse())
+	})
+
+	It("requires a bucket name", func() {
+		_, err := gcs.NewConfigFromOptions(map[string]interface{}{})
+		Expect(err).To(MatchError(ContainSubstring("bucket_name")))
+	})
+})
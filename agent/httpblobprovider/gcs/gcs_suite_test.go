@@ -0,0 +1,13 @@
+package gcs_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGCS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GCS Suite")
+}
@@ -0,0 +1,35 @@
+package gcs
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// ObjectStore addresses blobs in the configured bucket and authorizes
+// requests for them with the instance's service account token.
+type ObjectStore struct {
+	config Config
+	tokens TokenSource
+}
+
+func NewObjectStore(config Config, tokens TokenSource) ObjectStore {
+	return ObjectStore{config: config, tokens: tokens}
+}
+
+func (s ObjectStore) ObjectURL(blobID string) string {
+	return s.config.ObjectURL(blobID)
+}
+
+func (s ObjectStore) Authorize(method, objectURL string, headers map[string]string) (map[string]string, error) {
+	token, err := s.tokens.Token()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Retrieving GCS access token")
+	}
+
+	authorizedHeaders := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		authorizedHeaders[k] = v
+	}
+	authorizedHeaders["Authorization"] = "Bearer " + token.AccessToken
+
+	return authorizedHeaders, nil
+}
@@ -0,0 +1,49 @@
+package gcs_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/gcs"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/gcs/gcsfakes"
+)
+
+var _ = Describe("ObjectStore", func() {
+	var (
+		fakeTokens *gcsfakes.FakeTokenSource
+		store      gcs.ObjectStore
+	)
+
+	BeforeEach(func() {
+		fakeTokens = &gcsfakes.FakeTokenSource{}
+		fakeTokens.TokenReturns(gcs.Token{AccessToken: "fake-access-token"}, nil)
+
+		store = gcs.NewObjectStore(gcs.Config{BucketName: "bosh-blobs"}, fakeTokens)
+	})
+
+	It("addresses blobs in the configured bucket", func() {
+		Expect(store.ObjectURL("some-blob-id")).To(Equal("https://storage.googleapis.com/bosh-blobs/some-blob-id"))
+	})
+
+	It("authorizes requests with the service account token", func() {
+		headers := map[string]string{"X-Custom": "value"}
+
+		authorized, err := store.Authorize("GET", store.ObjectURL("some-blob-id"), headers)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(authorized).To(Equal(map[string]string{
+			"Authorization": "Bearer fake-access-token",
+			"X-Custom":      "value",
+		}))
+		Expect(headers).NotTo(HaveKey("Authorization"))
+	})
+
+	It("returns an error when no token is available", func() {
+		fakeTokens.TokenReturns(gcs.Token{}, errors.New("fake-token-error"))
+
+		_, err := store.Authorize("GET", store.ObjectURL("some-blob-id"), nil)
+		Expect(err).To(MatchError(ContainSubstring("fake-token-error")))
+	})
+})
@@ -0,0 +1,135 @@
+package gcs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	DefaultMetadataEndpoint = "http://metadata.google.internal"
+
+	// Tokens are refreshed this long before they expire so that requests
+	// authorized right before expiry still reach GCS in time
+	tokenExpiryWindow = 5 * time.Minute
+
+	metadataTimeout   = 5 * time.Second
+	serviceAccountURL = "/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+type Token struct {
+	AccessToken string
+	Expiration  time.Time
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . TokenSource
+
+type TokenSource interface {
+	Token() (Token, error)
+}
+
+// NewDefaultTokenSource fetches access tokens for the instance's service
+// account from the GCE metadata server, honouring GCE_METADATA_HOST like the
+// Google client libraries. Tokens are cached until shortly before they expire.
+func NewDefaultTokenSource(clock clock.Clock) TokenSource {
+	// The metadata server is link-local and must never be reached through
+	// the blobstore proxy
+	metadataClient := &http.Client{Timeout: metadataTimeout, Transport: &http.Transport{}}
+
+	endpoint := DefaultMetadataEndpoint
+	if host := os.Getenv("GCE_METADATA_HOST"); host != "" {
+		endpoint = "http://" + host
+	}
+
+	return NewCachingTokenSource(NewMetadataTokenSource(metadataClient, endpoint, clock), clock)
+}
+
+type metadataTokenSource struct {
+	client   *http.Client
+	endpoint string
+	clock    clock.Clock
+}
+
+func NewMetadataTokenSource(client *http.Client, endpoint string, clock clock.Clock) TokenSource {
+	return metadataTokenSource{client: client, endpoint: strings.TrimSuffix(endpoint, "/"), clock: clock}
+}
+
+func (s metadataTokenSource) Token() (Token, error) {
+	req, err := http.NewRequest(http.MethodGet, s.endpoint+serviceAccountURL, nil) //nolint:noctx
+	if err != nil {
+		return Token{}, bosherr.WrapError(err, "Building metadata token request")
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Token{}, bosherr.WrapError(err, "Fetching service account token")
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Token{}, bosherr.WrapError(err, "Reading service account token")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return Token{}, fmt.Errorf("Fetching service account token, response was %d", resp.StatusCode) //nolint:staticcheck
+	}
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return Token{}, bosherr.WrapError(err, "Unmarshalling service account token")
+	}
+
+	if response.AccessToken == "" {
+		return Token{}, bosherr.Error("Metadata server returned an empty service account token")
+	}
+
+	return Token{
+		AccessToken: response.AccessToken,
+		Expiration:  s.clock.Now().Add(time.Duration(response.ExpiresIn) * time.Second),
+	}, nil
+}
+
+type cachingTokenSource struct {
+	source TokenSource
+	clock  clock.Clock
+
+	lock  sync.Mutex
+	token *Token
+}
+
+func NewCachingTokenSource(source TokenSource, clock clock.Clock) TokenSource {
+	return &cachingTokenSource{source: source, clock: clock}
+}
+
+func (s *cachingTokenSource) Token() (Token, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.token != nil && s.clock.Now().Add(tokenExpiryWindow).Before(s.token.Expiration) {
+		return *s.token, nil
+	}
+
+	token, err := s.source.Token()
+	if err != nil {
+		return Token{}, err
+	}
+
+	s.token = &token
+
+	return token, nil
+}
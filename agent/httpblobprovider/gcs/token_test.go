@@ -0,0 +1,105 @@
+package gcs_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/gcs"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/gcs/gcsfakes"
+)
+
+var _ = Describe("Token sources", func() {
+	var (
+		clock *fakeclock.FakeClock
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	})
+
+	Describe("MetadataTokenSource", func() {
+		var (
+			server      *httptest.Server
+			tokenStatus int
+		)
+
+		BeforeEach(func() {
+			tokenStatus = http.StatusOK
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/computeMetadata/v1/instance/service-accounts/default/token"))
+				Expect(r.Header.Get("Metadata-Flavor")).To(Equal("Google"))
+
+				w.WriteHeader(tokenStatus)
+				w.Write([]byte(`{"access_token": "fake-access-token", "expires_in": 3599, "token_type": "Bearer"}`)) //nolint:errcheck
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("fetches the service account token", func() {
+			token, err := gcs.NewMetadataTokenSource(server.Client(), server.URL, clock).Token()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(token).To(Equal(gcs.Token{
+				AccessToken: "fake-access-token",
+				Expiration:  time.Date(2030, 1, 1, 0, 59, 59, 0, time.UTC),
+			}))
+		})
+
+		It("returns an error when the metadata server fails", func() {
+			tokenStatus = http.StatusNotFound
+
+			_, err := gcs.NewMetadataTokenSource(server.Client(), server.URL, clock).Token()
+			Expect(err).To(MatchError(ContainSubstring("response was 404")))
+		})
+	})
+
+	Describe("CachingTokenSource", func() {
+		var (
+			fakeSource *gcsfakes.FakeTokenSource
+			source     gcs.TokenSource
+		)
+
+		BeforeEach(func() {
+			fakeSource = &gcsfakes.FakeTokenSource{}
+			fakeSource.TokenReturns(gcs.Token{AccessToken: "fake-access-token", Expiration: clock.Now().Add(time.Hour)}, nil)
+
+			source = gcs.NewCachingTokenSource(fakeSource, clock)
+		})
+
+		It("reuses tokens until shortly before they expire", func() {
+			_, err := source.Token()
+			Expect(err).NotTo(HaveOccurred())
+
+			clock.Increment(50 * time.Minute)
+			_, err = source.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeSource.TokenCallCount()).To(Equal(1))
+
+			clock.Increment(6 * time.Minute)
+			_, err = source.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeSource.TokenCallCount()).To(Equal(2))
+		})
+
+		It("does not cache errors", func() {
+			fakeSource.TokenReturnsOnCall(0, gcs.Token{}, errors.New("fake-token-error"))
+
+			_, err := source.Token()
+			Expect(err).To(MatchError("fake-token-error"))
+
+			token, err := source.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("fake-access-token"))
+		})
+	})
+})
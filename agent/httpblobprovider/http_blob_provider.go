@@ -1,21 +1,34 @@
 package httpblobprovider
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
 )
 
+const downloadFilePrefix = "bosh-http-blob-provider-GET"
+
 type HTTPBlobImpl struct {
 	fs               boshsys.FileSystem
 	createAlgorithms []boshcrypto.Algorithm
 	httpClient       *http.Client
+	downloadLimiter  *RateLimiter
+	uploadLimiter    *RateLimiter
+	metrics          *TransferMetrics
+	stagingDir       string
+	diskStats        boshstats.Collector
 }
 
 func NewHTTPBlobImpl(fs boshsys.FileSystem, httpClient *http.Client) *HTTPBlobImpl {
@@ -32,6 +45,24 @@ func NewHTTPBlobImplWithDigestAlgorithms(fs boshsys.FileSystem, httpClient *http
 	}
 }
 
+// NewThrottledHTTPBlobImpl limits the bandwidth used by all downloads and
+// all uploads done through it and records them in metrics. Nil limiters do
+// not limit anything.
+func NewThrottledHTTPBlobImpl(fs boshsys.FileSystem, httpClient *http.Client, downloadLimiter, uploadLimiter *RateLimiter, metrics *TransferMetrics) *HTTPBlobImpl {
+	h := NewHTTPBlobImpl(fs, httpClient)
+	h.downloadLimiter = downloadLimiter
+	h.uploadLimiter = uploadLimiter
+	h.metrics = metrics
+	return h
+}
+
+// UseStagingDir writes downloaded blobs to dir instead of the temp directory
+// and checks that dir has enough free space for a blob before downloading it.
+func (h *HTTPBlobImpl) UseStagingDir(dir string, diskStats boshstats.Collector) {
+	h.stagingDir = dir
+	h.diskStats = diskStats
+}
+
 func (h *HTTPBlobImpl) Upload(signedURL, filepath string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
 	digest, err := boshcrypto.NewMultipleDigestFromPath(filepath, h.fs, h.createAlgorithms)
 	if err != nil {
@@ -50,7 +81,12 @@ func (h *HTTPBlobImpl) Upload(signedURL, filepath string, headers map[string]str
 		return boshcrypto.MultipleDigest{}, err
 	}
 
-	req, err := http.NewRequest("PUT", signedURL, file) //nolint:noctx
+	var body io.Reader = file
+	if h.uploadLimiter != nil {
+		body = throttledReadCloser{Reader: NewThrottledReader(file, h.uploadLimiter), Closer: file}
+	}
+
+	req, err := http.NewRequest("PUT", signedURL, body) //nolint:noctx
 	if err != nil {
 		defer file.Close() //nolint:errcheck
 		return boshcrypto.MultipleDigest{}, err
@@ -65,19 +101,31 @@ func (h *HTTPBlobImpl) Upload(signedURL, filepath string, headers map[string]str
 
 	req.ContentLength = stat.Size()
 
+	start := time.Now()
+
 	resp, err := h.httpClient.Do(req)
+	if err == nil {
+		defer drainAndClose(resp.Body)
+
+		if !isSuccess(resp) {
+			err = StatusError{
+				StatusCode: resp.StatusCode,
+				message:    fmt.Sprintf("Error executing PUT for %s, response was %d", file.Name(), resp.StatusCode),
+			}
+		}
+	}
+
+	h.metrics.RecordTransfer(TransferUpload, signedURL, stat.Size(), time.Since(start), err)
+
 	if err != nil {
 		return boshcrypto.MultipleDigest{}, err
 	}
-	if !isSuccess(resp) {
-		return boshcrypto.MultipleDigest{}, fmt.Errorf("Error executing PUT for %s, response was %d", file.Name(), resp.StatusCode) //nolint:staticcheck
-	}
 
 	return digest, nil
 }
 
 func (h *HTTPBlobImpl) Get(signedURL string, digest boshcrypto.Digest, headers map[string]string) (string, error) {
-	file, err := h.fs.TempFile("bosh-http-blob-provider-GET")
+	file, err := h.createDownloadFile()
 	if err != nil {
 		return "", bosherr.WrapError(err, "Creating temporary file")
 	}
@@ -92,31 +140,134 @@ func (h *HTTPBlobImpl) Get(signedURL string, digest boshcrypto.Digest, headers m
 		req.Header.Set(k, v)
 	}
 
+	start := time.Now()
+
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
+		h.metrics.RecordTransfer(TransferDownload, signedURL, 0, time.Since(start), err)
 		return file.Name(), bosherr.WrapError(err, "Excuting GET request") //nolint:staticcheck
 	}
 
 	if !isSuccess(resp) {
-		return file.Name(), fmt.Errorf("Error executing GET, response was %d", resp.StatusCode) //nolint:staticcheck
+		drainAndClose(resp.Body)
+		err = StatusError{
+			StatusCode: resp.StatusCode,
+			message:    fmt.Sprintf("Error executing GET, response was %d", resp.StatusCode),
+		}
+		h.metrics.RecordTransfer(TransferDownload, signedURL, 0, time.Since(start), err)
+		return file.Name(), err
+	}
+
+	if err = h.checkStagingSpace(resp.ContentLength); err != nil {
+		_ = resp.Body.Close()
+		h.metrics.RecordTransfer(TransferDownload, signedURL, 0, time.Since(start), err)
+		return file.Name(), err
 	}
 
-	_, err = io.Copy(file, resp.Body)
+	body := newResumingReader(h.httpClient, req, resp, h.metrics)
+	defer body.Close() //nolint:errcheck
+
+	// The digest is verified while the blob is written to disk rather than
+	// reading multi-GB blobs back once they have been downloaded
+	written, err := io.Copy(file, NewDigestVerifyingReader(NewThrottledReader(body, h.downloadLimiter), digest))
+	h.metrics.RecordTransfer(TransferDownload, signedURL, written, time.Since(start), err)
 	if err != nil {
+		var mismatchErr DigestMismatchError
+		if errors.As(err, &mismatchErr) {
+			return file.Name(), bosherr.WrapErrorf(err, "Checking downloaded blob digest") //nolint:staticcheck
+		}
 		return file.Name(), bosherr.WrapError(err, "Copying response to tempfile") //nolint:staticcheck
 	}
 
-	_, err = file.Seek(0, io.SeekStart)
+	return file.Name(), nil
+}
+
+func (h *HTTPBlobImpl) Delete(signedURL string, headers map[string]string) error {
+	req, err := http.NewRequest("DELETE", signedURL, nil) //nolint:noctx
 	if err != nil {
-		return file.Name(), bosherr.WrapErrorf(err, "Rewinding file pointer to beginning") //nolint:staticcheck
+		return bosherr.WrapError(err, "Creating Delete Request")
 	}
 
-	err = digest.Verify(file)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.httpClient.Do(req)
 	if err != nil {
-		return file.Name(), bosherr.WrapErrorf(err, "Checking downloaded blob digest") //nolint:staticcheck
+		return bosherr.WrapError(err, "Executing DELETE request")
 	}
+	defer drainAndClose(resp.Body)
 
-	return file.Name(), nil
+	if !isSuccess(resp) {
+		return StatusError{
+			StatusCode: resp.StatusCode,
+			message:    fmt.Sprintf("Error executing DELETE, response was %d", resp.StatusCode),
+		}
+	}
+
+	return nil
+}
+
+func (h *HTTPBlobImpl) createDownloadFile() (boshsys.File, error) {
+	if h.stagingDir == "" {
+		return h.fs.TempFile(downloadFilePrefix)
+	}
+
+	err := h.fs.MkdirAll(h.stagingDir, 0700)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating blob staging directory")
+	}
+
+	path := filepath.Join(h.stagingDir, fmt.Sprintf("%s%d", downloadFilePrefix, rand.Uint32())) //nolint:gosec
+
+	return h.fs.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+}
+
+func (h *HTTPBlobImpl) checkStagingSpace(size int64) error {
+	if h.stagingDir == "" || h.diskStats == nil || size <= 0 {
+		return nil
+	}
+
+	stats, err := h.diskStats.GetDiskStats(h.stagingDir)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking free space in blob staging directory")
+	}
+
+	// Disk usage is collected in KiB
+	free := int64(stats.DiskUsage.Total-stats.DiskUsage.Used) * 1024 //nolint:gosec
+	if free < size {
+		return bosherr.Errorf("Blob staging directory '%s' has %d bytes free but the blob is %d bytes", h.stagingDir, free, size)
+	}
+
+	return nil
+}
+
+// StatusError is returned when a signed URL endpoint responds unsuccessfully.
+type StatusError struct {
+	StatusCode int
+	message    string
+}
+
+func (e StatusError) Error() string {
+	return e.message
+}
+
+// IsSignedURLExpired reports whether err was caused by the endpoint rejecting
+// the signed URL, which is how blobstores respond to expired signatures.
+func IsSignedURLExpired(err error) bool {
+	var statusErr StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusForbidden
+}
+
+// maxDrainedBodySize bounds how much of an unread response body is read
+// before closing it. Bigger bodies are cheaper to drop with their connection.
+const maxDrainedBodySize = 64 * 1024
+
+// drainAndClose reads what is left of a response body before closing it so
+// that the connection is reused for the next transfer instead of being closed
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrainedBodySize))
+	_ = body.Close()
 }
 
 func isSuccess(resp *http.Response) bool {
@@ -2,14 +2,21 @@ package httpblobprovider_test
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"strings"
+	"sync/atomic"
 
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/ghttp"
 
 	. "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
+	fakestats "github.com/cloudfoundry/bosh-agent/v2/platform/stats/fakes"
 
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	"github.com/cloudfoundry/bosh-utils/system"
@@ -70,6 +77,72 @@ var _ = Describe("HTTPBlobImpl", func() {
 			Expect(content).To(Equal([]byte("abc")))
 		})
 
+		It("downloads the file when limiting bandwidth", func() {
+			blobProvider = NewThrottledHTTPBlobImpl(fakeFileSystem, server.HTTPTestServer.Client(), NewRateLimiter(1024, clock.NewClock()), nil, nil)
+
+			server.RouteToHandler("GET", "/success-get-signed-url", ghttp.RespondWith(http.StatusOK, "abc"))
+
+			filepath, err := blobProvider.Get(fmt.Sprintf("%s/success-get-signed-url", server.URL()), multiDigest, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			content, err := fakeFileSystem.ReadFile(filepath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(content).To(Equal([]byte("abc")))
+		})
+
+		It("records downloads in the transfer metrics", func() {
+			metrics := NewTransferMetrics(0, boshlog.NewLogger(boshlog.LevelNone))
+			blobProvider = NewThrottledHTTPBlobImpl(fakeFileSystem, server.HTTPTestServer.Client(), nil, nil, metrics)
+
+			server.RouteToHandler("GET", "/success-get-signed-url", ghttp.RespondWith(http.StatusOK, "abc"))
+
+			_, err := blobProvider.Get(fmt.Sprintf("%s/success-get-signed-url", server.URL()), multiDigest, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			download := metrics.Snapshot().Download
+			Expect(download.Transfers).To(Equal(int64(1)))
+			Expect(download.Failures).To(BeZero())
+			Expect(download.Bytes).To(Equal(int64(3)))
+		})
+
+		Context("with a staging directory", func() {
+			var diskStats *fakestats.FakeCollector
+
+			BeforeEach(func() {
+				diskStats = &fakestats.FakeCollector{DiskStats: map[string]boshstats.DiskStats{
+					"/var/vcap/data/blobs-staging": {DiskUsage: boshstats.Usage{Total: 10, Used: 9}},
+				}}
+				blobProvider.UseStagingDir("/var/vcap/data/blobs-staging", diskStats)
+			})
+
+			It("downloads the file into the staging directory", func() {
+				server.RouteToHandler("GET", "/success-get-signed-url", ghttp.RespondWith(http.StatusOK, "abc"))
+
+				filepath, err := blobProvider.Get(fmt.Sprintf("%s/success-get-signed-url", server.URL()), multiDigest, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(filepath).To(HavePrefix("/var/vcap/data/blobs-staging/bosh-http-blob-provider-GET"))
+
+				content, err := fakeFileSystem.ReadFile(filepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(content).To(Equal([]byte("abc")))
+			})
+
+			It("fails before downloading when the staging directory cannot hold the blob", func() {
+				server.RouteToHandler("GET", "/large-get-signed-url", ghttp.RespondWith(http.StatusOK, strings.Repeat("a", 2048)))
+
+				_, err := blobProvider.Get(fmt.Sprintf("%s/large-get-signed-url", server.URL()), multiDigest, nil)
+				Expect(err).To(MatchError("Blob staging directory '/var/vcap/data/blobs-staging' has 1024 bytes free but the blob is 2048 bytes"))
+			})
+
+			It("fails when the free space cannot be checked", func() {
+				diskStats.DiskStats = map[string]boshstats.DiskStats{}
+				server.RouteToHandler("GET", "/success-get-signed-url", ghttp.RespondWith(http.StatusOK, "abc"))
+
+				_, err := blobProvider.Get(fmt.Sprintf("%s/success-get-signed-url", server.URL()), multiDigest, nil)
+				Expect(err).To(MatchError(ContainSubstring("Checking free space in blob staging directory")))
+			})
+		})
+
 		It("does something when the server responds with a bad status code", func() {
 			server.RouteToHandler("GET", "/bad-get-signed-url",
 				ghttp.CombineHandlers(
@@ -82,6 +155,14 @@ var _ = Describe("HTTPBlobImpl", func() {
 			Expect(err.Error()).ToNot(ContainSubstring(fmt.Sprintf("%s/bad-get-signed-url", server.URL())))
 		})
 
+		It("reports expired signed urls", func() {
+			server.RouteToHandler("GET", "/expired-get-signed-url", ghttp.RespondWith(http.StatusForbidden, ""))
+
+			_, err := blobProvider.Get(fmt.Sprintf("%s/expired-get-signed-url", server.URL()), multiDigest, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(IsSignedURLExpired(err)).To(BeTrue())
+		})
+
 		It("does something when the server responds with an error", func() {
 			disconnectingRequestHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 				conn, _, err := w.(http.Hijacker).Hijack()
@@ -96,6 +177,65 @@ var _ = Describe("HTTPBlobImpl", func() {
 			Expect(err).To(HaveOccurred())
 		})
 
+		Context("when the connection is cut off partway", func() {
+			var (
+				metrics      *TransferMetrics
+				acceptRanges string
+			)
+
+			cutOffHandler := func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Accept-Ranges", acceptRanges)
+				w.Header().Set("ETag", `"fake-etag"`)
+				w.Header().Set("Content-Length", "3")
+				_, _ = w.Write([]byte("a")) //nolint:errcheck
+			}
+
+			BeforeEach(func() {
+				acceptRanges = "bytes"
+
+				fs := system.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+				Expect(fs.ChangeTempRoot(GinkgoT().TempDir())).To(Succeed())
+
+				metrics = NewTransferMetrics(0, boshlog.NewLogger(boshlog.LevelNone))
+				blobProvider = NewThrottledHTTPBlobImpl(fs, server.HTTPTestServer.Client(), nil, nil, metrics)
+			})
+
+			It("resumes the download from the last received byte", func() {
+				server.AppendHandlers(
+					cutOffHandler,
+					ghttp.CombineHandlers(
+						ghttp.VerifyHeader(http.Header{"Range": []string{"bytes=1-"}, "If-Range": []string{`"fake-etag"`}, "key": []string{"value"}}),
+						ghttp.RespondWith(http.StatusPartialContent, "bc", http.Header{"Content-Range": []string{"bytes 1-2/3"}}),
+					),
+				)
+
+				filepath, err := blobProvider.Get(fmt.Sprintf("%s/get-signed-url", server.URL()), multiDigest, map[string]string{"key": "value"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.ReadFile(filepath)).To(Equal([]byte("abc")))
+
+				Expect(metrics.Snapshot().Download.Resumes).To(Equal(int64(1)))
+			})
+
+			It("fails when the server does not send the remaining bytes", func() {
+				server.AppendHandlers(
+					cutOffHandler,
+					ghttp.RespondWith(http.StatusOK, "abc"),
+				)
+
+				_, err := blobProvider.Get(fmt.Sprintf("%s/get-signed-url", server.URL()), multiDigest, nil)
+				Expect(err).To(MatchError(ContainSubstring("resuming the download failed: server responded with 200 instead of the remaining bytes")))
+			})
+
+			It("does not resume when the server does not accept ranges", func() {
+				acceptRanges = ""
+				server.AppendHandlers(cutOffHandler)
+
+				_, err := blobProvider.Get(fmt.Sprintf("%s/get-signed-url", server.URL()), multiDigest, nil)
+				Expect(err).To(MatchError(ContainSubstring("Copying response to tempfile")))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
 		It("errors when content does not match provided digest", func() {
 			server.RouteToHandler("GET", "/success-get-signed-url",
 				ghttp.CombineHandlers(
@@ -108,7 +248,7 @@ var _ = Describe("HTTPBlobImpl", func() {
 			badMultiDigest := boshcrypto.MustNewMultipleDigest(badsha1, badsha512)
 
 			_, err := blobProvider.Get(fmt.Sprintf("%s/success-get-signed-url", server.URL()), badMultiDigest, nil)
-			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ContainSubstring("Checking downloaded blob digest")))
 		})
 	})
 
@@ -142,6 +282,20 @@ var _ = Describe("HTTPBlobImpl", func() {
 			Expect(digest.DigestFor(boshcrypto.DigestAlgorithmSHA512)).To(Equal(sha512))
 		})
 
+		It("uploads the file when limiting bandwidth", func() {
+			blobProvider = NewThrottledHTTPBlobImpl(fakeFileSystem, server.HTTPTestServer.Client(), nil, NewRateLimiter(1024, clock.NewClock()), nil)
+
+			server.RouteToHandler("PUT", "/success-signed-url",
+				ghttp.CombineHandlers(
+					ghttp.VerifyBody([]byte("abc")),
+					ghttp.RespondWith(http.StatusCreated, ``),
+				),
+			)
+
+			_, err := testUpload("/some/path.tgz", fmt.Sprintf("%s/success-signed-url", server.URL()))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		It("does something when the server responds with a bad status code", func() {
 			server.RouteToHandler("PUT", "/bad-status-code",
 				ghttp.CombineHandlers(
@@ -152,9 +306,33 @@ var _ = Describe("HTTPBlobImpl", func() {
 
 			_, err := testUpload("/some/path.tgz", fmt.Sprintf("%s/bad-status-code", server.URL()))
 			Expect(err).To(HaveOccurred())
+			Expect(IsSignedURLExpired(err)).To(BeFalse())
 			Expect(err.Error()).ToNot(ContainSubstring(fmt.Sprintf("%s/bad-status-code", server.URL())))
 		})
 
+		It("records failed uploads in the transfer metrics", func() {
+			metrics := NewTransferMetrics(0, boshlog.NewLogger(boshlog.LevelNone))
+			blobProvider = NewThrottledHTTPBlobImpl(fakeFileSystem, server.HTTPTestServer.Client(), nil, nil, metrics)
+
+			server.RouteToHandler("PUT", "/bad-status-code", ghttp.RespondWith(http.StatusBadRequest, ``))
+
+			_, err := testUpload("/some/path.tgz", fmt.Sprintf("%s/bad-status-code", server.URL()))
+			Expect(err).To(HaveOccurred())
+
+			upload := metrics.Snapshot().Upload
+			Expect(upload.Transfers).To(Equal(int64(1)))
+			Expect(upload.Failures).To(Equal(int64(1)))
+			Expect(upload.Bytes).To(Equal(int64(3)))
+		})
+
+		It("reports expired signed urls", func() {
+			server.RouteToHandler("PUT", "/expired-signed-url", ghttp.RespondWith(http.StatusForbidden, ``))
+
+			_, err := testUpload("/some/path.tgz", fmt.Sprintf("%s/expired-signed-url", server.URL()))
+			Expect(err).To(HaveOccurred())
+			Expect(IsSignedURLExpired(err)).To(BeTrue())
+		})
+
 		It("does something when the server responds with an error", func() {
 			disconnectingRequestHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 				conn, _, err := w.(http.Hijacker).Hijack()
@@ -168,4 +346,98 @@ var _ = Describe("HTTPBlobImpl", func() {
 			Expect(err).To(HaveOccurred())
 		})
 	})
+
+	Describe("Delete", func() {
+		It("deletes the blob with the signed URL and headers", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/delete-signed-url"),
+					ghttp.VerifyHeaderKV("key", "value"),
+					ghttp.RespondWith(http.StatusNoContent, nil),
+				),
+			)
+
+			err := blobProvider.Delete(server.URL()+"/delete-signed-url", map[string]string{"key": "value"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns a status error when deleting is rejected", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, "expired"))
+
+			err := blobProvider.Delete(server.URL()+"/delete-signed-url", nil)
+			Expect(err).To(MatchError("Error executing DELETE, response was 403"))
+			Expect(IsSignedURLExpired(err)).To(BeTrue())
+		})
+	})
+
+	Describe("connection reuse", func() {
+		var newConnections int32
+
+		BeforeEach(func() {
+			server.Close()
+
+			atomic.StoreInt32(&newConnections, 0)
+			server = ghttp.NewUnstartedServer()
+			server.HTTPTestServer.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt32(&newConnections, 1)
+				}
+			}
+			server.Start()
+
+			blobProvider = NewHTTPBlobImpl(fakeFileSystem, server.HTTPTestServer.Client())
+
+			Expect(fakeFileSystem.WriteFileString("/some/path.tgz", "abc")).To(Succeed())
+
+			var err error
+			tempFile, err = fakeFileSystem.OpenFile("fake-file", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+			Expect(err).ToNot(HaveOccurred())
+			fakeFileSystem.ReturnTempFile = tempFile
+		})
+
+		It("reuses the connection for repeated uploads", func() {
+			server.RouteToHandler("PUT", "/success-signed-url",
+				ghttp.CombineHandlers(
+					ghttp.VerifyBody([]byte("abc")),
+					ghttp.RespondWith(http.StatusCreated, "fake-response"),
+				),
+			)
+			server.RouteToHandler("PUT", "/bad-status-code",
+				ghttp.CombineHandlers(
+					ghttp.VerifyBody([]byte("abc")),
+					ghttp.RespondWith(http.StatusBadRequest, "fake-error-response"),
+				),
+			)
+
+			for i := 0; i < 3; i++ {
+				_, err := blobProvider.Upload(fmt.Sprintf("%s/success-signed-url", server.URL()), "/some/path.tgz", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = blobProvider.Upload(fmt.Sprintf("%s/bad-status-code", server.URL()), "/some/path.tgz", nil)
+				Expect(err).To(HaveOccurred())
+			}
+
+			Expect(server.ReceivedRequests()).To(HaveLen(6))
+			Expect(atomic.LoadInt32(&newConnections)).To(Equal(int32(1)))
+		})
+
+		It("reuses the connection for repeated downloads", func() {
+			multiDigest := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "a9993e364706816aba3e25717850c26c9cd0d89d"))
+
+			server.RouteToHandler("GET", "/success-get-signed-url", ghttp.RespondWith(http.StatusOK, "abc"))
+			server.RouteToHandler("GET", "/bad-get-signed-url", ghttp.RespondWith(http.StatusBadRequest, "fake-error-response"))
+
+			for i := 0; i < 3; i++ {
+				_, err := blobProvider.Get(fmt.Sprintf("%s/success-get-signed-url", server.URL()), multiDigest, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = blobProvider.Get(fmt.Sprintf("%s/bad-get-signed-url", server.URL()), multiDigest, nil)
+				Expect(err).To(HaveOccurred())
+			}
+
+			Expect(server.ReceivedRequests()).To(HaveLen(6))
+			Expect(atomic.LoadInt32(&newConnections)).To(Equal(int32(1)))
+		})
+	})
 })
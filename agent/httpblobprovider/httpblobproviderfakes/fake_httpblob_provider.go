@@ -2,6 +2,7 @@
 package httpblobproviderfakes
 
 import (
+	"io"
 	"sync"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
@@ -9,6 +10,18 @@ import (
 )
 
 type FakeHTTPBlobProvider struct {
+	DeleteStub        func(string, map[string]string) error
+	deleteMutex       sync.RWMutex
+	deleteArgsForCall []struct {
+		arg1 string
+		arg2 map[string]string
+	}
+	deleteReturns struct {
+		result1 error
+	}
+	deleteReturnsOnCall map[int]struct {
+		result1 error
+	}
 	GetStub        func(string, crypto.Digest, map[string]string) (string, error)
 	getMutex       sync.RWMutex
 	getArgsForCall []struct {
@@ -39,10 +52,102 @@ type FakeHTTPBlobProvider struct {
 		result1 crypto.MultipleDigest
 		result2 error
 	}
+	UploadMultipartStub        func(httpblobprovider.MultipartUpload, string, map[string]string) (crypto.MultipleDigest, error)
+	uploadMultipartMutex       sync.RWMutex
+	uploadMultipartArgsForCall []struct {
+		arg1 httpblobprovider.MultipartUpload
+		arg2 string
+		arg3 map[string]string
+	}
+	uploadMultipartReturns struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
+	uploadMultipartReturnsOnCall map[int]struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
+	UploadStreamStub        func(string, io.Reader, map[string]string) (crypto.MultipleDigest, error)
+	uploadStreamMutex       sync.RWMutex
+	uploadStreamArgsForCall []struct {
+		arg1 string
+		arg2 io.Reader
+		arg3 map[string]string
+	}
+	uploadStreamReturns struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
+	uploadStreamReturnsOnCall map[int]struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeHTTPBlobProvider) Delete(arg1 string, arg2 map[string]string) error {
+	fake.deleteMutex.Lock()
+	ret, specificReturn := fake.deleteReturnsOnCall[len(fake.deleteArgsForCall)]
+	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
+		arg1 string
+		arg2 map[string]string
+	}{arg1, arg2})
+	stub := fake.DeleteStub
+	fakeReturns := fake.deleteReturns
+	fake.recordInvocation("Delete", []interface{}{arg1, arg2})
+	fake.deleteMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHTTPBlobProvider) DeleteCallCount() int {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return len(fake.deleteArgsForCall)
+}
+
+func (fake *FakeHTTPBlobProvider) DeleteCalls(stub func(string, map[string]string) error) {
+	fake.deleteMutex.Lock()
+	defer fake.deleteMutex.Unlock()
+	fake.DeleteStub = stub
+}
+
+func (fake *FakeHTTPBlobProvider) DeleteArgsForCall(i int) (string, map[string]string) {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	argsForCall := fake.deleteArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeHTTPBlobProvider) DeleteReturns(result1 error) {
+	fake.deleteMutex.Lock()
+	defer fake.deleteMutex.Unlock()
+	fake.DeleteStub = nil
+	fake.deleteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHTTPBlobProvider) DeleteReturnsOnCall(i int, result1 error) {
+	fake.deleteMutex.Lock()
+	defer fake.deleteMutex.Unlock()
+	fake.DeleteStub = nil
+	if fake.deleteReturnsOnCall == nil {
+		fake.deleteReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeHTTPBlobProvider) Get(arg1 string, arg2 crypto.Digest, arg3 map[string]string) (string, error) {
 	fake.getMutex.Lock()
 	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
@@ -175,13 +280,141 @@ func (fake *FakeHTTPBlobProvider) UploadReturnsOnCall(i int, result1 crypto.Mult
 	}{result1, result2}
 }
 
+func (fake *FakeHTTPBlobProvider) UploadMultipart(arg1 httpblobprovider.MultipartUpload, arg2 string, arg3 map[string]string) (crypto.MultipleDigest, error) {
+	fake.uploadMultipartMutex.Lock()
+	ret, specificReturn := fake.uploadMultipartReturnsOnCall[len(fake.uploadMultipartArgsForCall)]
+	fake.uploadMultipartArgsForCall = append(fake.uploadMultipartArgsForCall, struct {
+		arg1 httpblobprovider.MultipartUpload
+		arg2 string
+		arg3 map[string]string
+	}{arg1, arg2, arg3})
+	stub := fake.UploadMultipartStub
+	fakeReturns := fake.uploadMultipartReturns
+	fake.recordInvocation("UploadMultipart", []interface{}{arg1, arg2, arg3})
+	fake.uploadMultipartMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHTTPBlobProvider) UploadMultipartCallCount() int {
+	fake.uploadMultipartMutex.RLock()
+	defer fake.uploadMultipartMutex.RUnlock()
+	return len(fake.uploadMultipartArgsForCall)
+}
+
+func (fake *FakeHTTPBlobProvider) UploadMultipartCalls(stub func(httpblobprovider.MultipartUpload, string, map[string]string) (crypto.MultipleDigest, error)) {
+	fake.uploadMultipartMutex.Lock()
+	defer fake.uploadMultipartMutex.Unlock()
+	fake.UploadMultipartStub = stub
+}
+
+func (fake *FakeHTTPBlobProvider) UploadMultipartArgsForCall(i int) (httpblobprovider.MultipartUpload, string, map[string]string) {
+	fake.uploadMultipartMutex.RLock()
+	defer fake.uploadMultipartMutex.RUnlock()
+	argsForCall := fake.uploadMultipartArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeHTTPBlobProvider) UploadMultipartReturns(result1 crypto.MultipleDigest, result2 error) {
+	fake.uploadMultipartMutex.Lock()
+	defer fake.uploadMultipartMutex.Unlock()
+	fake.UploadMultipartStub = nil
+	fake.uploadMultipartReturns = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHTTPBlobProvider) UploadMultipartReturnsOnCall(i int, result1 crypto.MultipleDigest, result2 error) {
+	fake.uploadMultipartMutex.Lock()
+	defer fake.uploadMultipartMutex.Unlock()
+	fake.UploadMultipartStub = nil
+	if fake.uploadMultipartReturnsOnCall == nil {
+		fake.uploadMultipartReturnsOnCall = make(map[int]struct {
+			result1 crypto.MultipleDigest
+			result2 error
+		})
+	}
+	fake.uploadMultipartReturnsOnCall[i] = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStream(arg1 string, arg2 io.Reader, arg3 map[string]string) (crypto.MultipleDigest, error) {
+	fake.uploadStreamMutex.Lock()
+	ret, specificReturn := fake.uploadStreamReturnsOnCall[len(fake.uploadStreamArgsForCall)]
+	fake.uploadStreamArgsForCall = append(fake.uploadStreamArgsForCall, struct {
+		arg1 string
+		arg2 io.Reader
+		arg3 map[string]string
+	}{arg1, arg2, arg3})
+	stub := fake.UploadStreamStub
+	fakeReturns := fake.uploadStreamReturns
+	fake.recordInvocation("UploadStream", []interface{}{arg1, arg2, arg3})
+	fake.uploadStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStreamCallCount() int {
+	fake.uploadStreamMutex.RLock()
+	defer fake.uploadStreamMutex.RUnlock()
+	return len(fake.uploadStreamArgsForCall)
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStreamCalls(stub func(string, io.Reader, map[string]string) (crypto.MultipleDigest, error)) {
+	fake.uploadStreamMutex.Lock()
+	defer fake.uploadStreamMutex.Unlock()
+	fake.UploadStreamStub = stub
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStreamArgsForCall(i int) (string, io.Reader, map[string]string) {
+	fake.uploadStreamMutex.RLock()
+	defer fake.uploadStreamMutex.RUnlock()
+	argsForCall := fake.uploadStreamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStreamReturns(result1 crypto.MultipleDigest, result2 error) {
+	fake.uploadStreamMutex.Lock()
+	defer fake.uploadStreamMutex.Unlock()
+	fake.UploadStreamStub = nil
+	fake.uploadStreamReturns = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStreamReturnsOnCall(i int, result1 crypto.MultipleDigest, result2 error) {
+	fake.uploadStreamMutex.Lock()
+	defer fake.uploadStreamMutex.Unlock()
+	fake.UploadStreamStub = nil
+	if fake.uploadStreamReturnsOnCall == nil {
+		fake.uploadStreamReturnsOnCall = make(map[int]struct {
+			result1 crypto.MultipleDigest
+			result2 error
+		})
+	}
+	fake.uploadStreamReturnsOnCall[i] = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeHTTPBlobProvider) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.getMutex.RLock()
-	defer fake.getMutex.RUnlock()
-	fake.uploadMutex.RLock()
-	defer fake.uploadMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
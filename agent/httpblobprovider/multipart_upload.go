@@ -0,0 +1,160 @@
+package httpblobprovider
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// MultipartUpload is an S3 compatible multipart upload initiated by the
+// director. It presigns enough part URLs for the largest blob it accepts
+// and a URL to complete the upload with.
+type MultipartUpload struct {
+	PartSignedURLs    []string `json:"part_signed_urls"`
+	PartSize          int64    `json:"part_size"`
+	CompleteSignedURL string   `json:"complete_signed_url"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// UploadMultipart puts the file in parts of the upload's part size and
+// completes the upload with the ETags returned for every part.
+func (h *HTTPBlobImpl) UploadMultipart(upload MultipartUpload, filepath string, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	if upload.PartSize <= 0 {
+		return boshcrypto.MultipleDigest{}, bosherr.Errorf("Multipart upload part size must be positive, got %d", upload.PartSize)
+	}
+
+	if upload.CompleteSignedURL == "" {
+		return boshcrypto.MultipleDigest{}, bosherr.Error("Multipart upload is missing the complete signed URL")
+	}
+
+	digest, err := boshcrypto.NewMultipleDigestFromPath(filepath, h.fs, h.createAlgorithms)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	stat, err := h.fs.Stat(filepath)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	size := stat.Size()
+
+	partCount := int((size + upload.PartSize - 1) / upload.PartSize)
+	if partCount == 0 {
+		partCount = 1
+	}
+
+	if partCount > len(upload.PartSignedURLs) {
+		return boshcrypto.MultipleDigest{}, bosherr.Errorf(
+			"Multipart upload of %d bytes needs %d parts of %d bytes but only %d part URLs were signed",
+			size, partCount, upload.PartSize, len(upload.PartSignedURLs),
+		)
+	}
+
+	file, err := h.fs.OpenFile(filepath, os.O_RDONLY, 0)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	start := time.Now()
+
+	err = h.uploadParts(upload, file, size, partCount, headers)
+
+	h.metrics.RecordTransfer(TransferUpload, upload.CompleteSignedURL, size, time.Since(start), err)
+
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	return digest, nil
+}
+
+func (h *HTTPBlobImpl) uploadParts(upload MultipartUpload, file io.ReaderAt, size int64, partCount int, headers map[string]string) error {
+	complete := completeMultipartUpload{}
+
+	for i := 0; i < partCount; i++ {
+		offset := int64(i) * upload.PartSize
+		length := min(upload.PartSize, size-offset)
+
+		body := NewThrottledReader(io.NewSectionReader(file, offset, length), h.uploadLimiter)
+
+		respHeader, _, err := h.doMultipartRequest(http.MethodPut, upload.PartSignedURLs[i], body, length, headers)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Uploading part %d", i+1)
+		}
+
+		etag := respHeader.Get("ETag")
+		if etag == "" {
+			return bosherr.Errorf("Uploading part %d: response has no ETag", i+1)
+		}
+
+		complete.Parts = append(complete.Parts, completedPart{PartNumber: i + 1, ETag: etag})
+	}
+
+	completeBody, err := xml.Marshal(complete)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling multipart upload completion")
+	}
+
+	_, respBody, err := h.doMultipartRequest(http.MethodPost, upload.CompleteSignedURL, bytes.NewReader(completeBody), int64(len(completeBody)), headers)
+	if err != nil {
+		return bosherr.WrapError(err, "Completing multipart upload")
+	}
+
+	// S3 reports failures to complete an upload in the body of a 200 response
+	if bytes.Contains(respBody, []byte("<Error>")) {
+		return bosherr.Errorf("Completing multipart upload: %s", respBody)
+	}
+
+	return nil
+}
+
+func (h *HTTPBlobImpl) doMultipartRequest(method, signedURL string, body io.Reader, length int64, headers map[string]string) (http.Header, []byte, error) {
+	req, err := http.NewRequest(method, signedURL, body) //nolint:noctx
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	req.ContentLength = length
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !isSuccess(resp) {
+		return nil, nil, StatusError{
+			StatusCode: resp.StatusCode,
+			message:    fmt.Sprintf("Error executing %s, response was %d", method, resp.StatusCode),
+		}
+	}
+
+	return resp.Header, respBody, nil
+}
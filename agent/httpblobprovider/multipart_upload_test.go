@@ -0,0 +1,111 @@
+package httpblobprovider_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+var _ = Describe("UploadMultipart", func() {
+	var (
+		server       *ghttp.Server
+		blobProvider *HTTPBlobImpl
+		blobPath     string
+		upload       MultipartUpload
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		fs := boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		blobProvider = NewHTTPBlobImpl(fs, server.HTTPTestServer.Client())
+
+		blobPath = filepath.Join(GinkgoT().TempDir(), "blob.tgz")
+		Expect(os.WriteFile(blobPath, []byte("abcdefgh"), 0600)).To(Succeed())
+
+		upload = MultipartUpload{
+			PartSignedURLs: []string{
+				fmt.Sprintf("%s/part-1", server.URL()),
+				fmt.Sprintf("%s/part-2", server.URL()),
+				fmt.Sprintf("%s/part-3", server.URL()),
+			},
+			PartSize:          5,
+			CompleteSignedURL: fmt.Sprintf("%s/complete", server.URL()),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("uploads every part and completes the upload with their ETags", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/part-1"),
+				ghttp.VerifyHeaderKV("key", "value"),
+				ghttp.VerifyBody([]byte("abcde")),
+				ghttp.RespondWith(http.StatusOK, "", http.Header{"ETag": []string{`"etag-1"`}}),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/part-2"),
+				ghttp.VerifyBody([]byte("fgh")),
+				ghttp.RespondWith(http.StatusOK, "", http.Header{"ETag": []string{`"etag-2"`}}),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/complete"),
+				ghttp.VerifyBody([]byte(
+					`<CompleteMultipartUpload>`+
+						`<Part><PartNumber>1</PartNumber><ETag>&#34;etag-1&#34;</ETag></Part>`+
+						`<Part><PartNumber>2</PartNumber><ETag>&#34;etag-2&#34;</ETag></Part>`+
+						`</CompleteMultipartUpload>`,
+				)),
+				ghttp.RespondWith(http.StatusOK, "<CompleteMultipartUploadResult/>"),
+			),
+		)
+
+		digest, err := blobProvider.UploadMultipart(upload, blobPath, map[string]string{"key": "value"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.ReceivedRequests()).To(HaveLen(3))
+
+		// sha1 of "abcdefgh"
+		sha1 := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "425af12a0743502b322e93a015bcf868e324d56a")
+		Expect(digest.DigestFor(boshcrypto.DigestAlgorithmSHA1)).To(Equal(sha1))
+	})
+
+	It("returns an error when too few part URLs were signed for the blob", func() {
+		upload.PartSize = 2
+
+		_, err := blobProvider.UploadMultipart(upload, blobPath, nil)
+		Expect(err).To(MatchError(ContainSubstring("needs 4 parts of 2 bytes but only 3 part URLs were signed")))
+		Expect(server.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("returns an error when a part upload fails", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, ""))
+
+		_, err := blobProvider.UploadMultipart(upload, blobPath, nil)
+		Expect(err).To(MatchError(ContainSubstring("Uploading part 1")))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("returns an error when completing the upload reports an error", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(http.StatusOK, "", http.Header{"ETag": []string{`"etag-1"`}}),
+			ghttp.RespondWith(http.StatusOK, "", http.Header{"ETag": []string{`"etag-2"`}}),
+			ghttp.RespondWith(http.StatusOK, "<Error><Code>InternalError</Code></Error>"),
+		)
+
+		_, err := blobProvider.UploadMultipart(upload, blobPath, nil)
+		Expect(err).To(MatchError(ContainSubstring("Completing multipart upload: <Error><Code>InternalError</Code></Error>")))
+	})
+})
@@ -0,0 +1,94 @@
+package httpblobprovider
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+)
+
+const maxThrottledReadSize = 32 * 1024
+
+// RateLimiter paces transfers so that, combined, they do not go above
+// bytesPerSecond. A nil RateLimiter does not limit anything.
+type RateLimiter struct {
+	bytesPerSecond int64
+	clock          clock.Clock
+
+	lock sync.Mutex
+	next time.Time
+}
+
+// NewRateLimiter returns nil when bytesPerSecond is not positive.
+func NewRateLimiter(bytesPerSecond int64, clock clock.Clock) *RateLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	return &RateLimiter{bytesPerSecond: bytesPerSecond, clock: clock}
+}
+
+// Wait accounts for n transferred bytes and blocks until
+// previously accounted bytes are within the limit.
+func (l *RateLimiter) Wait(n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.lock.Lock()
+	now := l.clock.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.bytesPerSecond))
+	l.lock.Unlock()
+
+	if wait > 0 {
+		l.clock.Sleep(wait)
+	}
+}
+
+// readSize keeps individual reads small enough to be paced
+// about ten times per second.
+func (l *RateLimiter) readSize() int {
+	size := l.bytesPerSecond / 10
+	if size < 1 {
+		return 1
+	}
+	if size > maxThrottledReadSize {
+		return maxThrottledReadSize
+	}
+	return int(size)
+}
+
+type throttledReader struct {
+	reader  io.Reader
+	limiter *RateLimiter
+}
+
+// NewThrottledReader limits the speed at which reader can be consumed.
+func NewThrottledReader(reader io.Reader, limiter *RateLimiter) io.Reader {
+	if limiter == nil {
+		return reader
+	}
+
+	return throttledReader{reader: reader, limiter: limiter}
+}
+
+func (r throttledReader) Read(p []byte) (int, error) {
+	if size := r.limiter.readSize(); len(p) > size {
+		p = p[:size]
+	}
+
+	n, err := r.reader.Read(p)
+	r.limiter.Wait(n)
+
+	return n, err
+}
+
+type throttledReadCloser struct {
+	io.Reader
+	io.Closer
+}
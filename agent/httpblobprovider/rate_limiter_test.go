@@ -0,0 +1,57 @@
+package httpblobprovider_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+var _ = Describe("RateLimiter", func() {
+	It("does not limit when the rate is not positive", func() {
+		Expect(NewRateLimiter(0, clock.NewClock())).To(BeNil())
+		Expect(NewRateLimiter(-1, clock.NewClock())).To(BeNil())
+
+		reader := strings.NewReader("abc")
+		Expect(NewThrottledReader(reader, nil)).To(BeIdenticalTo(reader))
+	})
+
+	It("paces reads to the configured rate", func() {
+		limiter := NewRateLimiter(10*1024, clock.NewClock())
+		contents := bytes.Repeat([]byte("a"), 5*1024)
+
+		start := time.Now()
+		read, err := io.ReadAll(NewThrottledReader(bytes.NewReader(contents), limiter))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read).To(Equal(contents))
+
+		// The last chunk is not waited for
+		Expect(time.Since(start)).To(BeNumerically(">=", 400*time.Millisecond))
+	})
+
+	It("shares the rate between readers", func() {
+		limiter := NewRateLimiter(10*1024, clock.NewClock())
+		contents := bytes.Repeat([]byte("a"), 3*1024)
+
+		start := time.Now()
+		done := make(chan struct{}, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				defer GinkgoRecover()
+				_, err := io.ReadAll(NewThrottledReader(bytes.NewReader(contents), limiter))
+				Expect(err).ToNot(HaveOccurred())
+				done <- struct{}{}
+			}()
+		}
+		Eventually(done).Should(Receive())
+		Eventually(done).Should(Receive())
+
+		Expect(time.Since(start)).To(BeNumerically(">=", 400*time.Millisecond))
+	})
+})
@@ -0,0 +1,101 @@
+package httpblobprovider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxDownloadResumes bounds how often a single download is continued after
+// its connection was cut off
+const MaxDownloadResumes = 5
+
+// resumingReader reads a download's response body and, when the connection
+// is cut off partway, continues from the last received byte with a Range
+// request instead of failing. Readers wrapping it see one uninterrupted
+// stream, so digests keep being computed across resumes.
+type resumingReader struct {
+	httpClient *http.Client
+	request    *http.Request
+	body       io.ReadCloser
+	validator  string
+	offset     int64
+	resumes    int
+	metrics    *TransferMetrics
+}
+
+// newResumingReader only resumes downloads from servers that accept byte
+// ranges for the blob.
+func newResumingReader(httpClient *http.Client, request *http.Request, resp *http.Response, metrics *TransferMetrics) io.ReadCloser {
+	if resp.Header.Get("Accept-Ranges") != "bytes" {
+		return resp.Body
+	}
+
+	// If-Range makes the server send the whole blob again if it changed
+	// in the meantime, which then cannot be resumed
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+
+	return &resumingReader{
+		httpClient: httpClient,
+		request:    request,
+		body:       resp.Body,
+		validator:  validator,
+		metrics:    metrics,
+	}
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+
+		if err == nil || errors.Is(err, io.EOF) || r.resumes >= MaxDownloadResumes {
+			return n, err
+		}
+
+		resumeErr := r.resume()
+		if resumeErr != nil {
+			return n, fmt.Errorf("%w (resuming the download failed: %s)", err, resumeErr.Error())
+		}
+
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (r *resumingReader) resume() error {
+	r.resumes++
+	_ = r.body.Close()
+
+	req := r.request.Clone(r.request.Context())
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+	if r.validator != "" {
+		req.Header.Set("If-Range", r.validator)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	contentRange := resp.Header.Get("Content-Range")
+	if resp.StatusCode != http.StatusPartialContent || !strings.HasPrefix(contentRange, fmt.Sprintf("bytes %d-", r.offset)) {
+		drainAndClose(resp.Body)
+		return fmt.Errorf("server responded with %d instead of the remaining bytes", resp.StatusCode)
+	}
+
+	r.body = resp.Body
+	r.metrics.RecordResume(TransferDownload)
+
+	return nil
+}
+
+func (r *resumingReader) Close() error {
+	return r.body.Close()
+}
@@ -0,0 +1,91 @@
+package s3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	BlobstoreTypeS3 = "s3"
+
+	CredentialsSourceEnvOrProfile = "env_or_profile"
+
+	defaultRegion = "us-east-1"
+)
+
+// Config is the subset of the s3cli blobstore options needed to address
+// objects directly from the agent.
+type Config struct {
+	BucketName        string `json:"bucket_name"`
+	Region            string `json:"region"`
+	Host              string `json:"host"`
+	Port              int    `json:"port"`
+	UseSSL            *bool  `json:"use_ssl"`
+	HostStyle         bool   `json:"host_style"`
+	CredentialsSource string `json:"credentials_source"`
+}
+
+func NewConfigFromOptions(options map[string]interface{}) (Config, error) {
+	var config Config
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Marshalling s3 blobstore options")
+	}
+
+	err = json.Unmarshal(optionsJSON, &config)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Unmarshalling s3 blobstore options")
+	}
+
+	if config.BucketName == "" {
+		return config, bosherr.Error("S3 blobstore options must include bucket_name")
+	}
+
+	if config.Region == "" {
+		config.Region = defaultRegion
+	}
+
+	return config, nil
+}
+
+// ObjectURL addresses key path style unless host_style is set, matching
+// the behaviour of the s3cli.
+func (c Config) ObjectURL(key string) string {
+	scheme := "https"
+	if c.UseSSL != nil && !*c.UseSSL {
+		scheme = "http"
+	}
+
+	host := c.Host
+	if host == "" {
+		host = fmt.Sprintf("s3.%s.%s", c.Region, c.domain())
+	}
+	if c.Port > 0 {
+		host = fmt.Sprintf("%s:%d", host, c.Port)
+	}
+
+	path := "/" + c.BucketName + "/" + key
+	if c.HostStyle {
+		host = c.BucketName + "." + host
+		path = "/" + key
+	}
+
+	u := url.URL{Scheme: scheme, Host: host, Path: path}
+	return u.String()
+}
+
+func (c Config) STSEndpoint() string {
+	return fmt.Sprintf("https://sts.%s.%s/", c.Region, c.domain())
+}
+
+func (c Config) domain() string {
+	if strings.HasPrefix(c.Region, "cn-") {
+		return "amazonaws.com.cn"
+	}
+	return "amazonaws.com"
+}
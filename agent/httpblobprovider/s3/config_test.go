@@ -0,0 +1,50 @@
+package s3_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/s3"
+)
+
+var _ = Describe("Config", func() {
+	It("reads the s3cli options", func() {
+		config, err := s3.NewConfigFromOptions(map[string]interface{}{
+			"bucket_name":        "bosh-blobs",
+			"region":             "eu-west-1",
+			"credentials_source": "env_or_profile",
+			"host":               "s3.example.com",
+			"port":               float64(8443),
+			"use_ssl":            false,
+			"host_style":         true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.BucketName).To(Equal("bosh-blobs"))
+		Expect(config.Region).To(Equal("eu-west-1"))
+		Expect(config.CredentialsSource).To(Equal(s3.CredentialsSourceEnvOrProfile))
+		Expect(config.ObjectURL("blob-id")).To(Equal("http://bosh-blobs.s3.example.com:8443/blob-id"))
+	})
+
+	It("defaults to path style urls on the regional AWS endpoint", func() {
+		config, err := s3.NewConfigFromOptions(map[string]interface{}{"bucket_name": "bosh-blobs"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.Region).To(Equal("us-east-1"))
+		Expect(config.ObjectURL("blob-id")).To(Equal("https://s3.us-east-1.amazonaws.com/bosh-blobs/blob-id"))
+		Expect(config.STSEndpoint()).To(Equal("https://sts.us-east-1.amazonaws.com/"))
+	})
+
+	It("uses the China partition endpoints for China regions", func() {
+		config, err := s3.NewConfigFromOptions(map[string]interface{}{"bucket_name": "bosh-blobs", "region": "cn-north-1"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.ObjectURL("blob-id")).To(Equal("https://s3.cn-north-1.amazonaws.com.cn/bosh-blobs/blob-id"))
+		Expect(config.STSEndpoint()).To(Equal("https://sts.cn-north-1.amazonaws.com.cn/"))
+	})
+
+	It("requires a bucket name", func() {
+		_, err := s3.NewConfigFromOptions(map[string]interface{}{"region": "eu-west-1"})
+		Expect(err).To(MatchError(ContainSubstring("bucket_name")))
+	})
+})
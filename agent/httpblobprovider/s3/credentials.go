@@ -0,0 +1,256 @@
+package s3
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	DefaultInstanceMetadataEndpoint = "http://169.254.169.254"
+
+	// Credentials are refreshed this long before they expire so that requests
+	// signed right before expiry still reach S3 in time
+	credentialsExpiryWindow = 5 * time.Minute
+
+	instanceMetadataTimeout = 5 * time.Second
+	imdsTokenTTLSeconds     = "21600"
+	webIdentitySession      = "bosh-agent"
+)
+
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . CredentialsProvider
+
+type CredentialsProvider interface {
+	Retrieve() (Credentials, error)
+}
+
+// NewDefaultCredentialsProvider uses IRSA web identity credentials when the
+// pod identity webhook environment is present and the EC2 instance profile
+// otherwise. Credentials are cached until shortly before they expire.
+func NewDefaultCredentialsProvider(fs boshsys.FileSystem, client *http.Client, config Config, clock clock.Clock) CredentialsProvider {
+	// The metadata service is link-local and must never be reached through
+	// the blobstore proxy
+	metadataClient := &http.Client{Timeout: instanceMetadataTimeout, Transport: &http.Transport{}}
+
+	var provider CredentialsProvider = NewInstanceProfileProvider(metadataClient, DefaultInstanceMetadataEndpoint)
+
+	roleARN := os.Getenv("AWS_ROLE_ARN")
+	tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	if roleARN != "" && tokenFile != "" {
+		provider = NewWebIdentityProvider(fs, client, config.STSEndpoint(), roleARN, tokenFile)
+	}
+
+	return NewCachingProvider(provider, clock)
+}
+
+type instanceProfileProvider struct {
+	client   *http.Client
+	endpoint string
+}
+
+// NewInstanceProfileProvider fetches the instance profile role credentials
+// from the EC2 instance metadata service using IMDSv2 session tokens.
+func NewInstanceProfileProvider(client *http.Client, endpoint string) CredentialsProvider {
+	return instanceProfileProvider{client: client, endpoint: strings.TrimSuffix(endpoint, "/")}
+}
+
+func (p instanceProfileProvider) Retrieve() (Credentials, error) {
+	tokenReq, err := http.NewRequest(http.MethodPut, p.endpoint+"/latest/api/token", nil) //nolint:noctx
+	if err != nil {
+		return Credentials{}, bosherr.WrapError(err, "Building instance metadata token request")
+	}
+	tokenReq.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", imdsTokenTTLSeconds)
+
+	token, err := p.do(tokenReq)
+	if err != nil {
+		return Credentials{}, bosherr.WrapError(err, "Fetching instance metadata token")
+	}
+
+	roles, err := p.get("/latest/meta-data/iam/security-credentials/", string(token))
+	if err != nil {
+		return Credentials{}, bosherr.WrapError(err, "Fetching instance profile role")
+	}
+
+	role := strings.TrimSpace(strings.SplitN(string(roles), "\n", 2)[0])
+	if role == "" {
+		return Credentials{}, bosherr.Error("Instance has no instance profile role")
+	}
+
+	body, err := p.get("/latest/meta-data/iam/security-credentials/"+role, string(token))
+	if err != nil {
+		return Credentials{}, bosherr.WrapErrorf(err, "Fetching credentials for instance profile role '%s'", role)
+	}
+
+	var response struct {
+		Code            string
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string
+		Token           string
+		Expiration      time.Time
+	}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return Credentials{}, bosherr.WrapError(err, "Unmarshalling instance profile credentials")
+	}
+
+	if response.Code != "" && response.Code != "Success" {
+		return Credentials{}, bosherr.Errorf("Instance profile credentials are unavailable: %s", response.Code)
+	}
+
+	return Credentials{
+		AccessKeyID:     response.AccessKeyID,
+		SecretAccessKey: response.SecretAccessKey,
+		SessionToken:    response.Token,
+		Expiration:      response.Expiration,
+	}, nil
+}
+
+func (p instanceProfileProvider) get(path, token string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, p.endpoint+path, nil) //nolint:noctx
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+
+	return p.do(req)
+}
+
+func (p instanceProfileProvider) do(req *http.Request) ([]byte, error) {
+	return doRequest(p.client, req)
+}
+
+type webIdentityProvider struct {
+	fs          boshsys.FileSystem
+	client      *http.Client
+	stsEndpoint string
+	roleARN     string
+	tokenFile   string
+}
+
+// NewWebIdentityProvider exchanges the projected service account token for
+// role credentials through STS AssumeRoleWithWebIdentity (IRSA).
+func NewWebIdentityProvider(fs boshsys.FileSystem, client *http.Client, stsEndpoint, roleARN, tokenFile string) CredentialsProvider {
+	return webIdentityProvider{
+		fs:          fs,
+		client:      client,
+		stsEndpoint: stsEndpoint,
+		roleARN:     roleARN,
+		tokenFile:   tokenFile,
+	}
+}
+
+func (p webIdentityProvider) Retrieve() (Credentials, error) {
+	// The token is rotated on disk, so it is read again for every exchange
+	token, err := p.fs.ReadFileString(p.tokenFile)
+	if err != nil {
+		return Credentials{}, bosherr.WrapErrorf(err, "Reading web identity token file '%s'", p.tokenFile)
+	}
+
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {p.roleARN},
+		"RoleSessionName":  {webIdentitySession},
+		"WebIdentityToken": {strings.TrimSpace(token)},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.stsEndpoint, strings.NewReader(form.Encode())) //nolint:noctx
+	if err != nil {
+		return Credentials{}, bosherr.WrapError(err, "Building AssumeRoleWithWebIdentity request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := doRequest(p.client, req)
+	if err != nil {
+		return Credentials{}, bosherr.WrapErrorf(err, "Assuming role '%s' with web identity", p.roleARN)
+	}
+
+	var response struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+
+	err = xml.Unmarshal(body, &response)
+	if err != nil {
+		return Credentials{}, bosherr.WrapError(err, "Unmarshalling AssumeRoleWithWebIdentity response")
+	}
+
+	return Credentials{
+		AccessKeyID:     response.Credentials.AccessKeyID,
+		SecretAccessKey: response.Credentials.SecretAccessKey,
+		SessionToken:    response.Credentials.SessionToken,
+		Expiration:      response.Credentials.Expiration,
+	}, nil
+}
+
+type cachingProvider struct {
+	provider CredentialsProvider
+	clock    clock.Clock
+
+	lock        sync.Mutex
+	credentials *Credentials
+}
+
+func NewCachingProvider(provider CredentialsProvider, clock clock.Clock) CredentialsProvider {
+	return &cachingProvider{provider: provider, clock: clock}
+}
+
+func (p *cachingProvider) Retrieve() (Credentials, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.credentials != nil && p.clock.Now().Add(credentialsExpiryWindow).Before(p.credentials.Expiration) {
+		return *p.credentials, nil
+	}
+
+	credentials, err := p.provider.Retrieve()
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	p.credentials = &credentials
+
+	return credentials, nil
+}
+
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s responded with %d", req.Method, req.URL.Path, resp.StatusCode)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,190 @@
+package s3_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/s3"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/s3/s3fakes"
+)
+
+var _ = Describe("Credentials providers", func() {
+	Describe("InstanceProfileProvider", func() {
+		var (
+			server      *httptest.Server
+			credsStatus int
+		)
+
+		BeforeEach(func() {
+			credsStatus = http.StatusOK
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal("PUT"))
+				Expect(r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds")).To(Equal("21600"))
+				w.Write([]byte("fake-imds-token")) //nolint:errcheck
+			})
+			mux.HandleFunc("/latest/meta-data/iam/security-credentials/", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header.Get("X-aws-ec2-metadata-token")).To(Equal("fake-imds-token"))
+
+				if r.URL.Path == "/latest/meta-data/iam/security-credentials/" {
+					w.Write([]byte("bosh-role\n")) //nolint:errcheck
+					return
+				}
+
+				Expect(r.URL.Path).To(Equal("/latest/meta-data/iam/security-credentials/bosh-role"))
+				w.WriteHeader(credsStatus)
+				w.Write([]byte(`{
+					"Code": "Success",
+					"AccessKeyId": "fake-access-key",
+					"SecretAccessKey": "fake-secret-key",
+					"Token": "fake-token",
+					"Expiration": "2030-01-02T03:04:05Z"
+				}`)) //nolint:errcheck
+			})
+
+			server = httptest.NewServer(mux)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("fetches the role credentials using an IMDSv2 token", func() {
+			credentials, err := s3.NewInstanceProfileProvider(server.Client(), server.URL).Retrieve()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(credentials).To(Equal(s3.Credentials{
+				AccessKeyID:     "fake-access-key",
+				SecretAccessKey: "fake-secret-key",
+				SessionToken:    "fake-token",
+				Expiration:      time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+			}))
+		})
+
+		It("returns an error when the metadata service fails", func() {
+			credsStatus = http.StatusNotFound
+
+			_, err := s3.NewInstanceProfileProvider(server.Client(), server.URL).Retrieve()
+			Expect(err).To(MatchError(ContainSubstring("Fetching credentials for instance profile role 'bosh-role'")))
+		})
+	})
+
+	Describe("WebIdentityProvider", func() {
+		var (
+			server *httptest.Server
+			fs     *fakesys.FakeFileSystem
+			form   url.Values
+		)
+
+		BeforeEach(func() {
+			fs = fakesys.NewFakeFileSystem()
+			err := fs.WriteFileString("/var/run/secrets/token", "fake-web-identity-token\n")
+			Expect(err).NotTo(HaveOccurred())
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				form, err = url.ParseQuery(string(body))
+				Expect(err).NotTo(HaveOccurred())
+
+				w.Write([]byte(`<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>fake-access-key</AccessKeyId>
+      <SecretAccessKey>fake-secret-key</SecretAccessKey>
+      <SessionToken>fake-token</SessionToken>
+      <Expiration>2030-01-02T03:04:05Z</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`)) //nolint:errcheck
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("exchanges the web identity token for role credentials", func() {
+			provider := s3.NewWebIdentityProvider(fs, server.Client(), server.URL, "arn:aws:iam::123456789012:role/bosh", "/var/run/secrets/token")
+
+			credentials, err := provider.Retrieve()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(form.Get("Action")).To(Equal("AssumeRoleWithWebIdentity"))
+			Expect(form.Get("RoleArn")).To(Equal("arn:aws:iam::123456789012:role/bosh"))
+			Expect(form.Get("WebIdentityToken")).To(Equal("fake-web-identity-token"))
+
+			Expect(credentials).To(Equal(s3.Credentials{
+				AccessKeyID:     "fake-access-key",
+				SecretAccessKey: "fake-secret-key",
+				SessionToken:    "fake-token",
+				Expiration:      time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+			}))
+		})
+
+		It("returns an error when the token file cannot be read", func() {
+			provider := s3.NewWebIdentityProvider(fs, server.Client(), server.URL, "arn:aws:iam::123456789012:role/bosh", "/missing")
+
+			_, err := provider.Retrieve()
+			Expect(err).To(MatchError(ContainSubstring("Reading web identity token file '/missing'")))
+		})
+	})
+
+	Describe("CachingProvider", func() {
+		var (
+			delegate *s3fakes.FakeCredentialsProvider
+			clock    *fakeclock.FakeClock
+			provider s3.CredentialsProvider
+		)
+
+		BeforeEach(func() {
+			delegate = &s3fakes.FakeCredentialsProvider{}
+			clock = fakeclock.NewFakeClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+			delegate.RetrieveReturns(s3.Credentials{AccessKeyID: "first", Expiration: clock.Now().Add(time.Hour)}, nil)
+
+			provider = s3.NewCachingProvider(delegate, clock)
+		})
+
+		It("reuses credentials until shortly before they expire", func() {
+			credentials, err := provider.Retrieve()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(credentials.AccessKeyID).To(Equal("first"))
+
+			clock.Increment(50 * time.Minute)
+			_, err = provider.Retrieve()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(delegate.RetrieveCallCount()).To(Equal(1))
+
+			delegate.RetrieveReturns(s3.Credentials{AccessKeyID: "second", Expiration: clock.Now().Add(time.Hour)}, nil)
+
+			clock.Increment(6 * time.Minute)
+			credentials, err = provider.Retrieve()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(credentials.AccessKeyID).To(Equal("second"))
+			Expect(delegate.RetrieveCallCount()).To(Equal(2))
+		})
+
+		It("does not cache errors", func() {
+			delegate.RetrieveReturns(s3.Credentials{}, errors.New("fake-error"))
+
+			_, err := provider.Retrieve()
+			Expect(err).To(MatchError("fake-error"))
+
+			_, err = provider.Retrieve()
+			Expect(err).To(MatchError("fake-error"))
+			Expect(delegate.RetrieveCallCount()).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,36 @@
+package s3
+
+import (
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// ObjectStore addresses blobs in the configured bucket and signs requests for
+// them with the instance's own IAM credentials.
+type ObjectStore struct {
+	config      Config
+	credentials CredentialsProvider
+	clock       clock.Clock
+}
+
+func NewObjectStore(config Config, credentials CredentialsProvider, clock clock.Clock) ObjectStore {
+	return ObjectStore{config: config, credentials: credentials, clock: clock}
+}
+
+func (s ObjectStore) ObjectURL(blobID string) string {
+	return s.config.ObjectURL(blobID)
+}
+
+func (s ObjectStore) Authorize(method, objectURL string, headers map[string]string) (map[string]string, error) {
+	credentials, err := s.credentials.Retrieve()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Retrieving S3 credentials")
+	}
+
+	signedHeaders, err := SignV4(method, objectURL, headers, UnsignedPayload, credentials, s.config.Region, s.clock.Now())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Signing S3 request")
+	}
+
+	return signedHeaders, nil
+}
@@ -0,0 +1,58 @@
+package s3_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/s3"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/s3/s3fakes"
+)
+
+var _ = Describe("ObjectStore", func() {
+	var (
+		fakeCredentials *s3fakes.FakeCredentialsProvider
+		store           s3.ObjectStore
+	)
+
+	BeforeEach(func() {
+		fakeCredentials = &s3fakes.FakeCredentialsProvider{}
+		fakeCredentials.RetrieveReturns(s3.Credentials{
+			AccessKeyID:     "fake-access-key",
+			SecretAccessKey: "fake-secret-key",
+			SessionToken:    "fake-token",
+		}, nil)
+
+		store = s3.NewObjectStore(
+			s3.Config{BucketName: "bosh-blobs", Region: "eu-west-1"},
+			fakeCredentials,
+			fakeclock.NewFakeClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)),
+		)
+	})
+
+	It("addresses blobs in the configured bucket", func() {
+		Expect(store.ObjectURL("some-blob-id")).To(Equal("https://s3.eu-west-1.amazonaws.com/bosh-blobs/some-blob-id"))
+	})
+
+	It("signs requests with the retrieved credentials", func() {
+		headers, err := store.Authorize("GET", store.ObjectURL("some-blob-id"), map[string]string{"X-Amz-Server-Side-Encryption": "AES256"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(headers).To(HaveKeyWithValue("X-Amz-Date", "20300101T000000Z"))
+		Expect(headers).To(HaveKeyWithValue("X-Amz-Security-Token", "fake-token"))
+		Expect(headers).To(HaveKeyWithValue("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD"))
+		Expect(headers).To(HaveKeyWithValue("X-Amz-Server-Side-Encryption", "AES256"))
+		Expect(headers["Authorization"]).To(HavePrefix("AWS4-HMAC-SHA256 Credential=fake-access-key/20300101/eu-west-1/s3/aws4_request, "))
+		Expect(headers["Authorization"]).To(ContainSubstring("x-amz-server-side-encryption"))
+	})
+
+	It("returns an error when credentials are unavailable", func() {
+		fakeCredentials.RetrieveReturns(s3.Credentials{}, errors.New("fake-credentials-error"))
+
+		_, err := store.Authorize("GET", store.ObjectURL("some-blob-id"), nil)
+		Expect(err).To(MatchError(ContainSubstring("fake-credentials-error")))
+	})
+})
@@ -0,0 +1,171 @@
+package httpblobprovider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// OpenStream opens a blob that is produced while it is uploaded. Retried
+// uploads open it again since a stream can only be read once.
+type OpenStream func() (io.ReadCloser, error)
+
+// UploadStream puts body with chunked transfer encoding since its size is
+// not known up front, and computes its digest while uploading it. Endpoints
+// that need to know the size reject the upload, see IsStreamingRejected.
+func (h *HTTPBlobImpl) UploadStream(signedURL string, body io.Reader, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	digester := newDigestingReader(body, h.createAlgorithms)
+
+	req, err := http.NewRequest("PUT", signedURL, io.NopCloser(NewThrottledReader(digester, h.uploadLimiter))) //nolint:noctx
+	if err != nil {
+		digester.finish() //nolint:errcheck
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("Expect", "100-continue")
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	req.ContentLength = -1
+
+	start := time.Now()
+
+	resp, err := h.httpClient.Do(req)
+	if err == nil {
+		defer drainAndClose(resp.Body)
+
+		if !isSuccess(resp) {
+			err = StatusError{
+				StatusCode: resp.StatusCode,
+				message:    fmt.Sprintf("Error executing streaming PUT, response was %d", resp.StatusCode),
+			}
+		}
+	}
+
+	digest, digestErr := digester.finish()
+
+	h.metrics.RecordTransfer(TransferUpload, signedURL, digester.bytesRead(), time.Since(start), err)
+
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	if digestErr != nil {
+		return boshcrypto.MultipleDigest{}, bosherr.WrapError(digestErr, "Computing digest of streamed blob")
+	}
+
+	return digest, nil
+}
+
+// IsStreamingRejected reports whether err was caused by the endpoint
+// refusing an upload without a content length, like S3 signed URLs do.
+func IsStreamingRejected(err error) bool {
+	var statusErr StatusError
+	return errors.As(err, &statusErr) &&
+		(statusErr.StatusCode == http.StatusLengthRequired || statusErr.StatusCode == http.StatusNotImplemented)
+}
+
+type digestResult struct {
+	digest boshcrypto.Digest
+	err    error
+}
+
+// digestingReader feeds everything read from reader to a digest per
+// algorithm. The HTTP transport may still read the body after the request
+// returned, so reads and finishing are serialized.
+type digestingReader struct {
+	reader  io.Reader
+	writers []*io.PipeWriter
+	results []chan digestResult
+
+	lock     sync.Mutex
+	read     int64
+	finished bool
+}
+
+func newDigestingReader(reader io.Reader, algorithms []boshcrypto.Algorithm) *digestingReader {
+	r := &digestingReader{reader: reader}
+
+	for _, algorithm := range algorithms {
+		pipeReader, pipeWriter := io.Pipe()
+		result := make(chan digestResult, 1)
+
+		go func(algorithm boshcrypto.Algorithm) {
+			digest, err := algorithm.CreateDigest(pipeReader)
+			pipeReader.CloseWithError(err) //nolint:errcheck
+			result <- digestResult{digest: digest, err: err}
+		}(algorithm)
+
+		r.writers = append(r.writers, pipeWriter)
+		r.results = append(r.results, result)
+	}
+
+	return r
+}
+
+func (r *digestingReader) Read(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.finished {
+		return 0, io.ErrClosedPipe
+	}
+
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	for _, writer := range r.writers {
+		if _, writeErr := writer.Write(p[:n]); writeErr != nil {
+			return n, writeErr
+		}
+	}
+
+	return n, err
+}
+
+func (r *digestingReader) bytesRead() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.read
+}
+
+// finish returns the digest of everything read so far
+func (r *digestingReader) finish() (boshcrypto.MultipleDigest, error) {
+	r.lock.Lock()
+	r.finished = true
+	r.lock.Unlock()
+
+	for _, writer := range r.writers {
+		writer.Close() //nolint:errcheck
+	}
+
+	var (
+		digests []boshcrypto.Digest
+		err     error
+	)
+
+	for _, result := range r.results {
+		res := <-result
+		if res.err != nil {
+			err = res.err
+			continue
+		}
+		digests = append(digests, res.digest)
+	}
+
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	return boshcrypto.MustNewMultipleDigest(digests...), nil
+}
@@ -0,0 +1,109 @@
+package httpblobprovider_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/clock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("fake-read-err")
+}
+
+var _ = Describe("UploadStream", func() {
+	var (
+		server       *ghttp.Server
+		blobProvider *HTTPBlobImpl
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		blobProvider = NewHTTPBlobImpl(fakesys.NewFakeFileSystem(), server.HTTPTestServer.Client())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("uploads the stream without a content length and computes its digest", func() {
+		server.RouteToHandler("PUT", "/stream",
+			ghttp.CombineHandlers(
+				ghttp.VerifyHeader(http.Header{"key": []string{"value"}}),
+				ghttp.VerifyBody([]byte("abc")),
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.ContentLength).To(Equal(int64(-1)))
+					Expect(r.TransferEncoding).To(ConsistOf("chunked"))
+					w.WriteHeader(http.StatusCreated)
+				}),
+			),
+		)
+
+		digest, err := blobProvider.UploadStream(fmt.Sprintf("%s/stream", server.URL()), strings.NewReader("abc"), map[string]string{"key": "value"})
+		Expect(err).NotTo(HaveOccurred())
+
+		// sha sums for "abc", the contents of the stream
+		sha1 := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "a9993e364706816aba3e25717850c26c9cd0d89d")
+		sha512 := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA512, "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f")
+		Expect(digest.DigestFor(boshcrypto.DigestAlgorithmSHA1)).To(Equal(sha1))
+		Expect(digest.DigestFor(boshcrypto.DigestAlgorithmSHA512)).To(Equal(sha512))
+	})
+
+	It("limits the bandwidth and records the upload in the transfer metrics", func() {
+		metrics := NewTransferMetrics(0, boshlog.NewLogger(boshlog.LevelNone))
+		blobProvider = NewThrottledHTTPBlobImpl(fakesys.NewFakeFileSystem(), server.HTTPTestServer.Client(), nil, NewRateLimiter(1024, clock.NewClock()), metrics)
+
+		server.RouteToHandler("PUT", "/stream", ghttp.CombineHandlers(
+			ghttp.VerifyBody([]byte("abc")),
+			ghttp.RespondWith(http.StatusCreated, ``),
+		))
+
+		_, err := blobProvider.UploadStream(fmt.Sprintf("%s/stream", server.URL()), strings.NewReader("abc"), nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		upload := metrics.Snapshot().Upload
+		Expect(upload.Transfers).To(Equal(int64(1)))
+		Expect(upload.Bytes).To(Equal(int64(3)))
+	})
+
+	It("reports endpoints that need a content length", func() {
+		server.RouteToHandler("PUT", "/stream", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(io.Discard, r.Body) //nolint:errcheck
+			w.WriteHeader(http.StatusLengthRequired)
+		})
+
+		_, err := blobProvider.UploadStream(fmt.Sprintf("%s/stream", server.URL()), strings.NewReader("abc"), nil)
+		Expect(err).To(HaveOccurred())
+		Expect(IsStreamingRejected(err)).To(BeTrue())
+		Expect(IsSignedURLExpired(err)).To(BeFalse())
+	})
+
+	It("reports expired signed urls", func() {
+		server.RouteToHandler("PUT", "/stream", ghttp.RespondWith(http.StatusForbidden, ``))
+
+		_, err := blobProvider.UploadStream(fmt.Sprintf("%s/stream", server.URL()), strings.NewReader("abc"), nil)
+		Expect(err).To(HaveOccurred())
+		Expect(IsSignedURLExpired(err)).To(BeTrue())
+		Expect(IsStreamingRejected(err)).To(BeFalse())
+	})
+
+	It("fails when the stream cannot be read", func() {
+		server.RouteToHandler("PUT", "/stream", ghttp.RespondWith(http.StatusCreated, ``))
+
+		_, err := blobProvider.UploadStream(fmt.Sprintf("%s/stream", server.URL()), failingReader{}, nil)
+		Expect(err).To(MatchError(ContainSubstring("fake-read-err")))
+	})
+})
@@ -0,0 +1,167 @@
+package httpblobprovider
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	TransferDownload = "download"
+	TransferUpload   = "upload"
+
+	transferMetricsLogTag = "BlobTransferMetrics"
+
+	// Throughput of smaller blobs is dominated by request latency
+	slowTransferMinBytes = 1024 * 1024
+)
+
+// TransferStats accumulate over the lifetime of the agent.
+type TransferStats struct {
+	Transfers  int64 `json:"transfers"`
+	Failures   int64 `json:"failures"`
+	Retries    int64 `json:"retries"`
+	Fallbacks  int64 `json:"fallbacks"`
+	Resumes    int64 `json:"resumes"`
+	Bytes      int64 `json:"bytes"`
+	DurationMS int64 `json:"duration_ms"`
+
+	// Throughput of the most recent successful transfer
+	LastBytesPerSecond int64 `json:"last_bytes_per_second"`
+}
+
+type TransferMetricsSnapshot struct {
+	Download TransferStats `json:"download"`
+	Upload   TransferStats `json:"upload"`
+
+	// Signed URLs keep failing and blobs are fetched with the blobstore
+	// client instead
+	SignedURLDegraded bool `json:"signed_url_degraded"`
+}
+
+// TransferMetrics records blob transfers and warns about transfers slower
+// than the configured throughput. Nil metrics record nothing.
+type TransferMetrics struct {
+	slowBytesPerSecond int64
+	logger             boshlog.Logger
+
+	lock     sync.Mutex
+	download TransferStats
+	upload   TransferStats
+	degraded bool
+}
+
+// NewTransferMetrics does not warn about slow transfers when
+// slowBytesPerSecond is not positive.
+func NewTransferMetrics(slowBytesPerSecond int64, logger boshlog.Logger) *TransferMetrics {
+	return &TransferMetrics{slowBytesPerSecond: slowBytesPerSecond, logger: logger}
+}
+
+func (m *TransferMetrics) RecordTransfer(direction, transferURL string, bytes int64, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	var throughput int64
+	if duration > 0 {
+		throughput = int64(float64(bytes) / duration.Seconds())
+	}
+
+	m.lock.Lock()
+	stats := m.stats(direction)
+	stats.Transfers++
+	stats.Bytes += bytes
+	stats.DurationMS += duration.Milliseconds()
+	if err != nil {
+		stats.Failures++
+	} else {
+		stats.LastBytesPerSecond = throughput
+	}
+	m.lock.Unlock()
+
+	if err == nil && m.slowBytesPerSecond > 0 && bytes >= slowTransferMinBytes && throughput < m.slowBytesPerSecond {
+		m.logger.Warn(transferMetricsLogTag,
+			"Slow blob transfer: direction=%s url=%s bytes=%d duration_ms=%d bytes_per_second=%d threshold_bytes_per_second=%d",
+			direction, redactURL(transferURL), bytes, duration.Milliseconds(), throughput, m.slowBytesPerSecond,
+		)
+	}
+}
+
+func (m *TransferMetrics) RecordRetry(direction string) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stats(direction).Retries++
+}
+
+// RecordFallback counts a transfer that bypassed its signed URL.
+func (m *TransferMetrics) RecordFallback(direction string) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stats(direction).Fallbacks++
+}
+
+// RecordResume counts a transfer continued where it was cut off.
+func (m *TransferMetrics) RecordResume(direction string) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stats(direction).Resumes++
+}
+
+func (m *TransferMetrics) SetSignedURLDegraded(degraded bool) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.degraded = degraded
+}
+
+func (m *TransferMetrics) Snapshot() TransferMetricsSnapshot {
+	if m == nil {
+		return TransferMetricsSnapshot{}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return TransferMetricsSnapshot{Download: m.download, Upload: m.upload, SignedURLDegraded: m.degraded}
+}
+
+func (m *TransferMetrics) stats(direction string) *TransferStats {
+	if direction == TransferUpload {
+		return &m.upload
+	}
+	return &m.download
+}
+
+// Signed URLs carry their credentials in the query
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<unparseable>"
+	}
+
+	u.RawQuery = ""
+	u.User = nil
+
+	return u.String()
+}
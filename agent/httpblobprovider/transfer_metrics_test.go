@@ -0,0 +1,97 @@
+package httpblobprovider_test
+
+import (
+	"errors"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+)
+
+var _ = Describe("TransferMetrics", func() {
+	var (
+		logBuffer *gbytes.Buffer
+		metrics   *TransferMetrics
+	)
+
+	BeforeEach(func() {
+		logBuffer = gbytes.NewBuffer()
+		metrics = NewTransferMetrics(1024*1024, boshlog.NewWriterLogger(boshlog.LevelWarn, logBuffer))
+	})
+
+	It("accumulates transfers per direction", func() {
+		metrics.RecordTransfer(TransferDownload, "https://blobs.example.com/a", 4096, 2*time.Second, nil)
+		metrics.RecordTransfer(TransferDownload, "https://blobs.example.com/b", 0, time.Second, errors.New("fake-err"))
+		metrics.RecordRetry(TransferDownload)
+		metrics.RecordTransfer(TransferUpload, "https://blobs.example.com/c", 1000, 500*time.Millisecond, nil)
+
+		Expect(metrics.Snapshot()).To(Equal(TransferMetricsSnapshot{
+			Download: TransferStats{
+				Transfers:          2,
+				Failures:           1,
+				Retries:            1,
+				Bytes:              4096,
+				DurationMS:         3000,
+				LastBytesPerSecond: 2048,
+			},
+			Upload: TransferStats{
+				Transfers:          1,
+				Bytes:              1000,
+				DurationMS:         500,
+				LastBytesPerSecond: 2000,
+			},
+		}))
+	})
+
+	It("records signed url fallbacks and degradation", func() {
+		metrics.RecordFallback(TransferDownload)
+		metrics.SetSignedURLDegraded(true)
+
+		snapshot := metrics.Snapshot()
+		Expect(snapshot.Download.Fallbacks).To(Equal(int64(1)))
+		Expect(snapshot.SignedURLDegraded).To(BeTrue())
+
+		metrics.SetSignedURLDegraded(false)
+		Expect(metrics.Snapshot().SignedURLDegraded).To(BeFalse())
+	})
+
+	It("records resumed transfers", func() {
+		metrics.RecordResume(TransferDownload)
+		Expect(metrics.Snapshot().Download.Resumes).To(Equal(int64(1)))
+	})
+
+	It("warns about large transfers below the throughput threshold without logging signed url queries", func() {
+		metrics.RecordTransfer(TransferDownload, "https://blobs.example.com/blob?X-Amz-Signature=secret", 4*1024*1024, 8*time.Second, nil)
+
+		Expect(logBuffer).To(gbytes.Say(`Slow blob transfer: direction=download url=https://blobs.example.com/blob bytes=4194304 duration_ms=8000 bytes_per_second=524288 threshold_bytes_per_second=1048576`))
+		Expect(string(logBuffer.Contents())).NotTo(ContainSubstring("secret"))
+	})
+
+	It("does not warn about fast or small transfers", func() {
+		metrics.RecordTransfer(TransferUpload, "https://blobs.example.com/fast", 4*1024*1024, time.Second, nil)
+		metrics.RecordTransfer(TransferUpload, "https://blobs.example.com/small", 1024, time.Second, nil)
+
+		Expect(logBuffer.Contents()).To(BeEmpty())
+	})
+
+	It("does not warn when no threshold is configured", func() {
+		metrics = NewTransferMetrics(0, boshlog.NewWriterLogger(boshlog.LevelWarn, logBuffer))
+		metrics.RecordTransfer(TransferUpload, "https://blobs.example.com/slow", 4*1024*1024, time.Hour, nil)
+
+		Expect(logBuffer.Contents()).To(BeEmpty())
+	})
+
+	It("records nothing when nil", func() {
+		var nilMetrics *TransferMetrics
+
+		nilMetrics.RecordTransfer(TransferDownload, "https://blobs.example.com/a", 1, time.Second, nil)
+		nilMetrics.RecordRetry(TransferDownload)
+		nilMetrics.RecordFallback(TransferDownload)
+		nilMetrics.SetSignedURLDegraded(true)
+		Expect(nilMetrics.Snapshot()).To(Equal(TransferMetricsSnapshot{}))
+	})
+})
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"sync"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
+)
+
+// maxIdempotencyKeys bounds how many responses are remembered. The director
+// only retries requests shortly after losing their response.
+const maxIdempotencyKeys = 1000
+
+// idempotencyCache remembers the responses to requests that carry an
+// idempotency key so that retried requests do not run their action again.
+type idempotencyCache struct {
+	lock     sync.Mutex
+	requests map[string]*idempotentRequest
+
+	// Keys in the order they were first seen, oldest first
+	keys []string
+}
+
+type idempotentRequest struct {
+	key    string
+	method string
+
+	// Closed once response is set
+	done     chan struct{}
+	response boshhandler.Response
+
+	// Task started by an asynchronous action
+	taskID string
+}
+
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{requests: map[string]*idempotentRequest{}}
+}
+
+// begin returns the earlier request with the same key and true. Otherwise it
+// records the request as in progress until it is passed to finish.
+func (c *idempotencyCache) begin(key, method string) (*idempotentRequest, bool) {
+	if key == "" {
+		return nil, false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if request, found := c.requests[key]; found {
+		return request, true
+	}
+
+	request := &idempotentRequest{key: key, method: method, done: make(chan struct{})}
+	c.add(request)
+
+	return request, false
+}
+
+// finish records the response to a request. Requests that were rejected
+// before running their action are forgotten so that retries run it.
+func (c *idempotencyCache) finish(request *idempotentRequest, response boshhandler.Response, taskID string, ran bool) {
+	if request == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	request.response = response
+	request.taskID = taskID
+	close(request.done)
+
+	if !ran && c.requests[request.key] == request {
+		c.remove(request.key)
+	}
+}
+
+// restore records a task that was started before the agent restarted.
+func (c *idempotencyCache) restore(key, method, taskID string) {
+	if key == "" {
+		return
+	}
+
+	request := &idempotentRequest{
+		key:      key,
+		method:   method,
+		done:     make(chan struct{}),
+		response: boshhandler.NewValueResponse(boshtask.StateValue{AgentTaskID: taskID, State: boshtask.StateRunning}),
+		taskID:   taskID,
+	}
+	close(request.done)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.add(request)
+}
+
+func (c *idempotencyCache) add(request *idempotentRequest) {
+	c.requests[request.key] = request
+	c.keys = append(c.keys, request.key)
+
+	if len(c.keys) > maxIdempotencyKeys {
+		delete(c.requests, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+}
+
+func (c *idempotencyCache) remove(key string) {
+	delete(c.requests, key)
+
+	for i, k := range c.keys {
+		if k == key {
+			c.keys = append(c.keys[:i], c.keys[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package linkverifier_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLinkVerifier(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Link Verifier Suite")
+}
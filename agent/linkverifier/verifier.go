@@ -0,0 +1,100 @@
+package linkverifier
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+)
+
+const (
+	DefaultTimeout = 5 * time.Second
+
+	logTag = "linkVerifier"
+)
+
+// Result describes one link address that could not be reached from this
+// instance.
+type Result struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Port    int    `json:"port,omitempty"`
+	Error   string `json:"error"`
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Verifier
+
+type Verifier interface {
+	// Verify resolves each address and, when a port is known, opens a TCP
+	// connection to it. Only failures are returned.
+	Verify(links []boshas.LinkAddressSpec) []Result
+}
+
+type verifier struct {
+	timeout time.Duration
+	logger  boshlog.Logger
+}
+
+func NewVerifier(timeout time.Duration, logger boshlog.Logger) Verifier {
+	return verifier{timeout: timeout, logger: logger}
+}
+
+func (v verifier) Verify(links []boshas.LinkAddressSpec) []Result {
+	errs := make([]error, len(links))
+
+	var wg sync.WaitGroup
+	for i, link := range links {
+		wg.Add(1)
+		go func(i int, link boshas.LinkAddressSpec) {
+			defer wg.Done()
+			errs[i] = v.verify(link)
+		}(i, link)
+	}
+	wg.Wait()
+
+	var failures []Result
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		v.logger.Warn(logTag, "Link '%s' address '%s' is unreachable: %s", links[i].Name, links[i].Address, err.Error())
+
+		failures = append(failures, Result{
+			Name:    links[i].Name,
+			Address: links[i].Address,
+			Port:    links[i].Port,
+			Error:   err.Error(),
+		})
+	}
+
+	return failures
+}
+
+func (v verifier) verify(link boshas.LinkAddressSpec) error {
+	ctx, cancel := context.WithTimeout(context.Background(), v.timeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, link.Address)
+	if err != nil {
+		return err
+	}
+
+	if link.Port == 0 {
+		return nil
+	}
+
+	// Probe the first resolved address, matching what most clients would use
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(addrs[0], strconv.Itoa(link.Port)))
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
@@ -0,0 +1,73 @@
+package linkverifier_test
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/linkverifier"
+)
+
+var _ = Describe("Verifier", func() {
+	var (
+		listener net.Listener
+		verifier linkverifier.Verifier
+	)
+
+	BeforeEach(func() {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		verifier = linkverifier.NewVerifier(2*time.Second, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		listener.Close() //nolint:errcheck
+	})
+
+	listenerPort := func() int {
+		return listener.Addr().(*net.TCPAddr).Port
+	}
+
+	It("reports nothing when every link is reachable", func() {
+		failures := verifier.Verify([]boshas.LinkAddressSpec{
+			{Name: "db", Address: "127.0.0.1", Port: listenerPort()},
+			{Name: "cache", Address: "localhost"},
+		})
+		Expect(failures).To(BeEmpty())
+	})
+
+	It("reports links whose port refuses connections", func() {
+		closed, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		closedPort := closed.Addr().(*net.TCPAddr).Port
+		closed.Close() //nolint:errcheck
+
+		failures := verifier.Verify([]boshas.LinkAddressSpec{
+			{Name: "db", Address: "127.0.0.1", Port: listenerPort()},
+			{Name: "cache", Address: "127.0.0.1", Port: closedPort},
+		})
+
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Name).To(Equal("cache"))
+		Expect(failures[0].Address).To(Equal("127.0.0.1"))
+		Expect(failures[0].Port).To(Equal(closedPort))
+		Expect(failures[0].Error).To(ContainSubstring("refused"))
+	})
+
+	It("reports links whose address does not resolve", func() {
+		failures := verifier.Verify([]boshas.LinkAddressSpec{
+			{Name: "db", Address: "does-not-exist.invalid", Port: 5432},
+		})
+
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Name).To(Equal("db"))
+		Expect(failures[0].Error).NotTo(BeEmpty())
+	})
+})
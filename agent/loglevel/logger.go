@@ -0,0 +1,142 @@
+package loglevel
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const loggerLogTag = "logLevel"
+
+// Logger wraps another logger, dropping the lines below its level. The level
+// can be changed while the agent runs, optionally only for a while.
+type Logger struct {
+	boshlog.Logger
+
+	timeService  clock.Clock
+	defaultLevel boshlog.LogLevel
+
+	lock     sync.RWMutex
+	level    boshlog.LogLevel
+	revertAt time.Time
+
+	// revertCh is only set while a level is to be reverted and closed when
+	// the level changes again before the revert
+	revertCh chan struct{}
+}
+
+func NewLogger(logger boshlog.Logger, defaultLevel boshlog.LogLevel, timeService clock.Clock) *Logger {
+	return &Logger{
+		Logger:       logger,
+		timeService:  timeService,
+		defaultLevel: defaultLevel,
+		level:        defaultLevel,
+	}
+}
+
+// Level returns the current level and, if it is reverted to the default
+// level later on, when that happens.
+func (l *Logger) Level() (boshlog.LogLevel, time.Time) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.level, l.revertAt
+}
+
+// SetLevel changes the level. A positive revertAfter reverts it to the
+// default level after that duration, zero keeps it until the next change.
+func (l *Logger) SetLevel(level boshlog.LogLevel, revertAfter time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.revertCh != nil {
+		close(l.revertCh)
+		l.revertCh = nil
+	}
+
+	l.level = level
+	l.revertAt = time.Time{}
+
+	if revertAfter <= 0 {
+		return
+	}
+
+	revertCh := make(chan struct{})
+	l.revertCh = revertCh
+	l.revertAt = l.timeService.Now().Add(revertAfter)
+
+	timer := l.timeService.NewTimer(revertAfter)
+
+	go func() {
+		defer timer.Stop()
+
+		select {
+		case <-revertCh:
+		case <-timer.C():
+			l.revert(revertCh)
+		}
+	}()
+}
+
+func (l *Logger) revert(revertCh chan struct{}) {
+	l.lock.Lock()
+
+	// The level changed again in the meantime
+	if l.revertCh != revertCh {
+		l.lock.Unlock()
+		return
+	}
+
+	l.revertCh = nil
+	l.level = l.defaultLevel
+	l.revertAt = time.Time{}
+
+	l.lock.Unlock()
+
+	l.Logger.Info(loggerLogTag, "Reverted log level to %s", boshlog.AsString(l.defaultLevel))
+}
+
+func (l *Logger) enabled(level boshlog.LogLevel) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return level >= l.level
+}
+
+func (l *Logger) Debug(tag, msg string, args ...interface{}) {
+	if l.enabled(boshlog.LevelDebug) {
+		l.Logger.Debug(tag, msg, args...)
+	}
+}
+
+func (l *Logger) DebugWithDetails(tag, msg string, args ...interface{}) {
+	if l.enabled(boshlog.LevelDebug) {
+		l.Logger.DebugWithDetails(tag, msg, args...)
+	}
+}
+
+func (l *Logger) Info(tag, msg string, args ...interface{}) {
+	if l.enabled(boshlog.LevelInfo) {
+		l.Logger.Info(tag, msg, args...)
+	}
+}
+
+func (l *Logger) Warn(tag, msg string, args ...interface{}) {
+	if l.enabled(boshlog.LevelWarn) {
+		l.Logger.Warn(tag, msg, args...)
+	}
+}
+
+func (l *Logger) Error(tag, msg string, args ...interface{}) {
+	if l.enabled(boshlog.LevelError) {
+		l.Logger.Error(tag, msg, args...)
+	}
+}
+
+func (l *Logger) ErrorWithDetails(tag, msg string, args ...interface{}) {
+	if l.enabled(boshlog.LevelError) {
+		l.Logger.ErrorWithDetails(tag, msg, args...)
+	}
+}
@@ -0,0 +1,84 @@
+package loglevel_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/loglevel"
+)
+
+var _ = Describe("Logger", func() {
+	var (
+		outBuf      *gbytes.Buffer
+		timeService *fakeclock.FakeClock
+		logger      *loglevel.Logger
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger = loglevel.NewLogger(boshlog.NewWriterLogger(boshlog.LevelDebug, outBuf), boshlog.LevelInfo, timeService)
+	})
+
+	It("drops log lines below the default level", func() {
+		logger.Debug("tag", "hidden")
+		logger.Info("tag", "hello %s", "world")
+
+		Expect(outBuf.Contents()).ToNot(ContainSubstring("hidden"))
+		Expect(outBuf.Contents()).To(ContainSubstring("INFO - hello world"))
+
+		level, revertAt := logger.Level()
+		Expect(level).To(Equal(boshlog.LevelInfo))
+		Expect(revertAt).To(BeZero())
+	})
+
+	It("changes the level", func() {
+		logger.SetLevel(boshlog.LevelError, 0)
+
+		logger.Warn("tag", "hidden")
+		logger.Error("tag", "shown")
+
+		Expect(outBuf.Contents()).ToNot(ContainSubstring("hidden"))
+		Expect(outBuf.Contents()).To(ContainSubstring("ERROR - shown"))
+	})
+
+	It("reverts to the default level after the given duration", func() {
+		logger.SetLevel(boshlog.LevelDebug, time.Minute)
+
+		level, revertAt := logger.Level()
+		Expect(level).To(Equal(boshlog.LevelDebug))
+		Expect(revertAt).To(Equal(timeService.Now().Add(time.Minute)))
+
+		logger.Debug("tag", "while debugging")
+		Expect(outBuf.Contents()).To(ContainSubstring("DEBUG - while debugging"))
+
+		Eventually(timeService.WatcherCount).Should(Equal(1))
+		timeService.Increment(time.Minute)
+
+		Eventually(outBuf).Should(gbytes.Say("Reverted log level to INFO"))
+
+		level, revertAt = logger.Level()
+		Expect(level).To(Equal(boshlog.LevelInfo))
+		Expect(revertAt).To(BeZero())
+	})
+
+	It("does not revert a level that was changed again in the meantime", func() {
+		logger.SetLevel(boshlog.LevelDebug, time.Minute)
+		Eventually(timeService.WatcherCount).Should(Equal(1))
+
+		logger.SetLevel(boshlog.LevelWarn, 0)
+		Eventually(timeService.WatcherCount).Should(Equal(0))
+
+		timeService.Increment(time.Minute)
+
+		Consistently(func() boshlog.LogLevel {
+			level, _ := logger.Level()
+			return level
+		}).Should(Equal(boshlog.LevelWarn))
+	})
+})
@@ -1,11 +1,14 @@
 package logstarprovider
 
 import (
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
@@ -14,27 +17,73 @@ type logsTarProvider struct {
 	compressor  boshcmd.Compressor
 	copier      boshcmd.Copier
 	settingsDir boshdirs.Provider
+	fs          boshsys.FileSystem
 }
 
 func NewLogsTarProvider(
 	compressor boshcmd.Compressor,
 	copier boshcmd.Copier,
-	settingsDir boshdirs.Provider) LogsTarProvider {
+	settingsDir boshdirs.Provider,
+	fs boshsys.FileSystem) LogsTarProvider {
 	return logsTarProvider{
 		compressor:  compressor,
 		copier:      copier,
 		settingsDir: settingsDir,
+		fs:          fs,
 	}
 }
 
-func (l logsTarProvider) Get(logTypes string, filters []string) (string, error) {
-	var directoriesAndPrefixes []boshcmd.DirToCopy
-	var err error
+func (l logsTarProvider) Get(logTypes string, selection Selection) (string, error) {
+	return l.get(logTypes, selection, boshcmd.CompressorOptions{})
+}
+
+// GetUncompressed returns a plain tarball of the logs for callers that
+// compress it themselves.
+func (l logsTarProvider) GetUncompressed(logTypes string, selection Selection) (string, error) {
+	return l.get(logTypes, selection, boshcmd.CompressorOptions{NoCompression: true})
+}
 
+func (l logsTarProvider) get(logTypes string, selection Selection, options boshcmd.CompressorOptions) (string, error) {
+	err := selection.validate()
+	if err != nil {
+		return "", err
+	}
+
+	directoriesAndPrefixes, err := l.logDirs(logTypes)
+	if err != nil {
+		return "", err
+	}
+
+	filters := selection.Include
 	if len(filters) == 0 {
 		filters = []string{"**/*"}
 	}
 
+	tmpDir, err := l.copier.FilteredMultiCopyToTemp(directoriesAndPrefixes, filters)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Copying filtered files to temp directory")
+	}
+
+	defer l.copier.CleanUp(tmpDir)
+
+	if selection.narrows() {
+		err = l.removeUnselected(tmpDir, directoriesAndPrefixes, selection)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Removing logs that are not selected")
+		}
+	}
+
+	tarball, err := l.compressor.CompressFilesInDir(tmpDir, options)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Making logs tarball")
+	}
+
+	return tarball, nil
+}
+
+func (l logsTarProvider) logDirs(logTypes string) ([]boshcmd.DirToCopy, error) {
+	var directoriesAndPrefixes []boshcmd.DirToCopy
+
 	for _, logType := range strings.Split(logTypes, ",") {
 		if logType == "job" {
 			directoriesAndPrefixes = append(directoriesAndPrefixes,
@@ -53,23 +102,48 @@ func (l logsTarProvider) Get(logTypes string, filters []string) (string, error)
 			}
 			continue
 		}
-		err = bosherr.Error("Invalid log type")
-		return "", err
+		return nil, bosherr.Error("Invalid log type")
 	}
 
-	tmpDir, err := l.copier.FilteredMultiCopyToTemp(directoriesAndPrefixes, filters)
-	if err != nil {
-		return "", bosherr.WrapError(err, "Copying filtered files to temp directory")
-	}
+	return directoriesAndPrefixes, nil
+}
 
-	defer l.copier.CleanUp(tmpDir)
+// removeUnselected removes the copies of excluded logs and of logs outside of
+// the time window, since copying neither knows about excluding nor keeps
+// modification times
+func (l logsTarProvider) removeUnselected(tmpDir string, dirs []boshcmd.DirToCopy, selection Selection) error {
+	for _, dir := range dirs {
+		if !l.fs.FileExists(dir.Dir) {
+			continue
+		}
 
-	tarball, err := l.compressor.CompressFilesInDir(tmpDir, boshcmd.CompressorOptions{})
-	if err != nil {
-		return "", bosherr.WrapError(err, "Making logs tarball")
+		err := l.fs.Walk(dir.Dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			relativePath, err := filepath.Rel(dir.Dir, path)
+			if err != nil {
+				return err
+			}
+
+			selected, err := selection.selects(filepath.ToSlash(relativePath), info.ModTime())
+			if err != nil || selected {
+				return err
+			}
+
+			return l.fs.RemoveAll(filepath.Join(tmpDir, dir.Prefix, relativePath))
+		})
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Walking logs in %s", dir.Dir)
+		}
 	}
 
-	return tarball, nil
+	return nil
 }
 
 func (l logsTarProvider) CleanUp(path string) error {
@@ -1,8 +1,14 @@
 package logstarprovider
 
+import (
+	"io"
+)
+
 //go:generate counterfeiter . LogsTarProvider
 
 type LogsTarProvider interface {
-	Get(logType string, filters []string) (string, error)
+	Get(logType string, selection Selection) (string, error)
+	GetUncompressed(logType string, selection Selection) (string, error)
+	Stream(logType string, selection Selection, w io.Writer) error
 	CleanUp(path string) error
 }
@@ -1,14 +1,21 @@
 package logstarprovider
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"errors"
+	"io"
+	"path/filepath"
 	"runtime"
+	"time"
 
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -19,6 +26,7 @@ var _ = Describe("LogsTarProvider", func() {
 		compressor  *fakecmd.FakeCompressor
 		copier      *fakecmd.FakeCopier
 		dirProvider boshdirs.Provider
+		fs          *fakesys.FakeFileSystem
 
 		provider LogsTarProvider
 	)
@@ -27,8 +35,9 @@ var _ = Describe("LogsTarProvider", func() {
 		compressor = fakecmd.NewFakeCompressor()
 		dirProvider = boshdirs.NewProvider("/fake/dir")
 		copier = fakecmd.NewFakeCopier()
+		fs = fakesys.NewFakeFileSystem()
 
-		provider = NewLogsTarProvider(compressor, copier, dirProvider)
+		provider = NewLogsTarProvider(compressor, copier, dirProvider, fs)
 	})
 
 	Describe("Get", func() {
@@ -39,7 +48,7 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("job logs", func() {
 				It("uses the correct logs dir", func() {
-					_, err := provider.Get("job", []string{})
+					_, err := provider.Get("job", Selection{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempDirs[0].Dir).To(boshassert.MatchPath(dirProvider.LogsDir()))
@@ -48,7 +57,7 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("agent logs", func() {
 				It("uses the correct logs dir", func() {
-					_, err := provider.Get("agent", []string{})
+					_, err := provider.Get("agent", Selection{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempDirs[0].Dir).To(boshassert.MatchPath(dirProvider.AgentLogsDir()))
@@ -57,7 +66,7 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("system logs", func() {
 				It("uses the correct logs dir", func() {
-					_, err := provider.Get("system", []string{})
+					_, err := provider.Get("system", Selection{})
 					Expect(err).NotTo(HaveOccurred())
 
 					if runtime.GOOS == "linux" {
@@ -70,7 +79,7 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("multiple logs", func() {
 				It("uses the correct logs dirs", func() {
-					_, err := provider.Get("job,agent,system", []string{})
+					_, err := provider.Get("job,agent,system", Selection{})
 					Expect(err).NotTo(HaveOccurred())
 
 					if runtime.GOOS == "linux" {
@@ -96,14 +105,14 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("job logs", func() {
 				It("uses the filters provided", func() {
-					_, err := provider.Get("job", []string{"foo", "bar"})
+					_, err := provider.Get("job", Selection{Include: []string{"foo", "bar"}})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("foo", "bar"))
 				})
 
 				It("uses the default filters when none are provided", func() {
-					_, err := provider.Get("job", []string{})
+					_, err := provider.Get("job", Selection{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("**/*"))
@@ -112,14 +121,14 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("agent logs", func() {
 				It("uses the filters provided", func() {
-					_, err := provider.Get("agent", []string{"foo", "bar"})
+					_, err := provider.Get("agent", Selection{Include: []string{"foo", "bar"}})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("foo", "bar"))
 				})
 
 				It("uses the default filters when none are provided", func() {
-					_, err := provider.Get("agent", []string{})
+					_, err := provider.Get("agent", Selection{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("**/*"))
@@ -128,14 +137,14 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("system logs", func() {
 				It("uses the filters provided", func() {
-					_, err := provider.Get("system", []string{"foo", "bar"})
+					_, err := provider.Get("system", Selection{Include: []string{"foo", "bar"}})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("foo", "bar"))
 				})
 
 				It("uses the default filters when none are provided", func() {
-					_, err := provider.Get("system", []string{})
+					_, err := provider.Get("system", Selection{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("**/*"))
@@ -144,14 +153,14 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("multiple log types", func() {
 				It("uses the filters provided, just as it does with one log type", func() {
-					_, err := provider.Get("system,agent,job", []string{"foo", "bar"})
+					_, err := provider.Get("system,agent,job", Selection{Include: []string{"foo", "bar"}})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("foo", "bar"))
 				})
 
 				It("uses the default filters when none are provided", func() {
-					_, err := provider.Get("agent,system,job", []string{})
+					_, err := provider.Get("agent,system,job", Selection{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("**/*"))
@@ -160,7 +169,7 @@ var _ = Describe("LogsTarProvider", func() {
 
 			Context("invalid log types", func() {
 				It("returns an error", func() {
-					_, err := provider.Get("lincoln", []string{})
+					_, err := provider.Get("lincoln", Selection{})
 					Expect(err).To(MatchError("Invalid log type"))
 				})
 			})
@@ -172,7 +181,7 @@ var _ = Describe("LogsTarProvider", func() {
 					})
 
 					It("returns an error if the copier returns an error", func() {
-						_, err := provider.Get("job", []string{})
+						_, err := provider.Get("job", Selection{})
 						Expect(err).To(MatchError(ContainSubstring("Copying filtered files to temp directory")))
 						Expect(err).To(MatchError(ContainSubstring("plagiarization")))
 					})
@@ -182,7 +191,7 @@ var _ = Describe("LogsTarProvider", func() {
 					copier.FilteredMultiCopyToTempDir = "/tmp/dir"
 					Expect(copier.CleanUpTempDir).To(BeZero())
 
-					_, err := provider.Get("job", []string{})
+					_, err := provider.Get("job", Selection{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(copier.CleanUpTempDir).To(Equal("/tmp/dir"))
@@ -196,7 +205,7 @@ var _ = Describe("LogsTarProvider", func() {
 					})
 
 					It("returns an error if the compressor returns an error", func() {
-						_, err := provider.Get("job", []string{})
+						_, err := provider.Get("job", Selection{})
 						Expect(err).To(MatchError(ContainSubstring("Making logs tarball")))
 						Expect(err).To(MatchError(ContainSubstring("squish")))
 					})
@@ -205,15 +214,177 @@ var _ = Describe("LogsTarProvider", func() {
 				It("returns the tarball path", func() {
 					compressor.CompressFilesInDirTarballPath = "/tmp/logs.tar"
 
-					tarballPath, err := provider.Get("job", []string{})
+					tarballPath, err := provider.Get("job", Selection{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(tarballPath).To(Equal("/tmp/logs.tar"))
 				})
+
+				It("compresses the tarball", func() {
+					_, err := provider.Get("job", Selection{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(compressor.CompressFilesInDirOptions.NoCompression).To(BeFalse())
+				})
 			})
 		})
 	})
 
+	Describe("selecting logs", func() {
+		var (
+			now    time.Time
+			logDir string
+		)
+
+		BeforeEach(func() {
+			now = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+			logDir = dirProvider.LogsDir()
+			copier.FilteredMultiCopyToTempDir = "/tmp/logs-copy"
+
+			writeLog := func(relativePath string, modTime time.Time) {
+				Expect(fs.WriteFileString(filepath.Join(logDir, relativePath), "fake-log")).To(Succeed())
+				fs.GetFileTestStat(filepath.Join(logDir, relativePath)).ModTime = modTime
+				Expect(fs.WriteFileString(filepath.Join("/tmp/logs-copy", relativePath), "fake-log")).To(Succeed())
+			}
+
+			writeLog("web/web.log", now)
+			writeLog("web/web.log.1.gz", now.Add(-2*time.Hour))
+			writeLog("web/archive/old.log", now)
+			writeLog("db/db.log", now.Add(-30*time.Minute))
+		})
+
+		It("removes the copies of excluded logs before making the tarball", func() {
+			_, err := provider.Get("job", Selection{Exclude: []string{"**/*.gz", "web/archive"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.FileExists("/tmp/logs-copy/web/web.log")).To(BeTrue())
+			Expect(fs.FileExists("/tmp/logs-copy/db/db.log")).To(BeTrue())
+			Expect(fs.FileExists("/tmp/logs-copy/web/web.log.1.gz")).To(BeFalse())
+			Expect(fs.FileExists("/tmp/logs-copy/web/archive/old.log")).To(BeFalse())
+		})
+
+		It("removes the copies of logs last modified outside of the time window", func() {
+			_, err := provider.Get("job", Selection{Since: now.Add(-time.Hour), Until: now.Add(-time.Minute)})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.FileExists("/tmp/logs-copy/db/db.log")).To(BeTrue())
+			Expect(fs.FileExists("/tmp/logs-copy/web/web.log")).To(BeFalse())
+			Expect(fs.FileExists("/tmp/logs-copy/web/web.log.1.gz")).To(BeFalse())
+		})
+
+		It("keeps everything the filters include otherwise", func() {
+			_, err := provider.Get("job", Selection{Include: []string{"web"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(copier.FilteredMultiCopyToTempFilters).To(ConsistOf("web"))
+			Expect(fs.FileExists("/tmp/logs-copy/web/web.log.1.gz")).To(BeTrue())
+		})
+
+		It("returns an error when the time window ends before it starts", func() {
+			_, err := provider.Get("job", Selection{Since: now, Until: now.Add(-time.Hour)})
+			Expect(err).To(MatchError(ContainSubstring("which is before since")))
+			Expect(copier.FilteredMultiCopyToTempDirs).To(BeEmpty())
+		})
+
+		It("returns an error for malformed exclude globs", func() {
+			_, err := provider.Get("job", Selection{Exclude: []string{"web/["}})
+			Expect(err).To(MatchError(ContainSubstring("Invalid exclude glob 'web/['")))
+		})
+	})
+
+	Describe("GetUncompressed", func() {
+		It("returns a tarball that is not compressed", func() {
+			compressor.CompressFilesInDirTarballPath = "/tmp/logs.tar"
+
+			tarballPath, err := provider.GetUncompressed("job", Selection{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tarballPath).To(Equal("/tmp/logs.tar"))
+			Expect(compressor.CompressFilesInDirOptions.NoCompression).To(BeTrue())
+		})
+	})
+
+	Describe("Stream", func() {
+		var (
+			now    time.Time
+			logDir string
+		)
+
+		streamedLogs := func(logTypes string, selection Selection) map[string]string {
+			var stream bytes.Buffer
+			Expect(provider.Stream(logTypes, selection, &stream)).To(Succeed())
+
+			gzipReader, err := gzip.NewReader(&stream)
+			Expect(err).NotTo(HaveOccurred())
+
+			logs := map[string]string{}
+			tarReader := tar.NewReader(gzipReader)
+			for {
+				header, err := tarReader.Next()
+				if err == io.EOF {
+					return logs
+				}
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := io.ReadAll(tarReader)
+				Expect(err).NotTo(HaveOccurred())
+				logs[header.Name] = string(contents)
+			}
+		}
+
+		BeforeEach(func() {
+			now = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+			logDir = dirProvider.LogsDir()
+
+			writeLog := func(relativePath, contents string, modTime time.Time) {
+				Expect(fs.WriteFileString(filepath.Join(logDir, relativePath), contents)).To(Succeed())
+				fs.GetFileTestStat(filepath.Join(logDir, relativePath)).ModTime = modTime
+			}
+
+			writeLog("web/web.log", "web-log", now)
+			writeLog("web/web.log.1.gz", "rotated-web-log", now.Add(-2*time.Hour))
+			writeLog("db/db.log", "db-log", now.Add(-30*time.Minute))
+		})
+
+		It("streams a gzipped tarball of the logs without copying them", func() {
+			Expect(streamedLogs("job", Selection{})).To(Equal(map[string]string{
+				"./web/web.log":      "web-log",
+				"./web/web.log.1.gz": "rotated-web-log",
+				"./db/db.log":        "db-log",
+			}))
+
+			Expect(copier.FilteredMultiCopyToTempDirs).To(BeEmpty())
+			Expect(compressor.CompressFilesInDirDir).To(BeEmpty())
+		})
+
+		It("only streams the selected logs", func() {
+			Expect(streamedLogs("job", Selection{Include: []string{"web"}, Exclude: []string{"**/*.gz"}})).To(Equal(map[string]string{
+				"./web/web.log": "web-log",
+			}))
+
+			Expect(streamedLogs("job", Selection{Since: now.Add(-time.Hour), Until: now.Add(-time.Minute)})).To(Equal(map[string]string{
+				"./db/db.log": "db-log",
+			}))
+		})
+
+		It("returns an error for invalid log types", func() {
+			err := provider.Stream("fake-type", Selection{}, io.Discard)
+			Expect(err).To(MatchError("Invalid log type"))
+		})
+
+		It("returns an error when a log cannot be read", func() {
+			fs.OpenFileErr = errors.New("fake-open-err")
+
+			err := provider.Stream("job", Selection{}, io.Discard)
+			Expect(err).To(MatchError(ContainSubstring("fake-open-err")))
+		})
+
+		It("returns an error for malformed include globs", func() {
+			err := provider.Stream("job", Selection{Include: []string{"web/["}}, io.Discard)
+			Expect(err).To(MatchError(ContainSubstring("Invalid include glob 'web/['")))
+		})
+	})
+
 	Describe("Cleanup", func() {
 		It("invokes the compressor's cleanup function", func() {
 			Expect(compressor.CleanUpTarballPath).To(BeZero())
@@ -2,6 +2,7 @@
 package logstarproviderfakes
 
 import (
+	"io"
 	"sync"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
@@ -19,11 +20,11 @@ type FakeLogsTarProvider struct {
 	cleanUpReturnsOnCall map[int]struct {
 		result1 error
 	}
-	GetStub        func(string, []string) (string, error)
+	GetStub        func(string, logstarprovider.Selection) (string, error)
 	getMutex       sync.RWMutex
 	getArgsForCall []struct {
 		arg1 string
-		arg2 []string
+		arg2 logstarprovider.Selection
 	}
 	getReturns struct {
 		result1 string
@@ -33,6 +34,33 @@ type FakeLogsTarProvider struct {
 		result1 string
 		result2 error
 	}
+	GetUncompressedStub        func(string, logstarprovider.Selection) (string, error)
+	getUncompressedMutex       sync.RWMutex
+	getUncompressedArgsForCall []struct {
+		arg1 string
+		arg2 logstarprovider.Selection
+	}
+	getUncompressedReturns struct {
+		result1 string
+		result2 error
+	}
+	getUncompressedReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	StreamStub        func(string, logstarprovider.Selection, io.Writer) error
+	streamMutex       sync.RWMutex
+	streamArgsForCall []struct {
+		arg1 string
+		arg2 logstarprovider.Selection
+		arg3 io.Writer
+	}
+	streamReturns struct {
+		result1 error
+	}
+	streamReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -43,15 +71,16 @@ func (fake *FakeLogsTarProvider) CleanUp(arg1 string) error {
 	fake.cleanUpArgsForCall = append(fake.cleanUpArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.CleanUpStub
+	fakeReturns := fake.cleanUpReturns
 	fake.recordInvocation("CleanUp", []interface{}{arg1})
 	fake.cleanUpMutex.Unlock()
-	if fake.CleanUpStub != nil {
-		return fake.CleanUpStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.cleanUpReturns
 	return fakeReturns.result1
 }
 
@@ -97,27 +126,23 @@ func (fake *FakeLogsTarProvider) CleanUpReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeLogsTarProvider) Get(arg1 string, arg2 []string) (string, error) {
-	var arg2Copy []string
-	if arg2 != nil {
-		arg2Copy = make([]string, len(arg2))
-		copy(arg2Copy, arg2)
-	}
+func (fake *FakeLogsTarProvider) Get(arg1 string, arg2 logstarprovider.Selection) (string, error) {
 	fake.getMutex.Lock()
 	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
 	fake.getArgsForCall = append(fake.getArgsForCall, struct {
 		arg1 string
-		arg2 []string
-	}{arg1, arg2Copy})
-	fake.recordInvocation("Get", []interface{}{arg1, arg2Copy})
+		arg2 logstarprovider.Selection
+	}{arg1, arg2})
+	stub := fake.GetStub
+	fakeReturns := fake.getReturns
+	fake.recordInvocation("Get", []interface{}{arg1, arg2})
 	fake.getMutex.Unlock()
-	if fake.GetStub != nil {
-		return fake.GetStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -127,13 +152,13 @@ func (fake *FakeLogsTarProvider) GetCallCount() int {
 	return len(fake.getArgsForCall)
 }
 
-func (fake *FakeLogsTarProvider) GetCalls(stub func(string, []string) (string, error)) {
+func (fake *FakeLogsTarProvider) GetCalls(stub func(string, logstarprovider.Selection) (string, error)) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = stub
 }
 
-func (fake *FakeLogsTarProvider) GetArgsForCall(i int) (string, []string) {
+func (fake *FakeLogsTarProvider) GetArgsForCall(i int) (string, logstarprovider.Selection) {
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
 	argsForCall := fake.getArgsForCall[i]
@@ -166,13 +191,137 @@ func (fake *FakeLogsTarProvider) GetReturnsOnCall(i int, result1 string, result2
 	}{result1, result2}
 }
 
+func (fake *FakeLogsTarProvider) GetUncompressed(arg1 string, arg2 logstarprovider.Selection) (string, error) {
+	fake.getUncompressedMutex.Lock()
+	ret, specificReturn := fake.getUncompressedReturnsOnCall[len(fake.getUncompressedArgsForCall)]
+	fake.getUncompressedArgsForCall = append(fake.getUncompressedArgsForCall, struct {
+		arg1 string
+		arg2 logstarprovider.Selection
+	}{arg1, arg2})
+	stub := fake.GetUncompressedStub
+	fakeReturns := fake.getUncompressedReturns
+	fake.recordInvocation("GetUncompressed", []interface{}{arg1, arg2})
+	fake.getUncompressedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLogsTarProvider) GetUncompressedCallCount() int {
+	fake.getUncompressedMutex.RLock()
+	defer fake.getUncompressedMutex.RUnlock()
+	return len(fake.getUncompressedArgsForCall)
+}
+
+func (fake *FakeLogsTarProvider) GetUncompressedCalls(stub func(string, logstarprovider.Selection) (string, error)) {
+	fake.getUncompressedMutex.Lock()
+	defer fake.getUncompressedMutex.Unlock()
+	fake.GetUncompressedStub = stub
+}
+
+func (fake *FakeLogsTarProvider) GetUncompressedArgsForCall(i int) (string, logstarprovider.Selection) {
+	fake.getUncompressedMutex.RLock()
+	defer fake.getUncompressedMutex.RUnlock()
+	argsForCall := fake.getUncompressedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLogsTarProvider) GetUncompressedReturns(result1 string, result2 error) {
+	fake.getUncompressedMutex.Lock()
+	defer fake.getUncompressedMutex.Unlock()
+	fake.GetUncompressedStub = nil
+	fake.getUncompressedReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLogsTarProvider) GetUncompressedReturnsOnCall(i int, result1 string, result2 error) {
+	fake.getUncompressedMutex.Lock()
+	defer fake.getUncompressedMutex.Unlock()
+	fake.GetUncompressedStub = nil
+	if fake.getUncompressedReturnsOnCall == nil {
+		fake.getUncompressedReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.getUncompressedReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLogsTarProvider) Stream(arg1 string, arg2 logstarprovider.Selection, arg3 io.Writer) error {
+	fake.streamMutex.Lock()
+	ret, specificReturn := fake.streamReturnsOnCall[len(fake.streamArgsForCall)]
+	fake.streamArgsForCall = append(fake.streamArgsForCall, struct {
+		arg1 string
+		arg2 logstarprovider.Selection
+		arg3 io.Writer
+	}{arg1, arg2, arg3})
+	stub := fake.StreamStub
+	fakeReturns := fake.streamReturns
+	fake.recordInvocation("Stream", []interface{}{arg1, arg2, arg3})
+	fake.streamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLogsTarProvider) StreamCallCount() int {
+	fake.streamMutex.RLock()
+	defer fake.streamMutex.RUnlock()
+	return len(fake.streamArgsForCall)
+}
+
+func (fake *FakeLogsTarProvider) StreamCalls(stub func(string, logstarprovider.Selection, io.Writer) error) {
+	fake.streamMutex.Lock()
+	defer fake.streamMutex.Unlock()
+	fake.StreamStub = stub
+}
+
+func (fake *FakeLogsTarProvider) StreamArgsForCall(i int) (string, logstarprovider.Selection, io.Writer) {
+	fake.streamMutex.RLock()
+	defer fake.streamMutex.RUnlock()
+	argsForCall := fake.streamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeLogsTarProvider) StreamReturns(result1 error) {
+	fake.streamMutex.Lock()
+	defer fake.streamMutex.Unlock()
+	fake.StreamStub = nil
+	fake.streamReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLogsTarProvider) StreamReturnsOnCall(i int, result1 error) {
+	fake.streamMutex.Lock()
+	defer fake.streamMutex.Unlock()
+	fake.StreamStub = nil
+	if fake.streamReturnsOnCall == nil {
+		fake.streamReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.streamReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeLogsTarProvider) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.cleanUpMutex.RLock()
-	defer fake.cleanUpMutex.RUnlock()
-	fake.getMutex.RLock()
-	defer fake.getMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
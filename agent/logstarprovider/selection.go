@@ -0,0 +1,75 @@
+package logstarprovider
+
+import (
+	"time"
+
+	"github.com/bmatcuk/doublestar"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Selection picks the logs to collect. Include and Exclude are globs relative
+// to the log directories, and a glob matching a directory matches everything
+// in it. Everything is included without Include globs. Since and Until only
+// keep logs last modified in that time window, a zero time leaves its end of
+// the window open.
+type Selection struct {
+	Include []string
+	Exclude []string
+	Since   time.Time
+	Until   time.Time
+}
+
+func (s Selection) validate() error {
+	if !s.Since.IsZero() && !s.Until.IsZero() && s.Until.Before(s.Since) {
+		return bosherr.Errorf("Selecting logs until %s, which is before since %s", s.Until.Format(time.RFC3339), s.Since.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// narrows is true when the selection does more than including globs, which
+// the copier takes care of
+func (s Selection) narrows() bool {
+	return len(s.Exclude) > 0 || !s.Since.IsZero() || !s.Until.IsZero()
+}
+
+// selects expects a slash separated path relative to its log directory
+func (s Selection) selects(relativePath string, modTime time.Time) (bool, error) {
+	if !s.Since.IsZero() && modTime.Before(s.Since) {
+		return false, nil
+	}
+
+	if !s.Until.IsZero() && modTime.After(s.Until) {
+		return false, nil
+	}
+
+	excluded, err := matchesAny(s.Exclude, relativePath, "exclude")
+	return !excluded && err == nil, err
+}
+
+// includes expects a slash separated path relative to its log directory. The
+// copier matches Include itself when staging logs, streaming them does not.
+func (s Selection) includes(relativePath string) (bool, error) {
+	if len(s.Include) == 0 {
+		return true, nil
+	}
+
+	return matchesAny(s.Include, relativePath, "include")
+}
+
+func matchesAny(patterns []string, relativePath, kind string) (bool, error) {
+	for _, pattern := range patterns {
+		for _, matching := range []string{pattern, pattern + "/**"} {
+			matched, err := doublestar.Match(matching, relativePath)
+			if err != nil {
+				return false, bosherr.WrapErrorf(err, "Invalid %s glob '%s'", kind, pattern)
+			}
+
+			if matched {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
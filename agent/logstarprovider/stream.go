@@ -0,0 +1,140 @@
+package logstarprovider
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+)
+
+// Stream writes a gzipped tarball of the selected logs to w while reading
+// them, so neither copies of the logs nor the tarball take up disk space. The
+// tarball has the same layout as the one Get makes.
+func (l logsTarProvider) Stream(logTypes string, selection Selection, w io.Writer) error {
+	err := selection.validate()
+	if err != nil {
+		return err
+	}
+
+	dirs, err := l.logDirs(logTypes)
+	if err != nil {
+		return err
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, dir := range dirs {
+		err = l.streamDir(tarWriter, dir, selection)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Streaming logs in %s", dir.Dir)
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Finishing logs tarball")
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Compressing logs tarball")
+	}
+
+	return nil
+}
+
+func (l logsTarProvider) streamDir(tarWriter *tar.Writer, dir boshcmd.DirToCopy, selection Selection) error {
+	if !l.fs.FileExists(dir.Dir) {
+		return nil
+	}
+
+	return l.fs.Walk(dir.Dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		// Like the copier, follow links to logs kept elsewhere
+		if info.Mode()&os.ModeSymlink != 0 {
+			info, err = l.fs.Stat(filePath)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Following link %s", filePath)
+			}
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(dir.Dir, filePath)
+		if err != nil {
+			return err
+		}
+
+		relativePath = filepath.ToSlash(relativePath)
+
+		selected, err := selection.includes(relativePath)
+		if err != nil || !selected {
+			return err
+		}
+
+		selected, err = selection.selects(relativePath, info.ModTime())
+		if err != nil || !selected {
+			return err
+		}
+
+		return l.streamFile(tarWriter, filePath, info, path.Join(filepath.ToSlash(dir.Prefix), relativePath))
+	})
+}
+
+func (l logsTarProvider) streamFile(tarWriter *tar.Writer, filePath string, info os.FileInfo, name string) error {
+	file, err := l.fs.OpenFile(filePath, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening log %s", filePath)
+	}
+	defer file.Close() //nolint:errcheck
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "./" + strings.TrimPrefix(name, "/"),
+		Mode:     int64(info.Mode().Perm()),
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Adding log %s", filePath)
+	}
+
+	// Logs keep being written while they are streamed. Only the size they had
+	// when they were found is streamed, and logs truncated since are padded.
+	copied, err := io.CopyN(tarWriter, file, info.Size())
+	if err != nil && err != io.EOF {
+		return bosherr.WrapErrorf(err, "Streaming log %s", filePath)
+	}
+
+	_, err = io.CopyN(tarWriter, zeroReader{}, info.Size()-copied)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Padding truncated log %s", filePath)
+	}
+
+	return nil
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,256 @@
+package logtail
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/bmatcuk/doublestar"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+const (
+	DefaultPollInterval = time.Second
+
+	// Bound the memory used for logs that grow faster than they are followed
+	maxChunkLines = 500
+	maxReadBytes  = 1024 * 1024
+	maxLineBytes  = 64 * 1024
+)
+
+type followedFile struct {
+	offset  int64
+	partial []byte
+}
+
+type follower struct {
+	fs           boshsys.FileSystem
+	logsDir      string
+	timeService  clock.Clock
+	pollInterval time.Duration
+}
+
+// NewFollower follows logs in logsDir by polling them every pollInterval.
+func NewFollower(fs boshsys.FileSystem, logsDir string, timeService clock.Clock, pollInterval time.Duration) Follower {
+	return follower{
+		fs:           fs,
+		logsDir:      logsDir,
+		timeService:  timeService,
+		pollInterval: pollInterval,
+	}
+}
+
+// Follow passes lines appended to the logs matching globs to emit until
+// duration is over or the task is cancelled. Globs are relative to the logs
+// directory and a glob matching a directory matches everything in it, all
+// logs are followed without globs. Logs created while following are followed
+// from their start, logs truncated by rotation from their new start.
+func (f follower) Follow(globs []string, duration time.Duration, cancel *boshtask.CancelSignal, emit func(Chunk) error) (Summary, error) {
+	t := &tail{globs: globs, files: map[string]*followedFile{}, emit: emit}
+
+	// Only lines appended from now on are followed
+	err := f.scan(globs, func(relativePath string, size int64) error {
+		t.files[relativePath] = &followedFile{offset: size}
+		return nil
+	})
+	if err != nil {
+		return Summary{}, err
+	}
+
+	ticker := f.timeService.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+
+	deadline := f.timeService.NewTimer(duration)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			if err := cancel.Err(); err != nil {
+				return t.summary, err
+			}
+
+			err = f.poll(t, false)
+			if err != nil {
+				return t.summary, err
+			}
+
+		case <-deadline.C():
+			return t.summary, f.poll(t, true)
+		}
+	}
+}
+
+type tail struct {
+	globs   []string
+	files   map[string]*followedFile
+	emit    func(Chunk) error
+	pending []Line
+	summary Summary
+}
+
+func (t *tail) flush(all bool) error {
+	for len(t.pending) >= maxChunkLines || (all && len(t.pending) > 0) {
+		n := min(len(t.pending), maxChunkLines)
+
+		t.summary.Chunks++
+		t.summary.Lines += n
+
+		err := t.emit(Chunk{Sequence: t.summary.Chunks, Lines: t.pending[:n]})
+		if err != nil {
+			return bosherr.WrapError(err, "Emitting followed log lines")
+		}
+
+		t.pending = t.pending[n:]
+	}
+
+	return nil
+}
+
+// poll reads what was appended to the logs since the last poll. The last
+// poll also passes on lines that are not terminated yet.
+func (f follower) poll(t *tail, last bool) error {
+	seen := map[string]bool{}
+
+	err := f.scan(t.globs, func(relativePath string, size int64) error {
+		seen[relativePath] = true
+
+		file, found := t.files[relativePath]
+		if !found {
+			file = &followedFile{}
+			t.files[relativePath] = file
+		}
+
+		if size < file.offset {
+			file.offset = 0
+			file.partial = nil
+		}
+
+		appended, err := f.read(relativePath, file.offset, min(size-file.offset, maxReadBytes))
+		if err != nil {
+			return err
+		}
+
+		file.offset += int64(len(appended))
+
+		lines := bytes.Split(append(file.partial, appended...), []byte("\n"))
+		file.partial = lines[len(lines)-1]
+
+		for _, line := range lines[:len(lines)-1] {
+			t.pending = append(t.pending, Line{File: relativePath, Text: string(line)})
+		}
+
+		if len(file.partial) > maxLineBytes || (last && len(file.partial) > 0) {
+			t.pending = append(t.pending, Line{File: relativePath, Text: string(file.partial)})
+			file.partial = nil
+		}
+
+		return t.flush(false)
+	})
+	if err != nil {
+		return err
+	}
+
+	for relativePath := range t.files {
+		if !seen[relativePath] {
+			delete(t.files, relativePath)
+		}
+	}
+
+	return t.flush(true)
+}
+
+func (f follower) read(relativePath string, offset, length int64) ([]byte, error) {
+	if length <= 0 {
+		return nil, nil
+	}
+
+	file, err := f.fs.OpenFile(filepath.Join(f.logsDir, relativePath), os.O_RDONLY, 0)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Opening log %s", relativePath)
+	}
+	defer file.Close() //nolint:errcheck
+
+	buf := make([]byte, length)
+
+	n, err := file.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, bosherr.WrapErrorf(err, "Reading log %s", relativePath)
+	}
+
+	return buf[:min(n, len(buf))], nil
+}
+
+// scan calls found with the size of every log matching globs
+func (f follower) scan(globs []string, found func(relativePath string, size int64) error) error {
+	if !f.fs.FileExists(f.logsDir) {
+		return nil
+	}
+
+	err := f.fs.Walk(f.logsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			info, err = f.fs.Stat(path)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Following link %s", path)
+			}
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(f.logsDir, path)
+		if err != nil {
+			return err
+		}
+
+		relativePath = filepath.ToSlash(relativePath)
+
+		matched, err := matches(globs, relativePath)
+		if err != nil || !matched {
+			return err
+		}
+
+		return found(relativePath, info.Size())
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Finding logs in %s", f.logsDir)
+	}
+
+	return nil
+}
+
+func matches(globs []string, relativePath string) (bool, error) {
+	if len(globs) == 0 {
+		return true, nil
+	}
+
+	for _, glob := range globs {
+		for _, pattern := range []string{glob, glob + "/**"} {
+			matched, err := doublestar.Match(pattern, relativePath)
+			if err != nil {
+				return false, bosherr.WrapErrorf(err, "Invalid log glob '%s'", glob)
+			}
+
+			if matched {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
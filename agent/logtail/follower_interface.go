@@ -0,0 +1,32 @@
+package logtail
+
+import (
+	"time"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+//go:generate counterfeiter . Follower
+
+type Follower interface {
+	Follow(globs []string, duration time.Duration, cancel *boshtask.CancelSignal, emit func(Chunk) error) (Summary, error)
+}
+
+// Line is a complete line appended to a followed log. File is relative to
+// the job logs directory.
+type Line struct {
+	File string `json:"file"`
+	Text string `json:"text"`
+}
+
+// Chunk is a batch of followed lines. Sequences start at 1 without gaps so
+// requesters that only see the latest chunk notice the ones they missed.
+type Chunk struct {
+	Sequence int    `json:"sequence"`
+	Lines    []Line `json:"lines"`
+}
+
+type Summary struct {
+	Chunks int `json:"chunks"`
+	Lines  int `json:"lines"`
+}
@@ -0,0 +1,140 @@
+package logtail_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/logtail"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+var _ = Describe("Follower", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		timeService *fakeclock.FakeClock
+		follower    logtail.Follower
+
+		chunks  chan logtail.Chunk
+		summary logtail.Summary
+		done    chan error
+	)
+
+	appendLog := func(path, contents string) {
+		existing, _ := fs.ReadFileString(path) //nolint:errcheck
+		Expect(fs.WriteFileString(path, existing+contents)).To(Succeed())
+	}
+
+	follow := func(globs []string, cancel *boshtask.CancelSignal) {
+		go func() {
+			defer GinkgoRecover()
+
+			var err error
+			summary, err = follower.Follow(globs, 10*time.Second, cancel, func(chunk logtail.Chunk) error {
+				chunks <- chunk
+				return nil
+			})
+			done <- err
+		}()
+
+		// Wait for the ticker and the deadline
+		Eventually(timeService.WatcherCount).Should(Equal(2))
+	}
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		timeService = fakeclock.NewFakeClock(time.Now())
+		follower = logtail.NewFollower(fs, "/logs", timeService, time.Second)
+
+		chunks = make(chan logtail.Chunk, 10)
+		done = make(chan error, 1)
+
+		appendLog("/logs/web/web.log", "before following\n")
+		appendLog("/logs/db/db.log", "db before following\n")
+	})
+
+	It("passes on the lines appended to the selected logs while following them", func() {
+		follow([]string{"web"}, nil)
+
+		appendLog("/logs/web/web.log", "first\nsecond\nunterminated")
+		appendLog("/logs/db/db.log", "not selected\n")
+		timeService.Increment(time.Second)
+
+		Eventually(chunks).Should(Receive(Equal(logtail.Chunk{
+			Sequence: 1,
+			Lines:    []logtail.Line{{File: "web/web.log", Text: "first"}, {File: "web/web.log", Text: "second"}},
+		})))
+
+		appendLog("/logs/web/web.log", " now\n")
+		appendLog("/logs/web/new.log", "created while following\n")
+		timeService.Increment(time.Second)
+
+		Eventually(chunks).Should(Receive(Equal(logtail.Chunk{
+			Sequence: 2,
+			Lines: []logtail.Line{
+				{File: "web/new.log", Text: "created while following"},
+				{File: "web/web.log", Text: "unterminated now"},
+			},
+		})))
+	})
+
+	It("stops after the duration and passes on unterminated lines", func() {
+		follow(nil, nil)
+
+		appendLog("/logs/db/db.log", "last words")
+		timeService.Increment(10 * time.Second)
+
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(chunks).To(Receive(Equal(logtail.Chunk{
+			Sequence: 1,
+			Lines:    []logtail.Line{{File: "db/db.log", Text: "last words"}},
+		})))
+		Expect(summary).To(Equal(logtail.Summary{Chunks: 1, Lines: 1}))
+	})
+
+	It("follows truncated logs from their new start", func() {
+		follow(nil, nil)
+
+		Expect(fs.WriteFileString("/logs/web/web.log", "rotated\n")).To(Succeed())
+		timeService.Increment(time.Second)
+
+		Eventually(chunks).Should(Receive(Equal(logtail.Chunk{
+			Sequence: 1,
+			Lines:    []logtail.Line{{File: "web/web.log", Text: "rotated"}},
+		})))
+	})
+
+	It("stops when the task is cancelled", func() {
+		cancelCh := make(chan struct{}, 1)
+		follow(nil, boshtask.NewCancelSignal(cancelCh))
+
+		cancelCh <- struct{}{}
+		timeService.Increment(time.Second)
+
+		Eventually(done).Should(Receive(MatchError(boshtask.ErrCancelled)))
+	})
+
+	It("returns the error passing on lines", func() {
+		go func() {
+			_, err := follower.Follow(nil, 10*time.Second, nil, func(logtail.Chunk) error {
+				return errors.New("fake-emit-err")
+			})
+			done <- err
+		}()
+		Eventually(timeService.WatcherCount).Should(Equal(2))
+
+		appendLog("/logs/web/web.log", "line\n")
+		timeService.Increment(time.Second)
+
+		Eventually(done).Should(Receive(MatchError(ContainSubstring("fake-emit-err"))))
+	})
+
+	It("returns an error for malformed globs", func() {
+		_, err := follower.Follow([]string{"web/["}, time.Second, nil, nil)
+		Expect(err).To(MatchError(ContainSubstring("Invalid log glob 'web/['")))
+	})
+})
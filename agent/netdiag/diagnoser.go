@@ -0,0 +1,113 @@
+package netdiag
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+// IP and ICMP headers are not part of the payload of pings
+const icmpHeadersSize = 28
+
+// Resolver is implemented by net.Resolver
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type diagnoser struct {
+	cmdRunner   boshsys.CmdRunner
+	resolver    Resolver
+	timeService clock.Clock
+}
+
+func NewDiagnoser(cmdRunner boshsys.CmdRunner, resolver Resolver, timeService clock.Clock) Diagnoser {
+	return diagnoser{
+		cmdRunner:   cmdRunner,
+		resolver:    resolver,
+		timeService: timeService,
+	}
+}
+
+func (d diagnoser) Diagnose(options Options, cancel *boshtask.CancelSignal) ([]Result, error) {
+	type check struct {
+		name   string
+		target string
+		run    func() ([]string, error)
+	}
+
+	var checks []check
+
+	if options.Gateway != "" && options.PingGateway {
+		checks = append(checks, check{CheckGateway, options.Gateway, func() ([]string, error) {
+			return nil, d.ping(options.Gateway, 0, options.Timeout)
+		}})
+	}
+
+	if options.Gateway != "" && options.MTU > 0 {
+		checks = append(checks, check{CheckMTU, options.Gateway, func() ([]string, error) {
+			// Packets of MTU bytes that may not be fragmented only get
+			// through when every hop supports the MTU
+			return nil, d.ping(options.Gateway, options.MTU-icmpHeadersSize, options.Timeout)
+		}})
+	}
+
+	for _, host := range options.Hosts {
+		host := host
+		checks = append(checks, check{CheckDNS, host, func() ([]string, error) {
+			ctx, cancelLookup := context.WithTimeout(context.Background(), options.Timeout)
+			defer cancelLookup()
+
+			return d.resolver.LookupHost(ctx, host)
+		}})
+	}
+
+	for _, address := range options.Addresses {
+		address := address
+		checks = append(checks, check{CheckTCP, address, func() ([]string, error) {
+			conn, err := net.DialTimeout("tcp", address, options.Timeout)
+			if err != nil {
+				return nil, err
+			}
+
+			defer conn.Close() //nolint:errcheck
+			return []string{conn.RemoteAddr().String()}, nil
+		}})
+	}
+
+	results := []Result{}
+
+	for _, check := range checks {
+		err := cancel.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		startedAt := d.timeService.Now()
+		addresses, err := check.run()
+
+		result := Result{
+			Check:          check.name,
+			Target:         check.target,
+			Succeeded:      err == nil,
+			Addresses:      addresses,
+			DurationMillis: d.timeService.Since(startedAt).Milliseconds(),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (d diagnoser) ping(host string, payloadSize int, timeout time.Duration) error {
+	_, _, _, err := d.cmdRunner.RunCommand("ping", pingArgs(host, payloadSize, timeout)...)
+	return err
+}
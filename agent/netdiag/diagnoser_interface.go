@@ -0,0 +1,46 @@
+package netdiag
+
+import (
+	"time"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+const (
+	CheckGateway = "gateway"
+	CheckDNS     = "dns"
+	CheckTCP     = "tcp"
+	CheckMTU     = "mtu"
+)
+
+//go:generate counterfeiter . Diagnoser
+
+type Diagnoser interface {
+	// Diagnose runs the checks selected by options one after the other and
+	// returns their results. Failing checks do not stop the others.
+	Diagnose(options Options, cancel *boshtask.CancelSignal) ([]Result, error)
+}
+
+// Options select the checks to run. Gateway is pinged when PingGateway is
+// set, and probed with packets of MTU bytes that must not be fragmented
+// when MTU is set. Hosts are resolved and TCP connections are opened to
+// Addresses, which are host:port pairs. Each check gives up after Timeout.
+type Options struct {
+	Gateway     string
+	PingGateway bool
+	MTU         int
+
+	Hosts     []string
+	Addresses []string
+	Timeout   time.Duration
+}
+
+type Result struct {
+	Check     string   `json:"check"`
+	Target    string   `json:"target"`
+	Succeeded bool     `json:"succeeded"`
+	Addresses []string `json:"addresses,omitempty"`
+	Error     string   `json:"error,omitempty"`
+
+	DurationMillis int64 `json:"duration_ms"`
+}
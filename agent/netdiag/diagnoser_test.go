@@ -0,0 +1,100 @@
+//go:build !windows
+// +build !windows
+
+package netdiag_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/netdiag"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+type fakeResolver struct {
+	addresses map[string][]string
+}
+
+func (r fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	addresses, found := r.addresses[host]
+	if !found {
+		return nil, errors.New("no such host")
+	}
+	return addresses, nil
+}
+
+var _ = Describe("Diagnoser", func() {
+	var (
+		cmdRunner *fakesys.FakeCmdRunner
+		resolver  fakeResolver
+		diagnoser netdiag.Diagnoser
+	)
+
+	BeforeEach(func() {
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		resolver = fakeResolver{addresses: map[string][]string{"director.example.com": {"10.0.0.6"}}}
+		diagnoser = netdiag.NewDiagnoser(cmdRunner, resolver, fakeclock.NewFakeClock(time.Now()))
+	})
+
+	It("pings the gateway and probes its MTU", func() {
+		cmdRunner.AddCmdResult("ping -c 1 -W 5 -M do -s 1472 10.0.0.1", fakesys.FakeCmdResult{Error: errors.New("fake-ping-err")})
+
+		results, err := diagnoser.Diagnose(netdiag.Options{Gateway: "10.0.0.1", PingGateway: true, MTU: 1500, Timeout: 5 * time.Second}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cmdRunner.RunCommands).To(Equal([][]string{
+			{"ping", "-c", "1", "-W", "5", "10.0.0.1"},
+			{"ping", "-c", "1", "-W", "5", "-M", "do", "-s", "1472", "10.0.0.1"},
+		}))
+		Expect(results).To(Equal([]netdiag.Result{
+			{Check: "gateway", Target: "10.0.0.1", Succeeded: true},
+			{Check: "mtu", Target: "10.0.0.1", Error: "fake-ping-err"},
+		}))
+	})
+
+	It("resolves hosts", func() {
+		results, err := diagnoser.Diagnose(netdiag.Options{Hosts: []string{"director.example.com", "nats.example.com"}, Timeout: time.Second}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(results).To(Equal([]netdiag.Result{
+			{Check: "dns", Target: "director.example.com", Succeeded: true, Addresses: []string{"10.0.0.6"}},
+			{Check: "dns", Target: "nats.example.com", Error: "no such host"},
+		}))
+	})
+
+	It("connects to addresses", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		address := listener.Addr().String()
+
+		closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		closedAddress := closedListener.Addr().String()
+		Expect(closedListener.Close()).To(Succeed())
+
+		results, err := diagnoser.Diagnose(netdiag.Options{Addresses: []string{address, closedAddress}, Timeout: time.Second}, nil)
+		Expect(listener.Close()).To(Succeed())
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(results).To(HaveLen(2))
+		Expect(results[0]).To(Equal(netdiag.Result{Check: "tcp", Target: address, Succeeded: true, Addresses: []string{address}}))
+		Expect(results[1].Succeeded).To(BeFalse())
+		Expect(results[1].Error).To(ContainSubstring("connection refused"))
+	})
+
+	It("stops running checks when cancelled", func() {
+		cancelCh := make(chan struct{}, 1)
+		cancelCh <- struct{}{}
+
+		_, err := diagnoser.Diagnose(netdiag.Options{Gateway: "10.0.0.1", PingGateway: true}, boshtask.NewCancelSignal(cancelCh))
+		Expect(err).To(Equal(boshtask.ErrCancelled))
+		Expect(cmdRunner.RunCommands).To(BeEmpty())
+	})
+})
@@ -0,0 +1,13 @@
+package netdiag_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestNetDiag(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Network Diagnostics Suite")
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package netdiag
+
+import (
+	"strconv"
+	"time"
+)
+
+// pingArgs sends a single ping, with payloadSize bytes which may not be
+// fragmented unless payloadSize is 0
+func pingArgs(host string, payloadSize int, timeout time.Duration) []string {
+	args := []string{"-c", "1", "-W", seconds(timeout)}
+	if payloadSize > 0 {
+		args = append(args, "-M", "do", "-s", strconv.Itoa(payloadSize))
+	}
+	return append(args, host)
+}
+
+func seconds(timeout time.Duration) string {
+	secs := int(timeout / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return strconv.Itoa(secs)
+}
@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package netdiag
+
+import (
+	"strconv"
+	"time"
+)
+
+// pingArgs sends a single ping, with payloadSize bytes which may not be
+// fragmented unless payloadSize is 0
+func pingArgs(host string, payloadSize int, timeout time.Duration) []string {
+	args := []string{"-n", "1", "-w", strconv.FormatInt(timeout.Milliseconds(), 10)}
+	if payloadSize > 0 {
+		args = append(args, "-f", "-l", strconv.Itoa(payloadSize))
+	}
+	return append(args, host)
+}
@@ -0,0 +1,303 @@
+package profiler
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+const (
+	CPUProfile = "cpu"
+
+	// Processes take a moment to write their stacks after SIGQUIT
+	stackDumpWait = 2 * time.Second
+
+	maxStackDumpBytes = 8 * 1024 * 1024
+
+	cancelPollInterval = time.Second
+)
+
+type profiler struct {
+	fs          boshsys.FileSystem
+	cmdRunner   boshsys.CmdRunner
+	dirProvider boshdir.Provider
+	timeService clock.Clock
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewProfiler(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	dirProvider boshdir.Provider,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) Profiler {
+	return profiler{
+		fs:          fs,
+		cmdRunner:   cmdRunner,
+		dirProvider: dirProvider,
+		timeService: timeService,
+
+		logTag: "profiler",
+		logger: logger,
+	}
+}
+
+func (p profiler) Capture(options Options, cancel *boshtask.CancelSignal) (string, error) {
+	for _, name := range options.Profiles {
+		if name != CPUProfile && pprof.Lookup(name) == nil {
+			return "", bosherr.Errorf("Unknown profile '%s'", name)
+		}
+	}
+
+	tarball, err := p.fs.TempFile("bosh-agent-profile")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating profile tarball")
+	}
+
+	err = p.writeTarball(tarball, options, cancel)
+
+	closeErr := tarball.Close()
+	if err == nil && closeErr != nil {
+		err = bosherr.WrapError(closeErr, "Closing profile tarball")
+	}
+
+	if err != nil {
+		_ = p.fs.RemoveAll(tarball.Name()) //nolint:errcheck
+		return "", err
+	}
+
+	return tarball.Name(), nil
+}
+
+func (p profiler) writeTarball(w io.Writer, options Options, cancel *boshtask.CancelSignal) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, name := range options.Profiles {
+		var (
+			profile bytes.Buffer
+			err     error
+		)
+
+		if name == CPUProfile {
+			err = p.profileCPU(&profile, options.CPUDuration, cancel)
+		} else {
+			// Goroutines are written as text stack traces like the ones of
+			// panics, the other profiles in the protobuf format pprof reads
+			err = pprof.Lookup(name).WriteTo(&profile, goroutineDebug(name))
+		}
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Profiling %s", name)
+		}
+
+		err = addEntry(tarWriter, profileFileName(name), profile.Bytes())
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Adding %s profile", name)
+		}
+	}
+
+	if len(options.StackDumpJobs) > 0 {
+		err := p.dumpStacks(tarWriter, options.StackDumpJobs, cancel)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tarWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Finishing profile tarball")
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Compressing profile tarball")
+	}
+
+	return nil
+}
+
+// profileCPU profiles the agent for duration, or until its task is cancelled
+func (p profiler) profileCPU(w io.Writer, duration time.Duration, cancel *boshtask.CancelSignal) error {
+	err := pprof.StartCPUProfile(w)
+	if err != nil {
+		return err
+	}
+	defer pprof.StopCPUProfile()
+
+	ticker := p.timeService.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+
+	deadline := p.timeService.NewTimer(duration)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			if err := cancel.Err(); err != nil {
+				return err
+			}
+
+		case <-deadline.C():
+			return nil
+		}
+	}
+}
+
+// dumpStacks signals the processes of jobs to dump their stacks and adds
+// what they appended to their logs
+func (p profiler) dumpStacks(tarWriter *tar.Writer, jobs []string, cancel *boshtask.CancelSignal) error {
+	type logSize struct {
+		path string
+		size int64
+	}
+
+	var logSizes []logSize
+
+	for _, job := range jobs {
+		logs, err := p.fs.Glob(filepath.Join(p.dirProvider.LogsDir(), job, "*.log"))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Finding logs of job %s", job)
+		}
+
+		for _, log := range logs {
+			info, err := p.fs.Stat(log)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Checking log %s", log)
+			}
+
+			logSizes = append(logSizes, logSize{path: log, size: info.Size()})
+		}
+	}
+
+	for _, job := range jobs {
+		err := p.signalProcesses(job)
+		if err != nil {
+			return err
+		}
+	}
+
+	p.timeService.Sleep(stackDumpWait)
+
+	err := cancel.Err()
+	if err != nil {
+		return err
+	}
+
+	for _, log := range logSizes {
+		appended, err := p.readFrom(log.path, log.size)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading stack dumps in %s", log.path)
+		}
+
+		if len(appended) == 0 {
+			continue
+		}
+
+		relativePath, err := filepath.Rel(p.dirProvider.LogsDir(), log.path)
+		if err != nil {
+			return err
+		}
+
+		err = addEntry(tarWriter, path.Join("stacks", filepath.ToSlash(relativePath)), appended)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Adding stack dumps in %s", log.path)
+		}
+	}
+
+	return nil
+}
+
+// signalProcesses sends SIGQUIT to the processes of the job, found by the
+// pid files they keep in the run directory of the job
+func (p profiler) signalProcesses(job string) error {
+	pidFiles, err := p.fs.Glob(filepath.Join(p.dirProvider.JobRunDir(job), "*.pid"))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Finding processes of job %s", job)
+	}
+
+	if len(pidFiles) == 0 {
+		return bosherr.Errorf("Job %s has no processes to dump the stacks of", job)
+	}
+
+	for _, pidFile := range pidFiles {
+		pid, err := p.fs.ReadFileString(pidFile)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading pid file %s", pidFile)
+		}
+
+		// Pid files of stopped processes are left behind
+		_, _, _, err = p.cmdRunner.RunCommand("kill", "-s", "QUIT", strings.TrimSpace(pid))
+		if err != nil {
+			p.logger.Warn(p.logTag, "Failed to signal process in %s: %s", pidFile, err)
+		}
+	}
+
+	return nil
+}
+
+func (p profiler) readFrom(log string, offset int64) ([]byte, error) {
+	file, err := p.fs.OpenFile(log, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(io.LimitReader(file, maxStackDumpBytes))
+}
+
+func (p profiler) CleanUp(tarballPath string) error {
+	return p.fs.RemoveAll(tarballPath)
+}
+
+func goroutineDebug(name string) int {
+	if name == "goroutine" {
+		return 2
+	}
+	return 0
+}
+
+func profileFileName(name string) string {
+	if name == "goroutine" {
+		return "goroutine.txt"
+	}
+	return name + ".pprof"
+}
+
+func addEntry(tarWriter *tar.Writer, name string, contents []byte) error {
+	err := tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "./" + name,
+		Mode:     0644,
+		Size:     int64(len(contents)),
+		ModTime:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tarWriter.Write(contents)
+	return err
+}
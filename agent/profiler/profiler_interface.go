@@ -0,0 +1,27 @@
+package profiler
+
+import (
+	"time"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+//go:generate counterfeiter . Profiler
+
+type Profiler interface {
+	// Capture writes a gzipped tarball with the profiles of the agent and the
+	// stack dumps of job processes selected by options and returns its path
+	Capture(options Options, cancel *boshtask.CancelSignal) (string, error)
+	CleanUp(tarballPath string) error
+}
+
+// Options select what to capture. Profiles are named like runtime/pprof
+// profiles, e.g. heap or goroutine, or cpu for a CPU profile taken for
+// CPUDuration. The processes of StackDumpJobs are sent SIGQUIT to dump
+// their stacks to their logs, which only suits processes that survive it,
+// like Java ones.
+type Options struct {
+	Profiles      []string
+	CPUDuration   time.Duration
+	StackDumpJobs []string
+}
@@ -0,0 +1,160 @@
+package profiler_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/profiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+var _ = Describe("Profiler", func() {
+	var (
+		fs          boshsys.FileSystem
+		cmdRunner   *fakesys.FakeCmdRunner
+		dirProvider boshdir.Provider
+		timeService *fakeclock.FakeClock
+		p           profiler.Profiler
+	)
+
+	readTarball := func(tarballPath string) map[string]string {
+		file, err := os.Open(tarballPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close() //nolint:errcheck
+
+		gzipReader, err := gzip.NewReader(file)
+		Expect(err).ToNot(HaveOccurred())
+
+		entries := map[string]string{}
+
+		tarReader := tar.NewReader(gzipReader)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				return entries
+			}
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := io.ReadAll(tarReader)
+			Expect(err).ToNot(HaveOccurred())
+			entries[header.Name] = string(contents)
+		}
+	}
+
+	capture := func(options profiler.Options, cancel *boshtask.CancelSignal) (string, error) {
+		var (
+			tarballPath string
+			err         error
+		)
+
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			tarballPath, err = p.Capture(options, cancel)
+		}()
+
+		// Move the clock along until capturing is done
+		for {
+			select {
+			case <-done:
+				return tarballPath, err
+			case <-time.After(10 * time.Millisecond):
+				timeService.Increment(time.Second)
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		dirProvider = boshdir.NewProvider(GinkgoT().TempDir())
+		timeService = fakeclock.NewFakeClock(time.Now())
+		p = profiler.NewProfiler(fs, cmdRunner, dirProvider, timeService, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("captures the requested profiles of the agent", func() {
+		tarballPath, err := capture(profiler.Options{
+			Profiles:    []string{"cpu", "heap", "goroutine"},
+			CPUDuration: 3 * time.Second,
+		}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		entries := readTarball(tarballPath)
+		Expect(entries).To(HaveLen(3))
+		Expect(entries).To(HaveKey("./cpu.pprof"))
+		Expect(entries["./heap.pprof"]).ToNot(BeEmpty())
+		Expect(entries["./goroutine.txt"]).To(ContainSubstring("goroutine "))
+
+		Expect(p.CleanUp(tarballPath)).To(Succeed())
+		Expect(fs.FileExists(tarballPath)).To(BeFalse())
+	})
+
+	It("returns an error for unknown profiles", func() {
+		_, err := p.Capture(profiler.Options{Profiles: []string{"fake-profile"}}, nil)
+		Expect(err).To(MatchError("Unknown profile 'fake-profile'"))
+	})
+
+	It("stops profiling the CPU when cancelled", func() {
+		cancelCh := make(chan struct{}, 1)
+		cancelCh <- struct{}{}
+
+		_, err := capture(profiler.Options{Profiles: []string{"cpu"}, CPUDuration: time.Minute}, boshtask.NewCancelSignal(cancelCh))
+		Expect(err).To(MatchError(ContainSubstring(boshtask.ErrCancelled.Error())))
+	})
+
+	Describe("stack dumps", func() {
+		var logPath string
+
+		BeforeEach(func() {
+			Expect(fs.WriteFileString(filepath.Join(dirProvider.JobRunDir("web"), "web.pid"), "1234\n")).To(Succeed())
+
+			logPath = filepath.Join(dirProvider.LogsDir(), "web", "web.stdout.log")
+			Expect(fs.WriteFileString(logPath, "before the dump\n")).To(Succeed())
+
+			cmdRunner.SetCmdCallback("kill -s QUIT 1234", func() {
+				file, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0)
+				Expect(err).ToNot(HaveOccurred())
+				defer file.Close() //nolint:errcheck
+
+				_, err = file.WriteString("Full thread dump\n")
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		It("signals the job processes and captures the stacks they dump to their logs", func() {
+			tarballPath, err := capture(profiler.Options{StackDumpJobs: []string{"web"}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"kill", "-s", "QUIT", "1234"}}))
+			Expect(readTarball(tarballPath)).To(Equal(map[string]string{
+				"./stacks/web/web.stdout.log": "Full thread dump\n",
+			}))
+		})
+
+		It("carries on when processes cannot be signalled", func() {
+			cmdRunner.AddCmdResult("kill -s QUIT 1234", fakesys.FakeCmdResult{Error: errors.New("fake-kill-err")})
+
+			_, err := capture(profiler.Options{StackDumpJobs: []string{"web"}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error for jobs without processes", func() {
+			_, err := capture(profiler.Options{StackDumpJobs: []string{"db"}}, nil)
+			Expect(err).To(MatchError("Job db has no processes to dump the stacks of"))
+		})
+	})
+})
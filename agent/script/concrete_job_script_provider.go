@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"path"
 	"path/filepath"
+	"time"
 
 	"code.cloudfoundry.org/clock"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshdrain "github.com/cloudfoundry/bosh-agent/v2/agent/script/drain"
 	boshdir "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
@@ -38,22 +40,37 @@ func NewConcreteJobScriptProvider(
 	}
 }
 
-func (p ConcreteJobScriptProvider) NewScript(jobName string, scriptName string, scriptEnv map[string]string) Script {
+func (p ConcreteJobScriptProvider) NewScript(jobName string, scriptName string, scriptEnv map[string]string, scriptArgs []string, timeout time.Duration) Script {
+	return p.newScript(jobName, scriptName, scriptEnv, scriptArgs, timeout)
+}
+
+func (p ConcreteJobScriptProvider) NewStreamingScript(jobName string, scriptName string, scriptEnv map[string]string, scriptArgs []string, timeout time.Duration, output OutputFunc) Script {
+	return p.newScript(jobName, scriptName, scriptEnv, scriptArgs, timeout).StreamOutput(jobName, scriptName, output)
+}
+
+func (p ConcreteJobScriptProvider) newScript(jobName string, scriptName string, scriptEnv map[string]string, scriptArgs []string, timeout time.Duration) GenericScript {
 	path := path.Join(p.dirProvider.JobBinDir(jobName), scriptName+ScriptExt)
 
+	stdoutLogPath, stderrLogPath := LogPaths(p.dirProvider, jobName, scriptName)
+
+	return NewScript(p.fs, p.cmdRunner, jobName, path, stdoutLogPath, stderrLogPath, scriptEnv, scriptArgs, timeout, p.timeService)
+}
+
+// LogPaths returns the files the output of a job script is appended to
+func LogPaths(dirProvider boshdir.Provider, jobName string, scriptName string) (string, string) {
 	stdoutLogFilename := fmt.Sprintf("%s.stdout.log", scriptName)
-	stdoutLogPath := filepath.Join(p.dirProvider.LogsDir(), jobName, stdoutLogFilename)
+	stdoutLogPath := filepath.Join(dirProvider.LogsDir(), jobName, stdoutLogFilename)
 
 	stderrLogFilename := fmt.Sprintf("%s.stderr.log", scriptName)
-	stderrLogPath := filepath.Join(p.dirProvider.LogsDir(), jobName, stderrLogFilename)
+	stderrLogPath := filepath.Join(dirProvider.LogsDir(), jobName, stderrLogFilename)
 
-	return NewScript(p.fs, p.cmdRunner, jobName, path, stdoutLogPath, stderrLogPath, scriptEnv)
+	return stdoutLogPath, stderrLogPath
 }
 
-func (p ConcreteJobScriptProvider) NewDrainScript(jobName string, params boshdrain.ScriptParams) CancellableScript {
+func (p ConcreteJobScriptProvider) NewDrainScript(jobName string, params boshdrain.ScriptParams, limits models.JobDrain) CancellableScript {
 	path := path.Join(p.dirProvider.JobsDir(), jobName, "bin", "drain"+ScriptExt)
 
-	return boshdrain.NewConcreteScript(p.fs, p.cmdRunner, jobName, path, params, p.timeService, p.logger)
+	return boshdrain.NewConcreteScript(p.fs, p.cmdRunner, jobName, path, params, limits, p.timeService, p.logger)
 }
 
 func (p ConcreteJobScriptProvider) NewParallelScript(scriptName string, scripts []Script) CancellableScript {
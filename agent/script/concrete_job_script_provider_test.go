@@ -1,6 +1,8 @@
 package script_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -9,6 +11,7 @@ import (
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
 	fakeaction "github.com/cloudfoundry/bosh-agent/v2/agent/action/fakes"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
 	boshdrain "github.com/cloudfoundry/bosh-agent/v2/agent/script/drain"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/script/drain/drainfakes"
@@ -39,7 +42,7 @@ var _ = Describe("ConcreteJobScriptProvider", func() {
 
 	Describe("NewScript", func() {
 		It("returns script with relative job paths to the base directory", func() {
-			script := scriptProvider.NewScript("myjob", "the-best-hook-ever", scriptEnv)
+			script := scriptProvider.NewScript("myjob", "the-best-hook-ever", scriptEnv, nil, 0)
 			Expect(script.Tag()).To(Equal("myjob"))
 
 			expPath := "/the/base/dir/jobs/myjob/bin/the-best-hook-ever" + boshscript.ScriptExt
@@ -50,12 +53,13 @@ var _ = Describe("ConcreteJobScriptProvider", func() {
 	Describe("NewDrainScript", func() {
 		It("returns drain script", func() {
 			params := &drainfakes.FakeScriptParams{}
-			script := scriptProvider.NewDrainScript("foo", params)
+			script := scriptProvider.NewDrainScript("foo", params, models.JobDrain{MaxDuration: time.Minute})
 			Expect(script.Tag()).To(Equal("foo"))
 
 			expPath := "/the/base/dir/jobs/foo/bin/drain" + boshscript.ScriptExt
 			Expect(script.Path()).To(boshassert.MatchPath(expPath))
 			Expect(script.(boshdrain.ConcreteScript).Params()).To(Equal(params))
+			Expect(script.(boshdrain.ConcreteScript).Limits()).To(Equal(models.JobDrain{MaxDuration: time.Minute}))
 		})
 	})
 
@@ -10,6 +10,7 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/script/cmd"
 )
 
@@ -20,6 +21,7 @@ type ConcreteScript struct {
 	tag    string
 	path   string
 	params ScriptParams
+	limits models.JobDrain
 
 	timeService clock.Clock
 	logTag      string
@@ -34,6 +36,7 @@ func NewConcreteScript(
 	tag string,
 	path string,
 	params ScriptParams,
+	limits models.JobDrain,
 	timeService clock.Clock,
 	logger boshlog.Logger,
 ) ConcreteScript {
@@ -44,6 +47,7 @@ func NewConcreteScript(
 		tag:    tag,
 		path:   path,
 		params: params,
+		limits: limits,
 
 		timeService: timeService,
 
@@ -54,30 +58,79 @@ func NewConcreteScript(
 	}
 }
 
-func (s ConcreteScript) Tag() string          { return s.tag }
-func (s ConcreteScript) Path() string         { return s.path }
-func (s ConcreteScript) Params() ScriptParams { return s.params }
-func (s ConcreteScript) Exists() bool         { return s.fs.FileExists(s.path) }
+func (s ConcreteScript) Tag() string             { return s.tag }
+func (s ConcreteScript) Path() string            { return s.path }
+func (s ConcreteScript) Params() ScriptParams    { return s.params }
+func (s ConcreteScript) Limits() models.JobDrain { return s.limits }
+func (s ConcreteScript) Exists() bool            { return s.fs.FileExists(s.path) }
 
+// Run runs the script until it is done waiting, which is bounded by the
+// maximum drain duration of the job when there is one
 func (s ConcreteScript) Run() error {
 	params := s.params
 
+	var deadline <-chan time.Time
+	if s.limits.MaxDuration > 0 {
+		timer := s.timeService.NewTimer(s.limits.MaxDuration)
+		defer timer.Stop()
+		deadline = timer.C()
+	}
+
 	for {
-		value, err := s.runOnce(params)
+		value, timedOut, err := s.runOnce(params, deadline)
 		if err != nil {
 			return err
 		}
 
+		if timedOut {
+			return s.timedOut()
+		}
+
+		// Negative values ask to wait and run the script again for its status
+		wait := time.Duration(value) * time.Second
 		if value < 0 {
-			s.timeService.Sleep(time.Duration(-value) * time.Second)
-			params = params.ToStatusParams()
-		} else {
-			s.timeService.Sleep(time.Duration(value) * time.Second)
+			wait = -wait
+		}
+
+		if s.sleep(wait, deadline) {
+			return s.timedOut()
+		}
+
+		if value >= 0 {
 			return nil
 		}
+
+		params = params.ToStatusParams()
 	}
 }
 
+// sleep reports whether the deadline passed before it slept for duration
+func (s ConcreteScript) sleep(duration time.Duration, deadline <-chan time.Time) bool {
+	if deadline == nil {
+		s.timeService.Sleep(duration)
+		return false
+	}
+
+	timer := s.timeService.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return false
+	case <-deadline:
+		return true
+	}
+}
+
+func (s ConcreteScript) timedOut() error {
+	if s.limits.ContinueOnTimeout {
+		s.logger.Warn(s.logTag, "Drain script '%s' did not finish within %s, continuing", s.path, s.limits.MaxDuration)
+		return nil
+	}
+
+	return bosherr.Errorf("Drain script did not finish within %s", s.limits.MaxDuration)
+}
+
 func (s ConcreteScript) Cancel() error {
 	select {
 	case s.cancelCh <- struct{}{}:
@@ -86,7 +139,7 @@ func (s ConcreteScript) Cancel() error {
 	return nil
 }
 
-func (s ConcreteScript) runOnce(params ScriptParams) (int, error) {
+func (s ConcreteScript) runOnce(params ScriptParams, deadline <-chan time.Time) (int, bool, error) {
 	jobChange := params.JobChange()
 	hashChange := params.HashChange()
 	updatedPkgs := params.UpdatedPackages()
@@ -95,7 +148,7 @@ func (s ConcreteScript) runOnce(params ScriptParams) (int, error) {
 
 	jobState, err := params.JobState()
 	if err != nil {
-		return 0, bosherr.WrapError(err, "Getting job state")
+		return 0, false, bosherr.WrapError(err, "Getting job state")
 	}
 
 	if jobState != "" {
@@ -104,7 +157,7 @@ func (s ConcreteScript) runOnce(params ScriptParams) (int, error) {
 
 	jobNextState, err := params.JobNextState()
 	if err != nil {
-		return 0, bosherr.WrapError(err, "Getting job next state")
+		return 0, false, bosherr.WrapError(err, "Getting job next state")
 	}
 
 	if jobNextState != "" {
@@ -116,44 +169,55 @@ func (s ConcreteScript) runOnce(params ScriptParams) (int, error) {
 
 	process, err := s.runner.RunComplexCommandAsync(command)
 	if err != nil {
-		return 0, bosherr.WrapError(err, "Running drain script")
+		return 0, false, bosherr.WrapError(err, "Running drain script")
 	}
 
 	var result boshsys.Result
 
 	isCanceled := false
+	isTimedOut := false
 
 	// Can only wait once on a process but cancelling can happen multiple times
 	for processExitedCh := process.Wait(); processExitedCh != nil; {
 		select {
 		case result = <-processExitedCh:
 			processExitedCh = nil
+			continue
 		case <-s.cancelCh:
-			// Ignore possible TerminateNicely error since we cannot return it
-			err := process.TerminateNicely(10 * time.Second)
-			if err != nil {
-				s.logger.Error(s.logTag, "Failed to terminate %s", err.Error())
-			}
 			isCanceled = true
+		case <-deadline:
+			// The deadline only passes once
+			deadline = nil
+			isTimedOut = true
+		}
+
+		// Ignore possible TerminateNicely error since we cannot return it
+		err := process.TerminateNicely(10 * time.Second)
+		if err != nil {
+			s.logger.Error(s.logTag, "Failed to terminate %s", err.Error())
 		}
 	}
 
 	if isCanceled {
 		if result.Error != nil {
-			return 0, bosherr.WrapError(result.Error, "Script was cancelled by user request")
+			return 0, false, bosherr.WrapError(result.Error, "Script was cancelled by user request")
 		}
 
-		return 0, bosherr.Error("Script was cancelled by user request")
+		return 0, false, bosherr.Error("Script was cancelled by user request")
+	}
+
+	if isTimedOut {
+		return 0, true, nil
 	}
 
 	if result.Error != nil && result.ExitStatus == -1 {
-		return 0, bosherr.WrapError(result.Error, "Running drain script")
+		return 0, false, bosherr.WrapError(result.Error, "Running drain script")
 	}
 
 	value, err := strconv.Atoi(strings.TrimSpace(result.Stdout))
 	if err != nil {
-		return 0, bosherr.WrapError(err, "Script did not return a signed integer")
+		return 0, false, bosherr.WrapError(err, "Script did not return a signed integer")
 	}
 
-	return value, nil
+	return value, false, nil
 }
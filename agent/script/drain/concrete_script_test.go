@@ -5,6 +5,7 @@ import (
 	"runtime"
 	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -15,6 +16,7 @@ import (
 
 	fakeaction "github.com/cloudfoundry/bosh-agent/v2/agent/action/fakes"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	. "github.com/cloudfoundry/bosh-agent/v2/agent/script/drain"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/script/drain/drainfakes"
 	boshenv "github.com/cloudfoundry/bosh-agent/v2/agent/script/pathenv"
@@ -51,7 +53,7 @@ var _ = Describe("ConcreteScript", func() {
 
 	JustBeforeEach(func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		script = NewConcreteScript(fs, runner, "my-tag", "/fake/script", params, fakeClock, logger)
+		script = NewConcreteScript(fs, runner, "my-tag", "/fake/script", params, models.JobDrain{}, fakeClock, logger)
 	})
 
 	Describe("Tag", func() {
@@ -200,6 +202,78 @@ var _ = Describe("ConcreteScript", func() {
 			Expect(err).To(HaveOccurred())
 		})
 
+		Context("when the job has a maximum drain duration", func() {
+			var (
+				limits    models.JobDrain
+				timeClock *fakeclock.FakeClock
+				errCh     chan error
+			)
+
+			BeforeEach(func() {
+				limits = models.JobDrain{MaxDuration: 30 * time.Second}
+				timeClock = fakeclock.NewFakeClock(time.Now())
+				errCh = make(chan error, 1)
+			})
+
+			run := func() {
+				logger := boshlog.NewLogger(boshlog.LevelNone)
+				script = NewConcreteScript(fs, runner, "my-tag", "/fake/script", params, limits, timeClock, logger)
+				go func() { errCh <- script.Run() }()
+			}
+
+			It("succeeds when the script is done waiting within it", func() {
+				runner.AddProcess(jobChangedFullCommand,
+					&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: "5"}})
+
+				run()
+				timeClock.WaitForNWatchersAndIncrement(5*time.Second, 2)
+
+				Eventually(errCh).Should(Receive(BeNil()))
+			})
+
+			It("terminates the script when it keeps running past it", func() {
+				process := &fakesys.FakeProcess{
+					TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+						p.WaitCh <- boshsys.Result{ExitStatus: 143, Error: errors.New("Terminated")}
+					},
+				}
+				runner.AddProcess(jobChangedFullCommand, process)
+
+				run()
+				timeClock.WaitForWatcherAndIncrement(30 * time.Second)
+
+				Eventually(errCh).Should(Receive(MatchError("Drain script did not finish within 30s")))
+				Expect(process.TerminatedNicely).To(BeTrue())
+			})
+
+			It("fails when the script asks to wait past it", func() {
+				runner.AddProcess(jobChangedFullCommand,
+					&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: "-60"}})
+
+				run()
+				timeClock.WaitForNWatchersAndIncrement(30*time.Second, 2)
+
+				Eventually(errCh).Should(Receive(MatchError("Drain script did not finish within 30s")))
+				Expect(runner.RunComplexCommands).To(HaveLen(1))
+			})
+
+			Context("when the job continues on timeouts", func() {
+				BeforeEach(func() {
+					limits.ContinueOnTimeout = true
+				})
+
+				It("succeeds once the script times out", func() {
+					runner.AddProcess(jobChangedFullCommand,
+						&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: "60"}})
+
+					run()
+					timeClock.WaitForNWatchersAndIncrement(30*time.Second, 2)
+
+					Eventually(errCh).Should(Receive(BeNil()))
+				})
+			})
+		})
+
 		Describe("job state", func() {
 			BeforeEach(func() {
 				runner.AddProcess(jobChangedFullCommand,
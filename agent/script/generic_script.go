@@ -1,9 +1,13 @@
 package script
 
 import (
+	"io"
 	"os"
 	"path/filepath"
+	"time"
 
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/script/cmd"
@@ -12,6 +16,8 @@ import (
 const (
 	fileOpenFlag int         = os.O_RDWR | os.O_CREATE | os.O_APPEND
 	fileOpenPerm os.FileMode = os.FileMode(0640)
+
+	timedOutKillGracePeriod = 10 * time.Second
 )
 
 type GenericScript struct {
@@ -24,7 +30,14 @@ type GenericScript struct {
 	stdoutLogPath string
 	stderrLogPath string
 
-	env map[string]string
+	env  map[string]string
+	args []string
+
+	timeout     time.Duration
+	timeService clock.Clock
+
+	outputLine OutputLine
+	output     OutputFunc
 }
 
 func NewScript(
@@ -35,6 +48,9 @@ func NewScript(
 	stdoutLogPath string,
 	stderrLogPath string,
 	env map[string]string,
+	args []string,
+	timeout time.Duration,
+	timeService clock.Clock,
 ) GenericScript {
 	return GenericScript{
 		fs:     fs,
@@ -46,10 +62,23 @@ func NewScript(
 		stdoutLogPath: stdoutLogPath,
 		stderrLogPath: stderrLogPath,
 
-		env: env,
+		env:  env,
+		args: args,
+
+		timeout:     timeout,
+		timeService: timeService,
 	}
 }
 
+// StreamOutput makes the script pass each line it writes to output in
+// addition to appending it to its logs. The lines are tagged with the job
+// and script name.
+func (s GenericScript) StreamOutput(jobName, scriptName string, output OutputFunc) GenericScript {
+	s.outputLine = OutputLine{Job: jobName, Script: scriptName}
+	s.output = output
+	return s
+}
+
 func (s GenericScript) Tag() string  { return s.tag }
 func (s GenericScript) Path() string { return s.path }
 func (s GenericScript) Exists() bool { return s.fs.FileExists(s.path) }
@@ -81,17 +110,62 @@ func (s GenericScript) Run() error {
 		_ = stderrFile.Close() //nolint:errcheck
 	}()
 
+	var stdout, stderr io.Writer = stdoutFile, stderrFile
+
+	if s.output != nil {
+		stdoutLine, stderrLine := s.outputLine, s.outputLine
+		stdoutLine.Stream, stderrLine.Stream = "stdout", "stderr"
+
+		stdoutLines := newOutputLineWriter(stdoutLine, s.output)
+		defer stdoutLines.Flush()
+		stdout = io.MultiWriter(stdoutFile, stdoutLines)
+
+		stderrLines := newOutputLineWriter(stderrLine, s.output)
+		defer stderrLines.Flush()
+		stderr = io.MultiWriter(stderrFile, stderrLines)
+	}
+
 	command := cmd.BuildCommand(s.path)
-	command.Stdout = stdoutFile
-	command.Stderr = stderrFile
+	command.Args = append(command.Args, s.args...)
+	command.Stdout = stdout
+	command.Stderr = stderr
 
 	for key, val := range s.env {
 		command.Env[key] = val
 	}
 
-	_, _, _, err = s.runner.RunComplexCommand(command) //nolint:errcheck
+	if s.timeout <= 0 {
+		_, _, _, err = s.runner.RunComplexCommand(command) //nolint:errcheck
+		return err
+	}
+
+	return s.runWithTimeout(command)
+}
+
+// runWithTimeout terminates the script when it is still running once the
+// timeout is over
+func (s GenericScript) runWithTimeout(command boshsys.Command) error {
+	process, err := s.runner.RunComplexCommandAsync(command)
+	if err != nil {
+		return err
+	}
+
+	timer := s.timeService.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	processExitedCh := process.Wait()
 
-	return err
+	select {
+	case result := <-processExitedCh:
+		return result.Error
+
+	case <-timer.C():
+		// Ignore possible TerminateNicely error since the script timing out is reported instead
+		_ = process.TerminateNicely(timedOutKillGracePeriod) //nolint:errcheck
+		<-processExitedCh
+
+		return bosherr.Errorf("Script timed out after %s", s.timeout)
+	}
 }
 
 func (s GenericScript) ensureContainingDir(fullLogFilename string) error {
@@ -4,10 +4,14 @@ import (
 	"errors"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
 	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
@@ -43,11 +47,14 @@ var _ = Describe("GenericScript", func() {
 			stdoutLogPath,
 			stderrLogPath,
 			scriptEnv,
+			[]string{"--verbose", "fake-arg"},
+			0,
+			nil,
 		)
 		if runtime.GOOS == "windows" {
-			fullCommand = "powershell /path-to-script"
+			fullCommand = "powershell /path-to-script --verbose fake-arg"
 		} else {
-			fullCommand = "/path-to-script"
+			fullCommand = "/path-to-script --verbose fake-arg"
 		}
 	})
 
@@ -109,6 +116,13 @@ var _ = Describe("GenericScript", func() {
 			Expect(cmd.Env).To(HaveKeyWithValue("OTHER_EXAMPLE", "1243=abcd"))
 		})
 
+		It("passes the provided arguments to the script", func() {
+			Expect(genericScript.Run()).To(Succeed())
+			Expect(cmdRunner.RunComplexCommands).To(HaveLen(1))
+			cmd := cmdRunner.RunComplexCommands[0]
+			Expect(cmd.Args[len(cmd.Args)-2:]).To(Equal([]string{"--verbose", "fake-arg"}))
+		})
+
 		Context("when command succeeds", func() {
 			BeforeEach(func() {
 				cmdRunner.AddCmdResult(fullCommand, fakesys.FakeCmdResult{
@@ -163,5 +177,110 @@ var _ = Describe("GenericScript", func() {
 				Expect(stderr).To(Equal("fake-stderr"))
 			})
 		})
+
+		Context("when output is streamed", func() {
+			var lines []boshscript.OutputLine
+
+			BeforeEach(func() {
+				lines = nil
+				genericScript = genericScript.StreamOutput("fake-job", "pre-start", func(line boshscript.OutputLine) {
+					lines = append(lines, line)
+				})
+			})
+
+			It("passes each line to the output func tagged with job, script and stream", func() {
+				cmdRunner.AddCmdResult(fullCommand, fakesys.FakeCmdResult{
+					Stdout: "line-1\r\nline-2\npartial",
+					Stderr: "fake-stderr\n",
+				})
+
+				err := genericScript.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(lines).To(ConsistOf(
+					boshscript.OutputLine{Job: "fake-job", Script: "pre-start", Stream: "stdout", Text: "line-1"},
+					boshscript.OutputLine{Job: "fake-job", Script: "pre-start", Stream: "stdout", Text: "line-2"},
+					boshscript.OutputLine{Job: "fake-job", Script: "pre-start", Stream: "stdout", Text: "partial"},
+					boshscript.OutputLine{Job: "fake-job", Script: "pre-start", Stream: "stderr", Text: "fake-stderr"},
+				))
+			})
+
+			It("still saves stdout/stderr to log file", func() {
+				cmdRunner.AddCmdResult(fullCommand, fakesys.FakeCmdResult{
+					Stdout: "line-1\r\nline-2\npartial",
+					Stderr: "fake-stderr\n",
+				})
+
+				err := genericScript.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				stdout, err := fs.ReadFileString(stdoutLogPath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(stdout).To(Equal("line-1\r\nline-2\npartial"))
+			})
+
+			It("splits lines longer than 4096 bytes", func() {
+				cmdRunner.AddCmdResult(fullCommand, fakesys.FakeCmdResult{
+					Stdout: strings.Repeat("a", 5000) + "\n",
+				})
+
+				err := genericScript.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(lines).To(HaveLen(2))
+				Expect(lines[0].Text).To(HaveLen(4096))
+				Expect(lines[1].Text).To(HaveLen(904))
+			})
+		})
+
+		Context("when a timeout is given", func() {
+			var (
+				timeService *fakeclock.FakeClock
+				process     *fakesys.FakeProcess
+			)
+
+			BeforeEach(func() {
+				timeService = fakeclock.NewFakeClock(time.Now())
+				process = &fakesys.FakeProcess{}
+				cmdRunner.AddProcess(fullCommand, process)
+
+				genericScript = boshscript.NewScript(
+					fs,
+					cmdRunner,
+					"my-tag",
+					"/path-to-script",
+					stdoutLogPath,
+					stderrLogPath,
+					scriptEnv,
+					[]string{"--verbose", "fake-arg"},
+					time.Minute,
+					timeService,
+				)
+			})
+
+			It("returns the result of scripts finishing in time", func() {
+				process.WaitResult = boshsys.Result{Error: errors.New("fake-command-error")}
+
+				err := genericScript.Run()
+				Expect(err).To(MatchError("fake-command-error"))
+				Expect(process.TerminatedNicely).To(BeFalse())
+			})
+
+			It("terminates scripts still running after the timeout", func() {
+				process.TerminatedNicelyCallBack = func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{Error: errors.New("fake-terminated-error")}
+				}
+
+				errCh := make(chan error, 1)
+				go func() { errCh <- genericScript.Run() }()
+
+				Eventually(timeService.WatcherCount).Should(Equal(1))
+				timeService.Increment(time.Minute)
+
+				Eventually(errCh).Should(Receive(MatchError("Script timed out after 1m0s")))
+				Expect(process.TerminatedNicely).To(BeTrue())
+				Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+			})
+		})
 	})
 })
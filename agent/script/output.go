@@ -0,0 +1,65 @@
+package script
+
+import (
+	"bytes"
+)
+
+// maxOutputLineLength splits lines of scripts that write a lot without line
+// breaks, e.g. progress bars
+const maxOutputLineLength = 4096
+
+// OutputLine is a line a job script wrote to its stdout or stderr.
+type OutputLine struct {
+	Job    string `json:"job"`
+	Script string `json:"script"`
+	Stream string `json:"stream"`
+	Text   string `json:"text"`
+}
+
+type OutputFunc func(line OutputLine)
+
+// outputLineWriter passes complete lines written to it to output. Flush
+// passes on what is left once the script exited.
+type outputLineWriter struct {
+	line   OutputLine
+	output OutputFunc
+	buf    []byte
+}
+
+func newOutputLineWriter(line OutputLine, output OutputFunc) *outputLineWriter {
+	return &outputLineWriter{line: line, output: output}
+}
+
+func (w *outputLineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 || i > maxOutputLineLength {
+			if len(w.buf) < maxOutputLineLength {
+				break
+			}
+			w.report(w.buf[:maxOutputLineLength])
+			w.buf = w.buf[maxOutputLineLength:]
+			continue
+		}
+
+		w.report(bytes.TrimSuffix(w.buf[:i], []byte("\r")))
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (w *outputLineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.report(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *outputLineWriter) report(text []byte) {
+	line := w.line
+	line.Text = string(text)
+	w.output(line)
+}
@@ -1,6 +1,9 @@
 package script
 
 import (
+	"time"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshdrain "github.com/cloudfoundry/bosh-agent/v2/agent/script/drain"
 )
 
@@ -9,8 +12,15 @@ import (
 //counterfeiter:generate . JobScriptProvider
 
 type JobScriptProvider interface {
-	NewScript(jobName string, scriptName string, scriptEnv map[string]string) Script
-	NewDrainScript(jobName string, params boshdrain.ScriptParams) CancellableScript
+	// NewScript returns a job script that is terminated when it runs for longer
+	// than timeout, a timeout of zero lets it run until it finishes.
+	NewScript(jobName string, scriptName string, scriptEnv map[string]string, scriptArgs []string, timeout time.Duration) Script
+	// NewStreamingScript returns a job script like NewScript which also
+	// passes each line it writes to output while it runs
+	NewStreamingScript(jobName string, scriptName string, scriptEnv map[string]string, scriptArgs []string, timeout time.Duration, output OutputFunc) Script
+	// NewDrainScript returns the drain script of a job, which is bounded by
+	// the drain limits of the job
+	NewDrainScript(jobName string, params boshdrain.ScriptParams, limits models.JobDrain) CancellableScript
 	NewParallelScript(scriptName string, scripts []Script) CancellableScript
 }
 
@@ -3,17 +3,20 @@ package scriptfakes
 
 import (
 	"sync"
+	"time"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/script"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/script/drain"
 )
 
 type FakeJobScriptProvider struct {
-	NewDrainScriptStub        func(string, drain.ScriptParams) script.CancellableScript
+	NewDrainScriptStub        func(string, drain.ScriptParams, models.JobDrain) script.CancellableScript
 	newDrainScriptMutex       sync.RWMutex
 	newDrainScriptArgsForCall []struct {
 		arg1 string
 		arg2 drain.ScriptParams
+		arg3 models.JobDrain
 	}
 	newDrainScriptReturns struct {
 		result1 script.CancellableScript
@@ -33,12 +36,14 @@ type FakeJobScriptProvider struct {
 	newParallelScriptReturnsOnCall map[int]struct {
 		result1 script.CancellableScript
 	}
-	NewScriptStub        func(string, string, map[string]string) script.Script
+	NewScriptStub        func(string, string, map[string]string, []string, time.Duration) script.Script
 	newScriptMutex       sync.RWMutex
 	newScriptArgsForCall []struct {
 		arg1 string
 		arg2 string
 		arg3 map[string]string
+		arg4 []string
+		arg5 time.Duration
 	}
 	newScriptReturns struct {
 		result1 script.Script
@@ -46,23 +51,40 @@ type FakeJobScriptProvider struct {
 	newScriptReturnsOnCall map[int]struct {
 		result1 script.Script
 	}
+	NewStreamingScriptStub        func(string, string, map[string]string, []string, time.Duration, script.OutputFunc) script.Script
+	newStreamingScriptMutex       sync.RWMutex
+	newStreamingScriptArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 map[string]string
+		arg4 []string
+		arg5 time.Duration
+		arg6 script.OutputFunc
+	}
+	newStreamingScriptReturns struct {
+		result1 script.Script
+	}
+	newStreamingScriptReturnsOnCall map[int]struct {
+		result1 script.Script
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeJobScriptProvider) NewDrainScript(arg1 string, arg2 drain.ScriptParams) script.CancellableScript {
+func (fake *FakeJobScriptProvider) NewDrainScript(arg1 string, arg2 drain.ScriptParams, arg3 models.JobDrain) script.CancellableScript {
 	fake.newDrainScriptMutex.Lock()
 	ret, specificReturn := fake.newDrainScriptReturnsOnCall[len(fake.newDrainScriptArgsForCall)]
 	fake.newDrainScriptArgsForCall = append(fake.newDrainScriptArgsForCall, struct {
 		arg1 string
 		arg2 drain.ScriptParams
-	}{arg1, arg2})
+		arg3 models.JobDrain
+	}{arg1, arg2, arg3})
 	stub := fake.NewDrainScriptStub
 	fakeReturns := fake.newDrainScriptReturns
-	fake.recordInvocation("NewDrainScript", []interface{}{arg1, arg2})
+	fake.recordInvocation("NewDrainScript", []interface{}{arg1, arg2, arg3})
 	fake.newDrainScriptMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -76,17 +98,17 @@ func (fake *FakeJobScriptProvider) NewDrainScriptCallCount() int {
 	return len(fake.newDrainScriptArgsForCall)
 }
 
-func (fake *FakeJobScriptProvider) NewDrainScriptCalls(stub func(string, drain.ScriptParams) script.CancellableScript) {
+func (fake *FakeJobScriptProvider) NewDrainScriptCalls(stub func(string, drain.ScriptParams, models.JobDrain) script.CancellableScript) {
 	fake.newDrainScriptMutex.Lock()
 	defer fake.newDrainScriptMutex.Unlock()
 	fake.NewDrainScriptStub = stub
 }
 
-func (fake *FakeJobScriptProvider) NewDrainScriptArgsForCall(i int) (string, drain.ScriptParams) {
+func (fake *FakeJobScriptProvider) NewDrainScriptArgsForCall(i int) (string, drain.ScriptParams, models.JobDrain) {
 	fake.newDrainScriptMutex.RLock()
 	defer fake.newDrainScriptMutex.RUnlock()
 	argsForCall := fake.newDrainScriptArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeJobScriptProvider) NewDrainScriptReturns(result1 script.CancellableScript) {
@@ -179,20 +201,27 @@ func (fake *FakeJobScriptProvider) NewParallelScriptReturnsOnCall(i int, result1
 	}{result1}
 }
 
-func (fake *FakeJobScriptProvider) NewScript(arg1 string, arg2 string, arg3 map[string]string) script.Script {
+func (fake *FakeJobScriptProvider) NewScript(arg1 string, arg2 string, arg3 map[string]string, arg4 []string, arg5 time.Duration) script.Script {
+	var arg4Copy []string
+	if arg4 != nil {
+		arg4Copy = make([]string, len(arg4))
+		copy(arg4Copy, arg4)
+	}
 	fake.newScriptMutex.Lock()
 	ret, specificReturn := fake.newScriptReturnsOnCall[len(fake.newScriptArgsForCall)]
 	fake.newScriptArgsForCall = append(fake.newScriptArgsForCall, struct {
 		arg1 string
 		arg2 string
 		arg3 map[string]string
-	}{arg1, arg2, arg3})
+		arg4 []string
+		arg5 time.Duration
+	}{arg1, arg2, arg3, arg4Copy, arg5})
 	stub := fake.NewScriptStub
 	fakeReturns := fake.newScriptReturns
-	fake.recordInvocation("NewScript", []interface{}{arg1, arg2, arg3})
+	fake.recordInvocation("NewScript", []interface{}{arg1, arg2, arg3, arg4Copy, arg5})
 	fake.newScriptMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1
@@ -206,17 +235,17 @@ func (fake *FakeJobScriptProvider) NewScriptCallCount() int {
 	return len(fake.newScriptArgsForCall)
 }
 
-func (fake *FakeJobScriptProvider) NewScriptCalls(stub func(string, string, map[string]string) script.Script) {
+func (fake *FakeJobScriptProvider) NewScriptCalls(stub func(string, string, map[string]string, []string, time.Duration) script.Script) {
 	fake.newScriptMutex.Lock()
 	defer fake.newScriptMutex.Unlock()
 	fake.NewScriptStub = stub
 }
 
-func (fake *FakeJobScriptProvider) NewScriptArgsForCall(i int) (string, string, map[string]string) {
+func (fake *FakeJobScriptProvider) NewScriptArgsForCall(i int) (string, string, map[string]string, []string, time.Duration) {
 	fake.newScriptMutex.RLock()
 	defer fake.newScriptMutex.RUnlock()
 	argsForCall := fake.newScriptArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *FakeJobScriptProvider) NewScriptReturns(result1 script.Script) {
@@ -242,6 +271,77 @@ func (fake *FakeJobScriptProvider) NewScriptReturnsOnCall(i int, result1 script.
 	}{result1}
 }
 
+func (fake *FakeJobScriptProvider) NewStreamingScript(arg1 string, arg2 string, arg3 map[string]string, arg4 []string, arg5 time.Duration, arg6 script.OutputFunc) script.Script {
+	var arg4Copy []string
+	if arg4 != nil {
+		arg4Copy = make([]string, len(arg4))
+		copy(arg4Copy, arg4)
+	}
+	fake.newStreamingScriptMutex.Lock()
+	ret, specificReturn := fake.newStreamingScriptReturnsOnCall[len(fake.newStreamingScriptArgsForCall)]
+	fake.newStreamingScriptArgsForCall = append(fake.newStreamingScriptArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 map[string]string
+		arg4 []string
+		arg5 time.Duration
+		arg6 script.OutputFunc
+	}{arg1, arg2, arg3, arg4Copy, arg5, arg6})
+	stub := fake.NewStreamingScriptStub
+	fakeReturns := fake.newStreamingScriptReturns
+	fake.recordInvocation("NewStreamingScript", []interface{}{arg1, arg2, arg3, arg4Copy, arg5, arg6})
+	fake.newStreamingScriptMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5, arg6)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeJobScriptProvider) NewStreamingScriptCallCount() int {
+	fake.newStreamingScriptMutex.RLock()
+	defer fake.newStreamingScriptMutex.RUnlock()
+	return len(fake.newStreamingScriptArgsForCall)
+}
+
+func (fake *FakeJobScriptProvider) NewStreamingScriptCalls(stub func(string, string, map[string]string, []string, time.Duration, script.OutputFunc) script.Script) {
+	fake.newStreamingScriptMutex.Lock()
+	defer fake.newStreamingScriptMutex.Unlock()
+	fake.NewStreamingScriptStub = stub
+}
+
+func (fake *FakeJobScriptProvider) NewStreamingScriptArgsForCall(i int) (string, string, map[string]string, []string, time.Duration, script.OutputFunc) {
+	fake.newStreamingScriptMutex.RLock()
+	defer fake.newStreamingScriptMutex.RUnlock()
+	argsForCall := fake.newStreamingScriptArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6
+}
+
+func (fake *FakeJobScriptProvider) NewStreamingScriptReturns(result1 script.Script) {
+	fake.newStreamingScriptMutex.Lock()
+	defer fake.newStreamingScriptMutex.Unlock()
+	fake.NewStreamingScriptStub = nil
+	fake.newStreamingScriptReturns = struct {
+		result1 script.Script
+	}{result1}
+}
+
+func (fake *FakeJobScriptProvider) NewStreamingScriptReturnsOnCall(i int, result1 script.Script) {
+	fake.newStreamingScriptMutex.Lock()
+	defer fake.newStreamingScriptMutex.Unlock()
+	fake.NewStreamingScriptStub = nil
+	if fake.newStreamingScriptReturnsOnCall == nil {
+		fake.newStreamingScriptReturnsOnCall = make(map[int]struct {
+			result1 script.Script
+		})
+	}
+	fake.newStreamingScriptReturnsOnCall[i] = struct {
+		result1 script.Script
+	}{result1}
+}
+
 func (fake *FakeJobScriptProvider) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -251,6 +351,8 @@ func (fake *FakeJobScriptProvider) Invocations() map[string][][]interface{} {
 	defer fake.newParallelScriptMutex.RUnlock()
 	fake.newScriptMutex.RLock()
 	defer fake.newScriptMutex.RUnlock()
+	fake.newStreamingScriptMutex.RLock()
+	defer fake.newStreamingScriptMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
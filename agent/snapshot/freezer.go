@@ -0,0 +1,168 @@
+package snapshot
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
+)
+
+const freezerLogTag = "snapshotFreezer"
+
+type freezer struct {
+	platform    boshplatform.Platform
+	fs          boshsys.FileSystem
+	mountPoint  string
+	markerPath  string
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	lock sync.Mutex
+
+	// thawedCh is only set while the filesystem is frozen and closed when
+	// it is thawed
+	thawedCh chan struct{}
+}
+
+// NewFreezer keeps a marker file at markerPath while the filesystem is
+// frozen, so that an agent restarted in the meantime can thaw it.
+func NewFreezer(
+	platform boshplatform.Platform,
+	fs boshsys.FileSystem,
+	mountPoint string,
+	markerPath string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) Freezer {
+	return &freezer{
+		platform:    platform,
+		fs:          fs,
+		mountPoint:  mountPoint,
+		markerPath:  markerPath,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+func (f *freezer) Freeze(maxDuration time.Duration) (bool, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.thawedCh != nil {
+		return false, bosherr.Errorf("Filesystem at %s is already frozen", f.mountPoint)
+	}
+
+	_, mounted, err := f.platform.IsMountPoint(f.mountPoint)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Checking persistent disk mount point")
+	}
+
+	if !mounted {
+		f.logger.Info(freezerLogTag, "No persistent disk mounted at %s, nothing to freeze", f.mountPoint)
+		return false, nil
+	}
+
+	// Written before freezing so that no frozen filesystem is left without it
+	err = f.fs.WriteFileString(f.markerPath, f.mountPoint)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Writing frozen filesystem marker")
+	}
+
+	err = f.platform.FreezeFilesystem(f.mountPoint)
+	if err != nil {
+		f.removeMarker()
+		return false, err
+	}
+
+	thawedCh := make(chan struct{})
+	f.thawedCh = thawedCh
+
+	timer := f.timeService.NewTimer(maxDuration)
+
+	go func() {
+		defer timer.Stop()
+
+		select {
+		case <-thawedCh:
+		case <-timer.C():
+			f.logger.Warn(freezerLogTag, "Thawing filesystem at %s which was frozen for longer than %s", f.mountPoint, maxDuration)
+
+			_, err := f.Thaw()
+			if err != nil {
+				f.logger.Error(freezerLogTag, "Failed to thaw filesystem: %s", err.Error())
+			}
+		}
+	}()
+
+	return true, nil
+}
+
+func (f *freezer) Thaw() (bool, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.thawedCh == nil {
+		return false, nil
+	}
+
+	// Stays frozen on errors so that thawing can be retried
+	err := f.platform.ThawFilesystem(f.mountPoint)
+	if err != nil {
+		return false, err
+	}
+
+	close(f.thawedCh)
+	f.thawedCh = nil
+
+	f.removeMarker()
+
+	return true, nil
+}
+
+func (f *freezer) ThawLeftover() (bool, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.thawedCh != nil || !f.fs.FileExists(f.markerPath) {
+		return false, nil
+	}
+
+	mountPoint, err := f.fs.ReadFileString(f.markerPath)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Reading frozen filesystem marker")
+	}
+
+	// Rebooting thaws the filesystem as well, after which thawing it fails,
+	// so the marker is not kept around to retry
+	defer f.removeMarker()
+
+	_, mounted, err := f.platform.IsMountPoint(mountPoint)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Checking persistent disk mount point")
+	}
+
+	if !mounted {
+		return false, nil
+	}
+
+	f.logger.Warn(freezerLogTag, "Thawing filesystem at %s which was left frozen by a previous agent", mountPoint)
+
+	err = f.platform.ThawFilesystem(mountPoint)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Thawing filesystem at %s", mountPoint)
+	}
+
+	return true, nil
+}
+
+func (f *freezer) removeMarker() {
+	err := f.fs.RemoveAll(f.markerPath)
+	if err != nil {
+		f.logger.Warn(freezerLogTag, "Failed to remove frozen filesystem marker: %s", err.Error())
+	}
+}
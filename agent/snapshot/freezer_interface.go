@@ -0,0 +1,24 @@
+package snapshot
+
+import (
+	"time"
+)
+
+//go:generate counterfeiter . Freezer
+
+// Freezer freezes the persistent disk filesystem while it is snapshotted, so
+// the snapshot holds what the jobs wrote before they were quiesced.
+type Freezer interface {
+	// Freeze does nothing and returns false when no persistent disk is
+	// mounted. Writes to a frozen filesystem block, so it is thawed on its own
+	// once it was frozen for maxDuration.
+	Freeze(maxDuration time.Duration) (frozen bool, err error)
+
+	// Thaw returns false when the filesystem was not frozen, e.g. because it
+	// was already thawed after being frozen for too long
+	Thaw() (thawed bool, err error)
+
+	// ThawLeftover thaws the filesystem when a previous agent process froze
+	// it and exited before thawing it. It is called when the agent starts.
+	ThawLeftover() (thawed bool, err error)
+}
@@ -0,0 +1,212 @@
+package snapshot_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/snapshot"
+	"github.com/cloudfoundry/bosh-agent/v2/platform/platformfakes"
+)
+
+var _ = Describe("Freezer", func() {
+	var (
+		platform    *platformfakes.FakePlatform
+		fs          *fakesys.FakeFileSystem
+		timeService *fakeclock.FakeClock
+		freezer     snapshot.Freezer
+	)
+
+	BeforeEach(func() {
+		platform = &platformfakes.FakePlatform{}
+		platform.IsMountPointReturns("/dev/sdf1", true, nil)
+		fs = fakesys.NewFakeFileSystem()
+		timeService = fakeclock.NewFakeClock(time.Now())
+		freezer = snapshot.NewFreezer(platform, fs, "/fake-store", "/fake-data/frozen_filesystem", timeService, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("Freeze", func() {
+		It("freezes the filesystem mounted at the mount point", func() {
+			frozen, err := freezer.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frozen).To(BeTrue())
+
+			Expect(platform.IsMountPointArgsForCall(0)).To(Equal("/fake-store"))
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(1))
+			Expect(platform.FreezeFilesystemArgsForCall(0)).To(Equal("/fake-store"))
+		})
+
+		It("marks the filesystem as frozen before freezing it", func() {
+			platform.FreezeFilesystemStub = func(string) error {
+				Expect(fs.ReadFileString("/fake-data/frozen_filesystem")).To(Equal("/fake-store"))
+				return nil
+			}
+
+			_, err := freezer.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(1))
+		})
+
+		It("returns an error and does not freeze when marking the filesystem fails", func() {
+			fs.WriteFileError = errors.New("fake-write-err")
+
+			_, err := freezer.Freeze(time.Minute)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("does nothing when no persistent disk is mounted", func() {
+			platform.IsMountPointReturns("", false, nil)
+
+			frozen, err := freezer.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frozen).To(BeFalse())
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("returns an error when checking the mount point fails", func() {
+			platform.IsMountPointReturns("", false, errors.New("fake-mount-err"))
+
+			_, err := freezer.Freeze(time.Minute)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-mount-err"))
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("returns an error when freezing fails and stays thawed", func() {
+			platform.FreezeFilesystemReturns(errors.New("fake-freeze-err"))
+
+			_, err := freezer.Freeze(time.Minute)
+			Expect(err).To(MatchError("fake-freeze-err"))
+			Expect(fs.FileExists("/fake-data/frozen_filesystem")).To(BeFalse())
+
+			thawed, err := freezer.Thaw()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(thawed).To(BeFalse())
+		})
+
+		It("returns an error when the filesystem is already frozen", func() {
+			_, err := freezer.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = freezer.Freeze(time.Minute)
+			Expect(err).To(MatchError("Filesystem at /fake-store is already frozen"))
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(1))
+		})
+
+		It("thaws the filesystem once it was frozen for the max duration", func() {
+			_, err := freezer.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			timeService.Increment(59 * time.Second)
+			Consistently(platform.ThawFilesystemCallCount).Should(Equal(0))
+
+			timeService.Increment(time.Second)
+			Eventually(platform.ThawFilesystemCallCount).Should(Equal(1))
+			Expect(platform.ThawFilesystemArgsForCall(0)).To(Equal("/fake-store"))
+
+			thawed, err := freezer.Thaw()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(thawed).To(BeFalse())
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("Thaw", func() {
+		It("thaws the frozen filesystem", func() {
+			_, err := freezer.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			thawed, err := freezer.Thaw()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(thawed).To(BeTrue())
+			Expect(platform.ThawFilesystemArgsForCall(0)).To(Equal("/fake-store"))
+			Expect(fs.FileExists("/fake-data/frozen_filesystem")).To(BeFalse())
+
+			timeService.Increment(time.Minute)
+			Consistently(platform.ThawFilesystemCallCount).Should(Equal(1))
+		})
+
+		It("does nothing when the filesystem is not frozen", func() {
+			thawed, err := freezer.Thaw()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(thawed).To(BeFalse())
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("keeps the filesystem frozen when thawing fails so it can be retried", func() {
+			_, err := freezer.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			platform.ThawFilesystemReturns(errors.New("fake-thaw-err"))
+			_, err = freezer.Thaw()
+			Expect(err).To(MatchError("fake-thaw-err"))
+			Expect(fs.FileExists("/fake-data/frozen_filesystem")).To(BeTrue())
+
+			platform.ThawFilesystemReturns(nil)
+			thawed, err := freezer.Thaw()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(thawed).To(BeTrue())
+		})
+	})
+
+	Describe("ThawLeftover", func() {
+		BeforeEach(func() {
+			Expect(fs.WriteFileString("/fake-data/frozen_filesystem", "/fake-old-store")).To(Succeed())
+		})
+
+		It("thaws the filesystem a previous agent left frozen", func() {
+			thawed, err := freezer.ThawLeftover()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(thawed).To(BeTrue())
+
+			Expect(platform.IsMountPointArgsForCall(0)).To(Equal("/fake-old-store"))
+			Expect(platform.ThawFilesystemArgsForCall(0)).To(Equal("/fake-old-store"))
+			Expect(fs.FileExists("/fake-data/frozen_filesystem")).To(BeFalse())
+		})
+
+		It("does nothing when no filesystem was left frozen", func() {
+			Expect(fs.RemoveAll("/fake-data/frozen_filesystem")).To(Succeed())
+
+			thawed, err := freezer.ThawLeftover()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(thawed).To(BeFalse())
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("does nothing when the filesystem is no longer mounted", func() {
+			platform.IsMountPointReturns("", false, nil)
+
+			thawed, err := freezer.ThawLeftover()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(thawed).To(BeFalse())
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(0))
+			Expect(fs.FileExists("/fake-data/frozen_filesystem")).To(BeFalse())
+		})
+
+		It("returns an error and forgets the filesystem when thawing fails", func() {
+			platform.ThawFilesystemReturns(errors.New("fake-thaw-err"))
+
+			_, err := freezer.ThawLeftover()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-thaw-err"))
+			Expect(fs.FileExists("/fake-data/frozen_filesystem")).To(BeFalse())
+		})
+
+		It("does nothing while this agent has the filesystem frozen", func() {
+			_, err := freezer.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			thawed, err := freezer.ThawLeftover()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(thawed).To(BeFalse())
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(0))
+		})
+	})
+})
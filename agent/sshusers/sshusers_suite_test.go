@@ -0,0 +1,13 @@
+package sshusers_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSSHUsers(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SSH Users Suite")
+}
@@ -0,0 +1,127 @@
+package sshusers
+
+import (
+	"encoding/json"
+	"regexp"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type fileTracker struct {
+	fs          boshsys.FileSystem
+	usersPath   string
+	deleter     UserDeleter
+	timeService clock.Clock
+
+	logTag string
+	logger boshlog.Logger
+
+	lock sync.Mutex
+}
+
+// NewTracker keeps the expiry times of users in a JSON file at usersPath
+// so that they survive agent restarts
+func NewTracker(
+	fs boshsys.FileSystem,
+	usersPath string,
+	deleter UserDeleter,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) Tracker {
+	return &fileTracker{
+		fs:          fs,
+		usersPath:   usersPath,
+		deleter:     deleter,
+		timeService: timeService,
+		logTag:      "sshUsersTracker",
+		logger:      logger,
+	}
+}
+
+func (t *fileTracker) Track(user string, ttl time.Duration) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	expiries, err := t.read()
+	if err != nil {
+		return err
+	}
+
+	expiries[user] = t.timeService.Now().Add(ttl)
+
+	return t.write(expiries)
+}
+
+func (t *fileTracker) RemoveExpired() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	expiries, err := t.read()
+	if err != nil {
+		return err
+	}
+
+	now := t.timeService.Now()
+	removed := false
+
+	for user, expiresAt := range expiries {
+		if now.Before(expiresAt) {
+			continue
+		}
+
+		t.logger.Info(t.logTag, "Removing ssh user '%s' which expired at %s", user, expiresAt)
+
+		err = t.deleter.DeleteEphemeralUsersMatching("^" + regexp.QuoteMeta(user) + "$")
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing expired ssh user '%s'", user)
+		}
+
+		delete(expiries, user)
+		removed = true
+	}
+
+	if !removed {
+		return nil
+	}
+
+	return t.write(expiries)
+}
+
+func (t *fileTracker) read() (map[string]time.Time, error) {
+	expiries := map[string]time.Time{}
+
+	if !t.fs.FileExists(t.usersPath) {
+		return expiries, nil
+	}
+
+	contents, err := t.fs.ReadFile(t.usersPath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading ssh users")
+	}
+
+	err = json.Unmarshal(contents, &expiries)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling ssh users")
+	}
+
+	return expiries, nil
+}
+
+func (t *fileTracker) write(expiries map[string]time.Time) error {
+	contents, err := json.Marshal(expiries)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling ssh users")
+	}
+
+	err = t.fs.WriteFile(t.usersPath, contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing ssh users")
+	}
+
+	return nil
+}
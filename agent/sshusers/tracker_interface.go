@@ -0,0 +1,20 @@
+package sshusers
+
+import (
+	"time"
+)
+
+//go:generate counterfeiter . Tracker
+
+// Tracker remembers when the ephemeral users created for bosh ssh expire,
+// so they are removed even when cleanup is never requested
+type Tracker interface {
+	Track(user string, ttl time.Duration) error
+	RemoveExpired() error
+}
+
+//go:generate counterfeiter . UserDeleter
+
+type UserDeleter interface {
+	DeleteEphemeralUsersMatching(regex string) error
+}
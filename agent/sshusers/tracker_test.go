@@ -0,0 +1,117 @@
+package sshusers_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/sshusers"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/sshusers/sshusersfakes"
+)
+
+var _ = Describe("Tracker", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		deleter     *sshusersfakes.FakeUserDeleter
+		timeService *fakeclock.FakeClock
+		logger      boshlog.Logger
+		tracker     sshusers.Tracker
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		deleter = &sshusersfakes.FakeUserDeleter{}
+		timeService = fakeclock.NewFakeClock(time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC))
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		tracker = sshusers.NewTracker(fs, "/bosh/ephemeral_users.json", deleter, timeService, logger)
+	})
+
+	Describe("RemoveExpired", func() {
+		It("does nothing when no users are tracked", func() {
+			err := tracker.RemoveExpired()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleter.DeleteEphemeralUsersMatchingCallCount()).To(Equal(0))
+			Expect(fs.FileExists("/bosh/ephemeral_users.json")).To(BeFalse())
+		})
+
+		It("removes users once their TTL has passed", func() {
+			Expect(tracker.Track("bosh_short", time.Minute)).To(Succeed())
+			Expect(tracker.Track("bosh_long", time.Hour)).To(Succeed())
+
+			timeService.Increment(59 * time.Second)
+			Expect(tracker.RemoveExpired()).To(Succeed())
+			Expect(deleter.DeleteEphemeralUsersMatchingCallCount()).To(Equal(0))
+
+			timeService.Increment(time.Second)
+			Expect(tracker.RemoveExpired()).To(Succeed())
+			Expect(deleter.DeleteEphemeralUsersMatchingCallCount()).To(Equal(1))
+			Expect(deleter.DeleteEphemeralUsersMatchingArgsForCall(0)).To(Equal("^bosh_short$"))
+
+			Expect(tracker.RemoveExpired()).To(Succeed())
+			Expect(deleter.DeleteEphemeralUsersMatchingCallCount()).To(Equal(1))
+		})
+
+		It("quotes user names when matching them", func() {
+			Expect(tracker.Track("bosh_a.b", 0)).To(Succeed())
+
+			Expect(tracker.RemoveExpired()).To(Succeed())
+			Expect(deleter.DeleteEphemeralUsersMatchingArgsForCall(0)).To(Equal(`^bosh_a\.b$`))
+		})
+
+		It("extends the expiry of users tracked again", func() {
+			Expect(tracker.Track("bosh_user", time.Minute)).To(Succeed())
+			Expect(tracker.Track("bosh_user", time.Hour)).To(Succeed())
+
+			timeService.Increment(time.Minute)
+			Expect(tracker.RemoveExpired()).To(Succeed())
+			Expect(deleter.DeleteEphemeralUsersMatchingCallCount()).To(Equal(0))
+		})
+
+		It("remembers users across trackers", func() {
+			Expect(tracker.Track("bosh_user", time.Minute)).To(Succeed())
+
+			otherTracker := sshusers.NewTracker(fs, "/bosh/ephemeral_users.json", deleter, timeService, logger)
+
+			timeService.Increment(time.Minute)
+			Expect(otherTracker.RemoveExpired()).To(Succeed())
+			Expect(deleter.DeleteEphemeralUsersMatchingArgsForCall(0)).To(Equal("^bosh_user$"))
+		})
+
+		It("keeps users it fails to remove and retries them", func() {
+			Expect(tracker.Track("bosh_user", 0)).To(Succeed())
+			deleter.DeleteEphemeralUsersMatchingReturns(errors.New("fake-delete-err"))
+
+			err := tracker.RemoveExpired()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Removing expired ssh user 'bosh_user'"))
+			Expect(err.Error()).To(ContainSubstring("fake-delete-err"))
+
+			deleter.DeleteEphemeralUsersMatchingReturns(nil)
+			Expect(tracker.RemoveExpired()).To(Succeed())
+			Expect(deleter.DeleteEphemeralUsersMatchingCallCount()).To(Equal(2))
+		})
+
+		It("returns an error when the users file cannot be read", func() {
+			Expect(fs.WriteFileString("/bosh/ephemeral_users.json", "bad-json")).To(Succeed())
+
+			err := tracker.RemoveExpired()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling ssh users"))
+		})
+	})
+
+	Describe("Track", func() {
+		It("returns an error when the users file cannot be written", func() {
+			fs.WriteFileError = errors.New("fake-write-err")
+
+			err := tracker.Track("bosh_user", time.Minute)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Writing ssh users"))
+		})
+	})
+})
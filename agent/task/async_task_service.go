@@ -1,6 +1,7 @@
 package task
 
 import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
@@ -13,17 +14,23 @@ type asyncTaskService struct {
 	logger  boshlog.Logger
 
 	currentTasks map[string]Task
+	queue        []string
 	taskChan     chan Task
 	taskSem      chan func()
+
+	// Closed to cancel the task, by task id of unfinished tasks
+	cancelRequests map[string]chan struct{}
 }
 
 func NewAsyncTaskService(uuidGen boshuuid.Generator, logger boshlog.Logger) (service Service) {
-	s := asyncTaskService{
+	s := &asyncTaskService{
 		uuidGen:      uuidGen,
 		logger:       logger,
 		currentTasks: make(map[string]Task),
 		taskChan:     make(chan Task, 5),
 		taskSem:      make(chan func()),
+
+		cancelRequests: make(map[string]chan struct{}),
 	}
 
 	go s.processTasks()
@@ -32,7 +39,7 @@ func NewAsyncTaskService(uuidGen boshuuid.Generator, logger boshlog.Logger) (ser
 	return s
 }
 
-func (service asyncTaskService) CreateTask(
+func (service *asyncTaskService) CreateTask(
 	taskFunc Func,
 	cancelFunc CancelFunc,
 	endFunc EndFunc,
@@ -45,7 +52,7 @@ func (service asyncTaskService) CreateTask(
 	return service.CreateTaskWithID(uuid, taskFunc, cancelFunc, endFunc), nil
 }
 
-func (service asyncTaskService) CreateTaskWithID(
+func (service *asyncTaskService) CreateTaskWithID(
 	id string,
 	taskFunc Func,
 	cancelFunc CancelFunc,
@@ -60,19 +67,38 @@ func (service asyncTaskService) CreateTaskWithID(
 	}
 }
 
-func (service asyncTaskService) StartTask(task Task) {
-	taskChan := make(chan Task)
+func (service *asyncTaskService) StartTask(task Task) {
+	// Only the check can fail starting a task
+	_ = service.StartTaskIf(task, nil)
+}
+
+func (service *asyncTaskService) StartTaskIf(task Task, check func(queued []Task) error) error {
+	errChan := make(chan error)
 
 	service.taskSem <- func() {
+		if check != nil {
+			if err := check(service.queuedTasks()); err != nil {
+				errChan <- err
+				return
+			}
+		}
+
 		service.currentTasks[task.ID] = task
-		taskChan <- task
+		service.queue = append(service.queue, task.ID)
+		service.cancelRequests[task.ID] = make(chan struct{})
+		errChan <- nil
+	}
+
+	if err := <-errChan; err != nil {
+		return err
 	}
 
-	recordedTask := <-taskChan
-	service.taskChan <- recordedTask
+	service.taskChan <- task
+
+	return nil
 }
 
-func (service asyncTaskService) FindTaskWithID(id string) (Task, bool) {
+func (service *asyncTaskService) FindTaskWithID(id string) (Task, bool) {
 	taskChan := make(chan Task)
 	foundChan := make(chan bool)
 
@@ -85,7 +111,155 @@ func (service asyncTaskService) FindTaskWithID(id string) (Task, bool) {
 	return <-taskChan, <-foundChan
 }
 
-func (service asyncTaskService) processSemFuncs() {
+func (service *asyncTaskService) QueuedTasks() []Task {
+	tasksChan := make(chan []Task)
+
+	service.taskSem <- func() {
+		tasksChan <- service.queuedTasks()
+	}
+
+	return <-tasksChan
+}
+
+func (service *asyncTaskService) RecordProgress(progress interface{}) (Task, bool) {
+	taskChan := make(chan Task)
+	foundChan := make(chan bool)
+
+	service.taskSem <- func() {
+		if len(service.queue) == 0 {
+			taskChan <- Task{}
+			foundChan <- false
+			return
+		}
+
+		// Tasks are processed one at a time in queue order
+		task := service.currentTasks[service.queue[0]]
+		task.Progress = progress
+		service.currentTasks[task.ID] = task
+
+		taskChan <- task
+		foundChan <- true
+	}
+
+	return <-taskChan, <-foundChan
+}
+
+func (service *asyncTaskService) CancelTask(id string) error {
+	var (
+		task     Task
+		found    bool
+		position = -1
+	)
+
+	done := make(chan struct{})
+
+	service.taskSem <- func() {
+		defer close(done)
+
+		task, found = service.currentTasks[id]
+		for i, queuedID := range service.queue {
+			if queuedID == id {
+				position = i
+			}
+		}
+
+		// Queued tasks did not start yet and are dropped instead
+		if position > 0 {
+			service.dequeue(id)
+			task.State = StateFailed
+			task.Error = ErrCancelled
+			service.currentTasks[id] = task
+		}
+	}
+
+	<-done
+
+	switch {
+	case !found:
+		return bosherr.Errorf("Task with id %s could not be found", id)
+	case position < 0:
+		return bosherr.Errorf("Task %s is not running", id)
+	case position > 0:
+		return nil
+	}
+
+	// Cancelling fails for actions that cannot be cancelled
+	if err := task.Cancel(); err != nil {
+		return bosherr.WrapErrorf(err, "Cancelling task %s", id)
+	}
+
+	errChan := make(chan error)
+
+	service.taskSem <- func() {
+		requests, found := service.cancelRequests[id]
+		if !found {
+			errChan <- bosherr.Errorf("Task %s is not running", id)
+			return
+		}
+
+		select {
+		case <-requests:
+		default:
+			close(requests)
+		}
+
+		errChan <- nil
+	}
+
+	return <-errChan
+}
+
+func (service *asyncTaskService) RunningCancelSignal() *CancelSignal {
+	signalChan := make(chan *CancelSignal)
+
+	service.taskSem <- func() {
+		if len(service.queue) == 0 {
+			signalChan <- nil
+			return
+		}
+
+		// Tasks are processed one at a time in queue order
+		signalChan <- NewCancelSignal(service.cancelRequests[service.queue[0]])
+	}
+
+	return <-signalChan
+}
+
+func (service *asyncTaskService) isQueued(id string) bool {
+	queuedChan := make(chan bool)
+
+	service.taskSem <- func() {
+		for _, queuedID := range service.queue {
+			if queuedID == id {
+				queuedChan <- true
+				return
+			}
+		}
+		queuedChan <- false
+	}
+
+	return <-queuedChan
+}
+
+// queuedTasks must be called in the semaphore
+func (service *asyncTaskService) queuedTasks() []Task {
+	tasks := make([]Task, 0, len(service.queue))
+	for _, id := range service.queue {
+		tasks = append(tasks, service.currentTasks[id])
+	}
+	return tasks
+}
+
+func (service *asyncTaskService) dequeue(id string) {
+	for i, queuedID := range service.queue {
+		if queuedID == id {
+			service.queue = append(service.queue[:i], service.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (service *asyncTaskService) processSemFuncs() {
 	defer service.logger.HandlePanic("Task Service Process Sem Funcs")
 
 	for {
@@ -94,14 +268,17 @@ func (service asyncTaskService) processSemFuncs() {
 	}
 }
 
-func (service asyncTaskService) processTasks() {
+func (service *asyncTaskService) processTasks() {
 	defer service.logger.HandlePanic("Task Service Process Tasks")
 
 	for {
 		task := <-service.taskChan
 
-		value, err := task.Func()
-		if err != nil {
+		// Tasks cancelled while queued do not run at all
+		if !service.isQueued(task.ID) {
+			task.Error = ErrCancelled
+			task.State = StateFailed
+		} else if value, err := task.Func(); err != nil {
 			task.Error = err
 			task.State = StateFailed
 			service.logger.Error("Task Service", "Failed processing task #%s got: %s", task.ID, err.Error())
@@ -120,7 +297,10 @@ func (service asyncTaskService) processTasks() {
 		task.EndFunc = nil
 
 		service.taskSem <- func() {
+			task.Progress = service.currentTasks[task.ID].Progress
 			service.currentTasks[task.ID] = task
+			service.dequeue(task.ID)
+			delete(service.cancelRequests, task.ID)
 		}
 	}
 }
@@ -205,6 +205,260 @@ func init() { //nolint:funlen,gochecknoinits
 			}, SpecTimeout(time.Second*5))
 		})
 
+		Describe("StartTaskIf", func() {
+			It("checks the queued tasks before starting the task", func() {
+				release := make(chan bool)
+				blockingFunc := func() (interface{}, error) {
+					<-release
+					return nil, nil
+				}
+
+				task1 := service.CreateTaskWithID("fake-task-1", blockingFunc, nil, nil)
+				service.StartTask(task1)
+
+				var checked []Task
+				task2 := service.CreateTaskWithID("fake-task-2", blockingFunc, nil, nil)
+				err := service.StartTaskIf(task2, func(queued []Task) error {
+					checked = queued
+					return nil
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(checked).To(HaveLen(1))
+				Expect(checked[0].ID).To(Equal("fake-task-1"))
+				Expect(service.QueuedTasks()).To(HaveLen(2))
+
+				release <- true
+				release <- true
+				Eventually(service.QueuedTasks).Should(BeEmpty())
+			})
+
+			It("does not start the task when the check fails", func() {
+				ran := make(chan bool, 1)
+				task := service.CreateTaskWithID("fake-task", func() (interface{}, error) {
+					ran <- true
+					return nil, nil
+				}, nil, nil)
+
+				err := service.StartTaskIf(task, func([]Task) error { return errors.New("fake-busy-err") })
+				Expect(err).To(MatchError("fake-busy-err"))
+
+				_, found := service.FindTaskWithID("fake-task")
+				Expect(found).To(BeFalse())
+				Expect(service.QueuedTasks()).To(BeEmpty())
+				Consistently(ran).ShouldNot(Receive())
+			})
+
+			It("starts a single task when started concurrently with the same check", func() {
+				release := make(chan bool)
+				DeferCleanup(func() { close(release) })
+
+				blockingFunc := func() (interface{}, error) {
+					<-release
+					return nil, nil
+				}
+
+				// Slow enough for unsynchronised callers to check at the same time
+				notBusy := func(queued []Task) error {
+					time.Sleep(time.Millisecond)
+					if len(queued) > 0 {
+						return errors.New("fake-busy-err")
+					}
+					return nil
+				}
+
+				errs := make(chan error)
+				for i := 0; i < 50; i++ {
+					task := service.CreateTaskWithID(fmt.Sprintf("fake-task-%d", i), blockingFunc, nil, nil)
+					go func() { errs <- service.StartTaskIf(task, notBusy) }()
+				}
+
+				started := 0
+				for i := 0; i < 50; i++ {
+					var err error
+					Eventually(errs).Should(Receive(&err))
+					if err == nil {
+						started++
+					}
+				}
+				Expect(started).To(Equal(1))
+				Expect(service.QueuedTasks()).To(HaveLen(1))
+			})
+		})
+
+		Describe("QueuedTasks", func() {
+			It("returns unfinished tasks in execution order", func() {
+				release := make(chan bool)
+				blockingFunc := func() (interface{}, error) {
+					<-release
+					return nil, nil
+				}
+
+				task1 := service.CreateTaskWithID("fake-task-1", blockingFunc, nil, nil)
+				task1.Method = "apply"
+				service.StartTask(task1)
+
+				task2 := service.CreateTaskWithID("fake-task-2", blockingFunc, nil, nil)
+				task2.Method = "run_script"
+				service.StartTask(task2)
+
+				queued := service.QueuedTasks()
+				Expect(queued).To(HaveLen(2))
+				Expect(queued[0].ID).To(Equal("fake-task-1"))
+				Expect(queued[0].Method).To(Equal("apply"))
+				Expect(queued[1].ID).To(Equal("fake-task-2"))
+				Expect(queued[1].Method).To(Equal("run_script"))
+
+				release <- true
+				Eventually(service.QueuedTasks).Should(HaveLen(1))
+				Expect(service.QueuedTasks()[0].ID).To(Equal("fake-task-2"))
+
+				release <- true
+				Eventually(service.QueuedTasks).Should(BeEmpty())
+			})
+		})
+
+		Describe("RecordProgress", func() {
+			It("records the progress of the running task and keeps it once the task finished", func() {
+				release := make(chan bool)
+				blockingFunc := func() (interface{}, error) {
+					<-release
+					return nil, nil
+				}
+
+				task1 := service.CreateTaskWithID("fake-task-1", blockingFunc, nil, nil)
+				service.StartTask(task1)
+
+				task2 := service.CreateTaskWithID("fake-task-2", blockingFunc, nil, nil)
+				service.StartTask(task2)
+
+				task, found := service.RecordProgress("fake-progress")
+				Expect(found).To(BeTrue())
+				Expect(task.ID).To(Equal("fake-task-1"))
+
+				task, _ = service.FindTaskWithID("fake-task-1")
+				Expect(task.Progress).To(Equal("fake-progress"))
+
+				task, _ = service.FindTaskWithID("fake-task-2")
+				Expect(task.Progress).To(BeNil())
+
+				release <- true
+				Eventually(func() State {
+					task, _ := service.FindTaskWithID("fake-task-1")
+					return task.State
+				}).Should(Equal(StateDone))
+
+				task, _ = service.FindTaskWithID("fake-task-1")
+				Expect(task.Progress).To(Equal("fake-progress"))
+
+				release <- true
+				Eventually(service.QueuedTasks).Should(BeEmpty())
+			})
+
+			It("does not record progress without a running task", func() {
+				_, found := service.RecordProgress("fake-progress")
+				Expect(found).To(BeFalse())
+			})
+		})
+
+		Describe("CancelTask", func() {
+			var (
+				release chan bool
+				started chan *CancelSignal
+			)
+
+			BeforeEach(func() {
+				release = make(chan bool)
+				started = make(chan *CancelSignal, 1)
+			})
+
+			blockingFunc := func() (interface{}, error) {
+				started <- service.RunningCancelSignal()
+				<-release
+				return nil, nil
+			}
+
+			cancelFunc := func(Task) error { return nil }
+
+			It("signals the running task", func() {
+				task1 := service.CreateTaskWithID("fake-task-1", blockingFunc, cancelFunc, nil)
+				service.StartTask(task1)
+
+				signal := <-started
+				Expect(signal.Err()).ToNot(HaveOccurred())
+
+				Expect(service.CancelTask("fake-task-1")).To(Succeed())
+				Expect(signal.Err()).To(Equal(ErrCancelled))
+				Expect(service.CancelTask("fake-task-1")).To(Succeed())
+
+				release <- true
+				Eventually(service.QueuedTasks).Should(BeEmpty())
+			})
+
+			It("drops a queued task without running it or signalling the running task", func() {
+				task1 := service.CreateTaskWithID("fake-task-1", blockingFunc, cancelFunc, nil)
+				service.StartTask(task1)
+
+				ranTask2 := false
+				endedTask2 := make(chan Task, 1)
+				task2 := service.CreateTaskWithID("fake-task-2", func() (interface{}, error) {
+					ranTask2 = true
+					return nil, nil
+				}, cancelFunc, func(task Task) { endedTask2 <- task })
+				service.StartTask(task2)
+
+				signal := <-started
+
+				Expect(service.CancelTask("fake-task-2")).To(Succeed())
+				Expect(signal.Err()).ToNot(HaveOccurred())
+
+				queued := service.QueuedTasks()
+				Expect(queued).To(HaveLen(1))
+				Expect(queued[0].ID).To(Equal("fake-task-1"))
+
+				task, _ := service.FindTaskWithID("fake-task-2")
+				Expect(task.State).To(Equal(StateFailed))
+				Expect(task.Error).To(Equal(ErrCancelled))
+
+				release <- true
+				Eventually(endedTask2).Should(Receive(WithTransform(func(task Task) State { return task.State }, Equal(StateFailed))))
+				Expect(ranTask2).To(BeFalse())
+			})
+
+			It("returns an error when the task cannot be cancelled", func() {
+				task1 := service.CreateTaskWithID("fake-task-1", blockingFunc, func(Task) error { return errors.New("not supported") }, nil)
+				service.StartTask(task1)
+
+				signal := <-started
+
+				err := service.CancelTask("fake-task-1")
+				Expect(err).To(MatchError("Cancelling task fake-task-1: not supported"))
+				Expect(signal.Err()).ToNot(HaveOccurred())
+
+				release <- true
+				Eventually(service.QueuedTasks).Should(BeEmpty())
+			})
+
+			It("returns an error when the task already finished", func() {
+				task1 := service.CreateTaskWithID("fake-task-1", func() (interface{}, error) { return nil, nil }, cancelFunc, nil)
+				service.StartTask(task1)
+				Eventually(service.QueuedTasks).Should(BeEmpty())
+
+				err := service.CancelTask("fake-task-1")
+				Expect(err).To(MatchError("Task fake-task-1 is not running"))
+			})
+
+			It("returns an error when the task is not found", func() {
+				err := service.CancelTask("fake-task-id")
+				Expect(err).To(MatchError("Task with id fake-task-id could not be found"))
+			})
+		})
+
+		Describe("RunningCancelSignal", func() {
+			It("returns nil without a running task", func() {
+				Expect(service.RunningCancelSignal()).To(BeNil())
+			})
+		})
+
 		Describe("CreateTask", func() {
 			It("creates a task with auto-assigned id", func() {
 				uuidGen.GeneratedUUID = "fake-uuid"
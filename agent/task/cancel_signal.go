@@ -0,0 +1,62 @@
+package task
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrCancelled is returned by work that stopped early because its task was
+// cancelled
+var ErrCancelled = errors.New("task was cancelled")
+
+// CancelSignal lets long running work check whether its task was cancelled
+// at points where it can stop and clean up. Cancel requests arrive on a
+// channel, which the task service closes when the task is cancelled, and are
+// latched so that every check after the first request reports it, also
+// across goroutines. A nil CancelSignal is never cancelled.
+type CancelSignal struct {
+	requests  <-chan struct{}
+	cancelled int32
+}
+
+func NewCancelSignal(requests <-chan struct{}) *CancelSignal {
+	return &CancelSignal{requests: requests}
+}
+
+// Err returns ErrCancelled once cancelling was requested without waiting
+// for a request
+func (s *CancelSignal) Err() error {
+	if s == nil {
+		return nil
+	}
+
+	if atomic.LoadInt32(&s.cancelled) == 1 {
+		return ErrCancelled
+	}
+
+	select {
+	case <-s.requests:
+		atomic.StoreInt32(&s.cancelled, 1)
+		return ErrCancelled
+	default:
+		return nil
+	}
+}
+
+// Done lets work that waits for something else stop waiting when cancelling
+// is requested. It is never ready for a nil CancelSignal.
+func (s *CancelSignal) Done() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+
+	return s.requests
+}
+
+// CancelSignals hands the work of a task the signal cancel_task requests
+// for that task arrive on.
+type CancelSignals interface {
+	// RunningCancelSignal returns the cancel signal of the task that is
+	// currently running, nil if there is none
+	RunningCancelSignal() *CancelSignal
+}
@@ -0,0 +1,34 @@
+package task_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+var _ = Describe("CancelSignal", func() {
+	It("is not cancelled until cancelling is requested", func() {
+		requests := make(chan struct{}, 1)
+		signal := NewCancelSignal(requests)
+
+		Expect(signal.Err()).ToNot(HaveOccurred())
+
+		requests <- struct{}{}
+		Expect(signal.Err()).To(Equal(ErrCancelled))
+	})
+
+	It("stays cancelled after the request was received", func() {
+		requests := make(chan struct{}, 1)
+		signal := NewCancelSignal(requests)
+
+		requests <- struct{}{}
+		Expect(signal.Err()).To(Equal(ErrCancelled))
+		Expect(signal.Err()).To(Equal(ErrCancelled))
+	})
+
+	It("is never cancelled when nil", func() {
+		var signal *CancelSignal
+		Expect(signal.Err()).ToNot(HaveOccurred())
+	})
+})
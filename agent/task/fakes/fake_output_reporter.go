@@ -0,0 +1,9 @@
+package fakes
+
+type FakeOutputReporter struct {
+	Reported []interface{}
+}
+
+func (r *FakeOutputReporter) ReportOutput(output interface{}) {
+	r.Reported = append(r.Reported, output)
+}
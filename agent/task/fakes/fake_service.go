@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
 	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 )
 
@@ -8,6 +10,17 @@ type FakeService struct {
 	StartedTasks        map[string]boshtask.Task
 	CreateTaskErr       error
 	CreateTaskWithIDErr error
+
+	QueuedTasksResult []boshtask.Task
+
+	RecordedProgress    []interface{}
+	RecordProgressTask  boshtask.Task
+	RecordProgressFound bool
+
+	CanceledTaskIDs []string
+	CancelTaskErr   error
+
+	RunningCancelSignalResult *boshtask.CancelSignal
 }
 
 func NewFakeService() *FakeService {
@@ -46,7 +59,47 @@ func (s *FakeService) StartTask(task boshtask.Task) {
 	s.StartedTasks[task.ID] = task
 }
 
+func (s *FakeService) StartTaskIf(task boshtask.Task, check func(queued []boshtask.Task) error) error {
+	if check != nil {
+		if err := check(s.QueuedTasksResult); err != nil {
+			return err
+		}
+	}
+
+	s.StartTask(task)
+
+	return nil
+}
+
 func (s *FakeService) FindTaskWithID(id string) (boshtask.Task, bool) {
 	task, found := s.StartedTasks[id]
 	return task, found
 }
+
+func (s *FakeService) QueuedTasks() []boshtask.Task {
+	return s.QueuedTasksResult
+}
+
+func (s *FakeService) RecordProgress(progress interface{}) (boshtask.Task, bool) {
+	s.RecordedProgress = append(s.RecordedProgress, progress)
+	return s.RecordProgressTask, s.RecordProgressFound
+}
+
+func (s *FakeService) CancelTask(id string) error {
+	if s.CancelTaskErr != nil {
+		return s.CancelTaskErr
+	}
+
+	task, found := s.StartedTasks[id]
+	if !found {
+		return bosherr.Errorf("Task with id %s could not be found", id)
+	}
+
+	s.CanceledTaskIDs = append(s.CanceledTaskIDs, id)
+
+	return task.Cancel()
+}
+
+func (s *FakeService) RunningCancelSignal() *boshtask.CancelSignal {
+	return s.RunningCancelSignalResult
+}
@@ -0,0 +1,13 @@
+package fakes
+
+import (
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+)
+
+type FakeStageReporter struct {
+	Reported []boshtask.StageProgress
+}
+
+func (r *FakeStageReporter) ReportStage(progress boshtask.StageProgress) {
+	r.Reported = append(r.Reported, progress)
+}
@@ -9,6 +9,9 @@ type Info struct {
 	TaskID  string
 	Method  string
 	Payload []byte
+
+	// IdempotencyKey of the request that started the task
+	IdempotencyKey string
 }
 
 type ManagerProvider interface {
@@ -7,5 +7,26 @@ type Service interface {
 
 	// Records that task to run later
 	StartTask(Task)
+
+	// Records that task to run later unless check rejects the tasks queued
+	// so far. Checking and recording happen as one step, so concurrent
+	// callers see each other's tasks. check must not call the service.
+	StartTaskIf(task Task, check func(queued []Task) error) error
+
 	FindTaskWithID(string) (Task, bool)
+
+	// Started tasks that have not finished yet, in execution order.
+	// The first task is the one currently running.
+	QueuedTasks() []Task
+
+	// Records the progress of the task that is currently running and
+	// returns that task, if there is one
+	RecordProgress(progress interface{}) (Task, bool)
+
+	// Removes a queued task from the queue or signals the running task to
+	// stop. Returns an error when there was nothing to cancel, e.g. because
+	// the task already finished or its action cannot be cancelled.
+	CancelTask(id string) error
+
+	CancelSignals
 }
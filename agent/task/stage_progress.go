@@ -0,0 +1,24 @@
+package task
+
+// StageProgress is the progress long running actions other than apply publish
+// on their task. Stage names the step the action is in, Message describes it,
+// e.g. the package being installed, and Percent estimates how much of the
+// action is done.
+type StageProgress struct {
+	Stage   string `json:"stage"`
+	Percent int    `json:"percent"`
+	Message string `json:"message,omitempty"`
+}
+
+// StageReporter records the progress of the running task, which get_task
+// returns while the task is running.
+type StageReporter interface {
+	ReportStage(progress StageProgress)
+}
+
+// OutputReporter publishes output of the running task as it is produced, e.g.
+// followed log lines. get_task returns the latest output while the task is
+// running.
+type OutputReporter interface {
+	ReportOutput(output interface{})
+}
@@ -15,10 +15,14 @@ const (
 )
 
 type Task struct {
-	ID    string
-	State State
-	Value interface{}
-	Error error
+	ID     string
+	Method string
+	State  State
+	Value  interface{}
+	Error  error
+
+	// Progress is reported by the action while the task is running
+	Progress interface{}
 
 	Func       Func
 	CancelFunc CancelFunc
@@ -33,6 +37,11 @@ func (t Task) Cancel() error {
 }
 
 type StateValue struct {
-	AgentTaskID string `json:"agent_task_id"`
-	State       State  `json:"state"`
+	AgentTaskID string      `json:"agent_task_id"`
+	State       State       `json:"state"`
+	Progress    interface{} `json:"progress,omitempty"`
+
+	// WaitingFor is the id of the task that has to finish before a queued
+	// task starts running
+	WaitingFor string `json:"waiting_for,omitempty"`
 }
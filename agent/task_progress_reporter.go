@@ -0,0 +1,70 @@
+package agent
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	boshapplier "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	boshnotif "github.com/cloudfoundry/bosh-agent/v2/notification"
+)
+
+const taskProgressReporterLogTag = "Task Progress Reporter"
+
+// TaskProgressReporter records the progress of applies, the stages of other
+// long running actions and the output of actions like tail_logs.
+type TaskProgressReporter interface {
+	boshapplier.ProgressReporter
+	boshtask.StageReporter
+	boshtask.OutputReporter
+}
+
+type taskProgressReporter struct {
+	taskService boshtask.Service
+	notifier    boshnotif.Notifier
+	logger      boshlog.Logger
+}
+
+// NewTaskProgressReporter records the progress of applies and other long
+// running actions on the task running them, which get_task returns, and
+// sends it to the director. Progress outside of tasks, e.g. of applies while
+// bootstrapping, is dropped.
+func NewTaskProgressReporter(
+	taskService boshtask.Service,
+	notifier boshnotif.Notifier,
+	logger boshlog.Logger,
+) TaskProgressReporter {
+	return taskProgressReporter{
+		taskService: taskService,
+		notifier:    notifier,
+		logger:      logger,
+	}
+}
+
+func (r taskProgressReporter) ReportProgress(progress boshapplier.ApplyProgress) {
+	r.report(progress)
+}
+
+func (r taskProgressReporter) ReportStage(progress boshtask.StageProgress) {
+	r.report(progress)
+}
+
+func (r taskProgressReporter) ReportOutput(output interface{}) {
+	r.report(output)
+}
+
+func (r taskProgressReporter) report(progress interface{}) {
+	task, found := r.taskService.RecordProgress(progress)
+	if !found {
+		return
+	}
+
+	err := r.notifier.NotifyTaskProgress(boshnotif.TaskProgress{
+		AgentTaskID: task.ID,
+		Method:      task.Method,
+		Progress:    progress,
+	})
+	if err != nil {
+		// Progress is only informational, get_task still returns it
+		r.logger.Warn(taskProgressReporterLogTag, "Sending progress of task %s: %s", task.ID, err.Error())
+	}
+}
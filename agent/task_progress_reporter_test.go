@@ -0,0 +1,86 @@
+package agent_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/agent"
+	boshapplier "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/v2/agent/task/fakes"
+	boshnotif "github.com/cloudfoundry/bosh-agent/v2/notification"
+	fakenotif "github.com/cloudfoundry/bosh-agent/v2/notification/fakes"
+)
+
+var _ = Describe("TaskProgressReporter", func() {
+	var (
+		taskService *faketask.FakeService
+		notifier    *fakenotif.FakeNotifier
+		reporter    TaskProgressReporter
+		progress    boshapplier.ApplyProgress
+	)
+
+	BeforeEach(func() {
+		taskService = faketask.NewFakeService()
+		notifier = fakenotif.NewFakeNotifier()
+		reporter = NewTaskProgressReporter(taskService, notifier, boshlog.NewLogger(boshlog.LevelNone))
+		progress = boshapplier.ApplyProgress{Step: boshapplier.ApplyStepApplyingJob, Job: "fake-job", Percent: 50}
+	})
+
+	It("records the progress on the running task and sends it to the director", func() {
+		taskService.RecordProgressTask = boshtask.Task{ID: "fake-task-id", Method: "apply"}
+		taskService.RecordProgressFound = true
+
+		reporter.ReportProgress(progress)
+
+		Expect(taskService.RecordedProgress).To(Equal([]interface{}{progress}))
+		Expect(notifier.NotifiedTaskProgress).To(Equal([]boshnotif.TaskProgress{
+			{AgentTaskID: "fake-task-id", Method: "apply", Progress: progress},
+		}))
+	})
+
+	It("records the stages of other actions on the running task and sends them to the director", func() {
+		taskService.RecordProgressTask = boshtask.Task{ID: "fake-task-id", Method: "compile_package"}
+		taskService.RecordProgressFound = true
+
+		stage := boshtask.StageProgress{Stage: "uploading", Percent: 80, Message: "Uploading compiled package fake-pkg"}
+		reporter.ReportStage(stage)
+
+		Expect(taskService.RecordedProgress).To(Equal([]interface{}{stage}))
+		Expect(notifier.NotifiedTaskProgress).To(Equal([]boshnotif.TaskProgress{
+			{AgentTaskID: "fake-task-id", Method: "compile_package", Progress: stage},
+		}))
+	})
+
+	It("records the output of the running task and sends it to the director", func() {
+		taskService.RecordProgressTask = boshtask.Task{ID: "fake-task-id", Method: "tail_logs"}
+		taskService.RecordProgressFound = true
+
+		output := map[string]interface{}{"sequence": 1}
+		reporter.ReportOutput(output)
+
+		Expect(taskService.RecordedProgress).To(Equal([]interface{}{output}))
+		Expect(notifier.NotifiedTaskProgress).To(Equal([]boshnotif.TaskProgress{
+			{AgentTaskID: "fake-task-id", Method: "tail_logs", Progress: output},
+		}))
+	})
+
+	It("does not send progress when no task is running", func() {
+		reporter.ReportProgress(progress)
+
+		Expect(notifier.NotifiedTaskProgress).To(BeEmpty())
+	})
+
+	It("ignores failures to send the progress", func() {
+		taskService.RecordProgressFound = true
+		notifier.NotifyTaskProgressErr = errors.New("fake-notify-err")
+
+		reporter.ReportProgress(progress)
+
+		Expect(notifier.NotifiedTaskProgress).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,166 @@
+package templaterenderer
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Template is an unrendered job template. Name is the name of the template
+// in the job, e.g. config.yml.erb, and Destination the path of the rendered
+// file relative to the job directory, e.g. config/config.yml.
+type Template struct {
+	Name        string `json:"name"`
+	Destination string `json:"destination"`
+	Content     string `json:"content"`
+}
+
+// Job holds the templates of a job and the properties they are rendered with.
+type Job struct {
+	Name       string                 `json:"name"`
+	Templates  []Template             `json:"templates"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Instance describes the instance the templates are rendered for. Templates
+// reach it as .Spec, e.g. {{ .Spec.Address }}.
+type Instance struct {
+	ID         string             `json:"id"`
+	Index      int                `json:"index"`
+	Name       string             `json:"name"`
+	Deployment string             `json:"deployment"`
+	AZ         string             `json:"az"`
+	Bootstrap  bool               `json:"bootstrap"`
+	Address    string             `json:"address"`
+	Networks   map[string]Network `json:"networks"`
+}
+
+type Network struct {
+	IP      string `json:"ip"`
+	Netmask string `json:"netmask"`
+	Gateway string `json:"gateway"`
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Renderer
+
+type Renderer interface {
+	// RenderJob returns the rendered contents of the job's templates keyed
+	// by their destination
+	RenderJob(job Job, instance Instance) (map[string][]byte, error)
+}
+
+type renderer struct{}
+
+// NewRenderer renders Go templates. ERB templates need a Ruby runtime the
+// agent does not have, so the director has to keep rendering those.
+func NewRenderer() Renderer {
+	return renderer{}
+}
+
+type templateData struct {
+	Spec       Instance
+	Properties map[string]interface{}
+}
+
+func (r renderer) RenderJob(job Job, instance Instance) (map[string][]byte, error) {
+	rendered := map[string][]byte{}
+
+	for _, tmpl := range job.Templates {
+		if err := validDestination(tmpl.Destination); err != nil {
+			return nil, bosherr.WrapErrorf(err, "Rendering template '%s' of job '%s'", tmpl.Name, job.Name)
+		}
+
+		contents, err := r.render(tmpl, templateData{Spec: instance, Properties: job.Properties})
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Rendering template '%s' of job '%s'", tmpl.Name, job.Name)
+		}
+
+		rendered[tmpl.Destination] = contents
+	}
+
+	return rendered, nil
+}
+
+func (r renderer) render(tmpl Template, data templateData) ([]byte, error) {
+	if strings.HasSuffix(tmpl.Name, ".erb") {
+		return nil, bosherr.Error("ERB templates are not supported by the agent")
+	}
+
+	parsed, err := template.New(tmpl.Name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"p":     propertyFunc(data.Properties),
+			"has_p": hasPropertyFunc(data.Properties),
+		}).
+		Parse(tmpl.Content)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing template")
+	}
+
+	var buf bytes.Buffer
+
+	err = parsed.Execute(&buf, data)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Executing template")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// propertyFunc looks up a property by its dotted name like p in ERB
+// templates, e.g. {{ p "server.port" 8080 }}. Without a default missing
+// properties fail the rendering.
+func propertyFunc(properties map[string]interface{}) func(string, ...interface{}) (interface{}, error) {
+	return func(name string, defaultValue ...interface{}) (interface{}, error) {
+		value, found := lookupProperty(properties, name)
+		if found {
+			return value, nil
+		}
+
+		if len(defaultValue) > 0 {
+			return defaultValue[0], nil
+		}
+
+		return nil, fmt.Errorf("Can't find property '%s'", name)
+	}
+}
+
+func hasPropertyFunc(properties map[string]interface{}) func(string) bool {
+	return func(name string) bool {
+		_, found := lookupProperty(properties, name)
+		return found
+	}
+}
+
+func lookupProperty(properties map[string]interface{}, name string) (interface{}, bool) {
+	var current interface{} = properties
+
+	for _, key := range strings.Split(name, ".") {
+		nested, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = nested[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	// Properties set to null are unset
+	return current, current != nil
+}
+
+// validDestination keeps rendered files inside the job directory
+func validDestination(destination string) error {
+	cleaned := path.Clean(destination)
+	if destination == "" || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return bosherr.Errorf("Invalid destination '%s'", destination)
+	}
+
+	return nil
+}
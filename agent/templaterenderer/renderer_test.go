@@ -0,0 +1,86 @@
+package templaterenderer_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/agent/templaterenderer"
+)
+
+var _ = Describe("Renderer", func() {
+	var (
+		renderer templaterenderer.Renderer
+		instance templaterenderer.Instance
+		job      templaterenderer.Job
+	)
+
+	BeforeEach(func() {
+		renderer = templaterenderer.NewRenderer()
+		instance = templaterenderer.Instance{
+			ID:      "fake-instance-id",
+			Index:   2,
+			Address: "10.0.0.5",
+			Networks: map[string]templaterenderer.Network{
+				"default": {IP: "10.0.0.5", Netmask: "255.255.255.0", Gateway: "10.0.0.1"},
+			},
+		}
+		job = templaterenderer.Job{
+			Name: "fake-job",
+			Properties: map[string]interface{}{
+				"server": map[string]interface{}{"port": 8080, "tls": nil},
+			},
+		}
+	})
+
+	It("renders go templates with properties and instance values", func() {
+		job.Templates = []templaterenderer.Template{
+			{Name: "config.yml", Destination: "config/config.yml", Content: `listen: {{ .Spec.Address }}:{{ p "server.port" }}`},
+			{Name: "bin/ctl", Destination: "bin/ctl", Content: `{{ .Spec.ID }}/{{ .Spec.Index }} {{ (index .Spec.Networks "default").Gateway }}`},
+		}
+
+		rendered, err := renderer.RenderJob(job, instance)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rendered).To(Equal(map[string][]byte{
+			"config/config.yml": []byte("listen: 10.0.0.5:8080"),
+			"bin/ctl":           []byte("fake-instance-id/2 10.0.0.1"),
+		}))
+	})
+
+	It("uses defaults for missing properties", func() {
+		job.Templates = []templaterenderer.Template{
+			{Name: "config.yml", Destination: "config.yml", Content: `{{ p "server.host" "0.0.0.0" }} {{ has_p "server.port" }} {{ has_p "server.tls" }}`},
+		}
+
+		rendered, err := renderer.RenderJob(job, instance)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(rendered["config.yml"])).To(Equal("0.0.0.0 true false"))
+	})
+
+	It("fails when a property without a default is missing", func() {
+		job.Templates = []templaterenderer.Template{
+			{Name: "config.yml", Destination: "config.yml", Content: `{{ p "server.host" }}`},
+		}
+
+		_, err := renderer.RenderJob(job, instance)
+		Expect(err).To(MatchError(ContainSubstring("Can't find property 'server.host'")))
+		Expect(err).To(MatchError(ContainSubstring("Rendering template 'config.yml' of job 'fake-job'")))
+	})
+
+	It("fails for erb templates", func() {
+		job.Templates = []templaterenderer.Template{
+			{Name: "config.yml.erb", Destination: "config.yml", Content: `<%= p("server.port") %>`},
+		}
+
+		_, err := renderer.RenderJob(job, instance)
+		Expect(err).To(MatchError("Rendering template 'config.yml.erb' of job 'fake-job': ERB templates are not supported by the agent"))
+	})
+
+	It("fails for destinations outside of the job directory", func() {
+		for _, destination := range []string{"", "/etc/passwd", "../other-job/config.yml", "config/../../config.yml"} {
+			job.Templates = []templaterenderer.Template{{Name: "config.yml", Destination: destination}}
+
+			_, err := renderer.RenderJob(job, instance)
+			Expect(err).To(MatchError(ContainSubstring("Invalid destination")), destination)
+		}
+	})
+})
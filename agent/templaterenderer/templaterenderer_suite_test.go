@@ -0,0 +1,13 @@
+package templaterenderer_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTemplateRenderer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Template Renderer Suite")
+}
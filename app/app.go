@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
@@ -17,18 +18,30 @@ import (
 
 	boshagent "github.com/cloudfoundry/bosh-agent/v2/agent"
 	boshaction "github.com/cloudfoundry/bosh-agent/v2/agent/action"
+	boshdnsstate "github.com/cloudfoundry/bosh-agent/v2/agent/action/state"
 	boshapplier "github.com/cloudfoundry/bosh-agent/v2/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/v2/agent/applier/applyspec"
 	boshbc "github.com/cloudfoundry/bosh-agent/v2/agent/applier/bundlecollection"
 	boshaj "github.com/cloudfoundry/bosh-agent/v2/agent/applier/jobs"
 	boshap "github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/signatures"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/artifact"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/audit"
 	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/bootonce"
 	boshrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/coredump"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/filewatcher"
 	httpblobprovider "github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/azure"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/gcs"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/s3"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/loglevel"
 	boshscript "github.com/cloudfoundry/bosh-agent/v2/agent/script"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/snapshot"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/sshusers"
 	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshinf "github.com/cloudfoundry/bosh-agent/v2/infrastructure"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/v2/jobsupervisor"
@@ -36,6 +49,7 @@ import (
 	boshmbus "github.com/cloudfoundry/bosh-agent/v2/mbus"
 	boshnotif "github.com/cloudfoundry/bosh-agent/v2/notification"
 	boshplatform "github.com/cloudfoundry/bosh-agent/v2/platform"
+	boshdisk "github.com/cloudfoundry/bosh-agent/v2/platform/disk"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 	boshsigar "github.com/cloudfoundry/bosh-agent/v2/sigar"
@@ -49,18 +63,31 @@ type App interface {
 
 type app struct {
 	logger      boshlog.Logger
+	logLevel    *loglevel.Logger
 	agent       boshagent.Agent
 	platform    boshplatform.Platform
 	fs          boshsys.FileSystem
 	logTag      string
 	dirProvider boshdirs.Provider
+
+	// artifactServer is nil on Windows, which has no vcap group to grant
+	// jobs access to the socket
+	artifactServer *artifact.Server
+
+	// certificateWatcher is nil when the mbus handler cannot reload its
+	// certificates
+	certificateWatcher *boshmbus.CertificateWatcher
 }
 
 func New(logger boshlog.Logger, fs boshsys.FileSystem) App {
+	// The agent logs everything unless set_log_level says otherwise
+	logLevel := loglevel.NewLogger(logger, boshlog.LevelDebug, clock.NewClock())
+
 	return &app{
-		logger: logger,
-		fs:     fs,
-		logTag: "App",
+		logger:   logLevel,
+		logLevel: logLevel,
+		fs:       fs,
+		logTag:   "App",
 	}
 }
 
@@ -103,12 +130,44 @@ func (app *app) Setup(opts Options) error {
 		app.logger,
 	)
 
+	uuidGen := boshuuid.NewGenerator()
+
+	taskService := boshtask.NewAsyncTaskService(uuidGen, app.logger)
+
+	// Changes of the installed bundles and the applied spec are attributed
+	// to the task making them
+	auditLog := audit.NewFileLog(
+		app.platform.GetFs(),
+		filepath.Join(app.dirProvider.BoshDir(), "audit.log"),
+		audit.DefaultMaxSize,
+		taskService,
+		timeService,
+		app.logger,
+	)
+
 	specFilePath := filepath.Join(app.dirProvider.BoshDir(), "spec.json")
-	specService := boshas.NewConcreteV1Service(
+	specService := boshas.NewAuditedV1Service(
+		boshas.NewConcreteV1Service(
+			app.platform.GetFs(),
+			specFilePath,
+		),
+		auditLog,
+	)
+
+	// Writes to a filesystem left frozen would block bootstrapping
+	snapshotFreezer := snapshot.NewFreezer(
+		app.platform,
 		app.platform.GetFs(),
-		specFilePath,
+		app.dirProvider.StoreDir(),
+		filepath.Join(app.dirProvider.DataDir(), "frozen_filesystem"),
+		timeService,
+		app.logger,
 	)
 
+	if _, err = snapshotFreezer.ThawLeftover(); err != nil {
+		app.logger.Warn(app.logTag, "Failed to thaw filesystem left frozen: %s", err.Error())
+	}
+
 	boot := boshagent.NewBootstrap(
 		app.platform,
 		app.dirProvider,
@@ -121,6 +180,8 @@ func (app *app) Setup(opts Options) error {
 		return bosherr.WrapError(err, "Running bootstrap")
 	}
 
+	app.recoverDNSRecords(uuidGen)
+
 	// For storing large non-sensitive blobs
 	inconsiderateBlobManager, err := boshagentblobstore.NewBlobManager(app.dirProvider.BlobsDir())
 	if err != nil {
@@ -148,6 +209,10 @@ func (app *app) Setup(opts Options) error {
 		return bosherr.WrapError(err, "Getting mbus handler")
 	}
 
+	if reloader, ok := mbusHandler.(boshmbus.CertificateReloader); ok {
+		app.certificateWatcher = boshmbus.NewCertificateWatcher(settingsService, reloader, timeService, boshmbus.DefaultCertificateWatchInterval, app.logger)
+	}
+
 	monitClientProvider := boshmonit.NewProvider(app.platform, app.logger)
 
 	monitClient, err := monitClientProvider.Get()
@@ -175,23 +240,157 @@ func (app *app) Setup(opts Options) error {
 		return bosherr.WrapError(err, "Failed constructing blobstore http client")
 	}
 
-	blobstoreDelegator := blobstore_delegator.NewBlobstoreDelegator(
-		httpblobprovider.NewHTTPBlobImpl(app.platform.GetFs(), blobstoreHTTPClient),
-		blobstore, app.logger,
+	agentBlobstoreSettings := settingsService.GetSettings().Env.Bosh.Agent.Settings.Blobstore
+
+	err = httpblobprovider.ConfigureProxy(blobstoreHTTPClient, agentBlobstoreSettings.Proxy)
+	if err != nil {
+		return bosherr.WrapError(err, "Configuring blobstore proxy")
+	}
+
+	err = httpblobprovider.ConfigureClientTLS(blobstoreHTTPClient, agentBlobstoreSettings.SignedURLTLS)
+	if err != nil {
+		return bosherr.WrapError(err, "Configuring blobstore client TLS")
+	}
+
+	err = httpblobprovider.ConfigureConnectionPool(blobstoreHTTPClient, agentBlobstoreSettings.ConnectionPool)
+	if err != nil {
+		return bosherr.WrapError(err, "Configuring blobstore connection pool")
+	}
+
+	err = httpblobprovider.ConfigureEndpointCAs(blobstoreHTTPClient, agentBlobstoreSettings.EndpointCAs)
+	if err != nil {
+		return bosherr.WrapError(err, "Configuring blobstore endpoint CAs")
+	}
+
+	signedURLRefresher := blobstore_delegator.NewMbusSignedURLRefresher(mbusHandler, uuidGen, timeService, blobstore_delegator.DefaultSignedURLRefreshTimeout, app.logger)
+
+	uploadLimiter := httpblobprovider.NewRateLimiter(agentBlobstoreSettings.UploadBytesPerSecond, timeService)
+	transferMetrics := httpblobprovider.NewTransferMetrics(agentBlobstoreSettings.SlowTransferBytesPerSecond, app.logger)
+
+	httpBlobProvider := httpblobprovider.NewThrottledHTTPBlobImpl(
+		app.platform.GetFs(),
+		blobstoreHTTPClient,
+		httpblobprovider.NewRateLimiter(agentBlobstoreSettings.DownloadBytesPerSecond, timeService),
+		uploadLimiter,
+		transferMetrics,
+	)
+
+	if agentBlobstoreSettings.StagingDir != "" {
+		httpBlobProvider.UseStagingDir(agentBlobstoreSettings.StagingDir, statsCollector)
+	}
+
+	var blobstoreDelegator blobstore_delegator.BlobstoreDelegator = blobstore_delegator.NewRefreshingBlobstoreDelegator(
+		httpBlobProvider, blobstore, signedURLRefresher, blobstore_delegator.NewRetryPolicy(agentBlobstoreSettings.Retry), transferMetrics, app.logger,
 	)
 
-	applier, compiler := app.buildApplierAndCompiler(
+	objectStore, err := app.nativeObjectStore(settingsService.GetSettings().GetBlobstore(), agentBlobstoreSettings, blobstoreHTTPClient, uploadLimiter, timeService)
+	if err != nil {
+		return err
+	}
+
+	if objectStore != nil {
+		blobstoreDelegator = blobstore_delegator.NewNativeBlobstoreDelegator(
+			blobstoreDelegator,
+			httpBlobProvider,
+			blobstoreHTTPClient,
+			objectStore,
+			uuidGen,
+			app.logger,
+		)
+	}
+
+	if canFallBackToBlobstoreClient(settingsService.GetSettings().GetBlobstore()) {
+		blobstoreDelegator = blobstore_delegator.NewFallbackBlobstoreDelegator(
+			blobstoreDelegator,
+			blobstore,
+			blobstore_delegator.DefaultFallbackFailureThreshold,
+			blobstore_delegator.DefaultFallbackCooldown,
+			transferMetrics,
+			timeService,
+			app.logger,
+		)
+	}
+
+	if agentBlobstoreSettings.MaxConcurrentTransfers > 0 {
+		blobstoreDelegator = blobstore_delegator.NewLimitingBlobstoreDelegator(blobstoreDelegator, agentBlobstoreSettings.MaxConcurrentTransfers)
+	}
+
+	// Rendered job templates contain credentials and are never cached
+	jobsBlobstoreDelegator := blobstoreDelegator
+
+	if blobCacheSize := settingsService.GetSettings().Env.GetBlobCacheSizeInBytes(); blobCacheSize > 0 {
+		blobCache := blobstore_delegator.NewBlobCache(app.platform.GetFs(), app.dirProvider.BlobCacheDir(), blobCacheSize, app.logger)
+		blobstoreDelegator = blobstore_delegator.NewCachingBlobstoreDelegator(blobstoreDelegator, blobCache, app.logger)
+	}
+
+	// Pre-seeded blobs are preferred over the blob cache and downloads
+	seedDirs := append([]string{app.dirProvider.SeedsDir()}, agentBlobstoreSettings.SeedDirs...)
+	blobstoreDelegator = blobstore_delegator.NewSeedingBlobstoreDelegator(blobstoreDelegator, app.platform.GetFs(), seedDirs, app.logger)
+
+	blobstoreDelegator = blobstore_delegator.NewDeduplicatingBlobstoreDelegator(blobstoreDelegator, app.platform.GetFs(), app.logger)
+
+	if agentBlobstoreSettings.MinimumDigestAlgorithm != "" {
+		blobstoreDelegator, err = blobstore_delegator.NewDigestPolicyBlobstoreDelegator(blobstoreDelegator, agentBlobstoreSettings.MinimumDigestAlgorithm)
+		if err != nil {
+			return bosherr.WrapError(err, "Configuring blobstore digest policy")
+		}
+
+		jobsBlobstoreDelegator, err = blobstore_delegator.NewDigestPolicyBlobstoreDelegator(jobsBlobstoreDelegator, agentBlobstoreSettings.MinimumDigestAlgorithm)
+		if err != nil {
+			return bosherr.WrapError(err, "Configuring blobstore digest policy")
+		}
+	}
+
+	compilerBlobstoreDelegator := blobstoreDelegator
+
+	if len(agentBlobstoreSettings.Encryption.Keys) > 0 {
+		encryptionKeys, err := blobstore_delegator.NewBlobEncryptionKeys(agentBlobstoreSettings.Encryption)
+		if err != nil {
+			return bosherr.WrapError(err, "Configuring blob encryption")
+		}
+
+		plainBlobstoreDelegator := blobstoreDelegator
+		blobstoreDelegator = blobstore_delegator.NewDecryptingBlobstoreDelegator(plainBlobstoreDelegator, encryptionKeys, app.platform.GetFs())
+		compilerBlobstoreDelegator = blobstoreDelegator
+
+		// Only compiled packages are encrypted, other uploads like logs are read by the director
+		if encryptionKeys.CanEncrypt() {
+			compilerBlobstoreDelegator = blobstore_delegator.NewEncryptingBlobstoreDelegator(plainBlobstoreDelegator, encryptionKeys, app.platform.GetFs())
+		}
+	}
+
+	fileWatcher := filewatcher.NewWatcher(app.platform.GetFs(), app.platform.GetRunner(), filewatcher.DefaultDebounce, app.logger)
+
+	// Re-establish file watches of the currently applied spec after agent restarts
+	if currentSpec, err := specService.Get(); err == nil {
+		err = fileWatcher.Watch(currentSpec.FileWatches())
+		if err != nil {
+			app.logger.Error(app.logTag, "Watching job files: %s", err.Error())
+		}
+	}
+
+	signatureVerifier, err := signatures.NewVerifier(settingsService.GetSettings().Env.Bosh.Agent.Settings.BundleSigning, app.platform.GetFs())
+	if err != nil {
+		return bosherr.WrapError(err, "Configuring bundle signature verification")
+	}
+
+	taskProgressReporter := boshagent.NewTaskProgressReporter(taskService, notifier, app.logger)
+
+	applier, bundleVerifier, compiler := app.buildApplierAndCompiler(
 		app.dirProvider,
 		blobstoreDelegator,
+		jobsBlobstoreDelegator,
+		compilerBlobstoreDelegator,
+		signatureVerifier,
 		jobSupervisor,
+		fileWatcher,
 		settingsService.GetSettings(),
 		timeService,
+		specService,
+		taskProgressReporter,
+		auditLog,
 	)
 
-	uuidGen := boshuuid.NewGenerator()
-
-	taskService := boshtask.NewAsyncTaskService(uuidGen, app.logger)
-
 	taskManager := boshtask.NewManagerProvider().NewManager(
 		app.logger,
 		app.platform.GetFs(),
@@ -206,6 +405,14 @@ func (app *app) Setup(opts Options) error {
 		app.logger,
 	)
 
+	sshUsers := sshusers.NewTracker(
+		app.platform.GetFs(),
+		filepath.Join(app.dirProvider.BoshDir(), "ephemeral_users.json"),
+		app.platform,
+		timeService,
+		app.logger,
+	)
+
 	actionFactory := boshaction.NewFactory(
 		settingsService,
 		app.platform,
@@ -213,12 +420,20 @@ func (app *app) Setup(opts Options) error {
 		taskService,
 		notifier,
 		applier,
+		bundleVerifier,
 		compiler,
 		jobSupervisor,
 		specService,
+		auditLog,
 		jobScriptProvider,
 		app.logger,
 		blobstoreDelegator,
+		signedURLRefresher,
+		taskProgressReporter,
+		taskProgressReporter,
+		sshUsers,
+		app.logLevel,
+		snapshotFreezer,
 	)
 
 	actionRunner := boshaction.NewRunner()
@@ -229,6 +444,8 @@ func (app *app) Setup(opts Options) error {
 		taskManager,
 		actionFactory,
 		actionRunner,
+		notifier,
+		settingsService.GetSettings().Env.Bosh.Agent.Settings.QueueApplies,
 	)
 
 	startManager := bootonce.NewStartManager(
@@ -237,6 +454,28 @@ func (app *app) Setup(opts Options) error {
 		app.dirProvider,
 	)
 
+	// Bundles are verified on start only when enabled, the verify_bundles
+	// action is always available
+	var startupBundleVerifier boshapplier.BundleVerifier
+	if settingsService.GetSettings().Env.Bosh.Agent.Settings.VerifyBundles {
+		startupBundleVerifier = bundleVerifier
+	}
+
+	// Core dumps are only written to the core dumps dir when enabled
+	var coreDumps coredump.Collector
+	if settingsService.GetSettings().Env.Bosh.Agent.Settings.CoreDumps.Enabled {
+		coreDumps = coredump.NewCollector(app.platform.GetFs(), app.dirProvider)
+	}
+
+	if opts.PlatformName != "windows" {
+		app.artifactServer = artifact.NewServer(
+			artifact.NewUploader(blobstoreDelegator, specService, app.platform.GetFs(), app.dirProvider, app.logger),
+			app.platform.GetFs(),
+			filepath.Join(app.dirProvider.BoshDir(), "artifacts.sock"),
+			app.logger,
+		)
+	}
+
 	app.agent = boshagent.New(
 		app.logger,
 		mbusHandler,
@@ -249,12 +488,38 @@ func (app *app) Setup(opts Options) error {
 		uuidGen,
 		timeService,
 		startManager,
+		transferMetrics,
+		startupBundleVerifier,
+		sshUsers,
+		coreDumps,
 	)
 
 	return nil
 }
 
 func (app *app) Run() error {
+	if app.artifactServer != nil {
+		go func() {
+			defer app.logger.HandlePanic("Artifact Server")
+
+			// Not fatal, the upload_artifact action still uploads artifacts
+			err := app.artifactServer.ListenAndServe()
+			if err != nil {
+				app.logger.Error(app.logTag, "Serving artifact uploads: %s", err.Error())
+			}
+		}()
+	}
+
+	if app.certificateWatcher != nil {
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+
+		go func() {
+			defer app.logger.HandlePanic("Certificate Watcher")
+			app.certificateWatcher.Run(stopCh)
+		}()
+	}
+
 	if err := app.agent.Run(); err != nil {
 		return bosherr.WrapError(err, "Running agent")
 	}
@@ -268,74 +533,143 @@ func (app *app) GetPlatform() boshplatform.Platform {
 func (app *app) buildApplierAndCompiler(
 	dirProvider boshdirs.Provider,
 	blobstoreDelegator blobstore_delegator.BlobstoreDelegator,
+	jobsBlobstoreDelegator blobstore_delegator.BlobstoreDelegator,
+	compilerBlobstoreDelegator blobstore_delegator.BlobstoreDelegator,
+	signatureVerifier signatures.Verifier,
 	jobSupervisor boshjobsuper.JobSupervisor,
+	fileWatcher filewatcher.Watcher,
 	settings boshsettings.Settings,
 	timeService clock.Clock,
-) (boshapplier.Applier, boshcomp.Compiler) {
+	specService boshas.V1Service,
+	progressReporter boshagent.TaskProgressReporter,
+	auditLog audit.Log,
+) (boshapplier.Applier, boshapplier.BundleVerifier, boshcomp.Compiler) {
 	fileSystem := app.platform.GetFs()
 
-	jobsBc := boshbc.NewFileBundleCollection(
+	var bundleMounter boshdisk.Mounter
+	if settings.Env.Bosh.Agent.Settings.ReadOnlyBundles {
+		bundleMounter = boshdisk.NewLinuxBindMounter(boshdisk.NewLinuxMounter(
+			app.platform.GetRunner(),
+			boshdisk.NewProcMountsSearcher(fileSystem),
+			1*time.Second,
+		))
+	}
+
+	// Jobs are enabled in a staging directory until all of them are switched
+	// over at once, job specific packages are linked from there as well
+	jobsEnablePath := dirProvider.BaseDir()
+	if settings.Env.Bosh.Agent.Settings.AtomicJobSwitchover {
+		jobsEnablePath = filepath.Join(dirProvider.JobsRootsDir(), "staging")
+	}
+
+	jobsBc := boshbc.NewAuditedBundleCollection(boshbc.NewFileBundleCollection(
 		dirProvider.DataDir(),
-		dirProvider.BaseDir(),
+		jobsEnablePath,
 		"jobs",
 		os.FileMode(0750),
 		fileSystem,
 		timeService,
 		app.platform.GetCompressor(),
+		bundleMounter,
+		settings.Env.Bosh.Agent.Settings.BundleQuota.JobsMB*1024*1024,
 		app.logger,
+	), "jobs", auditLog)
+
+	cmdRunner := boshrunner.NewFileLoggingCmdRunner(
+		fileSystem,
+		app.platform.GetRunner(),
+		dirProvider.LogsDir(),
+		10*1024, // 10 Kb
 	)
 
 	packageApplierProvider := boshap.NewCompiledPackageApplierProvider(
 		dirProvider.DataDir(),
 		dirProvider.BaseDir(),
-		dirProvider.JobsDir(),
+		filepath.Join(jobsEnablePath, "jobs"),
 		"packages",
 		blobstoreDelegator,
+		signatureVerifier,
 		app.platform.GetCompressor(),
 		fileSystem,
+		cmdRunner,
 		timeService,
+		bundleMounter,
+		settings.Env.Bosh.Agent.Settings.BundleQuota.PackagesMB*1024*1024,
+		settings.Env.Bosh.Agent.Settings.BundlePermissions,
+		auditLog,
 		app.logger,
 	)
 
 	jobApplier := boshaj.NewRenderedJobApplier(
-		blobstoreDelegator,
+		jobsBlobstoreDelegator,
+		signatureVerifier,
 		dirProvider,
 		jobsBc,
 		jobSupervisor,
 		packageApplierProvider,
+		settings.Env.Bosh.Agent.Settings.JobScopedPackages,
+		settings.Env.Bosh.Agent.Settings.AtomicJobSwitchover,
+		settings.Env.Bosh.Agent.Settings.LazyPackages,
+		settings.Env.Bosh.Agent.Settings.BundlePermissions,
 		boshaj.FixPermissions,
 		fileSystem,
 		app.logger,
 	)
 
+	// Compilation keeps enabling packages system-wide since packaging
+	// scripts of dependent packages expect them there
+	rootPackageApplier := packageApplierProvider.Root()
+	if settings.Env.Bosh.Agent.Settings.JobScopedPackages {
+		rootPackageApplier = packageApplierProvider.JobScopedRoot()
+	}
+
 	applier := boshapplier.NewConcreteApplier(
 		jobApplier,
-		packageApplierProvider.Root(),
+		rootPackageApplier,
 		app.platform,
 		jobSupervisor,
+		fileWatcher,
 		dirProvider,
 		settings,
-	)
-
-	cmdRunner := boshrunner.NewFileLoggingCmdRunner(
+		boshbc.NewRetentionPolicy(
+			settings.Env.Bosh.Agent.Settings.BundleRetention.KeepVersions,
+			time.Duration(settings.Env.Bosh.Agent.Settings.BundleRetention.KeepHours)*time.Hour,
+			timeService,
+		),
+		specService,
+		progressReporter,
 		fileSystem,
-		app.platform.GetRunner(),
-		dirProvider.LogsDir(),
-		10*1024, // 10 Kb
 	)
 
+	bundleVerifier := boshapplier.NewBundleVerifier(jobsBc, packageApplierProvider.RootBundleCollection(), timeService, app.logger)
+
 	compiler := boshcomp.NewConcreteCompiler(
 		app.platform.GetCompressor(),
-		blobstoreDelegator,
+		compilerBlobstoreDelegator,
 		fileSystem,
 		cmdRunner,
 		dirProvider,
 		packageApplierProvider.Root(),
 		packageApplierProvider.RootBundleCollection(),
 		clock.NewClock(),
+		progressReporter,
 	)
 
-	return applier, compiler
+	return applier, bundleVerifier, compiler
+}
+
+func (app *app) recoverDNSRecords(uuidGen boshuuid.Generator) {
+	recordsPath := filepath.Join(app.dirProvider.InstanceDNSDir(), boshdnsstate.RecordsFileName)
+
+	recovered, err := boshdnsstate.NewSyncDNSState(app.platform, recordsPath, uuidGen).Recover()
+	if err != nil {
+		app.logger.Error(app.logTag, "Recovering DNS records: %s", err.Error())
+		return
+	}
+
+	if recovered {
+		app.logger.Info(app.logTag, "Recovered DNS records from journal")
+	}
 }
 
 func (app *app) loadConfig(path string) (Config, error) {
@@ -381,6 +715,67 @@ func (app *app) setupBlobstore(
 	return boshagentblobstore.NewCascadingBlobstore(blobstore, blobManagers, app.logger), nil
 }
 
+// nativeObjectStore returns the object store the agent can reach with the
+// instance's own identity, or nil when blobs have to go through the CLI.
+func (app *app) nativeObjectStore(
+	blobstoreSettings boshsettings.Blobstore,
+	agentBlobstoreSettings boshsettings.AgentBlobstoreSettings,
+	client *http.Client,
+	uploadLimiter *httpblobprovider.RateLimiter,
+	timeService clock.Clock,
+) (blobstore_delegator.ObjectStore, error) {
+	switch {
+	case agentBlobstoreSettings.NativeS3 && blobstoreSettings.Type == s3.BlobstoreTypeS3:
+		s3Config, err := s3.NewConfigFromOptions(blobstoreSettings.Options)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Configuring native s3 blobstore")
+		}
+
+		if s3Config.CredentialsSource == s3.CredentialsSourceEnvOrProfile {
+			credentials := s3.NewDefaultCredentialsProvider(app.platform.GetFs(), client, s3Config, timeService)
+			return s3.NewObjectStore(s3Config, credentials, timeService), nil
+		}
+
+	case agentBlobstoreSettings.NativeGCS && blobstoreSettings.Type == gcs.BlobstoreTypeGCS:
+		gcsConfig, err := gcs.NewConfigFromOptions(blobstoreSettings.Options)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Configuring native gcs blobstore")
+		}
+
+		if gcsConfig.UsesWorkloadIdentity() {
+			return gcs.NewObjectStore(gcsConfig, gcs.NewDefaultTokenSource(timeService)), nil
+		}
+
+	case agentBlobstoreSettings.NativeAzure && blobstoreSettings.Type == azure.BlobstoreTypeAzure:
+		azureConfig, err := azure.NewConfigFromOptions(blobstoreSettings.Options)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Configuring native azure blobstore")
+		}
+
+		if azureConfig.UsesManagedIdentity() {
+			tokens := azure.NewDefaultTokenSource(timeService)
+			return azure.NewObjectStore(azureConfig, tokens, app.platform.GetFs(), client, uploadLimiter, azure.DefaultBlockSize, timeService), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// canFallBackToBlobstoreClient is true for blobstores the agent can reach
+// without signed URLs, which are the local blobstore and dav blobstores the
+// agent has credentials for.
+func canFallBackToBlobstoreClient(blobstoreSettings boshsettings.Blobstore) bool {
+	switch blobstoreSettings.Type {
+	case boshblob.BlobstoreTypeLocal:
+		return true
+	case "dav":
+		user, _ := blobstoreSettings.Options["user"].(string)
+		return user != ""
+	default:
+		return false
+	}
+}
+
 func (app *app) patchBlobstoreOptions(blobstoreSettings boshsettings.Blobstore) boshsettings.Blobstore {
 	if blobstoreSettings.Type != boshblob.BlobstoreTypeLocal {
 		return blobstoreSettings
@@ -16,6 +16,10 @@ type Request struct {
 	Method          string
 	Payload         []byte
 	ProtocolVersion ProtocolVersion `json:"protocol"`
+
+	// IdempotencyKey identifies retries of a request so that the action
+	// only runs once
+	IdempotencyKey string `json:"idempotency_key"`
 }
 
 func (r Request) GetPayload() []byte {
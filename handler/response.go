@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
@@ -24,7 +27,8 @@ func (r valueResponse) Shorten() Response {
 
 type exceptionResponse struct {
 	Exception struct {
-		Message string `json:"message,omitempty"`
+		Message string     `json:"message,omitempty"`
+		Busy    *BusyError `json:"busy,omitempty"`
 	} `json:"exception"`
 
 	err error
@@ -34,6 +38,12 @@ func NewExceptionResponse(err error) (resp Response) {
 	r := exceptionResponse{}
 	r.Exception.Message = err.Error()
 	r.err = err
+
+	var busyErr BusyError
+	if errors.As(err, &busyErr) {
+		r.Exception.Busy = &busyErr
+	}
+
 	return r
 }
 
@@ -41,9 +51,22 @@ func (r exceptionResponse) Shorten() Response {
 	if typedErr, ok := r.err.(bosherr.ShortenableError); ok {
 		sr := exceptionResponse{}
 		sr.Exception.Message = typedErr.ShortError()
+		sr.Exception.Busy = r.Exception.Busy
 		sr.err = typedErr
 		return sr
 	}
 
 	return r
 }
+
+// BusyError rejects a request that conflicts with a task the agent is
+// already working on so that callers can retry instead of piling up.
+type BusyError struct {
+	TaskID   string `json:"agent_task_id"`
+	Method   string `json:"method"`
+	Position int    `json:"position"`
+}
+
+func (e BusyError) Error() string {
+	return fmt.Sprintf("busy: task %s in progress, position %d", e.TaskID, e.Position)
+}
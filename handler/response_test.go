@@ -93,3 +93,14 @@ var _ = Describe("NewExceptionResponse", func() {
 		})
 	})
 })
+
+var _ = Describe("BusyError", func() {
+	It("is serialized with the blocking task details", func() {
+		resp := NewExceptionResponse(BusyError{TaskID: "fake-task-id", Method: "apply", Position: 2})
+		boshassert.MatchesJSONString(
+			GinkgoT(),
+			resp,
+			`{"exception":{"message":"busy: task fake-task-id in progress, position 2","busy":{"agent_task_id":"fake-task-id","method":"apply","position":2}}}`,
+		)
+	})
+})
@@ -6,4 +6,8 @@ const (
 	Heartbeat = Topic("heartbeat")
 	Alert     = Topic("alert")
 	Shutdown  = Topic("shutdown")
+
+	SignedURLRefresh = Topic("signed_url_refresh")
+	ApplyCompleted   = Topic("apply_completed")
+	TaskProgress     = Topic("task_progress")
 )
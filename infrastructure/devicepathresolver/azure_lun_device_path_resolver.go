@@ -0,0 +1,66 @@
+package devicepathresolver
+
+import (
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+const azureDataDiskLinkDir = "/dev/disk/azure/scsi1/"
+
+// azureLunDevicePathResolver resolves data disks by the per LUN links that the
+// Azure guest agent udev rules create, falling back to scanning SCSI hosts
+// when the rules are not installed.
+type azureLunDevicePathResolver struct {
+	diskWaitTimeout  time.Duration
+	fs               boshsys.FileSystem
+	fallbackResolver DevicePathResolver
+	logTag           string
+	logger           boshlog.Logger
+}
+
+func NewAzureLunDevicePathResolver(
+	diskWaitTimeout time.Duration,
+	fs boshsys.FileSystem,
+	fallbackResolver DevicePathResolver,
+	logger boshlog.Logger,
+) DevicePathResolver {
+	return azureLunDevicePathResolver{
+		diskWaitTimeout:  diskWaitTimeout,
+		fs:               fs,
+		fallbackResolver: fallbackResolver,
+		logTag:           "azureLunDevicePathResolver",
+		logger:           logger,
+	}
+}
+
+func (apr azureLunDevicePathResolver) GetRealDevicePath(diskSettings boshsettings.DiskSettings) (string, bool, error) {
+	if diskSettings.Lun == "" {
+		return "", false, bosherr.Error("Disk lun is not set")
+	}
+
+	linkPath := azureDataDiskLinkDir + "lun" + diskSettings.Lun
+	stopAfter := time.Now().Add(apr.diskWaitTimeout)
+
+	for !apr.fs.FileExists(linkPath) {
+		if time.Now().After(stopAfter) {
+			apr.logger.Debug(apr.logTag, "Link '%s' did not appear, using fallback resolver", linkPath)
+			return apr.fallbackResolver.GetRealDevicePath(diskSettings)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	realPath, err := apr.fs.ReadAndFollowLink(linkPath)
+	if err != nil {
+		return "", false, bosherr.WrapErrorf(err, "Resolving Azure lun link '%s'", linkPath)
+	}
+
+	apr.logger.Debug(apr.logTag, "Resolved lun '%s' as '%s'", diskSettings.Lun, realPath)
+
+	return realPath, false, nil
+}
@@ -0,0 +1,64 @@
+package devicepathresolver_test
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	fakedpresolv "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver"
+)
+
+var _ = Describe("AzureLunDevicePathResolver", func() {
+	var (
+		fs               *fakesys.FakeFileSystem
+		fallbackResolver *fakedpresolv.FakeDevicePathResolver
+		pathResolver     DevicePathResolver
+
+		diskSettings boshsettings.DiskSettings
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		fallbackResolver = fakedpresolv.NewFakeDevicePathResolver()
+		fallbackResolver.RealDevicePath = "/dev/sdd"
+		pathResolver = NewAzureLunDevicePathResolver(100*time.Millisecond, fs, fallbackResolver, boshlog.NewLogger(boshlog.LevelNone))
+
+		diskSettings = boshsettings.DiskSettings{
+			Lun:          "2",
+			HostDeviceID: "fake-host-device-id",
+		}
+	})
+
+	It("returns the device linked for the lun", func() {
+		err := fs.WriteFileString("/dev/sdc", "")
+		Expect(err).NotTo(HaveOccurred())
+		err = fs.Symlink("/dev/sdc", "/dev/disk/azure/scsi1/lun2")
+		Expect(err).NotTo(HaveOccurred())
+
+		realPath, timedOut, err := pathResolver.GetRealDevicePath(diskSettings)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timedOut).To(BeFalse())
+		Expect(realPath).To(Equal("/dev/sdc"))
+	})
+
+	It("uses the fallback resolver when the lun link does not appear", func() {
+		realPath, _, err := pathResolver.GetRealDevicePath(diskSettings)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(realPath).To(Equal("/dev/sdd"))
+		Expect(fallbackResolver.GetRealDevicePathDiskSettings).To(Equal(diskSettings))
+	})
+
+	It("returns an error when the lun is missing", func() {
+		diskSettings.Lun = ""
+
+		_, _, err := pathResolver.GetRealDevicePath(diskSettings)
+		Expect(err).To(MatchError("Disk lun is not set"))
+	})
+})
@@ -0,0 +1,98 @@
+package devicepathresolver
+
+import (
+	"sync"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshopeniscsi "github.com/cloudfoundry/bosh-agent/v2/platform/openiscsi"
+	boshudev "github.com/cloudfoundry/bosh-agent/v2/platform/udevdevice"
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+)
+
+// DeviceResolverDeps are the collaborators available to IaaS specific
+// device resolvers when they are built.
+type DeviceResolverDeps struct {
+	FS          boshsys.FileSystem
+	Runner      boshsys.CmdRunner
+	Udev        boshudev.UdevDevice
+	OpenIscsi   boshopeniscsi.OpenIscsi
+	DirProvider boshdirs.Provider
+	Logger      boshlog.Logger
+
+	DiskIDTransformPattern     string
+	DiskIDTransformReplacement string
+}
+
+// DeviceResolver builds the DevicePathResolver implementing one IaaS disk
+// naming scheme.
+type DeviceResolver func(deps DeviceResolverDeps) DevicePathResolver
+
+var (
+	deviceResolversLock sync.RWMutex
+	deviceResolvers     = map[string]DeviceResolver{
+		"virtio": newVirtioDeviceResolver,
+		"scsi":   newSCSIDeviceResolver,
+		"iscsi":  newIscsiDeviceResolver,
+		"nvme":   newNVMeDeviceResolver,
+		"azure":  newAzureDeviceResolver,
+	}
+)
+
+// RegisterDeviceResolver makes a disk naming scheme selectable through the
+// device_path_resolution_type platform option.
+func RegisterDeviceResolver(resolutionType string, resolver DeviceResolver) {
+	deviceResolversLock.Lock()
+	defer deviceResolversLock.Unlock()
+
+	deviceResolvers[resolutionType] = resolver
+}
+
+// NewDevicePathResolver returns the resolver registered for resolutionType,
+// falling back to using disk paths as given for unknown types.
+func NewDevicePathResolver(resolutionType string, deps DeviceResolverDeps) DevicePathResolver {
+	deviceResolversLock.RLock()
+	resolver, found := deviceResolvers[resolutionType]
+	deviceResolversLock.RUnlock()
+
+	if !found {
+		return NewIdentityDevicePathResolver()
+	}
+
+	return resolver(deps)
+}
+
+// OpenStack and other KVM based IaaSes expose virtio disks by ID
+func newVirtioDeviceResolver(deps DeviceResolverDeps) DevicePathResolver {
+	idDevicePathResolver := NewIDDevicePathResolver(500*time.Millisecond, deps.Udev, deps.FS, deps.DiskIDTransformPattern, deps.DiskIDTransformReplacement, deps.Logger)
+	mappedDevicePathResolver := NewMappedDevicePathResolver(30000*time.Millisecond, deps.FS)
+	return NewVirtioDevicePathResolver(idDevicePathResolver, mappedDevicePathResolver, deps.Logger)
+}
+
+// vSphere attaches disks by SCSI ID or host/LUN
+func newSCSIDeviceResolver(deps DeviceResolverDeps) DevicePathResolver {
+	scsiIDPathResolver := NewSCSIIDDevicePathResolver(50000*time.Millisecond, deps.FS, deps.Logger)
+	scsiVolumeIDPathResolver := NewSCSIVolumeIDDevicePathResolver(500*time.Millisecond, deps.FS)
+	scsiLunPathResolver := NewSCSILunDevicePathResolver(50000*time.Millisecond, deps.FS, deps.Logger)
+	return NewScsiDevicePathResolver(scsiVolumeIDPathResolver, scsiIDPathResolver, scsiLunPathResolver)
+}
+
+func newIscsiDeviceResolver(deps DeviceResolverDeps) DevicePathResolver {
+	identityPathResolver := NewIdentityDevicePathResolver()
+	iscsiPathResolver := NewIscsiDevicePathResolver(50000*time.Millisecond, deps.Runner, deps.OpenIscsi, deps.FS, deps.DirProvider, deps.Logger)
+	return NewMultipathDevicePathResolver(identityPathResolver, iscsiPathResolver, deps.Logger)
+}
+
+// AWS Nitro instances expose EBS volumes as NVMe devices named after the volume ID
+func newNVMeDeviceResolver(deps DeviceResolverDeps) DevicePathResolver {
+	mappedDevicePathResolver := NewMappedDevicePathResolver(30000*time.Millisecond, deps.FS)
+	return NewNVMeDevicePathResolver(5000*time.Millisecond, deps.FS, mappedDevicePathResolver, deps.Logger)
+}
+
+// Azure attaches data disks by LUN, linked by the guest agent udev rules
+func newAzureDeviceResolver(deps DeviceResolverDeps) DevicePathResolver {
+	scsiLunPathResolver := NewSCSILunDevicePathResolver(50000*time.Millisecond, deps.FS, deps.Logger)
+	return NewAzureLunDevicePathResolver(5000*time.Millisecond, deps.FS, scsiLunPathResolver, deps.Logger)
+}
@@ -0,0 +1,50 @@
+package devicepathresolver_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	fakedpresolv "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver"
+)
+
+var _ = Describe("NewDevicePathResolver", func() {
+	var deps DeviceResolverDeps
+
+	BeforeEach(func() {
+		deps = DeviceResolverDeps{
+			FS:     fakesys.NewFakeFileSystem(),
+			Runner: fakesys.NewFakeCmdRunner(),
+			Logger: boshlog.NewLogger(boshlog.LevelNone),
+		}
+	})
+
+	DescribeTable("builds the resolver for each IaaS disk naming scheme",
+		func(resolutionType string) {
+			Expect(NewDevicePathResolver(resolutionType, deps)).NotTo(Equal(NewIdentityDevicePathResolver()))
+		},
+		Entry("virtio", "virtio"),
+		Entry("scsi", "scsi"),
+		Entry("iscsi", "iscsi"),
+		Entry("nvme", "nvme"),
+		Entry("azure", "azure"),
+	)
+
+	It("uses disk paths as given for unknown resolution types", func() {
+		Expect(NewDevicePathResolver("", deps)).To(Equal(NewIdentityDevicePathResolver()))
+		Expect(NewDevicePathResolver("fake-type", deps)).To(Equal(NewIdentityDevicePathResolver()))
+	})
+
+	It("builds registered resolvers", func() {
+		fakeResolver := fakedpresolv.NewFakeDevicePathResolver()
+		RegisterDeviceResolver("fake-iaas", func(_ DeviceResolverDeps) DevicePathResolver {
+			return fakeResolver
+		})
+
+		Expect(NewDevicePathResolver("fake-iaas", deps)).To(BeIdenticalTo(fakeResolver))
+	})
+})
@@ -0,0 +1,77 @@
+package devicepathresolver
+
+import (
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+const nvmeEBSLinkPrefix = "/dev/disk/by-id/nvme-Amazon_Elastic_Block_Store_"
+
+// nvmeDevicePathResolver finds EBS volumes attached to Nitro instances by the
+// udev link carrying their volume ID, falling back to the requested path.
+type nvmeDevicePathResolver struct {
+	diskWaitTimeout  time.Duration
+	fs               boshsys.FileSystem
+	fallbackResolver DevicePathResolver
+	logTag           string
+	logger           boshlog.Logger
+}
+
+func NewNVMeDevicePathResolver(
+	diskWaitTimeout time.Duration,
+	fs boshsys.FileSystem,
+	fallbackResolver DevicePathResolver,
+	logger boshlog.Logger,
+) DevicePathResolver {
+	return nvmeDevicePathResolver{
+		diskWaitTimeout:  diskWaitTimeout,
+		fs:               fs,
+		fallbackResolver: fallbackResolver,
+		logTag:           "nvmeDevicePathResolver",
+		logger:           logger,
+	}
+}
+
+func (npr nvmeDevicePathResolver) GetRealDevicePath(diskSettings boshsettings.DiskSettings) (string, bool, error) {
+	volumeID := diskSettings.VolumeID
+	if volumeID == "" {
+		volumeID = diskSettings.ID
+	}
+
+	if !strings.HasPrefix(volumeID, "vol-") {
+		npr.logger.Debug(npr.logTag, "Disk %+v has no EBS volume ID, using fallback resolver", diskSettings)
+		return npr.fallbackResolver.GetRealDevicePath(diskSettings)
+	}
+
+	// udev drops the dash from the volume ID in the serial based link name
+	linkPath := nvmeEBSLinkPrefix + strings.Replace(volumeID, "-", "", 1)
+	stopAfter := time.Now().Add(npr.diskWaitTimeout)
+
+	for !npr.fs.FileExists(linkPath) {
+		if time.Now().After(stopAfter) {
+			if diskSettings.Path == "" {
+				return "", true, bosherr.Errorf("Timed out getting real device path for EBS volume '%s'", volumeID)
+			}
+
+			npr.logger.Debug(npr.logTag, "Link '%s' did not appear, using fallback resolver", linkPath)
+			return npr.fallbackResolver.GetRealDevicePath(diskSettings)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	realPath, err := npr.fs.ReadAndFollowLink(linkPath)
+	if err != nil {
+		return "", false, bosherr.WrapErrorf(err, "Resolving EBS volume link '%s'", linkPath)
+	}
+
+	npr.logger.Debug(npr.logTag, "Resolved EBS volume '%s' as '%s'", volumeID, realPath)
+
+	return realPath, false, nil
+}
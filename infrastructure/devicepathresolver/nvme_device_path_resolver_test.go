@@ -0,0 +1,91 @@
+package devicepathresolver_test
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	fakedpresolv "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver"
+)
+
+var _ = Describe("NVMeDevicePathResolver", func() {
+	var (
+		fs               *fakesys.FakeFileSystem
+		fallbackResolver *fakedpresolv.FakeDevicePathResolver
+		pathResolver     DevicePathResolver
+
+		diskSettings boshsettings.DiskSettings
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		fallbackResolver = fakedpresolv.NewFakeDevicePathResolver()
+		fallbackResolver.RealDevicePath = "/dev/xvdf"
+		pathResolver = NewNVMeDevicePathResolver(100*time.Millisecond, fs, fallbackResolver, boshlog.NewLogger(boshlog.LevelNone))
+
+		diskSettings = boshsettings.DiskSettings{
+			ID:   "vol-0123456789abcdef0",
+			Path: "/dev/sdf",
+		}
+	})
+
+	Context("when the EBS volume link exists", func() {
+		BeforeEach(func() {
+			err := fs.WriteFileString("/dev/nvme1n1", "")
+			Expect(err).NotTo(HaveOccurred())
+			err = fs.Symlink("/dev/nvme1n1", "/dev/disk/by-id/nvme-Amazon_Elastic_Block_Store_vol0123456789abcdef0")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns the linked nvme device", func() {
+			realPath, timedOut, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(timedOut).To(BeFalse())
+			Expect(realPath).To(Equal("/dev/nvme1n1"))
+		})
+
+		It("prefers the volume id over the disk id", func() {
+			diskSettings.ID = "fake-disk-id"
+			diskSettings.VolumeID = "vol-0123456789abcdef0"
+
+			realPath, _, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(realPath).To(Equal("/dev/nvme1n1"))
+		})
+	})
+
+	Context("when the disk has no EBS volume id", func() {
+		It("uses the fallback resolver", func() {
+			diskSettings.ID = "fake-disk-id"
+
+			realPath, _, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(realPath).To(Equal("/dev/xvdf"))
+			Expect(fallbackResolver.GetRealDevicePathDiskSettings).To(Equal(diskSettings))
+		})
+	})
+
+	Context("when the EBS volume link does not appear", func() {
+		It("uses the fallback resolver", func() {
+			realPath, _, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(realPath).To(Equal("/dev/xvdf"))
+		})
+
+		It("times out when there is no path to fall back to", func() {
+			diskSettings.Path = ""
+
+			_, timedOut, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Timed out getting real device path for EBS volume 'vol-0123456789abcdef0'"))
+			Expect(timedOut).To(BeTrue())
+		})
+	})
+})
@@ -29,10 +29,26 @@ func (s *dummyJobSupervisor) StopAndWait() error {
 	return nil
 }
 
+func (s *dummyJobSupervisor) StartJobs(jobNames []string) error {
+	return nil
+}
+
+func (s *dummyJobSupervisor) StopJobs(jobNames []string) error {
+	return nil
+}
+
 func (s *dummyJobSupervisor) Unmonitor() error {
 	return nil
 }
 
+func (s *dummyJobSupervisor) RestartProcess(name string) error {
+	return nil
+}
+
+func (s *dummyJobSupervisor) RestartProcessAndWait(name string) error {
+	return nil
+}
+
 func (s *dummyJobSupervisor) Status() (status string) {
 	return s.status
 }
@@ -41,6 +57,20 @@ func (s *dummyJobSupervisor) Processes() ([]Process, error) {
 	return s.processes, nil
 }
 
+func (s *dummyJobSupervisor) ProcessStatuses() ([]ProcessStatus, error) {
+	return monitoredProcessStatuses(s.processes), nil
+}
+
+// monitoredProcessStatuses reports processes of dummy job supervisors as if
+// they were monitored and never failed
+func monitoredProcessStatuses(processes []Process) []ProcessStatus {
+	statuses := make([]ProcessStatus, len(processes))
+	for i, process := range processes {
+		statuses[i] = ProcessStatus{Process: process, Monitored: true}
+	}
+	return statuses
+}
+
 func (s *dummyJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
 	return nil
 }
@@ -96,10 +96,32 @@ func (d *dummyNatsJobSupervisor) StopAndWait() error {
 	return d.Stop()
 }
 
+func (d *dummyNatsJobSupervisor) StartJobs(jobNames []string) error {
+	if d.status == "fail_task" {
+		return bosherror.Error("fake-task-fail-error")
+	}
+	return nil
+}
+
+func (d *dummyNatsJobSupervisor) StopJobs(jobNames []string) error {
+	return nil
+}
+
 func (d *dummyNatsJobSupervisor) Unmonitor() error {
 	return nil
 }
 
+func (d *dummyNatsJobSupervisor) RestartProcess(name string) error {
+	if d.status == "fail_task" {
+		return bosherror.Error("fake-task-fail-error")
+	}
+	return nil
+}
+
+func (d *dummyNatsJobSupervisor) RestartProcessAndWait(name string) error {
+	return d.RestartProcess(name)
+}
+
 func (d *dummyNatsJobSupervisor) RemoveAllJobs() error {
 	return nil
 }
@@ -112,6 +134,10 @@ func (d *dummyNatsJobSupervisor) Processes() ([]Process, error) {
 	return d.processes, nil
 }
 
+func (d *dummyNatsJobSupervisor) ProcessStatuses() ([]ProcessStatus, error) {
+	return monitoredProcessStatuses(d.processes), nil
+}
+
 func (d *dummyNatsJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
 	d.jobFailureHandler = handler
 
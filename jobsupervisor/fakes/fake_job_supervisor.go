@@ -23,13 +23,26 @@ type FakeJobSupervisor struct {
 	StopErr          error
 	StoppedAndWaited bool
 
+	StartedJobs  []string
+	StartJobsErr error
+
+	StoppedJobs []string
+	StopJobsErr error
+
 	Unmonitored  bool
 	UnmonitorErr error
 
+	RestartedProcesses []string
+	RestartedAndWaited bool
+	RestartProcessErr  error
+
 	StatusStatus    string
 	ProcessesStatus []boshjobsuper.Process
 	ProcessesError  error
 
+	ProcessStatusesStatuses []boshjobsuper.ProcessStatus
+	ProcessStatusesError    error
+
 	JobFailureAlert *boshalert.MonitAlert
 
 	HealthRecorded      int
@@ -86,11 +99,32 @@ func (m *FakeJobSupervisor) StopAndWait() error {
 	return m.StopErr
 }
 
+func (m *FakeJobSupervisor) StartJobs(jobNames []string) error {
+	m.StartedJobs = append(m.StartedJobs, jobNames...)
+	return m.StartJobsErr
+}
+
+func (m *FakeJobSupervisor) StopJobs(jobNames []string) error {
+	m.StoppedJobs = append(m.StoppedJobs, jobNames...)
+	return m.StopJobsErr
+}
+
 func (m *FakeJobSupervisor) Unmonitor() error {
 	m.Unmonitored = true
 	return m.UnmonitorErr
 }
 
+func (m *FakeJobSupervisor) RestartProcess(name string) error {
+	m.RestartedProcesses = append(m.RestartedProcesses, name)
+	return m.RestartProcessErr
+}
+
+func (m *FakeJobSupervisor) RestartProcessAndWait(name string) error {
+	m.RestartedProcesses = append(m.RestartedProcesses, name)
+	m.RestartedAndWaited = true
+	return m.RestartProcessErr
+}
+
 func (m *FakeJobSupervisor) Status() string {
 	return m.StatusStatus
 }
@@ -99,6 +133,10 @@ func (m *FakeJobSupervisor) Processes() ([]boshjobsuper.Process, error) {
 	return m.ProcessesStatus, m.ProcessesError
 }
 
+func (m *FakeJobSupervisor) ProcessStatuses() ([]boshjobsuper.ProcessStatus, error) {
+	return m.ProcessStatusesStatuses, m.ProcessStatusesError
+}
+
 func (m *FakeJobSupervisor) MonitorJobFailures(handler boshjobsuper.JobFailureHandler) error {
 	if m.JobFailureAlert != nil {
 		return handler(*m.JobFailureAlert)
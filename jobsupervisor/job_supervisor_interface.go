@@ -7,9 +7,30 @@ import (
 type Process struct {
 	Name   string       `json:"name"`
 	State  string       `json:"state"`
+	PID    int          `json:"pid,omitempty"`
 	Uptime UptimeVitals `json:"uptime,omitempty"`
 	Memory MemoryVitals `json:"mem,omitempty"`
 	CPU    CPUVitals    `json:"cpu,omitempty"`
+
+	// Resources are only gathered for the full state of the agent
+	Resources *ResourceVitals `json:"resources,omitempty"`
+}
+
+// ProcessStatus is everything the job supervisor knows about a process while
+// Process only holds what is reported in the state of the agent.
+type ProcessStatus struct {
+	Process
+
+	Monitored     bool   `json:"monitored"`
+	PendingAction string `json:"pending_action,omitempty"`
+	StatusMessage string `json:"status_message,omitempty"`
+	Children      int    `json:"children,omitempty"`
+
+	// Restarts counts the restarts after the process failed since the agent
+	// started. LastExitStatus is only known when the supervisor reports how
+	// processes exited.
+	Restarts       int  `json:"restarts"`
+	LastExitStatus *int `json:"last_exit_status,omitempty"`
 }
 
 type UptimeVitals struct {
@@ -25,6 +46,14 @@ type CPUVitals struct {
 	Total float64 `json:"total"`
 }
 
+type ResourceVitals struct {
+	CPUSecs    float64 `json:"cpu_secs"`
+	RSSKb      uint64  `json:"rss_kb"`
+	OpenFDs    int     `json:"open_fds"`
+	Threads    int     `json:"threads"`
+	UptimeSecs uint64  `json:"uptime_secs"`
+}
+
 type JobFailureHandler func(boshalert.MonitAlert) error
 
 type JobSupervisor interface {
@@ -41,8 +70,20 @@ type JobSupervisor interface {
 	// (Monit complies to above requirements.)
 	Unmonitor() error
 
+	// Actions taken on the services of some jobs. Jobs stopped this way do
+	// not count towards the status until they are started again, either by
+	// name or along with all services.
+	StartJobs(jobNames []string) error
+	StopJobs(jobNames []string) error
+
+	// Actions taken on a single service
+	RestartProcess(name string) error
+	// RestartProcessAndWait also waits for the process to be running again
+	RestartProcessAndWait(name string) error
+
 	Status() string
 	Processes() ([]Process, error)
+	ProcessStatuses() ([]ProcessStatus, error)
 	// Job management
 	AddJob(jobName string, jobIndex int, configPath string) error
 	RemoveAllJobs() error
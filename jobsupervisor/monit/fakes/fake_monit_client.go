@@ -15,6 +15,9 @@ type FakeMonitClient struct {
 	StopServiceNames []string
 	StopServiceErr   error
 
+	RestartServiceNames []string
+	RestartServiceErr   error
+
 	UnmonitorServiceNames []string
 	UnmonitorServiceErrs  []error
 
@@ -45,6 +48,11 @@ func (c *FakeMonitClient) StopService(name string) error {
 	return c.StopServiceErr
 }
 
+func (c *FakeMonitClient) RestartService(name string) error {
+	c.RestartServiceNames = append(c.RestartServiceNames, name)
+	return c.RestartServiceErr
+}
+
 func (c *FakeMonitClient) UnmonitorService(name string) error {
 	c.UnmonitorServiceNames = append(c.UnmonitorServiceNames, name)
 	return c.UnmonitorServiceErrs[len(c.UnmonitorServiceNames)-1]
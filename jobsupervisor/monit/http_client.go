@@ -99,6 +99,21 @@ func (c httpClient) StopService(serviceName string) error {
 	return nil
 }
 
+func (c httpClient) RestartService(serviceName string) error {
+	response, err := c.makeRequest(c.stopClient, c.monitURL(serviceName), "POST", "action=restart")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Sending restart request for service '%s'", serviceName)
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	err = c.validateResponse(response)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Restarting Monit service '%s'", serviceName)
+	}
+
+	return nil
+}
+
 func (c httpClient) UnmonitorService(serviceName string) error {
 	response, err := c.makeRequest(c.unmonitorClient, c.monitURL(serviceName), "POST", "action=unmonitor")
 	if err != nil {
@@ -120,6 +120,50 @@ var _ = Describe("httpClient", func() {
 		})
 	})
 
+	Describe("RestartService", func() {
+		It("restart service", func() {
+			var calledMonit bool
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calledMonit = true
+				Expect(r.Method).To(Equal("POST"))
+				Expect(r.URL.Path).To(Equal("/test-service"))
+				Expect(r.PostFormValue("action")).To(Equal("restart"))
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/x-www-form-urlencoded"))
+
+				expectedAuthEncoded := base64.URLEncoding.EncodeToString([]byte("fake-user:fake-pass"))
+				Expect(r.Header.Get("Authorization")).To(Equal(fmt.Sprintf("Basic %s", expectedAuthEncoded)))
+			})
+			ts := httptest.NewServer(handler)
+			defer ts.Close()
+
+			client := newRealClient(ts.Listener.Addr().String())
+
+			err := client.RestartService("test-service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calledMonit).To(BeTrue())
+		})
+
+		It("uses the longClient to send a restart request", func() {
+			client := newFakeClient(shortClient, longClient)
+
+			longClient.DoReturns(&http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader(nil))}, nil)
+
+			err := client.RestartService("test-service")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(shortClient.DoCallCount()).To(Equal(0))
+			Expect(longClient.DoCallCount()).To(Equal(1))
+
+			req := longClient.DoArgsForCall(0)
+			Expect(req.URL.Path).To(Equal("/test-service"))
+
+			content, err := io.ReadAll(req.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("action=restart"))
+		})
+	})
+
 	Describe("UnmonitorService", func() {
 		It("issues a call to unmonitor service by name", func() {
 			var calledMonit bool
@@ -27,6 +27,7 @@ type serviceTag struct {
 	Status        int       `xml:"status"`
 	StatusMessage string    `xml:"status_message"`
 	Monitor       int       `xml:"monitor"`
+	PID           int       `xml:"pid"`
 	Uptime        int       `xml:"uptime"`
 	Children      int       `xml:"children"`
 	Memory        memoryTag `xml:"memory"`
@@ -72,6 +73,16 @@ func (s serviceTag) StatusString() string {
 	}
 }
 
+// pendingActionNames are indexed by the action codes monit reports
+var pendingActionNames = []string{"", "alert", "restart", "stop", "exec", "unmonitor", "start", "monitor"}
+
+func (s serviceTag) PendingActionString() string {
+	if s.Pending < 0 || s.Pending >= len(pendingActionNames) {
+		return "unknown"
+	}
+	return pendingActionNames[s.Pending]
+}
+
 func (t serviceGroupsTag) Get(name string) (group serviceGroupTag, found bool) {
 	for _, g := range t.ServiceGroups {
 		if g.Name == name {
@@ -105,11 +116,14 @@ func (status status) ServicesInGroup(name string) (services []Service) {
 			service := Service{
 				Name:                 serviceTag.Name,
 				Pending:              serviceTag.Pending > 0,
+				PendingAction:        serviceTag.PendingActionString(),
 				Status:               serviceTag.StatusString(),
 				Errored:              serviceTag.Status > 0 && serviceTag.StatusMessage != "",
 				StatusMessage:        serviceTag.StatusMessage,
 				Monitored:            serviceTag.Monitor > 0,
+				PID:                  serviceTag.PID,
 				Uptime:               serviceTag.Uptime,
+				Children:             serviceTag.Children,
 				MemoryPercentTotal:   serviceTag.Memory.PercentTotal,
 				MemoryKilobytesTotal: serviceTag.Memory.KilobyteTotal,
 				CPUPercentTotal:      serviceTag.CPU.PercentTotal,
@@ -17,9 +17,12 @@ type Service struct {
 	Monitored            bool
 	Errored              bool
 	Pending              bool
+	PendingAction        string
 	Status               string
 	StatusMessage        string
+	PID                  int
 	Uptime               int
+	Children             int
 	MemoryPercentTotal   float64
 	MemoryKilobytesTotal int
 	CPUPercentTotal      float64
@@ -45,11 +45,11 @@ var _ = Describe("status", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			expectedServices := []Service{
-				Service{Monitored: false, Name: "unmonitored-start-pending", Status: "unknown", Pending: true},
+				Service{Monitored: false, Name: "unmonitored-start-pending", Status: "unknown", Pending: true, PendingAction: "start"},
 				Service{Monitored: true, Name: "initializing", Status: "starting", Pending: false},
 				Service{Monitored: true, Name: "running", Status: "running", Pending: false},
-				Service{Monitored: true, Name: "running-stop-pending", Status: "running", Pending: true},
-				Service{Monitored: false, Name: "unmonitored-stop-pending", Status: "unknown", Pending: true},
+				Service{Monitored: true, Name: "running-stop-pending", Status: "running", Pending: true, PendingAction: "stop"},
+				Service{Monitored: false, Name: "unmonitored-stop-pending", Status: "unknown", Pending: true, PendingAction: "stop"},
 				Service{Monitored: false, Name: "unmonitored", Status: "unknown", Pending: false},
 				Service{Monitored: false, Name: "stopped", Status: "unknown", Pending: false},
 				Service{Monitored: true, Name: "failing", Status: "failing", Pending: false},
@@ -99,7 +99,9 @@ var _ = Describe("status", func() {
 					Pending:              false,
 					Status:               "running",
 					StatusMessage:        "",
+					PID:                  1,
 					Uptime:               880183,
+					Children:             163,
 					MemoryPercentTotal:   0,
 					MemoryKilobytesTotal: 4004,
 					CPUPercentTotal:      0,
@@ -1,8 +1,11 @@
 package jobsupervisor
 
 import (
+	"encoding/json"
 	"fmt"
 	"path"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -22,6 +25,8 @@ import (
 
 const monitJobSupervisorLogTag = "monitJobSupervisor"
 
+var monitCheckProcessRegexp = regexp.MustCompile(`(?m)^\s*check\s+process\s+(\S+)`)
+
 type monitJobSupervisor struct {
 	fs                    boshsys.FileSystem
 	runner                boshsys.CmdRunner
@@ -32,6 +37,7 @@ type monitJobSupervisor struct {
 	reloadOptions         MonitReloadOptions
 	timeService           clock.Clock
 	serviceManager        servicemanager.ServiceManager
+	failures              *processFailures
 }
 
 type MonitReloadOptions struct {
@@ -67,6 +73,7 @@ func NewMonitJobSupervisor(
 		reloadOptions:         reloadOptions,
 		timeService:           timeService,
 		serviceManager:        serviceManager,
+		failures:              newProcessFailures(),
 	}
 }
 
@@ -156,6 +163,72 @@ func (m monitJobSupervisor) Start() error {
 		return bosherr.WrapError(err, "Removing stopped File")
 	}
 
+	err = m.fs.RemoveAll(m.stoppedJobsFilePath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing stopped jobs file")
+	}
+
+	return nil
+}
+
+func (m monitJobSupervisor) StartJobs(jobNames []string) error {
+	jobServices, err := m.jobServices(jobNames)
+	if err != nil {
+		return err
+	}
+
+	stoppedJobs, err := m.stoppedJobs()
+	if err != nil {
+		return err
+	}
+
+	for _, jobName := range jobNames {
+		for _, service := range jobServices[jobName] {
+			m.logger.Debug(monitJobSupervisorLogTag, "Starting service %s of job %s", service, jobName)
+			err = m.client.StartService(service)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Starting service %s", service)
+			}
+		}
+
+		delete(stoppedJobs, jobName)
+	}
+
+	return m.saveStoppedJobs(stoppedJobs)
+}
+
+func (m monitJobSupervisor) StopJobs(jobNames []string) error {
+	jobServices, err := m.jobServices(jobNames)
+	if err != nil {
+		return err
+	}
+
+	stoppedJobs, err := m.stoppedJobs()
+	if err != nil {
+		return err
+	}
+
+	// Jobs are recorded as stopped before their services are, so that the
+	// status does not report them as failing while they stop
+	for _, jobName := range jobNames {
+		stoppedJobs[jobName] = jobServices[jobName]
+	}
+
+	err = m.saveStoppedJobs(stoppedJobs)
+	if err != nil {
+		return err
+	}
+
+	for _, jobName := range jobNames {
+		for _, service := range jobServices[jobName] {
+			m.logger.Debug(monitJobSupervisorLogTag, "Stopping service %s of job %s", service, jobName)
+			err = m.client.StopService(service)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Stopping service %s", service)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -273,6 +346,68 @@ func (m monitJobSupervisor) Unmonitor() error {
 	return nil
 }
 
+func (m monitJobSupervisor) RestartProcess(name string) error {
+	services, err := m.client.ServicesInGroup("vcap")
+	if err != nil {
+		return bosherr.WrapError(err, "Getting vcap services")
+	}
+
+	found := false
+	for _, service := range services {
+		if service == name {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return bosherr.Errorf("Process '%s' is not monitored", name)
+	}
+
+	m.logger.Debug(monitJobSupervisorLogTag, "Restarting service %s", name)
+	err = m.client.RestartService(name)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Restarting service %s", name)
+	}
+
+	return nil
+}
+
+func (m monitJobSupervisor) RestartProcessAndWait(name string) error {
+	timer := m.timeService.NewTimer(5 * time.Minute)
+
+	err := m.RestartProcess(name)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Debug(monitJobSupervisorLogTag, "Waiting for service %s to be running", name)
+	for {
+		services, err := m.checkServices()
+		if err != nil {
+			return err
+		}
+
+		// Monit keeps the restart pending until it is done with it
+		restartedServices := m.filterServices(services, func(service boshmonit.Service) bool {
+			return service.Name == name && !service.Pending && service.Status == boshmonit.StatusRunning
+		})
+
+		if len(restartedServices) > 0 {
+			m.logger.Debug(monitJobSupervisorLogTag, "Successfully restarted service %s", name)
+			return nil
+		}
+
+		select {
+		case <-timer.C():
+			return bosherr.Errorf("Timed out waiting for process '%s' to be running after 5 minutes", name)
+		default:
+		}
+
+		m.timeService.Sleep(500 * time.Millisecond)
+	}
+}
+
 func (m monitJobSupervisor) Status() (status string) {
 	status = "running"
 
@@ -286,8 +421,24 @@ func (m monitJobSupervisor) Status() (status string) {
 	if m.fs.FileExists(m.stoppedFilePath()) {
 		status = "stopped"
 	} else {
+		stoppedServices := map[string]bool{}
+
+		stoppedJobs, err := m.stoppedJobs()
+		if err != nil {
+			m.logger.Error(monitJobSupervisorLogTag, "Failed to read stopped jobs: %s", err.Error())
+		}
+
+		for _, services := range stoppedJobs {
+			for _, service := range services {
+				stoppedServices[service] = true
+			}
+		}
+
 		services := monitStatus.ServicesInGroup("vcap")
 		for _, service := range services {
+			if stoppedServices[service.Name] {
+				continue
+			}
 			if service.Status == "starting" {
 				return "starting"
 			}
@@ -309,26 +460,53 @@ func (m monitJobSupervisor) Processes() (processes []Process, err error) {
 	}
 
 	for _, service := range monitStatus.ServicesInGroup("vcap") {
-		process := Process{
-			Name:  service.Name,
-			State: service.Status,
-			Uptime: UptimeVitals{
-				Secs: service.Uptime,
-			},
-			Memory: MemoryVitals{
-				Kb:      service.MemoryKilobytesTotal,
-				Percent: service.MemoryPercentTotal,
-			},
-			CPU: CPUVitals{
-				Total: service.CPUPercentTotal,
-			},
-		}
-		processes = append(processes, process)
+		processes = append(processes, newMonitProcess(service))
 	}
 
 	return
 }
 
+func (m monitJobSupervisor) ProcessStatuses() ([]ProcessStatus, error) {
+	statuses := []ProcessStatus{}
+
+	monitStatus, err := m.client.Status()
+	if err != nil {
+		return statuses, bosherr.WrapError(err, "Getting service status")
+	}
+
+	for _, service := range monitStatus.ServicesInGroup("vcap") {
+		status := ProcessStatus{
+			Process:       newMonitProcess(service),
+			Monitored:     service.Monitored,
+			PendingAction: service.PendingAction,
+			StatusMessage: service.StatusMessage,
+			Children:      service.Children,
+		}
+		m.failures.Fill(&status)
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func newMonitProcess(service boshmonit.Service) Process {
+	return Process{
+		Name:  service.Name,
+		State: service.Status,
+		PID:   service.PID,
+		Uptime: UptimeVitals{
+			Secs: service.Uptime,
+		},
+		Memory: MemoryVitals{
+			Kb:      service.MemoryKilobytesTotal,
+			Percent: service.MemoryPercentTotal,
+		},
+		CPU: CPUVitals{
+			Total: service.CPUPercentTotal,
+		},
+	}
+}
+
 func (m monitJobSupervisor) getIncarnation() (int, error) {
 	monitStatus, err := m.client.Status()
 	if err != nil {
@@ -360,10 +538,18 @@ func (m monitJobSupervisor) RemoveAllJobs() error {
 }
 
 func (m monitJobSupervisor) MonitorJobFailures(handler JobFailureHandler) (err error) {
+	// Monit alerts with the restart action once it restarts a failed process
+	countingHandler := func(alert boshalert.MonitAlert) error {
+		if alert.Action == "restart" {
+			m.failures.RecordRestart(alert.Service)
+		}
+		return handler(alert)
+	}
+
 	alertHandler := func(smtpd.Connection, smtpd.MailAddress) (env smtpd.Envelope, err error) {
 		env = &alertEnvelope{
 			new(smtpd.BasicEnvelope),
-			handler,
+			countingHandler,
 			new(boshalert.MonitAlert),
 		}
 		return
@@ -385,6 +571,97 @@ func (m monitJobSupervisor) stoppedFilePath() string {
 	return path.Join(m.dirProvider.MonitDir(), "stopped")
 }
 
+func (m monitJobSupervisor) stoppedJobsFilePath() string {
+	return path.Join(m.dirProvider.MonitDir(), "stopped_jobs.json")
+}
+
+// stoppedJobs returns the services of the jobs stopped by name
+func (m monitJobSupervisor) stoppedJobs() (map[string][]string, error) {
+	stoppedJobs := map[string][]string{}
+
+	if !m.fs.FileExists(m.stoppedJobsFilePath()) {
+		return stoppedJobs, nil
+	}
+
+	contents, err := m.fs.ReadFile(m.stoppedJobsFilePath())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading stopped jobs file")
+	}
+
+	err = json.Unmarshal(contents, &stoppedJobs)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling stopped jobs")
+	}
+
+	return stoppedJobs, nil
+}
+
+func (m monitJobSupervisor) saveStoppedJobs(stoppedJobs map[string][]string) error {
+	if len(stoppedJobs) == 0 {
+		err := m.fs.RemoveAll(m.stoppedJobsFilePath())
+		if err != nil {
+			return bosherr.WrapError(err, "Removing stopped jobs file")
+		}
+		return nil
+	}
+
+	contents, err := json.Marshal(stoppedJobs)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling stopped jobs")
+	}
+
+	err = m.fs.WriteFile(m.stoppedJobsFilePath(), contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing stopped jobs file")
+	}
+
+	return nil
+}
+
+// jobServices returns the services of the given jobs as found in the monit
+// configs added for them
+func (m monitJobSupervisor) jobServices(jobNames []string) (map[string][]string, error) {
+	configPaths, err := m.fs.Glob(path.Join(m.dirProvider.MonitJobsDir(), "*.monitrc"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing job monit configs")
+	}
+
+	wanted := map[string]bool{}
+	for _, jobName := range jobNames {
+		wanted[jobName] = true
+	}
+
+	jobServices := map[string][]string{}
+
+	for _, configPath := range configPaths {
+		// Configs are named <index>_<job name>.monitrc by AddJob
+		_, jobName, found := strings.Cut(strings.TrimSuffix(filepath.Base(configPath), ".monitrc"), "_")
+		if !found || !wanted[jobName] {
+			continue
+		}
+
+		config, err := m.fs.ReadFileString(configPath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading monit config of job %s", jobName)
+		}
+
+		services := []string{}
+		for _, match := range monitCheckProcessRegexp.FindAllStringSubmatch(config, -1) {
+			services = append(services, match[1])
+		}
+
+		jobServices[jobName] = services
+	}
+
+	for _, jobName := range jobNames {
+		if _, found := jobServices[jobName]; !found {
+			return nil, bosherr.Errorf("Job '%s' is not monitored", jobName)
+		}
+	}
+
+	return jobServices, nil
+}
+
 func (m monitJobSupervisor) filterServices(services []boshmonit.Service, fn func(boshmonit.Service) bool) []string {
 	matchingServices := []string{}
 	for _, service := range services {
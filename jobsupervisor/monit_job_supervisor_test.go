@@ -246,6 +246,106 @@ var _ = Describe("monitJobSupervisor", func() {
 		})
 	})
 
+	Describe("selected jobs", func() {
+		BeforeEach(func() {
+			fs.SetGlob("/var/vcap/monit/job/*.monitrc", []string{
+				"/var/vcap/monit/job/0000_fake-job.monitrc",
+				"/var/vcap/monit/job/0001_other_job.monitrc",
+			})
+
+			err := fs.WriteFileString("/var/vcap/monit/job/0000_fake-job.monitrc", `check process fake-service-1
+  with pidfile /var/vcap/sys/run/fake-job/fake-service-1.pid
+  group vcap
+
+check process fake-service-2
+  group vcap
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.WriteFileString("/var/vcap/monit/job/0001_other_job.monitrc", "check process other-service\n  group vcap\n")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Describe("StopJobs", func() {
+			It("stops the services of the jobs", func() {
+				err := monit.StopJobs([]string{"fake-job"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(client.StopServiceNames).To(Equal([]string{"fake-service-1", "fake-service-2"}))
+				Expect(fs.FileExists("/var/vcap/monit/stopped")).To(BeFalse())
+			})
+
+			It("returns an error for jobs without monit config", func() {
+				err := monit.StopJobs([]string{"fake-job", "unknown-job"})
+				Expect(err).To(MatchError("Job 'unknown-job' is not monitored"))
+				Expect(client.StopServiceNames).To(BeEmpty())
+			})
+
+			It("returns an error when stopping a service fails", func() {
+				client.StopServiceErr = errors.New("fake-stop-error")
+
+				err := monit.StopJobs([]string{"fake-job"})
+				Expect(err).To(MatchError("Stopping service fake-service-1: fake-stop-error"))
+			})
+		})
+
+		Describe("StartJobs", func() {
+			It("starts the services of the jobs", func() {
+				err := monit.StartJobs([]string{"other_job"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(client.StartServiceNames).To(Equal([]string{"other-service"}))
+			})
+
+			It("returns an error when starting a service fails", func() {
+				client.StartServiceErr = errors.New("fake-start-error")
+
+				err := monit.StartJobs([]string{"other_job"})
+				Expect(err).To(MatchError("Starting service other-service: fake-start-error"))
+			})
+		})
+
+		Describe("Status", func() {
+			BeforeEach(func() {
+				client.StatusStatus = fakemonit.FakeMonitStatus{
+					Services: []boshmonit.Service{
+						{Name: "fake-service-1", Monitored: false, Status: "unknown"},
+						{Name: "fake-service-2", Monitored: false, Status: "unknown"},
+						{Name: "other-service", Monitored: true, Status: "running"},
+					},
+				}
+			})
+
+			It("does not report jobs stopped by name as failing", func() {
+				err := monit.StopJobs([]string{"fake-job"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(monit.Status()).To(Equal("running"))
+			})
+
+			It("reports jobs started again by name", func() {
+				err := monit.StopJobs([]string{"fake-job"})
+				Expect(err).ToNot(HaveOccurred())
+
+				err = monit.StartJobs([]string{"fake-job"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(monit.Status()).To(Equal("failing"))
+				Expect(fs.FileExists("/var/vcap/monit/stopped_jobs.json")).To(BeFalse())
+			})
+
+			It("reports jobs again once all services are started", func() {
+				err := monit.StopJobs([]string{"fake-job"})
+				Expect(err).ToNot(HaveOccurred())
+
+				err = monit.Start()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(monit.Status()).To(Equal("failing"))
+			})
+		})
+	})
+
 	Describe("StopAndWait", func() {
 		It("stop stops each monit service in group vcap", func() {
 			err := monit.StopAndWait()
@@ -665,6 +765,7 @@ var _ = Describe("monitJobSupervisor", func() {
 						Name:                 "fake-service-1",
 						Monitored:            true,
 						Status:               "running",
+						PID:                  4321,
 						Uptime:               1234,
 						MemoryPercentTotal:   0.4,
 						MemoryKilobytesTotal: 100,
@@ -688,6 +789,7 @@ var _ = Describe("monitJobSupervisor", func() {
 				Process{
 					Name:  "fake-service-1",
 					State: "running",
+					PID:   4321,
 					Uptime: UptimeVitals{
 						Secs: 1234,
 					},
@@ -725,6 +827,95 @@ var _ = Describe("monitJobSupervisor", func() {
 		})
 	})
 
+	Describe("ProcessStatuses", func() {
+		BeforeEach(func() {
+			client.StatusStatus = fakemonit.FakeMonitStatus{
+				Services: []boshmonit.Service{
+					{
+						Name:                 "fake-service-1",
+						Monitored:            true,
+						Status:               "running",
+						PID:                  4321,
+						Uptime:               1234,
+						Children:             2,
+						MemoryPercentTotal:   0.4,
+						MemoryKilobytesTotal: 100,
+						CPUPercentTotal:      0.5,
+					},
+					{
+						Name:          "fake-service-2",
+						Monitored:     false,
+						Status:        "unknown",
+						Pending:       true,
+						PendingAction: "start",
+						StatusMessage: "fake-status-message",
+					},
+				},
+			}
+		})
+
+		It("returns the complete status of all processes", func() {
+			statuses, err := monit.ProcessStatuses()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses).To(Equal([]ProcessStatus{
+				{
+					Process: Process{
+						Name:   "fake-service-1",
+						State:  "running",
+						PID:    4321,
+						Uptime: UptimeVitals{Secs: 1234},
+						Memory: MemoryVitals{Kb: 100, Percent: 0.4},
+						CPU:    CPUVitals{Total: 0.5},
+					},
+					Monitored: true,
+					Children:  2,
+				},
+				{
+					Process: Process{
+						Name:  "fake-service-2",
+						State: "unknown",
+					},
+					PendingAction: "start",
+					StatusMessage: "fake-status-message",
+				},
+			}))
+		})
+
+		It("counts the restarts monit alerted about", func() {
+			go func() {
+				defer GinkgoRecover()
+
+				err := monit.MonitorJobFailures(func(boshalert.MonitAlert) error { return nil })
+				Expect(err).NotTo(HaveOccurred())
+			}()
+
+			for _, action := range []string{"restart", "alert", "restart"} {
+				msg := fmt.Sprintf(`Message-id: <1304319946.0@localhost>
+ Service: fake-service-1
+ Event: does not exist
+ Action: %s
+ Date: Sun, 22 May 2011 20:07:41 +0500
+ Description: process is not running`, action)
+
+				Expect(doJobFailureEmail(msg, jobFailuresServerPort)).To(Succeed())
+			}
+
+			statuses, err := monit.ProcessStatuses()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses[0].Restarts).To(Equal(2))
+			Expect(statuses[0].LastExitStatus).To(BeNil())
+			Expect(statuses[1].Restarts).To(Equal(0))
+		})
+
+		It("returns error when getting the status fails", func() {
+			client.StatusErr = errors.New("fake-status-err")
+
+			_, err := monit.ProcessStatuses()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-status-err"))
+		})
+	})
+
 	Describe("MonitorJobFailures", func() {
 		It("monitor job failures", func() {
 			var handledAlert boshalert.MonitAlert
@@ -852,6 +1043,87 @@ var _ = Describe("monitJobSupervisor", func() {
 		})
 	})
 
+	Describe("RestartProcess", func() {
+		BeforeEach(func() {
+			client.ServicesInGroupServices = []string{"fake-srv-1", "fake-srv-2"}
+		})
+
+		It("restarts the monit service of the process", func() {
+			err := monit.RestartProcess("fake-srv-2")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.ServicesInGroupName).To(Equal("vcap"))
+			Expect(client.RestartServiceNames).To(Equal([]string{"fake-srv-2"}))
+		})
+
+		It("returns an error for processes that are not monitored", func() {
+			err := monit.RestartProcess("fake-srv-3")
+			Expect(err).To(MatchError("Process 'fake-srv-3' is not monitored"))
+			Expect(client.RestartServiceNames).To(BeEmpty())
+		})
+
+		It("returns an error when restarting the service fails", func() {
+			client.RestartServiceErr = errors.New("fake-restart-err")
+
+			err := monit.RestartProcess("fake-srv-1")
+			Expect(err).To(MatchError("Restarting service fake-srv-1: fake-restart-err"))
+		})
+	})
+
+	Describe("RestartProcessAndWait", func() {
+		BeforeEach(func() {
+			client.ServicesInGroupServices = []string{"fake-srv-1"}
+		})
+
+		It("waits for the restart to no longer be pending and the process to be running", func() {
+			client.StatusStatus = fakemonit.FakeMonitStatus{
+				Services: []boshmonit.Service{
+					{Monitored: true, Name: "fake-srv-1", Status: "running", Pending: true},
+				},
+			}
+
+			errchan := make(chan error)
+			go func() {
+				errchan <- monit.RestartProcessAndWait("fake-srv-1")
+			}()
+
+			Eventually(timeService.WatcherCount).Should(Equal(2)) // we hit the sleep
+			Expect(client.RestartServiceNames).To(Equal([]string{"fake-srv-1"}))
+
+			client.StatusStatus = fakemonit.FakeMonitStatus{
+				Services: []boshmonit.Service{
+					{Monitored: true, Name: "fake-srv-1", Status: "running", Pending: false},
+				},
+			}
+			timeService.Increment(time.Second)
+
+			Eventually(errchan).Should(Receive(BeNil()))
+		})
+
+		It("times out if the process takes too long to be running", func() {
+			client.StatusStatus = fakemonit.FakeMonitStatus{
+				Services: []boshmonit.Service{
+					{Monitored: true, Name: "fake-srv-1", Status: "failing", Pending: false},
+				},
+			}
+
+			errchan := make(chan error)
+			go func() {
+				errchan <- monit.RestartProcessAndWait("fake-srv-1")
+			}()
+
+			advanceTime(timeService, 10*time.Minute, 2)
+			Eventually(errchan).Should(Receive(Equal(errors.New("Timed out waiting for process 'fake-srv-1' to be running after 5 minutes"))))
+		})
+
+		It("does not wait when restarting the service fails", func() {
+			client.RestartServiceErr = errors.New("fake-restart-err")
+
+			err := monit.RestartProcessAndWait("fake-srv-1")
+			Expect(err).To(MatchError("Restarting service fake-srv-1: fake-restart-err"))
+		})
+	})
+
 	Describe("Unmonitor", func() {
 		BeforeEach(func() {
 			client.ServicesInGroupServices = []string{"fake-srv-1", "fake-srv-2", "fake-srv-3"}
@@ -0,0 +1,46 @@
+package jobsupervisor
+
+import (
+	"sync"
+)
+
+// processFailures keeps track of the process failures reported to the job
+// failure handler so they can be included in the process statuses.
+type processFailures struct {
+	lock         sync.Mutex
+	restarts     map[string]int
+	exitStatuses map[string]int
+}
+
+func newProcessFailures() *processFailures {
+	return &processFailures{
+		restarts:     map[string]int{},
+		exitStatuses: map[string]int{},
+	}
+}
+
+func (f *processFailures) RecordRestart(name string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.restarts[name]++
+}
+
+func (f *processFailures) RecordExit(name string, exitStatus int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.restarts[name]++
+	f.exitStatuses[name] = exitStatus
+}
+
+func (f *processFailures) Fill(status *ProcessStatus) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	status.Restarts = f.restarts[status.Name]
+
+	if exitStatus, found := f.exitStatuses[status.Name]; found {
+		status.LastExitStatus = &exitStatus
+	}
+}
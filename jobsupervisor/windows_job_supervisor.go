@@ -178,6 +178,8 @@ type windowsJobSupervisor struct {
 
 	state supervisorState
 	mgr   *winsvc.Mgr
+
+	failures *processFailures
 }
 
 func (w *windowsJobSupervisor) stateSet(s supervisorState) {
@@ -211,6 +213,7 @@ func NewWindowsJobSupervisor(
 		msgCh:                 make(chan *windowsServiceEvent, 8),
 		jobFailuresServerPort: jobFailuresServerPort,
 		cancelServer:          cancelChan,
+		failures:              newProcessFailures(),
 	}
 
 	s.stateSet(stateEnabled)
@@ -255,11 +258,31 @@ func (w *windowsJobSupervisor) StopAndWait() error {
 	return w.Stop()
 }
 
+func (w *windowsJobSupervisor) StartJobs(jobNames []string) error {
+	return bosherr.Error("Starting single jobs is not supported on Windows")
+}
+
+func (w *windowsJobSupervisor) StopJobs(jobNames []string) error {
+	return bosherr.Error("Stopping single jobs is not supported on Windows")
+}
+
 func (w *windowsJobSupervisor) Unmonitor() error {
 	w.stateSet(stateDisabled)
 	return w.mgr.Unmonitor()
 }
 
+func (w *windowsJobSupervisor) RestartProcess(name string) error {
+	if err := w.mgr.Restart(name); err != nil {
+		return bosherr.WrapErrorf(err, "Restarting windows job process %s", name)
+	}
+	return nil
+}
+
+func (w *windowsJobSupervisor) RestartProcessAndWait(name string) error {
+	// Restart already waits for the service to be running
+	return w.RestartProcess(name)
+}
+
 func (w *windowsJobSupervisor) Status() (status string) {
 	if w.fs.FileExists(w.stoppedFilePath()) {
 		return "stopped"
@@ -318,6 +341,20 @@ func (w *windowsJobSupervisor) Processes() ([]Process, error) {
 	return procs, nil
 }
 
+func (w *windowsJobSupervisor) ProcessStatuses() ([]ProcessStatus, error) {
+	processes, err := w.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]ProcessStatus, len(processes))
+	for i, process := range processes {
+		statuses[i] = ProcessStatus{Process: process, Monitored: !w.stateIs(stateDisabled)}
+		w.failures.Fill(&statuses[i])
+	}
+	return statuses, nil
+}
+
 func (w *windowsJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
 	configFileContents, err := w.fs.ReadFile(configPath)
 	if err != nil {
@@ -436,6 +473,8 @@ func (w *windowsJobSupervisor) handleJobFailure(hn JobFailureHandler, wr http.Re
 		w.logger.Error(w.logTag, "MonitorJobFailures: received unknown request: %s", err)
 		return
 	}
+	// The service restarts the process after it exited
+	w.failures.RecordExit(event.ProcessName, event.ExitCode)
 	alert := boshalert.MonitAlert{
 		Action:      "Start",
 		Date:        time.Now().Format(time.RFC1123Z),
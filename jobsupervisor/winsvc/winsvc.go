@@ -428,6 +428,26 @@ func (m *Mgr) Stop() (first error) {
 	return m.iter(Stop)
 }
 
+// Restart stops and starts the service named name monitored by Mgr m.
+func (m *Mgr) Restart(name string) error {
+	svcs, err := m.services()
+	if err != nil {
+		return err
+	}
+	defer closeServices(svcs) //nolint:errcheck
+
+	for _, s := range svcs {
+		if s.Name != name {
+			continue
+		}
+		if err := Stop(s); err != nil {
+			return err
+		}
+		return Start(s)
+	}
+	return &ServiceError{"restarting service", name, errors.New("service is not monitored")}
+}
+
 func (m *Mgr) doDelete(s *mgr.Service) error {
 	const Timeout = time.Second * 60
 
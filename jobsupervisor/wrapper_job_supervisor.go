@@ -46,6 +46,18 @@ func (w *wrapperJobSupervisor) Stop() error {
 func (w *wrapperJobSupervisor) StopAndWait() error {
 	return w.delegate.StopAndWait()
 }
+func (w *wrapperJobSupervisor) StartJobs(jobNames []string) error {
+	err := w.delegate.StartJobs(jobNames)
+	w.HealthRecorder(w.delegate.Status())
+
+	return err
+}
+func (w *wrapperJobSupervisor) StopJobs(jobNames []string) error {
+	err := w.delegate.StopJobs(jobNames)
+	w.HealthRecorder(w.delegate.Status())
+
+	return err
+}
 func (w *wrapperJobSupervisor) Unmonitor() error {
 	err := w.delegate.Unmonitor()
 	if err != nil {
@@ -55,12 +67,28 @@ func (w *wrapperJobSupervisor) Unmonitor() error {
 	w.HealthRecorder(w.delegate.Status())
 	return err
 }
+func (w *wrapperJobSupervisor) RestartProcess(name string) error {
+	err := w.delegate.RestartProcess(name)
+	w.HealthRecorder(w.delegate.Status())
+
+	return err
+}
+func (w *wrapperJobSupervisor) RestartProcessAndWait(name string) error {
+	err := w.delegate.RestartProcessAndWait(name)
+	w.HealthRecorder(w.delegate.Status())
+
+	return err
+}
 func (w *wrapperJobSupervisor) Status() string {
 	return w.delegate.Status()
 }
 func (w *wrapperJobSupervisor) Processes() ([]Process, error) {
 	return w.delegate.Processes()
 }
+
+func (w *wrapperJobSupervisor) ProcessStatuses() ([]ProcessStatus, error) {
+	return w.delegate.ProcessStatuses()
+}
 func (w *wrapperJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
 	return w.delegate.AddJob(jobName, jobIndex, configPath)
 }
@@ -91,6 +91,53 @@ var _ = Describe("WrapperJobSupervisor", func() {
 		Expect(err).To(Equal(boomError))
 	})
 
+	It("StartJobs should delegate to the underlying job supervisor", func() {
+		boomError := errors.New("BOOM")
+		fakeSupervisor.StartJobsErr = boomError
+		err := wrapper.StartJobs([]string{"fake-job"})
+		Expect(fakeSupervisor.StartedJobs).To(Equal([]string{"fake-job"}))
+		Expect(err).To(Equal(boomError))
+	})
+
+	It("StopJobs should delegate to the underlying job supervisor", func() {
+		boomError := errors.New("BOOM")
+		fakeSupervisor.StopJobsErr = boomError
+		err := wrapper.StopJobs([]string{"fake-job"})
+		Expect(fakeSupervisor.StoppedJobs).To(Equal([]string{"fake-job"}))
+		Expect(err).To(Equal(boomError))
+	})
+
+	Describe("RestartProcess", func() {
+		It("should delegate to the underlying job supervisor", func() {
+			boomError := errors.New("BOOM")
+			fakeSupervisor.RestartProcessErr = boomError
+			err := wrapper.RestartProcess("fake-process")
+			Expect(fakeSupervisor.RestartedProcesses).To(Equal([]string{"fake-process"}))
+			Expect(err).To(Equal(boomError))
+		})
+
+		It("write the health json", func() {
+			fakeSupervisor.StatusStatus = "failing"
+			err := wrapper.RestartProcess("fake-process")
+			Expect(err).NotTo(HaveOccurred())
+
+			healthRaw, err := fs.ReadFile(filepath.Join(dirProvider.InstanceDir(), "health.json"))
+			Expect(err).ToNot(HaveOccurred())
+			health := &Health{}
+			err = json.Unmarshal(healthRaw, health)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(health.State).To(Equal("failing"))
+		})
+	})
+
+	It("RestartProcessAndWait should delegate to the underlying job supervisor", func() {
+		boomError := errors.New("BOOM")
+		fakeSupervisor.RestartProcessErr = boomError
+		err := wrapper.RestartProcessAndWait("fake-process")
+		Expect(fakeSupervisor.RestartedAndWaited).To(BeTrue())
+		Expect(err).To(Equal(boomError))
+	})
+
 	Describe("Unmointor", func() {
 		It("Unmonitor should delegate to the underlying job supervisor", func() {
 			boomError := errors.New("BOOM")
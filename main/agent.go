@@ -10,9 +10,11 @@ import (
 	"github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/crashreport"
 	boshapp "github.com/cloudfoundry/bosh-agent/v2/app"
 	"github.com/cloudfoundry/bosh-agent/v2/infrastructure/agentlogger"
 	"github.com/cloudfoundry/bosh-agent/v2/platform"
+	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
 
 const mainLogTag = "main"
@@ -61,6 +63,8 @@ func startAgent(logger logger.Logger) error {
 		os.Exit(0)
 	}
 
+	logger = newCrashReportingLogger(opts, logger)
+
 	sigCh := make(chan os.Signal, 8)
 	// `os.Kill` can not be intercepted on UNIX OS's, possibly necessary for Windows?
 	signal.Notify(sigCh, syscall.SIGTERM, os.Interrupt, os.Kill) //nolint:staticcheck
@@ -97,6 +101,12 @@ func main() {
 	os.Exit(exitCode)
 }
 
+func newCrashReportingLogger(opts boshapp.Options, logger logger.Logger) logger.Logger {
+	dirProvider := boshdirs.NewProvider(opts.BaseDirectory)
+	store := crashreport.NewStore(boshsys.NewOsFileSystem(logger), dirProvider.CrashReportsDir(), crashreport.DefaultMaxReports)
+	return crashreport.NewLogger(logger, store, VersionLabel)
+}
+
 func newSignalableLogger(logger logger.Logger) logger.Logger {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGSEGV)
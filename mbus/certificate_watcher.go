@@ -0,0 +1,85 @@
+package mbus
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+const certificateWatcherLogTag = "certificateWatcher"
+
+// DefaultCertificateWatchInterval is how often the watcher checks the
+// settings for new mbus certificates
+const DefaultCertificateWatchInterval = 10 * time.Second
+
+//counterfeiter:generate . CertificateReloader
+
+// CertificateReloader is implemented by handlers that take new mbus
+// certificates without restarting the agent
+type CertificateReloader interface {
+	ReloadCertificates(certs boshsettings.CertKeyPair) error
+}
+
+// CertificateWatcher reloads the certificates of the handler when the mbus
+// certificates of the settings change, e.g. once update_settings saved new
+// ones or refresh_settings loaded them, so rotating the CA does not require
+// restarting the agent.
+type CertificateWatcher struct {
+	settingsService boshsettings.Service
+	reloader        CertificateReloader
+	timeService     clock.Clock
+	interval        time.Duration
+	logger          boshlog.Logger
+}
+
+func NewCertificateWatcher(
+	settingsService boshsettings.Service,
+	reloader CertificateReloader,
+	timeService clock.Clock,
+	interval time.Duration,
+	logger boshlog.Logger,
+) *CertificateWatcher {
+	return &CertificateWatcher{
+		settingsService: settingsService,
+		reloader:        reloader,
+		timeService:     timeService,
+		interval:        interval,
+		logger:          logger,
+	}
+}
+
+// Run checks the settings every interval until stopCh is closed. Failed
+// reloads are retried on the next check, the handler keeps the previous
+// certificates until then.
+func (w *CertificateWatcher) Run(stopCh <-chan struct{}) {
+	appliedCerts := w.settingsService.GetSettings().GetMbusCerts()
+
+	ticker := w.timeService.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+
+		case <-ticker.C():
+			certs := w.settingsService.GetSettings().GetMbusCerts()
+			if certs == appliedCerts {
+				continue
+			}
+
+			w.logger.Info(certificateWatcherLogTag, "Reloading changed mbus certificates")
+
+			err := w.reloader.ReloadCertificates(certs)
+			if err != nil {
+				w.logger.Error(certificateWatcherLogTag, "Reloading mbus certificates: %s", err.Error())
+				continue
+			}
+
+			appliedCerts = certs
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package mbus_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"github.com/cloudfoundry/bosh-agent/v2/mbus"
+	"github.com/cloudfoundry/bosh-agent/v2/mbus/mbusfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/v2/settings/fakes"
+)
+
+// lockedSettingsService lets tests change the settings while the watcher
+// reads them
+type lockedSettingsService struct {
+	*fakesettings.FakeSettingsService
+	lock sync.Mutex
+}
+
+func (s *lockedSettingsService) GetSettings() boshsettings.Settings {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.FakeSettingsService.GetSettings()
+}
+
+func (s *lockedSettingsService) SetUpdatedMbusCerts(certs boshsettings.CertKeyPair) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.Settings.UpdateSettings.Mbus.Cert = certs
+}
+
+var _ = Describe("CertificateWatcher", func() {
+	var (
+		settingsService *lockedSettingsService
+		reloader        *mbusfakes.FakeCertificateReloader
+		timeService     *fakeclock.FakeClock
+		stopCh          chan struct{}
+		doneCh          chan struct{}
+		newCerts        boshsettings.CertKeyPair
+	)
+
+	BeforeEach(func() {
+		settingsService = &lockedSettingsService{FakeSettingsService: &fakesettings.FakeSettingsService{}}
+		settingsService.Settings.Env.Bosh.Mbus.Cert = boshsettings.CertKeyPair{CA: "fake-ca", Certificate: "fake-cert", PrivateKey: "fake-key"}
+		newCerts = boshsettings.CertKeyPair{CA: "new-ca", Certificate: "new-cert", PrivateKey: "new-key"}
+
+		reloader = &mbusfakes.FakeCertificateReloader{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+
+		watcher := mbus.NewCertificateWatcher(settingsService, reloader, timeService, 10*time.Second, boshlog.NewLogger(boshlog.LevelNone))
+
+		stopCh = make(chan struct{})
+		doneCh = make(chan struct{})
+		go func() {
+			watcher.Run(stopCh)
+			close(doneCh)
+		}()
+	})
+
+	AfterEach(func() {
+		close(stopCh)
+		Eventually(doneCh).Should(BeClosed())
+	})
+
+	It("does not reload certificates that did not change", func() {
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+
+		Consistently(reloader.ReloadCertificatesCallCount).Should(Equal(0))
+	})
+
+	// The ticker of the fake clock is armed again asynchronously, ticks
+	// until then are lost
+	tickUntilReloaded := func(callCount int) {
+		Eventually(func() int {
+			timeService.Increment(10 * time.Second)
+			return reloader.ReloadCertificatesCallCount()
+		}).Should(Equal(callCount))
+	}
+
+	It("reloads the certificates once the settings have new ones", func() {
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		settingsService.SetUpdatedMbusCerts(newCerts)
+
+		tickUntilReloaded(1)
+		Expect(reloader.ReloadCertificatesArgsForCall(0)).To(Equal(newCerts))
+
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		Consistently(reloader.ReloadCertificatesCallCount).Should(Equal(1))
+	})
+
+	It("retries reloading certificates that failed to reload", func() {
+		reloader.ReloadCertificatesReturnsOnCall(0, errors.New("fake-reload-err"))
+
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		settingsService.SetUpdatedMbusCerts(newCerts)
+
+		tickUntilReloaded(2)
+		Expect(reloader.ReloadCertificatesArgsForCall(1)).To(Equal(newCerts))
+	})
+})
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sync"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -27,6 +28,11 @@ type HTTPSDispatcher struct {
 	logger                      boshlog.Logger
 	baseURL                     *url.URL
 	expectedAuthorizationHeader string
+
+	// certificate is served to new connections, it is replaced when
+	// certificates are reloaded
+	certificate     *tls.Certificate
+	certificateLock sync.RWMutex
 }
 
 type HTTPHandlerFunc func(writer http.ResponseWriter, request *http.Request)
@@ -63,22 +69,43 @@ func (h *HTTPSDispatcher) Start() error {
 	}
 	h.listener = tcpListener
 
-	var cert tls.Certificate
-	cert, err = tls.X509KeyPair([]byte(h.keyPair.Certificate), []byte(h.keyPair.PrivateKey))
+	err = h.ReloadCertificates(h.keyPair)
 	if err != nil {
-		return bosherr.WrapError(err, "Loading configured tls certificate")
+		return err
 	}
 
 	// update the server config with the cert
 	config := h.httpServer.TLSConfig
 	config.NextProtos = []string{"http/1.1"}
-	config.Certificates = []tls.Certificate{cert}
+	config.GetCertificate = h.getCertificate
 
 	tlsListener := tls.NewListener(tcpListener, config)
 
 	return h.httpServer.Serve(tlsListener)
 }
 
+// ReloadCertificates serves the certificate of keyPair to connections
+// established from now on, established connections are kept.
+func (h *HTTPSDispatcher) ReloadCertificates(keyPair settings.CertKeyPair) error {
+	cert, err := tls.X509KeyPair([]byte(keyPair.Certificate), []byte(keyPair.PrivateKey))
+	if err != nil {
+		return bosherr.WrapError(err, "Loading configured tls certificate")
+	}
+
+	h.certificateLock.Lock()
+	h.certificate = &cert
+	h.certificateLock.Unlock()
+
+	return nil
+}
+
+func (h *HTTPSDispatcher) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	h.certificateLock.RLock()
+	defer h.certificateLock.RUnlock()
+
+	return h.certificate, nil
+}
+
 func (h *HTTPSDispatcher) Stop() {
 	if h.listener != nil {
 		_ = h.listener.Close() //nolint:errcheck
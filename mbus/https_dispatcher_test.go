@@ -2,10 +2,12 @@ package mbus_test
 
 import (
 	"crypto/tls"
+	"encoding/pem"
 	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -264,6 +266,43 @@ var _ = Describe("HTTPSDispatcher", func() {
 		Expect(tag).To(Equal("HTTPS Dispatcher"))
 	})
 
+	Describe("ReloadCertificates", func() {
+		peerCertificate := func() []byte {
+			conn, err := tls.Dial("tcp", "127.0.0.1:7789", &tls.Config{InsecureSkipVerify: true}) //nolint:gosec
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close() //nolint:errcheck
+
+			return conn.ConnectionState().PeerCertificates[0].Raw
+		}
+
+		It("serves the new certificate to new connections", func() {
+			newCertificate, err := os.ReadFile("./test_assets/custom_cert.pem")
+			Expect(err).ToNot(HaveOccurred())
+			newPrivateKey, err := os.ReadFile("./test_assets/custom_key.pem")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = dispatcher.ReloadCertificates(settings.CertKeyPair{
+				Certificate: string(newCertificate),
+				PrivateKey:  string(newPrivateKey),
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			block, _ := pem.Decode(newCertificate)
+			Expect(peerCertificate()).To(Equal(block.Bytes))
+		})
+
+		It("keeps serving the previous certificate when the new one is invalid", func() {
+			err := dispatcher.ReloadCertificates(settings.CertKeyPair{
+				Certificate: "Invalid Certificate",
+				PrivateKey:  agentKey,
+			})
+			Expect(err).To(MatchError(ContainSubstring("Loading configured tls certificate")))
+
+			block, _ := pem.Decode([]byte(agentCert))
+			Expect(peerCertificate()).To(Equal(block.Bytes))
+		})
+	})
+
 	Context("When the basic authorization is wrong", func() {
 		It("returns 401", func() {
 			dispatcher.AddRoute("/example", func(w http.ResponseWriter, r *http.Request) {
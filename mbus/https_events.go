@@ -0,0 +1,79 @@
+package mbus
+
+import (
+	"encoding/json"
+	"sync"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/v2/handler"
+)
+
+// HTTPSEvent is a message the agent sent while the https handler is used,
+// e.g. a heartbeat or the progress of a task. IDs increase by one with every
+// event so that clients can ask for the events they have not seen yet.
+type HTTPSEvent struct {
+	ID      uint64             `json:"id"`
+	Target  boshhandler.Target `json:"target"`
+	Topic   boshhandler.Topic  `json:"topic"`
+	Message json.RawMessage    `json:"message"`
+}
+
+// httpsEventBuffer keeps the latest events so that clients polling
+// GET /events do not miss events sent between two requests.
+type httpsEventBuffer struct {
+	size int
+
+	lock   sync.Mutex
+	events []HTTPSEvent
+	lastID uint64
+
+	// added is closed when the next event is added, it is only created
+	// once a client waits for events
+	added chan struct{}
+}
+
+func newHTTPSEventBuffer(size int) *httpsEventBuffer {
+	return &httpsEventBuffer{size: size}
+}
+
+func (b *httpsEventBuffer) Add(target boshhandler.Target, topic boshhandler.Topic, message json.RawMessage) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.lastID++
+	b.events = append(b.events, HTTPSEvent{
+		ID:      b.lastID,
+		Target:  target,
+		Topic:   topic,
+		Message: message,
+	})
+
+	if len(b.events) > b.size {
+		b.events = b.events[len(b.events)-b.size:]
+	}
+
+	if b.added != nil {
+		close(b.added)
+		b.added = nil
+	}
+}
+
+// Since returns the buffered events with an ID greater than id and the ID of
+// the last event. When there are none the returned channel is closed once
+// the next event is added.
+func (b *httpsEventBuffer) Since(id uint64) ([]HTTPSEvent, uint64, <-chan struct{}) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	events := []HTTPSEvent{}
+	for _, event := range b.events {
+		if event.ID > id {
+			events = append(events, event)
+		}
+	}
+
+	if b.added == nil {
+		b.added = make(chan struct{})
+	}
+
+	return events, b.lastID, b.added
+}
@@ -2,10 +2,13 @@ package mbus
 
 import (
 	"bufio"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"time"
 
 	"github.com/cloudfoundry/bosh-agent/v2/platform"
 	"github.com/cloudfoundry/bosh-agent/v2/settings"
@@ -19,12 +22,24 @@ import (
 
 const httpsHandlerLogTag = "https_handler"
 
+const (
+	// DefaultEventsPollTimeout is how long GET /events waits for new events
+	// when the request does not specify a timeout
+	DefaultEventsPollTimeout = 30 * time.Second
+
+	// MaxEventsPollTimeout bounds the timeout requested by GET /events
+	MaxEventsPollTimeout = 60 * time.Second
+
+	httpsEventBufferSize = 100
+)
+
 type HTTPSHandler struct {
 	parsedURL   *url.URL
 	blobManager boshagentblobstore.BlobManagerInterface
 	logger      boshlog.Logger
 	dispatcher  *HTTPSDispatcher
 	auditLogger platform.AuditLogger
+	events      *httpsEventBuffer
 }
 
 func NewHTTPSHandler(
@@ -40,6 +55,7 @@ func NewHTTPSHandler(
 		blobManager: blobManager,
 		dispatcher:  NewHTTPSDispatcher(parsedURL, keyPair, logger),
 		auditLogger: auditLogger,
+		events:      newHTTPSEventBuffer(httpsEventBufferSize),
 	}
 }
 
@@ -54,6 +70,7 @@ func (h HTTPSHandler) Run(handlerFunc boshhandler.Func) error {
 func (h HTTPSHandler) Start(handlerFunc boshhandler.Func) error {
 	h.dispatcher.AddRoute("/agent", h.agentHandler(handlerFunc))
 	h.dispatcher.AddRoute("/blobs/", h.blobsHandler())
+	h.dispatcher.AddRoute("/events", h.eventsHandler())
 	return h.dispatcher.Start()
 }
 
@@ -61,11 +78,29 @@ func (h HTTPSHandler) Stop() {
 	h.dispatcher.Stop()
 }
 
+// ReloadCertificates serves the certificate of certs to new connections. The
+// CA is not used since the director authenticates with basic auth.
+func (h HTTPSHandler) ReloadCertificates(certs settings.CertKeyPair) error {
+	return h.dispatcher.ReloadCertificates(certs)
+}
+
 func (h HTTPSHandler) RegisterAdditionalFunc(_handlerFunc boshhandler.Func) {
 	panic("HTTPSHandler does not support registering additional handler funcs")
 }
 
+// Send records the message as an event that clients receive by polling
+// GET /events, since the https handler cannot push messages to the director.
 func (h HTTPSHandler) Send(target boshhandler.Target, topic boshhandler.Topic, message interface{}) error {
+	bytes, err := json.Marshal(message)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling message (target=%s, topic=%s): %#v", target, topic, message)
+	}
+
+	h.logger.Info(httpsHandlerLogTag, "Recording %s message '%s'", target, topic)
+	h.logger.DebugWithDetails(httpsHandlerLogTag, "Message Payload", string(bytes))
+
+	h.events.Add(target, topic, bytes)
+
 	return nil
 }
 
@@ -113,6 +148,95 @@ func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.Func) func(http.Respo
 	}
 }
 
+type httpsEventsResponse struct {
+	Events []HTTPSEvent `json:"events"`
+	LastID uint64       `json:"last_id"`
+}
+
+// eventsHandler returns the events after the ID given by the since query
+// parameter. When there are none yet it waits for the next event, for at
+// most the number of seconds given by the timeout query parameter.
+func (h HTTPSHandler) eventsHandler() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(404)
+			h.generateCEFLog(r, 404, "")
+
+			return
+		}
+
+		since, timeout, err := h.parseEventsQuery(r.URL.Query())
+		if err != nil {
+			h.logger.Error(httpsHandlerLogTag, err.Error())
+			w.WriteHeader(400)
+			h.generateCEFLog(r, 400, "")
+			if _, wErr := w.Write([]byte(err.Error())); wErr != nil {
+				h.logger.Error(httpsHandlerLogTag, "Failed to write response body: %s", wErr.Error())
+			}
+
+			return
+		}
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		events, lastID, added := h.events.Since(since)
+	waiting:
+		for len(events) == 0 {
+			select {
+			case <-added:
+				events, lastID, added = h.events.Since(since)
+			case <-timer.C:
+				break waiting
+			case <-r.Context().Done():
+				break waiting
+			}
+		}
+
+		respBytes, err := json.Marshal(httpsEventsResponse{Events: events, LastID: lastID})
+		if err != nil {
+			err = bosherr.WrapError(err, "Marshalling events")
+			h.logger.Error(httpsHandlerLogTag, err.Error())
+			w.WriteHeader(500)
+			h.generateCEFLog(r, 500, "")
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err = w.Write(respBytes); err != nil {
+			h.logger.Error(httpsHandlerLogTag, "Failed to write response body: %s", err.Error())
+		}
+		h.generateCEFLog(r, 200, "")
+	}
+}
+
+func (h HTTPSHandler) parseEventsQuery(query url.Values) (uint64, time.Duration, error) {
+	var since uint64
+	if rawSince := query.Get("since"); rawSince != "" {
+		var err error
+		since, err = strconv.ParseUint(rawSince, 10, 64)
+		if err != nil {
+			return 0, 0, bosherr.WrapErrorf(err, "Parsing since '%s'", rawSince)
+		}
+	}
+
+	timeout := DefaultEventsPollTimeout
+	if rawTimeout := query.Get("timeout"); rawTimeout != "" {
+		seconds, err := strconv.ParseUint(rawTimeout, 10, 32)
+		if err != nil {
+			return 0, 0, bosherr.WrapErrorf(err, "Parsing timeout '%s'", rawTimeout)
+		}
+
+		timeout = time.Duration(seconds) * time.Second
+		if timeout > MaxEventsPollTimeout {
+			timeout = MaxEventsPollTimeout
+		}
+	}
+
+	return since, timeout, nil
+}
+
 func (h HTTPSHandler) blobsHandler() func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
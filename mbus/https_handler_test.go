@@ -3,6 +3,7 @@ package mbus_test
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
@@ -223,6 +224,125 @@ var _ = Describe("HTTPSHandler", func() {
 			})
 		})
 
+		Describe("GET /events", func() {
+			getEvents := func(query string) (int, map[string]interface{}) {
+				httpResponse, err := httpClient.Get(serverURL + "/events?" + query)
+				Expect(err).ToNot(HaveOccurred())
+				defer httpResponse.Body.Close() //nolint:errcheck
+
+				httpBody, err := io.ReadAll(httpResponse.Body)
+				Expect(err).ToNot(HaveOccurred())
+
+				var body map[string]interface{}
+				if httpResponse.StatusCode == 200 {
+					Expect(json.Unmarshal(httpBody, &body)).To(Succeed())
+				}
+
+				return httpResponse.StatusCode, body
+			}
+
+			It("returns the messages sent by the agent", func() {
+				Expect(handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, map[string]string{"job": "fake-job"})).To(Succeed())
+				Expect(handler.Send(boshhandler.Director, boshhandler.TaskProgress, map[string]string{"agent_task_id": "fake-task-id"})).To(Succeed())
+
+				statusCode, body := getEvents("since=0")
+				Expect(statusCode).To(Equal(200))
+				Expect(body).To(Equal(map[string]interface{}{
+					"events": []interface{}{
+						map[string]interface{}{
+							"id":      float64(1),
+							"target":  "hm",
+							"topic":   "heartbeat",
+							"message": map[string]interface{}{"job": "fake-job"},
+						},
+						map[string]interface{}{
+							"id":      float64(2),
+							"target":  "director",
+							"topic":   "task_progress",
+							"message": map[string]interface{}{"agent_task_id": "fake-task-id"},
+						},
+					},
+					"last_id": float64(2),
+				}))
+			})
+
+			It("only returns the messages after since", func() {
+				Expect(handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, "first")).To(Succeed())
+				Expect(handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, "second")).To(Succeed())
+
+				statusCode, body := getEvents("since=1")
+				Expect(statusCode).To(Equal(200))
+				Expect(body["events"]).To(HaveLen(1))
+				Expect(body["events"].([]interface{})[0].(map[string]interface{})["message"]).To(Equal("second"))
+			})
+
+			It("only keeps the latest messages", func() {
+				for i := 0; i < 101; i++ {
+					Expect(handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, i)).To(Succeed())
+				}
+
+				statusCode, body := getEvents("since=0")
+				Expect(statusCode).To(Equal(200))
+				Expect(body["events"]).To(HaveLen(100))
+				Expect(body["events"].([]interface{})[0].(map[string]interface{})["id"]).To(Equal(float64(2)))
+				Expect(body["last_id"]).To(Equal(float64(101)))
+			})
+
+			It("waits for the next message when there are no new messages", func() {
+				Expect(handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, "first")).To(Succeed())
+
+				type eventsResult struct {
+					statusCode int
+					body       map[string]interface{}
+				}
+				resultCh := make(chan eventsResult, 1)
+				go func() {
+					defer GinkgoRecover()
+					statusCode, body := getEvents("since=1&timeout=5")
+					resultCh <- eventsResult{statusCode: statusCode, body: body}
+				}()
+
+				Consistently(resultCh, 200*time.Millisecond).ShouldNot(Receive())
+
+				Expect(handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, "second")).To(Succeed())
+
+				var result eventsResult
+				Eventually(resultCh, 5*time.Second).Should(Receive(&result))
+				Expect(result.statusCode).To(Equal(200))
+				Expect(result.body["events"]).To(HaveLen(1))
+				Expect(result.body["last_id"]).To(Equal(float64(2)))
+			})
+
+			It("returns no messages when the timeout expires", func() {
+				statusCode, body := getEvents("since=0&timeout=1")
+				Expect(statusCode).To(Equal(200))
+				Expect(body).To(Equal(map[string]interface{}{
+					"events":  []interface{}{},
+					"last_id": float64(0),
+				}))
+			})
+
+			It("returns a 400 when since is not a number", func() {
+				statusCode, _ := getEvents("since=fake-since")
+				Expect(statusCode).To(Equal(400))
+			})
+
+			It("returns a 400 when timeout is not a number of seconds", func() {
+				statusCode, _ := getEvents("timeout=-1")
+				Expect(statusCode).To(Equal(400))
+			})
+
+			Context("when incorrect http method is used", func() {
+				It("returns a 404", func() {
+					httpResponse, err := httpClient.Post(serverURL+"/events", "application/json", strings.NewReader("{}"))
+					Expect(err).ToNot(HaveOccurred())
+					defer httpResponse.Body.Close() //nolint:errcheck
+
+					Expect(httpResponse.StatusCode).To(Equal(404))
+				})
+			})
+		})
+
 		Describe("routing and auth", func() {
 			Context("when an incorrect uri is specified", func() {
 				It("returns a 404", func() {
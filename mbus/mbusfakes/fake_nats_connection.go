@@ -13,6 +13,16 @@ type FakeNatsConnection struct {
 	closeMutex       sync.RWMutex
 	closeArgsForCall []struct {
 	}
+	DrainStub        func() error
+	drainMutex       sync.RWMutex
+	drainArgsForCall []struct {
+	}
+	drainReturns struct {
+		result1 error
+	}
+	drainReturnsOnCall map[int]struct {
+		result1 error
+	}
 	PublishStub        func(string, []byte) error
 	publishMutex       sync.RWMutex
 	publishArgsForCall []struct {
@@ -25,17 +35,18 @@ type FakeNatsConnection struct {
 	publishReturnsOnCall map[int]struct {
 		result1 error
 	}
-	SubscribeStub        func(string, nats.MsgHandler) (*nats.Subscription, error)
-	subscribeMutex       sync.RWMutex
-	subscribeArgsForCall []struct {
+	QueueSubscribeStub        func(string, string, nats.MsgHandler) (*nats.Subscription, error)
+	queueSubscribeMutex       sync.RWMutex
+	queueSubscribeArgsForCall []struct {
 		arg1 string
-		arg2 nats.MsgHandler
+		arg2 string
+		arg3 nats.MsgHandler
 	}
-	subscribeReturns struct {
+	queueSubscribeReturns struct {
 		result1 *nats.Subscription
 		result2 error
 	}
-	subscribeReturnsOnCall map[int]struct {
+	queueSubscribeReturnsOnCall map[int]struct {
 		result1 *nats.Subscription
 		result2 error
 	}
@@ -67,6 +78,59 @@ func (fake *FakeNatsConnection) CloseCalls(stub func()) {
 	fake.CloseStub = stub
 }
 
+func (fake *FakeNatsConnection) Drain() error {
+	fake.drainMutex.Lock()
+	ret, specificReturn := fake.drainReturnsOnCall[len(fake.drainArgsForCall)]
+	fake.drainArgsForCall = append(fake.drainArgsForCall, struct {
+	}{})
+	stub := fake.DrainStub
+	fakeReturns := fake.drainReturns
+	fake.recordInvocation("Drain", []interface{}{})
+	fake.drainMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeNatsConnection) DrainCallCount() int {
+	fake.drainMutex.RLock()
+	defer fake.drainMutex.RUnlock()
+	return len(fake.drainArgsForCall)
+}
+
+func (fake *FakeNatsConnection) DrainCalls(stub func() error) {
+	fake.drainMutex.Lock()
+	defer fake.drainMutex.Unlock()
+	fake.DrainStub = stub
+}
+
+func (fake *FakeNatsConnection) DrainReturns(result1 error) {
+	fake.drainMutex.Lock()
+	defer fake.drainMutex.Unlock()
+	fake.DrainStub = nil
+	fake.drainReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNatsConnection) DrainReturnsOnCall(i int, result1 error) {
+	fake.drainMutex.Lock()
+	defer fake.drainMutex.Unlock()
+	fake.DrainStub = nil
+	if fake.drainReturnsOnCall == nil {
+		fake.drainReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.drainReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeNatsConnection) Publish(arg1 string, arg2 []byte) error {
 	var arg2Copy []byte
 	if arg2 != nil {
@@ -134,19 +198,20 @@ func (fake *FakeNatsConnection) PublishReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeNatsConnection) Subscribe(arg1 string, arg2 nats.MsgHandler) (*nats.Subscription, error) {
-	fake.subscribeMutex.Lock()
-	ret, specificReturn := fake.subscribeReturnsOnCall[len(fake.subscribeArgsForCall)]
-	fake.subscribeArgsForCall = append(fake.subscribeArgsForCall, struct {
+func (fake *FakeNatsConnection) QueueSubscribe(arg1 string, arg2 string, arg3 nats.MsgHandler) (*nats.Subscription, error) {
+	fake.queueSubscribeMutex.Lock()
+	ret, specificReturn := fake.queueSubscribeReturnsOnCall[len(fake.queueSubscribeArgsForCall)]
+	fake.queueSubscribeArgsForCall = append(fake.queueSubscribeArgsForCall, struct {
 		arg1 string
-		arg2 nats.MsgHandler
-	}{arg1, arg2})
-	stub := fake.SubscribeStub
-	fakeReturns := fake.subscribeReturns
-	fake.recordInvocation("Subscribe", []interface{}{arg1, arg2})
-	fake.subscribeMutex.Unlock()
+		arg2 string
+		arg3 nats.MsgHandler
+	}{arg1, arg2, arg3})
+	stub := fake.QueueSubscribeStub
+	fakeReturns := fake.queueSubscribeReturns
+	fake.recordInvocation("QueueSubscribe", []interface{}{arg1, arg2, arg3})
+	fake.queueSubscribeMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -154,46 +219,46 @@ func (fake *FakeNatsConnection) Subscribe(arg1 string, arg2 nats.MsgHandler) (*n
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeNatsConnection) SubscribeCallCount() int {
-	fake.subscribeMutex.RLock()
-	defer fake.subscribeMutex.RUnlock()
-	return len(fake.subscribeArgsForCall)
+func (fake *FakeNatsConnection) QueueSubscribeCallCount() int {
+	fake.queueSubscribeMutex.RLock()
+	defer fake.queueSubscribeMutex.RUnlock()
+	return len(fake.queueSubscribeArgsForCall)
 }
 
-func (fake *FakeNatsConnection) SubscribeCalls(stub func(string, nats.MsgHandler) (*nats.Subscription, error)) {
-	fake.subscribeMutex.Lock()
-	defer fake.subscribeMutex.Unlock()
-	fake.SubscribeStub = stub
+func (fake *FakeNatsConnection) QueueSubscribeCalls(stub func(string, string, nats.MsgHandler) (*nats.Subscription, error)) {
+	fake.queueSubscribeMutex.Lock()
+	defer fake.queueSubscribeMutex.Unlock()
+	fake.QueueSubscribeStub = stub
 }
 
-func (fake *FakeNatsConnection) SubscribeArgsForCall(i int) (string, nats.MsgHandler) {
-	fake.subscribeMutex.RLock()
-	defer fake.subscribeMutex.RUnlock()
-	argsForCall := fake.subscribeArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+func (fake *FakeNatsConnection) QueueSubscribeArgsForCall(i int) (string, string, nats.MsgHandler) {
+	fake.queueSubscribeMutex.RLock()
+	defer fake.queueSubscribeMutex.RUnlock()
+	argsForCall := fake.queueSubscribeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeNatsConnection) SubscribeReturns(result1 *nats.Subscription, result2 error) {
-	fake.subscribeMutex.Lock()
-	defer fake.subscribeMutex.Unlock()
-	fake.SubscribeStub = nil
-	fake.subscribeReturns = struct {
+func (fake *FakeNatsConnection) QueueSubscribeReturns(result1 *nats.Subscription, result2 error) {
+	fake.queueSubscribeMutex.Lock()
+	defer fake.queueSubscribeMutex.Unlock()
+	fake.QueueSubscribeStub = nil
+	fake.queueSubscribeReturns = struct {
 		result1 *nats.Subscription
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeNatsConnection) SubscribeReturnsOnCall(i int, result1 *nats.Subscription, result2 error) {
-	fake.subscribeMutex.Lock()
-	defer fake.subscribeMutex.Unlock()
-	fake.SubscribeStub = nil
-	if fake.subscribeReturnsOnCall == nil {
-		fake.subscribeReturnsOnCall = make(map[int]struct {
+func (fake *FakeNatsConnection) QueueSubscribeReturnsOnCall(i int, result1 *nats.Subscription, result2 error) {
+	fake.queueSubscribeMutex.Lock()
+	defer fake.queueSubscribeMutex.Unlock()
+	fake.QueueSubscribeStub = nil
+	if fake.queueSubscribeReturnsOnCall == nil {
+		fake.queueSubscribeReturnsOnCall = make(map[int]struct {
 			result1 *nats.Subscription
 			result2 error
 		})
 	}
-	fake.subscribeReturnsOnCall[i] = struct {
+	fake.queueSubscribeReturnsOnCall[i] = struct {
 		result1 *nats.Subscription
 		result2 error
 	}{result1, result2}
@@ -202,12 +267,6 @@ func (fake *FakeNatsConnection) SubscribeReturnsOnCall(i int, result1 *nats.Subs
 func (fake *FakeNatsConnection) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.closeMutex.RLock()
-	defer fake.closeMutex.RUnlock()
-	fake.publishMutex.RLock()
-	defer fake.publishMutex.RUnlock()
-	fake.subscribeMutex.RLock()
-	defer fake.subscribeMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"net/url"
 	"os"
@@ -16,6 +17,7 @@ import (
 	"syscall"
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	"github.com/nats-io/nats.go"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -50,19 +52,31 @@ type NatsConnector func(url string, options ...nats.Option) (NatsConnection, err
 
 type NatsConnection interface {
 	Close()
+	Drain() error
 	Publish(subj string, data []byte) error
-	Subscribe(subj string, cb nats.MsgHandler) (*nats.Subscription, error)
+	QueueSubscribe(subj, queue string, cb nats.MsgHandler) (*nats.Subscription, error)
 }
 
 type natsHandler struct {
 	settingsService boshsettings.Service
 	connector       NatsConnector
-	connection      NatsConnection
 	platform        boshplatform.Platform
 
+	// connection is replaced when certificates are reloaded, certs are the
+	// ones it was established with
+	connection     NatsConnection
+	certs          boshsettings.CertKeyPair
+	connectionLock sync.RWMutex
+
 	handlerFuncs     []boshhandler.Func
 	handlerFuncsLock sync.Mutex
 
+	metrics connectionMetrics
+
+	// Encrypted requests are only accepted once and while they are recent
+	timeService clock.Clock
+	replays     *ReplayWindow
+
 	logger      boshlog.Logger
 	auditLogger boshplatform.AuditLogger
 	logTag      string
@@ -87,10 +101,14 @@ func NewNatsHandler(
 	logger boshlog.Logger,
 	platform boshplatform.Platform,
 ) Handler {
+	timeService := clock.NewClock()
+
 	return &natsHandler{
 		settingsService: settingsService,
 		connector:       client,
 		platform:        platform,
+		timeService:     timeService,
+		replays:         NewReplayWindow(DefaultReplayWindow, timeService),
 		logger:          logger,
 		logTag:          natsHandlerLogTag,
 		auditLogger:     platform.GetAuditLogger(),
@@ -123,7 +141,9 @@ func (h *natsHandler) Run(handlerFunc boshhandler.Func) error {
 func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 	h.RegisterAdditionalFunc(handlerFunc)
 
-	connectionInfo, err := h.getConnectionInfo()
+	certs := h.settingsService.GetSettings().GetMbusCerts()
+
+	connectionInfo, err := h.getConnectionInfoWithCerts(certs)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting connection info")
 	}
@@ -131,9 +151,79 @@ func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 	if net.ParseIP(connectionInfo.IP) != nil {
 		h.arpClean()
 	}
-	var natsOptions = []nats.Option{
-		nats.RetryOnFailedConnect(true),
+
+	// just log this error. even if currently cannot connect to nats, we can eventually
+	connection, err := h.connect(connectionInfo, true)
+	if err != nil {
+		return err
+	}
+
+	h.connectionLock.Lock()
+	h.connection = connection
+	h.certs = certs
+	h.connectionLock.Unlock()
+
+	return nil
+}
+
+// ReloadCertificates establishes a new connection with certs and switches
+// over to it once it is subscribed before draining the previous connection.
+// The previous connection is kept when the new one cannot be established.
+//
+// Both connections subscribe in the same queue group, so a request sent
+// during the switchover is delivered to only one of them.
+func (h *natsHandler) ReloadCertificates(certs boshsettings.CertKeyPair) error {
+	h.connectionLock.RLock()
+	previousConnection, previousCerts := h.connection, h.certs
+	h.connectionLock.RUnlock()
+
+	// Start connects with the certificates of the settings
+	if previousConnection == nil || certs == previousCerts {
+		return nil
+	}
+
+	connectionInfo, err := h.getConnectionInfoWithCerts(certs)
+	if err != nil {
+		return bosherr.WrapError(err, "Getting connection info")
+	}
+
+	h.logger.Info(h.logTag, "Reconnecting to NATS with reloaded certificates")
+
+	// Unlike on start the new connection has to succeed right away, the
+	// previous one keeps working in the meantime
+	connection, err := h.connect(connectionInfo, false)
+	if err != nil {
+		return err
+	}
+
+	h.connectionLock.Lock()
+	h.connection = connection
+	h.certs = certs
+	h.connectionLock.Unlock()
+
+	// Requests the previous connection received already are still handled
+	err = previousConnection.Drain()
+	if err != nil {
+		h.logger.Warn(h.logTag, "Draining previous NATS connection: %s", err.Error())
+		previousConnection.Close()
+	}
+
+	h.logger.Info(h.logTag, "Switched over to NATS connection with reloaded certificates")
+
+	return nil
+}
+
+func (h *natsHandler) connect(connectionInfo *ConnectionInfo, retryOnFailedConnect bool) (NatsConnection, error) {
+	reconnectPolicy := NewReconnectPolicy(h.settingsService.GetSettings().Env.Bosh.Agent.Settings.MbusReconnect)
+
+	var natsOptions []nats.Option
+	if retryOnFailedConnect {
+		natsOptions = append(natsOptions, nats.RetryOnFailedConnect(true))
+	}
+
+	natsOptions = append(natsOptions,
 		nats.DisconnectErrHandler(func(c *nats.Conn, err error) {
+			h.metrics.RecordDisconnect()
 			h.logger.Debug(natsHandlerLogTag, "Nats disconnected with Error: %v", err.Error())
 			h.logger.Debug(natsHandlerLogTag, "Attempting to reconnect: %v", c.IsReconnecting())
 			for c.IsReconnecting() {
@@ -143,7 +233,9 @@ func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 			}
 		}),
 		nats.ReconnectHandler(func(c *nats.Conn) {
-			h.logger.Debug(natsHandlerLogTag, "Reconnected to %v", c.ConnectedAddr())
+			attempts := h.metrics.RecordReconnect()
+			metrics := h.metrics.Snapshot()
+			h.logger.Info(natsHandlerLogTag, "Reconnected to %v after %d attempts (disconnects: %d, reconnects: %d)", c.ConnectedAddr(), attempts, metrics.Disconnects, metrics.Reconnects)
 		}),
 		nats.ClosedHandler(func(c *nats.Conn) {
 			h.logger.Debug(natsHandlerLogTag, "Connection Closed with: %v", c.LastError().Error())
@@ -152,33 +244,27 @@ func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 			h.logger.Debug(natsHandlerLogTag, err.Error())
 		}),
 		nats.CustomReconnectDelay(func(attempts int) time.Duration {
-			reconnectSeconds := natsMinReconnectSeconds * float64(attempts)
-			if reconnectSeconds > natsMaxReconnectSeconds || reconnectSeconds <= 0.0 {
-				reconnectSeconds = natsMaxReconnectSeconds
-			}
-			reconnectDelay := time.Duration(reconnectSeconds * float64(time.Second))
-			h.logger.Debug(natsHandlerLogTag, "Increased reconnect to: %v", reconnectDelay)
+			h.metrics.RecordReconnectAttempt(attempts)
+			reconnectDelay := reconnectPolicy.Delay(attempts, rand.Float64()) //nolint:gosec
+			h.logger.Debug(natsHandlerLogTag, "Waiting %v before reconnect attempt %d", reconnectDelay, attempts)
 			return reconnectDelay
 		}),
 		nats.MaxReconnects(-1),
 		nats.Secure(connectionInfo.TLSConfig),
-	}
+	)
 
 	connection, err := h.connector(connectionInfo.Addr, natsOptions...)
-	// just log this error. even if currently cannot connect to nats, we can eventually
 	if err != nil {
-		return bosherr.WrapError(err, "Connecting to NATS")
+		return nil, bosherr.WrapError(err, "Connecting to NATS")
 	}
 
-	h.connection = connection
-
 	settings := h.settingsService.GetSettings()
 
 	subject := fmt.Sprintf("agent.%s", settings.AgentID)
 
 	h.logger.Info(h.logTag, "Subscribing to %s", subject)
 
-	_, err = h.connection.Subscribe(subject, func(natsMsg *nats.Msg) {
+	_, err = connection.QueueSubscribe(subject, subject, func(natsMsg *nats.Msg) {
 		// Do not lock handler funcs around possible network calls!
 		h.handlerFuncsLock.Lock()
 		handlerFuncs := h.handlerFuncs
@@ -189,10 +275,11 @@ func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 		}
 	})
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Subscribing to %s", subject)
+		connection.Close()
+		return nil, bosherr.WrapErrorf(err, "Subscribing to %s", subject)
 	}
 
-	return nil
+	return connection, nil
 }
 
 func (h *natsHandler) RegisterAdditionalFunc(handlerFunc boshhandler.Func) {
@@ -213,20 +300,48 @@ func (h *natsHandler) Send(target boshhandler.Target, topic boshhandler.Topic, m
 	h.logger.DebugWithDetails(h.logTag, "Message Payload", string(bytes))
 
 	settings := h.settingsService.GetSettings()
-
 	subject := fmt.Sprintf("%s.agent.%s.%s", target, topic, settings.AgentID)
-	if h.connection != nil {
-		return h.connection.Publish(subject, bytes)
+
+	// The health monitor does not know the keys of the agents
+	if target == boshhandler.Director {
+		encryptionKeys, err := NewPayloadEncryptionKeys(settings.GetMbusEncryption())
+		if err != nil {
+			return bosherr.WrapError(err, "Configuring mbus encryption")
+		}
+
+		binding := PayloadBinding{AgentID: settings.AgentID, Subject: subject}
+
+		bytes, err = encryptionKeys.Encrypt(bytes, binding, h.timeService.Now())
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Encrypting message (target=%s, topic=%s)", target, topic)
+		}
+	}
+
+	if connection := h.currentConnection(); connection != nil {
+		return connection.Publish(subject, bytes)
 	}
 	return nil
 }
 
 func (h *natsHandler) Stop() {
-	if h.connection != nil {
-		h.connection.Close()
+	if connection := h.currentConnection(); connection != nil {
+		connection.Close()
 	}
 }
 
+// ConnectionMetrics counts how often the connection to NATS was lost and
+// regained, they are reported with heartbeats.
+func (h *natsHandler) ConnectionMetrics() ConnectionMetricsSnapshot {
+	return h.metrics.Snapshot()
+}
+
+func (h *natsHandler) currentConnection() NatsConnection {
+	h.connectionLock.RLock()
+	defer h.connectionLock.RUnlock()
+
+	return h.connection
+}
+
 func (h *natsHandler) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
 	natsBoshInternalsRegexp := regexp.MustCompile(`^[a-zA-Z0-9*\-]*.nats.bosh-internal$`)
 	for _, chain := range verifiedChains {
@@ -242,10 +357,28 @@ func (h *natsHandler) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains []
 }
 
 func (h *natsHandler) handleNatsMsg(natsMsg *nats.Msg, handlerFunc boshhandler.Func) {
+	settings := h.settingsService.GetSettings()
+
+	// Keys are read for every message so that rotated keys apply right away
+	encryptionKeys, err := NewPayloadEncryptionKeys(settings.GetMbusEncryption())
+	if err != nil {
+		err = bosherr.WrapError(err, "Configuring mbus encryption")
+		h.logger.Error(h.logTag, "Running handler: %s", err)
+		h.generateCEFLog(natsMsg, 7, err.Error())
+		return
+	}
+
+	payload, err := encryptionKeys.Decrypt(natsMsg.Data, PayloadBinding{AgentID: settings.AgentID, Subject: natsMsg.Subject}, h.replays)
+	if err != nil {
+		h.logger.Error(h.logTag, "Running handler: %s", err)
+		h.generateCEFLog(natsMsg, 7, err.Error())
+		return
+	}
+
 	respBytes, req, err := boshhandler.PerformHandlerWithJSON(
-		natsMsg.Data,
+		payload,
 		handlerFunc,
-		responseMaxLength,
+		encryptionKeys.MaxPlaintextLength(responseMaxLength),
 		h.logger,
 	)
 
@@ -256,7 +389,17 @@ func (h *natsHandler) handleNatsMsg(natsMsg *nats.Msg, handlerFunc boshhandler.F
 	}
 
 	if len(respBytes) > 0 {
-		err = h.connection.Publish(req.ReplyTo, respBytes)
+		// Responses are bound to the reply subject the director listens on
+		binding := PayloadBinding{AgentID: settings.AgentID, Subject: req.ReplyTo}
+
+		respBytes, err = encryptionKeys.Encrypt(respBytes, binding, h.timeService.Now())
+		if err != nil {
+			h.generateCEFLog(natsMsg, 7, err.Error())
+			h.logger.Error(h.logTag, "Encrypting response: %s", err.Error())
+			return
+		}
+
+		err = h.currentConnection().Publish(req.ReplyTo, respBytes)
 		if err != nil {
 			h.generateCEFLog(natsMsg, 7, err.Error())
 			h.logger.Error(h.logTag, "Publishing to the client: %s", err.Error())
@@ -268,7 +411,7 @@ func (h *natsHandler) handleNatsMsg(natsMsg *nats.Msg, handlerFunc boshhandler.F
 }
 
 func (h *natsHandler) runUntilInterrupted() {
-	defer h.connection.Close()
+	defer h.Stop()
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -281,6 +424,10 @@ func (h *natsHandler) runUntilInterrupted() {
 }
 
 func (h *natsHandler) getConnectionInfo() (*ConnectionInfo, error) {
+	return h.getConnectionInfoWithCerts(h.settingsService.GetSettings().GetMbusCerts())
+}
+
+func (h *natsHandler) getConnectionInfoWithCerts(certs boshsettings.CertKeyPair) (*ConnectionInfo, error) {
 	settings := h.settingsService.GetSettings()
 
 	connInfo := new(ConnectionInfo)
@@ -295,7 +442,7 @@ func (h *natsHandler) getConnectionInfo() (*ConnectionInfo, error) {
 
 	connInfo.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
 
-	caCert := settings.GetMbusCerts().CA
+	caCert := certs.CA
 	if caCert != "" {
 		connInfo.TLSConfig.RootCAs = x509.NewCertPool()
 		if ok := connInfo.TLSConfig.RootCAs.AppendCertsFromPEM([]byte(caCert)); !ok {
@@ -305,7 +452,7 @@ func (h *natsHandler) getConnectionInfo() (*ConnectionInfo, error) {
 
 	connInfo.TLSConfig.VerifyPeerCertificate = h.VerifyPeerCertificate
 
-	clientCertificate, err := tls.X509KeyPair([]byte(settings.GetMbusCerts().Certificate), []byte(settings.GetMbusCerts().PrivateKey))
+	clientCertificate, err := tls.X509KeyPair([]byte(certs.Certificate), []byte(certs.PrivateKey))
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Parsing certificate and private key")
 	}
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"errors"
 	"os"
+	"time"
 
+	"code.cloudfoundry.org/clock"
 	"github.com/nats-io/nats.go"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -89,9 +92,10 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(err).NotTo(HaveOccurred())
 				defer handler.Stop()
 
-				Expect(connection.SubscribeCallCount()).To(Equal(1))
-				subj, handler := connection.SubscribeArgsForCall(0)
+				Expect(connection.QueueSubscribeCallCount()).To(Equal(1))
+				subj, queue, handler := connection.QueueSubscribeArgsForCall(0)
 				Expect(subj).To(Equal("agent.my-agent-id"))
+				Expect(queue).To(Equal("agent.my-agent-id"))
 
 				expectedPayload := []byte(`{"method":"ping","arguments":["foo","bar"], "reply_to": "reply to me!"}`)
 				handler(&nats.Msg{
@@ -151,7 +155,7 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(err).ToNot(HaveOccurred())
 				defer handler.Stop()
 
-				_, handler := connection.SubscribeArgsForCall(0)
+				_, _, handler := connection.QueueSubscribeArgsForCall(0)
 				handler(&nats.Msg{
 					Subject: "agent.my-agent-id",
 					Data:    []byte(`{"method":"ping","arguments":["foo","bar"], "reply_to": "reply to me!"}`),
@@ -160,6 +164,78 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(connection.PublishCallCount()).To(Equal(0))
 			})
 
+			Context("when mbus encryption is configured", func() {
+				var keys *mbus.PayloadEncryptionKeys
+
+				BeforeEach(func() {
+					settingsService.Settings.Env.Bosh.Mbus.Encryption = boshsettings.MbusEncryption{
+						ActiveKeyID: "key-1",
+						Keys:        map[string]string{"key-1": base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))},
+						Required:    true,
+					}
+
+					var err error
+					keys, err = mbus.NewPayloadEncryptionKeys(settingsService.Settings.Env.Bosh.Mbus.Encryption)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("handles a request only once and binds the response to the reply subject", func() {
+					handledRequests := 0
+					err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
+						handledRequests++
+						return boshhandler.NewValueResponse("expected value")
+					})
+					Expect(err).ToNot(HaveOccurred())
+					defer handler.Stop()
+
+					request, err := keys.Encrypt(
+						[]byte(`{"method":"ping","arguments":[],"reply_to":"reply to me!"}`),
+						mbus.PayloadBinding{AgentID: "my-agent-id", Subject: "agent.my-agent-id"},
+						time.Now(),
+					)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, _, natsHandler := connection.QueueSubscribeArgsForCall(0)
+					natsHandler(&nats.Msg{Subject: "agent.my-agent-id", Data: request})
+					natsHandler(&nats.Msg{Subject: "agent.my-agent-id", Data: request})
+
+					Expect(handledRequests).To(Equal(1))
+					Expect(loggerOutBuf).To(ContainSubstring("Payload was already received"))
+
+					Expect(connection.PublishCallCount()).To(Equal(1))
+					subj, message := connection.PublishArgsForCall(0)
+					Expect(subj).To(Equal("reply to me!"))
+
+					replays := mbus.NewReplayWindow(mbus.DefaultReplayWindow, clock.NewClock())
+					response, err := keys.Decrypt(message, mbus.PayloadBinding{AgentID: "my-agent-id", Subject: "reply to me!"}, replays)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(response).To(Equal([]byte(`{"value":"expected value"}`)))
+				})
+
+				It("rejects requests encrypted for another agent", func() {
+					handledRequests := 0
+					err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
+						handledRequests++
+						return boshhandler.NewValueResponse("expected value")
+					})
+					Expect(err).ToNot(HaveOccurred())
+					defer handler.Stop()
+
+					request, err := keys.Encrypt(
+						[]byte(`{"method":"ping","arguments":[],"reply_to":"reply to me!"}`),
+						mbus.PayloadBinding{AgentID: "other-agent-id", Subject: "agent.other-agent-id"},
+						time.Now(),
+					)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, _, natsHandler := connection.QueueSubscribeArgsForCall(0)
+					natsHandler(&nats.Msg{Subject: "agent.my-agent-id", Data: request})
+
+					Expect(handledRequests).To(BeZero())
+					Expect(connection.PublishCallCount()).To(BeZero())
+				})
+			})
+
 			It("responds with an error if the response is bigger than 1MB", func() {
 				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
 					chars := make([]byte, 1024*1024)
@@ -171,7 +247,7 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(err).ToNot(HaveOccurred())
 				defer handler.Stop()
 
-				_, handler := connection.SubscribeArgsForCall(0)
+				_, _, handler := connection.QueueSubscribeArgsForCall(0)
 				handler(&nats.Msg{
 					Subject: "agent.my-agent-id",
 					Data:    []byte(`{"method":"big","arguments":[], "reply_to": "fake-reply-to"}`),
@@ -201,7 +277,7 @@ func init() { //nolint:funlen,gochecknoinits
 
 				expectedPayload := []byte(`{"method":"ping","arguments":["foo","bar"], "reply_to": "fake-reply-to"}`)
 
-				_, handler := connection.SubscribeArgsForCall(0)
+				_, _, handler := connection.QueueSubscribeArgsForCall(0)
 				handler(&nats.Msg{
 					Subject: "agent.my-agent-id",
 					Data:    expectedPayload,
@@ -255,7 +331,7 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(err).ToNot(HaveOccurred())
 					defer handler.Stop()
 
-					_, handler := connection.SubscribeArgsForCall(0)
+					_, _, handler := connection.QueueSubscribeArgsForCall(0)
 					handler(&nats.Msg{
 						Subject: "agent.my-agent-id",
 						Data:    []byte(`{"method":"ping","arguments":["foo","bar"], "reply_to": "reply to me!"}`),
@@ -273,7 +349,7 @@ func init() { //nolint:funlen,gochecknoinits
 						Expect(err).ToNot(HaveOccurred())
 						defer handler.Stop()
 
-						_, handler := connection.SubscribeArgsForCall(0)
+						_, _, handler := connection.QueueSubscribeArgsForCall(0)
 						handler(&nats.Msg{
 							Subject: "agent.my-agent-id",
 							Data:    []byte(`bad json`),
@@ -296,7 +372,7 @@ func init() { //nolint:funlen,gochecknoinits
 						Expect(err).ToNot(HaveOccurred())
 						defer handler.Stop()
 
-						_, handler := connection.SubscribeArgsForCall(0)
+						_, _, handler := connection.QueueSubscribeArgsForCall(0)
 						handler(&nats.Msg{
 							Subject: "agent.my-agent-id",
 							Data:    []byte(`{"method":"ping","arguments":["foo","bar"], "reply_to": "reply to me!"}`),
@@ -409,6 +485,239 @@ func init() { //nolint:funlen,gochecknoinits
 			})
 		})
 
+		Describe("reconnecting", func() {
+			var options nats.Options
+
+			JustBeforeEach(func() {
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) { return nil })
+				Expect(err).NotTo(HaveOccurred())
+
+				options = nats.Options{}
+				for _, option := range connectorOptionsArg {
+					Expect(option(&options)).To(Succeed())
+				}
+			})
+
+			AfterEach(func() {
+				handler.Stop()
+			})
+
+			It("backs off exponentially up to ten seconds", func() {
+				Expect(options.MaxReconnect).To(Equal(-1))
+				Expect(options.CustomReconnectDelayCB(1)).To(BeNumerically("~", 2*time.Second, 400*time.Millisecond))
+				Expect(options.CustomReconnectDelayCB(2)).To(BeNumerically("~", 4*time.Second, 800*time.Millisecond))
+				Expect(options.CustomReconnectDelayCB(10)).To(BeNumerically("~", 10*time.Second, 2*time.Second))
+				Expect(options.CustomReconnectDelayCB(10)).To(BeNumerically("<=", 10*time.Second))
+			})
+
+			Context("when the agent settings tune reconnecting", func() {
+				BeforeEach(func() {
+					jitter := 0.0
+					settingsService.Settings.Env.Bosh.Agent.Settings.MbusReconnect = boshsettings.MbusReconnectPolicy{
+						InitialBackoffSeconds: 1,
+						Multiplier:            3,
+						MaxBackoffSeconds:     5,
+						Jitter:                &jitter,
+					}
+				})
+
+				It("backs off as configured", func() {
+					Expect(options.CustomReconnectDelayCB(1)).To(Equal(1 * time.Second))
+					Expect(options.CustomReconnectDelayCB(2)).To(Equal(3 * time.Second))
+					Expect(options.CustomReconnectDelayCB(3)).To(Equal(5 * time.Second))
+				})
+			})
+
+			It("counts disconnects, reconnect attempts and reconnects", func() {
+				metrics, ok := handler.(interface {
+					ConnectionMetrics() mbus.ConnectionMetricsSnapshot
+				})
+				Expect(ok).To(BeTrue())
+
+				options.DisconnectedErrCB(&nats.Conn{}, errors.New("fake-disconnect-err"))
+				options.CustomReconnectDelayCB(1)
+				options.CustomReconnectDelayCB(2)
+				Expect(metrics.ConnectionMetrics()).To(Equal(mbus.ConnectionMetricsSnapshot{
+					Disconnects:       1,
+					ReconnectAttempts: 2,
+				}))
+
+				options.ReconnectedCB(&nats.Conn{})
+				Expect(metrics.ConnectionMetrics()).To(Equal(mbus.ConnectionMetricsSnapshot{
+					Disconnects: 1,
+					Reconnects:  1,
+				}))
+			})
+		})
+
+		Describe("ReloadCertificates", func() {
+			var (
+				newConnection *mbusfakes.FakeNatsConnection
+				newCerts      boshsettings.CertKeyPair
+			)
+
+			BeforeEach(func() {
+				newConnection = &mbusfakes.FakeNatsConnection{}
+
+				connections := []*mbusfakes.FakeNatsConnection{connection, newConnection}
+				connector = func(url string, options ...nats.Option) (mbus.NatsConnection, error) {
+					connectorURLArg = url
+					connectorOptionsArg = options
+					next := connections[0]
+					connections = connections[1:]
+					return next, nil
+				}
+
+				newCA, err := os.ReadFile("./test_assets/custom_ca.pem")
+				Expect(err).ToNot(HaveOccurred())
+				newCertificate, err := os.ReadFile("./test_assets/custom_cert.pem")
+				Expect(err).ToNot(HaveOccurred())
+				newPrivateKey, err := os.ReadFile("./test_assets/custom_key.pem")
+				Expect(err).ToNot(HaveOccurred())
+
+				newCerts = boshsettings.CertKeyPair{
+					CA:          string(newCA),
+					Certificate: string(newCertificate),
+					PrivateKey:  string(newPrivateKey),
+				}
+			})
+
+			It("switches over to a connection with the new certificates", func() {
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) { return nil })
+				Expect(err).ToNot(HaveOccurred())
+				defer handler.Stop()
+
+				err = handler.(mbus.CertificateReloader).ReloadCertificates(newCerts)
+				Expect(err).ToNot(HaveOccurred())
+
+				options := nats.Options{}
+				for _, option := range connectorOptionsArg {
+					Expect(option(&options)).To(Succeed())
+				}
+
+				clientCert, err := tls.X509KeyPair([]byte(newCerts.Certificate), []byte(newCerts.PrivateKey))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(options.TLSConfig.Certificates[0]).To(Equal(clientCert))
+				Expect(options.RetryOnFailedConnect).To(BeFalse())
+
+				Expect(newConnection.QueueSubscribeCallCount()).To(Equal(1))
+				subj, queue, _ := newConnection.QueueSubscribeArgsForCall(0)
+				Expect(subj).To(Equal("agent.my-agent-id"))
+				Expect(queue).To(Equal("agent.my-agent-id"))
+
+				Expect(connection.DrainCallCount()).To(Equal(1))
+				Expect(connection.CloseCallCount()).To(Equal(0))
+
+				err = handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, "fake-payload")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(connection.PublishCallCount()).To(Equal(0))
+				Expect(newConnection.PublishCallCount()).To(Equal(1))
+			})
+
+			It("dispatches a request sent during the switchover once", func() {
+				// Delivers each message to a single subscriber of its queue
+				// group, like the NATS server does
+				var (
+					queueGroups = map[string][]nats.MsgHandler{}
+					published   int
+				)
+
+				subscribe := func(subj, queue string, cb nats.MsgHandler) (*nats.Subscription, error) {
+					queueGroups[queue] = append(queueGroups[queue], cb)
+					return nil, nil
+				}
+				publish := func() {
+					for _, subscribers := range queueGroups {
+						subscribers[published%len(subscribers)](&nats.Msg{
+							Subject: "agent.my-agent-id",
+							Data:    []byte(`{"method":"ping","arguments":[],"reply_to":"reply to me!"}`),
+						})
+					}
+					published++
+				}
+
+				connection.QueueSubscribeStub = subscribe
+				newConnection.QueueSubscribeStub = func(subj, queue string, cb nats.MsgHandler) (*nats.Subscription, error) {
+					_, err := subscribe(subj, queue, cb)
+
+					// Both connections are subscribed until the previous one is drained
+					publish()
+					publish()
+
+					return nil, err
+				}
+
+				handledRequests := 0
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
+					handledRequests++
+					return nil
+				})
+				Expect(err).ToNot(HaveOccurred())
+				defer handler.Stop()
+
+				err = handler.(mbus.CertificateReloader).ReloadCertificates(newCerts)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(published).To(Equal(2))
+				Expect(handledRequests).To(Equal(2))
+				Expect(connection.DrainCallCount()).To(Equal(1))
+			})
+
+			It("closes the previous connection when draining it fails", func() {
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) { return nil })
+				Expect(err).ToNot(HaveOccurred())
+				defer handler.Stop()
+
+				connection.DrainReturns(errors.New("fake-drain-err"))
+
+				err = handler.(mbus.CertificateReloader).ReloadCertificates(newCerts)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(connection.CloseCallCount()).To(Equal(1))
+			})
+
+			It("keeps the connection when the certificates did not change", func() {
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) { return nil })
+				Expect(err).ToNot(HaveOccurred())
+				defer handler.Stop()
+
+				err = handler.(mbus.CertificateReloader).ReloadCertificates(settingsService.Settings.GetMbusCerts())
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(newConnection.QueueSubscribeCallCount()).To(Equal(0))
+				Expect(connection.CloseCallCount()).To(Equal(0))
+			})
+
+			It("keeps the previous connection when connecting with the new certificates fails", func() {
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) { return nil })
+				Expect(err).ToNot(HaveOccurred())
+				defer handler.Stop()
+
+				newConnection.QueueSubscribeReturns(nil, errors.New("fake-subscribe-err"))
+
+				err = handler.(mbus.CertificateReloader).ReloadCertificates(newCerts)
+				Expect(err).To(MatchError("Subscribing to agent.my-agent-id: fake-subscribe-err"))
+
+				Expect(newConnection.CloseCallCount()).To(Equal(1))
+				Expect(connection.CloseCallCount()).To(Equal(0))
+
+				err = handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, "fake-payload")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(connection.PublishCallCount()).To(Equal(1))
+			})
+
+			It("returns an error when the new certificates are invalid", func() {
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) { return nil })
+				Expect(err).ToNot(HaveOccurred())
+				defer handler.Stop()
+
+				newCerts.CA = "Invalid Cert"
+
+				err = handler.(mbus.CertificateReloader).ReloadCertificates(newCerts)
+				Expect(err).To(MatchError("Getting connection info: Failed to load Mbus CA cert"))
+				Expect(connection.CloseCallCount()).To(Equal(0))
+			})
+		})
+
 		Describe("Send", func() {
 			It("sends the message over nats to a subject that includes the target and topic", func() {
 				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
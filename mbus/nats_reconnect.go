@@ -0,0 +1,111 @@
+package mbus
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+// ReconnectPolicy controls how long the agent waits between attempts to
+// reconnect to NATS. The backoff grows by Multiplier with every attempt up
+// to MaxBackoff and is shortened by a random fraction of up to Jitter, so
+// that agents losing their connection at the same time do not all
+// reconnect at once.
+type ReconnectPolicy struct {
+	InitialBackoff time.Duration
+	Multiplier     float64
+	MaxBackoff     time.Duration
+	Jitter         float64
+}
+
+var DefaultReconnectPolicy = ReconnectPolicy{
+	InitialBackoff: natsMinReconnectSeconds * time.Second,
+	Multiplier:     2,
+	MaxBackoff:     natsMaxReconnectSeconds * time.Second,
+	Jitter:         0.2,
+}
+
+// NewReconnectPolicy fills the settings left unset with the defaults.
+func NewReconnectPolicy(settings boshsettings.MbusReconnectPolicy) ReconnectPolicy {
+	policy := DefaultReconnectPolicy
+
+	if settings.InitialBackoffSeconds > 0 {
+		policy.InitialBackoff = time.Duration(settings.InitialBackoffSeconds * float64(time.Second))
+	}
+	if settings.Multiplier >= 1 {
+		policy.Multiplier = settings.Multiplier
+	}
+	if settings.MaxBackoffSeconds > 0 {
+		policy.MaxBackoff = time.Duration(settings.MaxBackoffSeconds * float64(time.Second))
+	}
+	if settings.Jitter != nil {
+		policy.Jitter = math.Min(math.Max(*settings.Jitter, 0), 1)
+	}
+
+	return policy
+}
+
+// Delay is the time to wait before the given reconnect attempt, starting at
+// one. random is between 0 and 1 and picks how much of the jitter is used.
+func (p ReconnectPolicy) Delay(attempt int, random float64) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if backoff > float64(p.MaxBackoff) || math.IsInf(backoff, 0) || math.IsNaN(backoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	return time.Duration(backoff * (1 - p.Jitter*random))
+}
+
+// ConnectionMetricsSnapshot counts how often the agent lost and regained its
+// connection to NATS since it started.
+type ConnectionMetricsSnapshot struct {
+	Disconnects int64 `json:"disconnects"`
+	Reconnects  int64 `json:"reconnects"`
+
+	// Attempts made since the connection was lost, zero while connected
+	ReconnectAttempts int64 `json:"reconnect_attempts"`
+}
+
+type connectionMetrics struct {
+	lock     sync.Mutex
+	snapshot ConnectionMetricsSnapshot
+}
+
+func (m *connectionMetrics) RecordDisconnect() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.snapshot.Disconnects++
+}
+
+func (m *connectionMetrics) RecordReconnectAttempt(attempt int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.snapshot.ReconnectAttempts = int64(attempt)
+}
+
+// RecordReconnect returns the number of attempts it took to reconnect.
+func (m *connectionMetrics) RecordReconnect() int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	attempts := m.snapshot.ReconnectAttempts
+	m.snapshot.Reconnects++
+	m.snapshot.ReconnectAttempts = 0
+
+	return attempts
+}
+
+func (m *connectionMetrics) Snapshot() ConnectionMetricsSnapshot {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.snapshot
+}
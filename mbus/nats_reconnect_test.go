@@ -0,0 +1,78 @@
+package mbus_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/mbus"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+var _ = Describe("ReconnectPolicy", func() {
+	Describe("NewReconnectPolicy", func() {
+		It("uses the defaults for unset settings", func() {
+			Expect(mbus.NewReconnectPolicy(boshsettings.MbusReconnectPolicy{})).To(Equal(mbus.DefaultReconnectPolicy))
+		})
+
+		It("uses the configured settings", func() {
+			jitter := 0.5
+			policy := mbus.NewReconnectPolicy(boshsettings.MbusReconnectPolicy{
+				InitialBackoffSeconds: 0.5,
+				Multiplier:            3,
+				MaxBackoffSeconds:     8,
+				Jitter:                &jitter,
+			})
+
+			Expect(policy).To(Equal(mbus.ReconnectPolicy{
+				InitialBackoff: 500 * time.Millisecond,
+				Multiplier:     3,
+				MaxBackoff:     8 * time.Second,
+				Jitter:         0.5,
+			}))
+		})
+
+		It("keeps the jitter between 0 and 1", func() {
+			jitter := 2.0
+			Expect(mbus.NewReconnectPolicy(boshsettings.MbusReconnectPolicy{Jitter: &jitter}).Jitter).To(Equal(1.0))
+
+			jitter = -1.0
+			Expect(mbus.NewReconnectPolicy(boshsettings.MbusReconnectPolicy{Jitter: &jitter}).Jitter).To(Equal(0.0))
+		})
+
+		It("ignores multipliers that would shrink the backoff", func() {
+			Expect(mbus.NewReconnectPolicy(boshsettings.MbusReconnectPolicy{Multiplier: 0.5}).Multiplier).To(Equal(mbus.DefaultReconnectPolicy.Multiplier))
+		})
+	})
+
+	Describe("Delay", func() {
+		var policy mbus.ReconnectPolicy
+
+		BeforeEach(func() {
+			policy = mbus.ReconnectPolicy{
+				InitialBackoff: 2 * time.Second,
+				Multiplier:     2,
+				MaxBackoff:     10 * time.Second,
+				Jitter:         0.2,
+			}
+		})
+
+		It("grows the backoff with every attempt up to the maximum", func() {
+			Expect(policy.Delay(1, 0)).To(Equal(2 * time.Second))
+			Expect(policy.Delay(2, 0)).To(Equal(4 * time.Second))
+			Expect(policy.Delay(3, 0)).To(Equal(8 * time.Second))
+			Expect(policy.Delay(4, 0)).To(Equal(10 * time.Second))
+			Expect(policy.Delay(5000, 0)).To(Equal(10 * time.Second))
+		})
+
+		It("treats attempts below one as the first attempt", func() {
+			Expect(policy.Delay(0, 0)).To(Equal(2 * time.Second))
+		})
+
+		It("shortens the backoff by up to the jitter", func() {
+			Expect(policy.Delay(1, 0.5)).To(Equal(1800 * time.Millisecond))
+			Expect(policy.Delay(4, 1)).To(Equal(8 * time.Second))
+		})
+	})
+})
@@ -0,0 +1,235 @@
+package mbus
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+// EncryptedPayload replaces the JSON payload of a message encrypted with one
+// of the mbus encryption keys. Nonce and Ciphertext are base64 encoded,
+// SentAt is in seconds since the epoch.
+//
+//	{"encrypted": {"key_id": "key-2", "sent_at": 1700000000, "nonce": "...", "ciphertext": "..."}}
+type EncryptedPayload struct {
+	Encrypted *EncryptedPayloadEnvelope `json:"encrypted"`
+}
+
+type EncryptedPayloadEnvelope struct {
+	KeyID  string `json:"key_id"`
+	SentAt int64  `json:"sent_at"`
+
+	// Random for every payload, it identifies the payload to the replay window
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// PayloadBinding is authenticated along with a payload so that it is only
+// accepted by the agent and on the NATS subject it was encrypted for.
+type PayloadBinding struct {
+	AgentID string
+	Subject string
+}
+
+func (b PayloadBinding) additionalData(sentAt int64) []byte {
+	// Agent IDs and NATS subjects do not contain whitespace
+	return []byte(fmt.Sprintf("bosh-mbus-v1\n%s\n%s\n%d", b.AgentID, b.Subject, sentAt))
+}
+
+// The envelope adds the key ID, nonce, tag and JSON around the base64
+// encoded ciphertext
+const encryptedPayloadOverhead = 256
+
+// PayloadEncryptionKeys encrypt payloads with the active key and decrypt
+// payloads encrypted with any of the keys.
+type PayloadEncryptionKeys struct {
+	activeKeyID string
+	keys        map[string]cipher.AEAD
+	required    bool
+}
+
+func NewPayloadEncryptionKeys(settings boshsettings.MbusEncryption) (*PayloadEncryptionKeys, error) {
+	keys := map[string]cipher.AEAD{}
+
+	for keyID, encodedKey := range settings.Keys {
+		if keyID == "" {
+			return nil, bosherr.Error("Invalid mbus encryption key ID ''")
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Decoding mbus encryption key '%s'", keyID)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Creating cipher for mbus encryption key '%s'", keyID)
+		}
+
+		keys[keyID], err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Creating cipher for mbus encryption key '%s'", keyID)
+		}
+	}
+
+	if _, found := keys[settings.ActiveKeyID]; settings.ActiveKeyID != "" && !found {
+		return nil, bosherr.Errorf("Active mbus encryption key '%s' is not one of the keys", settings.ActiveKeyID)
+	}
+
+	if settings.Required && len(keys) == 0 {
+		return nil, bosherr.Error("Mbus encryption is required but there are no keys")
+	}
+
+	return &PayloadEncryptionKeys{
+		activeKeyID: settings.ActiveKeyID,
+		keys:        keys,
+		required:    settings.Required,
+	}, nil
+}
+
+// MaxPlaintextLength is the length of payloads that fit into maxLength once
+// they are encrypted.
+func (k *PayloadEncryptionKeys) MaxPlaintextLength(maxLength int) int {
+	if _, found := k.keys[k.activeKeyID]; !found {
+		return maxLength
+	}
+
+	return base64.StdEncoding.DecodedLen(maxLength) - encryptedPayloadOverhead - len(k.activeKeyID)
+}
+
+// Encrypt returns payload unchanged when there is no active key.
+func (k *PayloadEncryptionKeys) Encrypt(payload []byte, binding PayloadBinding, sentAt time.Time) ([]byte, error) {
+	aead, found := k.keys[k.activeKeyID]
+	if !found {
+		return payload, nil
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, bosherr.WrapError(err, "Generating nonce")
+	}
+
+	envelope := EncryptedPayload{
+		Encrypted: &EncryptedPayloadEnvelope{
+			KeyID:      k.activeKeyID,
+			SentAt:     sentAt.Unix(),
+			Nonce:      nonce,
+			Ciphertext: aead.Seal(nil, nonce, payload, binding.additionalData(sentAt.Unix())),
+		},
+	}
+
+	encrypted, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling encrypted payload")
+	}
+
+	return encrypted, nil
+}
+
+// Decrypt returns payloads that are not encrypted unchanged unless
+// encryption is required. Encrypted payloads are rejected when they were
+// encrypted for another binding or when replays does not accept them.
+func (k *PayloadEncryptionKeys) Decrypt(payload []byte, binding PayloadBinding, replays *ReplayWindow) ([]byte, error) {
+	var envelope EncryptedPayload
+
+	// Plain requests do not unmarshal into an envelope
+	if err := json.Unmarshal(payload, &envelope); err != nil || envelope.Encrypted == nil {
+		if k.required {
+			return nil, bosherr.Error("Payload is not encrypted")
+		}
+		return payload, nil
+	}
+
+	aead, found := k.keys[envelope.Encrypted.KeyID]
+	if !found {
+		return nil, bosherr.Errorf("Payload is encrypted with unknown key '%s'", envelope.Encrypted.KeyID)
+	}
+
+	if len(envelope.Encrypted.Nonce) != aead.NonceSize() {
+		return nil, bosherr.Errorf("Invalid nonce length %d", len(envelope.Encrypted.Nonce))
+	}
+
+	additionalData := binding.additionalData(envelope.Encrypted.SentAt)
+
+	decrypted, err := aead.Open(nil, envelope.Encrypted.Nonce, envelope.Encrypted.Ciphertext, additionalData)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Decrypting payload")
+	}
+
+	// Only authenticated payloads are remembered, others could fill the window
+	err = replays.Accept(envelope.Encrypted.Nonce, time.Unix(envelope.Encrypted.SentAt, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	return decrypted, nil
+}
+
+// DefaultReplayWindow is how far the time an encrypted payload was sent at
+// may be off from the time of the agent. It covers delivery delays and
+// clock skew between the director and the agent.
+const DefaultReplayWindow = 5 * time.Minute
+
+// maxReplayWindowPayloads bounds how many payloads are remembered. The
+// director sends far fewer within a window.
+const maxReplayWindowPayloads = 10000
+
+// ReplayWindow rejects encrypted payloads that were sent too long ago or
+// too far in the future, and payloads that it already accepted. Payloads are
+// remembered until they would be rejected as stale anyway.
+type ReplayWindow struct {
+	duration    time.Duration
+	timeService clock.Clock
+
+	lock sync.Mutex
+	seen map[string]time.Time
+}
+
+func NewReplayWindow(duration time.Duration, timeService clock.Clock) *ReplayWindow {
+	return &ReplayWindow{
+		duration:    duration,
+		timeService: timeService,
+		seen:        map[string]time.Time{},
+	}
+}
+
+// Accept returns an error when the payload with nonce is stale or was
+// accepted before, otherwise it remembers nonce.
+func (w *ReplayWindow) Accept(nonce []byte, sentAt time.Time) error {
+	now := w.timeService.Now()
+
+	if sentAt.Before(now.Add(-w.duration)) || sentAt.After(now.Add(w.duration)) {
+		return bosherr.Errorf("Payload was sent at %s, outside of the replay window", sentAt.UTC().Format(time.RFC3339))
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for seenNonce, expiresAt := range w.seen {
+		if now.After(expiresAt) {
+			delete(w.seen, seenNonce)
+		}
+	}
+
+	if _, found := w.seen[string(nonce)]; found {
+		return bosherr.Error("Payload was already received")
+	}
+
+	if len(w.seen) >= maxReplayWindowPayloads {
+		return bosherr.Error("Too many payloads received within the replay window")
+	}
+
+	w.seen[string(nonce)] = sentAt.Add(w.duration)
+
+	return nil
+}
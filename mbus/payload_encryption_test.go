@@ -0,0 +1,253 @@
+package mbus_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/v2/mbus"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+var _ = Describe("PayloadEncryptionKeys", func() {
+	var (
+		oldKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+		newKey = base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+
+		binding     = mbus.PayloadBinding{AgentID: "fake-agent-id", Subject: "agent.fake-agent-id"}
+		timeService *fakeclock.FakeClock
+		replays     *mbus.ReplayWindow
+	)
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Now())
+		replays = mbus.NewReplayWindow(mbus.DefaultReplayWindow, timeService)
+	})
+
+	Describe("NewPayloadEncryptionKeys", func() {
+		It("returns an error when a key is not base64 encoded", func() {
+			_, err := mbus.NewPayloadEncryptionKeys(boshsettings.MbusEncryption{Keys: map[string]string{"key-1": "not base64!"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decoding mbus encryption key 'key-1'"))
+		})
+
+		It("returns an error when a key is not a valid AES key", func() {
+			_, err := mbus.NewPayloadEncryptionKeys(boshsettings.MbusEncryption{Keys: map[string]string{"key-1": "a2V5"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Creating cipher for mbus encryption key 'key-1'"))
+		})
+
+		It("returns an error when the active key is not one of the keys", func() {
+			_, err := mbus.NewPayloadEncryptionKeys(boshsettings.MbusEncryption{ActiveKeyID: "key-2", Keys: map[string]string{"key-1": oldKey}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Active mbus encryption key 'key-2' is not one of the keys"))
+		})
+
+		It("returns an error when encryption is required without keys", func() {
+			_, err := mbus.NewPayloadEncryptionKeys(boshsettings.MbusEncryption{Required: true})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Mbus encryption is required but there are no keys"))
+		})
+	})
+
+	Context("without keys", func() {
+		It("leaves payloads unchanged", func() {
+			keys, err := mbus.NewPayloadEncryptionKeys(boshsettings.MbusEncryption{})
+			Expect(err).ToNot(HaveOccurred())
+
+			encrypted, err := keys.Encrypt([]byte(`{"method":"ping"}`), binding, timeService.Now())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(encrypted).To(Equal([]byte(`{"method":"ping"}`)))
+
+			decrypted, err := keys.Decrypt([]byte(`{"method":"ping"}`), binding, replays)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypted).To(Equal([]byte(`{"method":"ping"}`)))
+
+			Expect(keys.MaxPlaintextLength(1024)).To(Equal(1024))
+		})
+	})
+
+	Context("with an active key", func() {
+		var keys *mbus.PayloadEncryptionKeys
+
+		BeforeEach(func() {
+			var err error
+			keys, err = mbus.NewPayloadEncryptionKeys(boshsettings.MbusEncryption{
+				ActiveKeyID: "key-2",
+				Keys:        map[string]string{"key-1": oldKey, "key-2": newKey},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("encrypts payloads with the active key", func() {
+			encrypted, err := keys.Encrypt([]byte(`{"method":"ping"}`), binding, timeService.Now())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(encrypted)).ToNot(ContainSubstring("ping"))
+
+			var envelope mbus.EncryptedPayload
+			Expect(json.Unmarshal(encrypted, &envelope)).To(Succeed())
+			Expect(envelope.Encrypted.KeyID).To(Equal("key-2"))
+			Expect(envelope.Encrypted.SentAt).To(Equal(timeService.Now().Unix()))
+
+			decrypted, err := keys.Decrypt(encrypted, binding, replays)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypted).To(Equal([]byte(`{"method":"ping"}`)))
+		})
+
+		It("decrypts payloads encrypted with a retired key", func() {
+			oldKeys, err := mbus.NewPayloadEncryptionKeys(boshsettings.MbusEncryption{ActiveKeyID: "key-1", Keys: map[string]string{"key-1": oldKey}})
+			Expect(err).ToNot(HaveOccurred())
+
+			encrypted, err := oldKeys.Encrypt([]byte(`{"method":"ping"}`), binding, timeService.Now())
+			Expect(err).ToNot(HaveOccurred())
+
+			decrypted, err := keys.Decrypt(encrypted, binding, replays)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypted).To(Equal([]byte(`{"method":"ping"}`)))
+		})
+
+		It("returns an error when a payload is encrypted with an unknown key", func() {
+			otherKeys, err := mbus.NewPayloadEncryptionKeys(boshsettings.MbusEncryption{ActiveKeyID: "key-3", Keys: map[string]string{"key-3": oldKey}})
+			Expect(err).ToNot(HaveOccurred())
+
+			encrypted, err := otherKeys.Encrypt([]byte(`{"method":"ping"}`), binding, timeService.Now())
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = keys.Decrypt(encrypted, binding, replays)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Payload is encrypted with unknown key 'key-3'"))
+		})
+
+		It("returns an error when a payload was tampered with", func() {
+			encrypted, err := keys.Encrypt([]byte(`{"method":"ping"}`), binding, timeService.Now())
+			Expect(err).ToNot(HaveOccurred())
+
+			var envelope mbus.EncryptedPayload
+			Expect(json.Unmarshal(encrypted, &envelope)).To(Succeed())
+			envelope.Encrypted.Ciphertext[0] ^= 0xff
+			tampered, err := json.Marshal(envelope)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = keys.Decrypt(tampered, binding, replays)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decrypting payload"))
+		})
+
+		It("decrypts plain payloads unless encryption is required", func() {
+			decrypted, err := keys.Decrypt([]byte(`{"method":"ping"}`), binding, replays)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypted).To(Equal([]byte(`{"method":"ping"}`)))
+
+			requiredKeys, err := mbus.NewPayloadEncryptionKeys(boshsettings.MbusEncryption{
+				ActiveKeyID: "key-2",
+				Keys:        map[string]string{"key-2": newKey},
+				Required:    true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = requiredKeys.Decrypt([]byte(`{"method":"ping"}`), binding, replays)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Payload is not encrypted"))
+		})
+
+		It("returns an error when a payload was encrypted for another agent", func() {
+			encrypted, err := keys.Encrypt([]byte(`{"method":"ping"}`), mbus.PayloadBinding{AgentID: "other-agent-id", Subject: binding.Subject}, timeService.Now())
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = keys.Decrypt(encrypted, binding, replays)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decrypting payload"))
+		})
+
+		It("returns an error when a payload was encrypted for another subject", func() {
+			encrypted, err := keys.Encrypt([]byte(`{"method":"ping"}`), mbus.PayloadBinding{AgentID: binding.AgentID, Subject: "agent.other-agent-id"}, timeService.Now())
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = keys.Decrypt(encrypted, binding, replays)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decrypting payload"))
+		})
+
+		It("returns an error when the time a payload was sent at was tampered with", func() {
+			encrypted, err := keys.Encrypt([]byte(`{"method":"ping"}`), binding, timeService.Now().Add(-time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+
+			var envelope mbus.EncryptedPayload
+			Expect(json.Unmarshal(encrypted, &envelope)).To(Succeed())
+			envelope.Encrypted.SentAt = timeService.Now().Unix()
+			tampered, err := json.Marshal(envelope)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = keys.Decrypt(tampered, binding, replays)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decrypting payload"))
+		})
+
+		It("returns an error when a payload is received again", func() {
+			encrypted, err := keys.Encrypt([]byte(`{"method":"ping"}`), binding, timeService.Now())
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = keys.Decrypt(encrypted, binding, replays)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = keys.Decrypt(encrypted, binding, replays)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Payload was already received"))
+		})
+
+		It("returns an error when a payload is stale", func() {
+			encrypted, err := keys.Encrypt([]byte(`{"method":"ping"}`), binding, timeService.Now())
+			Expect(err).ToNot(HaveOccurred())
+
+			timeService.Increment(mbus.DefaultReplayWindow + time.Second)
+
+			_, err = keys.Decrypt(encrypted, binding, replays)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("outside of the replay window"))
+		})
+
+		It("keeps encrypted payloads within the maximum length", func() {
+			maxLength := keys.MaxPlaintextLength(1024)
+			Expect(maxLength).To(BeNumerically("<", 1024))
+
+			encrypted, err := keys.Encrypt(make([]byte, maxLength), binding, timeService.Now())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(encrypted)).To(BeNumerically("<=", 1024))
+		})
+	})
+
+	Describe("ReplayWindow", func() {
+		It("accepts payloads sent within the window before or after now", func() {
+			Expect(replays.Accept([]byte("nonce-1"), timeService.Now().Add(-4*time.Minute))).To(Succeed())
+			Expect(replays.Accept([]byte("nonce-2"), timeService.Now().Add(4*time.Minute))).To(Succeed())
+		})
+
+		It("rejects payloads sent outside of the window", func() {
+			Expect(replays.Accept([]byte("nonce-1"), timeService.Now().Add(-6*time.Minute))).ToNot(Succeed())
+			Expect(replays.Accept([]byte("nonce-2"), timeService.Now().Add(6*time.Minute))).ToNot(Succeed())
+		})
+
+		It("rejects payloads it accepted before", func() {
+			Expect(replays.Accept([]byte("nonce-1"), timeService.Now())).To(Succeed())
+			Expect(replays.Accept([]byte("nonce-1"), timeService.Now())).To(MatchError("Payload was already received"))
+		})
+
+		It("forgets payloads once they are stale and stops accepting payloads while it is full", func() {
+			for i := 0; i < 10000; i++ {
+				Expect(replays.Accept([]byte(fmt.Sprintf("nonce-%d", i)), timeService.Now())).To(Succeed())
+			}
+
+			err := replays.Accept([]byte("nonce-full"), timeService.Now())
+			Expect(err).To(MatchError("Too many payloads received within the replay window"))
+
+			timeService.Increment(mbus.DefaultReplayWindow + time.Second)
+
+			Expect(replays.Accept([]byte("nonce-full"), timeService.Now())).To(Succeed())
+		})
+	})
+})
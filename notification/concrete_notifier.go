@@ -15,3 +15,11 @@ func NewNotifier(handler boshhandler.Handler) Notifier {
 func (n concreteNotifier) NotifyShutdown() error {
 	return n.handler.Send(boshhandler.HealthMonitor, boshhandler.Shutdown, nil)
 }
+
+func (n concreteNotifier) NotifyApplyCompleted(completion TaskCompletion) error {
+	return n.handler.Send(boshhandler.Director, boshhandler.ApplyCompleted, completion)
+}
+
+func (n concreteNotifier) NotifyTaskProgress(progress TaskProgress) error {
+	return n.handler.Send(boshhandler.Director, boshhandler.TaskProgress, progress)
+}
@@ -44,4 +44,43 @@ var _ = Describe("concreteNotifier", func() {
 			Expect(err.Error()).To(ContainSubstring("fake-send-error"))
 		})
 	})
+
+	Describe("NotifyApplyCompleted", func() {
+		It("sends the completion to the director", func() {
+			handler := fakembus.NewFakeHandler()
+			notifier := NewNotifier(handler)
+
+			completion := TaskCompletion{AgentTaskID: "fake-task-id", Method: "apply_async", State: "done", Value: "applied"}
+
+			err := notifier.NotifyApplyCompleted(completion)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(handler.SendInputs()).To(Equal([]fakembus.SendInput{
+				{
+					Target:  boshhandler.Director,
+					Topic:   boshhandler.ApplyCompleted,
+					Message: completion,
+				},
+			}))
+		})
+	})
+
+	Describe("NotifyTaskProgress", func() {
+		It("sends the progress to the director", func() {
+			handler := fakembus.NewFakeHandler()
+			notifier := NewNotifier(handler)
+
+			progress := TaskProgress{AgentTaskID: "fake-task-id", Method: "apply", Progress: "fake-progress"}
+
+			err := notifier.NotifyTaskProgress(progress)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(handler.SendInputs()).To(Equal([]fakembus.SendInput{
+				{
+					Target:  boshhandler.Director,
+					Topic:   boshhandler.TaskProgress,
+					Message: progress,
+				},
+			}))
+		})
+	})
 })
@@ -1,8 +1,18 @@
 package fakes
 
+import (
+	boshnotif "github.com/cloudfoundry/bosh-agent/v2/notification"
+)
+
 type FakeNotifier struct {
 	NotifiedShutdown  bool
 	NotifyShutdownErr error
+
+	NotifiedApplyCompletions []boshnotif.TaskCompletion
+	NotifyApplyCompletedErr  error
+
+	NotifiedTaskProgress  []boshnotif.TaskProgress
+	NotifyTaskProgressErr error
 }
 
 func NewFakeNotifier() *FakeNotifier {
@@ -13,3 +23,13 @@ func (n *FakeNotifier) NotifyShutdown() error {
 	n.NotifiedShutdown = true
 	return n.NotifyShutdownErr
 }
+
+func (n *FakeNotifier) NotifyApplyCompleted(completion boshnotif.TaskCompletion) error {
+	n.NotifiedApplyCompletions = append(n.NotifiedApplyCompletions, completion)
+	return n.NotifyApplyCompletedErr
+}
+
+func (n *FakeNotifier) NotifyTaskProgress(progress boshnotif.TaskProgress) error {
+	n.NotifiedTaskProgress = append(n.NotifiedTaskProgress, progress)
+	return n.NotifyTaskProgressErr
+}
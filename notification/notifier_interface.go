@@ -2,4 +2,24 @@ package notification
 
 type Notifier interface {
 	NotifyShutdown() (err error)
+	NotifyApplyCompleted(completion TaskCompletion) (err error)
+	NotifyTaskProgress(progress TaskProgress) (err error)
+}
+
+// TaskCompletion summarises how a task ended for requesters that asked to be
+// called back instead of polling get_task.
+type TaskCompletion struct {
+	AgentTaskID string      `json:"agent_task_id"`
+	Method      string      `json:"method"`
+	State       string      `json:"state"`
+	Value       interface{} `json:"value,omitempty"`
+	Exception   string      `json:"exception,omitempty"`
+}
+
+// TaskProgress is sent to the director while a task is running so that it
+// does not have to wait for get_task to show what the task is doing.
+type TaskProgress struct {
+	AgentTaskID string      `json:"agent_task_id"`
+	Method      string      `json:"method"`
+	Progress    interface{} `json:"progress"`
 }
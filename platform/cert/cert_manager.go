@@ -31,6 +31,13 @@ type Manager interface {
 	// concatenated together. Any text that is not between `-----BEGIN CERTIFICATE-----`
 	// and `-----END CERTIFICATE-----` lines is ignored.
 	UpdateCertificates(certs string) error
+
+	// ManageCertificates adds and removes individual CA certificates without
+	// touching the other ones the agent trusts. The changes are validated
+	// before the trust store is touched, and the trust store is regenerated
+	// once for all of them. If regenerating fails the previously trusted
+	// certificates are restored.
+	ManageCertificates(changes CertificateChanges) (CertificateReport, error)
 }
 
 type certManager struct {
@@ -39,6 +46,7 @@ type certManager struct {
 	path          string
 	updateCmdPath string
 	updateCmdArgs []string
+	storeName     string
 	logger        logger.Logger
 	logTag        string
 	// Update execution time limit in seconds
@@ -53,6 +61,7 @@ func NewUbuntuCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, timeo
 		path:          "/usr/local/share/ca-certificates/",
 		updateCmdPath: "/usr/sbin/update-ca-certificates",
 		updateCmdArgs: []string{"-f"},
+		storeName:     "ca-certificates",
 		logger:        logger,
 		logTag:        "UbuntuCertManager",
 		updateTimeout: timeout,
@@ -65,6 +74,7 @@ func NewCentOSCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, timeo
 		runner:        runner,
 		path:          "/etc/pki/ca-trust/source/anchors/",
 		updateCmdPath: "/usr/bin/update-ca-trust",
+		storeName:     "ca-trust",
 		logger:        logger,
 		logTag:        "CentOSCertManager",
 		updateTimeout: timeout,
@@ -90,21 +100,109 @@ func (c *certManager) UpdateCertificates(certs string) error {
 		return nil
 	}
 
+	err := c.writeCertificateFiles(splitCerts(certs))
+	if err != nil {
+		return err
+	}
+
+	return c.updateTrustStore()
+}
+
+func (c *certManager) ManageCertificates(changes CertificateChanges) (CertificateReport, error) {
+	c.logger.Info(c.logTag, "Managing individual certificates")
+
+	current, err := c.trustedCertificates()
+	if err != nil {
+		return CertificateReport{}, err
+	}
+
+	report, err := applyCertificateChanges(current, changes, time.Now())
+	if err != nil {
+		return CertificateReport{}, err
+	}
+
+	if !report.Changed() || c.updateCmdPath == "dummy" {
+		return report, nil
+	}
+
+	err = c.writeCertificateFiles(certificatePEMs(report.Trusted))
+	if err == nil {
+		err = c.updateTrustStore()
+	}
+
+	if err != nil {
+		c.restoreCertificates(current)
+		return CertificateReport{}, bosherr.WrapError(err, "Regenerating trust store")
+	}
+
+	c.logger.Debug(c.logTag, "Added %d and removed %d certificates", len(report.Added), len(report.Removed))
+
+	report.UpdatedStores = append(report.UpdatedStores, c.storeName)
+
+	return report, nil
+}
+
+// trustedCertificates parses the certificate files written by earlier updates
+func (c *certManager) trustedCertificates() ([]TrustedCertificate, error) {
+	if c.updateCmdPath == "dummy" {
+		return nil, nil
+	}
+
+	files, err := c.fs.Glob(fmt.Sprintf("%s%s*", c.path, "bosh-trusted-cert-"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Glob command failed")
+	}
+
+	var certs []TrustedCertificate
+	for _, file := range files {
+		contents, err := c.fs.ReadFileString(file)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading trusted certificate %s", file)
+		}
+
+		parsed, err := parseCertificates(contents)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing trusted certificate %s", file)
+		}
+
+		certs = append(certs, parsed...)
+	}
+
+	return certs, nil
+}
+
+// restoreCertificates puts back the certificates trusted before a failed
+// change, so that the trust store does not end up half updated
+func (c *certManager) restoreCertificates(certs []TrustedCertificate) {
+	err := c.writeCertificateFiles(certificatePEMs(certs))
+	if err == nil {
+		err = c.updateTrustStore()
+	}
+
+	if err != nil {
+		c.logger.Error(c.logTag, "Restoring previously trusted certificates: %s", err.Error())
+	}
+}
+
+func (c *certManager) writeCertificateFiles(certs []string) error {
 	deletedFilesCount, err := deleteFiles(c.fs, c.path, "bosh-trusted-cert-")
 	c.logger.Debug(c.logTag, "Deleted %d existing certificate files", deletedFilesCount)
 	if err != nil {
 		return err
 	}
 
-	slicedCerts := splitCerts(certs)
-	for i, cert := range slicedCerts {
+	for i, cert := range certs {
 		err := c.fs.WriteFileString(fmt.Sprintf("%sbosh-trusted-cert-%d.crt", c.path, i+1), cert)
 		if err != nil {
 			return err
 		}
 	}
-	c.logger.Debug(c.logTag, "Wrote %d new certificate files", len(slicedCerts))
+	c.logger.Debug(c.logTag, "Wrote %d new certificate files", len(certs))
+
+	return nil
+}
 
+func (c *certManager) updateTrustStore() error {
 	// For Ubuntu OS, update-ca-certificates occasionally hangs, which results
 	// in bosh-agent failure. A retry normally solves this issue. We kill the process
 	// if it runs over given time limit and retry for 3 times until we throw error.
@@ -143,7 +241,7 @@ func (c *certManager) UpdateCertificates(certs string) error {
 
 	c.logger.Debug(c.logTag, "Try to update new certificate files without retry")
 
-	_, _, _, err = c.runner.RunCommand(c.updateCmdPath, c.updateCmdArgs...)
+	_, _, _, err := c.runner.RunCommand(c.updateCmdPath, c.updateCmdArgs...)
 	if err != nil {
 		return bosherr.WrapError(err, "Running command to update certificates without retries")
 	}
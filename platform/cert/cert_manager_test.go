@@ -1,12 +1,22 @@
 package cert_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -186,7 +196,7 @@ var _ = Describe("Certificate Management", func() {
 			certManager   cert.Manager
 		)
 
-		SharedLinuxCertManagerExamples := func(certBasePath, certUpdateProgram string) {
+		SharedLinuxCertManagerExamples := func(certBasePath, certUpdateProgram, storeName string) {
 			It("writes 1 cert to a file", func() {
 				err := certManager.UpdateCertificates(cert1)
 				Expect(err).NotTo(HaveOccurred())
@@ -251,6 +261,85 @@ var _ = Describe("Certificate Management", func() {
 				err = certManager.UpdateCertificates("")
 				Expect(err).To(HaveOccurred())
 			})
+
+			Describe("ManageCertificates", func() {
+				var (
+					certA, certB               string
+					fingerprintA, fingerprintB string
+				)
+
+				BeforeEach(func() {
+					certA, fingerprintA = generateCert("cert-a", time.Now().Add(time.Hour))
+					certB, fingerprintB = generateCert("cert-b", time.Now().Add(time.Hour))
+
+					err := fakeFs.WriteFileString(fmt.Sprintf("%s/bosh-trusted-cert-1.crt", certBasePath), certA)
+					Expect(err).NotTo(HaveOccurred())
+					fakeFs.SetGlob(fmt.Sprintf("%s/bosh-trusted-cert-*", certBasePath), []string{
+						fmt.Sprintf("%s/bosh-trusted-cert-1.crt", certBasePath),
+					})
+				})
+
+				It("adds certificates keeping the trusted ones", func() {
+					report, err := certManager.ManageCertificates(cert.CertificateChanges{Add: certB})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(report.Added).To(HaveLen(1))
+					Expect(report.Added[0].Fingerprint).To(Equal(fingerprintB))
+					Expect(report.Added[0].Subject).To(Equal("CN=cert-b"))
+					Expect(report.Removed).To(BeEmpty())
+					Expect(report.Trusted).To(HaveLen(2))
+					Expect(report.UpdatedStores).To(Equal([]string{storeName}))
+					Expect(report.Bundle()).To(Equal(certA + "\n" + certB))
+
+					Expect(fakeFs.ReadFileString(fmt.Sprintf("%s/bosh-trusted-cert-1.crt", certBasePath))).To(Equal(certA))
+					Expect(fakeFs.ReadFileString(fmt.Sprintf("%s/bosh-trusted-cert-2.crt", certBasePath))).To(Equal(certB))
+				})
+
+				It("removes certificates by fingerprint", func() {
+					report, err := certManager.ManageCertificates(cert.CertificateChanges{Remove: []string{strings.ToUpper(fingerprintA)}})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(report.Removed).To(HaveLen(1))
+					Expect(report.Removed[0].Fingerprint).To(Equal(fingerprintA))
+					Expect(report.Trusted).To(BeEmpty())
+					Expect(report.UpdatedStores).To(Equal([]string{storeName}))
+
+					Expect(fakeFs.FileExists(fmt.Sprintf("%s/bosh-trusted-cert-1.crt", certBasePath))).To(BeFalse())
+				})
+
+				It("does not regenerate the trust store when adding a trusted certificate", func() {
+					report, err := certManager.ManageCertificates(cert.CertificateChanges{Add: certA})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(report.Added).To(BeEmpty())
+					Expect(report.Trusted).To(HaveLen(1))
+					Expect(report.UpdatedStores).To(BeEmpty())
+				})
+
+				It("rejects invalid certificates without touching the trusted ones", func() {
+					_, err := certManager.ManageCertificates(cert.CertificateChanges{Add: certB + "\n" + cert1})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Validating certificates to add"))
+
+					Expect(fakeFs.ReadFileString(fmt.Sprintf("%s/bosh-trusted-cert-1.crt", certBasePath))).To(Equal(certA))
+					Expect(fakeFs.FileExists(fmt.Sprintf("%s/bosh-trusted-cert-2.crt", certBasePath))).To(BeFalse())
+				})
+
+				It("rejects expired certificates", func() {
+					expiredCert, _ := generateCert("expired", time.Now().Add(-time.Hour))
+
+					_, err := certManager.ManageCertificates(cert.CertificateChanges{Add: expiredCert})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("expired at"))
+				})
+
+				It("rejects removing certificates that are not trusted", func() {
+					_, err := certManager.ManageCertificates(cert.CertificateChanges{Remove: []string{fingerprintB}})
+					Expect(err).To(MatchError(fmt.Sprintf("Validating certificates to remove: certificate %s is not trusted", fingerprintB)))
+
+					Expect(fakeFs.ReadFileString(fmt.Sprintf("%s/bosh-trusted-cert-1.crt", certBasePath))).To(Equal(certA))
+				})
+			})
 		}
 
 		Context("Ubuntu", func() {
@@ -286,7 +375,7 @@ var _ = Describe("Certificate Management", func() {
 				fakeCmdRunner.AddProcess("/usr/sbin/update-ca-certificates -f", fakeProcess3)
 			})
 
-			SharedLinuxCertManagerExamples("/usr/local/share/ca-certificates", "/usr/sbin/update-ca-certificates")
+			SharedLinuxCertManagerExamples("/usr/local/share/ca-certificates", "/usr/sbin/update-ca-certificates", "ca-certificates")
 
 			It("updates certs", func() {
 				err := certManager.UpdateCertificates(cert1)
@@ -352,7 +441,7 @@ var _ = Describe("Certificate Management", func() {
 				certManager = cert.NewCentOSCertManager(fakeFs, fakeCmdRunner, 0, log)
 			})
 
-			SharedLinuxCertManagerExamples("/etc/pki/ca-trust/source/anchors", "/usr/bin/update-ca-trust")
+			SharedLinuxCertManagerExamples("/etc/pki/ca-trust/source/anchors", "/usr/bin/update-ca-trust", "ca-trust")
 
 			It("executes update cert command", func() {
 				fakeCmdRunner = fakesys.NewFakeCmdRunner()
@@ -367,6 +456,30 @@ var _ = Describe("Certificate Management", func() {
 				err := certManager.UpdateCertificates(cert1)
 				Expect(err).To(HaveOccurred())
 			})
+
+			It("restores the trusted certificates when regenerating the trust store fails", func() {
+				certA, _ := generateCert("cert-a", time.Now().Add(time.Hour))
+				certB, _ := generateCert("cert-b", time.Now().Add(time.Hour))
+
+				err := fakeFs.WriteFileString("/etc/pki/ca-trust/source/anchors/bosh-trusted-cert-1.crt", certA)
+				Expect(err).NotTo(HaveOccurred())
+				fakeFs.SetGlob("/etc/pki/ca-trust/source/anchors/bosh-trusted-cert-*", []string{
+					"/etc/pki/ca-trust/source/anchors/bosh-trusted-cert-1.crt",
+				})
+
+				fakeCmdRunner = fakesys.NewFakeCmdRunner()
+				fakeCmdRunner.AddCmdResult("/usr/bin/update-ca-trust", fakesys.FakeCmdResult{
+					ExitStatus: 2,
+					Error:      errors.New("command failed"),
+				})
+				certManager = cert.NewCentOSCertManager(fakeFs, fakeCmdRunner, 0, log)
+
+				_, err = certManager.ManageCertificates(cert.CertificateChanges{Add: certB})
+				Expect(err).To(MatchError(ContainSubstring("Regenerating trust store")))
+
+				Expect(fakeFs.ReadFileString("/etc/pki/ca-trust/source/anchors/bosh-trusted-cert-1.crt")).To(Equal(certA))
+				Expect(fakeCmdRunner.RunCommands).To(Equal([][]string{{"/usr/bin/update-ca-trust"}, {"/usr/bin/update-ca-trust"}}))
+			})
 		})
 
 		Context("Windows", func() {
@@ -513,3 +626,24 @@ func countFiles(fs boshsys.FileSystem, dir string) (count int) {
 	Expect(err).NotTo(HaveOccurred())
 	return
 }
+
+func generateCert(commonName string, notAfter time.Time) (certPEM string, fingerprint string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             notAfter.Add(-24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	sum := sha256.Sum256(der)
+	certPEM = strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	return certPEM, hex.EncodeToString(sum[:])
+}
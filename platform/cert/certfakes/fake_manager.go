@@ -8,6 +8,19 @@ import (
 )
 
 type FakeManager struct {
+	ManageCertificatesStub        func(cert.CertificateChanges) (cert.CertificateReport, error)
+	manageCertificatesMutex       sync.RWMutex
+	manageCertificatesArgsForCall []struct {
+		arg1 cert.CertificateChanges
+	}
+	manageCertificatesReturns struct {
+		result1 cert.CertificateReport
+		result2 error
+	}
+	manageCertificatesReturnsOnCall map[int]struct {
+		result1 cert.CertificateReport
+		result2 error
+	}
 	UpdateCertificatesStub        func(string) error
 	updateCertificatesMutex       sync.RWMutex
 	updateCertificatesArgsForCall []struct {
@@ -23,6 +36,70 @@ type FakeManager struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeManager) ManageCertificates(arg1 cert.CertificateChanges) (cert.CertificateReport, error) {
+	fake.manageCertificatesMutex.Lock()
+	ret, specificReturn := fake.manageCertificatesReturnsOnCall[len(fake.manageCertificatesArgsForCall)]
+	fake.manageCertificatesArgsForCall = append(fake.manageCertificatesArgsForCall, struct {
+		arg1 cert.CertificateChanges
+	}{arg1})
+	stub := fake.ManageCertificatesStub
+	fakeReturns := fake.manageCertificatesReturns
+	fake.recordInvocation("ManageCertificates", []interface{}{arg1})
+	fake.manageCertificatesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) ManageCertificatesCallCount() int {
+	fake.manageCertificatesMutex.RLock()
+	defer fake.manageCertificatesMutex.RUnlock()
+	return len(fake.manageCertificatesArgsForCall)
+}
+
+func (fake *FakeManager) ManageCertificatesCalls(stub func(cert.CertificateChanges) (cert.CertificateReport, error)) {
+	fake.manageCertificatesMutex.Lock()
+	defer fake.manageCertificatesMutex.Unlock()
+	fake.ManageCertificatesStub = stub
+}
+
+func (fake *FakeManager) ManageCertificatesArgsForCall(i int) cert.CertificateChanges {
+	fake.manageCertificatesMutex.RLock()
+	defer fake.manageCertificatesMutex.RUnlock()
+	argsForCall := fake.manageCertificatesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeManager) ManageCertificatesReturns(result1 cert.CertificateReport, result2 error) {
+	fake.manageCertificatesMutex.Lock()
+	defer fake.manageCertificatesMutex.Unlock()
+	fake.ManageCertificatesStub = nil
+	fake.manageCertificatesReturns = struct {
+		result1 cert.CertificateReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) ManageCertificatesReturnsOnCall(i int, result1 cert.CertificateReport, result2 error) {
+	fake.manageCertificatesMutex.Lock()
+	defer fake.manageCertificatesMutex.Unlock()
+	fake.ManageCertificatesStub = nil
+	if fake.manageCertificatesReturnsOnCall == nil {
+		fake.manageCertificatesReturnsOnCall = make(map[int]struct {
+			result1 cert.CertificateReport
+			result2 error
+		})
+	}
+	fake.manageCertificatesReturnsOnCall[i] = struct {
+		result1 cert.CertificateReport
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeManager) UpdateCertificates(arg1 string) error {
 	fake.updateCertificatesMutex.Lock()
 	ret, specificReturn := fake.updateCertificatesReturnsOnCall[len(fake.updateCertificatesArgsForCall)]
@@ -87,6 +164,8 @@ func (fake *FakeManager) UpdateCertificatesReturnsOnCall(i int, result1 error) {
 func (fake *FakeManager) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.manageCertificatesMutex.RLock()
+	defer fake.manageCertificatesMutex.RUnlock()
 	fake.updateCertificatesMutex.RLock()
 	defer fake.updateCertificatesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -0,0 +1,161 @@
+package cert
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// TrustedCertificate is a CA certificate the agent added to the trust store.
+// It is identified by the SHA-256 fingerprint of its DER encoding.
+type TrustedCertificate struct {
+	Fingerprint string    `json:"fingerprint"`
+	Subject     string    `json:"subject"`
+	NotAfter    time.Time `json:"not_after"`
+
+	PEM string `json:"-"`
+}
+
+// CertificateChanges add the PEM certificates in Add to the trusted
+// certificates and remove the ones with the SHA-256 fingerprints in Remove.
+// Fingerprints may be given in upper or lower case, with or without colons.
+type CertificateChanges struct {
+	Add    string
+	Remove []string
+}
+
+// CertificateReport lists the certificates that were added and removed, the
+// ones trusted afterwards and the system stores that were regenerated.
+type CertificateReport struct {
+	Added         []TrustedCertificate
+	Removed       []TrustedCertificate
+	Trusted       []TrustedCertificate
+	UpdatedStores []string
+}
+
+// Changed tells whether the changes altered the trusted certificates.
+func (r CertificateReport) Changed() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0
+}
+
+// Bundle concatenates the trusted certificates in the format
+// UpdateCertificates expects.
+func (r CertificateReport) Bundle() string {
+	return strings.Join(certificatePEMs(r.Trusted), "\n")
+}
+
+func certificatePEMs(certs []TrustedCertificate) []string {
+	pems := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		pems = append(pems, cert.PEM)
+	}
+	return pems
+}
+
+// applyCertificateChanges validates the changes against the currently trusted
+// certificates and returns the report of the certificates trusted afterwards.
+// Certificates to add must parse and must not have expired, certificates to
+// remove must be trusted. Adding a trusted certificate again is a no-op.
+func applyCertificateChanges(current []TrustedCertificate, changes CertificateChanges, now time.Time) (CertificateReport, error) {
+	report := CertificateReport{
+		Added:         []TrustedCertificate{},
+		Removed:       []TrustedCertificate{},
+		Trusted:       []TrustedCertificate{},
+		UpdatedStores: []string{},
+	}
+
+	toAdd, err := parseCertificates(changes.Add)
+	if err != nil {
+		return report, bosherr.WrapError(err, "Validating certificates to add")
+	}
+
+	if strings.TrimSpace(changes.Add) != "" && len(toAdd) == 0 {
+		return report, bosherr.Error("Validating certificates to add: no PEM certificates found")
+	}
+
+	for _, cert := range toAdd {
+		if now.After(cert.NotAfter) {
+			return report, bosherr.Errorf("Validating certificates to add: certificate %s (%s) expired at %s",
+				cert.Fingerprint, cert.Subject, cert.NotAfter.Format(time.RFC3339))
+		}
+	}
+
+	toRemove := map[string]bool{}
+	for _, fingerprint := range changes.Remove {
+		toRemove[normalizeFingerprint(fingerprint)] = true
+	}
+
+	trusted := map[string]bool{}
+	for _, cert := range current {
+		if trusted[cert.Fingerprint] {
+			continue
+		}
+		trusted[cert.Fingerprint] = true
+
+		if toRemove[cert.Fingerprint] {
+			report.Removed = append(report.Removed, cert)
+		} else {
+			report.Trusted = append(report.Trusted, cert)
+		}
+	}
+
+	for _, fingerprint := range changes.Remove {
+		if !trusted[normalizeFingerprint(fingerprint)] {
+			return report, bosherr.Errorf("Validating certificates to remove: certificate %s is not trusted", fingerprint)
+		}
+	}
+
+	for _, cert := range toAdd {
+		if toRemove[cert.Fingerprint] {
+			return report, bosherr.Errorf("Validating certificates: certificate %s is both added and removed", cert.Fingerprint)
+		}
+
+		if trusted[cert.Fingerprint] {
+			continue
+		}
+
+		trusted[cert.Fingerprint] = true
+		report.Added = append(report.Added, cert)
+		report.Trusted = append(report.Trusted, cert)
+	}
+
+	return report, nil
+}
+
+// parseCertificates parses each PEM certificate in the given string, see
+// splitCerts for the text that is ignored.
+func parseCertificates(certs string) ([]TrustedCertificate, error) {
+	var result []TrustedCertificate
+
+	for i, certPEM := range splitCerts(certs) {
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil || block.Type != "CERTIFICATE" {
+			return nil, bosherr.Errorf("Decoding PEM certificate %d", i+1)
+		}
+
+		parsed, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing certificate %d", i+1)
+		}
+
+		fingerprint := sha256.Sum256(parsed.Raw)
+
+		result = append(result, TrustedCertificate{
+			Fingerprint: hex.EncodeToString(fingerprint[:]),
+			Subject:     parsed.Subject.String(),
+			NotAfter:    parsed.NotAfter.UTC(),
+			PEM:         strings.TrimSpace(certPEM),
+		})
+	}
+
+	return result, nil
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}
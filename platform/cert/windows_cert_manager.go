@@ -7,6 +7,7 @@ import (
 	"path"
 	"strconv"
 
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
@@ -106,3 +107,9 @@ func (c *windowsCertManager) UpdateCertificates(rawCerts string) error {
 	}
 	return nil
 }
+
+// ManageCertificates is not supported on Windows, where the agent does not
+// keep track of which certificates of the root store it added.
+func (c *windowsCertManager) ManageCertificates(_ CertificateChanges) (CertificateReport, error) {
+	return CertificateReport{}, bosherr.Error("Managing individual certificates is not supported on Windows")
+}
@@ -36,6 +36,19 @@ type FakeMounter struct {
 		result1 bool
 		result2 error
 	}
+	LazyUnmountStub        func(string) (bool, error)
+	lazyUnmountMutex       sync.RWMutex
+	lazyUnmountArgsForCall []struct {
+		arg1 string
+	}
+	lazyUnmountReturns struct {
+		result1 bool
+		result2 error
+	}
+	lazyUnmountReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
 	MountStub        func(string, string, ...string) error
 	mountMutex       sync.RWMutex
 	mountArgsForCall []struct {
@@ -270,6 +283,70 @@ func (fake *FakeMounter) IsMountedReturnsOnCall(i int, result1 bool, result2 err
 	}{result1, result2}
 }
 
+func (fake *FakeMounter) LazyUnmount(arg1 string) (bool, error) {
+	fake.lazyUnmountMutex.Lock()
+	ret, specificReturn := fake.lazyUnmountReturnsOnCall[len(fake.lazyUnmountArgsForCall)]
+	fake.lazyUnmountArgsForCall = append(fake.lazyUnmountArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.LazyUnmountStub
+	fakeReturns := fake.lazyUnmountReturns
+	fake.recordInvocation("LazyUnmount", []interface{}{arg1})
+	fake.lazyUnmountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMounter) LazyUnmountCallCount() int {
+	fake.lazyUnmountMutex.RLock()
+	defer fake.lazyUnmountMutex.RUnlock()
+	return len(fake.lazyUnmountArgsForCall)
+}
+
+func (fake *FakeMounter) LazyUnmountCalls(stub func(string) (bool, error)) {
+	fake.lazyUnmountMutex.Lock()
+	defer fake.lazyUnmountMutex.Unlock()
+	fake.LazyUnmountStub = stub
+}
+
+func (fake *FakeMounter) LazyUnmountArgsForCall(i int) string {
+	fake.lazyUnmountMutex.RLock()
+	defer fake.lazyUnmountMutex.RUnlock()
+	argsForCall := fake.lazyUnmountArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMounter) LazyUnmountReturns(result1 bool, result2 error) {
+	fake.lazyUnmountMutex.Lock()
+	defer fake.lazyUnmountMutex.Unlock()
+	fake.LazyUnmountStub = nil
+	fake.lazyUnmountReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMounter) LazyUnmountReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.lazyUnmountMutex.Lock()
+	defer fake.lazyUnmountMutex.Unlock()
+	fake.LazyUnmountStub = nil
+	if fake.lazyUnmountReturnsOnCall == nil {
+		fake.lazyUnmountReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.lazyUnmountReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeMounter) Mount(arg1 string, arg2 string, arg3 ...string) error {
 	fake.mountMutex.Lock()
 	ret, specificReturn := fake.mountReturnsOnCall[len(fake.mountArgsForCall)]
@@ -777,6 +854,8 @@ func (fake *FakeMounter) Invocations() map[string][][]interface{} {
 	defer fake.isMountPointMutex.RUnlock()
 	fake.isMountedMutex.RLock()
 	defer fake.isMountedMutex.RUnlock()
+	fake.lazyUnmountMutex.RLock()
+	defer fake.lazyUnmountMutex.RUnlock()
 	fake.mountMutex.RLock()
 	defer fake.mountMutex.RUnlock()
 	fake.mountFilesystemMutex.RLock()
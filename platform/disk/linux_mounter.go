@@ -132,6 +132,20 @@ func (m linuxMounter) Unmount(partitionOrMountPoint string) (bool, error) {
 	return err == nil, err
 }
 
+func (m linuxMounter) LazyUnmount(partitionOrMountPoint string) (bool, error) {
+	isMounted, err := m.IsMounted(partitionOrMountPoint)
+	if err != nil || !isMounted {
+		return false, err
+	}
+
+	_, _, _, err = m.runner.RunCommand("umount", "--lazy", partitionOrMountPoint)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Shelling out to umount")
+	}
+
+	return true, nil
+}
+
 func (m linuxMounter) IsMountPoint(path string) (string, bool, error) {
 	mounts, err := m.mountsSearcher.SearchMounts()
 	if err != nil {
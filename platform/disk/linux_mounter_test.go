@@ -371,6 +371,40 @@ var _ = Describe("linuxMounter", func() {
 		})
 	})
 
+	Describe("LazyUnmount", func() {
+		BeforeEach(func() {
+			mountsSearcher.SearchMountsMounts = []Mount{
+				Mount{PartitionPath: "/dev/xvdb2", MountPoint: "/var/vcap/data"},
+			}
+		})
+
+		It("unmounts lazily when partition is mounted", func() {
+			didUnmount, err := mounter.LazyUnmount("/dev/xvdb2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(didUnmount).To(BeTrue())
+
+			Expect(runner.RunCommands).To(Equal([][]string{{"umount", "--lazy", "/dev/xvdb2"}}))
+		})
+
+		It("returns without an error indicating that nothing was unmounted when partition or mount point is not mounted", func() {
+			didUnmount, err := mounter.LazyUnmount("/dev/xvdb3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(didUnmount).To(BeFalse())
+
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns error without retrying when unmounting fails", func() {
+			runner.AddCmdResult("umount --lazy /dev/xvdb2", fakesys.FakeCmdResult{Error: errors.New("fake-error")})
+
+			didUnmount, err := mounter.LazyUnmount("/dev/xvdb2")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-error"))
+			Expect(didUnmount).To(BeFalse())
+			Expect(runner.RunCommands).To(HaveLen(1))
+		})
+	})
+
 	Describe("IsMountPoint", func() {
 		Context("when it is a mount point", func() {
 			It("is mount point", func() {
@@ -7,6 +7,8 @@ type Mounter interface {
 	MountTmpfs(mountPoint string, size string) (err error)
 	MountFilesystem(partitionPath, mountPoint, fstype string, mountOptions ...string) (err error)
 	Unmount(partitionOrMountPoint string) (didUnmount bool, err error)
+	// LazyUnmount detaches the filesystem right away even if it is busy
+	LazyUnmount(partitionOrMountPoint string) (didUnmount bool, err error)
 
 	RemountAsReadonly(mountPoint string) (err error)
 	Remount(fromMountPoint, toMountPoint string, mountOptions ...string) (err error)
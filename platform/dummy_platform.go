@@ -13,6 +13,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	boshlogstarprovider "github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/v2/platform/cert"
 	boship "github.com/cloudfoundry/bosh-agent/v2/platform/net/ip"
@@ -151,6 +152,10 @@ func (p dummyPlatform) SetUserPassword(user, encryptedPwd string) (err error) {
 	return p.fs.WriteFileString(credentialsPath, encryptedPwd)
 }
 
+func (p dummyPlatform) SetAccountPolicy(policy boshsettings.AccountPolicy) (err error) {
+	return
+}
+
 func (p dummyPlatform) SaveDNSRecords(dnsRecords boshsettings.DNSRecords, hostname string) (err error) {
 	etcHostsPath := filepath.Join(p.dirProvider.BoshDir(), EtcHostsFileName)
 
@@ -210,6 +215,10 @@ func (p dummyPlatform) SetupLogrotate(groupName, basePath, size string) (err err
 	return
 }
 
+func (p dummyPlatform) RotateLogs(groupName string, truncate bool) (err error) {
+	return
+}
+
 func (p dummyPlatform) SetTimeWithNtpServers(servers []string) (err error) {
 	return
 }
@@ -275,6 +284,15 @@ func (p dummyPlatform) SetupBlobsDir() error {
 	return nil
 }
 
+func (p dummyPlatform) SetupCoreDumpsDir() error {
+	coreDumpsDir := p.dirProvider.CoreDumpsDir()
+	if err := p.fs.MkdirAll(coreDumpsDir, blobsDirPermissions); err != nil {
+		return bosherr.WrapErrorf(err, "Making %s dir", coreDumpsDir)
+	}
+
+	return nil
+}
+
 func (p dummyPlatform) SetupLoggingAndAuditing() error {
 	return nil
 }
@@ -338,10 +356,10 @@ func (p dummyPlatform) MountPersistentDisk(diskSettings boshsettings.DiskSetting
 	return p.fs.WriteFile(p.mountsPath(), mountsJSON)
 }
 
-func (p dummyPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error) {
+func (p dummyPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings, options UnmountOptions) (UnmountReport, error) {
 	mounts, err := p.existingMounts()
 	if err != nil {
-		return false, err
+		return UnmountReport{}, err
 	}
 
 	var updatedMounts []mount
@@ -353,21 +371,33 @@ func (p dummyPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSetti
 
 	updatedMountsJSON, err := json.Marshal(updatedMounts)
 	if err != nil {
-		return false, err
+		return UnmountReport{}, err
 	}
 
 	err = p.fs.WriteFile(p.mountsPath(), updatedMountsJSON)
 	if err != nil {
-		return false, err
+		return UnmountReport{}, err
 	}
 
-	return true, nil
+	return UnmountReport{DidUnmount: true}, nil
 }
 
 func (p dummyPlatform) GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) (string, error) {
 	return "/dev/sdb", nil
 }
 
+func (p dummyPlatform) FreezeFilesystem(mountPoint string) (err error) {
+	return
+}
+
+func (p dummyPlatform) ThawFilesystem(mountPoint string) (err error) {
+	return
+}
+
+func (p dummyPlatform) GrowEphemeralDisk(devicePath string) (grown bool, err error) {
+	return
+}
+
 func (p dummyPlatform) GetFileContentsFromCDROM(filePath string) (contents []byte, err error) {
 	return
 }
@@ -376,7 +406,7 @@ func (p dummyPlatform) GetFilesContentsFromDisk(diskPath string, fileNames []str
 	return
 }
 
-func (p dummyPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error) {
+func (p dummyPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string, progress MigrationProgressFunc, cancel *boshtask.CancelSignal) (err error) {
 	diskMigrationsPath := filepath.Join(p.dirProvider.BoshDir(), "disk_migrations.json")
 	var diskMigrations []diskMigration
 	if p.fs.FileExists(diskMigrationsPath) {
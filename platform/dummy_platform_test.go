@@ -166,9 +166,9 @@ var _ = Describe("DummyPlatform", func() {
 			})
 
 			It("removes one of the disks from the mounts json", func() {
-				unmounted, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{ID: "cid1"})
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{ID: "cid1"}, UnmountOptions{})
 				Expect(err).NotTo(HaveOccurred())
-				Expect(unmounted).To(Equal(true))
+				Expect(report.DidUnmount).To(Equal(true))
 
 				_, isMountPoint, err := platform.IsMountPoint("dir1")
 				Expect(err).NotTo(HaveOccurred())
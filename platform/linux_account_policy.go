@@ -0,0 +1,251 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
+)
+
+const (
+	faillockConfPath            = "/etc/security/faillock.conf"
+	sshdAccountPolicyConfigPath = "/etc/ssh/sshd_config.d/00-bosh-account-policy.conf"
+	sshdPidPath                 = "/run/sshd.pid"
+
+	accountPolicyFilePermissions = os.FileMode(0644)
+)
+
+func (p linux) SetAccountPolicy(policy boshsettings.AccountPolicy) error {
+	users := make([]string, 0, len(policy.PasswordHashes))
+	for user := range policy.PasswordHashes {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	for _, user := range users {
+		err := p.rotatePasswordHash(user, policy.PasswordHashes[user])
+		p.auditAccountPolicy("password_rotation", user, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	if policy.LockoutThreshold > 0 {
+		err := p.setLockoutPolicy(policy.LockoutThreshold, policy.LockoutUnlockTime)
+		p.auditAccountPolicy("lockout_policy", "", err)
+		if err != nil {
+			return err
+		}
+	}
+
+	if policy.SSHPasswordAuthentication != nil {
+		err := p.setSSHPasswordAuthentication(*policy.SSHPasswordAuthentication)
+		p.auditAccountPolicy("ssh_password_authentication", "", err)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p linux) rotatePasswordHash(user, hash string) error {
+	if user != boshsettings.VCAPUsername && user != boshsettings.RootUsername {
+		return bosherr.Errorf("Account policy cannot manage user '%s'", user)
+	}
+
+	if !strings.HasPrefix(hash, "$") && hash != "*" && !strings.HasPrefix(hash, "!") {
+		return bosherr.Errorf("Password hash for user '%s' is not a crypt(3) hash", user)
+	}
+
+	if err := p.SetUserPassword(user, hash); err != nil {
+		return bosherr.WrapErrorf(err, "Rotating password hash for user '%s'", user)
+	}
+
+	shadow, err := p.fs.ReadFileString("/etc/shadow")
+	if err != nil {
+		return bosherr.WrapError(err, "Reading /etc/shadow")
+	}
+
+	for _, line := range strings.Split(shadow, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) > 1 && fields[0] == user {
+			if fields[1] != hash {
+				return bosherr.Errorf("Verifying password hash for user '%s': hash was not applied", user)
+			}
+			return nil
+		}
+	}
+
+	return bosherr.Errorf("Verifying password hash for user '%s': user not found in /etc/shadow", user)
+}
+
+// setLockoutPolicy only sets the deny and unlock_time keys of faillock.conf;
+// pam_faillock reads no drop-in files, so the rest of the file is left as is.
+func (p linux) setLockoutPolicy(threshold, unlockTime int) error {
+	values := [][2]string{
+		{"deny", strconv.Itoa(threshold)},
+		{"unlock_time", strconv.Itoa(unlockTime)},
+	}
+
+	var existing string
+	if p.fs.FileExists(faillockConfPath) {
+		var err error
+		existing, err = p.fs.ReadFileString(faillockConfPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading %s", faillockConfPath)
+		}
+	}
+
+	err := p.fs.WriteFileString(faillockConfPath, setConfValues(existing, values))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", faillockConfPath)
+	}
+
+	err = p.fs.Chmod(faillockConfPath, accountPolicyFilePermissions)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Chmoding %s", faillockConfPath)
+	}
+
+	written, err := p.fs.ReadFileString(faillockConfPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading %s", faillockConfPath)
+	}
+
+	for _, kv := range values {
+		if confValue(written, kv[0]) != kv[1] {
+			return bosherr.Errorf("Verifying %s: %s was not applied", faillockConfPath, kv[0])
+		}
+	}
+
+	return nil
+}
+
+// setConfValues replaces the active "key = value" lines of a faillock.conf
+// style file and appends the keys that are not set yet.
+func setConfValues(contents string, values [][2]string) string {
+	lines := strings.Split(strings.TrimSuffix(contents, "\n"), "\n")
+	if contents == "" {
+		lines = nil
+	}
+
+	set := map[string]bool{}
+	for i, line := range lines {
+		key := confKey(line)
+		for _, kv := range values {
+			if key == kv[0] {
+				lines[i] = fmt.Sprintf("%s = %s", kv[0], kv[1])
+				set[kv[0]] = true
+			}
+		}
+	}
+
+	for _, kv := range values {
+		if !set[kv[0]] {
+			lines = append(lines, fmt.Sprintf("%s = %s", kv[0], kv[1]))
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// confValue returns the value of the last active line setting key.
+func confValue(contents, key string) string {
+	var value string
+	for _, line := range strings.Split(contents, "\n") {
+		if confKey(line) == key {
+			_, v, _ := strings.Cut(line, "=")
+			value = strings.TrimSpace(v)
+		}
+	}
+	return value
+}
+
+func confKey(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#") {
+		return ""
+	}
+	key, _, found := strings.Cut(line, "=")
+	if !found {
+		return strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(key)
+}
+
+func (p linux) setSSHPasswordAuthentication(enabled bool) error {
+	value := "no"
+	if enabled {
+		value = "yes"
+	}
+
+	err := p.fs.WriteFileString(sshdAccountPolicyConfigPath, fmt.Sprintf("# Generated by bosh-agent\nPasswordAuthentication %s\n", value))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", sshdAccountPolicyConfigPath)
+	}
+
+	err = p.fs.Chmod(sshdAccountPolicyConfigPath, accountPolicyFilePermissions)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Chmoding %s", sshdAccountPolicyConfigPath)
+	}
+
+	// Never leave sshd with a configuration it refuses to start with
+	_, stderr, _, err := p.cmdRunner.RunCommand("sshd", "-t")
+	if err != nil {
+		p.fs.RemoveAll(sshdAccountPolicyConfigPath) //nolint:errcheck
+		return bosherr.WrapErrorf(err, "Validating sshd configuration: %s", stderr)
+	}
+
+	if p.fs.FileExists(sshdPidPath) {
+		pid, err := p.fs.ReadFileString(sshdPidPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading %s", sshdPidPath)
+		}
+
+		_, _, _, err = p.cmdRunner.RunCommand("kill", "-HUP", strings.TrimSpace(pid))
+		if err != nil {
+			return bosherr.WrapError(err, "Reloading sshd")
+		}
+	}
+
+	stdout, _, _, err := p.cmdRunner.RunCommand("sshd", "-T")
+	if err != nil {
+		return bosherr.WrapError(err, "Reading effective sshd configuration")
+	}
+
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.TrimSpace(line) == "passwordauthentication "+value {
+			return nil
+		}
+	}
+
+	return bosherr.Errorf("Verifying sshd configuration: PasswordAuthentication is not '%s'", value)
+}
+
+func (p linux) auditAccountPolicy(event, user string, err error) {
+	severity := 1
+	if err != nil {
+		severity = 7
+	}
+
+	hostname, _ := os.Hostname()
+
+	extension := fmt.Sprintf("duser=%s shost=%s ", user, hostname)
+	if err != nil {
+		extension += fmt.Sprintf("cs1=%s cs1Label=statusReason", err.Error())
+	}
+
+	cefString := fmt.Sprintf("CEF:0|CloudFoundry|BOSH|1|agent_account_policy|%s|%d|%s", event, severity, extension)
+
+	if err != nil {
+		p.auditLogger.Err(cefString)
+		return
+	}
+
+	p.auditLogger.Debug(cefString)
+}
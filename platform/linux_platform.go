@@ -18,7 +18,9 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/coredump"
 	boshlogstarprovider "github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/cdrom"
 	boshcert "github.com/cloudfoundry/bosh-agent/v2/platform/cert"
@@ -30,6 +32,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/v2/servicemanager"
 	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
+	"time"
 )
 
 const (
@@ -46,11 +49,14 @@ const (
 	userRootOptDirPermissions = os.FileMode(0755)
 	tmpDirPermissions         = os.FileMode(0755) // 0755 to make sure that vcap user can use new temp dir
 	blobsDirPermissions       = os.FileMode(0700)
+	coreDumpsDirPermissions   = os.FileMode(0733)
 
 	sshDirPermissions          = os.FileMode(0700)
 	sshAuthKeysFilePermissions = os.FileMode(0600)
 
 	minRootEphemeralSpaceInBytes = uint64(1024 * 1024 * 1024)
+
+	migrationKillGracePeriod = 10 * time.Second
 )
 
 type LinuxOptions struct {
@@ -319,6 +325,10 @@ func (p linux) DeleteEphemeralUsersMatching(reg string) error {
 }
 
 func (p linux) deleteUser(user string) (err error) {
+	// Sessions of the user would outlive it otherwise. pkill fails when
+	// the user has no processes.
+	_, _, _, _ = p.cmdRunner.RunCommand("pkill", "-KILL", "-u", user) //nolint:errcheck
+
 	_, _, _, err = p.cmdRunner.RunCommand("userdel", "-rf", user)
 	return
 }
@@ -600,6 +610,48 @@ func (p linux) SetupLogrotate(groupName, basePath, size string) (err error) {
 	return
 }
 
+func (p linux) RotateLogs(groupName string, truncate bool) error {
+	if truncate {
+		return p.truncateLogs()
+	}
+
+	configPath := path.Join("/etc/logrotate.d", groupName)
+
+	_, stderr, _, err := p.cmdRunner.RunCommand("logrotate", "--force", configPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Rotating logs: %s", stderr)
+	}
+
+	return nil
+}
+
+// truncateLogs empties the logs matched by the logrotate config. Like with
+// copytruncate, processes keep writing to the same files.
+func (p linux) truncateLogs() error {
+	logDir := path.Join(p.dirProvider.DataDir(), "sys", "log")
+
+	for _, pattern := range []string{"*.log", ".*.log", "*/*.log", "*/.*.log", "*/*/*.log", "*/*/.*.log"} {
+		logPaths, err := p.fs.Glob(path.Join(logDir, pattern))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Globbing logs '%s'", pattern)
+		}
+
+		for _, logPath := range logPaths {
+			file, err := p.fs.OpenFile(logPath, os.O_WRONLY|os.O_TRUNC, 0)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Truncating log '%s'", logPath)
+			}
+
+			err = file.Close()
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Truncating log '%s'", logPath)
+			}
+		}
+	}
+
+	return nil
+}
+
 // Logrotate config file - /etc/logrotate.d/<group-name>
 // Stemcell stage logrotate_config configures logrotate to run every hour
 const etcLogrotateDTemplate = `# Generated by bosh-agent
@@ -927,6 +979,33 @@ func (p linux) SetupBlobsDir() error {
 	return nil
 }
 
+// SetupCoreDumpsDir makes the kernel write core dumps of crashed processes
+// to the core dumps dir, named after the executable that dumped core
+func (p linux) SetupCoreDumpsDir() error {
+	const corePatternPath = "/proc/sys/kernel/core_pattern"
+
+	coreDumpsDir := p.dirProvider.CoreDumpsDir()
+
+	err := p.fs.MkdirAll(coreDumpsDir, coreDumpsDirPermissions)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating core dumps dir")
+	}
+
+	// Core dumps are written as the user the crashed process ran as, who
+	// should not be able to read or remove the core dumps of others
+	_, _, _, err = p.cmdRunner.RunCommand("chmod", "1733", coreDumpsDir)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "chmod %s", coreDumpsDir)
+	}
+
+	err = p.fs.WriteFileString(corePatternPath, path.Join(coreDumpsDir, coredump.CorePattern))
+	if err != nil {
+		return bosherr.WrapError(err, "Setting kernel core pattern")
+	}
+
+	return nil
+}
+
 func (p linux) SetupCanRestartDir() error {
 	canRebootDir := p.dirProvider.CanRestartDir()
 
@@ -1349,22 +1428,121 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 	return nil
 }
 
-func (p linux) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (bool, error) {
-	p.logger.Debug(logTag, "Unmounting persistent disk %+v", diskSettings)
+func (p linux) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings, options UnmountOptions) (UnmountReport, error) {
+	p.logger.Debug(logTag, "Unmounting persistent disk %+v with options %+v", diskSettings, options)
 
 	realPath, timedOut, err := p.devicePathResolver.GetRealDevicePath(diskSettings)
 	if timedOut {
-		return false, nil
+		return UnmountReport{}, nil
 	}
 	if err != nil {
-		return false, bosherr.WrapError(err, "Getting real device path")
+		return UnmountReport{}, bosherr.WrapError(err, "Getting real device path")
 	}
 
 	if !p.options.UsePreformattedPersistentDisk {
 		realPath = p.partitionPath(realPath, 1)
 	}
 
-	return p.diskManager.GetMounter().Unmount(realPath)
+	mounter := p.diskManager.GetMounter()
+	report := UnmountReport{}
+
+	if options.Force || options.Lazy { //nolint:nestif
+		isMounted, err := mounter.IsMounted(realPath)
+		if err != nil || !isMounted {
+			return report, err
+		}
+
+		holders, err := p.findMountHolders(realPath)
+		if err != nil {
+			return report, err
+		}
+
+		if options.Force && len(holders) > 0 {
+			err = p.killMountHolders(holders)
+			if err != nil {
+				return report, err
+			}
+
+			report.KilledProcesses = holders
+
+			holders, err = p.findMountHolders(realPath)
+			if err != nil {
+				return report, err
+			}
+		}
+
+		if options.Lazy && len(holders) > 0 {
+			report.BlockingProcesses = holders
+			report.DidUnmount, err = mounter.LazyUnmount(realPath)
+			report.Lazy = report.DidUnmount
+			return report, err
+		}
+	}
+
+	report.DidUnmount, err = mounter.Unmount(realPath)
+	if err != nil {
+		// Only best effort, the processes are reported to explain the failure
+		holders, _ := p.findMountHolders(realPath) //nolint:errcheck
+		if len(holders) > 0 {
+			report.BlockingProcesses = holders
+			return report, bosherr.WrapErrorf(err, "Unmounting %s held by processes %s", realPath, describeMountHolders(holders))
+		}
+
+		return report, err
+	}
+
+	return report, nil
+}
+
+// findMountHolders lists the processes using the filesystem mounted from
+// partitionPath
+func (p linux) findMountHolders(partitionPath string) ([]MountHolder, error) {
+	stdout, _, exitStatus, err := p.cmdRunner.RunCommand("fuser", "-m", partitionPath)
+	if err != nil {
+		// fuser exits with 1 when no process uses the filesystem
+		if exitStatus == 1 && strings.TrimSpace(stdout) == "" {
+			return nil, nil
+		}
+
+		return nil, bosherr.WrapErrorf(err, "Finding processes using %s", partitionPath)
+	}
+
+	var holders []MountHolder
+
+	// PIDs are followed by letters telling how the process uses the filesystem
+	for _, field := range strings.Fields(stdout) {
+		pid, err := strconv.Atoi(strings.TrimRight(field, "cefFrm"))
+		if err != nil {
+			continue
+		}
+
+		holder := MountHolder{PID: pid}
+
+		comm, err := p.fs.ReadFileString(fmt.Sprintf("/proc/%d/comm", pid))
+		if err == nil {
+			holder.Command = strings.TrimSpace(comm)
+		}
+
+		holders = append(holders, holder)
+	}
+
+	return holders, nil
+}
+
+func (p linux) killMountHolders(holders []MountHolder) error {
+	args := []string{"-s", "KILL"}
+	for _, holder := range holders {
+		args = append(args, strconv.Itoa(holder.PID))
+	}
+
+	p.logger.Warn(logTag, "Killing processes using the persistent disk: %s", describeMountHolders(holders))
+
+	_, _, _, err := p.cmdRunner.RunCommand("kill", args...)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Killing processes %s", describeMountHolders(holders))
+	}
+
+	return nil
 }
 
 func (p linux) GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) (string, error) {
@@ -1377,6 +1555,74 @@ func (p linux) GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) (str
 	return realPath, nil
 }
 
+func (p linux) FreezeFilesystem(mountPoint string) error {
+	_, _, _, err := p.cmdRunner.RunCommand("fsfreeze", "--freeze", mountPoint)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Freezing filesystem at %s", mountPoint)
+	}
+
+	return nil
+}
+
+func (p linux) ThawFilesystem(mountPoint string) error {
+	_, _, _, err := p.cmdRunner.RunCommand("fsfreeze", "--unfreeze", mountPoint)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Thawing filesystem at %s", mountPoint)
+	}
+
+	return nil
+}
+
+func (p linux) GrowEphemeralDisk(devicePath string) (bool, error) {
+	if p.options.SkipDiskSetup {
+		return false, nil
+	}
+
+	if devicePath == "" {
+		return false, bosherr.Error("Growing ephemeral partitions on the root device is not supported")
+	}
+
+	if !p.cmdRunner.CommandExists("growpart") {
+		return false, bosherr.Error("The program 'growpart' is not installed, Ephemeral Filesystem cannot be grown")
+	}
+
+	partitions, _, err := p.diskManager.GetEphemeralDevicePartitioner().GetPartitions(devicePath)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Getting partitions of `%s'", devicePath)
+	}
+
+	// The data partition follows the optional swap partition, so it is the
+	// only one that can grow into space added at the end of the disk
+	if len(partitions) == 0 || partitions[len(partitions)-1].Type != boshdisk.PartitionTypeLinux {
+		return false, bosherr.Errorf("Ephemeral disk `%s' does not end with a data partition", devicePath)
+	}
+
+	dataPartitionNumber := partitions[len(partitions)-1].Index
+
+	p.logger.Info(logTag, "Growing partition %d of ephemeral disk `%s'", dataPartitionNumber, devicePath)
+
+	stdout, _, _, err := p.cmdRunner.RunCommand("growpart", devicePath, strconv.Itoa(dataPartitionNumber))
+	if err != nil {
+		if strings.Contains(stdout, "NOCHANGE") {
+			p.logger.Info(logTag, "Ephemeral disk `%s' has no space to grow into", devicePath)
+			return false, nil
+		}
+		return false, bosherr.WrapError(err, "growpart")
+	}
+
+	canonicalDataPartitionPath, err := resolveCanonicalLink(p.cmdRunner, p.partitionPath(devicePath, dataPartitionNumber))
+	if err != nil {
+		return false, err
+	}
+
+	err = p.diskManager.GetFormatter().GrowFilesystem(canonicalDataPartitionPath)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Growing ephemeral filesystem")
+	}
+
+	return true, nil
+}
+
 func (p linux) IsPersistentDiskMountable(diskSettings boshsettings.DiskSettings) (bool, error) {
 	realPath, _, err := p.devicePathResolver.GetRealDevicePath(diskSettings)
 	if err != nil {
@@ -1396,7 +1642,7 @@ func (p linux) IsMountPoint(path string) (string, bool, error) {
 	return p.diskManager.GetMounter().IsMountPoint(path)
 }
 
-func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error {
+func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string, progress MigrationProgressFunc, cancel *boshtask.CancelSignal) error {
 	p.logger.Debug(logTag, "Migrating persistent disk %v to %v", fromMountPoint, toMountPoint)
 
 	err := p.diskManager.GetMounter().RemountAsReadonly(fromMountPoint)
@@ -1404,11 +1650,17 @@ func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error
 		return bosherr.WrapError(err, "Remounting persistent disk as readonly")
 	}
 
-	// Golang does not implement a file copy that would allow us to preserve dates...
-	// So we have to shell out to tar to perform the copy instead of delegating to the FileSystem
-	// The --xattrs and --xattrs-include=*.* flags ensure that all extended attributes (ex. capabilities) are preserved
-	tarCopy := fmt.Sprintf("(tar -C %s --xattrs --xattrs-include=*.* --sparse -cf - .) | (tar -C %s --xattrs --xattrs-include=*.* -xpf -)", fromMountPoint, toMountPoint)
-	_, _, _, err = p.cmdRunner.RunCommand("sh", "-c", tarCopy)
+	err = p.copyPersistentDisk(fromMountPoint, toMountPoint, progress, cancel)
+	if err == boshtask.ErrCancelled {
+		// What was copied stays on the new disk for the next migration
+		remountErr := p.diskManager.GetMounter().Remount(fromMountPoint, fromMountPoint)
+		if remountErr != nil {
+			return bosherr.WrapError(remountErr, "Remounting persistent disk as writable after cancelling the migration")
+		}
+
+		return err
+	}
+
 	if err != nil {
 		return bosherr.WrapError(err, "Copying files from old disk to new disk")
 	}
@@ -1452,6 +1704,62 @@ func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error
 	return err
 }
 
+// copyPersistentDisk copies with rsync when it is installed. rsync skips files
+// that were already copied and keeps partially copied ones, so a copy that was
+// interrupted continues where it stopped when it runs again. Only the rsync
+// copy can be cancelled once it started.
+func (p linux) copyPersistentDisk(fromMountPoint, toMountPoint string, progress MigrationProgressFunc, cancel *boshtask.CancelSignal) error {
+	err := cancel.Err()
+	if err != nil {
+		return err
+	}
+
+	if !p.cmdRunner.CommandExists("rsync") {
+		p.logger.Info(logTag, "The program 'rsync' is not installed, copying the whole persistent disk without progress")
+
+		// Golang does not implement a file copy that would allow us to preserve dates...
+		// So we have to shell out to tar to perform the copy instead of delegating to the FileSystem
+		// The --xattrs and --xattrs-include=*.* flags ensure that all extended attributes (ex. capabilities) are preserved
+		tarCopy := fmt.Sprintf("(tar -C %s --xattrs --xattrs-include=*.* --sparse -cf - .) | (tar -C %s --xattrs --xattrs-include=*.* -xpf -)", fromMountPoint, toMountPoint)
+		_, _, _, err := p.cmdRunner.RunCommand("sh", "-c", tarCopy)
+		return err
+	}
+
+	// --archive, --hard-links, --acls and --xattrs preserve what tar did,
+	// --no-inc-recursive makes rsync count all files up front so that the
+	// progress percentage does not jump back
+	process, err := p.cmdRunner.RunComplexCommandAsync(boshsys.Command{
+		Name: "rsync",
+		Args: []string{
+			"--archive", "--hard-links", "--acls", "--xattrs", "--sparse",
+			"--partial-dir=.rsync-partial",
+			"--info=progress2", "--no-inc-recursive",
+			fromMountPoint + "/", toMountPoint + "/",
+		},
+		Stdout: newRsyncProgressWriter(progress),
+	})
+	if err != nil {
+		return err
+	}
+
+	processExitedCh := process.Wait()
+
+	select {
+	case result := <-processExitedCh:
+		return result.Error
+	case <-cancel.Done():
+	}
+
+	err = process.TerminateNicely(migrationKillGracePeriod)
+	if err != nil {
+		p.logger.Error(logTag, "Failed to terminate rsync: %s", err.Error())
+	}
+
+	<-processExitedCh
+
+	return boshtask.ErrCancelled
+}
+
 func (p linux) IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (bool, error) {
 	p.logger.Debug(logTag, "Checking whether persistent disk %+v is mounted", diskSettings)
 	realPath, timedOut, err := p.devicePathResolver.GetRealDevicePath(diskSettings)
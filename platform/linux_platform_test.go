@@ -23,6 +23,7 @@ import (
 	fakeuuidgen "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 
 	fakelogstarprovider "github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider/logstarproviderfakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	fakedpresolv "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver/fakes"
 	. "github.com/cloudfoundry/bosh-agent/v2/platform"
 	fakecdrom "github.com/cloudfoundry/bosh-agent/v2/platform/cdrom/fakes"
@@ -40,6 +41,19 @@ import (
 	boshdirs "github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
 
+// outputCmdRunner writes output to the commands it starts, which the fake
+// runner only does for synchronous commands
+type outputCmdRunner struct {
+	*fakesys.FakeCmdRunner
+
+	stdout string
+}
+
+func (r *outputCmdRunner) RunComplexCommandAsync(cmd boshsys.Command) (boshsys.Process, error) {
+	cmd.Stdout.Write([]byte(r.stdout)) //nolint:errcheck
+	return r.FakeCmdRunner.RunComplexCommandAsync(cmd)
+}
+
 var _ = Describe("LinuxPlatform", func() {
 	var (
 		collector                  *fakestats.FakeCollector
@@ -234,9 +248,23 @@ bosh_foobar:...`
 
 			err = platform.DeleteEphemeralUsersMatching("bar$")
 			Expect(err).NotTo(HaveOccurred())
-			Expect(len(cmdRunner.RunCommands)).To(Equal(2))
-			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"userdel", "-rf", "bosh_bar"}))
-			Expect(cmdRunner.RunCommands[1]).To(Equal([]string{"userdel", "-rf", "bosh_foobar"}))
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"pkill", "-KILL", "-u", "bosh_bar"},
+				{"userdel", "-rf", "bosh_bar"},
+				{"pkill", "-KILL", "-u", "bosh_foobar"},
+				{"userdel", "-rf", "bosh_foobar"},
+			}))
+		})
+
+		It("deletes users without processes", func() {
+			err := fs.WriteFileString("/etc/passwd", "bosh_foo:...")
+			Expect(err).NotTo(HaveOccurred())
+
+			cmdRunner.AddCmdResult("pkill -KILL -u bosh_foo", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("fake-pkill-err")})
+
+			err = platform.DeleteEphemeralUsersMatching("foo$")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmdRunner.RunCommands[1]).To(Equal([]string{"userdel", "-rf", "bosh_foo"}))
 		})
 	})
 
@@ -821,6 +849,119 @@ bosh_foobar:...`
 		})
 	})
 
+	Describe("SetAccountPolicy", func() {
+		Context("when rotating password hashes", func() {
+			BeforeEach(func() {
+				err := fs.WriteFileString("/etc/shadow", "root:$6$new-root:19000:0:99999:7:::\nvcap:$6$new-vcap:19000:0:99999:7:::\n")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("sets and verifies the hash for each user", func() {
+				err := platform.SetAccountPolicy(boshsettings.AccountPolicy{
+					PasswordHashes: map[string]string{"vcap": "$6$new-vcap", "root": "$6$new-root"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cmdRunner.RunCommands).To(Equal([][]string{
+					{"usermod", "-p", "$6$new-root", "root"},
+					{"usermod", "-p", "$6$new-vcap", "vcap"},
+				}))
+				Expect(fakeAuditLogger.GetDebugMsgs()).To(HaveLen(2))
+				Expect(fakeAuditLogger.GetDebugMsgs()[0]).To(ContainSubstring("|agent_account_policy|password_rotation|1|duser=root "))
+			})
+
+			It("returns an error when the hash was not applied", func() {
+				err := platform.SetAccountPolicy(boshsettings.AccountPolicy{
+					PasswordHashes: map[string]string{"vcap": "$6$other"},
+				})
+				Expect(err).To(MatchError(ContainSubstring("hash was not applied")))
+				Expect(fakeAuditLogger.GetErrMsgs()).To(HaveLen(1))
+				Expect(fakeAuditLogger.GetErrMsgs()[0]).To(ContainSubstring("|password_rotation|7|duser=vcap "))
+			})
+
+			It("refuses to manage other users", func() {
+				err := platform.SetAccountPolicy(boshsettings.AccountPolicy{
+					PasswordHashes: map[string]string{"bosh_abc": "$6$hash"},
+				})
+				Expect(err).To(MatchError(ContainSubstring("cannot manage user 'bosh_abc'")))
+				Expect(cmdRunner.RunCommands).To(BeEmpty())
+			})
+
+			It("refuses plain text passwords", func() {
+				err := platform.SetAccountPolicy(boshsettings.AccountPolicy{
+					PasswordHashes: map[string]string{"vcap": "secret"},
+				})
+				Expect(err).To(MatchError(ContainSubstring("is not a crypt(3) hash")))
+				Expect(cmdRunner.RunCommands).To(BeEmpty())
+			})
+		})
+
+		It("writes the faillock configuration", func() {
+			err := platform.SetAccountPolicy(boshsettings.AccountPolicy{LockoutThreshold: 5, LockoutUnlockTime: 900})
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := fs.ReadFileString("/etc/security/faillock.conf")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(ContainSubstring("deny = 5\nunlock_time = 900\n"))
+			Expect(fakeAuditLogger.GetDebugMsgs()[0]).To(ContainSubstring("|lockout_policy|1|"))
+		})
+
+		It("only replaces the lockout keys of an existing faillock configuration", func() {
+			err := fs.WriteFileString("/etc/security/faillock.conf", "dir = /var/run/faillock\n# deny = 3\ndeny = 3\nsilent\n")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = platform.SetAccountPolicy(boshsettings.AccountPolicy{LockoutThreshold: 5, LockoutUnlockTime: 900})
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := fs.ReadFileString("/etc/security/faillock.conf")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(Equal("dir = /var/run/faillock\n# deny = 3\ndeny = 5\nsilent\nunlock_time = 900\n"))
+		})
+
+		Context("when toggling sshd password authentication", func() {
+			var disabled = false
+
+			BeforeEach(func() {
+				cmdRunner.AddCmdResult("sshd -T", fakesys.FakeCmdResult{Stdout: "port 22\npasswordauthentication no\n"})
+			})
+
+			It("writes a drop-in, validates it and reloads sshd", func() {
+				err := fs.WriteFileString("/run/sshd.pid", "1234\n")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = platform.SetAccountPolicy(boshsettings.AccountPolicy{SSHPasswordAuthentication: &disabled})
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := fs.ReadFileString("/etc/ssh/sshd_config.d/00-bosh-account-policy.conf")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(contents).To(ContainSubstring("PasswordAuthentication no\n"))
+
+				Expect(cmdRunner.RunCommands).To(Equal([][]string{
+					{"sshd", "-t"},
+					{"kill", "-HUP", "1234"},
+					{"sshd", "-T"},
+				}))
+				Expect(fakeAuditLogger.GetDebugMsgs()[0]).To(ContainSubstring("|ssh_password_authentication|1|"))
+			})
+
+			It("removes the drop-in when sshd rejects the configuration", func() {
+				cmdRunner.AddCmdResult("sshd -t", fakesys.FakeCmdResult{Error: errors.New("fake-sshd-error"), Stderr: "bad config"})
+
+				err := platform.SetAccountPolicy(boshsettings.AccountPolicy{SSHPasswordAuthentication: &disabled})
+				Expect(err).To(MatchError(ContainSubstring("bad config")))
+				Expect(fs.FileExists("/etc/ssh/sshd_config.d/00-bosh-account-policy.conf")).To(BeFalse())
+				Expect(fakeAuditLogger.GetErrMsgs()).To(HaveLen(1))
+			})
+
+			It("returns an error when the effective configuration does not match", func() {
+				enabled := true
+
+				err := platform.SetAccountPolicy(boshsettings.AccountPolicy{SSHPasswordAuthentication: &enabled})
+				Expect(err).To(MatchError(ContainSubstring("PasswordAuthentication is not 'yes'")))
+			})
+		})
+	})
+
 	Describe("SetupHostname", func() {
 		const expectedEtcHosts = `127.0.0.1 foobar.local localhost
 
@@ -936,6 +1077,43 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 		})
 	})
 
+	Describe("RotateLogs", func() {
+		It("forces logrotate to rotate the logs of the group", func() {
+			err := platform.RotateLogs("fake-group-name", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"logrotate", "--force", "/etc/logrotate.d/fake-group-name"}}))
+		})
+
+		It("returns an error when logrotate fails", func() {
+			cmdRunner.AddCmdResult("logrotate --force /etc/logrotate.d/fake-group-name", fakesys.FakeCmdResult{
+				Stderr: "fake-stderr",
+				Error:  errors.New("fake-logrotate-error"),
+			})
+
+			err := platform.RotateLogs("fake-group-name", false)
+			Expect(err).To(MatchError("Rotating logs: fake-stderr: fake-logrotate-error"))
+		})
+
+		It("truncates the logs in place when asked to", func() {
+			fs.SetGlob("/fake-dir/data/sys/log/*/*.log", []string{"/fake-dir/data/sys/log/fake-job/fake-job.log"})
+
+			err := platform.RotateLogs("fake-group-name", true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+			Expect(fs.GetFileTestStat("/fake-dir/data/sys/log/fake-job/fake-job.log").Flags).To(Equal(os.O_WRONLY | os.O_TRUNC))
+		})
+
+		It("returns an error when a log cannot be truncated", func() {
+			fs.SetGlob("/fake-dir/data/sys/log/*.log", []string{"/fake-dir/data/sys/log/fake.log"})
+			fs.OpenFileErr = errors.New("fake-open-error")
+
+			err := platform.RotateLogs("fake-group-name", true)
+			Expect(err).To(MatchError("Truncating log '/fake-dir/data/sys/log/fake.log': fake-open-error"))
+		})
+	})
+
 	Describe("SetTimeWithNtpServers", func() {
 		It("sets time with ntp servers", func() {
 			err := platform.SetTimeWithNtpServers([]string{"0.north-america.pool.ntp.org", "1.north-america.pool.ntp.org"})
@@ -2906,6 +3084,33 @@ sam:fakeanotheruser`)
 		})
 	})
 
+	Describe("SetupCoreDumpsDir", func() {
+		It("creates a core dumps folder crashed processes can write but not list", func() {
+			err := platform.SetupCoreDumpsDir()
+			Expect(err).NotTo(HaveOccurred())
+
+			testFileStat := fs.GetFileTestStat("/fake-dir/data/sys/cores")
+			Expect(testFileStat.FileType).To(Equal(fakesys.FakeFileTypeDir))
+			Expect(cmdRunner.RunCommands).To(ContainElement([]string{"chmod", "1733", "/fake-dir/data/sys/cores"}))
+		})
+
+		It("makes the kernel write core dumps to the core dumps folder", func() {
+			err := platform.SetupCoreDumpsDir()
+			Expect(err).NotTo(HaveOccurred())
+
+			corePattern, err := fs.ReadFileString("/proc/sys/kernel/core_pattern")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(corePattern).To(Equal("/fake-dir/data/sys/cores/core.%E.%p.%t"))
+		})
+
+		It("returns an error when setting the core pattern fails", func() {
+			fs.WriteFileErrors["/proc/sys/kernel/core_pattern"] = errors.New("fake-write-err")
+
+			err := platform.SetupCoreDumpsDir()
+			Expect(err).To(MatchError(ContainSubstring("Setting kernel core pattern")))
+		})
+	})
+
 	Describe("SetupLoggingAndAuditing", func() {
 		act := func() error {
 			return platform.SetupLoggingAndAuditing()
@@ -3482,9 +3687,9 @@ sam:fakeanotheruser`)
 			It("returs true without an error if unmounting succeeded", func() {
 				mounter.UnmountReturns(true, nil)
 
-				didUnmount, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{})
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{})
 				Expect(err).NotTo(HaveOccurred())
-				Expect(didUnmount).To(BeTrue())
+				Expect(report.DidUnmount).To(BeTrue())
 				Expect(mounter.UnmountCallCount()).To(Equal(1))
 				Expect(mounter.UnmountArgsForCall(0)).To(Equal(expectedUnmountMountPoint))
 			})
@@ -3492,9 +3697,9 @@ sam:fakeanotheruser`)
 			It("returs false without an error if was already unmounted", func() {
 				mounter.UnmountReturns(false, nil)
 
-				didUnmount, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{})
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{})
 				Expect(err).NotTo(HaveOccurred())
-				Expect(didUnmount).To(BeFalse())
+				Expect(report.DidUnmount).To(BeFalse())
 				Expect(mounter.UnmountCallCount()).To(Equal(1))
 				Expect(mounter.UnmountArgsForCall(0)).To(Equal(expectedUnmountMountPoint))
 			})
@@ -3502,10 +3707,10 @@ sam:fakeanotheruser`)
 			It("returns error if unmounting fails", func() {
 				mounter.UnmountReturns(false, errors.New("fake-unmount-err"))
 
-				didUnmount, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{})
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{})
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-unmount-err"))
-				Expect(didUnmount).To(BeFalse())
+				Expect(report.DidUnmount).To(BeFalse())
 				Expect(mounter.UnmountCallCount()).To(Equal(1))
 				Expect(mounter.UnmountArgsForCall(0)).To(Equal(expectedUnmountMountPoint))
 			})
@@ -3549,6 +3754,107 @@ sam:fakeanotheruser`)
 			})
 		})
 
+		Context("when processes keep the disk busy", func() {
+			BeforeEach(func() {
+				devicePathResolver.RealDevicePath = "fake-real-device-path"
+				mounter.IsMountedReturns(true, nil)
+
+				cmdRunner.AddCmdResult("fuser -m fake-real-device-path1", fakesys.FakeCmdResult{Stdout: "    42c    43ce"})
+				Expect(fs.WriteFileString("/proc/42/comm", "fake-db\n")).To(Succeed())
+				Expect(fs.WriteFileString("/proc/43/comm", "fake-shell\n")).To(Succeed())
+			})
+
+			It("reports the processes when unmounting fails", func() {
+				mounter.UnmountReturns(false, errors.New("fake-unmount-err"))
+
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Unmounting fake-real-device-path1 held by processes 42 (fake-db), 43 (fake-shell): fake-unmount-err"))
+				Expect(report.BlockingProcesses).To(Equal([]MountHolder{{PID: 42, Command: "fake-db"}, {PID: 43, Command: "fake-shell"}}))
+			})
+
+			It("kills the processes before unmounting when forced", func() {
+				cmdRunner.AddCmdResult("fuser -m fake-real-device-path1", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("fake-no-processes")})
+				mounter.UnmountReturns(true, nil)
+
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{Force: true})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report).To(Equal(UnmountReport{
+					DidUnmount:      true,
+					KilledProcesses: []MountHolder{{PID: 42, Command: "fake-db"}, {PID: 43, Command: "fake-shell"}},
+				}))
+
+				Expect(cmdRunner.RunCommands).To(ContainElement([]string{"kill", "-s", "KILL", "42", "43"}))
+				Expect(mounter.UnmountArgsForCall(0)).To(Equal("fake-real-device-path1"))
+				Expect(mounter.LazyUnmountCallCount()).To(Equal(0))
+			})
+
+			It("returns an error when killing the processes fails", func() {
+				cmdRunner.AddCmdResult("kill -s KILL 42 43", fakesys.FakeCmdResult{Error: errors.New("fake-kill-err")})
+
+				_, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{Force: true})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-kill-err"))
+				Expect(mounter.UnmountCallCount()).To(Equal(0))
+			})
+
+			It("unmounts lazily instead of waiting for the processes", func() {
+				mounter.LazyUnmountReturns(true, nil)
+
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{Lazy: true})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report).To(Equal(UnmountReport{
+					DidUnmount:        true,
+					Lazy:              true,
+					BlockingProcesses: []MountHolder{{PID: 42, Command: "fake-db"}, {PID: 43, Command: "fake-shell"}},
+				}))
+
+				Expect(mounter.LazyUnmountArgsForCall(0)).To(Equal("fake-real-device-path1"))
+				Expect(mounter.UnmountCallCount()).To(Equal(0))
+			})
+
+			It("unmounts lazily when processes survive being killed", func() {
+				cmdRunner.AddCmdResult("fuser -m fake-real-device-path1", fakesys.FakeCmdResult{Stdout: " 43ce"})
+				mounter.LazyUnmountReturns(true, nil)
+
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{Force: true, Lazy: true})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Lazy).To(BeTrue())
+				Expect(report.KilledProcesses).To(HaveLen(2))
+				Expect(report.BlockingProcesses).To(Equal([]MountHolder{{PID: 43, Command: "fake-shell"}}))
+			})
+
+			It("unmounts normally when no process uses the disk", func() {
+				devicePathResolver.RealDevicePath = "fake-idle-device-path"
+				mounter.UnmountReturns(true, nil)
+
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{Lazy: true})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report).To(Equal(UnmountReport{DidUnmount: true}))
+				Expect(mounter.LazyUnmountCallCount()).To(Equal(0))
+			})
+
+			It("does nothing when the disk is not mounted", func() {
+				mounter.IsMountedReturns(false, nil)
+
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{Force: true})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report).To(Equal(UnmountReport{}))
+				Expect(cmdRunner.RunCommands).To(BeEmpty())
+			})
+
+			It("returns an error when finding the processes fails", func() {
+				devicePathResolver.RealDevicePath = "fake-idle-device-path"
+				cmdRunner.AddCmdResult("fuser -m fake-idle-device-path1", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("fake-fuser-err")})
+
+				_, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{}, UnmountOptions{Lazy: true})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Finding processes using fake-idle-device-path1: fake-fuser-err"))
+				Expect(mounter.UnmountCallCount()).To(Equal(0))
+				Expect(mounter.LazyUnmountCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when device path cannot be resolved", func() {
 			BeforeEach(func() {
 				devicePathResolver.GetRealDevicePathErr = errors.New("fake-get-real-device-path-err")
@@ -3556,10 +3862,10 @@ sam:fakeanotheruser`)
 			})
 
 			It("returns error", func() {
-				isMounted, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{Path: "fake-device-path"})
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{Path: "fake-device-path"}, UnmountOptions{})
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-get-real-device-path-err"))
-				Expect(isMounted).To(BeFalse())
+				Expect(report.DidUnmount).To(BeFalse())
 			})
 		})
 
@@ -3570,9 +3876,9 @@ sam:fakeanotheruser`)
 			})
 
 			It("does not return error", func() {
-				isMounted, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{Path: "fake-device-path"})
+				report, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{Path: "fake-device-path"}, UnmountOptions{})
 				Expect(err).NotTo(HaveOccurred())
-				Expect(isMounted).To(BeFalse())
+				Expect(report.DidUnmount).To(BeFalse())
 			})
 		})
 	})
@@ -3678,9 +3984,120 @@ sam:fakeanotheruser`)
 		})
 	})
 
+	Describe("FreezeFilesystem", func() {
+		It("freezes the filesystem", func() {
+			err := platform.FreezeFilesystem("/var/vcap/store")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"fsfreeze", "--freeze", "/var/vcap/store"}}))
+		})
+
+		It("returns an error when fsfreeze fails", func() {
+			cmdRunner.AddCmdResult("fsfreeze --freeze /var/vcap/store", fakesys.FakeCmdResult{Error: errors.New("fake-fsfreeze-error")})
+
+			err := platform.FreezeFilesystem("/var/vcap/store")
+			Expect(err).To(MatchError("Freezing filesystem at /var/vcap/store: fake-fsfreeze-error"))
+		})
+	})
+
+	Describe("ThawFilesystem", func() {
+		It("thaws the filesystem", func() {
+			err := platform.ThawFilesystem("/var/vcap/store")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"fsfreeze", "--unfreeze", "/var/vcap/store"}}))
+		})
+
+		It("returns an error when fsfreeze fails", func() {
+			cmdRunner.AddCmdResult("fsfreeze --unfreeze /var/vcap/store", fakesys.FakeCmdResult{Error: errors.New("fake-fsfreeze-error")})
+
+			err := platform.ThawFilesystem("/var/vcap/store")
+			Expect(err).To(MatchError("Thawing filesystem at /var/vcap/store: fake-fsfreeze-error"))
+		})
+	})
+
+	Describe("GrowEphemeralDisk", func() {
+		BeforeEach(func() {
+			cmdRunner.AvailableCommands = map[string]bool{"growpart": true}
+
+			partitioner.GetPartitionsPartitions = []boshdisk.ExistingPartition{
+				{Index: 1, Type: boshdisk.PartitionTypeSwap},
+				{Index: 2, Type: boshdisk.PartitionTypeLinux},
+			}
+
+			cmdRunner.AddCmdResult("readlink -f /dev/sdb2", fakesys.FakeCmdResult{Stdout: "/dev/sdb2\n"})
+		})
+
+		It("grows the data partition and its filesystem", func() {
+			grown, err := platform.GrowEphemeralDisk("/dev/sdb")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(grown).To(BeTrue())
+
+			Expect(cmdRunner.RunCommands).To(ContainElement([]string{"growpart", "/dev/sdb", "2"}))
+			Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/sdb2"))
+		})
+
+		It("does not grow the filesystem when there is no space to grow into", func() {
+			cmdRunner.AddCmdResult("growpart /dev/sdb 2", fakesys.FakeCmdResult{
+				Stdout: "NOCHANGE: partition 2 is size 20969439. it cannot be grown",
+				Error:  errors.New("fake-growpart-error"),
+			})
+
+			grown, err := platform.GrowEphemeralDisk("/dev/sdb")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(grown).To(BeFalse())
+			Expect(formatter.GrowFilesystemCalled).To(BeFalse())
+		})
+
+		It("returns an error when growpart fails", func() {
+			cmdRunner.AddCmdResult("growpart /dev/sdb 2", fakesys.FakeCmdResult{Error: errors.New("fake-growpart-error")})
+
+			_, err := platform.GrowEphemeralDisk("/dev/sdb")
+			Expect(err).To(MatchError("growpart: fake-growpart-error"))
+		})
+
+		It("returns an error when growing the filesystem fails", func() {
+			formatter.GrowFilesystemError = errors.New("fake-grow-error")
+
+			_, err := platform.GrowEphemeralDisk("/dev/sdb")
+			Expect(err).To(MatchError("Growing ephemeral filesystem: fake-grow-error"))
+		})
+
+		It("returns an error when the disk does not end with a data partition", func() {
+			partitioner.GetPartitionsPartitions = []boshdisk.ExistingPartition{{Index: 1, Type: boshdisk.PartitionTypeSwap}}
+
+			_, err := platform.GrowEphemeralDisk("/dev/sdb")
+			Expect(err).To(MatchError("Ephemeral disk `/dev/sdb' does not end with a data partition"))
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns an error when the ephemeral partitions are on the root device", func() {
+			_, err := platform.GrowEphemeralDisk("")
+			Expect(err).To(MatchError("Growing ephemeral partitions on the root device is not supported"))
+		})
+
+		It("returns an error when growpart is not installed", func() {
+			cmdRunner.AvailableCommands = map[string]bool{}
+
+			_, err := platform.GrowEphemeralDisk("/dev/sdb")
+			Expect(err).To(MatchError(ContainSubstring("'growpart' is not installed")))
+		})
+
+		Context("when SkipDiskSetup is true", func() {
+			BeforeEach(func() {
+				options.SkipDiskSetup = true
+			})
+
+			It("does nothing", func() {
+				grown, err := platform.GrowEphemeralDisk("/dev/sdb")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(grown).To(BeFalse())
+				Expect(cmdRunner.RunCommands).To(BeEmpty())
+			})
+		})
+	})
+
 	Describe("MigratePersistentDisk", func() {
 		It("migrate persistent disk", func() {
-			err := platform.MigratePersistentDisk("/from/path", "/to/path")
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", nil, nil)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(mounter.RemountAsReadonlyCallCount()).To(Equal(1))
@@ -3699,6 +4116,124 @@ sam:fakeanotheruser`)
 			Expect(options).To(BeEmpty())
 		})
 
+		Context("when rsync is installed", func() {
+			var (
+				rsyncCmd    []string
+				rsyncOutput string
+			)
+
+			BeforeEach(func() {
+				cmdRunner.AvailableCommands = map[string]bool{"rsync": true}
+
+				rsyncCmd = []string{
+					"rsync",
+					"--archive", "--hard-links", "--acls", "--xattrs", "--sparse",
+					"--partial-dir=.rsync-partial",
+					"--info=progress2", "--no-inc-recursive",
+					"/from/path/", "/to/path/",
+				}
+				rsyncOutput = ""
+			})
+
+			JustBeforeEach(func() {
+				platform = NewLinuxPlatform(
+					fs,
+					&outputCmdRunner{FakeCmdRunner: cmdRunner, stdout: rsyncOutput},
+					collector,
+					compressor,
+					copier,
+					dirProvider,
+					vitalsService,
+					cdutil,
+					diskManager,
+					netManager,
+					certManager,
+					monitRetryStrategy,
+					devicePathResolver,
+					state,
+					options,
+					logger,
+					fakeDefaultNetworkResolver,
+					fakeUUIDGenerator,
+					fakeAuditLogger,
+					fakeLogsTarProvider,
+					serviceManager,
+				)
+			})
+
+			Context("when rsync reports its progress", func() {
+				BeforeEach(func() {
+					rsyncOutput = "\r      1,048,576  12%   1.00MB/s    0:00:08 (xfr#1, to-chk=9/10)" +
+						"\r      2,097,152  12%   1.00MB/s    0:00:07 (xfr#2, to-chk=8/10)" +
+						"\r      8,388,608 100%   1.00MB/s    0:00:00 (xfr#10, to-chk=0/10)\n"
+				})
+
+				It("copies with rsync and reports the progress", func() {
+					cmdRunner.AddProcess(strings.Join(rsyncCmd, " "), &fakesys.FakeProcess{})
+
+					var reported []int
+					err := platform.MigratePersistentDisk("/from/path", "/to/path", func(percent int) {
+						reported = append(reported, percent)
+					}, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(cmdRunner.RunCommands).To(BeEmpty())
+					Expect(cmdRunner.RunComplexCommands).To(HaveLen(1))
+					Expect(append([]string{cmdRunner.RunComplexCommands[0].Name}, cmdRunner.RunComplexCommands[0].Args...)).To(Equal(rsyncCmd))
+
+					Expect(reported).To(Equal([]int{12, 100}))
+
+					Expect(mounter.RemountCallCount()).To(Equal(1))
+				})
+			})
+
+			It("returns an error without remounting when rsync fails", func() {
+				cmdRunner.AddProcess(strings.Join(rsyncCmd, " "), &fakesys.FakeProcess{
+					WaitResult: boshsys.Result{ExitStatus: 1, Error: errors.New("fake-rsync-error")},
+				})
+
+				err := platform.MigratePersistentDisk("/from/path", "/to/path", nil, nil)
+				Expect(err).To(MatchError("Copying files from old disk to new disk: fake-rsync-error"))
+
+				Expect(mounter.UnmountCallCount()).To(Equal(0))
+				Expect(mounter.RemountCallCount()).To(Equal(0))
+			})
+
+			It("stops rsync and mounts the old disk writable again when the migration is cancelled", func() {
+				cancelCh := make(chan struct{})
+				process := &fakesys.FakeProcess{
+					TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+						p.WaitCh <- boshsys.Result{ExitStatus: 20, Error: errors.New("fake-rsync-killed")}
+					},
+				}
+				cmdRunner.AddProcess(strings.Join(rsyncCmd, " "), process)
+				cmdRunner.SetCmdCallback(strings.Join(rsyncCmd, " "), func() { close(cancelCh) })
+
+				err := platform.MigratePersistentDisk("/from/path", "/to/path", nil, boshtask.NewCancelSignal(cancelCh))
+				Expect(err).To(Equal(boshtask.ErrCancelled))
+
+				Expect(process.TerminatedNicely).To(BeTrue())
+
+				Expect(mounter.UnmountCallCount()).To(Equal(0))
+				Expect(mounter.RemountCallCount()).To(Equal(1))
+				fromPath, toPath, options := mounter.RemountArgsForCall(0)
+				Expect(fromPath).To(Equal("/from/path"))
+				Expect(toPath).To(Equal("/from/path"))
+				Expect(options).To(BeEmpty())
+			})
+
+			It("does not start rsync when the migration was cancelled before copying", func() {
+				cancelCh := make(chan struct{})
+				close(cancelCh)
+
+				err := platform.MigratePersistentDisk("/from/path", "/to/path", nil, boshtask.NewCancelSignal(cancelCh))
+				Expect(err).To(Equal(boshtask.ErrCancelled))
+
+				Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+				Expect(mounter.RemountCallCount()).To(Equal(1))
+			})
+		})
+
 		Context("when device path resolution type is iscsi", func() {
 			BeforeEach(func() {
 				mountsSearcher.SearchMountsMounts = []boshdisk.Mount{
@@ -3742,7 +4277,7 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 					serviceManager,
 				)
 
-				err := platformWithISCSIType.MigratePersistentDisk("/from/path", "/to/path")
+				err := platformWithISCSIType.MigratePersistentDisk("/from/path", "/to/path", nil, nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(mounter.RemountAsReadonlyCallCount()).To(Equal(1))
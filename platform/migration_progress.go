@@ -0,0 +1,57 @@
+package platform
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// MigrationProgressFunc receives the estimated percentage of a persistent
+// disk migration whenever it changes
+type MigrationProgressFunc func(percent int)
+
+var rsyncProgressRegexp = regexp.MustCompile(`\s(\d{1,3})%\s`)
+
+// rsyncProgressWriter parses the overall progress rsync prints with
+// --info=progress2. rsync redraws the progress line with carriage returns,
+// so both they and newlines end a line.
+type rsyncProgressWriter struct {
+	progress MigrationProgressFunc
+	line     []byte
+	percent  int
+}
+
+func newRsyncProgressWriter(progress MigrationProgressFunc) *rsyncProgressWriter {
+	return &rsyncProgressWriter{progress: progress, percent: -1}
+}
+
+func (w *rsyncProgressWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b != '\r' && b != '\n' {
+			w.line = append(w.line, b)
+			continue
+		}
+
+		w.parseLine()
+		w.line = w.line[:0]
+	}
+
+	return len(p), nil
+}
+
+func (w *rsyncProgressWriter) parseLine() {
+	matches := rsyncProgressRegexp.FindSubmatch(append(w.line, ' '))
+	if matches == nil {
+		return
+	}
+
+	percent, err := strconv.Atoi(string(matches[1]))
+	if err != nil || percent == w.percent || percent > 100 {
+		return
+	}
+
+	w.percent = percent
+
+	if w.progress != nil {
+		w.progress(percent)
+	}
+}
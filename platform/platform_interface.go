@@ -9,6 +9,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	boshlogstarprovider "github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver"
 	boship "github.com/cloudfoundry/bosh-agent/v2/platform/net/ip"
 	boshvitals "github.com/cloudfoundry/bosh-agent/v2/platform/vitals"
@@ -56,6 +57,7 @@ type Platform interface {
 	SetupRootDisk(ephemeralDiskPath string) (err error)
 	SetupSSH(publicKey []string, username string) (err error)
 	SetUserPassword(user, encryptedPwd string) (err error)
+	SetAccountPolicy(policy boshsettings.AccountPolicy) (err error)
 	SetupBoshSettingsDisk() (err error)
 	SetupIPv6(boshsettings.IPv6) error
 	SetupHostname(hostname string) (err error)
@@ -70,6 +72,7 @@ type Platform interface {
 	SetupCanRestartDir() (err error)
 	SetupHomeDir() (err error)
 	SetupBlobsDir() (err error)
+	SetupCoreDumpsDir() (err error)
 	SetupMonitUser() (err error)
 	StartMonit() (err error)
 	SetupRuntimeConfiguration() (err error)
@@ -81,9 +84,23 @@ type Platform interface {
 	// Disk management
 	AdjustPersistentDiskPartitioning(diskSettings boshsettings.DiskSettings, mountPoint string) error
 	MountPersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) error
-	UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error)
-	MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error)
+	// UnmountPersistentDisk reports the processes that were killed or kept
+	// the disk busy according to options
+	UnmountPersistentDisk(diskSettings boshsettings.DiskSettings, options UnmountOptions) (report UnmountReport, err error)
+	// MigratePersistentDisk copies the persistent disk to the disk mounted at
+	// toMountPoint and mounts that one in its place. Running it again after it
+	// was interrupted only copies what is still missing. Cancelling stops the
+	// copy and leaves the persistent disk mounted as it was.
+	MigratePersistentDisk(fromMountPoint, toMountPoint string, progress MigrationProgressFunc, cancel *boshtask.CancelSignal) (err error)
 	GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) (string, error)
+	// FreezeFilesystem suspends writes to the filesystem mounted at
+	// mountPoint until ThawFilesystem, e.g. while it is snapshotted
+	FreezeFilesystem(mountPoint string) error
+	ThawFilesystem(mountPoint string) error
+	// GrowEphemeralDisk grows the data partition of the ephemeral disk and its
+	// mounted filesystem into space added to the disk since it was set up.
+	// It returns false when there is no space to grow into.
+	GrowEphemeralDisk(devicePath string) (grown bool, err error)
 	IsMountPoint(path string) (partitionPath string, result bool, err error)
 	IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (result bool, err error)
 	IsPersistentDiskMountable(diskSettings boshsettings.DiskSettings) (bool, error)
@@ -106,6 +123,10 @@ type Platform interface {
 
 	GetHostPublicKey() (string, error)
 
+	// RotateLogs forces the rotation of the logs set up by SetupLogrotate
+	// for groupName. With truncate the logs are emptied in place instead.
+	RotateLogs(groupName string, truncate bool) error
+
 	RemoveDevTools(packageFileListPath string) error
 	RemoveStaticLibraries(packageFileListPath string) error
 
@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	"github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver"
 	"github.com/cloudfoundry/bosh-agent/v2/platform"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/cert"
@@ -88,6 +89,17 @@ type FakePlatform struct {
 	deleteEphemeralUsersMatchingReturnsOnCall map[int]struct {
 		result1 error
 	}
+	FreezeFilesystemStub        func(string) error
+	freezeFilesystemMutex       sync.RWMutex
+	freezeFilesystemArgsForCall []struct {
+		arg1 string
+	}
+	freezeFilesystemReturns struct {
+		result1 error
+	}
+	freezeFilesystemReturnsOnCall map[int]struct {
+		result1 error
+	}
 	GetAgentSettingsPathStub        func(bool) string
 	getAgentSettingsPathMutex       sync.RWMutex
 	getAgentSettingsPathArgsForCall []struct {
@@ -321,6 +333,19 @@ type FakePlatform struct {
 	getVitalsServiceReturnsOnCall map[int]struct {
 		result1 vitals.Service
 	}
+	GrowEphemeralDiskStub        func(string) (bool, error)
+	growEphemeralDiskMutex       sync.RWMutex
+	growEphemeralDiskArgsForCall []struct {
+		arg1 string
+	}
+	growEphemeralDiskReturns struct {
+		result1 bool
+		result2 error
+	}
+	growEphemeralDiskReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
 	IsMountPointStub        func(string) (string, bool, error)
 	isMountPointMutex       sync.RWMutex
 	isMountPointArgsForCall []struct {
@@ -362,11 +387,13 @@ type FakePlatform struct {
 		result1 bool
 		result2 error
 	}
-	MigratePersistentDiskStub        func(string, string) error
+	MigratePersistentDiskStub        func(string, string, platform.MigrationProgressFunc, *task.CancelSignal) error
 	migratePersistentDiskMutex       sync.RWMutex
 	migratePersistentDiskArgsForCall []struct {
 		arg1 string
 		arg2 string
+		arg3 platform.MigrationProgressFunc
+		arg4 *task.CancelSignal
 	}
 	migratePersistentDiskReturns struct {
 		result1 error
@@ -418,6 +445,18 @@ type FakePlatform struct {
 	removeStaticLibrariesReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RotateLogsStub        func(string, bool) error
+	rotateLogsMutex       sync.RWMutex
+	rotateLogsArgsForCall []struct {
+		arg1 string
+		arg2 bool
+	}
+	rotateLogsReturns struct {
+		result1 error
+	}
+	rotateLogsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SaveDNSRecordsStub        func(settings.DNSRecords, string) error
 	saveDNSRecordsMutex       sync.RWMutex
 	saveDNSRecordsArgsForCall []struct {
@@ -430,6 +469,17 @@ type FakePlatform struct {
 	saveDNSRecordsReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetAccountPolicyStub        func(settings.AccountPolicy) error
+	setAccountPolicyMutex       sync.RWMutex
+	setAccountPolicyArgsForCall []struct {
+		arg1 settings.AccountPolicy
+	}
+	setAccountPolicyReturns struct {
+		result1 error
+	}
+	setAccountPolicyReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetTimeWithNtpServersStub        func([]string) error
 	setTimeWithNtpServersMutex       sync.RWMutex
 	setTimeWithNtpServersArgsForCall []struct {
@@ -463,6 +513,16 @@ type FakePlatform struct {
 	setupBlobsDirReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetupCoreDumpsDirStub        func() error
+	setupCoreDumpsDirMutex       sync.RWMutex
+	setupCoreDumpsDirArgsForCall []struct {
+	}
+	setupCoreDumpsDirReturns struct {
+		result1 error
+	}
+	setupCoreDumpsDirReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetupBoshSettingsDiskStub        func() error
 	setupBoshSettingsDiskMutex       sync.RWMutex
 	setupBoshSettingsDiskArgsForCall []struct {
@@ -700,17 +760,29 @@ type FakePlatform struct {
 	startMonitReturnsOnCall map[int]struct {
 		result1 error
 	}
-	UnmountPersistentDiskStub        func(settings.DiskSettings) (bool, error)
+	ThawFilesystemStub        func(string) error
+	thawFilesystemMutex       sync.RWMutex
+	thawFilesystemArgsForCall []struct {
+		arg1 string
+	}
+	thawFilesystemReturns struct {
+		result1 error
+	}
+	thawFilesystemReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UnmountPersistentDiskStub        func(settings.DiskSettings, platform.UnmountOptions) (platform.UnmountReport, error)
 	unmountPersistentDiskMutex       sync.RWMutex
 	unmountPersistentDiskArgsForCall []struct {
 		arg1 settings.DiskSettings
+		arg2 platform.UnmountOptions
 	}
 	unmountPersistentDiskReturns struct {
-		result1 bool
+		result1 platform.UnmountReport
 		result2 error
 	}
 	unmountPersistentDiskReturnsOnCall map[int]struct {
-		result1 bool
+		result1 platform.UnmountReport
 		result2 error
 	}
 	invocations      map[string][][]interface{}
@@ -1092,6 +1164,67 @@ func (fake *FakePlatform) DeleteEphemeralUsersMatchingReturnsOnCall(i int, resul
 	}{result1}
 }
 
+func (fake *FakePlatform) FreezeFilesystem(arg1 string) error {
+	fake.freezeFilesystemMutex.Lock()
+	ret, specificReturn := fake.freezeFilesystemReturnsOnCall[len(fake.freezeFilesystemArgsForCall)]
+	fake.freezeFilesystemArgsForCall = append(fake.freezeFilesystemArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.FreezeFilesystemStub
+	fakeReturns := fake.freezeFilesystemReturns
+	fake.recordInvocation("FreezeFilesystem", []interface{}{arg1})
+	fake.freezeFilesystemMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePlatform) FreezeFilesystemCallCount() int {
+	fake.freezeFilesystemMutex.RLock()
+	defer fake.freezeFilesystemMutex.RUnlock()
+	return len(fake.freezeFilesystemArgsForCall)
+}
+
+func (fake *FakePlatform) FreezeFilesystemCalls(stub func(string) error) {
+	fake.freezeFilesystemMutex.Lock()
+	defer fake.freezeFilesystemMutex.Unlock()
+	fake.FreezeFilesystemStub = stub
+}
+
+func (fake *FakePlatform) FreezeFilesystemArgsForCall(i int) string {
+	fake.freezeFilesystemMutex.RLock()
+	defer fake.freezeFilesystemMutex.RUnlock()
+	argsForCall := fake.freezeFilesystemArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePlatform) FreezeFilesystemReturns(result1 error) {
+	fake.freezeFilesystemMutex.Lock()
+	defer fake.freezeFilesystemMutex.Unlock()
+	fake.FreezeFilesystemStub = nil
+	fake.freezeFilesystemReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePlatform) FreezeFilesystemReturnsOnCall(i int, result1 error) {
+	fake.freezeFilesystemMutex.Lock()
+	defer fake.freezeFilesystemMutex.Unlock()
+	fake.FreezeFilesystemStub = nil
+	if fake.freezeFilesystemReturnsOnCall == nil {
+		fake.freezeFilesystemReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.freezeFilesystemReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakePlatform) GetAgentSettingsPath(arg1 bool) string {
 	fake.getAgentSettingsPathMutex.Lock()
 	ret, specificReturn := fake.getAgentSettingsPathReturnsOnCall[len(fake.getAgentSettingsPathArgsForCall)]
@@ -2283,6 +2416,70 @@ func (fake *FakePlatform) GetVitalsServiceReturnsOnCall(i int, result1 vitals.Se
 	}{result1}
 }
 
+func (fake *FakePlatform) GrowEphemeralDisk(arg1 string) (bool, error) {
+	fake.growEphemeralDiskMutex.Lock()
+	ret, specificReturn := fake.growEphemeralDiskReturnsOnCall[len(fake.growEphemeralDiskArgsForCall)]
+	fake.growEphemeralDiskArgsForCall = append(fake.growEphemeralDiskArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GrowEphemeralDiskStub
+	fakeReturns := fake.growEphemeralDiskReturns
+	fake.recordInvocation("GrowEphemeralDisk", []interface{}{arg1})
+	fake.growEphemeralDiskMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePlatform) GrowEphemeralDiskCallCount() int {
+	fake.growEphemeralDiskMutex.RLock()
+	defer fake.growEphemeralDiskMutex.RUnlock()
+	return len(fake.growEphemeralDiskArgsForCall)
+}
+
+func (fake *FakePlatform) GrowEphemeralDiskCalls(stub func(string) (bool, error)) {
+	fake.growEphemeralDiskMutex.Lock()
+	defer fake.growEphemeralDiskMutex.Unlock()
+	fake.GrowEphemeralDiskStub = stub
+}
+
+func (fake *FakePlatform) GrowEphemeralDiskArgsForCall(i int) string {
+	fake.growEphemeralDiskMutex.RLock()
+	defer fake.growEphemeralDiskMutex.RUnlock()
+	argsForCall := fake.growEphemeralDiskArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePlatform) GrowEphemeralDiskReturns(result1 bool, result2 error) {
+	fake.growEphemeralDiskMutex.Lock()
+	defer fake.growEphemeralDiskMutex.Unlock()
+	fake.GrowEphemeralDiskStub = nil
+	fake.growEphemeralDiskReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePlatform) GrowEphemeralDiskReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.growEphemeralDiskMutex.Lock()
+	defer fake.growEphemeralDiskMutex.Unlock()
+	fake.GrowEphemeralDiskStub = nil
+	if fake.growEphemeralDiskReturnsOnCall == nil {
+		fake.growEphemeralDiskReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.growEphemeralDiskReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakePlatform) IsMountPoint(arg1 string) (string, bool, error) {
 	fake.isMountPointMutex.Lock()
 	ret, specificReturn := fake.isMountPointReturnsOnCall[len(fake.isMountPointArgsForCall)]
@@ -2478,19 +2675,21 @@ func (fake *FakePlatform) IsPersistentDiskMountedReturnsOnCall(i int, result1 bo
 	}{result1, result2}
 }
 
-func (fake *FakePlatform) MigratePersistentDisk(arg1 string, arg2 string) error {
+func (fake *FakePlatform) MigratePersistentDisk(arg1 string, arg2 string, arg3 platform.MigrationProgressFunc, arg4 *task.CancelSignal) error {
 	fake.migratePersistentDiskMutex.Lock()
 	ret, specificReturn := fake.migratePersistentDiskReturnsOnCall[len(fake.migratePersistentDiskArgsForCall)]
 	fake.migratePersistentDiskArgsForCall = append(fake.migratePersistentDiskArgsForCall, struct {
 		arg1 string
 		arg2 string
-	}{arg1, arg2})
+		arg3 platform.MigrationProgressFunc
+		arg4 *task.CancelSignal
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.MigratePersistentDiskStub
 	fakeReturns := fake.migratePersistentDiskReturns
-	fake.recordInvocation("MigratePersistentDisk", []interface{}{arg1, arg2})
+	fake.recordInvocation("MigratePersistentDisk", []interface{}{arg1, arg2, arg3, arg4})
 	fake.migratePersistentDiskMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
@@ -2504,17 +2703,17 @@ func (fake *FakePlatform) MigratePersistentDiskCallCount() int {
 	return len(fake.migratePersistentDiskArgsForCall)
 }
 
-func (fake *FakePlatform) MigratePersistentDiskCalls(stub func(string, string) error) {
+func (fake *FakePlatform) MigratePersistentDiskCalls(stub func(string, string, platform.MigrationProgressFunc, *task.CancelSignal) error) {
 	fake.migratePersistentDiskMutex.Lock()
 	defer fake.migratePersistentDiskMutex.Unlock()
 	fake.MigratePersistentDiskStub = stub
 }
 
-func (fake *FakePlatform) MigratePersistentDiskArgsForCall(i int) (string, string) {
+func (fake *FakePlatform) MigratePersistentDiskArgsForCall(i int) (string, string, platform.MigrationProgressFunc, *task.CancelSignal) {
 	fake.migratePersistentDiskMutex.RLock()
 	defer fake.migratePersistentDiskMutex.RUnlock()
 	argsForCall := fake.migratePersistentDiskArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakePlatform) MigratePersistentDiskReturns(result1 error) {
@@ -2777,6 +2976,68 @@ func (fake *FakePlatform) RemoveStaticLibrariesReturnsOnCall(i int, result1 erro
 	}{result1}
 }
 
+func (fake *FakePlatform) RotateLogs(arg1 string, arg2 bool) error {
+	fake.rotateLogsMutex.Lock()
+	ret, specificReturn := fake.rotateLogsReturnsOnCall[len(fake.rotateLogsArgsForCall)]
+	fake.rotateLogsArgsForCall = append(fake.rotateLogsArgsForCall, struct {
+		arg1 string
+		arg2 bool
+	}{arg1, arg2})
+	stub := fake.RotateLogsStub
+	fakeReturns := fake.rotateLogsReturns
+	fake.recordInvocation("RotateLogs", []interface{}{arg1, arg2})
+	fake.rotateLogsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePlatform) RotateLogsCallCount() int {
+	fake.rotateLogsMutex.RLock()
+	defer fake.rotateLogsMutex.RUnlock()
+	return len(fake.rotateLogsArgsForCall)
+}
+
+func (fake *FakePlatform) RotateLogsCalls(stub func(string, bool) error) {
+	fake.rotateLogsMutex.Lock()
+	defer fake.rotateLogsMutex.Unlock()
+	fake.RotateLogsStub = stub
+}
+
+func (fake *FakePlatform) RotateLogsArgsForCall(i int) (string, bool) {
+	fake.rotateLogsMutex.RLock()
+	defer fake.rotateLogsMutex.RUnlock()
+	argsForCall := fake.rotateLogsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakePlatform) RotateLogsReturns(result1 error) {
+	fake.rotateLogsMutex.Lock()
+	defer fake.rotateLogsMutex.Unlock()
+	fake.RotateLogsStub = nil
+	fake.rotateLogsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePlatform) RotateLogsReturnsOnCall(i int, result1 error) {
+	fake.rotateLogsMutex.Lock()
+	defer fake.rotateLogsMutex.Unlock()
+	fake.RotateLogsStub = nil
+	if fake.rotateLogsReturnsOnCall == nil {
+		fake.rotateLogsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.rotateLogsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakePlatform) SaveDNSRecords(arg1 settings.DNSRecords, arg2 string) error {
 	fake.saveDNSRecordsMutex.Lock()
 	ret, specificReturn := fake.saveDNSRecordsReturnsOnCall[len(fake.saveDNSRecordsArgsForCall)]
@@ -2839,6 +3100,67 @@ func (fake *FakePlatform) SaveDNSRecordsReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakePlatform) SetAccountPolicy(arg1 settings.AccountPolicy) error {
+	fake.setAccountPolicyMutex.Lock()
+	ret, specificReturn := fake.setAccountPolicyReturnsOnCall[len(fake.setAccountPolicyArgsForCall)]
+	fake.setAccountPolicyArgsForCall = append(fake.setAccountPolicyArgsForCall, struct {
+		arg1 settings.AccountPolicy
+	}{arg1})
+	stub := fake.SetAccountPolicyStub
+	fakeReturns := fake.setAccountPolicyReturns
+	fake.recordInvocation("SetAccountPolicy", []interface{}{arg1})
+	fake.setAccountPolicyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePlatform) SetAccountPolicyCallCount() int {
+	fake.setAccountPolicyMutex.RLock()
+	defer fake.setAccountPolicyMutex.RUnlock()
+	return len(fake.setAccountPolicyArgsForCall)
+}
+
+func (fake *FakePlatform) SetAccountPolicyCalls(stub func(settings.AccountPolicy) error) {
+	fake.setAccountPolicyMutex.Lock()
+	defer fake.setAccountPolicyMutex.Unlock()
+	fake.SetAccountPolicyStub = stub
+}
+
+func (fake *FakePlatform) SetAccountPolicyArgsForCall(i int) settings.AccountPolicy {
+	fake.setAccountPolicyMutex.RLock()
+	defer fake.setAccountPolicyMutex.RUnlock()
+	argsForCall := fake.setAccountPolicyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePlatform) SetAccountPolicyReturns(result1 error) {
+	fake.setAccountPolicyMutex.Lock()
+	defer fake.setAccountPolicyMutex.Unlock()
+	fake.SetAccountPolicyStub = nil
+	fake.setAccountPolicyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePlatform) SetAccountPolicyReturnsOnCall(i int, result1 error) {
+	fake.setAccountPolicyMutex.Lock()
+	defer fake.setAccountPolicyMutex.Unlock()
+	fake.SetAccountPolicyStub = nil
+	if fake.setAccountPolicyReturnsOnCall == nil {
+		fake.setAccountPolicyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setAccountPolicyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakePlatform) SetTimeWithNtpServers(arg1 []string) error {
 	var arg1Copy []string
 	if arg1 != nil {
@@ -3020,6 +3342,59 @@ func (fake *FakePlatform) SetupBlobsDirReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakePlatform) SetupCoreDumpsDir() error {
+	fake.setupCoreDumpsDirMutex.Lock()
+	ret, specificReturn := fake.setupCoreDumpsDirReturnsOnCall[len(fake.setupCoreDumpsDirArgsForCall)]
+	fake.setupCoreDumpsDirArgsForCall = append(fake.setupCoreDumpsDirArgsForCall, struct {
+	}{})
+	stub := fake.SetupCoreDumpsDirStub
+	fakeReturns := fake.setupCoreDumpsDirReturns
+	fake.recordInvocation("SetupCoreDumpsDir", []interface{}{})
+	fake.setupCoreDumpsDirMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePlatform) SetupCoreDumpsDirCallCount() int {
+	fake.setupCoreDumpsDirMutex.RLock()
+	defer fake.setupCoreDumpsDirMutex.RUnlock()
+	return len(fake.setupCoreDumpsDirArgsForCall)
+}
+
+func (fake *FakePlatform) SetupCoreDumpsDirCalls(stub func() error) {
+	fake.setupCoreDumpsDirMutex.Lock()
+	defer fake.setupCoreDumpsDirMutex.Unlock()
+	fake.SetupCoreDumpsDirStub = stub
+}
+
+func (fake *FakePlatform) SetupCoreDumpsDirReturns(result1 error) {
+	fake.setupCoreDumpsDirMutex.Lock()
+	defer fake.setupCoreDumpsDirMutex.Unlock()
+	fake.SetupCoreDumpsDirStub = nil
+	fake.setupCoreDumpsDirReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePlatform) SetupCoreDumpsDirReturnsOnCall(i int, result1 error) {
+	fake.setupCoreDumpsDirMutex.Lock()
+	defer fake.setupCoreDumpsDirMutex.Unlock()
+	fake.SetupCoreDumpsDirStub = nil
+	if fake.setupCoreDumpsDirReturnsOnCall == nil {
+		fake.setupCoreDumpsDirReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setupCoreDumpsDirReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakePlatform) SetupBoshSettingsDisk() error {
 	fake.setupBoshSettingsDiskMutex.Lock()
 	ret, specificReturn := fake.setupBoshSettingsDiskReturnsOnCall[len(fake.setupBoshSettingsDiskArgsForCall)]
@@ -4283,18 +4658,80 @@ func (fake *FakePlatform) StartMonitReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakePlatform) UnmountPersistentDisk(arg1 settings.DiskSettings) (bool, error) {
+func (fake *FakePlatform) ThawFilesystem(arg1 string) error {
+	fake.thawFilesystemMutex.Lock()
+	ret, specificReturn := fake.thawFilesystemReturnsOnCall[len(fake.thawFilesystemArgsForCall)]
+	fake.thawFilesystemArgsForCall = append(fake.thawFilesystemArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ThawFilesystemStub
+	fakeReturns := fake.thawFilesystemReturns
+	fake.recordInvocation("ThawFilesystem", []interface{}{arg1})
+	fake.thawFilesystemMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePlatform) ThawFilesystemCallCount() int {
+	fake.thawFilesystemMutex.RLock()
+	defer fake.thawFilesystemMutex.RUnlock()
+	return len(fake.thawFilesystemArgsForCall)
+}
+
+func (fake *FakePlatform) ThawFilesystemCalls(stub func(string) error) {
+	fake.thawFilesystemMutex.Lock()
+	defer fake.thawFilesystemMutex.Unlock()
+	fake.ThawFilesystemStub = stub
+}
+
+func (fake *FakePlatform) ThawFilesystemArgsForCall(i int) string {
+	fake.thawFilesystemMutex.RLock()
+	defer fake.thawFilesystemMutex.RUnlock()
+	argsForCall := fake.thawFilesystemArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePlatform) ThawFilesystemReturns(result1 error) {
+	fake.thawFilesystemMutex.Lock()
+	defer fake.thawFilesystemMutex.Unlock()
+	fake.ThawFilesystemStub = nil
+	fake.thawFilesystemReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePlatform) ThawFilesystemReturnsOnCall(i int, result1 error) {
+	fake.thawFilesystemMutex.Lock()
+	defer fake.thawFilesystemMutex.Unlock()
+	fake.ThawFilesystemStub = nil
+	if fake.thawFilesystemReturnsOnCall == nil {
+		fake.thawFilesystemReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.thawFilesystemReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePlatform) UnmountPersistentDisk(arg1 settings.DiskSettings, arg2 platform.UnmountOptions) (platform.UnmountReport, error) {
 	fake.unmountPersistentDiskMutex.Lock()
 	ret, specificReturn := fake.unmountPersistentDiskReturnsOnCall[len(fake.unmountPersistentDiskArgsForCall)]
 	fake.unmountPersistentDiskArgsForCall = append(fake.unmountPersistentDiskArgsForCall, struct {
 		arg1 settings.DiskSettings
-	}{arg1})
+		arg2 platform.UnmountOptions
+	}{arg1, arg2})
 	stub := fake.UnmountPersistentDiskStub
 	fakeReturns := fake.unmountPersistentDiskReturns
-	fake.recordInvocation("UnmountPersistentDisk", []interface{}{arg1})
+	fake.recordInvocation("UnmountPersistentDisk", []interface{}{arg1, arg2})
 	fake.unmountPersistentDiskMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -4308,41 +4745,41 @@ func (fake *FakePlatform) UnmountPersistentDiskCallCount() int {
 	return len(fake.unmountPersistentDiskArgsForCall)
 }
 
-func (fake *FakePlatform) UnmountPersistentDiskCalls(stub func(settings.DiskSettings) (bool, error)) {
+func (fake *FakePlatform) UnmountPersistentDiskCalls(stub func(settings.DiskSettings, platform.UnmountOptions) (platform.UnmountReport, error)) {
 	fake.unmountPersistentDiskMutex.Lock()
 	defer fake.unmountPersistentDiskMutex.Unlock()
 	fake.UnmountPersistentDiskStub = stub
 }
 
-func (fake *FakePlatform) UnmountPersistentDiskArgsForCall(i int) settings.DiskSettings {
+func (fake *FakePlatform) UnmountPersistentDiskArgsForCall(i int) (settings.DiskSettings, platform.UnmountOptions) {
 	fake.unmountPersistentDiskMutex.RLock()
 	defer fake.unmountPersistentDiskMutex.RUnlock()
 	argsForCall := fake.unmountPersistentDiskArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakePlatform) UnmountPersistentDiskReturns(result1 bool, result2 error) {
+func (fake *FakePlatform) UnmountPersistentDiskReturns(result1 platform.UnmountReport, result2 error) {
 	fake.unmountPersistentDiskMutex.Lock()
 	defer fake.unmountPersistentDiskMutex.Unlock()
 	fake.UnmountPersistentDiskStub = nil
 	fake.unmountPersistentDiskReturns = struct {
-		result1 bool
+		result1 platform.UnmountReport
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakePlatform) UnmountPersistentDiskReturnsOnCall(i int, result1 bool, result2 error) {
+func (fake *FakePlatform) UnmountPersistentDiskReturnsOnCall(i int, result1 platform.UnmountReport, result2 error) {
 	fake.unmountPersistentDiskMutex.Lock()
 	defer fake.unmountPersistentDiskMutex.Unlock()
 	fake.UnmountPersistentDiskStub = nil
 	if fake.unmountPersistentDiskReturnsOnCall == nil {
 		fake.unmountPersistentDiskReturnsOnCall = make(map[int]struct {
-			result1 bool
+			result1 platform.UnmountReport
 			result2 error
 		})
 	}
 	fake.unmountPersistentDiskReturnsOnCall[i] = struct {
-		result1 bool
+		result1 platform.UnmountReport
 		result2 error
 	}{result1, result2}
 }
@@ -4362,6 +4799,8 @@ func (fake *FakePlatform) Invocations() map[string][][]interface{} {
 	defer fake.deleteARPEntryWithIPMutex.RUnlock()
 	fake.deleteEphemeralUsersMatchingMutex.RLock()
 	defer fake.deleteEphemeralUsersMatchingMutex.RUnlock()
+	fake.freezeFilesystemMutex.RLock()
+	defer fake.freezeFilesystemMutex.RUnlock()
 	fake.getAgentSettingsPathMutex.RLock()
 	defer fake.getAgentSettingsPathMutex.RUnlock()
 	fake.getAuditLoggerMutex.RLock()
@@ -4404,6 +4843,8 @@ func (fake *FakePlatform) Invocations() map[string][][]interface{} {
 	defer fake.getUpdateSettingsPathMutex.RUnlock()
 	fake.getVitalsServiceMutex.RLock()
 	defer fake.getVitalsServiceMutex.RUnlock()
+	fake.growEphemeralDiskMutex.RLock()
+	defer fake.growEphemeralDiskMutex.RUnlock()
 	fake.isMountPointMutex.RLock()
 	defer fake.isMountPointMutex.RUnlock()
 	fake.isPersistentDiskMountableMutex.RLock()
@@ -4420,14 +4861,20 @@ func (fake *FakePlatform) Invocations() map[string][][]interface{} {
 	defer fake.removeDevToolsMutex.RUnlock()
 	fake.removeStaticLibrariesMutex.RLock()
 	defer fake.removeStaticLibrariesMutex.RUnlock()
+	fake.rotateLogsMutex.RLock()
+	defer fake.rotateLogsMutex.RUnlock()
 	fake.saveDNSRecordsMutex.RLock()
 	defer fake.saveDNSRecordsMutex.RUnlock()
+	fake.setAccountPolicyMutex.RLock()
+	defer fake.setAccountPolicyMutex.RUnlock()
 	fake.setTimeWithNtpServersMutex.RLock()
 	defer fake.setTimeWithNtpServersMutex.RUnlock()
 	fake.setUserPasswordMutex.RLock()
 	defer fake.setUserPasswordMutex.RUnlock()
 	fake.setupBlobsDirMutex.RLock()
 	defer fake.setupBlobsDirMutex.RUnlock()
+	fake.setupCoreDumpsDirMutex.RLock()
+	defer fake.setupCoreDumpsDirMutex.RUnlock()
 	fake.setupBoshSettingsDiskMutex.RLock()
 	defer fake.setupBoshSettingsDiskMutex.RUnlock()
 	fake.setupCanRestartDirMutex.RLock()
@@ -4472,6 +4919,8 @@ func (fake *FakePlatform) Invocations() map[string][][]interface{} {
 	defer fake.shutdownMutex.RUnlock()
 	fake.startMonitMutex.RLock()
 	defer fake.startMonitMutex.RUnlock()
+	fake.thawFilesystemMutex.RLock()
+	defer fake.thawFilesystemMutex.RUnlock()
 	fake.unmountPersistentDiskMutex.RLock()
 	defer fake.unmountPersistentDiskMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
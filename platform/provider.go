@@ -70,7 +70,7 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	linuxCdrom := boshcdrom.NewLinuxCdrom("/dev/sr0", udev, runner)
 	linuxCdutil := boshcdrom.NewCdUtil(dirProvider.SettingsDir(), fs, linuxCdrom, logger)
 
-	compressor := boshcmd.NewTarballCompressor(runner, fs)
+	compressor := NewPreservingTarballCompressor(runner, fs)
 	copier := boshcmd.NewGenericCpCopier(fs, logger)
 
 	// Kick of stats collection as soon as possible
@@ -128,30 +128,20 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	monitRetryable := NewMonitRetryable(serviceManager)
 	monitRetryStrategy := boshretry.NewAttemptRetryStrategy(10, 1*time.Second, monitRetryable, logger)
 
-	var devicePathResolver devicepathresolver.DevicePathResolver
-	switch options.Linux.DevicePathResolutionType {
-	case "virtio":
-		udev := boshudev.NewConcreteUdevDevice(runner, logger)
-		idDevicePathResolver := devicepathresolver.NewIDDevicePathResolver(500*time.Millisecond, udev, fs, options.Linux.DiskIDTransformPattern, options.Linux.DiskIDTransformReplacement, logger)
-		mappedDevicePathResolver := devicepathresolver.NewMappedDevicePathResolver(30000*time.Millisecond, fs)
-		devicePathResolver = devicepathresolver.NewVirtioDevicePathResolver(idDevicePathResolver, mappedDevicePathResolver, logger)
-	case "scsi":
-		scsiIDPathResolver := devicepathresolver.NewSCSIIDDevicePathResolver(50000*time.Millisecond, fs, logger)
-		scsiVolumeIDPathResolver := devicepathresolver.NewSCSIVolumeIDDevicePathResolver(500*time.Millisecond, fs)
-		scsiLunPathResolver := devicepathresolver.NewSCSILunDevicePathResolver(50000*time.Millisecond, fs, logger)
-		devicePathResolver = devicepathresolver.NewScsiDevicePathResolver(scsiVolumeIDPathResolver, scsiIDPathResolver, scsiLunPathResolver)
-	case "iscsi":
-		identityPathResolver := devicepathresolver.NewIdentityDevicePathResolver()
-		iscsiAdm := boshiscsi.NewConcreteOpenIscsiAdmin(fs, runner, logger)
-		iscsiPathResolver := devicepathresolver.NewIscsiDevicePathResolver(50000*time.Millisecond, runner, iscsiAdm, fs, dirProvider, logger)
-		devicePathResolver = devicepathresolver.NewMultipathDevicePathResolver(identityPathResolver, iscsiPathResolver, logger)
+	devicePathResolver := devicepathresolver.NewDevicePathResolver(options.Linux.DevicePathResolutionType, devicepathresolver.DeviceResolverDeps{
+		FS:          fs,
+		Runner:      runner,
+		Udev:        boshudev.NewConcreteUdevDevice(runner, logger),
+		OpenIscsi:   boshiscsi.NewConcreteOpenIscsiAdmin(fs, runner, logger),
+		DirProvider: dirProvider,
+		Logger:      logger,
 
-	default:
-		devicePathResolver = devicepathresolver.NewIdentityDevicePathResolver()
-	}
+		DiskIDTransformPattern:     options.Linux.DiskIDTransformPattern,
+		DiskIDTransformReplacement: options.Linux.DiskIDTransformReplacement,
+	})
 
 	uuidGenerator := boshuuid.NewGenerator()
-	logsTarProvider := boshlogstarprovider.NewLogsTarProvider(compressor, copier, dirProvider)
+	logsTarProvider := boshlogstarprovider.NewLogsTarProvider(compressor, copier, dirProvider, fs)
 
 	var centos = func() Platform {
 		return NewLinuxPlatform(
@@ -0,0 +1,19 @@
+package fakes
+
+import (
+	"errors"
+
+	boshstats "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
+)
+
+type FakeProcessCollector struct {
+	ProcessStats map[int]boshstats.ProcessStats
+}
+
+func (c *FakeProcessCollector) GetProcessStats(pid int) (stats boshstats.ProcessStats, err error) {
+	stats, found := c.ProcessStats[pid]
+	if !found {
+		err = errors.New("Process not found") //nolint:staticcheck
+	}
+	return
+}
@@ -0,0 +1,132 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// Times in /proc are in clock ticks, which are USER_HZ on every architecture
+// the agent runs on
+const clockTicksPerSecond = 100
+
+type ProcessStats struct {
+	CPUSecs    float64
+	RSSKb      uint64
+	OpenFDs    int
+	Threads    int
+	UptimeSecs uint64
+}
+
+type ProcessCollector interface {
+	GetProcessStats(pid int) (stats ProcessStats, err error)
+}
+
+type procProcessCollector struct {
+	fs       boshsys.FileSystem
+	procDir  string
+	pageSize uint64
+}
+
+// NewProcProcessCollector reads the stats of processes from procDir, which
+// is /proc outside of tests
+func NewProcProcessCollector(fs boshsys.FileSystem, procDir string) ProcessCollector {
+	return procProcessCollector{
+		fs:       fs,
+		procDir:  procDir,
+		pageSize: uint64(os.Getpagesize()),
+	}
+}
+
+func (c procProcessCollector) GetProcessStats(pid int) (ProcessStats, error) {
+	processDir := filepath.Join(c.procDir, strconv.Itoa(pid))
+
+	stat, err := c.fs.ReadFileString(filepath.Join(processDir, "stat"))
+	if err != nil {
+		return ProcessStats{}, bosherr.WrapErrorf(err, "Reading stat of process %d", pid)
+	}
+
+	// The command name is in parentheses and may contain spaces, so fields
+	// are only split after it. fields[0] is the 3rd field, the state.
+	commandEnd := strings.LastIndex(stat, ")")
+	if commandEnd == -1 {
+		return ProcessStats{}, bosherr.Errorf("Parsing stat of process %d", pid)
+	}
+
+	fields := strings.Fields(stat[commandEnd+1:])
+	if len(fields) < 22 {
+		return ProcessStats{}, bosherr.Errorf("Parsing stat of process %d", pid)
+	}
+
+	userTicks, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return ProcessStats{}, bosherr.WrapErrorf(err, "Parsing user time of process %d", pid)
+	}
+
+	sysTicks, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return ProcessStats{}, bosherr.WrapErrorf(err, "Parsing system time of process %d", pid)
+	}
+
+	threads, err := strconv.Atoi(fields[17])
+	if err != nil {
+		return ProcessStats{}, bosherr.WrapErrorf(err, "Parsing threads of process %d", pid)
+	}
+
+	startTicks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return ProcessStats{}, bosherr.WrapErrorf(err, "Parsing start time of process %d", pid)
+	}
+
+	rssPages, err := strconv.ParseUint(fields[21], 10, 64)
+	if err != nil {
+		return ProcessStats{}, bosherr.WrapErrorf(err, "Parsing resident set size of process %d", pid)
+	}
+
+	fds, err := c.fs.Glob(filepath.Join(processDir, "fd", "*"))
+	if err != nil {
+		return ProcessStats{}, bosherr.WrapErrorf(err, "Listing open files of process %d", pid)
+	}
+
+	systemUptime, err := c.systemUptimeSecs()
+	if err != nil {
+		return ProcessStats{}, err
+	}
+
+	stats := ProcessStats{
+		CPUSecs: float64(userTicks+sysTicks) / clockTicksPerSecond,
+		RSSKb:   rssPages * c.pageSize / 1024,
+		OpenFDs: len(fds),
+		Threads: threads,
+	}
+
+	startSecs := startTicks / clockTicksPerSecond
+	if systemUptime > startSecs {
+		stats.UptimeSecs = systemUptime - startSecs
+	}
+
+	return stats, nil
+}
+
+func (c procProcessCollector) systemUptimeSecs() (uint64, error) {
+	uptime, err := c.fs.ReadFileString(filepath.Join(c.procDir, "uptime"))
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Reading system uptime")
+	}
+
+	fields := strings.Fields(uptime)
+	if len(fields) == 0 {
+		return 0, bosherr.Error("Parsing system uptime")
+	}
+
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Parsing system uptime")
+	}
+
+	return uint64(secs), nil
+}
@@ -0,0 +1,65 @@
+package stats_test
+
+import (
+	"errors"
+	"os"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/platform/stats"
+)
+
+var _ = Describe("procProcessCollector", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		collector ProcessCollector
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		collector = NewProcProcessCollector(fs, "/fake-proc")
+
+		// The command name of the process contains spaces and parentheses
+		err := fs.WriteFileString("/fake-proc/1234/stat", "1234 (web (worker) 1) S 1 2 3 4 5 6 7 8 9 10 250 50 13 14 15 16 7 18 100000 20 512 22 23 24\n")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = fs.WriteFileString("/fake-proc/uptime", "1060.52 2000.10\n")
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.SetGlob("/fake-proc/1234/fd/*", []string{"/fake-proc/1234/fd/0", "/fake-proc/1234/fd/1", "/fake-proc/1234/fd/2"})
+	})
+
+	It("gathers the resources used by the process", func() {
+		stats, err := collector.GetProcessStats(1234)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stats).To(Equal(ProcessStats{
+			CPUSecs:    3,
+			RSSKb:      512 * uint64(os.Getpagesize()) / 1024,
+			OpenFDs:    3,
+			Threads:    7,
+			UptimeSecs: 60,
+		}))
+	})
+
+	It("returns an error when the process does not exist", func() {
+		_, err := collector.GetProcessStats(4321)
+		Expect(err).To(MatchError(ContainSubstring("Reading stat of process 4321")))
+	})
+
+	It("returns an error when the stat of the process is malformed", func() {
+		err := fs.WriteFileString("/fake-proc/1234/stat", "1234 (web) S 1 2 3\n")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = collector.GetProcessStats(1234)
+		Expect(err).To(MatchError("Parsing stat of process 1234"))
+	})
+
+	It("returns an error when listing open files fails", func() {
+		fs.GlobErr = errors.New("fake-glob-err")
+
+		_, err := collector.GetProcessStats(1234)
+		Expect(err).To(MatchError("Listing open files of process 1234: fake-glob-err"))
+	})
+})
@@ -0,0 +1,88 @@
+package platform
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// preservingTarballCompressor shells out to GNU tar like the bosh-utils
+// tarball compressor but keeps holes of sparse files and extended
+// attributes, e.g. file capabilities set with setcap, so that releases
+// do not have to resort to setuid binaries.
+type preservingTarballCompressor struct {
+	boshcmd.Compressor
+
+	cmdRunner boshsys.CmdRunner
+	fs        boshsys.FileSystem
+}
+
+func NewPreservingTarballCompressor(cmdRunner boshsys.CmdRunner, fs boshsys.FileSystem) boshcmd.Compressor {
+	return preservingTarballCompressor{
+		Compressor: boshcmd.NewTarballCompressor(cmdRunner, fs),
+		cmdRunner:  cmdRunner,
+		fs:         fs,
+	}
+}
+
+func (c preservingTarballCompressor) CompressFilesInDir(dir string, options boshcmd.CompressorOptions) (string, error) {
+	return c.CompressSpecificFilesInDir(dir, []string{"."}, options)
+}
+
+func (c preservingTarballCompressor) CompressSpecificFilesInDir(dir string, files []string, options boshcmd.CompressorOptions) (string, error) {
+	tarball, err := c.fs.TempFile("bosh-platform-disk-TarballCompressor-CompressSpecificFilesInDir")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file for tarball")
+	}
+
+	defer tarball.Close()
+
+	tarballPath := tarball.Name()
+
+	args := []string{"--sparse", "--xattrs", "--xattrs-include=*", "-cf", tarballPath, "-C", dir}
+	if !options.NoCompression {
+		args = append(args, "-z")
+	}
+
+	args = append(args, files...)
+
+	_, _, _, err = c.cmdRunner.RunCommand("tar", args...)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Shelling out to tar")
+	}
+
+	return tarballPath, nil
+}
+
+// DecompressFileToDir restores sparse files without an option since GNU tar
+// recognizes them in the archive. Extended attributes other than user.* are
+// only restored when asked for explicitly.
+func (c preservingTarballCompressor) DecompressFileToDir(tarballPath string, dir string, options boshcmd.CompressorOptions) error {
+	sameOwnerOption := "--no-same-owner"
+	if options.SameOwner {
+		sameOwnerOption = "--same-owner"
+	}
+
+	resolvedTarballPath, err := c.fs.ReadAndFollowLink(tarballPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Resolving tarball path")
+	}
+
+	args := []string{sameOwnerOption, "--xattrs", "--xattrs-include=*", "-xf", resolvedTarballPath, "-C", dir}
+	if options.StripComponents != 0 {
+		args = append(args, fmt.Sprintf("--strip-components=%d", options.StripComponents))
+	}
+
+	if options.PathInArchive != "" {
+		args = append(args, options.PathInArchive)
+	}
+
+	_, _, _, err = c.cmdRunner.RunCommand("tar", args...)
+	if err != nil {
+		return bosherr.WrapError(err, "Shelling out to tar")
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package platform_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/cloudfoundry/bosh-agent/v2/platform"
+)
+
+var _ = Describe("preservingTarballCompressor", func() {
+	var (
+		cmdRunner  *fakesys.FakeCmdRunner
+		fs         *fakesys.FakeFileSystem
+		compressor boshcmd.Compressor
+	)
+
+	BeforeEach(func() {
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		fs = fakesys.NewFakeFileSystem()
+		compressor = NewPreservingTarballCompressor(cmdRunner, fs)
+	})
+
+	Describe("CompressFilesInDir", func() {
+		It("keeps sparse files and extended attributes", func() {
+			tarballPath, err := compressor.CompressFilesInDir("/fake-dir", boshcmd.CompressorOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"tar", "--sparse", "--xattrs", "--xattrs-include=*", "-cf", tarballPath, "-C", "/fake-dir", "-z", "."},
+			}))
+		})
+
+		It("does not compress when asked not to", func() {
+			tarballPath, err := compressor.CompressFilesInDir("/fake-dir", boshcmd.CompressorOptions{NoCompression: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"tar", "--sparse", "--xattrs", "--xattrs-include=*", "-cf", tarballPath, "-C", "/fake-dir", "."},
+			}))
+		})
+
+		It("returns an error when tar fails", func() {
+			cmdRunner.AddCmdResult("tar --sparse --xattrs --xattrs-include=* -cf /fake-tarball -C /fake-dir -z .", fakesys.FakeCmdResult{
+				Error: errors.New("fake-tar-error"),
+			})
+			fs.ReturnTempFile = fakesys.NewFakeFile("/fake-tarball", fs)
+
+			_, err := compressor.CompressFilesInDir("/fake-dir", boshcmd.CompressorOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-tar-error"))
+		})
+	})
+
+	Describe("DecompressFileToDir", func() {
+		BeforeEach(func() {
+			err := fs.WriteFileString("/fake-tarball", "fake-contents")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("restores all extended attributes", func() {
+			err := compressor.DecompressFileToDir("/fake-tarball", "/fake-dir", boshcmd.CompressorOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"tar", "--no-same-owner", "--xattrs", "--xattrs-include=*", "-xf", "/fake-tarball", "-C", "/fake-dir"},
+			}))
+		})
+
+		It("passes the options on to tar", func() {
+			err := compressor.DecompressFileToDir("/fake-tarball", "/fake-dir", boshcmd.CompressorOptions{
+				SameOwner:       true,
+				StripComponents: 1,
+				PathInArchive:   "fake-path",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"tar", "--same-owner", "--xattrs", "--xattrs-include=*", "-xf", "/fake-tarball", "-C", "/fake-dir", "--strip-components=1", "fake-path"},
+			}))
+		})
+	})
+})
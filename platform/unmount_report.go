@@ -0,0 +1,48 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnmountOptions decide what happens when processes keep a persistent disk
+// busy. Without them unmounting is retried until the processes let go.
+type UnmountOptions struct {
+	// Force kills the processes holding the disk before unmounting it
+	Force bool
+	// Lazy detaches the disk while it is still busy, it is only released
+	// once the remaining processes let go of it
+	Lazy bool
+}
+
+// UnmountReport describes how a persistent disk was unmounted.
+type UnmountReport struct {
+	DidUnmount bool
+	// Lazy is true when the disk was detached while still busy
+	Lazy bool
+	// KilledProcesses were killed because they held the disk
+	KilledProcesses []MountHolder
+	// BlockingProcesses still held the disk when it was unmounted lazily or
+	// when unmounting failed
+	BlockingProcesses []MountHolder
+}
+
+// MountHolder is a process with open files or its working directory on a
+// mounted filesystem.
+type MountHolder struct {
+	PID     int    `json:"pid"`
+	Command string `json:"command"`
+}
+
+func (h MountHolder) String() string {
+	return fmt.Sprintf("%d (%s)", h.PID, h.Command)
+}
+
+func describeMountHolders(holders []MountHolder) string {
+	descriptions := make([]string, len(holders))
+	for i, holder := range holders {
+		descriptions[i] = holder.String()
+	}
+
+	return strings.Join(descriptions, ", ")
+}
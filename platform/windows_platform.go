@@ -18,6 +18,7 @@ import (
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
 	boshlogstarprovider "github.com/cloudfoundry/bosh-agent/v2/agent/logstarprovider"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/v2/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/v2/platform/cert"
 	boshnet "github.com/cloudfoundry/bosh-agent/v2/platform/net"
@@ -354,6 +355,13 @@ func (p WindowsPlatform) SetUserPassword(user, encryptedPwd string) (err error)
 	return
 }
 
+func (p WindowsPlatform) SetAccountPolicy(policy boshsettings.AccountPolicy) error {
+	if len(policy.PasswordHashes) > 0 || policy.LockoutThreshold > 0 || policy.SSHPasswordAuthentication != nil {
+		return errors.New("Account policies are not supported on Windows")
+	}
+	return nil
+}
+
 func (p WindowsPlatform) SaveDNSRecords(dnsRecords boshsettings.DNSRecords, hostname string) error {
 	windir := os.Getenv("windir")
 	if windir == "" {
@@ -418,6 +426,10 @@ func (p WindowsPlatform) SetupLogrotate(groupName, basePath, size string) error
 	return nil
 }
 
+func (p WindowsPlatform) RotateLogs(groupName string, truncate bool) error {
+	return nil
+}
+
 func (p WindowsPlatform) SetTimeWithNtpServers(servers []string) error {
 	if len(servers) == 0 {
 		return nil
@@ -619,6 +631,10 @@ func (p WindowsPlatform) SetupBlobsDir() error {
 	return nil
 }
 
+func (p WindowsPlatform) SetupCoreDumpsDir() error {
+	return nil
+}
+
 func (p WindowsPlatform) SetupLoggingAndAuditing() error {
 	return nil
 }
@@ -631,7 +647,7 @@ func (p WindowsPlatform) MountPersistentDisk(diskSettings boshsettings.DiskSetti
 	return
 }
 
-func (p WindowsPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error) {
+func (p WindowsPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings, options UnmountOptions) (report UnmountReport, err error) {
 	return
 }
 
@@ -672,6 +688,18 @@ func (p WindowsPlatform) GetEphemeralDiskPath(diskSettings boshsettings.DiskSett
 	return diskPath, nil
 }
 
+func (p WindowsPlatform) FreezeFilesystem(mountPoint string) error {
+	return bosherr.Error("Freezing filesystems is not supported on Windows")
+}
+
+func (p WindowsPlatform) ThawFilesystem(mountPoint string) error {
+	return bosherr.Error("Freezing filesystems is not supported on Windows")
+}
+
+func (p WindowsPlatform) GrowEphemeralDisk(devicePath string) (bool, error) {
+	return false, bosherr.Error("Growing the ephemeral disk is not supported on Windows")
+}
+
 func (p WindowsPlatform) GetFileContentsFromCDROM(filePath string) (contents []byte, err error) {
 	return p.fs.ReadFile("D:/" + filePath)
 }
@@ -680,7 +708,7 @@ func (p WindowsPlatform) GetFilesContentsFromDisk(diskPath string, fileNames []s
 	return
 }
 
-func (p WindowsPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error) {
+func (p WindowsPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string, progress MigrationProgressFunc, cancel *boshtask.CancelSignal) (err error) {
 	return
 }
 
@@ -40,11 +40,13 @@ import (
 
 	boshmodels "github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	boshap "github.com/cloudfoundry/bosh-agent/v2/agent/applier/packages"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/signatures"
 	boshagentblobstore "github.com/cloudfoundry/bosh-agent/v2/agent/blobstore"
 	boshrunner "github.com/cloudfoundry/bosh-agent/v2/agent/cmdrunner"
 	boshcomp "github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/httpblobprovider/blobstore_delegator"
+	boshsettings "github.com/cloudfoundry/bosh-agent/v2/settings"
 	"github.com/cloudfoundry/bosh-agent/v2/settings/directories"
 )
 
@@ -65,12 +67,16 @@ func NewCompiler(dirProvider directories.Provider) (boshcomp.Compiler, error) {
 	if err != nil {
 		return nil, err
 	}
-	bd := blobstore_delegator.NewBlobstoreDelegator(httpblobprovider.NewHTTPBlobImpl(filesystem, http.DefaultClient), boshagentblobstore.NewCascadingBlobstore(db, nil, logger), logger)
+	bd := blobstore_delegator.NewBlobstoreDelegator(httpblobprovider.NewHTTPBlobImpl(filesystem, http.DefaultClient), boshagentblobstore.NewCascadingBlobstore(db, nil, logger), blobstore_delegator.DefaultRetryPolicy, logger)
 	ts := clock.NewClock()
-	packageApplierProvider := boshap.NewCompiledPackageApplierProvider(dirProvider.DataDir(), dirProvider.BaseDir(), dirProvider.JobsDir(), "packages", bd, compressor, filesystem, ts, logger)
 	const truncateLen = 10 * 1024 // 10kb
 	runner := boshrunner.NewFileLoggingCmdRunner(filesystem, cmdRunner, dirProvider.LogsDir(), truncateLen)
-	compiler := boshcomp.NewConcreteCompiler(compressor, bd, filesystem, runner, dirProvider, packageApplierProvider.Root(), packageApplierProvider.RootBundleCollection(), ts)
+	verifier, err := signatures.NewVerifier(boshsettings.BundleSigning{}, filesystem)
+	if err != nil {
+		return nil, err
+	}
+	packageApplierProvider := boshap.NewCompiledPackageApplierProvider(dirProvider.DataDir(), dirProvider.BaseDir(), dirProvider.JobsDir(), "packages", bd, verifier, compressor, filesystem, runner, ts, nil, 0, boshmodels.PermissionPolicy{}, nil, logger)
+	compiler := boshcomp.NewConcreteCompiler(compressor, bd, filesystem, runner, dirProvider, packageApplierProvider.Root(), packageApplierProvider.RootBundleCollection(), ts, nil)
 	return compiler, nil
 }
 
@@ -128,7 +134,7 @@ func compilePackage(compiledPackages []boshmodels.Package, p manifest.PackageRef
 		})
 		modelsDeps = append(modelsDeps, compiledPackages[index])
 	}
-	compiledBlobID, compiledDigest, err := compiler.Compile(pkg, modelsDeps)
+	compiledBlobID, compiledDigest, err := compiler.Compile(pkg, modelsDeps, nil)
 	if err != nil {
 		return nil, err
 	}
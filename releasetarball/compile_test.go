@@ -25,6 +25,7 @@ import (
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	"github.com/cloudfoundry/bosh-agent/v2/releasetarball"
 	"github.com/cloudfoundry/bosh-agent/v2/releasetarball/internal/fakes"
 	"github.com/cloudfoundry/bosh-agent/v2/settings/directories"
@@ -361,8 +362,8 @@ func listFileNamesInTarball(t GinkgoTInterface, filePath string) []tar.Header {
 	return infos
 }
 
-func fakeCompilation(d directories.Provider) func(c compiler.Package, packages []models.Package) (string, boshcrypto.Digest, error) {
-	return func(c compiler.Package, packages []models.Package) (string, boshcrypto.Digest, error) {
+func fakeCompilation(d directories.Provider) func(c compiler.Package, packages []models.Package, _ *boshtask.CancelSignal) (string, boshcrypto.Digest, error) {
+	return func(c compiler.Package, packages []models.Package, _ *boshtask.CancelSignal) (string, boshcrypto.Digest, error) {
 		blobContent, err := createTGZ(simpleFile("packaging", fmt.Appendf(nil, `"echo Compiled %q`, c.Name), 0o0744))
 		if err != nil {
 			log.Fatal(err)
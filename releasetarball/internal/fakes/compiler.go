@@ -6,15 +6,17 @@ import (
 
 	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/agent/compiler"
+	"github.com/cloudfoundry/bosh-agent/v2/agent/task"
 	"github.com/cloudfoundry/bosh-utils/crypto"
 )
 
 type Compiler struct {
-	CompileStub        func(compiler.Package, []models.Package) (string, crypto.Digest, error)
+	CompileStub        func(compiler.Package, []models.Package, *task.CancelSignal) (string, crypto.Digest, error)
 	compileMutex       sync.RWMutex
 	compileArgsForCall []struct {
 		arg1 compiler.Package
 		arg2 []models.Package
+		arg3 *task.CancelSignal
 	}
 	compileReturns struct {
 		result1 string
@@ -30,7 +32,7 @@ type Compiler struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *Compiler) Compile(arg1 compiler.Package, arg2 []models.Package) (string, crypto.Digest, error) {
+func (fake *Compiler) Compile(arg1 compiler.Package, arg2 []models.Package, arg3 *task.CancelSignal) (string, crypto.Digest, error) {
 	var arg2Copy []models.Package
 	if arg2 != nil {
 		arg2Copy = make([]models.Package, len(arg2))
@@ -41,13 +43,14 @@ func (fake *Compiler) Compile(arg1 compiler.Package, arg2 []models.Package) (str
 	fake.compileArgsForCall = append(fake.compileArgsForCall, struct {
 		arg1 compiler.Package
 		arg2 []models.Package
-	}{arg1, arg2Copy})
+		arg3 *task.CancelSignal
+	}{arg1, arg2Copy, arg3})
 	stub := fake.CompileStub
 	fakeReturns := fake.compileReturns
-	fake.recordInvocation("Compile", []interface{}{arg1, arg2Copy})
+	fake.recordInvocation("Compile", []interface{}{arg1, arg2Copy, arg3})
 	fake.compileMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2, ret.result3
@@ -61,17 +64,17 @@ func (fake *Compiler) CompileCallCount() int {
 	return len(fake.compileArgsForCall)
 }
 
-func (fake *Compiler) CompileCalls(stub func(compiler.Package, []models.Package) (string, crypto.Digest, error)) {
+func (fake *Compiler) CompileCalls(stub func(compiler.Package, []models.Package, *task.CancelSignal) (string, crypto.Digest, error)) {
 	fake.compileMutex.Lock()
 	defer fake.compileMutex.Unlock()
 	fake.CompileStub = stub
 }
 
-func (fake *Compiler) CompileArgsForCall(i int) (compiler.Package, []models.Package) {
+func (fake *Compiler) CompileArgsForCall(i int) (compiler.Package, []models.Package, *task.CancelSignal) {
 	fake.compileMutex.RLock()
 	defer fake.compileMutex.RUnlock()
 	argsForCall := fake.compileArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *Compiler) CompileReturns(result1 string, result2 crypto.Digest, result3 error) {
@@ -106,8 +109,6 @@ func (fake *Compiler) CompileReturnsOnCall(i int, result1 string, result2 crypto
 func (fake *Compiler) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.compileMutex.RLock()
-	defer fake.compileMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
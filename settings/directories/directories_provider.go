@@ -68,6 +68,12 @@ func (p Provider) DataJobsDir() string {
 	return filepath.Join(p.DataDir(), "jobs")
 }
 
+// JobsRootsDir holds the directories the jobs directory links to when jobs
+// are switched over all at once
+func (p Provider) JobsRootsDir() string {
+	return filepath.Join(p.DataDir(), "jobs-roots")
+}
+
 func (p Provider) JobLogDir(jobName string) string {
 	return filepath.Join(p.DataDir(), "sys", "log", jobName)
 }
@@ -76,6 +82,13 @@ func (p Provider) JobRunDir(jobName string) string {
 	return filepath.Join(p.DataDir(), "sys", "run", jobName)
 }
 
+// JobTrustedCertsDir receives a bundle of the certificates the agent trusts
+// for the job. It is kept outside of the job bundle, which must not change
+// once it is installed.
+func (p Provider) JobTrustedCertsDir(jobName string) string {
+	return filepath.Join(p.DataDir(), "sys", "certs", jobName)
+}
+
 func (p Provider) JobDir(jobName string) string {
 	return filepath.Join(p.DataDir(), jobName)
 }
@@ -123,3 +136,22 @@ func (p Provider) BlobsDir() string {
 func (p Provider) SensitiveBlobsDir() string {
 	return filepath.Join(p.DataDir(), "sensitive_blobs")
 }
+
+func (p Provider) CrashReportsDir() string {
+	return filepath.Join(p.BoshDir(), "crash_reports")
+}
+
+// CoreDumpsDir is where the kernel writes core dumps of crashed processes
+func (p Provider) CoreDumpsDir() string {
+	return filepath.Join(p.DataDir(), "sys", "cores")
+}
+
+func (p Provider) BlobCacheDir() string {
+	return filepath.Join(p.DataDir(), "blob_cache")
+}
+
+// SeedsDir holds blobs baked into the stemcell or copied by the CPI, named
+// by their digest like the blob cache, e.g. sha256-DIGEST
+func (p Provider) SeedsDir() string {
+	return filepath.Join(p.BoshDir(), "seeds")
+}
@@ -29,9 +29,11 @@ var _ = Describe("Provider", func() {
 		Entry("MonitDir()", p.MonitDir(), "/some/dir/monit"),
 		Entry("JobsDir()", p.JobsDir(), "/some/dir/jobs"),
 		Entry("DataJobsDir()", p.DataJobsDir(), "/some/dir/data/jobs"),
+		Entry("JobsRootsDir()", p.JobsRootsDir(), "/some/dir/data/jobs-roots"),
 		Entry("JobBinDir(jobName)", p.JobBinDir("myJob"), "/some/dir/jobs/myJob/bin"),
 		Entry("JobLogDir(jobName)", p.JobLogDir("myJob"), "/some/dir/data/sys/log/myJob"),
 		Entry("JobRunDir(jobName)", p.JobRunDir("myJob"), "/some/dir/data/sys/run/myJob"),
+		Entry("JobTrustedCertsDir(jobName)", p.JobTrustedCertsDir("myJob"), "/some/dir/data/sys/certs/myJob"),
 		Entry("JobDir(jobName)", p.JobDir("myJob"), "/some/dir/data/myJob"),
 		Entry("SettingsDir()", p.SettingsDir(), "/some/dir/bosh/settings"),
 		Entry("TmpDir()", p.TmpDir(), "/some/dir/data/tmp"),
@@ -42,6 +44,10 @@ var _ = Describe("Provider", func() {
 		Entry("DisksDir()", p.DisksDir(), "/some/dir/instance/disks"),
 		Entry("BlobsDir()", p.BlobsDir(), "/some/dir/data/blobs"),
 		Entry("InstanceDNSDir()", p.InstanceDNSDir(), "/some/dir/instance/dns"),
+		Entry("CrashReportsDir()", p.CrashReportsDir(), "/some/dir/bosh/crash_reports"),
+		Entry("CoreDumpsDir()", p.CoreDumpsDir(), "/some/dir/data/sys/cores"),
+		Entry("BlobCacheDir()", p.BlobCacheDir(), "/some/dir/data/blob_cache"),
+		Entry("SeedsDir()", p.SeedsDir(), "/some/dir/bosh/seeds"),
 	)
 
 	It("cleans the base dir", func() {
@@ -10,6 +10,8 @@ type FakeSettingsService struct {
 
 	LoadSettingsError  error
 	SettingsWereLoaded bool
+	// LoadedSettings replace Settings when loading settings if given
+	LoadedSettings *boshsettings.Settings
 
 	GetPersistentDiskSettingsError    error
 	GetAllPersistentDiskSettingsError error
@@ -45,6 +47,9 @@ func (service *FakeSettingsService) PublicSSHKeyForUsername(_ string) (string, e
 
 func (service *FakeSettingsService) LoadSettings() error {
 	service.SettingsWereLoaded = true
+	if service.LoadedSettings != nil && service.LoadSettingsError == nil {
+		service.Settings = *service.LoadedSettings
+	}
 	return service.LoadSettingsError
 }
 
@@ -79,6 +84,7 @@ func (service *FakeSettingsService) SaveUpdateSettings(updateSettings boshsettin
 	if service.SaveUpdateSettingsErr != nil {
 		return service.SaveUpdateSettingsErr
 	}
+	service.Settings.UpdateSettings = updateSettings
 	return nil
 }
 
@@ -233,6 +233,13 @@ func (s *settingsService) SaveUpdateSettings(updateSettings UpdateSettings) erro
 	if err != nil {
 		return bosherr.WrapError(err, "Writing Update Settings json")
 	}
+
+	// Changes that do not need a restart, e.g. of mbus certificates, are
+	// picked up from the current settings
+	s.settingsMutex.Lock()
+	s.settings.UpdateSettings = updateSettings
+	s.settingsMutex.Unlock()
+
 	return nil
 }
 
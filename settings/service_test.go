@@ -741,5 +741,14 @@ var _ = Describe("settingsService", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fileContent).To(Equal(jsonString))
 		})
+
+		It("updates the current settings", func() {
+			updateSettings := UpdateSettings{TrustedCerts: "a trusted cert"}
+
+			err := service.SaveUpdateSettings(updateSettings)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(service.GetSettings().UpdateSettings).To(Equal(updateSettings))
+		})
 	})
 })
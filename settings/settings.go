@@ -5,6 +5,7 @@ import (
 	"net"
 	"strconv"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/disk"
 )
 
@@ -167,6 +168,13 @@ func (s Settings) GetMbusCerts() CertKeyPair {
 	return s.Env.Bosh.Mbus.Cert
 }
 
+func (s Settings) GetMbusEncryption() MbusEncryption {
+	if len(s.UpdateSettings.Mbus.Encryption.Keys) > 0 {
+		return s.UpdateSettings.Mbus.Encryption
+	}
+	return s.Env.Bosh.Mbus.Encryption
+}
+
 func (s Settings) GetBlobstore() Blobstore {
 	if len(s.UpdateSettings.Blobstores) > 0 {
 		return s.UpdateSettings.Blobstores[0]
@@ -270,6 +278,20 @@ func (e Env) GetSwapSizeInBytes() *uint64 {
 	return &result
 }
 
+func (e Env) GetBlobCacheSizeInBytes() uint64 {
+	return e.Bosh.Agent.Settings.BlobCacheSizeInMB * 1024 * 1024
+}
+
+const DefaultCoreDumpsMaxSizeInMB = 2048
+
+func (e Env) GetCoreDumpsMaxSizeInBytes() uint64 {
+	sizeInMB := uint64(DefaultCoreDumpsMaxSizeInMB)
+	if e.Bosh.Agent.Settings.CoreDumps.MaxSizeInMB != nil {
+		sizeInMB = *e.Bosh.Agent.Settings.CoreDumps.MaxSizeInMB
+	}
+	return sizeInMB * 1024 * 1024
+}
+
 func (e Env) GetParallel() *int {
 	result := 5
 	if e.Bosh.Parallel != nil {
@@ -278,6 +300,15 @@ func (e Env) GetParallel() *int {
 	return &result
 }
 
+// GetJobParallel returns how many jobs are installed at the same time. Jobs
+// are installed one at a time unless configured otherwise.
+func (e Env) GetJobParallel() int {
+	if e.Bosh.JobParallel != nil && *e.Bosh.JobParallel > 1 {
+		return *e.Bosh.JobParallel
+	}
+	return 1
+}
+
 type BoshEnv struct {
 	Agent                 AgentEnv    `json:"agent"`
 	Password              string      `json:"password"`
@@ -293,6 +324,7 @@ type BoshEnv struct {
 	Blobstores            []Blobstore `json:"blobstores"`
 	NTP                   []string    `json:"ntp"`
 	Parallel              *int        `json:"parallel"`
+	JobParallel           *int        `json:"job_parallel"`
 }
 
 type AgentEnv struct {
@@ -301,11 +333,252 @@ type AgentEnv struct {
 
 type AgentSettings struct {
 	TmpFS bool `json:"tmpfs"`
+
+	// Downloaded blobs are only cached when a size is given, the blob
+	// cache is disabled by default.
+	BlobCacheSizeInMB uint64 `json:"blob_cache_size"`
+
+	// Bind mount enabled job and package bundles read-only. The mounts do
+	// not survive a reboot and are set up again by the next apply.
+	ReadOnlyBundles bool `json:"read_only_bundles"`
+
+	BundleRetention BundleRetention `json:"bundle_retention"`
+
+	BundleQuota BundleQuota `json:"bundle_quota"`
+
+	// Ownership and modes given to job and package bundles when they are
+	// installed, the apply spec may override them. Jobs are owned by
+	// root:vcap with 0750 directories and 0640 files by default.
+	BundlePermissions models.PermissionPolicy `json:"bundle_permissions"`
+
+	BundleSigning BundleSigning `json:"bundle_signing"`
+
+	// Verify the installed job and package bundles against the digests
+	// recorded at installation when the agent starts and alert the health
+	// monitor about modified ones
+	VerifyBundles bool `json:"verify_bundles"`
+
+	// Link packages only into the packages directories of the jobs that
+	// declare them instead of enabling them system-wide
+	JobScopedPackages bool `json:"job_scoped_packages"`
+
+	// Enable jobs in a staging directory and switch the jobs directory over
+	// to all of them at once by replacing it with a link. Linux only.
+	AtomicJobSwitchover bool `json:"atomic_job_switchover"`
+
+	// Apply the packages of jobs without monit configuration, e.g. errands,
+	// only once they are run instead of when applying them
+	LazyPackages bool `json:"lazy_packages"`
+
+	// Let an apply wait for a previous apply that is still running instead
+	// of rejecting it as busy
+	QueueApplies bool `json:"queue_applies"`
+
+	MbusReconnect MbusReconnectPolicy `json:"mbus_reconnect"`
+
+	Blobstore AgentBlobstoreSettings `json:"blobstore"`
+
+	// Commands the exec_command action may run, it runs none without them
+	ExecCommands []ExecCommand `json:"exec_commands"`
+
+	CoreDumps CoreDumps `json:"core_dumps"`
+}
+
+// CoreDumps routes core dumps of crashed processes to the core dumps
+// directory by setting the kernel core pattern. The core pattern of the
+// stemcell is left alone unless enabled.
+type CoreDumps struct {
+	Enabled bool `json:"enabled"`
+
+	// The oldest core dumps are removed once all of them take up more space.
+	// Since 0 is not a useful limit use pointer to indicate that the default
+	// size should be used.
+	MaxSizeInMB *uint64 `json:"max_size"`
+}
+
+// MbusReconnectPolicy tunes how long the agent waits between attempts to
+// reconnect to NATS. Unset fields keep the defaults of two seconds doubling
+// up to ten seconds, shortened by up to a fifth at random.
+type MbusReconnectPolicy struct {
+	InitialBackoffSeconds float64 `json:"initial_backoff_seconds"`
+	Multiplier            float64 `json:"multiplier"`
+
+	// Should stay below the time the director waits for agent responses
+	MaxBackoffSeconds float64 `json:"max_backoff_seconds"`
+
+	// Fraction of the backoff between 0 and 1 it is randomly shortened by so
+	// that agents do not all reconnect at once. Since 0 disables the jitter
+	// use pointer to indicate that the default should be used.
+	Jitter *float64 `json:"jitter"`
+}
+
+// ExecCommand allows the exec_command action to run the executable at Path
+// under Name. Each argument has to fully match the regular expression at its
+// position in Args, so the command takes at most as many arguments.
+type ExecCommand struct {
+	Name string   `json:"name"`
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+}
+
+// BundleRetention keeps job and package bundles installed after they are
+// no longer used, e.g. for fast rollbacks. Unused bundles are kept when
+// either applies.
+type BundleRetention struct {
+	// Number of previous versions kept per job or package
+	KeepVersions int `json:"keep_versions"`
+
+	// Hours previous versions are kept after they were superseded
+	KeepHours int `json:"keep_hours"`
+
+	// Keep the versions the previously applied specs use, which the
+	// revert_apply action reverts to
+	KeepHistory bool `json:"keep_history"`
+}
+
+// BundleSigning holds the public keys job and package archives are verified
+// with against the signatures the apply spec gives for them, which protects
+// against a blobstore serving tampered archives.
+type BundleSigning struct {
+	// PEM encoded RSA, ECDSA or Ed25519 public keys by key ID
+	PublicKeys map[string]string `json:"public_keys"`
+
+	// Reject archives without signatures instead of only verifying the
+	// signed ones
+	Required bool `json:"required"`
+}
+
+// BundleQuota limits the disk space the installed job and package bundles
+// take up in the data directory. Zero means unlimited.
+type BundleQuota struct {
+	JobsMB     uint64 `json:"jobs_mb"`
+	PackagesMB uint64 `json:"packages_mb"`
+
+	// Uninstall all bundles the desired spec does not use, including the
+	// retained ones, when an apply would exceed a quota instead of failing
+	EmergencyGC bool `json:"emergency_gc"`
+}
+
+// AgentBlobstoreSettings tune how the agent itself transfers blobs.
+type AgentBlobstoreSettings struct {
+	// Zero means unlimited
+	DownloadBytesPerSecond int64 `json:"download_bytes_per_second"`
+	UploadBytesPerSecond   int64 `json:"upload_bytes_per_second"`
+
+	Proxy BlobstoreProxy `json:"proxy"`
+
+	// Client certificate and CA presented to signed URL endpoints
+	// behind mTLS terminating gateways
+	SignedURLTLS CertKeyPair `json:"signed_url_tls"`
+
+	// PEM encoded CA certificates trusted only for the blobstore endpoints
+	// with these host names
+	EndpointCAs map[string]string `json:"endpoint_cas"`
+
+	// Access s3 blobstores with env_or_profile credentials directly instead
+	// of through the s3cli
+	NativeS3 bool `json:"native_s3"`
+
+	// Access gcs blobstores without static credentials directly with the
+	// instance's service account instead of through the gcscli
+	NativeGCS bool `json:"native_gcs"`
+
+	// Access azure-storage blobstores without an account key directly with
+	// the VM's managed identity instead of through the azure-storage-cli
+	NativeAzure bool `json:"native_azure"`
+
+	// Retry policy for signed URL downloads and uploads
+	Retry BlobstoreRetryPolicy `json:"retry"`
+
+	// Transfers slower than this are logged as warnings, zero disables them
+	SlowTransferBytesPerSecond int64 `json:"slow_transfer_bytes_per_second"`
+
+	// Signed URL downloads are written here instead of the agent's temp
+	// directory, which may be a small tmpfs
+	StagingDir string `json:"staging_dir"`
+
+	ConnectionPool BlobstoreConnectionPool `json:"connection_pool"`
+
+	// Blobs transferred at the same time across all actions, zero is unlimited
+	MaxConcurrentTransfers int `json:"max_concurrent_transfers"`
+
+	// Weakest digest algorithm (sha1, sha256 or sha512) blobs may be
+	// verified with, empty accepts blobs without digests
+	MinimumDigestAlgorithm string `json:"minimum_digest_algorithm"`
+
+	Encryption BlobEncryption `json:"encryption"`
+
+	// Directories with pre-seeded blobs, e.g. copied by the CPI, that are
+	// used instead of downloading blobs with the same digest. The seeds
+	// directory of the agent is always used.
+	SeedDirs []string `json:"seed_dirs"`
+}
+
+// BlobEncryption holds the keys compiled packages are encrypted with before
+// they are uploaded. Retired keys stay listed to decrypt older blobs.
+type BlobEncryption struct {
+	// Key new compiled packages are encrypted with, empty only decrypts
+	ActiveKeyID string `json:"active_key_id"`
+
+	// Base64 encoded AES keys by key ID
+	Keys map[string]string `json:"keys"`
+}
+
+// BlobstoreConnectionPool tunes how blob transfers reuse connections. Unset
+// fields keep the defaults.
+type BlobstoreConnectionPool struct {
+	MaxIdleConns        int     `json:"max_idle_conns"`
+	MaxIdleConnsPerHost int     `json:"max_idle_conns_per_host"`
+	IdleTimeoutSeconds  float64 `json:"idle_timeout_seconds"`
+
+	// Stick to HTTP/1.1 for endpoints that mishandle HTTP/2
+	DisableHTTP2 bool `json:"disable_http2"`
+}
+
+// BlobstoreRetryPolicy tunes retries of failed blob transfers. Unset fields
+// keep the defaults of three attempts five seconds apart.
+type BlobstoreRetryPolicy struct {
+	Attempts              int     `json:"attempts"`
+	InitialBackoffSeconds float64 `json:"initial_backoff_seconds"`
+	Multiplier            float64 `json:"multiplier"`
+	MaxElapsedSeconds     float64 `json:"max_elapsed_seconds"`
+}
+
+const (
+	BlobstoreProxyAuthBasic = "basic"
+	BlobstoreProxyAuthNTLM  = "ntlm"
+)
+
+// BlobstoreProxy routes blob transfers through a proxy independent of the
+// agent process environment. An empty URL keeps the environment defaults.
+type BlobstoreProxy struct {
+	URL      string   `json:"url"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	AuthType string   `json:"auth_type"`
+	NoProxy  []string `json:"no_proxy"`
 }
 
 type MBus struct {
 	Cert CertKeyPair `json:"cert"`
 	URLs []string    `json:"urls"`
+
+	Encryption MbusEncryption `json:"encryption"`
+}
+
+// MbusEncryption holds the keys payloads exchanged with the director over
+// NATS are encrypted with on top of TLS. Retired keys stay listed to decrypt
+// messages sent before the director switched keys.
+type MbusEncryption struct {
+	// Key responses are encrypted with, empty only decrypts
+	ActiveKeyID string `json:"active_key_id"`
+
+	// Base64 encoded AES keys by key ID
+	Keys map[string]string `json:"keys"`
+
+	// Reject requests that are not encrypted instead of only decrypting the
+	// encrypted ones
+	Required bool `json:"required"`
 }
 
 type CertKeyPair struct {
@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/cloudfoundry/bosh-agent/v2/agent/applier/models"
 	. "github.com/cloudfoundry/bosh-agent/v2/matchers"
 	"github.com/cloudfoundry/bosh-agent/v2/platform/disk"
 	. "github.com/cloudfoundry/bosh-agent/v2/settings"
@@ -856,6 +857,7 @@ var _ = Describe("Settings", func() {
     ],
     "swap_size": 2048,
     "parallel": 10,
+    "job_parallel": 3,
 	"blobstores": [
 		{
 			"options": {
@@ -885,6 +887,7 @@ var _ = Describe("Settings", func() {
 			Expect(env.GetAuthorizedKeys()).To(ConsistOf("fake-key"))
 			Expect(*env.GetSwapSizeInBytes()).To(Equal(uint64(2048 * 1024 * 1024)))
 			Expect(*env.GetParallel()).To(Equal(10))
+			Expect(env.GetJobParallel()).To(Equal(3))
 			Expect(env.Bosh.Blobstores).To(Equal(
 				[](Blobstore){
 					Blobstore{
@@ -986,6 +989,268 @@ var _ = Describe("Settings", func() {
 			})
 		})
 
+		Context("#GetBlobCacheSizeInBytes", func() {
+			It("disables the blob cache when blob_cache_size is not specified", func() {
+				var env Env
+				err := json.Unmarshal([]byte(`{"bosh": {}}`), &env)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(env.GetBlobCacheSizeInBytes()).To(BeZero())
+			})
+
+			It("uses the specified size", func() {
+				var env Env
+				err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blob_cache_size": 512}}}}`), &env)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(env.GetBlobCacheSizeInBytes()).To(Equal(uint64(512 * 1024 * 1024)))
+			})
+
+			It("allows disabling the blob cache", func() {
+				var env Env
+				err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blob_cache_size": 0}}}}`), &env)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(env.GetBlobCacheSizeInBytes()).To(BeZero())
+			})
+		})
+
+		Context("#GetCoreDumpsMaxSizeInBytes", func() {
+			It("leaves the core pattern alone and uses the default size when core_dumps is not specified", func() {
+				var env Env
+				err := json.Unmarshal([]byte(`{"bosh": {}}`), &env)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(env.Bosh.Agent.Settings.CoreDumps.Enabled).To(BeFalse())
+				Expect(env.GetCoreDumpsMaxSizeInBytes()).To(Equal(uint64(2048 * 1024 * 1024)))
+			})
+
+			It("uses the specified size", func() {
+				var env Env
+				err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"core_dumps": {"enabled": true, "max_size": 512}}}}}`), &env)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(env.Bosh.Agent.Settings.CoreDumps.Enabled).To(BeTrue())
+				Expect(env.GetCoreDumpsMaxSizeInBytes()).To(Equal(uint64(512 * 1024 * 1024)))
+			})
+		})
+
+		It("can mount enabled bundles read-only", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"read_only_bundles": true}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.ReadOnlyBundles).To(BeTrue())
+		})
+
+		It("can retain previous versions of bundles", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"bundle_retention": {"keep_versions": 2, "keep_hours": 24, "keep_history": true}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.BundleRetention).To(Equal(BundleRetention{KeepVersions: 2, KeepHours: 24, KeepHistory: true}))
+		})
+
+		It("can limit the disk space of bundles", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"bundle_quota": {"jobs_mb": 512, "packages_mb": 4096, "emergency_gc": true}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.BundleQuota).To(Equal(BundleQuota{JobsMB: 512, PackagesMB: 4096, EmergencyGC: true}))
+		})
+
+		It("can set the permissions of bundles", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"bundle_permissions": {"packages": {"*": {"owner": "vcap:vcap", "dir_mode": "0755"}}}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.BundlePermissions.Package("some-package")).To(Equal(models.Permissions{Owner: "vcap:vcap", DirMode: 0755}))
+		})
+
+		It("can set the keys bundles are signed with", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"bundle_signing": {"public_keys": {"some-key": "some-pem"}, "required": true}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.BundleSigning).To(Equal(BundleSigning{
+				PublicKeys: map[string]string{"some-key": "some-pem"},
+				Required:   true,
+			}))
+		})
+
+		It("can verify bundles on start", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"verify_bundles": true}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.VerifyBundles).To(BeTrue())
+		})
+
+		It("can scope packages to jobs", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"job_scoped_packages": true}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.JobScopedPackages).To(BeTrue())
+		})
+
+		It("can switch over jobs atomically", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"atomic_job_switchover": true}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.AtomicJobSwitchover).To(BeTrue())
+		})
+
+		It("can defer packages of unsupervised jobs", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"lazy_packages": true}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.LazyPackages).To(BeTrue())
+		})
+
+		It("can queue applies", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"queue_applies": true}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.QueueApplies).To(BeTrue())
+		})
+
+		It("can tune reconnecting to the mbus", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"mbus_reconnect": {"initial_backoff_seconds": 0.5, "multiplier": 3, "max_backoff_seconds": 8, "jitter": 0}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+
+			jitter := 0.0
+			Expect(env.Bosh.Agent.Settings.MbusReconnect).To(Equal(MbusReconnectPolicy{
+				InitialBackoffSeconds: 0.5,
+				Multiplier:            3,
+				MaxBackoffSeconds:     8,
+				Jitter:                &jitter,
+			}))
+		})
+
+		It("can allow commands to execute", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"exec_commands": [{"name": "df", "path": "/bin/df", "args": ["-h", "/var/vcap/.*"]}]}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.ExecCommands).To(Equal([]ExecCommand{
+				{Name: "df", Path: "/bin/df", Args: []string{"-h", "/var/vcap/.*"}},
+			}))
+		})
+
+		It("can set blobstore transfer rate limits", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"download_bytes_per_second": 1024, "upload_bytes_per_second": 512}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore).To(Equal(AgentBlobstoreSettings{
+				DownloadBytesPerSecond: 1024,
+				UploadBytesPerSecond:   512,
+			}))
+		})
+
+		It("can set a blobstore proxy", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"proxy": {"url": "http://proxy:3128", "username": "user", "password": "secret", "auth_type": "ntlm", "no_proxy": ["10.0.0.0/8"]}}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.Proxy).To(Equal(BlobstoreProxy{
+				URL:      "http://proxy:3128",
+				Username: "user",
+				Password: "secret",
+				AuthType: BlobstoreProxyAuthNTLM,
+				NoProxy:  []string{"10.0.0.0/8"},
+			}))
+		})
+
+		It("can enable native s3 blobstore access", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"native_s3": true}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.NativeS3).To(BeTrue())
+		})
+
+		It("can enable native gcs blobstore access", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"native_gcs": true}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.NativeGCS).To(BeTrue())
+		})
+
+		It("can enable native azure blobstore access", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"native_azure": true}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.NativeAzure).To(BeTrue())
+		})
+
+		It("can set a blobstore retry policy", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"retry": {"attempts": 5, "initial_backoff_seconds": 0.5, "multiplier": 2, "max_elapsed_seconds": 120}}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.Retry).To(Equal(BlobstoreRetryPolicy{
+				Attempts:              5,
+				InitialBackoffSeconds: 0.5,
+				Multiplier:            2,
+				MaxElapsedSeconds:     120,
+			}))
+		})
+
+		It("can set a slow blob transfer threshold", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"slow_transfer_bytes_per_second": 1048576}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.SlowTransferBytesPerSecond).To(Equal(int64(1048576)))
+		})
+
+		It("can set a blob staging directory", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"staging_dir": "/var/vcap/data/blobs-staging"}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.StagingDir).To(Equal("/var/vcap/data/blobs-staging"))
+		})
+
+		It("can set CAs for specific blobstore endpoints", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"endpoint_cas": {"s3.internal": "fake-ca"}}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.EndpointCAs).To(Equal(map[string]string{"s3.internal": "fake-ca"}))
+		})
+
+		It("can set a minimum digest algorithm for blobs", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"minimum_digest_algorithm": "sha256"}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.MinimumDigestAlgorithm).To(Equal("sha256"))
+		})
+
+		It("can set blob encryption keys", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"encryption": {"active_key_id": "key-2", "keys": {"key-1": "a2V5LTE=", "key-2": "a2V5LTI="}}}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.Encryption).To(Equal(BlobEncryption{
+				ActiveKeyID: "key-2",
+				Keys:        map[string]string{"key-1": "a2V5LTE=", "key-2": "a2V5LTI="},
+			}))
+		})
+
+		It("can limit concurrent blob transfers", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"max_concurrent_transfers": 4}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.MaxConcurrentTransfers).To(Equal(4))
+		})
+
+		It("can use blobs pre-seeded into directories", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"seed_dirs": ["/mnt/seeds"]}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.SeedDirs).To(Equal([]string{"/mnt/seeds"}))
+		})
+
+		It("can tune the blobstore connection pool", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"agent": {"settings": {"blobstore": {"connection_pool": {"max_idle_conns": 50, "max_idle_conns_per_host": 8, "idle_timeout_seconds": 30, "disable_http2": true}}}}}}`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Agent.Settings.Blobstore.ConnectionPool).To(Equal(BlobstoreConnectionPool{
+				MaxIdleConns:        50,
+				MaxIdleConnsPerHost: 8,
+				IdleTimeoutSeconds:  30,
+				DisableHTTP2:        true,
+			}))
+		})
+
 		Context("when parallel is not specified in the json", func() {
 			It("sets to the default value", func() {
 				var env Env
@@ -998,6 +1263,16 @@ var _ = Describe("Settings", func() {
 			})
 		})
 
+		Context("when job_parallel is not specified in the json", func() {
+			It("installs one job at a time", func() {
+				var env Env
+				err := json.Unmarshal([]byte(`{"bosh": {"parallel": 10}}`), &env)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(env.GetJobParallel()).To(Equal(1))
+			})
+		})
+
 		Context("#GetBlobstore", func() {
 			blobstoreLocal := Blobstore{
 				Type: "local",
@@ -1272,6 +1547,35 @@ var _ = Describe("Settings", func() {
 		})
 	})
 
+	Describe("#GetMbusEncryption", func() {
+		It("returns the keys of the update settings when there are any", func() {
+			settings = Settings{
+				Env: Env{
+					Bosh: BoshEnv{
+						Mbus: MBus{Encryption: MbusEncryption{ActiveKeyID: "ignored", Keys: map[string]string{"ignored": "a2V5"}}},
+					},
+				},
+				UpdateSettings: UpdateSettings{
+					Mbus: MBus{Encryption: MbusEncryption{ActiveKeyID: "rotated", Keys: map[string]string{"rotated": "a2V5"}}},
+				},
+			}
+
+			Expect(settings.GetMbusEncryption()).To(Equal(settings.UpdateSettings.Mbus.Encryption))
+		})
+
+		It("returns the keys of the env otherwise", func() {
+			settings = Settings{
+				Env: Env{
+					Bosh: BoshEnv{
+						Mbus: MBus{Encryption: MbusEncryption{ActiveKeyID: "initial", Keys: map[string]string{"initial": "a2V5"}, Required: true}},
+					},
+				},
+			}
+
+			Expect(settings.GetMbusEncryption()).To(Equal(settings.Env.Bosh.Mbus.Encryption))
+		})
+	})
+
 	Describe("HasInterfaceAlias", func() {
 		Context("when networks is empty", func() {
 			It("returns found=false", func() {
@@ -9,6 +9,21 @@ type UpdateSettings struct {
 	DiskAssociations DiskAssociations `json:"disk_associations"`
 	Mbus             MBus             `json:"mbus"`
 	TrustedCerts     string           `json:"trusted_certs"`
+	AccountPolicy    *AccountPolicy   `json:"account_policy,omitempty"`
+}
+
+// AccountPolicy hardens the vcap and root accounts. Zero values leave the
+// corresponding part of the system untouched.
+type AccountPolicy struct {
+	// Crypted password hashes keyed by username
+	PasswordHashes map[string]string `json:"password_hashes,omitempty"`
+
+	// Failed logins before pam_faillock locks an account
+	LockoutThreshold int `json:"lockout_threshold,omitempty"`
+	// Seconds until a locked account is unlocked, zero requires an admin
+	LockoutUnlockTime int `json:"lockout_unlock_time,omitempty"`
+
+	SSHPasswordAuthentication *bool `json:"ssh_password_authentication,omitempty"`
 }
 
 func (updateSettings *UpdateSettings) MergeSettings(newSettings UpdateSettings) bool {
@@ -17,9 +32,17 @@ func (updateSettings *UpdateSettings) MergeSettings(newSettings UpdateSettings)
 	updateSettings.TrustedCerts = newSettings.TrustedCerts
 	updateSettings.DiskAssociations = newSettings.DiskAssociations
 
+	if newSettings.AccountPolicy != nil {
+		updateSettings.AccountPolicy = newSettings.AccountPolicy
+	}
+
+	// The mbus handler reloads changed certificates and encryption keys,
+	// only connecting to other URLs needs a restart
 	if !reflect.DeepEqual(newSettings.Mbus, updateSettings.Mbus) && !reflect.DeepEqual(newSettings.Mbus, MBus{}) {
+		if !reflect.DeepEqual(newSettings.Mbus.URLs, updateSettings.Mbus.URLs) {
+			mbusOrBlobstoreSettingsChanged = true
+		}
 		updateSettings.Mbus = newSettings.Mbus
-		mbusOrBlobstoreSettingsChanged = true
 	}
 
 	if !reflect.DeepEqual(newSettings.Blobstores, updateSettings.Blobstores) && newSettings.Blobstores != nil {
@@ -27,6 +27,21 @@ var _ = Describe("UpdateSettings", func() {
 			Expect(existingSettings.DiskAssociations[0].Name).To(Equal("new disk"))
 		})
 
+		It("updates the account policy without requiring a restart", func() {
+			restartNeeded := existingSettings.MergeSettings(UpdateSettings{
+				AccountPolicy: &AccountPolicy{LockoutThreshold: 5},
+			})
+			Expect(restartNeeded).To(BeFalse())
+			Expect(existingSettings.AccountPolicy).To(Equal(&AccountPolicy{LockoutThreshold: 5}))
+		})
+
+		It("keeps the existing account policy when none is given", func() {
+			existingSettings.AccountPolicy = &AccountPolicy{LockoutThreshold: 5}
+
+			existingSettings.MergeSettings(UpdateSettings{})
+			Expect(existingSettings.AccountPolicy).To(Equal(&AccountPolicy{LockoutThreshold: 5}))
+		})
+
 		Context("when the existing update settings json contains nats settings", func() {
 			BeforeEach(func() {
 				existingSettings = UpdateSettings{
@@ -44,7 +59,7 @@ var _ = Describe("UpdateSettings", func() {
 				Expect(existingSettings.Mbus.Cert.CA).To(Equal("existing CA"))
 			})
 
-			It("updates nats settings with new values", func() {
+			It("updates nats certificates with new values without requiring a restart", func() {
 				restartNeeded := existingSettings.MergeSettings(UpdateSettings{
 					Mbus: MBus{
 						Cert: CertKeyPair{
@@ -52,9 +67,38 @@ var _ = Describe("UpdateSettings", func() {
 						},
 					},
 				})
-				Expect(restartNeeded).To(BeTrue())
+				Expect(restartNeeded).To(BeFalse())
 				Expect(existingSettings.Mbus.Cert.CA).To(Equal("new CA"))
 			})
+
+			It("updates nats encryption keys without requiring a restart", func() {
+				restartNeeded := existingSettings.MergeSettings(UpdateSettings{
+					Mbus: MBus{
+						Cert: CertKeyPair{
+							CA: "existing CA",
+						},
+						Encryption: MbusEncryption{
+							ActiveKeyID: "new-key",
+							Keys:        map[string]string{"new-key": "a2V5"},
+						},
+					},
+				})
+				Expect(restartNeeded).To(BeFalse())
+				Expect(existingSettings.Mbus.Encryption.ActiveKeyID).To(Equal("new-key"))
+			})
+
+			It("updates nats urls with new values", func() {
+				restartNeeded := existingSettings.MergeSettings(UpdateSettings{
+					Mbus: MBus{
+						Cert: CertKeyPair{
+							CA: "existing CA",
+						},
+						URLs: []string{"nats://new-url:4222"},
+					},
+				})
+				Expect(restartNeeded).To(BeTrue())
+				Expect(existingSettings.Mbus.URLs).To(Equal([]string{"nats://new-url:4222"}))
+			})
 		})
 
 		Context("when the existing update settings json contains blobstore settings", func() {